@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	echoSwagger "github.com/swaggo/echo-swagger"
 	"go.uber.org/zap"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
 
 	_ "github.com/tutorflow/tutorflow-server/docs"
 	"github.com/tutorflow/tutorflow-server/internal/domain"
@@ -20,19 +22,26 @@ import (
 	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/database"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/jwt"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/metrics"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/tracing"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
 	"github.com/tutorflow/tutorflow-server/internal/repository/postgres"
 	"github.com/tutorflow/tutorflow-server/internal/service/email"
 	"github.com/tutorflow/tutorflow-server/internal/service/export"
+	"github.com/tutorflow/tutorflow-server/internal/service/oauth"
 	"github.com/tutorflow/tutorflow-server/internal/service/payment"
 	"github.com/tutorflow/tutorflow-server/internal/service/push"
+	"github.com/tutorflow/tutorflow-server/internal/service/realtime"
 	"github.com/tutorflow/tutorflow-server/internal/service/storage"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/admin"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/analytics"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/announcement"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/auth"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/bundle"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/cart"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/certificate"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/dataexport"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/discussion"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/enrollment"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/learningpath"
@@ -41,6 +50,7 @@ import (
 	"github.com/tutorflow/tutorflow-server/internal/usecase/order"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/peer_review"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/quiz"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/recommendation"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/refund"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/reports"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/review"
@@ -68,12 +78,27 @@ func New(cfg *config.Config, logger *zap.SugaredLogger) *App {
 
 // Run starts the application
 func (a *App) Run() error {
+	// Tracing is a no-op unless an OTLP endpoint is set in the environment
+	shutdownTracing, err := tracing.Setup(context.Background(), a.cfg.Server.Version)
+	if err != nil {
+		return err
+	}
+	if tracing.Enabled() {
+		a.logger.Info("Exporting traces over OTLP")
+	}
+
 	// Connect to database
 	db, err := database.Connect(a.cfg.Database)
 	if err != nil {
 		return err
 	}
 	a.logger.Info("Connected to database")
+	if tracing.Enabled() {
+		// Statements only; their arguments can hold personal data
+		if err := db.Use(gormtracing.NewPlugin(gormtracing.WithoutQueryVariables(), gormtracing.WithoutMetrics())); err != nil {
+			return err
+		}
+	}
 
 	// Run migrations
 	if err := database.AutoMigrate(db); err != nil {
@@ -83,15 +108,28 @@ func (a *App) Run() error {
 
 	// Middleware
 	a.echo.HideBanner = true
+	ipExtractor, err := appMiddleware.IPExtractor(a.cfg.Server.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	a.echo.IPExtractor = ipExtractor
 	a.echo.Use(middleware.RequestID())
+	if tracing.Enabled() {
+		a.echo.Use(appMiddleware.Tracing())
+	}
+	if a.cfg.Metrics.Enabled {
+		a.echo.Use(appMiddleware.Metrics())
+	}
 	a.echo.Use(middleware.Recover())
-	a.echo.Use(middleware.BodyLimit("500M"))
-	a.echo.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     a.cfg.Server.AllowedOrigins,
-		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-Session-ID"},
-		AllowCredentials: true,
-	}))
+	// Uploads get room for the largest file plus the form around it
+	storageCfg := a.cfg.Storage
+	uploadLimit := max(storageCfg.MaxImageSize, storageCfg.MaxVideoSize, storageCfg.MaxDocumentSize) + 1<<20
+	a.echo.Use(appMiddleware.BodyLimit(a.cfg.Server.BodyLimit, uploadLimit))
+	cors, err := appMiddleware.CORS(a.cfg.Server.AllowedOrigins, a.cfg.CORS)
+	if err != nil {
+		return err
+	}
+	a.echo.Use(cors)
 	a.echo.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
 		Format: `{"time":"${time_rfc3339}","id":"${id}","method":"${method}","uri":"${uri}","status":${status},"latency":"${latency_human}"}` + "\n",
 	}))
@@ -99,17 +137,6 @@ func (a *App) Run() error {
 	// Custom Error Handler
 	a.echo.HTTPErrorHandler = appMiddleware.ErrorHandler(a.logger)
 
-	// Serve static uploads
-	a.echo.Static("/uploads", a.cfg.Storage.LocalPath)
-
-	// Health check
-	a.echo.GET("/health", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, map[string]string{
-			"status":  "healthy",
-			"version": a.cfg.Server.Version,
-		})
-	})
-
 	// Webhook (no group, no auth) - to catch stripe listen forward
 	// We'll use the order handler's webhook as the main entry point
 	// after it's initialized
@@ -120,6 +147,13 @@ func (a *App) Run() error {
 	// Initialize repositories
 	userRepo := postgres.NewUserRepository(db)
 	refreshTokenRepo := postgres.NewRefreshTokenRepository(db)
+	loginAttemptRepo := postgres.NewLoginAttemptRepository(db)
+	emailVerificationRepo := postgres.NewEmailVerificationRepository(db)
+	passwordResetRepo := postgres.NewPasswordResetRepository(db)
+	accountDeletionRepo := postgres.NewAccountDeletionRepository(db)
+	linkedAccountRepo := postgres.NewLinkedAccountRepository(db)
+	auditLogRepo := postgres.NewAuditLogRepository(db)
+	dataExportRepo := postgres.NewDataExportRepository(db)
 	tutorProfileRepo := postgres.NewTutorProfileRepository(db)
 	courseRepo := postgres.NewCourseRepository(db)
 	categoryRepo := postgres.NewCategoryRepository(db)
@@ -128,10 +162,12 @@ func (a *App) Run() error {
 	enrollmentRepo := postgres.NewEnrollmentRepository(db)
 	progressRepo := postgres.NewLessonProgressRepository(db)
 	notificationRepo := postgres.NewNotificationRepository(db)
+	notificationPrefRepo := postgres.NewNotificationPreferenceRepository(db)
 	cartRepo := postgres.NewCartRepository(db)
 	wishlistRepo := postgres.NewWishlistRepository(db)
 	couponRepo := postgres.NewCouponRepository(db)
 	orderRepo := postgres.NewOrderRepository(db)
+	earningRepo := postgres.NewEarningRepository(db)
 	quizRepo := postgres.NewQuizRepository(db)
 	attemptRepo := postgres.NewQuizAttemptRepository(db)
 	assignmentRepo := postgres.NewAssignmentRepository(db)
@@ -151,6 +187,8 @@ func (a *App) Run() error {
 	refundRepo := postgres.NewRefundRepository(db)
 	bundleRepo := postgres.NewBundleRepository(db)
 	peerReviewRepo := postgres.NewPeerReviewRepository(db)
+	analyticsRepo := postgres.NewAnalyticsEventRepository(db)
+	recommendationRepo := postgres.NewRecommendationRepository(db)
 
 	// Initialize services
 	storageSvc := storage.NewService(a.cfg.Storage)
@@ -161,39 +199,64 @@ func (a *App) Run() error {
 		VAPIDPublicKey:  a.cfg.Push.VAPIDPublicKey,
 		VAPIDPrivateKey: a.cfg.Push.VAPIDPrivateKey,
 		VAPIDSubject:    a.cfg.Push.VAPIDSubject,
-	}, pushRepo)
+	}, pushRepo, notificationPrefRepo)
 	exportSvc := export.NewService(db)
+	realtimeHub := realtime.NewHub()
+
+	// Sign-in providers are enabled by configuring their client
+	oauthProviders := map[domain.AuthProvider]oauth.Provider{}
+	if a.cfg.OAuth.Google.ClientID != "" {
+		oauthProviders[domain.AuthProviderGoogle] = oauth.NewGoogle(a.cfg.OAuth.Google)
+	}
 
 	// Initialize use cases
-	authUC := auth.NewUseCase(userRepo, refreshTokenRepo, jwtManager)
-	userUC := user.NewUseCase(userRepo, tutorProfileRepo)
-	courseUC := course.NewUseCase(courseRepo, categoryRepo, moduleRepo, lessonRepo, enrollmentRepo)
-	enrollmentUC := enrollment.NewUseCase(enrollmentRepo, progressRepo, courseRepo, lessonRepo, notificationRepo)
+	authUC := auth.NewUseCase(userRepo, refreshTokenRepo, loginAttemptRepo, emailVerificationRepo, passwordResetRepo, linkedAccountRepo, jwtManager, emailSvc, oauthProviders, a.cfg.Auth)
+	subscriptionUC := subscription.NewUseCase(subscriptionRepo, userRepo, paymentSvc)
+	userUC := user.NewUseCase(userRepo, tutorProfileRepo, auditLogRepo, accountDeletionRepo, subscriptionUC, emailSvc)
+	courseUC := course.NewUseCase(courseRepo, categoryRepo, moduleRepo, lessonRepo, enrollmentRepo, userRepo, wishlistRepo, auditLogRepo, a.cfg.Course, a.logger)
+	notifier := notification.NewNotifier(notificationRepo, notificationPrefRepo, realtimeHub)
+	enrollmentUC := enrollment.NewUseCase(enrollmentRepo, progressRepo, courseRepo, lessonRepo, notifier, notificationPrefRepo, userRepo, certRepo, subscriptionRepo, emailSvc, a.cfg.Enrollment)
 	cartUC := cart.NewUseCase(cartRepo, wishlistRepo, courseRepo, enrollmentRepo)
-	orderUC := order.NewUseCase(orderRepo, cartRepo, couponRepo, enrollmentRepo, courseRepo, paymentSvc)
+	orderUC := order.NewUseCase(orderRepo, cartRepo, couponRepo, enrollmentRepo, courseRepo, bundleRepo, earningRepo, userRepo, auditLogRepo, paymentSvc, a.logger)
 	quizUC := quiz.NewUseCase(quizRepo, attemptRepo, assignmentRepo, submissionRepo, enrollmentRepo, progressRepo)
-	reviewUC := review.NewUseCase(reviewRepo, enrollmentRepo, courseRepo, notificationRepo)
-	notificationUC := notification.NewUseCase(notificationRepo, enrollmentRepo)
-	discussionUC := discussion.NewUseCase(discussionRepo, enrollmentRepo, courseRepo)
+	reviewUC := review.NewUseCase(reviewRepo, enrollmentRepo, courseRepo, notifier, a.cfg.Review)
+	notificationUC := notification.NewUseCase(notificationRepo, notificationPrefRepo, enrollmentRepo, emailSvc, realtimeHub)
+	discussionUC := discussion.NewUseCase(discussionRepo, enrollmentRepo, courseRepo, lessonRepo, userRepo, notifier, a.cfg.Discussion)
 	certificateUC := certificate.NewUseCase(certRepo, enrollmentRepo, courseRepo)
 	reportUC := reports.NewUseCase(scheduledReportRepo, rvRepo, courseRepo, exportSvc)
-	searchUC := search.NewUseCase(searchRepo, courseRepo, categoryRepo)
-	adminUC := admin.NewUseCase(db)
-	announcementUC := announcement.NewUseCase(announcementRepo, courseRepo, enrollmentRepo, notificationRepo)
-	messageUC := message.NewUseCase(messageRepo, userRepo)
+	searchUC := search.NewUseCase(searchRepo, courseRepo, categoryRepo, a.cfg.Search)
+	adminUC := admin.NewUseCase(db, auditLogRepo, a.cfg.Admin.StatsCacheTTL)
+	announcementUC := announcement.NewUseCase(announcementRepo, courseRepo, enrollmentRepo, notifier, notificationPrefRepo, emailSvc)
+	messageUC := message.NewUseCase(messageRepo, userRepo, courseRepo, enrollmentRepo, storageSvc, realtime.NewHub(), a.cfg.Messaging)
 	learningPathUC := learningpath.NewUseCase(learningPathRepo, enrollmentRepo, certRepo)
-	videoUC := video.NewUseCase(videoRepo, lessonRepo, enrollmentRepo, storageSvc, a.cfg.JWT.Secret)
-	subscriptionUC := subscription.NewUseCase(subscriptionRepo, userRepo)
-	refundUC := refund.NewUseCase(refundRepo, orderRepo, enrollmentRepo)
-	bundleUC := bundle.NewUseCase(bundleRepo, courseRepo, orderRepo, enrollmentRepo)
+	videoUC := video.NewUseCase(videoRepo, lessonRepo, enrollmentRepo, progressRepo, storageSvc, a.cfg.JWT.Secret, a.cfg.Video)
+	refundUC := refund.NewUseCase(refundRepo, orderRepo, enrollmentRepo, auditLogRepo)
+	bundleUC := bundle.NewUseCase(bundleRepo, courseRepo)
 	peerReviewUC := peer_review.NewUseCase(peerReviewRepo, lessonRepo)
+	analyticsUC := analytics.NewUseCase(analyticsRepo)
+	recommendationUC := recommendation.NewUseCase(recommendationRepo, courseRepo)
+	dataExportUC := dataexport.NewUseCase(dataExportRepo, userRepo, exportSvc, storageSvc, emailSvc, a.logger)
 
 	// Initialize handlers
-	authHandler := handler.NewAuthHandler(authUC)
+	authHandler := handler.NewAuthHandler(authUC, a.cfg.OAuth.CallbackURL)
 	userHandler := handler.NewUserHandler(userUC)
-	courseHandler := handler.NewCourseHandler(courseUC)
+	courseHandler := handler.NewCourseHandler(courseUC, reportUC, enrollmentUC, storageSvc)
 	enrollmentHandler := handler.NewEnrollmentHandler(enrollmentUC)
 	uploadHandler := handler.NewUploadHandler(storageSvc)
+	healthChecks := []handler.HealthCheck{{Name: "database", Check: func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}}}
+	if a.cfg.Server.CheckDependencies {
+		healthChecks = append(healthChecks,
+			handler.HealthCheck{Name: "storage", Check: storageSvc.Ping},
+			handler.HealthCheck{Name: "stripe", Check: paymentSvc.Ping},
+		)
+	}
+	healthHandler := handler.NewHealthHandler(a.cfg.Server.Version, a.logger, healthChecks...)
 	cartHandler := handler.NewCartHandler(cartUC)
 	orderHandler := handler.NewOrderHandler(orderUC, paymentSvc)
 	quizHandler := handler.NewQuizHandler(quizUC)
@@ -204,65 +267,123 @@ func (a *App) Run() error {
 	searchHandler := handler.NewSearchHandler(searchUC)
 	adminHandler := handler.NewAdminDashboardHandler(adminUC)
 	announcementHandler := handler.NewAnnouncementHandler(announcementUC)
-	messageHandler := handler.NewMessageHandler(messageUC)
+	messageHandler := handler.NewMessageHandler(messageUC, a.cfg.Server.AllowedOrigins)
 	pushHandler := handler.NewPushHandler(pushSvc)
 	learningPathHandler := handler.NewLearningPathHandler(learningPathUC)
 	reportHandler := handler.NewReportHandler(reportUC)
 	videoHandler := handler.NewVideoHandler(videoUC)
-	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionUC)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionUC, paymentSvc)
 	refundHandler := handler.NewRefundHandler(refundUC)
-	bundleHandler := handler.NewBundleHandler(bundleUC)
+	bundleHandler := handler.NewBundleHandler(bundleUC, orderUC)
 	peerReviewHandler := handler.NewPeerReviewHandler(peerReviewUC)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsUC)
+	recommendationHandler := handler.NewRecommendationHandler(recommendationUC)
+	dataExportHandler := handler.NewDataExportHandler(dataExportUC)
 
 	// Register root webhook
 	a.echo.POST("/webhook", orderHandler.HandleWebhook)
 
 	// Middleware functions
-	authMW := appMiddleware.AuthMiddleware(jwtManager)
+	rateLimitStore := appMiddleware.NewMemoryStore()
+	rateLimit := func(scope string, rule config.RateLimitRule) echo.MiddlewareFunc {
+		if !a.cfg.RateLimit.Enabled {
+			return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+		}
+		return appMiddleware.RateLimitMiddleware(appMiddleware.NewRateLimiterWithStore(rateLimitStore, appMiddleware.RateLimiterConfig{
+			Scope:             scope,
+			RequestsPerMinute: rule.RequestsPerMinute,
+			BurstSize:         rule.Burst,
+		}))
+	}
+	ipRateLimitMW := rateLimit("ip", a.cfg.RateLimit.IP)
+	userRateLimitMW := rateLimit("user", a.cfg.RateLimit.User)
+	authRateLimitMW := rateLimit("auth", a.cfg.RateLimit.Auth)
+	jwtAuthMW := appMiddleware.AuthMiddleware(jwtManager)
+	inactiveMW := appMiddleware.RejectInactive(authUC, 30*time.Second)
+	// Authenticated routes are also limited per user and closed to
+	// suspended and deleted accounts
+	authMW := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return jwtAuthMW(userRateLimitMW(inactiveMW(next)))
+	}
 	optionalAuthMW := appMiddleware.OptionalAuthMiddleware(jwtManager)
 	adminMW := appMiddleware.RequireAdmin()
 	managerMW := appMiddleware.RequireAdminOrManager()
 	tutorMW := appMiddleware.RequireRole(domain.RoleAdmin, domain.RoleManager, domain.RoleTutor)
+	eventRateLimitMW := appMiddleware.RateLimitMiddleware(appMiddleware.DefaultRateLimiter())
+	verifyRateLimitMW := appMiddleware.RateLimitMiddleware(appMiddleware.StrictRateLimiter())
+
+	// Health checks (no auth, no rate limit) for load balancers and orchestrators
+	healthHandler.RegisterRoutes(a.echo)
+
+	if a.cfg.Metrics.Enabled {
+		if sqlDB, err := db.DB(); err == nil {
+			metrics.RegisterDB(sqlDB)
+		}
+		a.echo.GET("/metrics", echo.WrapHandler(metrics.Handler()), appMiddleware.MetricsAuth(a.cfg.Metrics.Token))
+	}
 
 	// API v1 routes
-	api := a.echo.Group("/api/v1")
+	api := a.echo.Group("/api/v1", ipRateLimitMW)
 
 	// Register routes
-	authHandler.RegisterRoutes(api.Group("/auth"), authMW)
+	authHandler.RegisterRoutes(api.Group("/auth"), authMW, authRateLimitMW)
 	userHandler.RegisterRoutes(api.Group("/users"), authMW, adminMW, managerMW)
+	userHandler.RegisterAccountRoutes(api, authMW)
 	courseHandler.RegisterRoutes(api.Group("/courses"), authMW, optionalAuthMW, tutorMW, adminMW)
 	enrollmentHandler.RegisterRoutes(api.Group("/enrollments"), authMW, managerMW)
 	uploadHandler.RegisterRoutes(api.Group("/uploads"), authMW)
+	// Stored files without a public URL of their own, whichever backend holds them
+	a.echo.GET(storage.ServePrefix+"*", uploadHandler.ServeFile)
 	cartHandler.RegisterRoutes(api, authMW, optionalAuthMW)
 	orderHandler.RegisterRoutes(api.Group("/orders"), authMW, adminMW)
 	quizHandler.RegisterRoutes(api, authMW, tutorMW)
 	reviewHandler.RegisterRoutes(api, authMW, tutorMW)
 	notificationHandler.RegisterRoutes(api, authMW)
 	discussionHandler.RegisterRoutes(api, authMW)
-	certificateHandler.RegisterRoutes(api, authMW)
-	searchHandler.RegisterRoutes(api, optionalAuthMW)
+	certificateHandler.RegisterRoutes(api, authMW, verifyRateLimitMW)
+	searchHandler.RegisterRoutes(api, authMW, optionalAuthMW)
 	adminHandler.RegisterRoutes(api, authMW, adminMW)
+	adminHandler.RegisterInstructorRoutes(api, authMW, tutorMW)
 	api.POST("/progress/complete", enrollmentHandler.MarkLessonCompleteByLessonID, authMW)
+	api.POST("/courses/enroll-with-code", enrollmentHandler.EnrollWithCode, authMW)
+	api.PUT("/lessons/:id/position", enrollmentHandler.SaveLessonPosition, authMW)
+	api.GET("/lessons/:id/position", enrollmentHandler.GetLessonPosition, authMW)
+	api.GET("/lessons/:id/access", enrollmentHandler.CheckLessonAccess, authMW)
 	announcementHandler.RegisterRoutes(api, authMW, tutorMW)
-	messageHandler.RegisterRoutes(api, authMW)
+	messageHandler.RegisterRoutes(api, authMW, tutorMW)
 	pushHandler.RegisterRoutes(api, authMW)
 	learningPathHandler.RegisterRoutes(api, authMW, optionalAuthMW, adminMW)
 	reportHandler.RegisterRoutes(api, authMW, adminMW)
 	videoHandler.RegisterRoutes(api, authMW)
 	subscriptionHandler.RegisterRoutes(api, authMW)
 	refundHandler.RegisterRoutes(api, authMW)
-	bundleHandler.RegisterRoutes(api, authMW)
+	bundleHandler.RegisterRoutes(api, authMW, adminMW)
 	peerReviewHandler.RegisterRoutes(api, authMW)
+	analyticsHandler.RegisterRoutes(api, authMW, optionalAuthMW, adminMW, eventRateLimitMW)
+	recommendationHandler.RegisterRoutes(api, authMW, optionalAuthMW)
+	dataExportHandler.RegisterRoutes(api, authMW, adminMW)
 
 	// Swagger route
 	a.echo.GET("/swagger/*", echoSwagger.WrapHandler)
 
+	// Background workers stop when workerCtx is cancelled on shutdown, and
+	// shutdown waits on workers for them to finish what they were doing
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+
 	// Background worker for scheduled reports
-	go func() {
+	workers.Go(func() {
+		ctx := workerCtx
 		ticker := time.NewTicker(1 * time.Hour)
 		defer ticker.Stop()
-		for range ticker.C {
-			ctx := context.Background()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
 			reportsDue, err := scheduledReportRepo.GetDueReports(ctx)
 			if err != nil {
 				continue
@@ -278,8 +399,70 @@ func (a *App) Run() error {
 				next := export.GetScheduledReportNextRun(r.Schedule, now)
 				_ = scheduledReportRepo.UpdateLastRun(ctx, r.ID, now, next)
 			}
+
+			a.cleanupPlaybackData(ctx, videoRepo)
+
+			if n, err := courseUC.PurgeTrash(ctx); err != nil {
+				a.logger.Errorf("Failed to purge deleted courses: %v", err)
+			} else if n > 0 {
+				a.logger.Infof("Purged %d deleted courses", n)
+			}
+
+			if n, err := reviewUC.RecomputeRatings(ctx); err != nil {
+				a.logger.Errorf("Failed to recompute course ratings: %v", err)
+			} else if n > 0 {
+				a.logger.Infof("Corrected ratings on %d courses", n)
+			}
+
+			if err := refreshTokenRepo.DeleteExpired(ctx); err != nil {
+				a.logger.Errorf("Failed to delete expired refresh tokens: %v", err)
+			}
+
+			if err := authUC.PurgeLoginAttempts(ctx); err != nil {
+				a.logger.Errorf("Failed to purge login attempts: %v", err)
+			}
+
+			if n, err := notificationUC.SendDigests(ctx, time.Now()); err != nil {
+				a.logger.Errorf("Failed to send notification digests: %v", err)
+			} else if n > 0 {
+				a.logger.Infof("Sent %d notification digests", n)
+			}
+
+			if n, err := subscriptionUC.ExpireLapsed(ctx); err != nil {
+				a.logger.Errorf("Failed to expire lapsed subscriptions: %v", err)
+			} else if n > 0 {
+				a.logger.Infof("Expired %d lapsed subscriptions", n)
+			}
+
+			if n, err := dataExportUC.PurgeExpired(ctx); err != nil {
+				a.logger.Errorf("Failed to purge data exports: %v", err)
+			} else if n > 0 {
+				a.logger.Infof("Purged %d expired data exports", n)
+			}
 		}
-	}()
+	})
+
+	// Background worker for video transcoding jobs
+	videoWorker := video.NewWorker(videoRepo, videoUC, a.cfg.Video)
+	workers.Go(func() { videoWorker.Start(workerCtx) })
+
+	// Background worker for scheduled announcements
+	workers.Go(func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-workerCtx.Done():
+				return
+			case now := <-ticker.C:
+				if n, err := announcementUC.PublishDue(workerCtx, now); err != nil {
+					a.logger.Errorf("Failed to publish scheduled announcements: %v", err)
+				} else if n > 0 {
+					a.logger.Infof("Published %d scheduled announcements", n)
+				}
+			}
+		}
+	})
 
 	// Start server
 	go func() {
@@ -295,14 +478,53 @@ func (a *App) Run() error {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	a.logger.Info("Shutting down server...")
+	stopWorkers()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := a.echo.Shutdown(ctx); err != nil {
 		a.logger.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Interrupted video jobs are requeued as they stop; any still running at
+	// the deadline are picked up again once their heartbeat goes stale
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		a.logger.Warn("Background workers did not stop in time; unfinished video jobs will be retried")
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		a.logger.Warnf("Failed to flush traces: %v", err)
+	}
+
 	a.logger.Info("Server exited properly")
 	return nil
 }
+
+// cleanupPlaybackData prunes expired signed URLs and deactivates devices
+// that haven't been seen within the staleness window
+func (a *App) cleanupPlaybackData(ctx context.Context, videoRepo repository.VideoRepository) {
+	now := time.Now()
+
+	if a.cfg.Video.URLRetention > 0 {
+		if n, err := videoRepo.DeleteExpiredSignedURLs(ctx, now.Add(-a.cfg.Video.URLRetention)); err != nil {
+			a.logger.Errorf("Failed to delete expired signed URLs: %v", err)
+		} else if n > 0 {
+			a.logger.Infof("Deleted %d expired signed URLs", n)
+		}
+	}
+
+	if a.cfg.Video.DeviceStaleAfter > 0 {
+		if n, err := videoRepo.DeactivateStaleDeviceSessions(ctx, now.Add(-a.cfg.Video.DeviceStaleAfter)); err != nil {
+			a.logger.Errorf("Failed to deactivate stale devices: %v", err)
+		} else if n > 0 {
+			a.logger.Infof("Deactivated %d stale devices", n)
+		}
+	}
+}
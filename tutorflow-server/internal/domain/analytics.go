@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnalyticsEventType represents a client-side event type
+type AnalyticsEventType string
+
+const (
+	EventPageView       AnalyticsEventType = "page_view"
+	EventCourseView     AnalyticsEventType = "course_view"
+	EventLessonStart    AnalyticsEventType = "lesson_start"
+	EventLessonComplete AnalyticsEventType = "lesson_complete"
+	EventVideoPlay      AnalyticsEventType = "video_play"
+	EventVideoPause     AnalyticsEventType = "video_pause"
+	EventVideoSeek      AnalyticsEventType = "video_seek"
+	EventVideoComplete  AnalyticsEventType = "video_complete"
+	EventSearch         AnalyticsEventType = "search"
+	EventClick          AnalyticsEventType = "click"
+)
+
+// IsValid reports whether the event type is one of the known types
+func (t AnalyticsEventType) IsValid() bool {
+	switch t {
+	case EventPageView, EventCourseView, EventLessonStart, EventLessonComplete,
+		EventVideoPlay, EventVideoPause, EventVideoSeek, EventVideoComplete,
+		EventSearch, EventClick:
+		return true
+	}
+	return false
+}
+
+// AnalyticsEvent represents a client-side engagement event
+type AnalyticsEvent struct {
+	ID         uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     *uuid.UUID         `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	SessionID  *string            `gorm:"type:varchar(100);index" json:"session_id,omitempty"`
+	Type       AnalyticsEventType `gorm:"type:varchar(50);index;not null" json:"type"`
+	Payload    *string            `gorm:"type:jsonb" json:"payload,omitempty"`
+	OccurredAt time.Time          `gorm:"index;not null" json:"occurred_at"`
+	CreatedAt  time.Time          `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+}
@@ -99,6 +99,20 @@ type QuizAttempt struct {
 	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
+// QuizAnswer is one graded answer from a submitted attempt, kept per question
+// for analytics. Choice questions store one row per selected option.
+type QuizAnswer struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AttemptID  uuid.UUID  `gorm:"type:uuid;index;not null" json:"attempt_id"`
+	QuizID     uuid.UUID  `gorm:"type:uuid;index;not null" json:"quiz_id"`
+	QuestionID uuid.UUID  `gorm:"type:uuid;index;not null" json:"question_id"`
+	OptionID   *uuid.UUID `gorm:"type:uuid" json:"option_id,omitempty"`
+	IsCorrect  bool       `gorm:"not null;default:false" json:"is_correct"` // whether the whole question was answered correctly
+	CreatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	Attempt *QuizAttempt `gorm:"foreignKey:AttemptID" json:"-"`
+}
+
 // Assignment represents an assignment
 type Assignment struct {
 	ID                  uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditAction names an administrative operation
+type AuditAction string
+
+const (
+	AuditUserRoleChange   AuditAction = "user.role_change"
+	AuditUserStatusChange AuditAction = "user.status_change"
+	AuditUserDelete       AuditAction = "user.delete"
+	AuditCouponCreate     AuditAction = "coupon.create"
+	AuditRefundApprove    AuditAction = "refund.approve"
+	AuditRefundReject     AuditAction = "refund.reject"
+	AuditCourseTakedown   AuditAction = "course.takedown"
+)
+
+// AuditLog records who performed an administrative operation on what, with
+// the affected fields before and after
+type AuditLog struct {
+	ID         uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ActorID    uuid.UUID   `gorm:"type:uuid;index;not null" json:"actor_id"`
+	Action     AuditAction `gorm:"type:varchar(50);index;not null" json:"action"`
+	TargetType string      `gorm:"type:varchar(50);not null" json:"target_type"` // user, coupon, refund, course
+	TargetID   uuid.UUID   `gorm:"type:uuid;index;not null" json:"target_id"`
+	Before     *string     `gorm:"type:jsonb" json:"before,omitempty"`
+	After      *string     `gorm:"type:jsonb" json:"after,omitempty"`
+	CreatedAt  time.Time   `gorm:"index;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Relations
+	Actor *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+}
+
+// NewAuditLog builds an entry, storing before and after as JSON. Either may
+// be nil, e.g. there is nothing before a create.
+func NewAuditLog(actorID uuid.UUID, action AuditAction, targetType string, targetID uuid.UUID, before, after interface{}) *AuditLog {
+	return &AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     auditJSON(before),
+		After:      auditJSON(after),
+		CreatedAt:  time.Now(),
+	}
+}
+
+func auditJSON(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	s := string(data)
+	return &s
+}
@@ -34,9 +34,12 @@ type Bundle struct {
 	EndDate         *time.Time `gorm:"" json:"end_date,omitempty"`
 	MaxPurchases    *int       `gorm:"" json:"max_purchases,omitempty"`
 	PurchaseCount   int        `gorm:"default:0" json:"purchase_count"`
-	CreatedBy       uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
-	CreatedAt       time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt       time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	// DeductOwnedCourses lowers the price for buyers who already own some of
+	// the courses; otherwise they pay the full bundle price for the rest
+	DeductOwnedCourses bool      `gorm:"default:false" json:"deduct_owned_courses"`
+	CreatedBy          uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt          time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt          time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
 	Category     *Category      `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
 	LearningPath *LearningPath  `gorm:"foreignKey:LearningPathID" json:"learning_path,omitempty"`
@@ -101,7 +104,6 @@ type BundleUseCase interface {
 	DeleteBundle(ctx context.Context, id uuid.UUID) error
 	AddCourseToBundle(ctx context.Context, bundleID, courseID uuid.UUID) error
 	RemoveCourseFromBundle(ctx context.Context, bundleID, courseID uuid.UUID) error
-	PurchaseBundle(ctx context.Context, userID, bundleID uuid.UUID) (*BundlePurchase, error)
 	GetUserBundles(ctx context.Context, userID uuid.UUID) ([]BundlePurchase, error)
 	CalculateBundlePrice(ctx context.Context, courseIDs []uuid.UUID, discountPercent float64) (original, discounted float64, err error)
 }
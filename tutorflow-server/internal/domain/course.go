@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -43,31 +45,38 @@ type Category struct {
 
 // Course represents a course
 type Course struct {
-	ID               uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Title            string         `gorm:"type:varchar(255);not null" json:"title"`
-	Slug             string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
-	Description      *string        `gorm:"type:text" json:"description,omitempty"`
-	ShortDescription *string        `gorm:"type:varchar(500)" json:"short_description,omitempty"`
-	ThumbnailURL     *string        `gorm:"type:varchar(500)" json:"thumbnail_url,omitempty"`
-	PreviewVideoURL  *string        `gorm:"type:varchar(500)" json:"preview_video_url,omitempty"`
-	InstructorID     uuid.UUID      `gorm:"type:uuid;index;not null" json:"instructor_id"`
-	Status           CourseStatus   `gorm:"type:course_status;not null;default:'draft'" json:"status"`
-	Level            CourseLevel    `gorm:"type:course_level;not null;default:'beginner'" json:"level"`
-	Price            float64        `gorm:"type:decimal(10,2);default:0" json:"price"`
-	DiscountPrice    *float64       `gorm:"type:decimal(10,2)" json:"discount_price,omitempty"`
-	DurationHours    *int           `json:"duration_hours,omitempty"`
-	TotalLessons     int            `gorm:"default:0" json:"total_lessons"`
-	TotalStudents    int            `gorm:"default:0" json:"total_students"`
-	Rating           float64        `gorm:"type:decimal(3,2);default:0" json:"rating"`
-	TotalReviews     int            `gorm:"default:0" json:"total_reviews"`
-	IsFeatured       bool           `gorm:"default:false" json:"is_featured"`
-	Requirements     pq.StringArray `gorm:"type:text[]" json:"requirements,omitempty" swaggertype:"array,string"`
-	WhatYouLearn     pq.StringArray `gorm:"type:text[]" json:"what_you_learn,omitempty" swaggertype:"array,string"`
-	Language         string         `gorm:"type:varchar(50);default:'English'" json:"language"`
-	PublishedAt      *time.Time     `json:"published_at,omitempty"`
-	CreatedAt        time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt        time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                 uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title              string            `gorm:"type:varchar(255);not null" json:"title"`
+	Slug               string            `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
+	Description        *string           `gorm:"type:text" json:"description,omitempty"`
+	ShortDescription   *string           `gorm:"type:varchar(500)" json:"short_description,omitempty"`
+	ThumbnailURL       *string           `gorm:"type:varchar(500)" json:"thumbnail_url,omitempty"`
+	ThumbnailVariants  map[string]string `gorm:"type:jsonb;serializer:json" json:"thumbnail_variants,omitempty"` // resized copies of an uploaded thumbnail, variant name -> URL
+	PreviewVideoURL    *string           `gorm:"type:varchar(500)" json:"preview_video_url,omitempty"`
+	InstructorID       uuid.UUID         `gorm:"type:uuid;index;not null" json:"instructor_id"`
+	Status             CourseStatus      `gorm:"type:course_status;not null;default:'draft'" json:"status"`
+	Level              CourseLevel       `gorm:"type:course_level;not null;default:'beginner'" json:"level"`
+	Price              float64           `gorm:"type:decimal(10,2);default:0" json:"price"`
+	DiscountPrice      *float64          `gorm:"type:decimal(10,2)" json:"discount_price,omitempty"`
+	DurationHours      *int              `json:"duration_hours,omitempty"`
+	TotalLessons       int               `gorm:"default:0" json:"total_lessons"`
+	TotalStudents      int               `gorm:"default:0" json:"total_students"`
+	Rating             float64           `gorm:"type:decimal(3,2);default:0" json:"rating"`
+	TotalReviews       int               `gorm:"default:0" json:"total_reviews"`
+	IsFeatured         bool              `gorm:"default:false" json:"is_featured"`
+	CertificateEnabled bool              `gorm:"not null;default:true" json:"certificate_enabled"` // issue a certificate on completion
+	PublicDiscussions  bool              `gorm:"not null;default:false" json:"public_discussions"` // let any signed-in user read the course's discussions
+	SubscriptionTier   int               `gorm:"not null;default:0" json:"subscription_tier"`      // included in plans of this tier and up; 0 = purchase only
+	Requirements       pq.StringArray    `gorm:"type:text[]" json:"requirements,omitempty" swaggertype:"array,string"`
+	WhatYouLearn       pq.StringArray    `gorm:"type:text[]" json:"what_you_learn,omitempty" swaggertype:"array,string"`
+	Language           string            `gorm:"type:varchar(50);default:'English'" json:"language"`
+	EnrollmentCode     *string           `gorm:"type:varchar(32);uniqueIndex" json:"-"`
+	PublishedAt        *time.Time        `json:"published_at,omitempty"`
+	CreatedAt          time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt          time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt          gorm.DeletedAt    `gorm:"index" json:"-"`
+
+	WishlistCount *int64 `gorm:"-" json:"wishlist_count,omitempty"` // set on course detail only
 
 	// Relationships
 	Instructor  *User          `gorm:"foreignKey:InstructorID" json:"instructor,omitempty"`
@@ -86,12 +95,86 @@ func (c *Course) IsFree() bool {
 }
 
 func (c *Course) GetEffectivePrice() float64 {
-	if c.DiscountPrice != nil && *c.DiscountPrice < c.Price {
+	// A discount outside 0..Price predates validation and is ignored
+	if c.DiscountPrice != nil && *c.DiscountPrice >= 0 && *c.DiscountPrice < c.Price {
 		return *c.DiscountPrice
 	}
 	return c.Price
 }
 
+// ValidatePricing checks that any discount price lies between 0 and the
+// course price
+func (c *Course) ValidatePricing() error {
+	if c.DiscountPrice != nil && (*c.DiscountPrice < 0 || *c.DiscountPrice > c.Price) {
+		return ErrInvalidDiscountPrice
+	}
+	return nil
+}
+
+// PublishRequirement is one thing a course needs before it can be published
+type PublishRequirement string
+
+const (
+	PublishRequirementThumbnail       PublishRequirement = "thumbnail"
+	PublishRequirementDescription     PublishRequirement = "description"
+	PublishRequirementPublishedLesson PublishRequirement = "published_lesson"
+	PublishRequirementPrice           PublishRequirement = "price"
+)
+
+// PublishRequirements lists every requirement in the order a checklist shows them
+var PublishRequirements = []PublishRequirement{
+	PublishRequirementThumbnail,
+	PublishRequirementDescription,
+	PublishRequirementPublishedLesson,
+	PublishRequirementPrice,
+}
+
+// PublishChecklistItem reports whether a course meets one requirement
+type PublishChecklistItem struct {
+	Requirement PublishRequirement `json:"requirement"`
+	Met         bool               `json:"met"`
+}
+
+// PublishChecklist is a course's readiness to be published
+type PublishChecklist struct {
+	CourseID uuid.UUID              `json:"course_id"`
+	Ready    bool                   `json:"ready"`
+	Items    []PublishChecklistItem `json:"items"`
+	Missing  []PublishRequirement   `json:"missing"`
+}
+
+// CheckPublishReadiness builds the checklist for a course. hasPublishedLesson
+// is whether any of its modules holds a published lesson.
+func (c *Course) CheckPublishReadiness(hasPublishedLesson bool) *PublishChecklist {
+	met := map[PublishRequirement]bool{
+		PublishRequirementThumbnail:       c.ThumbnailURL != nil && strings.TrimSpace(*c.ThumbnailURL) != "",
+		PublishRequirementDescription:     c.Description != nil && strings.TrimSpace(*c.Description) != "",
+		PublishRequirementPublishedLesson: hasPublishedLesson,
+		PublishRequirementPrice:           c.Price >= 0 && (c.DiscountPrice == nil || *c.DiscountPrice >= 0),
+	}
+
+	checklist := &PublishChecklist{CourseID: c.ID, Missing: []PublishRequirement{}}
+	for _, req := range PublishRequirements {
+		checklist.Items = append(checklist.Items, PublishChecklistItem{Requirement: req, Met: met[req]})
+		if !met[req] {
+			checklist.Missing = append(checklist.Missing, req)
+		}
+	}
+	checklist.Ready = len(checklist.Missing) == 0
+	return checklist
+}
+
+// CourseNotReady is returned when publishing a course that fails its
+// checklist. It carries the missing requirements so the UI can list them.
+type CourseNotReady struct {
+	CourseID uuid.UUID            `json:"course_id"`
+	Missing  []PublishRequirement `json:"missing"`
+}
+
+func (e *CourseNotReady) Error() string {
+	return "course is not ready to publish"
+}
+
 // CourseCategory join table
 type CourseCategory struct {
 	CourseID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"course_id"`
@@ -102,6 +185,63 @@ func (CourseCategory) TableName() string {
 	return "course_categories"
 }
 
+// CourseInstructor is a co-teacher's share of a course's instructor revenue.
+// When a course has splits they include the owner and sum to 100 percent.
+type CourseInstructor struct {
+	CourseID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"course_id"`
+	InstructorID uuid.UUID `gorm:"type:uuid;primaryKey" json:"instructor_id"`
+	SharePercent float64   `gorm:"type:decimal(5,2);not null" json:"share_percent"`
+	CreatedAt    time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	Instructor *User `gorm:"foreignKey:InstructorID" json:"instructor,omitempty"`
+}
+
+// ValidateRevenueSplits checks that every share is positive, no instructor is
+// listed twice and the shares add up to 100 percent
+func ValidateRevenueSplits(splits []CourseInstructor) error {
+	if len(splits) == 0 {
+		return ErrInvalidRevenueSplit
+	}
+
+	seen := make(map[uuid.UUID]bool, len(splits))
+	var total float64
+	for _, s := range splits {
+		if s.SharePercent <= 0 || s.SharePercent > 100 || seen[s.InstructorID] {
+			return ErrInvalidRevenueSplit
+		}
+		seen[s.InstructorID] = true
+		total += s.SharePercent
+	}
+
+	if math.Abs(total-100) > 0.001 {
+		return ErrInvalidRevenueSplit
+	}
+	return nil
+}
+
+// SplitAmount divides an amount across splits by share, rounded to cents.
+// The rounding remainder goes to the first split so the parts add up exactly.
+func SplitAmount(amount float64, splits []CourseInstructor) []float64 {
+	parts := make([]float64, len(splits))
+	if len(splits) == 0 {
+		return parts
+	}
+
+	totalCents := int64(math.Round(amount * 100))
+	var allocated int64
+	cents := make([]int64, len(splits))
+	for i, s := range splits {
+		cents[i] = int64(math.Floor(float64(totalCents) * s.SharePercent / 100))
+		allocated += cents[i]
+	}
+	cents[0] += totalCents - allocated
+
+	for i, c := range cents {
+		parts[i] = float64(c) / 100
+	}
+	return parts
+}
+
 // Module represents a course module/section
 type Module struct {
 	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -2,12 +2,17 @@ package domain
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
+// PlatformFeePercent is the platform's cut of every course sale; the rest
+// is the instructors' share
+const PlatformFeePercent = 0.30
+
 // OrderStatus enum
 type OrderStatus string
 
@@ -62,7 +67,7 @@ func (c *Cart) Total() float64 {
 type CartItem struct {
 	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	CartID   uuid.UUID `gorm:"type:uuid;index;not null" json:"cart_id"`
-	CourseID uuid.UUID `gorm:"type:uuid;not null" json:"course_id"`
+	CourseID uuid.UUID `gorm:"type:uuid;index;not null" json:"course_id"`
 	AddedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"added_at"`
 
 	Cart   *Cart   `gorm:"foreignKey:CartID" json:"-"`
@@ -157,6 +162,7 @@ type Order struct {
 	PaidAt          *time.Time     `json:"paid_at,omitempty"`
 	RefundedAt      *time.Time     `json:"refunded_at,omitempty"`
 	RefundReason    *string        `gorm:"type:text" json:"refund_reason,omitempty"`
+	BundleID        *uuid.UUID     `gorm:"type:uuid;index" json:"bundle_id,omitempty"` // set when the order buys a bundle
 	CreatedAt       time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 
 	User   *User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -173,6 +179,39 @@ func GenerateOrderNumber() string {
 	return "ORD-" + time.Now().Format("20060102") + "-" + uuid.New().String()[:6]
 }
 
+// ProrateAmount divides an amount in proportion to weights, rounded to cents.
+// Zero weights share it evenly. The rounding remainder goes to the first part
+// so the parts add up exactly.
+func ProrateAmount(amount float64, weights []float64) []float64 {
+	parts := make([]float64, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+
+	var totalWeight float64
+	for _, w := range weights {
+		totalWeight += w
+	}
+
+	totalCents := int64(math.Round(amount * 100))
+	var allocated int64
+	cents := make([]int64, len(weights))
+	for i, w := range weights {
+		share := 1 / float64(len(weights))
+		if totalWeight > 0 {
+			share = w / totalWeight
+		}
+		cents[i] = int64(math.Floor(float64(totalCents) * share))
+		allocated += cents[i]
+	}
+	cents[0] += totalCents - allocated
+
+	for i, c := range cents {
+		parts[i] = float64(c) / 100
+	}
+	return parts
+}
+
 // OrderItem represents an item in an order
 type OrderItem struct {
 	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -189,8 +228,8 @@ type OrderItem struct {
 // InstructorEarning tracks earnings for instructors
 type InstructorEarning struct {
 	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	InstructorID uuid.UUID  `gorm:"type:uuid;index;not null" json:"instructor_id"`
-	OrderItemID  uuid.UUID  `gorm:"type:uuid;not null" json:"order_item_id"`
+	InstructorID uuid.UUID  `gorm:"type:uuid;index;uniqueIndex:idx_earning_item_instructor,priority:2;not null" json:"instructor_id"`
+	OrderItemID  uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_earning_item_instructor,priority:1;not null" json:"order_item_id"`
 	Amount       float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
 	PlatformFee  float64    `gorm:"type:decimal(10,2);not null" json:"platform_fee"`
 	Status       string     `gorm:"type:varchar(20);default:'pending'" json:"status"`
@@ -269,6 +308,7 @@ type OrderUseCase interface {
 	GetByCheckoutSession(ctx context.Context, sessionID string) (*Order, error)
 	CreateOrder(ctx context.Context, userID uuid.UUID, email string, input CreateOrderInput) (*CreateOrderOutput, error)
 	CreateCheckout(ctx context.Context, userID uuid.UUID, email string, input CreateOrderInput) (*CreateOrderOutput, error)
+	CreateBundleCheckout(ctx context.Context, userID uuid.UUID, email string, bundleID uuid.UUID) (*CreateOrderOutput, error)
 	ConfirmPayment(ctx context.Context, paymentIntentID string) (*Order, error)
 	HandleWebhook(ctx context.Context, eventType string, paymentIntentID string) error
 	ValidateCoupon(ctx context.Context, code string, subtotal float64) (*Coupon, float64, error)
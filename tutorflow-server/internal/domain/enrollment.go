@@ -29,7 +29,11 @@ type Enrollment struct {
 	ExpiresAt      *time.Time       `json:"expires_at,omitempty"`
 	Progress       float64          `gorm:"column:progress_percent;type:decimal(5,2);default:0" json:"progress"`
 	LastAccessedAt *time.Time       `json:"last_accessed_at,omitempty"`
-	OrderID        *uuid.UUID       `gorm:"type:uuid" json:"order_id,omitempty"` // Link to purchase
+	PausedAt       *time.Time       `json:"paused_at,omitempty"`
+	PausedSeconds  int64            `gorm:"default:0" json:"paused_seconds"`                  // total time credited back from pauses
+	LastNudgedAt   *time.Time       `json:"last_nudged_at,omitempty"`                         // when the instructor last reminded this learner to continue
+	OrderID        *uuid.UUID       `gorm:"type:uuid" json:"order_id,omitempty"`              // Link to purchase
+	SubscriptionID *uuid.UUID       `gorm:"type:uuid;index" json:"subscription_id,omitempty"` // set when access comes from a subscription, which then controls it
 
 	// Relationships
 	User             *User            `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -46,15 +50,49 @@ func (e *Enrollment) IsCompleted() bool {
 	return e.Status == EnrollmentStatusCompleted
 }
 
+func (e *Enrollment) IsPaused() bool {
+	return e.PausedAt != nil
+}
+
+// IsExpired reports whether access has run out. The clock is frozen while
+// paused, so a paused enrollment is judged as of the moment it was paused.
 func (e *Enrollment) IsExpired() bool {
 	if e.ExpiresAt == nil {
 		return false
 	}
-	return time.Now().After(*e.ExpiresAt)
+	now := time.Now()
+	if e.PausedAt != nil {
+		now = *e.PausedAt
+	}
+	return now.After(*e.ExpiresAt)
 }
 
 func (e *Enrollment) CanAccess() bool {
-	return e.IsActive() && !e.IsExpired()
+	return e.IsActive() && !e.IsPaused() && !e.IsExpired()
+}
+
+// Pause freezes the expiry clock at the given time
+func (e *Enrollment) Pause(at time.Time) {
+	e.PausedAt = &at
+}
+
+// Resume unfreezes the expiry clock and pushes ExpiresAt back by the time
+// spent paused, up to maxCredit. It returns the duration credited.
+func (e *Enrollment) Resume(at time.Time, maxCredit time.Duration) time.Duration {
+	if e.PausedAt == nil {
+		return 0
+	}
+
+	credit := at.Sub(*e.PausedAt).Truncate(time.Second)
+	credit = max(min(credit, maxCredit), 0)
+
+	if e.ExpiresAt != nil {
+		expiresAt := e.ExpiresAt.Add(credit)
+		e.ExpiresAt = &expiresAt
+	}
+	e.PausedSeconds += int64(credit / time.Second)
+	e.PausedAt = nil
+	return credit
 }
 
 // LessonProgress tracks progress for each lesson
@@ -86,6 +124,41 @@ type EnrollmentRepository interface {
 	UpdateProgress(enrollmentID uuid.UUID, percent float64) error
 }
 
+// FunnelMilestones are the progress percentages a course funnel counts
+// learners reaching
+var FunnelMilestones = []int{25, 50, 75, 100}
+
+// CourseFunnel shows how far a course's learners get
+type CourseFunnel struct {
+	CourseID            uuid.UUID    `json:"course_id"`
+	Enrolled            int64        `json:"enrolled"`
+	Steps               []FunnelStep `json:"steps"`
+	Completed           int64        `json:"completed"`
+	CompletionRate      float64      `json:"completion_rate"`
+	AvgDaysToCompletion float64      `json:"avg_days_to_completion"`
+}
+
+// FunnelStep counts learners at or past a progress milestone
+type FunnelStep struct {
+	Progress int     `json:"progress"`
+	Learners int64   `json:"learners"`
+	Percent  float64 `json:"percent"` // of enrolled learners
+}
+
+// AtRiskStudent is an active learner who has stopped working through a course
+// early on
+type AtRiskStudent struct {
+	EnrollmentID   uuid.UUID  `json:"enrollment_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	FirstName      string     `json:"first_name"`
+	LastName       string     `json:"last_name"`
+	Email          string     `json:"email"`
+	Progress       float64    `json:"progress"`
+	EnrolledAt     time.Time  `json:"enrolled_at"`
+	LastAccessedAt *time.Time `json:"last_accessed_at"` // latest lesson activity; nil if they never started a lesson
+	LastNudgedAt   *time.Time `json:"last_nudged_at"`   // nil if they were never reminded
+}
+
 // StudentDashboardStats represents statistics for the student dashboard
 type StudentDashboardStats struct {
 	EnrolledCourses int     `json:"enrolled_courses"`
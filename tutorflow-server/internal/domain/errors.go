@@ -8,25 +8,60 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrUserAlreadyExists  = errors.New("user already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserNotVerified    = errors.New("email not verified")
-	ErrUserSuspended      = errors.New("user account is suspended")
+	ErrEmailNotVerified   = errors.New("email not verified")
+	ErrAccountSuspended   = errors.New("account is suspended")
 	ErrUserInactive       = errors.New("user account is inactive")
+	ErrUsernameTaken      = errors.New("username is already taken")
+	ErrAccountLocked      = errors.New("too many failed logins, try again later")
 
 	// Auth errors
-	ErrInvalidToken        = errors.New("invalid token")
-	ErrTokenExpired        = errors.New("token has expired")
-	ErrTokenRevoked        = errors.New("token has been revoked")
-	ErrRefreshTokenInvalid = errors.New("refresh token is invalid")
+	ErrInvalidToken            = errors.New("invalid token")
+	ErrTokenExpired            = errors.New("token has expired")
+	ErrTokenRevoked            = errors.New("token has been revoked")
+	ErrRefreshTokenInvalid     = errors.New("refresh token is invalid")
+	ErrRefreshTokenReused      = errors.New("refresh token was already used")
+	ErrSessionNotFound         = errors.New("session not found")
+	ErrUnknownAuthProvider     = errors.New("sign-in provider is not available")
+	ErrProviderEmailUnverified = errors.New("the provider has not verified this email")
+	ErrVerificationInvalid     = errors.New("verification link is invalid or has expired")
+	ErrResetTokenInvalid       = errors.New("reset link is invalid or has expired")
+	ErrDeletionTokenInvalid    = errors.New("deletion link is invalid or has expired")
+	ErrDataExportInvalid       = errors.New("download link is invalid or has expired")
 
 	// Course errors
 	ErrCourseNotFound     = errors.New("course not found")
 	ErrCourseNotPublished = errors.New("course is not published")
 	ErrNotCourseOwner     = errors.New("not the course owner")
 
+	ErrInvalidEnrollmentCode = errors.New("invalid enrollment code")
+	ErrInvalidRevenueSplit   = errors.New("revenue split must include the course owner and sum to 100 percent")
+	ErrInvalidDiscountPrice  = errors.New("discount price must be between 0 and the course price")
+
+	// Review errors
+	ErrReviewNotFound         = errors.New("review not found")
+	ErrAlreadyReviewed        = errors.New("already reviewed this course")
+	ErrReviewProgressRequired = errors.New("more of the course must be completed before reviewing it")
+	ErrCannotVoteOwnReview    = errors.New("you cannot vote on your own review")
+
+	// Discussion errors
+	ErrDiscussionNotFound = errors.New("discussion not found")
+	ErrReplyTooDeep       = errors.New("replies cannot be nested any deeper")
+
+	// Announcement errors
+	ErrAnnouncementNotFound  = errors.New("announcement not found")
+	ErrAnnouncementPublished = errors.New("announcement has already been published")
+
+	// Category errors
+	ErrCategoryInUse        = errors.New("category still has courses or subcategories")
+	ErrInvalidCategoryOrder = errors.New("category order must list every category under the parent exactly once")
+
 	// Enrollment errors
-	ErrAlreadyEnrolled   = errors.New("already enrolled in this course")
-	ErrNotEnrolled       = errors.New("not enrolled in this course")
-	ErrEnrollmentExpired = errors.New("enrollment has expired")
+	ErrAlreadyEnrolled     = errors.New("already enrolled in this course")
+	ErrNotEnrolled         = errors.New("not enrolled in this course")
+	ErrEnrollmentExpired   = errors.New("enrollment has expired")
+	ErrEnrollmentPaused    = errors.New("enrollment is paused")
+	ErrEnrollmentNotPaused = errors.New("enrollment is not paused")
+	ErrPauseNotAllowed     = errors.New("enrollment cannot be paused")
 
 	// Content errors
 	ErrLessonNotFound = errors.New("lesson not found")
@@ -34,25 +69,54 @@ var (
 	ErrNoAccess       = errors.New("no access to this content")
 	ErrContentLocked  = errors.New("content is locked")
 
+	ErrInvalidLessonOrder  = errors.New("lesson order must list every lesson in the module exactly once")
+	ErrInvalidPrerequisite = errors.New("prerequisite must be another lesson in the same course")
+
 	// Assessment errors
 	ErrQuizNotFound       = errors.New("quiz not found")
+	ErrQuestionNotFound   = errors.New("question not found")
+	ErrQuizNotPublished   = errors.New("quiz is not published")
+	ErrAttemptNotFound    = errors.New("attempt not found")
+	ErrAttemptCompleted   = errors.New("attempt already completed")
+	ErrTimeLimitExceeded  = errors.New("time limit exceeded")
 	ErrAssignmentNotFound = errors.New("assignment not found")
+	ErrAssignmentPastDue  = errors.New("assignment is past due date")
 	ErrMaxAttemptsReached = errors.New("maximum attempts reached")
 	ErrSubmissionNotFound = errors.New("submission not found")
 
+	// Upload errors
+	ErrFileTooLarge            = errors.New("file is too large")
+	ErrInvalidFileType         = errors.New("file type is not allowed")
+	ErrFileContentMismatch     = errors.New("file content does not match its type")
+	ErrFileRejected            = errors.New("file was rejected by the upload scanner")
+	ErrFileNotFound            = errors.New("file not found")
+	ErrDirectUploadUnavailable = errors.New("direct uploads need object storage")
+
 	// Payment errors
 	ErrPaymentFailed       = errors.New("payment failed")
 	ErrOrderNotFound       = errors.New("order not found")
 	ErrCouponInvalid       = errors.New("coupon is invalid or expired")
 	ErrCouponNotApplicable = errors.New("coupon is not applicable")
+	ErrBundleNotAvailable  = errors.New("bundle is not available")
+	ErrBundleAlreadyOwned  = errors.New("you already own every course in this bundle")
 
 	// Device/DRM errors
 	ErrDeviceLimitReached    = errors.New("device limit reached")
 	ErrConcurrentStreamLimit = errors.New("concurrent stream limit reached")
 
+	// Messaging errors
+	ErrBroadcastLimitReached = errors.New("broadcast limit reached")
+	ErrMessagingNotAllowed   = errors.New("you can only message instructors and learners of your courses")
+
 	// Permission errors
 	ErrForbidden    = errors.New("forbidden")
 	ErrUnauthorized = errors.New("unauthorized")
+
+	// Notification errors
+	ErrNotificationFilterRequired = errors.New("give notification ids, a before time or both")
+
+	// Pagination errors
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
 )
 
 // ValidationError for request validation
@@ -30,6 +30,16 @@ const (
 	VideoStatusFailed     VideoProcessingStatus = "failed"
 )
 
+// VideoJobStatus defines transcoding job states
+type VideoJobStatus string
+
+const (
+	VideoJobPending   VideoJobStatus = "pending"
+	VideoJobRunning   VideoJobStatus = "running"
+	VideoJobCompleted VideoJobStatus = "completed"
+	VideoJobFailed    VideoJobStatus = "failed"
+)
+
 // VideoAsset represents a video file with HLS encoding
 type HLSVideoAsset struct {
 	ID              uuid.UUID             `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -39,6 +49,7 @@ type HLSVideoAsset struct {
 	FileSize        int64                 `gorm:"" json:"file_size"`
 	Resolution      string                `gorm:"size:20" json:"resolution"` // e.g., "1920x1080"
 	Status          VideoProcessingStatus `gorm:"size:20;not null;default:'pending'" json:"status"`
+	Progress        int                   `gorm:"default:0" json:"progress"` // transcoding percentage
 	ProcessingError string                `gorm:"type:text" json:"processing_error,omitempty"`
 	CreatedAt       time.Time             `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt       time.Time             `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
@@ -46,6 +57,24 @@ type HLSVideoAsset struct {
 	Lesson        *Lesson          `gorm:"foreignKey:LessonID" json:"-"`
 	Qualities     []VideoQuality   `gorm:"foreignKey:VideoID" json:"qualities,omitempty"`
 	HLSEncryption *VideoEncryption `gorm:"foreignKey:VideoID" json:"-"`
+	Job           *VideoJob        `gorm:"-" json:"job,omitempty"`
+}
+
+// VideoJob is a persisted transcoding job. Workers claim pending jobs, and
+// running jobs whose heartbeat stopped (e.g. the server restarted mid-encode)
+// are claimed again once they go stale.
+type VideoJob struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	VideoID     uuid.UUID      `gorm:"type:uuid;uniqueIndex;not null" json:"video_id"`
+	Status      VideoJobStatus `gorm:"size:20;not null;default:'pending';index:idx_video_jobs_status_run_after" json:"status"`
+	Attempts    int            `gorm:"not null;default:0" json:"attempts"`
+	LastError   string         `gorm:"type:text" json:"last_error,omitempty"`
+	RunAfter    time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_video_jobs_status_run_after" json:"run_after"`
+	HeartbeatAt *time.Time     `json:"heartbeat_at,omitempty"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	CreatedAt   time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
 
 // VideoQuality represents an HLS quality variant
@@ -103,13 +132,37 @@ type SignedURL struct {
 	URL       string     `gorm:"size:1000;not null" json:"-"`
 	Token     string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
 	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
-	UsedAt    *time.Time `gorm:"" json:"-"`
+	UsedAt    *time.Time `gorm:"" json:"-"` // first key fetch
 	CreatedAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 
+	// Key fetch audit. Players fetch the key again on seek or reload, so the
+	// token stays valid until ExpiresAt and fetches are counted instead.
+	KeyFetchCount  int        `gorm:"not null;default:0" json:"key_fetch_count"`
+	LastKeyFetchAt *time.Time `json:"last_key_fetch_at,omitempty"`
+
+	// WatermarkCode identifies the session in overlays for takedown tracing
+	WatermarkCode string `gorm:"size:16;index" json:"watermark_code,omitempty"`
+
 	Video *HLSVideoAsset `gorm:"foreignKey:VideoID" json:"-"`
 	User  *User          `gorm:"foreignKey:UserID" json:"-"`
 }
 
+// PlaybackGrant is a signed playback URL returned to the player
+type PlaybackGrant struct {
+	URL       string     `json:"url"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	Watermark *Watermark `json:"watermark,omitempty"`
+}
+
+// Watermark is drawn by the player over the video. HLS segments are encoded
+// once and shared by every viewer, so a per-viewer mark can't be burned into
+// them; the overlay shows the session's code instead, which TraceWatermark
+// resolves to the viewer.
+type Watermark struct {
+	Code    string  `json:"code"`
+	Opacity float64 `json:"opacity"`
+}
+
 // IsValid checks if the signed URL is still valid
 func (s *SignedURL) IsValid() bool {
 	return time.Now().Before(s.ExpiresAt)
@@ -128,24 +181,35 @@ type DeviceSession struct {
 	IsActive   bool      `gorm:"default:true" json:"is_active"`
 	LastSeenAt time.Time `gorm:"not null" json:"last_seen_at"`
 	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	IsCurrent  bool      `gorm:"-" json:"is_current"`
 
 	User *User `gorm:"foreignKey:UserID" json:"-"`
 }
 
+// DeviceInfo identifies the client requesting playback
+type DeviceInfo struct {
+	DeviceID  string
+	UserAgent string
+	IP        string
+}
+
 // VideoUseCase interface
 type VideoUseCase interface {
 	// Upload & Processing
-	UploadVideo(ctx context.Context, lessonID uuid.UUID, fileURL string) (*HLSVideoAsset, error)
+	// UploadVideo registers a video userID already put in their
+	// VideoUploadFolder
+	UploadVideo(ctx context.Context, lessonID, userID uuid.UUID, fileURL string) (*HLSVideoAsset, error)
 	UploadVideoFile(ctx context.Context, lessonID uuid.UUID, file *multipart.FileHeader) (*HLSVideoAsset, error)
 	ProcessVideo(ctx context.Context, videoID uuid.UUID) error
 	GetProcessingStatus(ctx context.Context, lessonID uuid.UUID) (*HLSVideoAsset, error)
 	DeleteVideo(ctx context.Context, lessonID uuid.UUID) error
 
 	// Playback
-	GetPlaybackURL(ctx context.Context, lessonID, userID uuid.UUID, deviceID string) (string, error)
-	GetEncryptionKey(ctx context.Context, token string) ([]byte, error)
+	GetPlaybackURL(ctx context.Context, lessonID, userID uuid.UUID, device DeviceInfo) (*PlaybackGrant, error)
+	TraceWatermark(ctx context.Context, code string) (*SignedURL, error)
+	GetEncryptionKey(ctx context.Context, videoID uuid.UUID, token string) ([]byte, error)
 	GetEncryptionKeyByVideoID(ctx context.Context, videoID uuid.UUID) ([]byte, error)
-	ValidatePlayback(ctx context.Context, token string) error
+	ValidatePlayback(ctx context.Context, videoID uuid.UUID, token string) error
 	GetVideoSegment(ctx context.Context, videoID uuid.UUID, segment string) (io.ReadCloser, string, error)
 
 	// DRM
@@ -153,10 +217,11 @@ type VideoUseCase interface {
 	RotateEncryptionKey(ctx context.Context, videoID uuid.UUID) error
 
 	// Device Management
-	RegisterDevice(ctx context.Context, userID uuid.UUID, deviceID, deviceName, deviceType string) error
-	GetUserDevices(ctx context.Context, userID uuid.UUID) ([]DeviceSession, error)
+	RegisterDevice(ctx context.Context, userID uuid.UUID, device DeviceInfo) error
+	GetUserDevices(ctx context.Context, userID uuid.UUID, currentDeviceID string) ([]DeviceSession, error)
+	RenameDevice(ctx context.Context, userID uuid.UUID, deviceID, name string) (*DeviceSession, error)
 	RemoveDevice(ctx context.Context, userID uuid.UUID, deviceID string) error
-	ValidateDeviceLimit(ctx context.Context, userID uuid.UUID) error
+	ValidateDeviceLimit(ctx context.Context, userID uuid.UUID, deviceID string) error
 }
 
 // HLSConfig defines HLS encoding settings
@@ -167,6 +232,9 @@ type HLSConfig struct {
 	MaxConcurrentStreams int               `json:"max_concurrent_streams"`
 	MaxDevices           int               `json:"max_devices"`
 	SignedURLExpiry      int               `json:"signed_url_expiry"` // seconds
+	KeyBaseURL           string            `json:"key_base_url"`      // origin prepended to key URIs when playlists are served
+	Watermark            bool              `json:"watermark"`         // ask players to overlay the session's watermark code
+	WatermarkOpacity     float64           `json:"watermark_opacity"`
 }
 
 // QualityPreset defines encoding quality settings
@@ -192,5 +260,6 @@ func DefaultHLSConfig() HLSConfig {
 		MaxConcurrentStreams: 1,
 		MaxDevices:           3,
 		SignedURLExpiry:      14400, // 4 hours
+		WatermarkOpacity:     0.15,
 	}
 }
@@ -43,8 +43,13 @@ type Lesson struct {
 	SortOrder     int           `gorm:"not null;default:0" json:"sort_order"`
 	IsPublished   bool          `gorm:"default:false" json:"is_published"`
 	IsPreview     bool          `gorm:"default:false" json:"is_preview"`
-	CreatedAt     time.Time     `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt     time.Time     `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// Sequential and scheduled unlocking; both are optional
+	PrerequisiteLessonID    *uuid.UUID `gorm:"type:uuid;index" json:"prerequisite_lesson_id,omitempty"`
+	DripDaysAfterEnrollment *int       `json:"drip_days_after_enrollment,omitempty"`
+
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
 	// Relationships
 	Module      *Module      `gorm:"foreignKey:ModuleID" json:"module,omitempty"`
@@ -57,6 +62,66 @@ func (l *Lesson) IsFreeAccess() bool {
 	return l.AccessType == ContentAccessFree || l.IsPreview
 }
 
+// LockFor reports why the lesson is still locked for an enrollment that
+// started at enrolledAt, or nil if it's open. prerequisiteDone is whether the
+// learner has completed the prerequisite lesson. Preview lessons never lock;
+// course owners are exempted by the caller.
+func (l *Lesson) LockFor(enrolledAt time.Time, prerequisiteDone bool, now time.Time) *LessonLock {
+	if l.IsPreview {
+		return nil
+	}
+
+	if l.PrerequisiteLessonID != nil && !prerequisiteDone {
+		return &LessonLock{
+			LessonID:             l.ID,
+			Reason:               LessonLockPrerequisite,
+			PrerequisiteLessonID: l.PrerequisiteLessonID,
+		}
+	}
+
+	if l.DripDaysAfterEnrollment != nil && *l.DripDaysAfterEnrollment > 0 {
+		unlocksAt := enrolledAt.AddDate(0, 0, *l.DripDaysAfterEnrollment)
+		if now.Before(unlocksAt) {
+			return &LessonLock{
+				LessonID:  l.ID,
+				Reason:    LessonLockDrip,
+				UnlocksAt: &unlocksAt,
+			}
+		}
+	}
+
+	return nil
+}
+
+// LessonLockReason says what is keeping a lesson locked
+type LessonLockReason string
+
+const (
+	LessonLockPrerequisite LessonLockReason = "prerequisite"
+	LessonLockDrip         LessonLockReason = "drip"
+)
+
+// LessonLock is returned as an error when a learner opens a lesson that
+// hasn't unlocked yet. It carries enough for the UI to explain why.
+type LessonLock struct {
+	LessonID             uuid.UUID        `json:"lesson_id"`
+	Reason               LessonLockReason `json:"reason"`
+	PrerequisiteLessonID *uuid.UUID       `json:"prerequisite_lesson_id,omitempty"`
+	UnlocksAt            *time.Time       `json:"unlocks_at,omitempty"`
+}
+
+func (l *LessonLock) Error() string {
+	if l.Reason == LessonLockDrip && l.UnlocksAt != nil {
+		return "lesson unlocks on " + l.UnlocksAt.Format(time.RFC3339)
+	}
+	return "complete the prerequisite lesson first"
+}
+
+// Is lets errors.Is match a lesson lock against ErrContentLocked
+func (l *LessonLock) Is(target error) bool {
+	return target == ErrContentLocked
+}
+
 // VideoAsset represents encrypted video files for DRM
 type VideoAsset struct {
 	ID               uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -35,8 +35,34 @@ type Message struct {
 	CreatedAt      time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 
 	// Relationships
-	Conversation *Conversation `gorm:"foreignKey:ConversationID" json:"-"`
-	Sender       *User         `gorm:"foreignKey:SenderID" json:"sender,omitempty"`
+	Conversation *Conversation       `gorm:"foreignKey:ConversationID" json:"-"`
+	Sender       *User               `gorm:"foreignKey:SenderID" json:"sender,omitempty"`
+	Attachments  []MessageAttachment `gorm:"foreignKey:MessageID" json:"attachments,omitempty"`
+}
+
+// MessageAttachment is a file sent with a message
+type MessageAttachment struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	MessageID   uuid.UUID `gorm:"type:uuid;index;not null" json:"message_id"`
+	FileURL     string    `gorm:"type:varchar(500);not null" json:"file_url"`
+	FileName    string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	FileSize    int64     `gorm:"not null" json:"file_size"`
+	ContentType string    `gorm:"type:varchar(100);not null" json:"content_type"`
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// MessageBroadcast is the audit record of an instructor messaging a whole course
+type MessageBroadcast struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SenderID       uuid.UUID `gorm:"type:uuid;index;not null" json:"sender_id"`
+	CourseID       uuid.UUID `gorm:"type:uuid;index;not null" json:"course_id"`
+	Content        string    `gorm:"type:text;not null" json:"content"`
+	RecipientCount int       `gorm:"default:0" json:"recipient_count"`
+	FailedCount    int       `gorm:"default:0" json:"failed_count"`
+	CreatedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	Sender *User   `gorm:"foreignKey:SenderID" json:"-"`
+	Course *Course `gorm:"foreignKey:CourseID" json:"-"`
 }
 
 // IsRead returns true if the message has been read
@@ -52,6 +78,26 @@ func (c *Conversation) GetOtherParticipant(userID uuid.UUID) uuid.UUID {
 	return c.Participant1
 }
 
+// HasParticipant reports whether the user is one of the two people in the conversation
+func (c *Conversation) HasParticipant(userID uuid.UUID) bool {
+	return c.Participant1 == userID || c.Participant2 == userID
+}
+
+// Presence is whether a user is connected right now and when they were last active
+type Presence struct {
+	UserID       uuid.UUID  `json:"user_id"`
+	Online       bool       `json:"online"`
+	LastActiveAt *time.Time `json:"last_active_at,omitempty"`
+}
+
+// MessageSearchResult is a message matching a search, with the conversation it
+// is in and an excerpt showing where it matched
+type MessageSearchResult struct {
+	Message      Message       `json:"message"`
+	Conversation *Conversation `json:"conversation"`
+	Snippet      string        `json:"snippet"` // HTML-escaped, with matches wrapped in <mark> tags
+}
+
 // ConversationWithUnread for list view
 type ConversationWithUnread struct {
 	Conversation
@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationCategory groups notification types for user preferences
+type NotificationCategory string
+
+const (
+	NotificationCategoryEnrollment NotificationCategory = "enrollment" // enrollments and certificates
+	NotificationCategoryCourse     NotificationCategory = "course"     // new lessons, updates and announcements
+	NotificationCategoryDiscussion NotificationCategory = "discussion" // mentions and accepted answers
+	NotificationCategoryGrade      NotificationCategory = "grade"      // grades and assignment deadlines
+	NotificationCategoryReview     NotificationCategory = "review"     // reviews of and responses on courses
+	NotificationCategoryPayment    NotificationCategory = "payment"
+	NotificationCategoryMessage    NotificationCategory = "message"
+	NotificationCategoryMarketing  NotificationCategory = "marketing" // promotions; off unless the user opts in
+)
+
+// NotificationCategories lists every category in display order
+var NotificationCategories = []NotificationCategory{
+	NotificationCategoryEnrollment,
+	NotificationCategoryCourse,
+	NotificationCategoryDiscussion,
+	NotificationCategoryGrade,
+	NotificationCategoryReview,
+	NotificationCategoryPayment,
+	NotificationCategoryMessage,
+	NotificationCategoryMarketing,
+}
+
+// NotificationChannel is how a notification reaches the user
+type NotificationChannel string
+
+const (
+	NotificationChannelInApp NotificationChannel = "in_app"
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelPush  NotificationChannel = "push"
+)
+
+// NotificationChannels lists every channel
+var NotificationChannels = []NotificationChannel{
+	NotificationChannelInApp,
+	NotificationChannelEmail,
+	NotificationChannelPush,
+}
+
+// Category returns the preference category a notification type belongs to
+func (t NotificationType) Category() NotificationCategory {
+	switch t {
+	case NotificationEnrollmentApproved:
+		return NotificationCategoryEnrollment
+	case NotificationAssignmentDue, NotificationGradePosted:
+		return NotificationCategoryGrade
+	case NotificationMention, NotificationAnswerAccepted:
+		return NotificationCategoryDiscussion
+	case NotificationReviewReceived, NotificationReviewResponse:
+		return NotificationCategoryReview
+	case NotificationPaymentReceived:
+		return NotificationCategoryPayment
+	case NotificationMessage:
+		return NotificationCategoryMessage
+	default:
+		return NotificationCategoryCourse
+	}
+}
+
+// NotificationPreference is a user's choice for one category on one channel.
+// Only choices the user has made are stored; the rest use the defaults.
+type NotificationPreference struct {
+	ID        uuid.UUID            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"-"`
+	UserID    uuid.UUID            `gorm:"type:uuid;not null;uniqueIndex:idx_notification_preferences_user_category_channel" json:"-"`
+	Category  NotificationCategory `gorm:"type:varchar(30);not null;uniqueIndex:idx_notification_preferences_user_category_channel" json:"category"`
+	Channel   NotificationChannel  `gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_preferences_user_category_channel" json:"channel"`
+	Enabled   bool                 `gorm:"not null" json:"enabled"`
+	UpdatedAt time.Time            `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// NotificationPreferences is the set of choices a user has stored
+type NotificationPreferences []NotificationPreference
+
+// Allows reports whether the user wants notifications of the category on the
+// channel. Everything is on by default except marketing.
+func (p NotificationPreferences) Allows(category NotificationCategory, channel NotificationChannel) bool {
+	for _, pref := range p {
+		if pref.Category == category && pref.Channel == channel {
+			return pref.Enabled
+		}
+	}
+	return category != NotificationCategoryMarketing
+}
+
+// NotificationPreferenceMatrix maps each category to its per-channel setting
+type NotificationPreferenceMatrix map[NotificationCategory]map[NotificationChannel]bool
+
+// Matrix fills in every category and channel, defaults included
+func (p NotificationPreferences) Matrix() NotificationPreferenceMatrix {
+	matrix := make(NotificationPreferenceMatrix, len(NotificationCategories))
+	for _, category := range NotificationCategories {
+		matrix[category] = make(map[NotificationChannel]bool, len(NotificationChannels))
+		for _, channel := range NotificationChannels {
+			matrix[category][channel] = p.Allows(category, channel)
+		}
+	}
+	return matrix
+}
+
+// ValidNotificationCategory reports whether c is a known category
+func ValidNotificationCategory(c NotificationCategory) bool {
+	for _, known := range NotificationCategories {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidNotificationChannel reports whether c is a known channel
+func ValidNotificationChannel(c NotificationChannel) bool {
+	for _, known := range NotificationChannels {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// DigestFrequency is how often a user's notification emails are sent
+type DigestFrequency string
+
+const (
+	DigestImmediate DigestFrequency = "immediate" // an email per event
+	DigestDaily     DigestFrequency = "daily"
+	DigestWeekly    DigestFrequency = "weekly"
+)
+
+// Period returns how much time one digest covers, or zero for immediate
+func (f DigestFrequency) Period() time.Duration {
+	switch f {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// ValidDigestFrequency reports whether f is a known frequency
+func ValidDigestFrequency(f DigestFrequency) bool {
+	return f == DigestImmediate || f == DigestDaily || f == DigestWeekly
+}
+
+// NotificationDigest is a user's choice to receive their notifications as a
+// periodic email instead of one email per event
+type NotificationDigest struct {
+	UserID     uuid.UUID       `gorm:"type:uuid;primary_key" json:"-"`
+	Frequency  DigestFrequency `gorm:"type:varchar(20);not null;default:'immediate';index" json:"frequency"`
+	LastSentAt *time.Time      `json:"last_sent_at,omitempty"`
+	UpdatedAt  time.Time       `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+}
@@ -9,9 +9,9 @@ import (
 // RecentlyViewed tracks user's recently viewed courses
 type RecentlyViewed struct {
 	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID   uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
-	CourseID uuid.UUID `gorm:"type:uuid;index;not null" json:"course_id"`
-	ViewedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"viewed_at"`
+	UserID   uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_recently_viewed_user_course;not null" json:"user_id"`
+	CourseID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_recently_viewed_user_course;index;not null" json:"course_id"`
+	ViewedAt time.Time `gorm:"index;not null;default:CURRENT_TIMESTAMP" json:"viewed_at"`
 
 	User   *User   `gorm:"foreignKey:UserID" json:"-"`
 	Course *Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchLog records a course search for trending and analytics
+type SearchLog struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Query       string     `gorm:"type:varchar(255);not null;index:idx_search_logs_query_created" json:"query"`
+	UserID      *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	ResultCount int64      `gorm:"default:0" json:"result_count"`
+	CreatedAt   time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_search_logs_query_created" json:"created_at"`
+}
@@ -1,41 +1,55 @@
 package domain
 
 import (
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ReviewStatus enum
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"  // waiting for moderation
+	ReviewStatusApproved ReviewStatus = "approved" // public and counted in the course rating
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
 // CourseReview represents a course review
 type CourseReview struct {
-	ID                 uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	CourseID           uuid.UUID  `gorm:"type:uuid;index;not null" json:"course_id"`
-	UserID             uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
-	Rating             float64    `gorm:"type:decimal(2,1);not null" json:"rating"` // 1.0 - 5.0
-	Title              *string    `gorm:"type:varchar(200)" json:"title,omitempty"`
-	Content            *string    `gorm:"type:text" json:"content,omitempty"`
-	HelpfulCount       int        `gorm:"default:0" json:"helpful_count"`
-	UnhelpfulCount     int        `gorm:"default:0" json:"unhelpful_count"`
-	InstructorReply    *string    `gorm:"type:text" json:"instructor_reply,omitempty"`
-	InstructorReplyAt  *time.Time `json:"instructor_reply_at,omitempty"`
-	IsVerifiedPurchase bool       `gorm:"default:false" json:"is_verified_purchase"`
-	IsFeatured         bool       `gorm:"default:false" json:"is_featured"`
-	Status             string     `gorm:"type:varchar(20);default:'published'" json:"status"`
-	CreatedAt          time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt          time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID                 uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CourseID           uuid.UUID    `gorm:"type:uuid;index;uniqueIndex:idx_course_reviews_user_course;not null" json:"course_id"`
+	UserID             uuid.UUID    `gorm:"type:uuid;index;uniqueIndex:idx_course_reviews_user_course;not null" json:"user_id"`
+	Rating             float64      `gorm:"type:decimal(2,1);not null" json:"rating"` // 1.0 - 5.0
+	Title              *string      `gorm:"type:varchar(200)" json:"title,omitempty"`
+	Content            *string      `gorm:"type:text" json:"content,omitempty"`
+	HelpfulCount       int          `gorm:"default:0" json:"helpful_count"`
+	UnhelpfulCount     int          `gorm:"default:0" json:"unhelpful_count"`
+	InstructorReply    *string      `gorm:"type:text" json:"instructor_reply,omitempty"`
+	InstructorReplyAt  *time.Time   `json:"instructor_reply_at,omitempty"`
+	IsVerifiedPurchase bool         `gorm:"default:false" json:"is_verified_purchase"`
+	IsFeatured         bool         `gorm:"default:false" json:"is_featured"`
+	Status             ReviewStatus `gorm:"type:varchar(20);index;default:'approved'" json:"status"`
+	ModeratedBy        *uuid.UUID   `gorm:"type:uuid" json:"moderated_by,omitempty"`
+	ModeratedAt        *time.Time   `json:"moderated_at,omitempty"`
+	CreatedAt          time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt          time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
 	Course *Course      `gorm:"foreignKey:CourseID" json:"course,omitempty"`
 	User   *User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Votes  []ReviewVote `gorm:"foreignKey:ReviewID" json:"-"`
 }
 
-// ReviewVote represents a vote on a review
+// ReviewVote represents a vote on a review. A user has at most one vote per
+// review; voting again changes its direction.
 type ReviewVote struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	ReviewID  uuid.UUID `gorm:"type:uuid;index;not null" json:"review_id"`
-	UserID    uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	ReviewID  uuid.UUID `gorm:"type:uuid;index;uniqueIndex:idx_review_votes_review_user;not null" json:"review_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;uniqueIndex:idx_review_votes_review_user;not null" json:"user_id"`
 	IsHelpful bool      `gorm:"not null" json:"is_helpful"`
 	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
 	Review *CourseReview `gorm:"foreignKey:ReviewID" json:"-"`
 	User   *User         `gorm:"foreignKey:UserID" json:"-"`
@@ -54,36 +68,61 @@ const (
 	NotificationCourseUpdate       NotificationType = "course_update"
 	NotificationPaymentReceived    NotificationType = "payment_received"
 	NotificationReviewReceived     NotificationType = "review_received"
+	NotificationReviewResponse     NotificationType = "review_response"
+	NotificationMention            NotificationType = "mention"
+	NotificationAnswerAccepted     NotificationType = "answer_accepted"
 )
 
 // Announcement represents a course or global announcement
 type Announcement struct {
-	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	CourseID    *uuid.UUID `gorm:"type:uuid;index" json:"course_id,omitempty"`
-	AuthorID    uuid.UUID  `gorm:"type:uuid;not null" json:"author_id"`
-	Title       string     `gorm:"type:varchar(255);not null" json:"title"`
-	Content     string     `gorm:"type:text;not null" json:"content"`
-	IsPinned    bool       `gorm:"default:false" json:"is_pinned"`
-	PublishedAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"published_at"`
-	CreatedAt   time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CourseID     *uuid.UUID `gorm:"type:uuid;index" json:"course_id,omitempty"`
+	AuthorID     uuid.UUID  `gorm:"type:uuid;not null" json:"author_id"`
+	Title        string     `gorm:"type:varchar(255);not null" json:"title"`
+	Content      string     `gorm:"type:text;not null" json:"content"`
+	IsPinned     bool       `gorm:"default:false" json:"is_pinned"`
+	PinnedAt     *time.Time `json:"pinned_at,omitempty"`
+	SendEmail    bool       `gorm:"not null;default:false" json:"send_email"` // publishing also emails the audience
+	ScheduledFor *time.Time `gorm:"index" json:"scheduled_for,omitempty"`     // set until a scheduled announcement is published
+	PublishedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"published_at"`
+	ExpiresAt    *time.Time `gorm:"index" json:"expires_at,omitempty"` // hidden from learners after this
+	CreatedAt    time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 
 	Course *Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
 	Author *User   `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
 }
 
+// IsScheduled reports whether the announcement is still waiting to be
+// published
+func (a *Announcement) IsScheduled() bool {
+	return a.ScheduledFor != nil
+}
+
+// IsExpired reports whether the announcement's time has passed
+func (a *Announcement) IsExpired(now time.Time) bool {
+	return a.ExpiresAt != nil && !a.ExpiresAt.After(now)
+}
+
 // Discussion represents a discussion thread
 type Discussion struct {
 	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	CourseID   uuid.UUID  `gorm:"type:uuid;index;not null" json:"course_id"`
 	LessonID   *uuid.UUID `gorm:"type:uuid;index" json:"lesson_id,omitempty"`
 	UserID     uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
-	ParentID   *uuid.UUID `gorm:"type:uuid" json:"parent_id,omitempty"`
+	ParentID   *uuid.UUID `gorm:"type:uuid;index" json:"parent_id,omitempty"`
+	Depth      int        `gorm:"not null;default:0" json:"depth"` // 0 for top-level posts
 	Content    string     `gorm:"type:text;not null" json:"content"`
 	IsPinned   bool       `gorm:"default:false" json:"is_pinned"`
 	IsResolved bool       `gorm:"default:false" json:"is_resolved"`
-	Upvotes    int        `gorm:"default:0" json:"upvotes"`
-	CreatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	// AcceptedReplyID is the reply chosen as the answer to a question
+	AcceptedReplyID *uuid.UUID `gorm:"type:uuid" json:"accepted_reply_id,omitempty"`
+	Upvotes         int        `gorm:"default:0" json:"upvotes"`
+	CreatedAt       time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// ReplyCount is the number of direct replies, including any not loaded
+	// into Replies, so clients know whether to fetch more
+	ReplyCount int64 `gorm:"-" json:"reply_count"`
 
 	Course  *Course      `gorm:"foreignKey:CourseID" json:"course,omitempty"`
 	Lesson  *Lesson      `gorm:"foreignKey:LessonID" json:"lesson,omitempty"`
@@ -92,16 +131,37 @@ type Discussion struct {
 	Replies []Discussion `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
 }
 
+// mentionPattern finds @handles that don't sit inside a word, so addresses
+// like jane@example.com aren't read as mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@.])@([A-Za-z0-9_]+)`)
+
+// Mentions returns the distinct usernames @-mentioned in the content, in the
+// order they first appear
+func (d *Discussion) Mentions() []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(d.Content, -1) {
+		username, ok := NormalizeUsername(m[1])
+		if !ok || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+	return usernames
+}
+
 // Notification represents a user notification
 type Notification struct {
-	ID        uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID        `gorm:"type:uuid;index;not null" json:"user_id"`
-	Type      NotificationType `gorm:"type:notification_type;not null" json:"type"`
-	Title     string           `gorm:"type:varchar(255);not null" json:"title"`
-	Message   *string          `gorm:"type:text" json:"message,omitempty"`
-	Data      *string          `gorm:"type:jsonb;default:'{}'" json:"data,omitempty"`
-	ReadAt    *time.Time       `json:"read_at,omitempty"`
-	CreatedAt time.Time        `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	ID         uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID        `gorm:"type:uuid;index;not null" json:"user_id"`
+	Type       NotificationType `gorm:"type:notification_type;not null" json:"type"`
+	Title      string           `gorm:"type:varchar(255);not null" json:"title"`
+	Message    *string          `gorm:"type:text" json:"message,omitempty"`
+	Data       *string          `gorm:"type:jsonb;default:'{}'" json:"data,omitempty"`
+	ReadAt     *time.Time       `json:"read_at,omitempty"`
+	DigestedAt *time.Time       `json:"-"` // when it went out in a digest email
+	CreatedAt  time.Time        `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 
 	User *User `gorm:"foreignKey:UserID" json:"-"`
 }
@@ -4,8 +4,17 @@ import (
 	"context"
 	"io"
 	"mime/multipart"
+
+	"github.com/google/uuid"
 )
 
+// VideoUploadFolder is where a user's own video uploads are stored. A video
+// registered for a lesson by URL must come from the registering user's
+// folder, so nobody can reuse a file someone else uploaded.
+func VideoUploadFolder(userID uuid.UUID) string {
+	return "videos/uploads/" + userID.String()
+}
+
 // StorageService interface for file storage operations
 type StorageService interface {
 	UploadFile(ctx context.Context, file *multipart.FileHeader, folder string) (string, error)
@@ -16,8 +25,8 @@ type StorageService interface {
 	DeleteFolder(ctx context.Context, path string) error
 	GetFilePath(url string) string
 	FileExists(url string) bool
-	UploadHLSFiles(ctx context.Context, localDir string, s3Prefix string) error
+	UploadHLSFiles(ctx context.Context, localDir string, prefix string) error
 	GetFileStream(ctx context.Context, path string) (io.ReadCloser, string, error)
-	GetBucket() string
-	GetBasePath() string
+	PathFromURL(url string) (string, bool)
+	LocalPath(path string) string
 }
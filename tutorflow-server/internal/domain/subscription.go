@@ -25,6 +25,8 @@ const (
 	SubscriptionStatusPastDue  SubscriptionStatus = "past_due"
 	SubscriptionStatusTrialing SubscriptionStatus = "trialing"
 	SubscriptionStatusExpired  SubscriptionStatus = "expired"
+	// SubscriptionStatusIncomplete is a paid subscription waiting on checkout
+	SubscriptionStatusIncomplete SubscriptionStatus = "incomplete"
 )
 
 // SubscriptionPlan represents a subscription tier
@@ -41,10 +43,12 @@ type SubscriptionPlan struct {
 	OfflineAccess        bool           `gorm:"default:false" json:"offline_access"`
 	CertificateAccess    bool           `gorm:"default:true" json:"certificate_access"`
 	Priority             int            `gorm:"default:0" json:"priority"`
+	Tier                 int            `gorm:"not null;default:1" json:"tier"`            // covers courses with a subscription tier up to this
+	AllCourses           bool           `gorm:"not null;default:false" json:"all_courses"` // covers every course whatever its tier
 	IsActive             bool           `gorm:"default:true" json:"is_active"`
-	StripeProductID      string         `gorm:"size:100" json:"-"`
-	StripePriceMonthlyID string         `gorm:"size:100" json:"-"`
-	StripePriceYearlyID  string         `gorm:"size:100" json:"-"`
+	StripeProductID      string         `gorm:"size:100" json:"stripe_product_id,omitempty"`
+	StripePriceMonthlyID string         `gorm:"size:100" json:"stripe_price_monthly_id,omitempty"`
+	StripePriceYearlyID  string         `gorm:"size:100" json:"stripe_price_yearly_id,omitempty"`
 	CreatedAt            time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt            time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
@@ -78,6 +82,36 @@ func (s *Subscription) IsActive() bool {
 		s.CurrentPeriodEnd.After(now)
 }
 
+// GrantsAccess reports whether the subscriber currently gets the plan's
+// courses. Past-due, canceled and expired subscriptions don't.
+func (s *Subscription) GrantsAccess() bool {
+	return (s.Status == SubscriptionStatusActive || s.Status == SubscriptionStatusTrialing) &&
+		s.CurrentPeriodEnd.After(time.Now())
+}
+
+// Covers reports whether the plan includes the course
+func (p *SubscriptionPlan) Covers(course *Course) bool {
+	if p.AllCourses {
+		return true
+	}
+	return course.SubscriptionTier > 0 && course.SubscriptionTier <= p.Tier
+}
+
+// PriceFor returns the plan's price and Stripe price for a billing interval
+func (p *SubscriptionPlan) PriceFor(interval SubscriptionInterval) (float64, string) {
+	if interval == SubscriptionIntervalYearly {
+		return p.PriceYearly, p.StripePriceYearlyID
+	}
+	return p.PriceMonthly, p.StripePriceMonthlyID
+}
+
+// SubscriptionCheckout is the result of subscribing. Paid plans return a
+// Stripe Checkout URL; the subscription starts once payment is made.
+type SubscriptionCheckout struct {
+	Subscription *Subscription `json:"subscription"`
+	CheckoutURL  string        `json:"checkout_url,omitempty"`
+}
+
 // IsTrialing checks if subscription is in trial period
 func (s *Subscription) IsTrialing() bool {
 	if s.TrialEnd == nil {
@@ -110,6 +144,7 @@ type SubscriptionRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*Subscription, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*Subscription, error)
 	GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*Subscription, error)
+	GetByStripeID(ctx context.Context, stripeSubscriptionID string) (*Subscription, error)
 	Update(ctx context.Context, subscription *Subscription) error
 	Cancel(ctx context.Context, id uuid.UUID) error
 	GetExpiringSubscriptions(ctx context.Context, days int) ([]Subscription, error)
@@ -124,10 +159,12 @@ type SubscriptionUseCase interface {
 	UpdatePlan(ctx context.Context, plan *SubscriptionPlan) error
 
 	// Subscriptions
-	Subscribe(ctx context.Context, userID uuid.UUID, planSlug string, interval SubscriptionInterval) (*Subscription, error)
+	Subscribe(ctx context.Context, userID uuid.UUID, planSlug string, interval SubscriptionInterval) (*SubscriptionCheckout, error)
 	GetUserSubscription(ctx context.Context, userID uuid.UUID) (*Subscription, error)
 	CancelSubscription(ctx context.Context, userID uuid.UUID) error
 	ResumeSubscription(ctx context.Context, userID uuid.UUID) error
 	ChangeSubscription(ctx context.Context, userID uuid.UUID, newPlanSlug string) (*Subscription, error)
+	EndSubscriptions(ctx context.Context, userID uuid.UUID) error
 	HandleWebhook(ctx context.Context, event string, data map[string]interface{}) error
+	ExpireLapsed(ctx context.Context) (int, error)
 }
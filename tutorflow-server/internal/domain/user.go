@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,12 +27,14 @@ const (
 	StatusInactive  UserStatus = "inactive"
 	StatusSuspended UserStatus = "suspended"
 	StatusPending   UserStatus = "pending"
+	StatusDeleted   UserStatus = "deleted" // closed by the user and anonymized; kept so their orders and posts stay valid
 )
 
 // User represents a user in the system
 type User struct {
 	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Email           string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
+	Username        *string        `gorm:"type:varchar(30);uniqueIndex" json:"username,omitempty"` // lowercase handle used for @mentions
 	PasswordHash    string         `gorm:"type:varchar(255);not null" json:"-"`
 	FirstName       string         `gorm:"type:varchar(100);not null" json:"first_name"`
 	LastName        string         `gorm:"type:varchar(100);not null" json:"last_name"`
@@ -41,6 +45,8 @@ type User struct {
 	Bio             *string        `gorm:"type:text" json:"bio,omitempty"`
 	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
 	LastLoginAt     *time.Time     `json:"last_login_at,omitempty"`
+	LastActiveAt    *time.Time     `json:"-"`                                           // shown through presence, which respects HidePresence
+	HidePresence    bool           `gorm:"not null;default:false" json:"hide_presence"` // don't show others when this user is online
 	CreatedAt       time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt       time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
@@ -52,6 +58,15 @@ type User struct {
 	RefreshTokens []RefreshToken `gorm:"foreignKey:UserID" json:"-"`
 }
 
+// usernamePattern is the handle format shared by sign-up and @mentions
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// NormalizeUsername lowercases a handle and reports whether it is valid
+func NormalizeUsername(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return s, usernamePattern.MatchString(s)
+}
+
 func (u *User) FullName() string {
 	return u.FirstName + " " + u.LastName
 }
@@ -80,6 +95,28 @@ func (u *User) IsEmailVerified() bool {
 	return u.EmailVerifiedAt != nil
 }
 
+func (u *User) IsDeleted() bool {
+	return u.Status == StatusDeleted
+}
+
+// Anonymize scrubs the user's personal details, leaving a placeholder that
+// reviews, discussions and orders can still point at. passwordHash should be
+// for a password nobody knows.
+func (u *User) Anonymize(passwordHash string) {
+	u.Email = "deleted-" + u.ID.String() + "@deleted.invalid"
+	u.Username = nil
+	u.PasswordHash = passwordHash
+	u.FirstName = "Deleted"
+	u.LastName = "User"
+	u.Status = StatusDeleted
+	u.AvatarURL = nil
+	u.Phone = nil
+	u.Bio = nil
+	u.EmailVerifiedAt = nil
+	u.LastActiveAt = nil
+	u.HidePresence = true
+}
+
 // TutorProfile contains tutor-specific data
 type TutorProfile struct {
 	ID                uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -100,18 +137,83 @@ type TutorProfile struct {
 	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
-// RefreshToken for JWT authentication
+// RefreshToken for JWT authentication. Each active token is a signed-in
+// session; refreshing replaces the token but carries the session details over.
 type RefreshToken struct {
-	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
-	TokenHash string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
-	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
-	CreatedAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	TokenHash  string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	CreatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"` // last sign-in or refresh
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	SignedInAt time.Time  `json:"signed_in_at"`
+	Device     string     `gorm:"type:varchar(100)" json:"device"` // e.g. "Chrome on macOS", from the user agent
+	UserAgent  string     `gorm:"type:varchar(512)" json:"user_agent"`
+	IPAddress  string     `gorm:"type:varchar(45)" json:"ip_address"`
+	// ReplacedBy is set when the token was spent on a refresh, so presenting
+	// it again means it was stolen
+	ReplacedBy *uuid.UUID `gorm:"type:uuid" json:"-"`
 
 	User *User `gorm:"foreignKey:UserID" json:"-"`
 }
 
+// EmailVerificationToken is emailed to a user to confirm they own the address.
+// Only a hash of the token is stored.
+type EmailVerificationToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	TokenHash string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// PasswordResetToken lets a user who forgot their password choose a new one.
+// Only a hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	TokenHash string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// AccountDeletionToken confirms a request to delete an account, for users who
+// sign in through a provider and have no password to confirm it with. Only a
+// hash of the token is stored.
+type AccountDeletionToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	TokenHash string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// AuthProvider is an external identity provider users can sign in with
+type AuthProvider string
+
+const (
+	AuthProviderGoogle AuthProvider = "google"
+)
+
+// LinkedAccount ties a user to their account at an identity provider
+type LinkedAccount struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID         uuid.UUID    `gorm:"type:uuid;index;not null" json:"user_id"`
+	Provider       AuthProvider `gorm:"type:varchar(20);not null;uniqueIndex:idx_linked_account_provider_user" json:"provider"`
+	ProviderUserID string       `gorm:"type:varchar(255);not null;uniqueIndex:idx_linked_account_provider_user" json:"-"`
+	Email          string       `gorm:"type:varchar(255)" json:"email"` // as the provider reported it when linked
+	CreatedAt      time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// LoginAttempt records a failed sign-in. Recent failures for an email lock
+// the account for a while to stop password guessing.
+type LoginAttempt struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Email     string    `gorm:"type:varchar(255);index;not null" json:"email"` // lowercased, so unknown emails are tracked too
+	IPAddress string    `gorm:"type:varchar(45)" json:"ip_address,omitempty"`
+	CreatedAt time.Time `gorm:"index;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
 func (r *RefreshToken) IsExpired() bool {
 	return time.Now().After(r.ExpiresAt)
 }
@@ -159,3 +261,27 @@ type UserRepository interface {
 	UpdatePassword(id uuid.UUID, passwordHash string) error
 	VerifyEmail(id uuid.UUID) error
 }
+
+// DataExportStatus is the progress of a background data export
+type DataExportStatus string
+
+const (
+	DataExportPending DataExportStatus = "pending"
+	DataExportReady   DataExportStatus = "ready"
+	DataExportFailed  DataExportStatus = "failed"
+)
+
+// DataExport is a copy of a user's personal data prepared in the background,
+// downloaded with a link emailed to the user. Only the hash of the link's
+// token is kept.
+type DataExport struct {
+	ID          uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID        `gorm:"type:uuid;index;not null" json:"user_id"`
+	RequestedBy uuid.UUID        `gorm:"type:uuid;not null" json:"requested_by"` // the user, or an admin acting for them
+	Status      DataExportStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	FilePath    string           `gorm:"type:varchar(500)" json:"-"`
+	TokenHash   *string          `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	ExpiresAt   *time.Time       `gorm:"index" json:"expires_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt   time.Time        `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
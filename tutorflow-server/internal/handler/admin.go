@@ -1,11 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/admin"
 )
 
@@ -29,18 +35,36 @@ func (h *AdminHandler) RegisterRoutes(g *echo.Group, authMW, adminMW echo.Middle
 	a.GET("/recent-orders", h.GetRecentOrders)
 	a.GET("/recent-users", h.GetRecentUsers)
 	a.GET("/system-health", h.GetSystemHealth)
+	listRoute(a, "/audit-logs", h.ListAuditLogs)
+}
+
+// RegisterInstructorRoutes registers an instructor's own dashboard routes.
+// They always report on the caller, never on an instructor named in the
+// request.
+func (h *AdminHandler) RegisterInstructorRoutes(g *echo.Group, authMW, tutorMW echo.MiddlewareFunc) {
+	i := g.Group("/instructor", authMW, tutorMW)
+	i.GET("/dashboard", h.GetInstructorDashboard)
+	i.GET("/revenue-chart", h.GetInstructorRevenueChart)
 }
 
 // GetDashboard godoc
 // @Summary Get dashboard statistics
 // @Tags Admin
 // @Security BearerAuth
+// @Param from query string false "Range start (RFC3339)"
+// @Param to query string false "Range end, exclusive (RFC3339)"
+// @Param refresh query bool false "Bypass the stats cache"
 // @Success 200 {object} response.Response{data=admin.DashboardStats}
 // @Router /admin/dashboard [get]
 func (h *AdminHandler) GetDashboard(c echo.Context) error {
-	stats, err := h.adminUC.GetDashboardStats(c.Request().Context())
+	rng, err := parseDateRange(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
+
+	stats, err := h.adminUC.GetDashboardStats(c.Request().Context(), rng, refreshRequested(c))
 	if err != nil {
-		return response.InternalError(c, "Failed to get dashboard stats")
+		return err
 	}
 
 	return response.Success(c, stats)
@@ -51,6 +75,9 @@ func (h *AdminHandler) GetDashboard(c echo.Context) error {
 // @Tags Admin
 // @Security BearerAuth
 // @Param period query string false "Period (week, month, year)"
+// @Param from query string false "Range start (RFC3339)"
+// @Param to query string false "Range end, exclusive (RFC3339)"
+// @Param refresh query bool false "Bypass the stats cache"
 // @Success 200 {object} response.Response{data=admin.RevenueChartData}
 // @Router /admin/revenue-chart [get]
 func (h *AdminHandler) GetRevenueChart(c echo.Context) error {
@@ -58,10 +85,14 @@ func (h *AdminHandler) GetRevenueChart(c echo.Context) error {
 	if period == "" {
 		period = "month"
 	}
+	rng, err := parseDateRange(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
 
-	data, err := h.adminUC.GetRevenueChart(c.Request().Context(), period)
+	data, err := h.adminUC.GetRevenueChart(c.Request().Context(), period, rng, refreshRequested(c))
 	if err != nil {
-		return response.InternalError(c, "Failed to get revenue chart")
+		return err
 	}
 
 	return response.Success(c, data)
@@ -73,6 +104,9 @@ func (h *AdminHandler) GetRevenueChart(c echo.Context) error {
 // @Security BearerAuth
 // @Param limit query int false "Limit (default 10)"
 // @Param sort_by query string false "Sort by (revenue, rating, enrollments)"
+// @Param from query string false "Range start (RFC3339)"
+// @Param to query string false "Range end, exclusive (RFC3339)"
+// @Param refresh query bool false "Bypass the stats cache"
 // @Success 200 {object} response.Response{data=[]admin.TopCourse}
 // @Router /admin/top-courses [get]
 func (h *AdminHandler) GetTopCourses(c echo.Context) error {
@@ -83,10 +117,14 @@ func (h *AdminHandler) GetTopCourses(c echo.Context) error {
 		}
 	}
 	sortBy := c.QueryParam("sort_by")
+	rng, err := parseDateRange(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
 
-	courses, err := h.adminUC.GetTopCourses(c.Request().Context(), limit, sortBy)
+	courses, err := h.adminUC.GetTopCourses(c.Request().Context(), limit, sortBy, rng, refreshRequested(c))
 	if err != nil {
-		return response.InternalError(c, "Failed to get top courses")
+		return err
 	}
 
 	return response.Success(c, courses)
@@ -97,6 +135,7 @@ func (h *AdminHandler) GetTopCourses(c echo.Context) error {
 // @Tags Admin
 // @Security BearerAuth
 // @Param limit query int false "Limit (default 10)"
+// @Param refresh query bool false "Bypass the stats cache"
 // @Success 200 {object} response.Response{data=[]admin.TopInstructor}
 // @Router /admin/top-instructors [get]
 func (h *AdminHandler) GetTopInstructors(c echo.Context) error {
@@ -107,19 +146,64 @@ func (h *AdminHandler) GetTopInstructors(c echo.Context) error {
 		}
 	}
 
-	instructors, err := h.adminUC.GetTopInstructors(c.Request().Context(), limit)
+	instructors, err := h.adminUC.GetTopInstructors(c.Request().Context(), limit, refreshRequested(c))
 	if err != nil {
-		return response.InternalError(c, "Failed to get top instructors")
+		return err
 	}
 
 	return response.Success(c, instructors)
 }
 
+// GetInstructorDashboard godoc
+// @Summary Get the current instructor's course statistics
+// @Tags Instructor
+// @Security BearerAuth
+// @Param refresh query bool false "Bypass the stats cache"
+// @Success 200 {object} response.Response{data=admin.InstructorDashboard}
+// @Router /instructor/dashboard [get]
+func (h *AdminHandler) GetInstructorDashboard(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	stats, err := h.adminUC.GetInstructorDashboard(c.Request().Context(), claims.UserID, refreshRequested(c))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, stats)
+}
+
+// GetInstructorRevenueChart godoc
+// @Summary Get the current instructor's earnings over time
+// @Tags Instructor
+// @Security BearerAuth
+// @Param period query string false "Period (week, month, year)"
+// @Param from query string false "Range start (RFC3339)"
+// @Param to query string false "Range end, exclusive (RFC3339)"
+// @Param refresh query bool false "Bypass the stats cache"
+// @Success 200 {object} response.Response{data=admin.RevenueChartData}
+// @Router /instructor/revenue-chart [get]
+func (h *AdminHandler) GetInstructorRevenueChart(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+	rng, err := parseDateRange(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
+
+	data, err := h.adminUC.GetInstructorRevenueChart(c.Request().Context(), claims.UserID, c.QueryParam("period"), rng, refreshRequested(c))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, data)
+}
+
 // GetRecentOrders godoc
 // @Summary Get recent orders
 // @Tags Admin
 // @Security BearerAuth
 // @Param limit query int false "Limit (default 10)"
+// @Param from query string false "Range start (RFC3339)"
+// @Param to query string false "Range end, exclusive (RFC3339)"
 // @Success 200 {object} response.Response{data=[]admin.RecentOrder}
 // @Router /admin/recent-orders [get]
 func (h *AdminHandler) GetRecentOrders(c echo.Context) error {
@@ -130,9 +214,14 @@ func (h *AdminHandler) GetRecentOrders(c echo.Context) error {
 		}
 	}
 
-	orders, err := h.adminUC.GetRecentOrders(c.Request().Context(), limit)
+	rng, err := parseDateRange(c)
 	if err != nil {
-		return response.InternalError(c, "Failed to get recent orders")
+		return badRequest(c, err)
+	}
+
+	orders, err := h.adminUC.GetRecentOrders(c.Request().Context(), limit, rng)
+	if err != nil {
+		return err
 	}
 
 	return response.Success(c, orders)
@@ -155,7 +244,7 @@ func (h *AdminHandler) GetRecentUsers(c echo.Context) error {
 
 	users, err := h.adminUC.GetRecentUsers(c.Request().Context(), limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get recent users")
+		return err
 	}
 
 	return response.Success(c, users)
@@ -170,8 +259,105 @@ func (h *AdminHandler) GetRecentUsers(c echo.Context) error {
 func (h *AdminHandler) GetSystemHealth(c echo.Context) error {
 	health, err := h.adminUC.GetSystemHealth(c.Request().Context())
 	if err != nil {
-		return response.InternalError(c, "Failed to get system health")
+		return err
 	}
 
 	return response.Success(c, health)
 }
+
+// ListAuditLogs godoc
+// @Summary List audit log entries
+// @Description Administrative changes (role and status changes, coupon creation, refund decisions, course takedowns), newest first
+// @Tags Admin
+// @Security BearerAuth
+// @Param actor_id query string false "Admin who made the change"
+// @Param action query string false "Action, e.g. user.role_change"
+// @Param from query string false "From (RFC3339)"
+// @Param to query string false "To (RFC3339)"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} response.Response{data=[]domain.AuditLog}
+// @Router /admin/audit-logs [get]
+func (h *AdminHandler) ListAuditLogs(c echo.Context) error {
+	filters, err := parseAuditLogFilters(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
+
+	entries, total, err := h.adminUC.ListAuditLogs(c.Request().Context(), filters)
+	if err != nil {
+		return err
+	}
+
+	return response.Paginated(c, entries, filters.Page, filters.Limit, total)
+}
+
+func parseAuditLogFilters(c echo.Context) (repository.AuditLogFilters, error) {
+	filters := repository.AuditLogFilters{}
+
+	if a := c.QueryParam("actor_id"); a != "" {
+		actorID, err := uuid.Parse(a)
+		if err != nil {
+			return filters, errors.New("Invalid actor ID")
+		}
+		filters.ActorID = &actorID
+	}
+	if a := c.QueryParam("action"); a != "" {
+		action := domain.AuditAction(a)
+		filters.Action = &action
+	}
+	if f := c.QueryParam("from"); f != "" {
+		from, err := time.Parse(time.RFC3339, f)
+		if err != nil {
+			return filters, errors.New("Invalid from date")
+		}
+		filters.From = &from
+	}
+	if t := c.QueryParam("to"); t != "" {
+		to, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return filters, errors.New("Invalid to date")
+		}
+		filters.To = &to
+	}
+
+	filters.Page, filters.Limit = ParsePaginationWithin(c, 50, 100)
+
+	return filters, nil
+}
+
+// parseDateRange reads the optional from/to range for analytics. Either end
+// may be left out: to defaults to now and from to 30 days before to.
+func parseDateRange(c echo.Context) (admin.DateRange, error) {
+	var rng admin.DateRange
+	f, t := c.QueryParam("from"), c.QueryParam("to")
+	if f == "" && t == "" {
+		return rng, nil
+	}
+
+	rng.To = time.Now()
+	if t != "" {
+		to, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return rng, errors.New("Invalid to date")
+		}
+		rng.To = to
+	}
+	rng.From = rng.To.AddDate(0, 0, -30)
+	if f != "" {
+		from, err := time.Parse(time.RFC3339, f)
+		if err != nil {
+			return rng, errors.New("Invalid from date")
+		}
+		rng.From = from
+	}
+
+	rng = rng.WholeDays()
+	return rng, rng.Validate()
+}
+
+// refreshRequested reports whether ?refresh=true asks to skip cached stats
+func refreshRequested(c echo.Context) bool {
+	refresh, _ := strconv.ParseBool(c.QueryParam("refresh"))
+	return refresh
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/validator"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/analytics"
+)
+
+// AnalyticsHandler handles analytics event HTTP requests
+type AnalyticsHandler struct {
+	analyticsUC *analytics.UseCase
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(analyticsUC *analytics.UseCase) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsUC: analyticsUC}
+}
+
+// RegisterRoutes registers analytics routes
+func (h *AnalyticsHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuthMW, adminMW, rateLimitMW echo.MiddlewareFunc) {
+	g.POST("/events", h.Track, rateLimitMW, optionalAuthMW)
+
+	admin := g.Group("/admin/events", authMW, adminMW)
+	listRoute(admin, "", h.List)
+	admin.GET("/summary", h.GetSummary)
+}
+
+// Track godoc
+// @Summary Ingest client-side analytics events
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param request body analytics.TrackInput true "Event batch"
+// @Success 201 {object} response.Response{data=map[string]int}
+// @Failure 400 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /events [post]
+func (h *AnalyticsHandler) Track(c echo.Context) error {
+	var input analytics.TrackInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	var userID *uuid.UUID
+	if claims, ok := middleware.GetClaims(c); ok {
+		userID = &claims.UserID
+	}
+
+	accepted, err := h.analyticsUC.Track(c.Request().Context(), userID, input)
+	if err != nil {
+		return err
+	}
+
+	return response.Created(c, map[string]int{"accepted": accepted})
+}
+
+// List godoc
+// @Summary List analytics events
+// @Tags Analytics
+// @Security BearerAuth
+// @Param type query string false "Event type"
+// @Param user_id query string false "User ID"
+// @Param from query string false "From (RFC3339)"
+// @Param to query string false "To (RFC3339)"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} response.Response{data=[]domain.AnalyticsEvent}
+// @Router /admin/events [get]
+func (h *AnalyticsHandler) List(c echo.Context) error {
+	filters, err := parseAnalyticsFilters(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
+
+	events, total, err := h.analyticsUC.ListEvents(c.Request().Context(), filters)
+	if err != nil {
+		return err
+	}
+
+	return response.Paginated(c, events, filters.Page, filters.Limit, total)
+}
+
+// GetSummary godoc
+// @Summary Get analytics event counts by type
+// @Tags Analytics
+// @Security BearerAuth
+// @Param type query string false "Event type"
+// @Param user_id query string false "User ID"
+// @Param from query string false "From (RFC3339)"
+// @Param to query string false "To (RFC3339)"
+// @Success 200 {object} response.Response{data=[]repository.AnalyticsEventCount}
+// @Router /admin/events/summary [get]
+func (h *AnalyticsHandler) GetSummary(c echo.Context) error {
+	filters, err := parseAnalyticsFilters(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
+
+	summary, err := h.analyticsUC.GetSummary(c.Request().Context(), filters)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, summary)
+}
+
+func parseAnalyticsFilters(c echo.Context) (repository.AnalyticsEventFilters, error) {
+	filters := repository.AnalyticsEventFilters{}
+
+	if t := c.QueryParam("type"); t != "" {
+		eventType := domain.AnalyticsEventType(t)
+		filters.Type = &eventType
+	}
+	if u := c.QueryParam("user_id"); u != "" {
+		userID, err := uuid.Parse(u)
+		if err != nil {
+			return filters, errors.New("Invalid user ID")
+		}
+		filters.UserID = &userID
+	}
+	if f := c.QueryParam("from"); f != "" {
+		from, err := time.Parse(time.RFC3339, f)
+		if err != nil {
+			return filters, errors.New("Invalid from date")
+		}
+		filters.From = &from
+	}
+	if t := c.QueryParam("to"); t != "" {
+		to, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return filters, errors.New("Invalid to date")
+		}
+		filters.To = &to
+	}
+
+	filters.Page, filters.Limit = ParsePaginationWithin(c, 50, 100)
+
+	return filters, nil
+}
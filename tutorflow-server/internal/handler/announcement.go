@@ -24,14 +24,15 @@ func NewAnnouncementHandler(announcementUC *announcement.UseCase) *AnnouncementH
 // RegisterRoutes registers announcement routes
 func (h *AnnouncementHandler) RegisterRoutes(g *echo.Group, authMW, tutorMW echo.MiddlewareFunc) {
 	announcements := g.Group("/announcements")
-	announcements.GET("/feed", h.GetMyFeed, authMW)
-	announcements.GET("/my", h.GetMyAnnouncements, authMW, tutorMW)
-	announcements.GET("/global", h.GetGlobalAnnouncements, authMW)
-	announcements.GET("/course/:courseId", h.GetCourseAnnouncements, authMW)
+	listRoute(announcements, "/feed", h.GetMyFeed, authMW)
+	listRoute(announcements, "/my", h.GetMyAnnouncements, authMW, tutorMW)
+	listRoute(announcements, "/global", h.GetGlobalAnnouncements, authMW)
+	listRoute(announcements, "/course/:courseId", h.GetCourseAnnouncements, authMW)
 	announcements.GET("/:id", h.GetAnnouncement, authMW)
 	announcements.POST("", h.CreateAnnouncement, authMW, tutorMW)
 	announcements.PUT("/:id", h.UpdateAnnouncement, authMW, tutorMW)
 	announcements.DELETE("/:id", h.DeleteAnnouncement, authMW, tutorMW)
+	announcements.POST("/:id/cancel", h.CancelAnnouncement, authMW, tutorMW)
 	announcements.POST("/:id/pin", h.PinAnnouncement, authMW, tutorMW)
 	announcements.DELETE("/:id/pin", h.UnpinAnnouncement, authMW, tutorMW)
 }
@@ -40,15 +41,17 @@ func (h *AnnouncementHandler) RegisterRoutes(g *echo.Group, authMW, tutorMW echo
 // @Summary Get my announcements feed
 // @Tags Announcements
 // @Security BearerAuth
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /announcements/feed [get]
 func (h *AnnouncementHandler) GetMyFeed(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	page, limit := 1, 20
+	page, limit := ParsePagination(c)
 	announcements, total, err := h.announcementUC.GetMyFeed(c.Request().Context(), claims.UserID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get announcements")
+		return err
 	}
 
 	return response.Paginated(c, announcements, page, limit, total)
@@ -58,15 +61,17 @@ func (h *AnnouncementHandler) GetMyFeed(c echo.Context) error {
 // @Summary Get announcements I created
 // @Tags Announcements
 // @Security BearerAuth
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /announcements/my [get]
 func (h *AnnouncementHandler) GetMyAnnouncements(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	page, limit := 1, 20
+	page, limit := ParsePagination(c)
 	announcements, total, err := h.announcementUC.GetMyAnnouncements(c.Request().Context(), claims.UserID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get announcements")
+		return err
 	}
 
 	return response.Paginated(c, announcements, page, limit, total)
@@ -76,13 +81,15 @@ func (h *AnnouncementHandler) GetMyAnnouncements(c echo.Context) error {
 // @Summary Get global announcements
 // @Tags Announcements
 // @Security BearerAuth
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /announcements/global [get]
 func (h *AnnouncementHandler) GetGlobalAnnouncements(c echo.Context) error {
-	page, limit := 1, 20
+	page, limit := ParsePagination(c)
 	announcements, total, err := h.announcementUC.GetGlobalAnnouncements(c.Request().Context(), page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get announcements")
+		return err
 	}
 
 	return response.Paginated(c, announcements, page, limit, total)
@@ -93,6 +100,8 @@ func (h *AnnouncementHandler) GetGlobalAnnouncements(c echo.Context) error {
 // @Tags Announcements
 // @Security BearerAuth
 // @Param courseId path string true "Course ID"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /announcements/course/{courseId} [get]
 func (h *AnnouncementHandler) GetCourseAnnouncements(c echo.Context) error {
@@ -101,10 +110,10 @@ func (h *AnnouncementHandler) GetCourseAnnouncements(c echo.Context) error {
 		return response.BadRequest(c, "Invalid course ID")
 	}
 
-	page, limit := 1, 20
+	page, limit := ParsePagination(c)
 	announcements, total, err := h.announcementUC.GetCourseAnnouncements(c.Request().Context(), courseID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get announcements")
+		return err
 	}
 
 	return response.Paginated(c, announcements, page, limit, total)
@@ -123,7 +132,8 @@ func (h *AnnouncementHandler) GetAnnouncement(c echo.Context) error {
 		return response.BadRequest(c, "Invalid announcement ID")
 	}
 
-	ann, err := h.announcementUC.GetAnnouncement(c.Request().Context(), id)
+	claims, _ := middleware.GetClaims(c)
+	ann, err := h.announcementUC.GetAnnouncement(c.Request().Context(), id, claims.UserID, claims.Role == domain.RoleAdmin)
 	if err != nil || ann == nil {
 		return response.NotFound(c, "Announcement not found")
 	}
@@ -154,7 +164,7 @@ func (h *AnnouncementHandler) CreateAnnouncement(c echo.Context) error {
 
 	ann, err := h.announcementUC.CreateAnnouncement(c.Request().Context(), claims.UserID, isAdmin, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, ann)
@@ -185,7 +195,7 @@ func (h *AnnouncementHandler) UpdateAnnouncement(c echo.Context) error {
 
 	ann, err := h.announcementUC.UpdateAnnouncement(c.Request().Context(), id, claims.UserID, isAdmin, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, ann)
@@ -208,7 +218,31 @@ func (h *AnnouncementHandler) DeleteAnnouncement(c echo.Context) error {
 	isAdmin := claims.Role == domain.RoleAdmin
 
 	if err := h.announcementUC.DeleteAnnouncement(c.Request().Context(), id, claims.UserID, isAdmin); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
+	}
+
+	return response.NoContent(c)
+}
+
+// CancelAnnouncement godoc
+// @Summary Cancel a scheduled announcement
+// @Description Deletes the announcement if it has not been published yet
+// @Tags Announcements
+// @Security BearerAuth
+// @Param id path string true "Announcement ID"
+// @Success 204
+// @Router /announcements/{id}/cancel [post]
+func (h *AnnouncementHandler) CancelAnnouncement(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid announcement ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	isAdmin := claims.Role == domain.RoleAdmin
+
+	if err := h.announcementUC.CancelScheduled(c.Request().Context(), id, claims.UserID, isAdmin); err != nil {
+		return badRequest(c, err)
 	}
 
 	return response.NoContent(c)
@@ -231,7 +265,7 @@ func (h *AnnouncementHandler) PinAnnouncement(c echo.Context) error {
 	isAdmin := claims.Role == domain.RoleAdmin
 
 	if err := h.announcementUC.PinAnnouncement(c.Request().Context(), id, claims.UserID, isAdmin, true); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Announcement pinned", nil)
@@ -254,7 +288,7 @@ func (h *AnnouncementHandler) UnpinAnnouncement(c echo.Context) error {
 	isAdmin := claims.Role == domain.RoleAdmin
 
 	if err := h.announcementUC.PinAnnouncement(c.Request().Context(), id, claims.UserID, isAdmin, false); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Announcement unpinned", nil)
@@ -1,10 +1,18 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/validator"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/auth"
@@ -12,22 +20,40 @@ import (
 
 // AuthHandler handles auth-related HTTP requests
 type AuthHandler struct {
-	authUC *auth.UseCase
+	authUC           *auth.UseCase
+	oauthCallbackURL string
 }
 
-// NewAuthHandler creates a new auth handler
-func NewAuthHandler(authUC *auth.UseCase) *AuthHandler {
-	return &AuthHandler{authUC: authUC}
+// NewAuthHandler creates a new auth handler. OAuth sign-ins finish by
+// redirecting to oauthCallbackURL.
+func NewAuthHandler(authUC *auth.UseCase, oauthCallbackURL string) *AuthHandler {
+	return &AuthHandler{authUC: authUC, oauthCallbackURL: oauthCallbackURL}
 }
 
-// RegisterRoutes registers auth routes
-func (h *AuthHandler) RegisterRoutes(g *echo.Group, authMiddleware echo.MiddlewareFunc) {
-	g.POST("/register", h.Register)
-	g.POST("/login", h.Login)
+// RegisterRoutes registers auth routes. rateLimitMW guards the endpoints that
+// take passwords or send email.
+func (h *AuthHandler) RegisterRoutes(g *echo.Group, authMiddleware, rateLimitMW echo.MiddlewareFunc) {
+	g.POST("/register", h.Register, rateLimitMW)
+	g.POST("/login", h.Login, rateLimitMW)
 	g.POST("/refresh", h.Refresh)
+	g.POST("/introspect", h.Introspect)
 	g.POST("/logout", h.Logout, authMiddleware)
 	g.GET("/me", h.Me, authMiddleware)
-	g.PUT("/password", h.ChangePassword, authMiddleware)
+	g.PUT("/password", h.ChangePassword, authMiddleware, rateLimitMW)
+	g.POST("/forgot-password", h.ForgotPassword, rateLimitMW)
+	g.POST("/reset-password", h.ResetPassword, rateLimitMW)
+	g.GET("/verify-email", h.VerifyEmail)
+	g.POST("/resend-verification", h.ResendVerification, authMiddleware, rateLimitMW)
+	g.GET("/sessions", h.ListSessions, authMiddleware)
+	g.DELETE("/sessions/:id", h.RevokeSession, authMiddleware)
+	g.POST("/sessions/revoke-all", h.RevokeAllSessions, authMiddleware)
+	g.GET("/oauth/:provider", h.OAuthStart, rateLimitMW)
+	g.GET("/oauth/:provider/callback", h.OAuthCallback, rateLimitMW)
+}
+
+// clientOf describes the device making the request
+func clientOf(c echo.Context) auth.Client {
+	return auth.Client{IPAddress: c.RealIP(), UserAgent: c.Request().UserAgent()}
 }
 
 // Register godoc
@@ -45,6 +71,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 	if err := c.Bind(&input); err != nil {
 		return response.BadRequest(c, "Invalid request body")
 	}
+	input.Client = clientOf(c)
 
 	if err := validator.Validate(input); err != nil {
 		return validator.FormatValidationErrors(err)
@@ -67,12 +94,14 @@ func (h *AuthHandler) Register(c echo.Context) error {
 // @Success 200 {object} response.Response{data=auth.LoginOutput}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response "Account locked after repeated failed logins"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c echo.Context) error {
 	var input auth.LoginInput
 	if err := c.Bind(&input); err != nil {
 		return response.BadRequest(c, "Invalid request body")
 	}
+	input.Client = clientOf(c)
 
 	if err := validator.Validate(input); err != nil {
 		return validator.FormatValidationErrors(err)
@@ -100,6 +129,7 @@ func (h *AuthHandler) Refresh(c echo.Context) error {
 	if err := c.Bind(&input); err != nil {
 		return response.BadRequest(c, "Invalid request body")
 	}
+	input.Client = clientOf(c)
 
 	if err := validator.Validate(input); err != nil {
 		return validator.FormatValidationErrors(err)
@@ -113,6 +143,34 @@ func (h *AuthHandler) Refresh(c echo.Context) error {
 	return response.Success(c, tokens)
 }
 
+// Introspect godoc
+// @Summary Introspect a token
+// @Description Returns whether the token is active along with its user, role and remaining lifetime
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.IntrospectInput true "Token to introspect"
+// @Success 200 {object} response.Response{data=auth.IntrospectOutput}
+// @Failure 400 {object} response.Response
+// @Router /auth/introspect [post]
+func (h *AuthHandler) Introspect(c echo.Context) error {
+	var input auth.IntrospectInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	output, err := h.authUC.Introspect(c.Request().Context(), input)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, output)
+}
+
 // Logout godoc
 // @Summary Logout user
 // @Tags Auth
@@ -133,6 +191,172 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 	return response.NoContent(c)
 }
 
+// ForgotPassword godoc
+// @Summary Request a password reset link
+// @Description Emails a link valid for one hour. Succeeds whether or not the email has an account.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ForgotPasswordInput true "Account email"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	var input auth.ForgotPasswordInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	if err := h.authUC.ForgotPassword(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "If that email has an account, a reset link is on its way", nil)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Sets a new password using the token from the reset email and signs out every session
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ResetPasswordInput true "Reset token and new password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	var input auth.ResetPasswordInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	if err := h.authUC.ResetPassword(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "Password reset successfully", nil)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirms the address using the token from the verification email
+// @Tags Auth
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /auth/verify-email [get]
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return response.BadRequest(c, "Missing verification token")
+	}
+
+	if err := h.authUC.VerifyEmail(c.Request().Context(), token); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "Email verified", nil)
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c echo.Context) error {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	if err := h.authUC.ResendVerification(c.Request().Context(), claims.UserID); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "Verification email sent", nil)
+}
+
+// ListSessions godoc
+// @Summary List signed-in sessions
+// @Description Each device signed in to the account, most recently used first
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.Response{data=[]domain.RefreshToken}
+// @Router /auth/sessions [get]
+func (h *AuthHandler) ListSessions(c echo.Context) error {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	sessions, err := h.authUC.ListSessions(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Sign a session out
+// @Description The device can keep using its current access token until it expires, but can't refresh it
+// @Tags Auth
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 204
+// @Failure 404 {object} response.Response
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c echo.Context) error {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid session ID")
+	}
+
+	if err := h.authUC.RevokeSession(c.Request().Context(), claims.UserID, id); err != nil {
+		return err
+	}
+
+	return response.NoContent(c)
+}
+
+// RevokeAllSessions godoc
+// @Summary Sign every session out
+// @Tags Auth
+// @Security BearerAuth
+// @Success 204
+// @Router /auth/sessions/revoke-all [post]
+func (h *AuthHandler) RevokeAllSessions(c echo.Context) error {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	if err := h.authUC.LogoutAll(c.Request().Context(), claims.UserID); err != nil {
+		return err
+	}
+
+	return response.NoContent(c)
+}
+
 // Me godoc
 // @Summary Get current user
 // @Tags Auth
@@ -188,3 +412,95 @@ func (h *AuthHandler) ChangePassword(c echo.Context) error {
 
 	return response.SuccessWithMessage(c, "Password changed successfully", nil)
 }
+
+// oauthStateCookie holds the state sent to the provider, so the callback can
+// check it came from a sign-in this browser started
+const oauthStateCookie = "oauth_state"
+
+// oauthStateTTL is how long the user has to finish signing in at the provider
+const oauthStateTTL = 10 * time.Minute
+
+// OAuthStart godoc
+// @Summary Sign in with a provider
+// @Description Redirects to the provider's consent page
+// @Tags Auth
+// @Param provider path string true "Provider" Enums(google)
+// @Success 302
+// @Failure 404 {object} response.Response
+// @Router /auth/oauth/{provider} [get]
+func (h *AuthHandler) OAuthStart(c echo.Context) error {
+	state, err := hash.GenerateRandomToken(16)
+	if err != nil {
+		return err
+	}
+
+	redirectURL, err := h.authUC.OAuthURL(domain.AuthProvider(c.Param("provider")), state)
+	if err != nil {
+		return err
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(oauthStateTTL.Seconds()),
+	})
+
+	return c.Redirect(http.StatusFound, redirectURL)
+}
+
+// OAuthCallback godoc
+// @Summary Finish signing in with a provider
+// @Description The provider redirects here. Redirects on to the client's OAuth callback page with
+// @Description access_token, refresh_token and expires_at in the URL fragment, or ?error=<code> on failure.
+// @Tags Auth
+// @Param provider path string true "Provider" Enums(google)
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 302
+// @Router /auth/oauth/{provider}/callback [get]
+func (h *AuthHandler) OAuthCallback(c echo.Context) error {
+	cookie, err := c.Cookie(oauthStateCookie)
+	c.SetCookie(&http.Cookie{Name: oauthStateCookie, Path: "/", HttpOnly: true, MaxAge: -1})
+
+	switch {
+	case c.QueryParam("error") != "":
+		// The user declined, or the provider refused
+		return h.oauthFailed(c, "ACCESS_DENIED")
+	case err != nil || cookie.Value == "" || cookie.Value != c.QueryParam("state"):
+		return h.oauthFailed(c, "INVALID_STATE")
+	case c.QueryParam("code") == "":
+		return h.oauthFailed(c, "INVALID_CODE")
+	}
+
+	output, err := h.authUC.OAuthLogin(c.Request().Context(), domain.AuthProvider(c.Param("provider")), c.QueryParam("code"), clientOf(c))
+	switch {
+	case err == nil:
+	case errors.Is(err, domain.ErrProviderEmailUnverified):
+		return h.oauthFailed(c, "PROVIDER_EMAIL_UNVERIFIED")
+	case errors.Is(err, domain.ErrAccountSuspended):
+		return h.oauthFailed(c, "USER_SUSPENDED")
+	case errors.Is(err, domain.ErrUserInactive):
+		return h.oauthFailed(c, "USER_INACTIVE")
+	case errors.Is(err, domain.ErrUnknownAuthProvider):
+		return err
+	default:
+		c.Logger().Errorf("oauth sign-in failed: %v", err)
+		return h.oauthFailed(c, "OAUTH_FAILED")
+	}
+
+	// Tokens go in the fragment so they never reach server logs
+	fragment := url.Values{}
+	fragment.Set("access_token", output.Tokens.AccessToken)
+	fragment.Set("refresh_token", output.Tokens.RefreshToken)
+	fragment.Set("expires_at", strconv.FormatInt(output.Tokens.ExpiresAt.Unix(), 10))
+	return c.Redirect(http.StatusFound, h.oauthCallbackURL+"#"+fragment.Encode())
+}
+
+// oauthFailed sends the browser back to the client with an error code
+func (h *AuthHandler) oauthFailed(c echo.Context, code string) error {
+	return c.Redirect(http.StatusFound, h.oauthCallbackURL+"?error="+url.QueryEscape(code))
+}
@@ -2,9 +2,9 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -13,15 +13,16 @@ import (
 // BundleHandler handles bundle-related HTTP requests
 type BundleHandler struct {
 	bundleUC domain.BundleUseCase
+	orderUC  domain.OrderUseCase
 }
 
 // NewBundleHandler creates a new bundle handler
-func NewBundleHandler(uc domain.BundleUseCase) *BundleHandler {
-	return &BundleHandler{bundleUC: uc}
+func NewBundleHandler(uc domain.BundleUseCase, orderUC domain.OrderUseCase) *BundleHandler {
+	return &BundleHandler{bundleUC: uc, orderUC: orderUC}
 }
 
 // RegisterRoutes registers bundle routes
-func (h *BundleHandler) RegisterRoutes(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+func (h *BundleHandler) RegisterRoutes(e *echo.Group, authMiddleware, adminMiddleware echo.MiddlewareFunc) {
 	// Public routes
 	bundles := e.Group("/bundles")
 	bundles.GET("", h.GetActiveBundles)
@@ -29,11 +30,11 @@ func (h *BundleHandler) RegisterRoutes(e *echo.Group, authMiddleware echo.Middle
 
 	// Authenticated routes
 	auth := e.Group("/bundles", authMiddleware)
-	auth.POST("/:id/purchase", h.PurchaseBundle)
+	auth.POST("/:id/checkout", h.Checkout)
 	auth.GET("/my", h.GetMyBundles)
 
 	// Admin routes
-	admin := e.Group("/admin/bundles", authMiddleware)
+	admin := e.Group("/admin/bundles", authMiddleware, adminMiddleware)
 	admin.POST("", h.CreateBundle)
 	admin.PUT("/:id", h.UpdateBundle)
 	admin.DELETE("/:id", h.DeleteBundle)
@@ -43,19 +44,7 @@ func (h *BundleHandler) RegisterRoutes(e *echo.Group, authMiddleware echo.Middle
 
 // GetActiveBundles returns all active bundles
 func (h *BundleHandler) GetActiveBundles(c echo.Context) error {
-	page := 1
-	limit := 20
-
-	if p := c.QueryParam("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
-	}
-	if l := c.QueryParam("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
-			limit = parsed
-		}
-	}
+	page, limit := ParsePagination(c)
 
 	bundles, total, err := h.bundleUC.GetActiveBundles(c.Request().Context(), page, limit)
 	if err != nil {
@@ -101,6 +90,7 @@ type CreateBundleRequest struct {
 	Description     string      `json:"description"`
 	ThumbnailURL    string      `json:"thumbnail_url"`
 	DiscountPercent float64     `json:"discount_percent"`
+	DeductOwned     bool        `json:"deduct_owned_courses"`
 	CourseIDs       []uuid.UUID `json:"course_ids"`
 	StartDate       *string     `json:"start_date"`
 	EndDate         *string     `json:"end_date"`
@@ -120,14 +110,15 @@ func (h *BundleHandler) CreateBundle(c echo.Context) error {
 	}
 
 	bundle := &domain.Bundle{
-		Title:           req.Title,
-		Slug:            req.Slug,
-		Description:     req.Description,
-		ThumbnailURL:    req.ThumbnailURL,
-		DiscountPercent: req.DiscountPercent,
-		MaxPurchases:    req.MaxPurchases,
-		CreatedBy:       userID,
-		IsActive:        true,
+		Title:              req.Title,
+		Slug:               req.Slug,
+		Description:        req.Description,
+		ThumbnailURL:       req.ThumbnailURL,
+		DiscountPercent:    req.DiscountPercent,
+		MaxPurchases:       req.MaxPurchases,
+		DeductOwnedCourses: req.DeductOwned,
+		CreatedBy:          userID,
+		IsActive:           true,
 	}
 
 	created, err := h.bundleUC.CreateBundle(c.Request().Context(), bundle, req.CourseIDs)
@@ -266,8 +257,9 @@ func (h *BundleHandler) RemoveCourse(c echo.Context) error {
 	})
 }
 
-// PurchaseBundle purchases a bundle
-func (h *BundleHandler) PurchaseBundle(c echo.Context) error {
+// Checkout starts a Stripe Checkout for a bundle. Completing payment enrolls
+// the user in every course in it they don't already own.
+func (h *BundleHandler) Checkout(c echo.Context) error {
 	bundleID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{
@@ -276,19 +268,16 @@ func (h *BundleHandler) PurchaseBundle(c echo.Context) error {
 		})
 	}
 
-	userID := getUserIDFromContext(c)
+	claims, _ := middleware.GetClaims(c)
 
-	purchase, err := h.bundleUC.PurchaseBundle(c.Request().Context(), userID, bundleID)
+	output, err := h.orderUC.CreateBundleCheckout(c.Request().Context(), claims.UserID, claims.Email, bundleID)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   map[string]string{"message": err.Error()},
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, map[string]interface{}{
 		"success": true,
-		"data":    purchase,
+		"data":    output,
 	})
 }
 
@@ -35,7 +35,7 @@ func (h *CartHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuthMW echo.
 
 	// Wishlist routes (auth required)
 	wishlistGroup := g.Group("/wishlist", authMW)
-	wishlistGroup.GET("", h.GetWishlist)
+	listRoute(wishlistGroup, "", h.GetWishlist)
 	wishlistGroup.POST("/:courseId", h.AddToWishlist)
 	wishlistGroup.DELETE("/:courseId", h.RemoveFromWishlist)
 	wishlistGroup.GET("/:courseId/check", h.IsInWishlist)
@@ -53,7 +53,7 @@ func (h *CartHandler) GetCart(c echo.Context) error {
 
 	cart, err := h.cartUC.GetCart(c.Request().Context(), userID, sessionID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get cart")
+		return err
 	}
 
 	return response.Success(c, cart)
@@ -70,7 +70,7 @@ func (h *CartHandler) GetCartSummary(c echo.Context) error {
 
 	summary, err := h.cartUC.GetCartSummary(c.Request().Context(), userID, sessionID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get cart summary")
+		return err
 	}
 
 	return response.Success(c, summary)
@@ -98,7 +98,7 @@ func (h *CartHandler) AddToCart(c echo.Context) error {
 
 	updatedCart, err := h.cartUC.AddToCart(c.Request().Context(), userID, sessionID, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, updatedCart)
@@ -120,7 +120,7 @@ func (h *CartHandler) RemoveFromCart(c echo.Context) error {
 
 	updatedCart, err := h.cartUC.RemoveFromCart(c.Request().Context(), userID, sessionID, courseID)
 	if err != nil {
-		return response.InternalError(c, "Failed to remove from cart")
+		return err
 	}
 
 	return response.Success(c, updatedCart)
@@ -135,7 +135,7 @@ func (h *CartHandler) ClearCart(c echo.Context) error {
 	userID, sessionID := h.getCartIdentifiers(c)
 
 	if err := h.cartUC.ClearCart(c.Request().Context(), userID, sessionID); err != nil {
-		return response.InternalError(c, "Failed to clear cart")
+		return err
 	}
 
 	return response.NoContent(c)
@@ -164,7 +164,7 @@ func (h *CartHandler) MergeCart(c echo.Context) error {
 	}
 
 	if err := h.cartUC.MergeGuestCart(c.Request().Context(), input.SessionID, claims.UserID); err != nil {
-		return response.InternalError(c, "Failed to merge cart")
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "Cart merged successfully", nil)
@@ -213,17 +213,20 @@ func (h *CartHandler) getCartIdentifiers(c echo.Context) (*uuid.UUID, *string) {
 // @Tags Wishlist
 // @Security BearerAuth
 // @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /wishlist [get]
 func (h *CartHandler) GetWishlist(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	items, total, err := h.cartUC.GetWishlist(c.Request().Context(), claims.UserID, 1, 50)
+	page, limit := ParsePagination(c)
+	items, total, err := h.cartUC.GetWishlist(c.Request().Context(), claims.UserID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get wishlist")
+		return err
 	}
 
-	return response.Paginated(c, items, 1, 50, total)
+	return response.Paginated(c, items, page, limit, total)
 }
 
 // AddToWishlist godoc
@@ -242,7 +245,7 @@ func (h *CartHandler) AddToWishlist(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.cartUC.AddToWishlist(c.Request().Context(), claims.UserID, courseID); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Added to wishlist", nil)
@@ -264,7 +267,7 @@ func (h *CartHandler) RemoveFromWishlist(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.cartUC.RemoveFromWishlist(c.Request().Context(), claims.UserID, courseID); err != nil {
-		return response.InternalError(c, "Failed to remove from wishlist")
+		return err
 	}
 
 	return response.NoContent(c)
@@ -287,7 +290,7 @@ func (h *CartHandler) IsInWishlist(c echo.Context) error {
 
 	exists, err := h.cartUC.IsInWishlist(c.Request().Context(), claims.UserID, courseID)
 	if err != nil {
-		return response.InternalError(c, "Failed to check wishlist")
+		return err
 	}
 
 	return response.Success(c, map[string]bool{"in_wishlist": exists})
@@ -309,7 +312,7 @@ func (h *CartHandler) MoveToCart(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.cartUC.MoveToCart(c.Request().Context(), claims.UserID, courseID); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Moved to cart", nil)
@@ -6,6 +6,7 @@ import (
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/middleware"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/validator"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/certificate"
 )
 
@@ -22,10 +23,11 @@ func NewCertificateHandler(certUC *certificate.UseCase) *CertificateHandler {
 }
 
 // RegisterRoutes registers certificate routes
-func (h *CertificateHandler) RegisterRoutes(g *echo.Group, authMW echo.MiddlewareFunc) {
+func (h *CertificateHandler) RegisterRoutes(g *echo.Group, authMW, rateLimitMW echo.MiddlewareFunc) {
 	certs := g.Group("/certificates")
 	certs.GET("/verify/:number", h.VerifyCertificate) // Public endpoint
-	certs.GET("/my", h.GetMyCertificates, authMW)
+	certs.POST("/verify-batch", h.VerifyCertificates, rateLimitMW)
+	listRoute(certs, "/my", h.GetMyCertificates, authMW)
 	certs.GET("/:id", h.GetCertificate, authMW)
 	certs.POST("/request/:courseId", h.RequestCertificate, authMW)
 	certs.GET("/:id/data", h.GetCertificateData, authMW)
@@ -51,19 +53,49 @@ func (h *CertificateHandler) VerifyCertificate(c echo.Context) error {
 	return response.Success(c, verification)
 }
 
+// VerifyCertificates godoc
+// @Summary Verify up to 100 certificates at once (public)
+// @Tags Certificates
+// @Accept json
+// @Produce json
+// @Param request body certificate.VerifyBatchInput true "Certificate numbers"
+// @Success 200 {object} response.Response{data=[]certificate.CertificateVerification}
+// @Failure 400 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /certificates/verify-batch [post]
+func (h *CertificateHandler) VerifyCertificates(c echo.Context) error {
+	var input certificate.VerifyBatchInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	results, err := h.certUC.VerifyCertificates(c.Request().Context(), input.CertificateNumbers)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, results)
+}
+
 // GetMyCertificates godoc
 // @Summary Get my certificates
 // @Tags Certificates
 // @Security BearerAuth
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /certificates/my [get]
 func (h *CertificateHandler) GetMyCertificates(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	page, limit := 1, 20
+	page, limit := ParsePagination(c)
 	certs, total, err := h.certUC.GetMyCertificates(c.Request().Context(), claims.UserID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get certificates")
+		return err
 	}
 
 	return response.Paginated(c, certs, page, limit, total)
@@ -107,7 +139,7 @@ func (h *CertificateHandler) RequestCertificate(c echo.Context) error {
 
 	cert, err := h.certUC.RequestCertificate(c.Request().Context(), claims.UserID, courseID)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, cert)
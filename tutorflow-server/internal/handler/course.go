@@ -1,13 +1,9 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"io"
 	"mime/multipart"
-	"os"
-	"path/filepath"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -17,25 +13,32 @@ import (
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/validator"
+	"github.com/tutorflow/tutorflow-server/internal/service/storage"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/enrollment"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/reports"
 )
 
 // CourseHandler handles course-related HTTP requests
 type CourseHandler struct {
-	courseUC *course.UseCase
+	courseUC     *course.UseCase
+	reportUC     *reports.UseCase
+	enrollmentUC *enrollment.UseCase
+	storageSvc   *storage.Service
 }
 
 // NewCourseHandler creates a new course handler
-func NewCourseHandler(courseUC *course.UseCase) *CourseHandler {
-	return &CourseHandler{courseUC: courseUC}
+func NewCourseHandler(courseUC *course.UseCase, reportUC *reports.UseCase, enrollmentUC *enrollment.UseCase, storageSvc *storage.Service) *CourseHandler {
+	return &CourseHandler{courseUC: courseUC, reportUC: reportUC, enrollmentUC: enrollmentUC, storageSvc: storageSvc}
 }
 
 // RegisterRoutes registers course routes
 func (h *CourseHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuthMW, tutorMW, adminMW echo.MiddlewareFunc) {
 	// Public routes
-	g.GET("", h.List, optionalAuthMW)
+	listRoute(g, "", h.List, optionalAuthMW)
 	g.GET("/:idOrSlug", h.Get, optionalAuthMW)
 	g.GET("/:id/curriculum", h.GetCurriculum, optionalAuthMW)
 
@@ -43,9 +46,21 @@ func (h *CourseHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuthMW, tu
 	g.POST("", h.Create, authMW, tutorMW)
 	g.PUT("/:id", h.Update, authMW, tutorMW)
 	g.DELETE("/:id", h.Delete, authMW, tutorMW)
+	g.GET("/:id/publish-checklist", h.GetPublishChecklist, authMW, tutorMW)
 	g.PATCH("/:id/publish", h.Publish, authMW, tutorMW)
 	g.PATCH("/:id/archive", h.Archive, authMW, tutorMW)
-	g.GET("/my", h.MyCourses, authMW, tutorMW)
+	g.GET("/:id/enrollment-code", h.GetEnrollmentCode, authMW, tutorMW)
+	g.POST("/:id/enrollment-code", h.RotateEnrollmentCode, authMW, tutorMW)
+	g.DELETE("/:id/enrollment-code", h.DisableEnrollmentCode, authMW, tutorMW)
+	g.GET("/:id/instructors", h.GetCoInstructors, authMW, tutorMW)
+	g.PUT("/:id/instructors", h.SetCoInstructors, authMW, tutorMW)
+	g.GET("/:id/wishlist-stats", h.GetWishlistStats, authMW, tutorMW)
+	g.GET("/:courseId/funnel", h.GetCourseFunnel, authMW, tutorMW)
+	g.GET("/:courseId/at-risk", h.GetAtRiskStudents, authMW, tutorMW)
+	g.POST("/:courseId/at-risk/nudge", h.NudgeAtRiskStudents, authMW, tutorMW)
+	listRoute(g, "/my", h.MyCourses, authMW, tutorMW)
+	listRoute(g, "/trash", h.ListTrash, authMW, tutorMW)
+	g.POST("/:id/restore", h.Restore, authMW, tutorMW)
 
 	// Module routes
 	modules := g.Group("/:courseId/modules", authMW, tutorMW)
@@ -54,6 +69,7 @@ func (h *CourseHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuthMW, tu
 	modules.PUT("/:moduleId", h.UpdateModule)
 	modules.DELETE("/:moduleId", h.DeleteModule)
 	modules.PATCH("/reorder", h.ReorderModules)
+	modules.POST("/:moduleId/lessons/bulk", h.BulkCreateLessons)
 
 	// Lesson routes
 	lessons := g.Group("/modules/:moduleId/lessons", authMW, tutorMW)
@@ -62,11 +78,14 @@ func (h *CourseHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuthMW, tu
 	lessons.GET("/:lessonId", h.GetLesson)
 	lessons.PUT("/:lessonId", h.UpdateLesson)
 	lessons.DELETE("/:lessonId", h.DeleteLesson)
+	lessons.PATCH("/reorder", h.ReorderLessons)
+	g.GET("/lessons/:lessonId/siblings", h.GetAdjacentLessons, optionalAuthMW)
 
 	// Category routes
 	categories := g.Group("/categories")
 	categories.GET("", h.ListCategories)
 	categories.POST("", h.CreateCategory, authMW, adminMW)
+	categories.PATCH("/reorder", h.ReorderCategories, authMW, adminMW)
 	categories.PUT("/:id", h.UpdateCategory, authMW, adminMW)
 	categories.DELETE("/:id", h.DeleteCategory, authMW, adminMW)
 }
@@ -83,6 +102,7 @@ func (h *CourseHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuthMW, tu
 // @Param sort_by query string false "Sort by: created_at, price, rating, students"
 // @Param page query int false "Page number"
 // @Param limit query int false "Items per page"
+// @Param cursor query string false "Page by cursor instead of offset: empty for the first page, then meta.next_cursor"
 // @Success 200 {object} response.Response
 // @Router /courses [get]
 func (h *CourseHandler) List(c echo.Context) error {
@@ -90,6 +110,19 @@ func (h *CourseHandler) List(c echo.Context) error {
 	if err := c.Bind(&input); err != nil {
 		return response.BadRequest(c, "Invalid query parameters")
 	}
+	input.Page, input.Limit = ParsePagination(c)
+
+	cursor, err := pagination.FromRequest(c)
+	if err != nil {
+		return err
+	}
+	if cursor != nil {
+		courses, next, err := h.courseUC.ListAfter(c.Request().Context(), input, true, *cursor)
+		if err != nil {
+			return err
+		}
+		return response.CursorPaginated(c, courses, next)
+	}
 
 	courses, total, err := h.courseUC.List(c.Request().Context(), input, true)
 	if err != nil {
@@ -104,7 +137,9 @@ func (h *CourseHandler) List(c echo.Context) error {
 // @Tags Courses
 // @Produce json
 // @Param idOrSlug path string true "Course ID or slug"
+// @Param If-None-Match header string false "ETag from an earlier response"
 // @Success 200 {object} response.Response{data=domain.Course}
+// @Success 304 "Not modified since the ETag given"
 // @Router /courses/{idOrSlug} [get]
 func (h *CourseHandler) Get(c echo.Context) error {
 	idOrSlug := c.Param("idOrSlug")
@@ -131,6 +166,23 @@ func (h *CourseHandler) Get(c echo.Context) error {
 		}
 	}
 
+	// Social proof for the course page; the page still renders without it
+	if count, err := h.courseUC.GetWishlistCount(c.Request().Context(), crs.ID); err == nil {
+		crs.WishlistCount = &count
+	}
+
+	if claims, ok := middleware.GetClaims(c); ok {
+		// Record outside the request so a slow history write never delays the page
+		ctx := context.WithoutCancel(c.Request().Context())
+		userID, courseID := claims.UserID, crs.ID
+		go func() {
+			_ = h.reportUC.RecordView(ctx, userID, courseID)
+		}()
+	}
+
+	if response.Fresh(c, courseETag(crs)) {
+		return response.NotModified(c)
+	}
 	return response.Success(c, crs)
 }
 
@@ -139,7 +191,9 @@ func (h *CourseHandler) Get(c echo.Context) error {
 // @Tags Courses
 // @Produce json
 // @Param id path string true "Course ID"
+// @Param If-None-Match header string false "ETag from an earlier response"
 // @Success 200 {object} response.Response
+// @Success 304 "Not modified since the ETag given"
 // @Router /courses/{id}/curriculum [get]
 func (h *CourseHandler) GetCurriculum(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
@@ -152,9 +206,38 @@ func (h *CourseHandler) GetCurriculum(c echo.Context) error {
 		return err
 	}
 
+	if response.Fresh(c, response.ETag(curriculumVersion(modules)...)) {
+		return response.NotModified(c)
+	}
 	return response.Success(c, modules)
 }
 
+// courseETag versions a course page by the course's own update time, the
+// stats shown with it and its curriculum
+func courseETag(crs *domain.Course) string {
+	parts := []interface{}{crs.ID, crs.UpdatedAt.UnixNano(), crs.Status, crs.TotalLessons, crs.TotalStudents, crs.Rating, crs.TotalReviews}
+	if crs.WishlistCount != nil {
+		parts = append(parts, *crs.WishlistCount)
+	}
+	if crs.Instructor != nil {
+		parts = append(parts, crs.Instructor.UpdatedAt.UnixNano())
+	}
+	return response.ETag(append(parts, curriculumVersion(crs.Modules)...)...)
+}
+
+// curriculumVersion lists every module and lesson in order with its update
+// time, so editing, reordering, adding or removing any of them changes it
+func curriculumVersion(modules []domain.Module) []interface{} {
+	var parts []interface{}
+	for _, module := range modules {
+		parts = append(parts, module.ID, module.UpdatedAt.UnixNano())
+		for _, lesson := range module.Lessons {
+			parts = append(parts, lesson.ID, lesson.UpdatedAt.UnixNano())
+		}
+	}
+	return parts
+}
+
 // Create godoc
 // @Summary Create course
 // @Tags Courses
@@ -167,40 +250,6 @@ func (h *CourseHandler) GetCurriculum(c echo.Context) error {
 func (h *CourseHandler) Create(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	f, _ := os.OpenFile("/tmp/course_debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if f != nil {
-		defer f.Close()
-		fmt.Fprintf(f, "\n--- Create Course Request Received %v ---\n", time.Now())
-		fmt.Fprintf(f, "Content-Type: %s\n", c.Request().Header.Get(echo.HeaderContentType))
-		fmt.Fprintf(f, "Headers: %+v\n", c.Request().Header)
-
-		if err := c.Request().ParseMultipartForm(32 << 20); err != nil {
-			fmt.Fprintf(f, "ParseMultipartForm error: %v\n", err)
-		}
-
-		fmt.Fprintf(f, "Form Keys: ")
-		for k := range c.Request().Form {
-			fmt.Fprintf(f, "%s, ", k)
-		}
-		fmt.Fprintln(f)
-
-		if c.Request().MultipartForm != nil {
-			fmt.Fprintf(f, "Multipart Form Keys: ")
-			for k := range c.Request().MultipartForm.Value {
-				fmt.Fprintf(f, "%s, ", k)
-			}
-			fmt.Fprintln(f)
-		}
-	}
-
-	if c.Request().MultipartForm != nil {
-		fmt.Printf("Multipart Form Keys: ")
-		for k := range c.Request().MultipartForm.Value {
-			fmt.Printf("%s, ", k)
-		}
-		fmt.Println()
-	}
-
 	var input course.CreateInput
 
 	// Use c.FormValue which is reliable in Echo for both multipart and urlencoded
@@ -208,11 +257,6 @@ func (h *CourseHandler) Create(c echo.Context) error {
 	input.Level = c.FormValue("level")
 	input.Language = c.FormValue("language")
 
-	if f != nil {
-		fmt.Fprintf(f, "Extracted values: title=%q, level=%q, language=%q\n", input.Title, input.Level, input.Language)
-	}
-	fmt.Printf("Extracted: title=%q, level=%q\n", input.Title, input.Level)
-
 	desc := c.FormValue("description")
 	if desc != "" {
 		input.Description = &desc
@@ -235,6 +279,12 @@ func (h *CourseHandler) Create(c echo.Context) error {
 		}
 	}
 
+	if certStr := c.FormValue("certificate_enabled"); certStr != "" {
+		if enabled, err := strconv.ParseBool(certStr); err == nil {
+			input.CertificateEnabled = &enabled
+		}
+	}
+
 	catID := c.FormValue("category_id")
 	if catID != "" {
 		input.CategoryID = &catID
@@ -251,25 +301,9 @@ func (h *CourseHandler) Create(c echo.Context) error {
 		input.CategoryIDs = c.Request().Form["category_ids"]
 	}
 
-	// Handle thumbnail upload
-	file, err := c.FormFile("thumbnail")
-	if err == nil {
-		thumbnailURL, err := h.uploadFile(file)
-		if err != nil {
-			return response.InternalError(c, "Failed to upload thumbnail")
-		}
-		input.ThumbnailURL = &thumbnailURL
-	}
-
 	// Parse modules JSON if provided in multipart form
 	if modulesJSON := c.FormValue("modules"); modulesJSON != "" {
-		if f != nil {
-			fmt.Fprintf(f, "Modules JSON: %s\n", modulesJSON)
-		}
 		if err := json.Unmarshal([]byte(modulesJSON), &input.Modules); err != nil {
-			if f != nil {
-				fmt.Fprintf(f, "Unmarshal modules error: %v\n", err)
-			}
 			return response.BadRequest(c, "Invalid modules JSON")
 		}
 	}
@@ -278,8 +312,25 @@ func (h *CourseHandler) Create(c echo.Context) error {
 		return validator.FormatValidationErrors(err)
 	}
 
+	// Check before storing the thumbnail so a refused request leaves no file
+	if err := h.courseUC.CanCreate(c.Request().Context(), claims.UserID); err != nil {
+		return err
+	}
+
+	// Handle thumbnail upload
+	file, err := c.FormFile("thumbnail")
+	if err == nil {
+		thumbnail, err := h.uploadThumbnail(c, file)
+		if err != nil {
+			return err
+		}
+		input.ThumbnailURL = &thumbnail.URL
+		input.ThumbnailVariants = thumbnail.Variants
+	}
+
 	crs, err := h.courseUC.Create(c.Request().Context(), claims.UserID, input)
 	if err != nil {
+		h.deleteThumbnail(c, input.ThumbnailURL, input.ThumbnailVariants)
 		return err
 	}
 
@@ -307,23 +358,6 @@ func (h *CourseHandler) Update(c echo.Context) error {
 		return err
 	}
 
-	f, _ := os.OpenFile("/tmp/course_debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if f != nil {
-		defer f.Close()
-		fmt.Fprintf(f, "\n--- Update Course Request Received %v ID=%s ---\n", time.Now(), id)
-		fmt.Fprintf(f, "Content-Type: %s\n", c.Request().Header.Get(echo.HeaderContentType))
-
-		if err := c.Request().ParseMultipartForm(32 << 20); err != nil {
-			fmt.Fprintf(f, "ParseMultipartForm error: %v\n", err)
-		}
-
-		fmt.Fprintf(f, "Form Keys: ")
-		for k := range c.Request().Form {
-			fmt.Fprintf(f, "%s, ", k)
-		}
-		fmt.Fprintln(f)
-	}
-
 	var input course.UpdateInput
 	contentType := c.Request().Header.Get(echo.HeaderContentType)
 
@@ -374,34 +408,36 @@ func (h *CourseHandler) Update(c echo.Context) error {
 			}
 		}
 
+		if certStr := c.Request().FormValue("certificate_enabled"); certStr != "" {
+			if enabled, err := strconv.ParseBool(certStr); err == nil {
+				input.CertificateEnabled = &enabled
+			}
+		}
+
+		if publicStr := c.Request().FormValue("public_discussions"); publicStr != "" {
+			if public, err := strconv.ParseBool(publicStr); err == nil {
+				input.PublicDiscussions = &public
+			}
+		}
+
+		if catID := c.Request().FormValue("category_id"); catID != "" {
+			input.CategoryID = &catID
+		}
+
 		if c.Request().MultipartForm != nil {
 			input.Requirements = c.Request().MultipartForm.Value["requirements"]
 			input.WhatYouLearn = c.Request().MultipartForm.Value["what_you_learn"]
+			input.CategoryIDs = c.Request().MultipartForm.Value["category_ids"]
 		} else {
 			input.Requirements = c.Request().PostForm["requirements"]
 			input.WhatYouLearn = c.Request().PostForm["what_you_learn"]
+			input.CategoryIDs = c.Request().PostForm["category_ids"]
 		}
 	}
 
-	// Handle thumbnail upload
-	file, err := c.FormFile("thumbnail")
-	if err == nil {
-		thumbnailURL, err := h.uploadFile(file)
-		if err != nil {
-			return response.InternalError(c, "Failed to upload thumbnail")
-		}
-		input.ThumbnailURL = &thumbnailURL
-	}
-
 	// Parse modules JSON
 	if modulesJSON := c.Request().FormValue("modules"); modulesJSON != "" {
-		if f != nil {
-			fmt.Fprintf(f, "Modules JSON: %s\n", modulesJSON)
-		}
 		if err := json.Unmarshal([]byte(modulesJSON), &input.Modules); err != nil {
-			if f != nil {
-				fmt.Fprintf(f, "Unmarshal modules error: %v\n", err)
-			}
 			return response.BadRequest(c, "Invalid modules JSON")
 		}
 	}
@@ -410,47 +446,46 @@ func (h *CourseHandler) Update(c echo.Context) error {
 		return validator.FormatValidationErrors(err)
 	}
 
+	// Stored only once the request is known to be valid
+	file, err := c.FormFile("thumbnail")
+	if err == nil {
+		thumbnail, err := h.uploadThumbnail(c, file)
+		if err != nil {
+			return err
+		}
+		input.ThumbnailURL = &thumbnail.URL
+		input.ThumbnailVariants = thumbnail.Variants
+	}
+
+	var replaced *domain.Course
+	if input.ThumbnailURL != nil {
+		replaced, _ = h.courseUC.GetByID(c.Request().Context(), id)
+	}
+
 	crs, err := h.courseUC.Update(c.Request().Context(), id, input)
 	if err != nil {
+		h.deleteThumbnail(c, input.ThumbnailURL, input.ThumbnailVariants)
 		return err
 	}
+	if replaced != nil && replaced.ThumbnailURL != nil && *replaced.ThumbnailURL != *input.ThumbnailURL {
+		h.deleteThumbnail(c, replaced.ThumbnailURL, replaced.ThumbnailVariants)
+	}
 
 	return response.Success(c, crs)
 }
 
-func (h *CourseHandler) uploadFile(header *multipart.FileHeader) (string, error) {
-	// Simple local file upload implementation
-	// Ensure directory exists
-	uploadDir := "uploads"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return "", err
-	}
-
-	// Generate filename
-	filename := fmt.Sprintf("%s%s", uuid.New().String(), filepath.Ext(header.Filename))
-	dstPath := filepath.Join(uploadDir, filename)
-
-	// Open source file
-	src, err := header.Open()
-	if err != nil {
-		return "", err
-	}
-	defer src.Close()
-
-	// Create destination file
-	dst, err := os.Create(dstPath)
-	if err != nil {
-		return "", err
-	}
-	defer dst.Close()
+// uploadThumbnail stores a course thumbnail with its resized variants
+func (h *CourseHandler) uploadThumbnail(c echo.Context, header *multipart.FileHeader) (*storage.Image, error) {
+	return h.storageSvc.UploadImageVariants(c.Request().Context(), header, "thumbnails")
+}
 
-	// Copy content
-	if _, err = io.Copy(dst, src); err != nil {
-		return "", err
+// deleteThumbnail removes a thumbnail this handler stored, which is one with
+// variants. A linked thumbnail may be used elsewhere, so it is left alone.
+func (h *CourseHandler) deleteThumbnail(c echo.Context, url *string, variants map[string]string) {
+	if url == nil || len(variants) == 0 {
+		return
 	}
-
-	// Return URL (relative for simplicity in dev)
-	return fmt.Sprintf("/%s/%s", uploadDir, filename), nil
+	_ = h.storageSvc.DeleteImage(c.Request().Context(), &storage.Image{URL: *url, Variants: variants})
 }
 
 // Delete godoc
@@ -478,12 +513,41 @@ func (h *CourseHandler) Delete(c echo.Context) error {
 	return response.NoContent(c)
 }
 
+// GetPublishChecklist godoc
+// @Summary Get course publish checklist
+// @Description Reports which publishing requirements the course meets, without publishing it
+// @Tags Courses
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} response.Response{data=domain.PublishChecklist}
+// @Router /courses/{id}/publish-checklist [get]
+func (h *CourseHandler) GetPublishChecklist(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	checklist, err := h.courseUC.GetPublishChecklist(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, checklist)
+}
+
 // Publish godoc
 // @Summary Publish course
 // @Tags Courses
 // @Security BearerAuth
 // @Param id path string true "Course ID"
 // @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response{data=domain.CourseNotReady}
 // @Router /courses/{id}/publish [patch]
 func (h *CourseHandler) Publish(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
@@ -521,29 +585,347 @@ func (h *CourseHandler) Archive(c echo.Context) error {
 		return err
 	}
 
-	if err := h.courseUC.Archive(c.Request().Context(), id); err != nil {
+	if err := h.courseUC.Archive(c.Request().Context(), id, claims.UserID, claims.Role == domain.RoleAdmin); err != nil {
 		return err
 	}
 
 	return response.SuccessWithMessage(c, "Course archived successfully", nil)
 }
 
+// GetEnrollmentCode godoc
+// @Summary Get course enrollment code
+// @Tags Courses
+// @Security BearerAuth
+// @Param id path string true "Course ID"
+// @Success 200 {object} response.Response{data=map[string]string}
+// @Router /courses/{id}/enrollment-code [get]
+func (h *CourseHandler) GetEnrollmentCode(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	code, err := h.courseUC.GetEnrollmentCode(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	if code == nil {
+		return response.NotFound(c, "Course has no enrollment code")
+	}
+
+	return response.Success(c, map[string]string{"code": *code})
+}
+
+// RotateEnrollmentCode godoc
+// @Summary Generate a new course enrollment code
+// @Description Replaces any existing code, so previously shared codes stop working
+// @Tags Courses
+// @Security BearerAuth
+// @Param id path string true "Course ID"
+// @Success 200 {object} response.Response{data=map[string]string}
+// @Router /courses/{id}/enrollment-code [post]
+func (h *CourseHandler) RotateEnrollmentCode(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	code, err := h.courseUC.RotateEnrollmentCode(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "Enrollment code generated", map[string]string{"code": code})
+}
+
+// DisableEnrollmentCode godoc
+// @Summary Disable course enrollment code
+// @Tags Courses
+// @Security BearerAuth
+// @Param id path string true "Course ID"
+// @Success 204
+// @Router /courses/{id}/enrollment-code [delete]
+func (h *CourseHandler) DisableEnrollmentCode(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	if err := h.courseUC.DisableEnrollmentCode(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return response.NoContent(c)
+}
+
+// GetCoInstructors godoc
+// @Summary Get course co-instructors and revenue splits
+// @Tags Courses
+// @Security BearerAuth
+// @Param id path string true "Course ID"
+// @Success 200 {object} response.Response{data=[]domain.CourseInstructor}
+// @Router /courses/{id}/instructors [get]
+func (h *CourseHandler) GetCoInstructors(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	splits, err := h.courseUC.GetCoInstructors(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, splits)
+}
+
+// SetCoInstructors godoc
+// @Summary Set course co-instructors and revenue splits
+// @Description The owner must be included and shares must sum to 100 percent
+// @Tags Courses
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param request body course.SetCoInstructorsInput true "Revenue splits"
+// @Success 200 {object} response.Response{data=[]domain.CourseInstructor}
+// @Failure 400 {object} response.Response
+// @Router /courses/{id}/instructors [put]
+func (h *CourseHandler) SetCoInstructors(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	var input course.SetCoInstructorsInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	splits, err := h.courseUC.SetCoInstructors(c.Request().Context(), id, input)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, splits)
+}
+
+// GetWishlistStats godoc
+// @Summary Get how many learners have wishlisted a course
+// @Tags Courses
+// @Security BearerAuth
+// @Param id path string true "Course ID"
+// @Success 200 {object} response.Response{data=course.WishlistStats}
+// @Router /courses/{id}/wishlist-stats [get]
+func (h *CourseHandler) GetWishlistStats(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	stats, err := h.courseUC.GetWishlistStats(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, stats)
+}
+
+// GetCourseFunnel godoc
+// @Summary Get how far a course's learners progress
+// @Tags Courses
+// @Security BearerAuth
+// @Param courseId path string true "Course ID"
+// @Success 200 {object} response.Response{data=domain.CourseFunnel}
+// @Router /courses/{courseId}/funnel [get]
+func (h *CourseHandler) GetCourseFunnel(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	funnel, err := h.courseUC.GetCourseFunnel(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, funnel)
+}
+
+// GetAtRiskStudents godoc
+// @Summary List learners who have stalled early in a course
+// @Description Active learners with no lesson activity for the configured window and progress below the configured threshold, longest inactive first
+// @Tags Courses
+// @Security BearerAuth
+// @Param courseId path string true "Course ID"
+// @Success 200 {object} response.Response{data=[]domain.AtRiskStudent}
+// @Router /courses/{courseId}/at-risk [get]
+func (h *CourseHandler) GetAtRiskStudents(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	students, err := h.enrollmentUC.GetAtRiskStudents(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, students)
+}
+
+// NudgeAtRiskStudents godoc
+// @Summary Remind a course's at-risk learners to continue
+// @Description Learners already nudged within the configured cooldown are skipped; nudged counts those reminded now
+// @Tags Courses
+// @Security BearerAuth
+// @Accept json
+// @Param courseId path string true "Course ID"
+// @Param body body enrollment.NudgeInput false "Optional note to include"
+// @Success 200 {object} response.Response
+// @Router /courses/{courseId}/at-risk/nudge [post]
+func (h *CourseHandler) NudgeAtRiskStudents(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, id, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	var input enrollment.NudgeInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	nudged, err := h.enrollmentUC.NudgeAtRiskStudents(c.Request().Context(), id, input)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, map[string]int{"nudged": nudged})
+}
+
 // MyCourses godoc
 // @Summary Get my courses (as instructor)
 // @Tags Courses
 // @Security BearerAuth
 // @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /courses/my [get]
 func (h *CourseHandler) MyCourses(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	courses, total, err := h.courseUC.GetByInstructor(c.Request().Context(), claims.UserID, 1, 50)
+	page, limit := ParsePaginationWithin(c, 50, maxPageLimit)
+	courses, total, err := h.courseUC.GetByInstructor(c.Request().Context(), claims.UserID, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return response.Paginated(c, courses, page, limit, total)
+}
+
+// ListTrash godoc
+// @Summary List deleted courses
+// @Description Instructors see their own deleted courses; admins see all of them. Courses are purged once purge_after passes.
+// @Tags Courses
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} response.Response{data=[]course.TrashedCourse}
+// @Router /courses/trash [get]
+func (h *CourseHandler) ListTrash(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	page, limit := ParsePaginationWithin(c, defaultPageLimit, 100)
+
+	var instructorID *uuid.UUID
+	if claims.Role != domain.RoleAdmin {
+		instructorID = &claims.UserID
+	}
+
+	courses, total, err := h.courseUC.ListTrash(c.Request().Context(), instructorID, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return response.Paginated(c, courses, page, limit, total)
+}
+
+// Restore godoc
+// @Summary Restore a deleted course
+// @Tags Courses
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} response.Response{data=domain.Course}
+// @Router /courses/{id}/restore [post]
+func (h *CourseHandler) Restore(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	crs, err := h.courseUC.Restore(c.Request().Context(), id, claims.UserID, claims.Role == domain.RoleAdmin)
 	if err != nil {
 		return err
 	}
 
-	return response.Paginated(c, courses, 1, 50, total)
+	return response.Success(c, crs)
 }
 
 // --- Module Handlers ---
@@ -563,7 +945,7 @@ func (h *CourseHandler) ListModules(c echo.Context) error {
 
 	modules, err := h.courseUC.GetCurriculum(c.Request().Context(), courseID)
 	if err != nil {
-		return response.InternalError(c, "Failed to list modules")
+		return err
 	}
 
 	return response.Success(c, modules)
@@ -695,7 +1077,7 @@ func (h *CourseHandler) ReorderModules(c echo.Context) error {
 // @Tags Lessons
 // @Produce json
 // @Param moduleId path string true "Module ID"
-// @Success 200 {object} response.Response
+// @Success 200 {object} response.Response{data=[]domain.Lesson}
 // @Router /courses/modules/{moduleId}/lessons [get]
 func (h *CourseHandler) ListLessons(c echo.Context) error {
 	moduleID, err := uuid.Parse(c.Param("moduleId"))
@@ -703,12 +1085,12 @@ func (h *CourseHandler) ListLessons(c echo.Context) error {
 		return response.BadRequest(c, "Invalid module ID")
 	}
 
-	modules, err := h.courseUC.GetCurriculum(c.Request().Context(), moduleID)
+	lessons, err := h.courseUC.GetLessonsByModule(c.Request().Context(), moduleID)
 	if err != nil {
 		return err
 	}
 
-	return response.Success(c, modules)
+	return response.Success(c, lessons)
 }
 
 // CreateLesson godoc
@@ -744,6 +1126,50 @@ func (h *CourseHandler) CreateLesson(c echo.Context) error {
 	return response.Created(c, lesson)
 }
 
+// BulkCreateLessons godoc
+// @Summary Create several lessons in a module
+// @Description Creates the lessons in order after the module's existing lessons. Nothing is saved if any lesson fails.
+// @Tags Lessons
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param courseId path string true "Course ID"
+// @Param moduleId path string true "Module ID"
+// @Param request body course.BulkCreateLessonsInput true "Lessons in order"
+// @Success 201 {object} response.Response{data=[]domain.Lesson}
+// @Router /courses/{courseId}/modules/{moduleId}/lessons/bulk [post]
+func (h *CourseHandler) BulkCreateLessons(c echo.Context) error {
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+	moduleID, err := uuid.Parse(c.Param("moduleId"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid module ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, courseID, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	var input course.BulkCreateLessonsInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	lessons, err := h.courseUC.BulkCreateLessons(c.Request().Context(), courseID, moduleID, input)
+	if err != nil {
+		return err
+	}
+
+	return response.Created(c, lessons)
+}
+
 // GetLesson godoc
 // @Summary Get a lesson
 // @Tags Lessons
@@ -789,6 +1215,10 @@ func (h *CourseHandler) UpdateLesson(c echo.Context) error {
 		return response.BadRequest(c, "Invalid request body")
 	}
 
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
 	lesson, err := h.courseUC.UpdateLesson(c.Request().Context(), lessonID, input)
 	if err != nil {
 		return err
@@ -818,6 +1248,74 @@ func (h *CourseHandler) DeleteLesson(c echo.Context) error {
 	return response.NoContent(c)
 }
 
+// ReorderLessons godoc
+// @Summary Reorder lessons in a module
+// @Tags Lessons
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param moduleId path string true "Module ID"
+// @Param request body object{lesson_ids=[]string} true "Every lesson ID in the module, in order"
+// @Success 200 {object} response.Response{data=[]domain.Lesson}
+// @Router /courses/modules/{moduleId}/lessons/reorder [patch]
+func (h *CourseHandler) ReorderLessons(c echo.Context) error {
+	moduleID, err := uuid.Parse(c.Param("moduleId"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid module ID")
+	}
+
+	var input struct {
+		LessonIDs []uuid.UUID `json:"lesson_ids"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	module, err := h.courseUC.GetModule(c.Request().Context(), moduleID)
+	if err != nil {
+		return err
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.checkOwnership(c, module.CourseID, claims.UserID, claims.Role); err != nil {
+		return err
+	}
+
+	lessons, err := h.courseUC.ReorderLessons(c.Request().Context(), moduleID, input.LessonIDs)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, lessons)
+}
+
+// GetAdjacentLessons godoc
+// @Summary Get previous and next lessons
+// @Description Returns the lessons before and after this one in course order, skipping unpublished lessons and ones the user can't open. Either side is null at the ends of the course.
+// @Tags Lessons
+// @Produce json
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} response.Response{data=course.AdjacentLessons}
+// @Router /courses/lessons/{lessonId}/siblings [get]
+func (h *CourseHandler) GetAdjacentLessons(c echo.Context) error {
+	lessonID, err := uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid lesson ID")
+	}
+
+	var userID *uuid.UUID
+	if claims, ok := middleware.GetClaims(c); ok {
+		userID = &claims.UserID
+	}
+
+	siblings, err := h.courseUC.GetAdjacentLessons(c.Request().Context(), lessonID, userID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, siblings)
+}
+
 // --- Category Handlers ---
 
 // ListCategories godoc
@@ -891,12 +1389,43 @@ func (h *CourseHandler) UpdateCategory(c echo.Context) error {
 	return response.Success(c, category)
 }
 
+// ReorderCategories godoc
+// @Summary Reorder categories (admin)
+// @Description Orders the categories under parent_id, or the top-level categories when it is omitted
+// @Tags Categories
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body object{parent_id=string,category_ids=[]string} true "Category IDs in order"
+// @Success 200 {object} response.Response{data=[]domain.Category}
+// @Router /courses/categories/reorder [patch]
+func (h *CourseHandler) ReorderCategories(c echo.Context) error {
+	var input struct {
+		ParentID    *uuid.UUID  `json:"parent_id"`
+		CategoryIDs []uuid.UUID `json:"category_ids"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	categories, err := h.courseUC.ReorderCategories(c.Request().Context(), input.ParentID, input.CategoryIDs)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, categories)
+}
+
 // DeleteCategory godoc
 // @Summary Delete a category (admin)
 // @Tags Categories
 // @Security BearerAuth
+// @Description A category with courses or subcategories is only deleted with force=true. Its subcategories move up to its parent and its courses move to reassignTo.
 // @Param id path string true "Category ID"
+// @Param force query bool false "Delete even though the category is in use"
+// @Param reassignTo query string false "Category that receives the courses"
 // @Success 204
+// @Failure 409 {object} response.Response
 // @Router /courses/categories/{id} [delete]
 func (h *CourseHandler) DeleteCategory(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
@@ -904,7 +1433,17 @@ func (h *CourseHandler) DeleteCategory(c echo.Context) error {
 		return response.BadRequest(c, "Invalid category ID")
 	}
 
-	if err := h.courseUC.DeleteCategory(c.Request().Context(), id); err != nil {
+	var input course.DeleteCategoryInput
+	input.Force, _ = strconv.ParseBool(c.QueryParam("force"))
+	if raw := c.QueryParam("reassignTo"); raw != "" {
+		reassignTo, err := uuid.Parse(raw)
+		if err != nil {
+			return response.BadRequest(c, "Invalid reassignTo category ID")
+		}
+		input.ReassignTo = &reassignTo
+	}
+
+	if err := h.courseUC.DeleteCategory(c.Request().Context(), id, input); err != nil {
 		return err
 	}
 
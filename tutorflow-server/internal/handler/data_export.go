@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/dataexport"
+)
+
+// DataExportHandler handles personal data export HTTP requests
+type DataExportHandler struct {
+	exportUC *dataexport.UseCase
+}
+
+// NewDataExportHandler creates a new data export handler
+func NewDataExportHandler(exportUC *dataexport.UseCase) *DataExportHandler {
+	return &DataExportHandler{exportUC: exportUC}
+}
+
+// RegisterRoutes registers data export routes
+func (h *DataExportHandler) RegisterRoutes(g *echo.Group, authMW, adminMW echo.MiddlewareFunc) {
+	g.GET("/me/data-export", h.ExportMine, authMW)
+	g.GET("/users/:id/data-export", h.ExportUser, authMW, adminMW)
+	g.GET("/data-exports/download", h.Download)
+}
+
+// ExportMine godoc
+// @Summary Export my personal data
+// @Description Returns the profile, enrollments, orders, reviews, discussions, messages and certificates as JSON. Large accounts get 202 and the download link is emailed.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {file} file
+// @Success 202 {object} response.Response{data=domain.DataExport}
+// @Router /me/data-export [get]
+func (h *DataExportHandler) ExportMine(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+	return h.export(c, claims.UserID)
+}
+
+// ExportUser godoc
+// @Summary Export a user's personal data (Admin)
+// @Description Same as /me/data-export on the user's behalf; emailed links go to the user
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {file} file
+// @Success 202 {object} response.Response{data=domain.DataExport}
+// @Router /users/{id}/data-export [get]
+func (h *DataExportHandler) ExportUser(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid user ID")
+	}
+	return h.export(c, userID)
+}
+
+func (h *DataExportHandler) export(c echo.Context, userID uuid.UUID) error {
+	claims, _ := middleware.GetClaims(c)
+
+	result, err := h.exportUC.Request(c.Request().Context(), claims.UserID, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.Job != nil {
+		return c.JSON(http.StatusAccepted, response.Response{
+			Success: true,
+			Message: "Export is being prepared; a download link will be emailed",
+			Data:    result.Job,
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename="+result.File.Filename)
+	return c.Blob(http.StatusOK, result.File.ContentType, result.File.Data)
+}
+
+// Download godoc
+// @Summary Download a prepared data export
+// @Tags Users
+// @Produce json
+// @Param token query string true "Token from the emailed link"
+// @Success 200 {file} file
+// @Router /data-exports/download [get]
+func (h *DataExportHandler) Download(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return response.BadRequest(c, "Token is required")
+	}
+
+	stream, filename, err := h.exportUC.Download(c.Request().Context(), token)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename="+filename)
+	return c.Stream(http.StatusOK, echo.MIMEApplicationJSON, stream)
+}
@@ -25,9 +25,9 @@ func NewDiscussionHandler(discussionUC *discussion.UseCase) *DiscussionHandler {
 func (h *DiscussionHandler) RegisterRoutes(g *echo.Group, authMW echo.MiddlewareFunc) {
 	discussions := g.Group("/discussions", authMW)
 	discussions.GET("/:id", h.GetDiscussion)
-	discussions.GET("/course/:courseId", h.GetCourseDiscussions)
-	discussions.GET("/lesson/:lessonId", h.GetLessonDiscussions)
-	discussions.GET("/:id/replies", h.GetReplies)
+	listRoute(discussions, "/course/:courseId", h.GetCourseDiscussions)
+	listRoute(discussions, "/lesson/:lessonId", h.GetLessonDiscussions)
+	listRoute(discussions, "/:id/replies", h.GetReplies)
 	discussions.POST("", h.CreateDiscussion)
 	discussions.PUT("/:id", h.UpdateDiscussion)
 	discussions.DELETE("/:id", h.DeleteDiscussion)
@@ -37,6 +37,8 @@ func (h *DiscussionHandler) RegisterRoutes(g *echo.Group, authMW echo.Middleware
 	discussions.DELETE("/:id/resolve", h.Unresolve)
 	discussions.POST("/:id/pin", h.Pin)
 	discussions.DELETE("/:id/pin", h.Unpin)
+	discussions.POST("/:id/accept/:replyId", h.AcceptAnswer)
+	discussions.DELETE("/:id/accept", h.ClearAnswer)
 }
 
 // GetDiscussion godoc
@@ -52,8 +54,13 @@ func (h *DiscussionHandler) GetDiscussion(c echo.Context) error {
 		return response.BadRequest(c, "Invalid discussion ID")
 	}
 
-	disc, err := h.discussionUC.GetDiscussion(c.Request().Context(), id)
-	if err != nil || disc == nil {
+	claims, _ := middleware.GetClaims(c)
+
+	disc, err := h.discussionUC.GetDiscussion(c.Request().Context(), id, claims.UserID, claims.Role == domain.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if disc == nil {
 		return response.NotFound(c, "Discussion not found")
 	}
 
@@ -65,6 +72,8 @@ func (h *DiscussionHandler) GetDiscussion(c echo.Context) error {
 // @Tags Discussions
 // @Security BearerAuth
 // @Param courseId path string true "Course ID"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /discussions/course/{courseId} [get]
 func (h *DiscussionHandler) GetCourseDiscussions(c echo.Context) error {
@@ -73,10 +82,12 @@ func (h *DiscussionHandler) GetCourseDiscussions(c echo.Context) error {
 		return response.BadRequest(c, "Invalid course ID")
 	}
 
-	page, limit := 1, 20
-	discussions, total, err := h.discussionUC.GetCourseDiscussions(c.Request().Context(), courseID, page, limit)
+	claims, _ := middleware.GetClaims(c)
+
+	page, limit := ParsePagination(c)
+	discussions, total, err := h.discussionUC.GetCourseDiscussions(c.Request().Context(), courseID, claims.UserID, claims.Role == domain.RoleAdmin, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get discussions")
+		return err
 	}
 
 	return response.Paginated(c, discussions, page, limit, total)
@@ -87,6 +98,8 @@ func (h *DiscussionHandler) GetCourseDiscussions(c echo.Context) error {
 // @Tags Discussions
 // @Security BearerAuth
 // @Param lessonId path string true "Lesson ID"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /discussions/lesson/{lessonId} [get]
 func (h *DiscussionHandler) GetLessonDiscussions(c echo.Context) error {
@@ -95,10 +108,12 @@ func (h *DiscussionHandler) GetLessonDiscussions(c echo.Context) error {
 		return response.BadRequest(c, "Invalid lesson ID")
 	}
 
-	page, limit := 1, 20
-	discussions, total, err := h.discussionUC.GetLessonDiscussions(c.Request().Context(), lessonID, page, limit)
+	claims, _ := middleware.GetClaims(c)
+
+	page, limit := ParsePagination(c)
+	discussions, total, err := h.discussionUC.GetLessonDiscussions(c.Request().Context(), lessonID, claims.UserID, claims.Role == domain.RoleAdmin, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get discussions")
+		return err
 	}
 
 	return response.Paginated(c, discussions, page, limit, total)
@@ -109,6 +124,8 @@ func (h *DiscussionHandler) GetLessonDiscussions(c echo.Context) error {
 // @Tags Discussions
 // @Security BearerAuth
 // @Param id path string true "Discussion ID"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /discussions/{id}/replies [get]
 func (h *DiscussionHandler) GetReplies(c echo.Context) error {
@@ -117,10 +134,12 @@ func (h *DiscussionHandler) GetReplies(c echo.Context) error {
 		return response.BadRequest(c, "Invalid discussion ID")
 	}
 
-	page, limit := 1, 50
-	replies, total, err := h.discussionUC.GetReplies(c.Request().Context(), id, page, limit)
+	claims, _ := middleware.GetClaims(c)
+
+	page, limit := ParsePaginationWithin(c, 50, maxPageLimit)
+	replies, total, err := h.discussionUC.GetReplies(c.Request().Context(), id, claims.UserID, claims.Role == domain.RoleAdmin, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get replies")
+		return err
 	}
 
 	return response.Paginated(c, replies, page, limit, total)
@@ -147,9 +166,9 @@ func (h *DiscussionHandler) CreateDiscussion(c echo.Context) error {
 		return validator.FormatValidationErrors(err)
 	}
 
-	disc, err := h.discussionUC.CreateDiscussion(c.Request().Context(), claims.UserID, input)
+	disc, err := h.discussionUC.CreateDiscussion(c.Request().Context(), claims.UserID, claims.Role == domain.RoleAdmin, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Created(c, disc)
@@ -179,7 +198,7 @@ func (h *DiscussionHandler) UpdateDiscussion(c echo.Context) error {
 
 	disc, err := h.discussionUC.UpdateDiscussion(c.Request().Context(), id, claims.UserID, input.Content)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, disc)
@@ -202,7 +221,7 @@ func (h *DiscussionHandler) DeleteDiscussion(c echo.Context) error {
 	isAdmin := claims.Role == domain.RoleAdmin
 
 	if err := h.discussionUC.DeleteDiscussion(c.Request().Context(), id, claims.UserID, isAdmin); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.NoContent(c)
@@ -221,8 +240,10 @@ func (h *DiscussionHandler) Upvote(c echo.Context) error {
 		return response.BadRequest(c, "Invalid discussion ID")
 	}
 
-	if err := h.discussionUC.Upvote(c.Request().Context(), id); err != nil {
-		return response.InternalError(c, "Failed to upvote")
+	claims, _ := middleware.GetClaims(c)
+
+	if err := h.discussionUC.Upvote(c.Request().Context(), id, claims.UserID, claims.Role == domain.RoleAdmin); err != nil {
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "Upvoted", nil)
@@ -241,8 +262,10 @@ func (h *DiscussionHandler) RemoveUpvote(c echo.Context) error {
 		return response.BadRequest(c, "Invalid discussion ID")
 	}
 
-	if err := h.discussionUC.RemoveUpvote(c.Request().Context(), id); err != nil {
-		return response.InternalError(c, "Failed to remove upvote")
+	claims, _ := middleware.GetClaims(c)
+
+	if err := h.discussionUC.RemoveUpvote(c.Request().Context(), id, claims.UserID, claims.Role == domain.RoleAdmin); err != nil {
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "Upvote removed", nil)
@@ -264,7 +287,7 @@ func (h *DiscussionHandler) MarkResolved(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.discussionUC.MarkResolved(c.Request().Context(), id, claims.UserID); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Marked as resolved", nil)
@@ -286,7 +309,7 @@ func (h *DiscussionHandler) Unresolve(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.discussionUC.Unresolve(c.Request().Context(), id, claims.UserID); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Unmarked as resolved", nil)
@@ -308,7 +331,7 @@ func (h *DiscussionHandler) Pin(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.discussionUC.Pin(c.Request().Context(), id, claims.UserID); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Discussion pinned", nil)
@@ -330,8 +353,58 @@ func (h *DiscussionHandler) Unpin(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.discussionUC.Unpin(c.Request().Context(), id, claims.UserID); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Discussion unpinned", nil)
 }
+
+// AcceptAnswer godoc
+// @Summary Accept a reply as the answer (instructor, asker or admin)
+// @Tags Discussions
+// @Security BearerAuth
+// @Param id path string true "Discussion ID"
+// @Param replyId path string true "Reply ID"
+// @Success 200 {object} response.Response{data=domain.Discussion}
+// @Router /discussions/{id}/accept/{replyId} [post]
+func (h *DiscussionHandler) AcceptAnswer(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid discussion ID")
+	}
+	replyID, err := uuid.Parse(c.Param("replyId"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid reply ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+
+	disc, err := h.discussionUC.AcceptAnswer(c.Request().Context(), id, replyID, claims.UserID, claims.Role == domain.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, disc)
+}
+
+// ClearAnswer godoc
+// @Summary Remove the accepted answer (instructor, asker or admin)
+// @Tags Discussions
+// @Security BearerAuth
+// @Param id path string true "Discussion ID"
+// @Success 200 {object} response.Response
+// @Router /discussions/{id}/accept [delete]
+func (h *DiscussionHandler) ClearAnswer(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid discussion ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+
+	if err := h.discussionUC.ClearAnswer(c.Request().Context(), id, claims.UserID, claims.Role == domain.RoleAdmin); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "Accepted answer removed", nil)
+}
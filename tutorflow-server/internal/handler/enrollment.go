@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"io"
+	"net/http"
+
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/validator"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/enrollment"
@@ -24,12 +28,16 @@ func NewEnrollmentHandler(enrollmentUC *enrollment.UseCase) *EnrollmentHandler {
 // RegisterRoutes registers enrollment routes
 func (h *EnrollmentHandler) RegisterRoutes(g *echo.Group, authMW, managerMW echo.MiddlewareFunc) {
 	g.GET("/dashboard-stats", h.GetDashboardStats, authMW)
-	g.GET("/my", h.MyEnrollments, authMW)
-	g.GET("", h.List, authMW, managerMW)
+	listRoute(g, "/my", h.MyEnrollments, authMW)
+	listRoute(g, "", h.List, authMW, managerMW)
 	g.POST("", h.Enroll, authMW)
+	g.POST("/bulk", h.BulkEnroll, authMW, managerMW)
+	g.POST("/import", h.ImportRoster, authMW, managerMW)
 	g.GET("/course/:slug", h.GetByCourseSlug, authMW)
 	g.GET("/:id", h.GetByID, authMW)
 	g.PATCH("/:id/cancel", h.Cancel, authMW)
+	g.PATCH("/:id/pause", h.Pause, authMW)
+	g.PATCH("/:id/resume", h.Resume, authMW)
 	g.GET("/:id/progress", h.GetProgress, authMW)
 	g.POST("/:id/lessons/:lessonId/complete", h.MarkLessonComplete, authMW)
 	g.POST("/progress/complete", h.MarkLessonCompleteByLessonID, authMW)
@@ -41,6 +49,9 @@ func (h *EnrollmentHandler) RegisterRoutes(g *echo.Group, authMW, managerMW echo
 // @Tags Enrollments
 // @Security BearerAuth
 // @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Page by cursor instead of offset: empty for the first page, then meta.next_cursor"
 // @Success 200 {object} response.Response
 // @Router /enrollments [get]
 func (h *EnrollmentHandler) List(c echo.Context) error {
@@ -48,10 +59,23 @@ func (h *EnrollmentHandler) List(c echo.Context) error {
 	if err := c.Bind(&input); err != nil {
 		return response.BadRequest(c, "Invalid query parameters")
 	}
+	input.Page, input.Limit = ParsePaginationWithin(c, defaultPageLimit, 100)
+
+	cursor, err := pagination.FromRequest(c)
+	if err != nil {
+		return err
+	}
+	if cursor != nil {
+		enrollments, next, err := h.enrollmentUC.ListAfter(c.Request().Context(), input, *cursor)
+		if err != nil {
+			return err
+		}
+		return response.CursorPaginated(c, enrollments, next)
+	}
 
 	enrollments, total, err := h.enrollmentUC.List(c.Request().Context(), input)
 	if err != nil {
-		return response.InternalError(c, "Failed to list enrollments")
+		return err
 	}
 
 	return response.Paginated(c, enrollments, input.Page, input.Limit, total)
@@ -62,17 +86,18 @@ func (h *EnrollmentHandler) List(c echo.Context) error {
 // @Tags Enrollments
 // @Security BearerAuth
 // @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /enrollments/my [get]
 func (h *EnrollmentHandler) MyEnrollments(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	page := 1
-	limit := 20
+	page, limit := ParsePagination(c)
 
 	enrollments, total, err := h.enrollmentUC.GetMyEnrollments(c.Request().Context(), claims.UserID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get enrollments")
+		return err
 	}
 
 	return response.Paginated(c, enrollments, page, limit, total)
@@ -90,7 +115,7 @@ func (h *EnrollmentHandler) GetDashboardStats(c echo.Context) error {
 
 	stats, err := h.enrollmentUC.GetDashboardStats(c.Request().Context(), claims.UserID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get dashboard stats")
+		return err
 	}
 
 	return response.Success(c, stats)
@@ -119,16 +144,107 @@ func (h *EnrollmentHandler) Enroll(c echo.Context) error {
 
 	enroll, err := h.enrollmentUC.Enroll(c.Request().Context(), claims.UserID, input)
 	if err != nil {
-		switch err {
-		case domain.ErrAlreadyEnrolled:
-			return response.BadRequest(c, "Already enrolled in this course")
-		case domain.ErrCourseNotFound:
-			return response.NotFound(c, "Course not found")
-		case domain.ErrCourseNotPublished:
-			return response.BadRequest(c, "Course is not available")
-		default:
-			return response.InternalError(c, "Failed to enroll")
-		}
+		return err
+	}
+
+	return response.Created(c, enroll)
+}
+
+// BulkEnroll godoc
+// @Summary Enroll several users in a course
+// @Description Assigns course seats; users already enrolled are skipped. Returns a result per user.
+// @Tags Enrollments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body enrollment.BulkEnrollInput true "Course and users"
+// @Success 200 {object} response.Response{data=enrollment.BulkEnrollOutput}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /enrollments/bulk [post]
+func (h *EnrollmentHandler) BulkEnroll(c echo.Context) error {
+	var input enrollment.BulkEnrollInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	output, err := h.enrollmentUC.BulkEnroll(c.Request().Context(), input.CourseID, input.UserIDs)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, output)
+}
+
+// ImportRoster godoc
+// @Summary Import a CSV roster into a course
+// @Description Creates accounts for unknown emails, sends them a password setup email and enrolls every row. Columns: email, first_name, last_name (names optional).
+// @Tags Enrollments
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param course_id formData string true "Course ID"
+// @Param file formData file true "Roster CSV (max 1 MB)"
+// @Success 200 {object} response.Response{data=enrollment.RosterImportReport}
+// @Failure 400 {object} response.Response
+// @Router /enrollments/import [post]
+func (h *EnrollmentHandler) ImportRoster(c echo.Context) error {
+	courseID, err := uuid.Parse(c.FormValue("course_id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return response.BadRequest(c, "Roster file is required")
+	}
+	if file.Size > enrollment.MaxRosterFileSize {
+		return response.BadRequest(c, "Roster file is too large")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return response.BadRequest(c, "Invalid roster file")
+	}
+	defer src.Close()
+
+	report, err := h.enrollmentUC.ImportRoster(c.Request().Context(), courseID, io.LimitReader(src, enrollment.MaxRosterFileSize))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, report)
+}
+
+// EnrollWithCode godoc
+// @Summary Enroll in a course using an enrollment code
+// @Tags Enrollments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body enrollment.EnrollWithCodeInput true "Enrollment code"
+// @Success 201 {object} response.Response{data=domain.Enrollment}
+// @Failure 400 {object} response.Response
+// @Router /courses/enroll-with-code [post]
+func (h *EnrollmentHandler) EnrollWithCode(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	var input enrollment.EnrollWithCodeInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	enroll, err := h.enrollmentUC.EnrollWithCode(c.Request().Context(), claims.UserID, input.Code)
+	if err != nil {
+		return err
 	}
 
 	return response.Created(c, enroll)
@@ -189,12 +305,86 @@ func (h *EnrollmentHandler) Cancel(c echo.Context) error {
 	}
 
 	if err := h.enrollmentUC.Cancel(c.Request().Context(), id); err != nil {
-		return response.InternalError(c, "Failed to cancel enrollment")
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "Enrollment cancelled", nil)
 }
 
+// Pause godoc
+// @Summary Pause a time-limited enrollment
+// @Description Freezes the access expiry clock until the enrollment is resumed
+// @Tags Enrollments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Enrollment ID"
+// @Success 200 {object} response.Response{data=domain.Enrollment}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /enrollments/{id}/pause [patch]
+func (h *EnrollmentHandler) Pause(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid enrollment ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+
+	enroll, err := h.enrollmentUC.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return response.NotFound(c, "Enrollment not found")
+	}
+
+	// Learners manage their own enrollment; staff may act on anyone's
+	if enroll.UserID != claims.UserID && claims.Role != domain.RoleAdmin && claims.Role != domain.RoleManager {
+		return response.Forbidden(c, "")
+	}
+
+	enroll, err = h.enrollmentUC.PauseEnrollment(c.Request().Context(), id, claims.Role)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "Enrollment paused", enroll)
+}
+
+// Resume godoc
+// @Summary Resume a paused enrollment
+// @Description Restarts the access clock and extends the expiry date by the time spent paused
+// @Tags Enrollments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Enrollment ID"
+// @Success 200 {object} response.Response{data=domain.Enrollment}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /enrollments/{id}/resume [patch]
+func (h *EnrollmentHandler) Resume(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid enrollment ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+
+	enroll, err := h.enrollmentUC.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return response.NotFound(c, "Enrollment not found")
+	}
+
+	// Learners manage their own enrollment; staff may act on anyone's
+	if enroll.UserID != claims.UserID && claims.Role != domain.RoleAdmin && claims.Role != domain.RoleManager {
+		return response.Forbidden(c, "")
+	}
+
+	enroll, err = h.enrollmentUC.ResumeEnrollment(c.Request().Context(), id, claims.Role)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "Enrollment resumed", enroll)
+}
+
 // GetProgress godoc
 // @Summary Get enrollment progress
 // @Tags Enrollments
@@ -211,7 +401,7 @@ func (h *EnrollmentHandler) GetProgress(c echo.Context) error {
 
 	progress, err := h.enrollmentUC.GetProgress(c.Request().Context(), id)
 	if err != nil {
-		return response.InternalError(c, "Failed to get progress")
+		return err
 	}
 
 	return response.Success(c, progress)
@@ -249,14 +439,7 @@ func (h *EnrollmentHandler) MarkLessonComplete(c echo.Context) error {
 	}
 
 	if err := h.enrollmentUC.MarkLessonComplete(c.Request().Context(), claims.UserID, enroll.CourseID, lessonID); err != nil {
-		switch err {
-		case domain.ErrNotEnrolled:
-			return response.Forbidden(c, "Not enrolled in this course")
-		case domain.ErrEnrollmentExpired:
-			return response.Forbidden(c, "Enrollment has expired")
-		default:
-			return response.InternalError(c, "Failed to mark lesson complete")
-		}
+		return err
 	}
 
 	return response.Success(c, nil)
@@ -274,7 +457,7 @@ func (h *EnrollmentHandler) MarkLessonCompleteByLessonID(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.enrollmentUC.MarkLessonCompleteByOriginalID(c.Request().Context(), claims.UserID, input.LessonID); err != nil {
-		return response.InternalError(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, nil)
@@ -320,12 +503,93 @@ func (h *EnrollmentHandler) UpdateVideoPosition(c echo.Context) error {
 	}
 
 	if err := h.enrollmentUC.UpdateVideoPosition(c.Request().Context(), claims.UserID, enroll.CourseID, input); err != nil {
-		return response.InternalError(c, "Failed to update position")
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "Position updated", nil)
 }
 
+// SaveLessonPosition godoc
+// @Summary Save video resume position for a lesson
+// @Description Stores where the learner is in the lesson video so playback can resume on another device. Writes closer together than the configured interval are skipped.
+// @Tags Enrollments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Param request body enrollment.SaveLessonPositionInput true "Position in seconds"
+// @Success 200 {object} response.Response{data=enrollment.LessonPosition}
+// @Router /lessons/{id}/position [put]
+func (h *EnrollmentHandler) SaveLessonPosition(c echo.Context) error {
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid lesson ID")
+	}
+
+	var input enrollment.SaveLessonPositionInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	position, err := h.enrollmentUC.SaveLessonPosition(c.Request().Context(), claims.UserID, lessonID, input.Position)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, position)
+}
+
+// GetLessonPosition godoc
+// @Summary Get video resume position for a lesson
+// @Tags Enrollments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Success 200 {object} response.Response{data=enrollment.LessonPosition}
+// @Router /lessons/{id}/position [get]
+func (h *EnrollmentHandler) GetLessonPosition(c echo.Context) error {
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid lesson ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	position, err := h.enrollmentUC.GetLessonPosition(c.Request().Context(), claims.UserID, lessonID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, position)
+}
+
+// CheckLessonAccess godoc
+// @Summary Check whether a lesson is unlocked
+// @Description Returns 200 when the learner can open the lesson. A locked lesson returns 403 with code LESSON_LOCKED and the lock reason in data.
+// @Tags Enrollments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response{data=domain.LessonLock}
+// @Router /lessons/{id}/access [get]
+func (h *EnrollmentHandler) CheckLessonAccess(c echo.Context) error {
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid lesson ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.enrollmentUC.CheckLessonAccess(c.Request().Context(), claims.UserID, lessonID); err != nil {
+		return err
+	}
+
+	return response.Success(c, map[string]bool{"accessible": true})
+}
+
 // GetByCourseSlug godoc
 // @Summary Get enrollment by course slug
 // @Tags Enrollments
@@ -340,13 +604,11 @@ func (h *EnrollmentHandler) GetByCourseSlug(c echo.Context) error {
 
 	enroll, err := h.enrollmentUC.GetByCourseSlug(c.Request().Context(), claims.UserID, slug)
 	if err != nil {
+		// Not being enrolled is an expected answer here, not a lack of access
 		if err == domain.ErrNotEnrolled {
-			return response.NotFound(c, "Not enrolled in this course")
-		}
-		if err == domain.ErrCourseNotFound {
-			return response.NotFound(c, "Course not found")
+			return response.ErrorWithCode(c, http.StatusNotFound, "NOT_ENROLLED", "Not enrolled in this course")
 		}
-		return response.InternalError(c, "Failed to get enrollment")
+		return err
 	}
 
 	return response.Success(c, enroll)
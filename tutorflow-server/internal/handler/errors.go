@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+)
+
+// badRequest reports err as a 400 with its text, unless it is a domain error
+// the error handler gives its own status and code
+func badRequest(c echo.Context, err error) error {
+	if middleware.IsDomainError(err) {
+		return err
+	}
+	return response.BadRequest(c, err.Error())
+}
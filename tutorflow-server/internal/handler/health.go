@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// healthCheckTimeout bounds each dependency check, so a hung dependency
+// reports as down rather than hanging the probe
+const healthCheckTimeout = 3 * time.Second
+
+// HealthCheck is a dependency the app can't serve requests without
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// ComponentStatus is the state of one dependency
+type ComponentStatus struct {
+	Status  string `json:"status"` // "up" or "down"
+	Latency string `json:"latency"`
+}
+
+// HealthStatus is the state of the app and each of its dependencies
+type HealthStatus struct {
+	Status     string                     `json:"status"` // "healthy" or "unhealthy"
+	Version    string                     `json:"version"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// HealthHandler reports whether the app is running and ready for traffic
+type HealthHandler struct {
+	version string
+	checks  []HealthCheck
+	logger  *zap.SugaredLogger
+}
+
+// NewHealthHandler creates a new health handler that checks each of checks
+// before reporting the app ready
+func NewHealthHandler(version string, logger *zap.SugaredLogger, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{version: version, checks: checks, logger: logger}
+}
+
+// RegisterRoutes registers health routes
+func (h *HealthHandler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/health", h.Ready)
+	e.GET("/health/ready", h.Ready)
+	e.GET("/health/live", h.Live)
+}
+
+// Live godoc
+// @Summary Liveness probe
+// @Description Reports the process is up, without checking any dependency. Restart the app only when this fails.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} HealthStatus
+// @Router /health/live [get]
+func (h *HealthHandler) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, HealthStatus{Status: "healthy", Version: h.version})
+}
+
+// Ready godoc
+// @Summary Readiness probe
+// @Description Checks the database and other dependencies the app needs, and reports each one. Also served at /health.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} HealthStatus
+// @Failure 503 {object} HealthStatus "A dependency is down"
+// @Router /health/ready [get]
+func (h *HealthHandler) Ready(c echo.Context) error {
+	status := HealthStatus{
+		Status:     "healthy",
+		Version:    h.version,
+		Components: make(map[string]ComponentStatus, len(h.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request().Context(), healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			component := ComponentStatus{Status: "up", Latency: time.Since(start).Round(time.Millisecond).String()}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				// The reason is only logged, as probes are public
+				h.logger.Warnw("health check failed", "component", check.Name, "error", err)
+				component.Status = "down"
+				status.Status = "unhealthy"
+			}
+			status.Components[check.Name] = component
+		}()
+	}
+	wg.Wait()
+
+	if status.Status != "healthy" {
+		return c.JSON(http.StatusServiceUnavailable, status)
+	}
+	return c.JSON(http.StatusOK, status)
+}
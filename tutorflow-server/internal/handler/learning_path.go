@@ -29,7 +29,7 @@ func (h *LearningPathHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuth
 	paths := g.Group("/learning-paths")
 
 	// Public routes
-	paths.GET("", h.ListPaths, optionalAuthMW)
+	listRoute(paths, "", h.ListPaths, optionalAuthMW)
 	paths.GET("/featured", h.GetFeaturedPaths)
 	paths.GET("/category/:categoryId", h.GetPathsByCategory)
 	paths.GET("/:idOrSlug", h.GetPath, optionalAuthMW)
@@ -38,7 +38,7 @@ func (h *LearningPathHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuth
 	// Authenticated student routes
 	paths.POST("/:id/enroll", h.Enroll, authMW)
 	paths.GET("/:id/progress", h.GetProgress, authMW)
-	paths.GET("/my/enrollments", h.GetMyEnrollments, authMW)
+	listRoute(paths, "/my/enrollments", h.GetMyEnrollments, authMW)
 
 	// Admin routes
 	paths.POST("", h.CreatePath, authMW, adminMW)
@@ -55,6 +55,8 @@ func (h *LearningPathHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuth
 // @Param category_id query string false "Category ID"
 // @Param level query string false "Level"
 // @Param search query string false "Search"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /learning-paths [get]
 func (h *LearningPathHandler) ListPaths(c echo.Context) error {
@@ -71,24 +73,12 @@ func (h *LearningPathHandler) ListPaths(c echo.Context) error {
 		Level:       c.QueryParam("level"),
 		Search:      c.QueryParam("search"),
 		IsPublished: &isPublished,
-		Page:        1,
-		Limit:       20,
-	}
-
-	if p := c.QueryParam("page"); p != "" {
-		if val, err := strconv.Atoi(p); err == nil {
-			filters.Page = val
-		}
-	}
-	if l := c.QueryParam("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil {
-			filters.Limit = val
-		}
 	}
+	filters.Page, filters.Limit = ParsePagination(c)
 
 	paths, total, err := h.pathUC.ListPaths(c.Request().Context(), filters)
 	if err != nil {
-		return response.InternalError(c, "Failed to list paths")
+		return err
 	}
 
 	return response.Paginated(c, paths, filters.Page, filters.Limit, total)
@@ -109,7 +99,7 @@ func (h *LearningPathHandler) GetFeaturedPaths(c echo.Context) error {
 
 	paths, err := h.pathUC.GetFeaturedPaths(c.Request().Context(), limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get featured paths")
+		return err
 	}
 
 	return response.Success(c, paths)
@@ -136,7 +126,7 @@ func (h *LearningPathHandler) GetPathsByCategory(c echo.Context) error {
 
 	paths, err := h.pathUC.GetPathsByCategory(c.Request().Context(), categoryID, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get paths")
+		return err
 	}
 
 	return response.Success(c, paths)
@@ -182,7 +172,7 @@ func (h *LearningPathHandler) GetPathCourses(c echo.Context) error {
 
 	courses, err := h.pathUC.GetPathCourses(c.Request().Context(), pathID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get courses")
+		return err
 	}
 
 	return response.Success(c, courses)
@@ -211,7 +201,7 @@ func (h *LearningPathHandler) CreatePath(c echo.Context) error {
 
 	path, err := h.pathUC.CreatePath(c.Request().Context(), claims.UserID, isAdmin, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, path)
@@ -242,7 +232,7 @@ func (h *LearningPathHandler) UpdatePath(c echo.Context) error {
 
 	path, err := h.pathUC.UpdatePath(c.Request().Context(), pathID, isAdmin, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, path)
@@ -265,7 +255,7 @@ func (h *LearningPathHandler) DeletePath(c echo.Context) error {
 	isAdmin := claims.Role == domain.RoleAdmin
 
 	if err := h.pathUC.DeletePath(c.Request().Context(), pathID, isAdmin); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.NoContent(c)
@@ -295,7 +285,7 @@ func (h *LearningPathHandler) AddCourse(c echo.Context) error {
 	}
 
 	if err := h.pathUC.AddCourse(c.Request().Context(), pathID, isAdmin, input); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, map[string]string{"message": "Course added to path"})
@@ -323,7 +313,7 @@ func (h *LearningPathHandler) RemoveCourse(c echo.Context) error {
 	isAdmin := claims.Role == domain.RoleAdmin
 
 	if err := h.pathUC.RemoveCourse(c.Request().Context(), pathID, courseID, isAdmin); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.NoContent(c)
@@ -358,7 +348,7 @@ func (h *LearningPathHandler) ReorderCourses(c echo.Context) error {
 	}
 
 	if err := h.pathUC.ReorderCourses(c.Request().Context(), pathID, isAdmin, input.CourseOrder); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Courses reordered", nil)
@@ -381,7 +371,7 @@ func (h *LearningPathHandler) Enroll(c echo.Context) error {
 
 	enrollment, err := h.pathUC.EnrollInPath(c.Request().Context(), pathID, claims.UserID)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, enrollment)
@@ -391,26 +381,18 @@ func (h *LearningPathHandler) Enroll(c echo.Context) error {
 // @Summary Get my learning path enrollments
 // @Tags Learning Paths
 // @Security BearerAuth
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /learning-paths/my/enrollments [get]
 func (h *LearningPathHandler) GetMyEnrollments(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	page, limit := 1, 20
-	if p := c.QueryParam("page"); p != "" {
-		if val, err := strconv.Atoi(p); err == nil {
-			page = val
-		}
-	}
-	if l := c.QueryParam("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil {
-			limit = val
-		}
-	}
+	page, limit := ParsePagination(c)
 
 	enrollments, total, err := h.pathUC.GetMyEnrollments(c.Request().Context(), claims.UserID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get enrollments")
+		return err
 	}
 
 	return response.Paginated(c, enrollments, page, limit, total)
@@ -433,7 +415,7 @@ func (h *LearningPathHandler) GetProgress(c echo.Context) error {
 
 	progress, err := h.pathUC.GetProgress(c.Request().Context(), pathID, claims.UserID)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, progress)
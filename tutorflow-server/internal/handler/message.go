@@ -1,13 +1,18 @@
 package handler
 
 import (
-	"strconv"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/validator"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/message"
@@ -18,26 +23,34 @@ var _ = domain.Message{}
 
 // MessageHandler handles messaging HTTP requests
 type MessageHandler struct {
-	messageUC *message.UseCase
+	messageUC      *message.UseCase
+	allowedOrigins []string
 }
 
-// NewMessageHandler creates a new message handler
-func NewMessageHandler(messageUC *message.UseCase) *MessageHandler {
-	return &MessageHandler{messageUC: messageUC}
+// NewMessageHandler creates a new message handler. allowedOrigins are the
+// browser origins that may open the messaging socket.
+func NewMessageHandler(messageUC *message.UseCase, allowedOrigins []string) *MessageHandler {
+	return &MessageHandler{messageUC: messageUC, allowedOrigins: allowedOrigins}
 }
 
 // RegisterRoutes registers messaging routes
-func (h *MessageHandler) RegisterRoutes(g *echo.Group, authMW echo.MiddlewareFunc) {
+func (h *MessageHandler) RegisterRoutes(g *echo.Group, authMW, tutorMW echo.MiddlewareFunc) {
 	msgs := g.Group("/messages", authMW)
-	msgs.GET("/conversations", h.GetConversations)
+	listRoute(msgs, "/conversations", h.GetConversations)
 	msgs.GET("/conversations/:id", h.GetConversation)
-	msgs.GET("/conversations/:id/messages", h.GetMessages)
+	listRoute(msgs, "/conversations/:id/messages", h.GetMessages)
 	msgs.POST("/conversations/:userId", h.StartConversation)
 	msgs.POST("", h.SendMessage)
+	msgs.POST("/attachments", h.SendWithAttachments)
 	msgs.POST("/:id/read", h.MarkAsRead)
 	msgs.POST("/conversations/:id/read", h.MarkConversationAsRead)
 	msgs.GET("/unread-count", h.GetUnreadCount)
+	listRoute(msgs, "/search", h.SearchMessages)
+	msgs.GET("/ws", h.Connect)
 	msgs.DELETE("/:id", h.DeleteMessage)
+	msgs.POST("/broadcast/:courseId", h.BroadcastToCourse, tutorMW)
+
+	g.GET("/users/:id/presence", h.GetPresence, authMW)
 }
 
 // GetConversations godoc
@@ -51,26 +64,39 @@ func (h *MessageHandler) RegisterRoutes(g *echo.Group, authMW echo.MiddlewareFun
 func (h *MessageHandler) GetConversations(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	page, limit := 1, 20
-	if p := c.QueryParam("page"); p != "" {
-		if val, err := strconv.Atoi(p); err == nil {
-			page = val
-		}
-	}
-	if l := c.QueryParam("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil {
-			limit = val
-		}
-	}
+	page, limit := ParsePagination(c)
 
 	conversations, total, err := h.messageUC.GetConversations(c.Request().Context(), claims.UserID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get conversations")
+		return err
 	}
 
 	return response.Paginated(c, conversations, page, limit, total)
 }
 
+// SearchMessages godoc
+// @Summary Search my messages
+// @Description Full-text search across the conversations the user is in. Each result's snippet is HTML-escaped with matches wrapped in <mark> tags.
+// @Tags Messages
+// @Security BearerAuth
+// @Param q query string true "Search text"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} response.Response{data=[]domain.MessageSearchResult}
+// @Router /messages/search [get]
+func (h *MessageHandler) SearchMessages(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	page, limit := ParsePagination(c)
+
+	results, total, err := h.messageUC.SearchMessages(c.Request().Context(), claims.UserID, c.QueryParam("q"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return response.Paginated(c, results, page, limit, total)
+}
+
 // GetConversation godoc
 // @Summary Get a conversation
 // @Tags Messages
@@ -88,7 +114,7 @@ func (h *MessageHandler) GetConversation(c echo.Context) error {
 
 	conv, err := h.messageUC.GetConversation(c.Request().Context(), claims.UserID, convID)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, conv)
@@ -101,6 +127,7 @@ func (h *MessageHandler) GetConversation(c echo.Context) error {
 // @Param id path string true "Conversation ID"
 // @Param page query int false "Page"
 // @Param limit query int false "Limit"
+// @Param cursor query string false "Page by cursor instead of offset: empty for the first page, then meta.next_cursor"
 // @Success 200 {object} response.Response
 // @Router /messages/conversations/{id}/messages [get]
 func (h *MessageHandler) GetMessages(c echo.Context) error {
@@ -111,21 +138,24 @@ func (h *MessageHandler) GetMessages(c echo.Context) error {
 
 	claims, _ := middleware.GetClaims(c)
 
-	page, limit := 1, 50
-	if p := c.QueryParam("page"); p != "" {
-		if val, err := strconv.Atoi(p); err == nil {
-			page = val
-		}
+	cursor, err := pagination.FromRequest(c)
+	if err != nil {
+		return err
 	}
-	if l := c.QueryParam("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil {
-			limit = val
+	if cursor != nil {
+		_, limit := ParsePaginationWithin(c, 50, 100)
+		messages, next, err := h.messageUC.GetMessagesAfter(c.Request().Context(), claims.UserID, convID, *cursor, limit)
+		if err != nil {
+			return badRequest(c, err)
 		}
+		return response.CursorPaginated(c, messages, next)
 	}
 
+	page, limit := ParsePaginationWithin(c, 50, 100)
+
 	messages, total, err := h.messageUC.GetMessages(c.Request().Context(), claims.UserID, convID, page, limit)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Paginated(c, messages, page, limit, total)
@@ -147,8 +177,11 @@ func (h *MessageHandler) StartConversation(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	conv, err := h.messageUC.GetOrCreateConversation(c.Request().Context(), claims.UserID, otherUserID)
+	if err == domain.ErrMessagingNotAllowed {
+		return err
+	}
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, conv)
@@ -175,8 +208,58 @@ func (h *MessageHandler) SendMessage(c echo.Context) error {
 	}
 
 	msg, err := h.messageUC.SendMessage(c.Request().Context(), claims.UserID, input)
+	if err == domain.ErrMessagingNotAllowed {
+		return err
+	}
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
+	}
+
+	return response.Created(c, msg)
+}
+
+// SendWithAttachments godoc
+// @Summary Send a message with files attached
+// @Description Up to 5 images, PDFs, office documents, text files or zips of at most 25MB each
+// @Tags Messages
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Param files formData file true "Files to attach"
+// @Param conversation_id formData string false "Conversation ID"
+// @Param recipient_id formData string false "Recipient user ID, to start a conversation"
+// @Param content formData string false "Message text"
+// @Success 201 {object} response.Response{data=domain.Message}
+// @Router /messages/attachments [post]
+func (h *MessageHandler) SendWithAttachments(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return response.BadRequest(c, "Invalid multipart form")
+	}
+
+	input := message.SendMessageInput{Content: c.FormValue("content")}
+	if v := c.FormValue("conversation_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return response.BadRequest(c, "Invalid conversation ID")
+		}
+		input.ConversationID = &id
+	}
+	if v := c.FormValue("recipient_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return response.BadRequest(c, "Invalid recipient ID")
+		}
+		input.RecipientID = &id
+	}
+
+	msg, err := h.messageUC.SendWithAttachments(c.Request().Context(), claims.UserID, input, form.File["files"])
+	if _, ok := err.(domain.ValidationErrors); ok || err == domain.ErrMessagingNotAllowed {
+		return err
+	}
+	if err != nil {
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, msg)
@@ -198,7 +281,7 @@ func (h *MessageHandler) MarkAsRead(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.messageUC.MarkAsRead(c.Request().Context(), claims.UserID, msgID); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Message marked as read", nil)
@@ -220,7 +303,7 @@ func (h *MessageHandler) MarkConversationAsRead(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.messageUC.MarkConversationAsRead(c.Request().Context(), claims.UserID, convID); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Conversation marked as read", nil)
@@ -237,7 +320,7 @@ func (h *MessageHandler) GetUnreadCount(c echo.Context) error {
 
 	count, err := h.messageUC.GetUnreadCount(c.Request().Context(), claims.UserID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get unread count")
+		return err
 	}
 
 	return response.Success(c, map[string]int64{"unread_count": count})
@@ -259,8 +342,150 @@ func (h *MessageHandler) DeleteMessage(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.messageUC.DeleteMessage(c.Request().Context(), claims.UserID, msgID); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.NoContent(c)
 }
+
+// BroadcastToCourse godoc
+// @Summary Message every student enrolled in a course
+// @Tags Messages
+// @Security BearerAuth
+// @Accept json
+// @Param courseId path string true "Course ID"
+// @Param request body message.BroadcastInput true "Message content"
+// @Success 201 {object} response.Response{data=domain.MessageBroadcast}
+// @Failure 403 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /messages/broadcast/{courseId} [post]
+func (h *MessageHandler) BroadcastToCourse(c echo.Context) error {
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+
+	var input message.BroadcastInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	broadcast, err := h.messageUC.BroadcastToCourse(c.Request().Context(), claims.UserID, courseID, input.Content)
+	if err != nil {
+		return err
+	}
+
+	return response.Created(c, broadcast)
+}
+
+// Connect godoc
+// @Summary Open the messaging socket
+// @Description WebSocket that pushes {"type": "message"|"read"|"typing"|"presence", "data": ...} events from the user's conversations. Send {"type": "typing", "conversation_id": "..."} while composing. Authenticate with the usual bearer token or cookie.
+// @Tags Messages
+// @Security BearerAuth
+// @Success 101
+// @Router /messages/ws [get]
+func (h *MessageHandler) Connect(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+	ctx := c.Request().Context()
+
+	server := websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			events, disconnect := h.messageUC.Connect(ctx, claims.UserID)
+			defer disconnect()
+
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var frame []byte
+				for websocket.Message.Receive(ws, &frame) == nil {
+					h.handleClientEvent(ctx, claims.UserID, frame)
+				}
+			}()
+
+			for {
+				select {
+				case <-closed:
+					return
+				case event, ok := <-events:
+					if !ok {
+						return
+					}
+					if err := websocket.JSON.Send(ws, event); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// clientEvent is a frame sent by the client over the messaging socket
+type clientEvent struct {
+	Type           string    `json:"type"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+}
+
+// handleClientEvent acts on a frame from the client, ignoring any it doesn't
+// understand
+func (h *MessageHandler) handleClientEvent(ctx context.Context, userID uuid.UUID, frame []byte) {
+	var event clientEvent
+	if err := json.Unmarshal(frame, &event); err != nil {
+		return
+	}
+	switch event.Type {
+	case message.EventTyping:
+		_ = h.messageUC.Typing(ctx, userID, event.ConversationID)
+	}
+}
+
+// GetPresence godoc
+// @Summary Get a user's online status
+// @Description Users who hide their status always appear offline
+// @Tags Messages
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response{data=domain.Presence}
+// @Router /users/{id}/presence [get]
+func (h *MessageHandler) GetPresence(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid user ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+
+	presence, err := h.messageUC.GetPresence(c.Request().Context(), claims.UserID, userID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, presence)
+}
+
+// checkOrigin rejects sockets opened from pages on other sites, which would
+// otherwise ride on the user's auth cookie. Clients that send no Origin are
+// not browsers and must have authenticated with a bearer token.
+func (h *MessageHandler) checkOrigin(_ *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get(echo.HeaderOrigin)
+	if origin == "" {
+		return nil
+	}
+	for _, allowed := range h.allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %s not allowed", origin)
+}
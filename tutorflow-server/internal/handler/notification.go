@@ -1,11 +1,19 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
+	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/validator"
 	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
 )
 
@@ -22,11 +30,19 @@ func NewNotificationHandler(notificationUC *notification.UseCase) *NotificationH
 // RegisterRoutes registers notification routes
 func (h *NotificationHandler) RegisterRoutes(g *echo.Group, authMW echo.MiddlewareFunc) {
 	notifications := g.Group("/notifications", authMW)
-	notifications.GET("", h.List)
+	listRoute(notifications, "", h.List)
 	notifications.GET("/unread-count", h.GetUnreadCount)
+	notifications.GET("/stream", h.Stream)
 	notifications.POST("/:id/read", h.MarkAsRead)
+	notifications.POST("/read", h.MarkManyAsRead)
 	notifications.POST("/read-all", h.MarkAllAsRead)
+	notifications.DELETE("", h.DeleteMany)
 	notifications.DELETE("/:id", h.Delete)
+
+	g.GET("/me/notification-preferences", h.GetPreferences, authMW)
+	g.PUT("/me/notification-preferences", h.UpdatePreferences, authMW)
+	g.GET("/me/notification-digest", h.GetDigest, authMW)
+	g.PUT("/me/notification-digest", h.UpdateDigest, authMW)
 }
 
 // List godoc
@@ -35,18 +51,30 @@ func (h *NotificationHandler) RegisterRoutes(g *echo.Group, authMW echo.Middlewa
 // @Security BearerAuth
 // @Param page query int false "Page"
 // @Param limit query int false "Limit"
+// @Param cursor query string false "Page by cursor instead of offset: empty for the first page, then meta.next_cursor"
 // @Success 200 {object} response.Response
 // @Router /notifications [get]
 func (h *NotificationHandler) List(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	// Parse query params
-	page := 1
-	limit := 20
+	cursor, err := pagination.FromRequest(c)
+	if err != nil {
+		return err
+	}
+	if cursor != nil {
+		_, limit := ParsePagination(c)
+		notifications, next, err := h.notificationUC.GetNotificationsAfter(c.Request().Context(), claims.UserID, *cursor, limit)
+		if err != nil {
+			return err
+		}
+		return response.CursorPaginated(c, notifications, next)
+	}
+
+	page, limit := ParsePagination(c)
 
 	notifications, total, err := h.notificationUC.GetNotifications(c.Request().Context(), claims.UserID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get notifications")
+		return err
 	}
 
 	return response.Paginated(c, notifications, page, limit, total)
@@ -63,12 +91,66 @@ func (h *NotificationHandler) GetUnreadCount(c echo.Context) error {
 
 	count, err := h.notificationUC.GetUnreadCount(c.Request().Context(), claims.UserID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get unread count")
+		return err
 	}
 
 	return response.Success(c, map[string]int64{"unread_count": count})
 }
 
+// streamKeepAlive is how often an idle stream sends a comment so proxies
+// don't close it
+const streamKeepAlive = 30 * time.Second
+
+// Stream godoc
+// @Summary Stream new notifications
+// @Description Server-Sent Events stream; each new notification is sent as a "notification" event
+// @Tags Notifications
+// @Security BearerAuth
+// @Produce text/event-stream
+// @Success 200
+// @Router /notifications/stream [get]
+func (h *NotificationHandler) Stream(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	events, unsubscribe := h.notificationUC.Subscribe(claims.UserID)
+	defer unsubscribe()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return nil
+			}
+			res.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
 // MarkAsRead godoc
 // @Summary Mark notification as read
 // @Tags Notifications
@@ -85,12 +167,41 @@ func (h *NotificationHandler) MarkAsRead(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.notificationUC.MarkAsRead(c.Request().Context(), id, claims.UserID); err != nil {
-		return response.InternalError(c, "Failed to mark as read")
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "Marked as read", nil)
 }
 
+// MarkManyAsRead godoc
+// @Summary Mark several notifications as read
+// @Description IDs of other users' notifications are ignored. Returns how many changed and the new unread count.
+// @Tags Notifications
+// @Security BearerAuth
+// @Accept json
+// @Param request body notification.BulkInput true "Notification IDs"
+// @Success 200 {object} response.Response{data=notification.BulkOutput}
+// @Router /notifications/read [post]
+func (h *NotificationHandler) MarkManyAsRead(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	var input notification.BulkInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	result, err := h.notificationUC.MarkManyAsRead(c.Request().Context(), claims.UserID, input)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, result)
+}
+
 // MarkAllAsRead godoc
 // @Summary Mark all notifications as read
 // @Tags Notifications
@@ -101,7 +212,7 @@ func (h *NotificationHandler) MarkAllAsRead(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.notificationUC.MarkAllAsRead(c.Request().Context(), claims.UserID); err != nil {
-		return response.InternalError(c, "Failed to mark all as read")
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "All notifications marked as read", nil)
@@ -123,8 +234,135 @@ func (h *NotificationHandler) Delete(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.notificationUC.DeleteNotification(c.Request().Context(), id, claims.UserID); err != nil {
-		return response.InternalError(c, "Failed to delete notification")
+		return err
 	}
 
 	return response.NoContent(c)
 }
+
+// DeleteMany godoc
+// @Summary Delete several notifications
+// @Description Deletes the notifications with the given IDs, those created before a time, or those matching both. Returns how many were deleted and the new unread count.
+// @Tags Notifications
+// @Security BearerAuth
+// @Accept json
+// @Param before query string false "Only notifications created before this RFC 3339 time"
+// @Param request body notification.DeleteInput false "Notification IDs"
+// @Success 200 {object} response.Response{data=notification.BulkOutput}
+// @Router /notifications [delete]
+func (h *NotificationHandler) DeleteMany(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	var input notification.DeleteInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	if before := c.QueryParam("before"); before != "" {
+		t, err := time.Parse(time.RFC3339, before)
+		if err != nil {
+			return response.BadRequest(c, "Invalid before time")
+		}
+		input.Before = &t
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	result, err := h.notificationUC.DeleteMany(c.Request().Context(), claims.UserID, input)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, result)
+}
+
+// GetPreferences godoc
+// @Summary Get notification preferences
+// @Description Every category with its in_app, email and push setting
+// @Tags Notifications
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=domain.NotificationPreferenceMatrix}
+// @Router /me/notification-preferences [get]
+func (h *NotificationHandler) GetPreferences(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	prefs, err := h.notificationUC.GetPreferences(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, prefs)
+}
+
+// UpdatePreferences godoc
+// @Summary Update notification preferences
+// @Description Only the categories and channels sent are changed, e.g. {"marketing": {"email": true}}
+// @Tags Notifications
+// @Security BearerAuth
+// @Accept json
+// @Param request body domain.NotificationPreferenceMatrix true "Settings to change"
+// @Success 200 {object} response.Response{data=domain.NotificationPreferenceMatrix}
+// @Router /me/notification-preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	var input domain.NotificationPreferenceMatrix
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	prefs, err := h.notificationUC.UpdatePreferences(c.Request().Context(), claims.UserID, input)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, prefs)
+}
+
+// DigestInput represents the digest frequency request body
+type DigestInput struct {
+	Frequency domain.DigestFrequency `json:"frequency" example:"daily"`
+}
+
+// GetDigest godoc
+// @Summary Get notification email frequency
+// @Tags Notifications
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=domain.NotificationDigest}
+// @Router /me/notification-digest [get]
+func (h *NotificationHandler) GetDigest(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	digest, err := h.notificationUC.GetDigest(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, digest)
+}
+
+// UpdateDigest godoc
+// @Summary Set notification email frequency
+// @Description daily and weekly batch email notifications into one summary email per period
+// @Tags Notifications
+// @Security BearerAuth
+// @Accept json
+// @Param request body DigestInput true "Frequency"
+// @Success 200 {object} response.Response{data=domain.NotificationDigest}
+// @Router /me/notification-digest [put]
+func (h *NotificationHandler) UpdateDigest(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	var input DigestInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	digest, err := h.notificationUC.SetDigest(c.Request().Context(), claims.UserID, input.Frequency)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, digest)
+}
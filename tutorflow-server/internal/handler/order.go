@@ -34,7 +34,7 @@ func NewOrderHandler(orderUC domain.OrderUseCase, paymentSvc *payment.Service) *
 // RegisterRoutes registers order routes
 func (h *OrderHandler) RegisterRoutes(g *echo.Group, authMW, adminMW echo.MiddlewareFunc) {
 	// Order routes
-	g.GET("/my", h.MyOrders, authMW)
+	listRoute(g, "/my", h.MyOrders, authMW)
 	g.POST("", h.CreateOrder, authMW)
 	g.POST("/checkout", h.CreateCheckout, authMW)
 	g.GET("/checkout-session/:id", h.GetByCheckoutSession, authMW)
@@ -47,7 +47,7 @@ func (h *OrderHandler) RegisterRoutes(g *echo.Group, authMW, adminMW echo.Middle
 	// Coupon routes
 	coupons := g.Group("/coupons")
 	coupons.POST("/validate", h.ValidateCoupon, authMW)
-	coupons.GET("", h.ListCoupons, authMW, adminMW)
+	listRoute(coupons, "", h.ListCoupons, authMW, adminMW)
 	coupons.POST("", h.CreateCoupon, authMW, adminMW)
 	coupons.DELETE("/:id", h.DeleteCoupon, authMW, adminMW)
 	coupons.PATCH("/:id/toggle", h.ToggleCoupon, authMW, adminMW)
@@ -58,17 +58,20 @@ func (h *OrderHandler) RegisterRoutes(g *echo.Group, authMW, adminMW echo.Middle
 // @Tags Orders
 // @Security BearerAuth
 // @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /orders/my [get]
 func (h *OrderHandler) MyOrders(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
-	orders, total, err := h.orderUC.GetMyOrders(c.Request().Context(), claims.UserID, 1, 20)
+	page, limit := ParsePagination(c)
+	orders, total, err := h.orderUC.GetMyOrders(c.Request().Context(), claims.UserID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get orders")
+		return err
 	}
 
-	return response.Paginated(c, orders, 1, 20, total)
+	return response.Paginated(c, orders, page, limit, total)
 }
 
 // CreateOrder godoc
@@ -90,7 +93,7 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 
 	output, err := h.orderUC.CreateOrder(c.Request().Context(), claims.UserID, claims.Email, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, output)
@@ -115,7 +118,7 @@ func (h *OrderHandler) CreateCheckout(c echo.Context) error {
 
 	output, err := h.orderUC.CreateCheckout(c.Request().Context(), claims.UserID, claims.Email, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, output)
@@ -196,7 +199,7 @@ func (h *OrderHandler) ConfirmPayment(c echo.Context) error {
 
 	orderObj, err := h.orderUC.ConfirmPayment(c.Request().Context(), input.PaymentIntentID)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, orderObj)
@@ -299,15 +302,18 @@ func (h *OrderHandler) ValidateCoupon(c echo.Context) error {
 // @Tags Coupons
 // @Security BearerAuth
 // @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /orders/coupons [get]
 func (h *OrderHandler) ListCoupons(c echo.Context) error {
-	coupons, total, err := h.orderUC.ListCoupons(c.Request().Context(), 1, 50)
+	page, limit := ParsePaginationWithin(c, 50, maxPageLimit)
+	coupons, total, err := h.orderUC.ListCoupons(c.Request().Context(), page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to list coupons")
+		return err
 	}
 
-	return response.Paginated(c, coupons, 1, 50, total)
+	return response.Paginated(c, coupons, page, limit, total)
 }
 
 // CreateCoupon godoc
@@ -333,7 +339,7 @@ func (h *OrderHandler) CreateCoupon(c echo.Context) error {
 
 	coupon, err := h.orderUC.CreateCoupon(c.Request().Context(), input, claims.UserID)
 	if err != nil {
-		return response.InternalError(c, "Failed to create coupon")
+		return err
 	}
 
 	return response.Created(c, coupon)
@@ -353,7 +359,7 @@ func (h *OrderHandler) DeleteCoupon(c echo.Context) error {
 	}
 
 	if err := h.orderUC.DeleteCoupon(c.Request().Context(), id); err != nil {
-		return response.InternalError(c, "Failed to delete coupon")
+		return err
 	}
 
 	return response.NoContent(c)
@@ -385,7 +391,7 @@ func (h *OrderHandler) ToggleCoupon(c echo.Context) error {
 	}
 
 	if err := h.orderUC.ToggleCoupon(c.Request().Context(), id, input.IsActive); err != nil {
-		return response.InternalError(c, "Failed to toggle coupon")
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "Coupon updated", nil)
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 50
+)
+
+// ParsePagination reads the page and limit query parameters. A missing or
+// invalid page is the first page, a missing or invalid limit is the default,
+// and limits above the maximum are capped to it.
+func ParsePagination(c echo.Context) (page, limit int) {
+	return ParsePaginationWithin(c, defaultPageLimit, maxPageLimit)
+}
+
+// ParsePaginationWithin is ParsePagination for a list with its own default
+// and maximum limit, such as a conversation's messages or the audit log
+func ParsePaginationWithin(c echo.Context, defaultLimit, maxLimit int) (page, limit int) {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err = strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	return page, limit
+}
+
+// listRoute registers a paged list for GET and for HEAD, which answers with
+// just the paging headers; Go's server drops a HEAD response's body. Only
+// lists answer HEAD, as other GET routes may stream or record the request.
+func listRoute(g *echo.Group, path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) {
+	g.Match([]string{http.MethodGet, http.MethodHead}, path, h, m...)
+}
@@ -61,7 +61,7 @@ func (h *PushHandler) Subscribe(c echo.Context) error {
 
 	sub, err := h.pushSvc.Subscribe(c.Request().Context(), claims.UserID, input)
 	if err != nil {
-		return response.InternalError(c, "Failed to subscribe")
+		return err
 	}
 
 	return response.Created(c, sub)
@@ -89,7 +89,7 @@ func (h *PushHandler) Unsubscribe(c echo.Context) error {
 	}
 
 	if err := h.pushSvc.Unsubscribe(c.Request().Context(), claims.UserID, input.Endpoint); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.SuccessWithMessage(c, "Unsubscribed successfully", nil)
@@ -105,7 +105,7 @@ func (h *PushHandler) UnsubscribeAll(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 
 	if err := h.pushSvc.UnsubscribeAll(c.Request().Context(), claims.UserID); err != nil {
-		return response.InternalError(c, "Failed to unsubscribe")
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "All devices unsubscribed", nil)
@@ -122,7 +122,7 @@ func (h *PushHandler) GetSubscriptions(c echo.Context) error {
 
 	subs, err := h.pushSvc.GetUserSubscriptions(c.Request().Context(), claims.UserID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get subscriptions")
+		return err
 	}
 
 	return response.Success(c, subs)
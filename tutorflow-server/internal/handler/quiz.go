@@ -41,6 +41,7 @@ func (h *QuizHandler) RegisterRoutes(g *echo.Group, authMW, tutorMW echo.Middlew
 	quizzes.POST("/attempts/:attemptId/submit", h.SubmitAttempt, authMW)
 	quizzes.GET("/attempts/:attemptId", h.GetAttempt, authMW)
 	quizzes.GET("/:id/my-attempts", h.GetMyAttempts, authMW)
+	quizzes.GET("/:id/analytics", h.GetQuizAnalytics, authMW, tutorMW)
 
 	// Assignment routes
 	assignments := g.Group("/assignments")
@@ -53,7 +54,7 @@ func (h *QuizHandler) RegisterRoutes(g *echo.Group, authMW, tutorMW echo.Middlew
 	// Submission routes
 	assignments.POST("/:id/submit", h.SubmitAssignment, authMW)
 	assignments.GET("/:id/my-submission", h.GetMySubmission, authMW)
-	assignments.GET("/:id/submissions", h.GetSubmissions, authMW, tutorMW)
+	listRoute(assignments, "/:id/submissions", h.GetSubmissions, authMW, tutorMW)
 	assignments.POST("/submissions/:submissionId/grade", h.GradeSubmission, authMW, tutorMW)
 }
 
@@ -132,7 +133,7 @@ func (h *QuizHandler) CreateQuiz(c echo.Context) error {
 
 	quizObj, err := h.quizUC.CreateQuiz(c.Request().Context(), input)
 	if err != nil {
-		return response.InternalError(c, "Failed to create quiz")
+		return err
 	}
 
 	return response.Created(c, quizObj)
@@ -161,7 +162,7 @@ func (h *QuizHandler) UpdateQuiz(c echo.Context) error {
 
 	quizObj, err := h.quizUC.UpdateQuiz(c.Request().Context(), id, input)
 	if err != nil {
-		return response.InternalError(c, "Failed to update quiz")
+		return err
 	}
 
 	return response.Success(c, quizObj)
@@ -181,7 +182,7 @@ func (h *QuizHandler) DeleteQuiz(c echo.Context) error {
 	}
 
 	if err := h.quizUC.DeleteQuiz(c.Request().Context(), id); err != nil {
-		return response.InternalError(c, "Failed to delete quiz")
+		return err
 	}
 
 	return response.NoContent(c)
@@ -216,7 +217,7 @@ func (h *QuizHandler) AddQuestion(c echo.Context) error {
 
 	question, err := h.quizUC.AddQuestion(c.Request().Context(), quizID, input)
 	if err != nil {
-		return response.InternalError(c, "Failed to add question")
+		return err
 	}
 
 	return response.Created(c, question)
@@ -236,7 +237,7 @@ func (h *QuizHandler) UpdateQuestion(c echo.Context) error {
 
 	question, err := h.quizUC.UpdateQuestion(c.Request().Context(), questionID, input)
 	if err != nil {
-		return response.InternalError(c, "Failed to update question")
+		return err
 	}
 
 	return response.Success(c, question)
@@ -250,7 +251,7 @@ func (h *QuizHandler) DeleteQuestion(c echo.Context) error {
 	}
 
 	if err := h.quizUC.DeleteQuestion(c.Request().Context(), questionID); err != nil {
-		return response.InternalError(c, "Failed to delete question")
+		return err
 	}
 
 	return response.NoContent(c)
@@ -275,7 +276,7 @@ func (h *QuizHandler) StartAttempt(c echo.Context) error {
 
 	attempt, err := h.quizUC.StartAttempt(c.Request().Context(), claims.UserID, quizID)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, attempt)
@@ -304,7 +305,7 @@ func (h *QuizHandler) SubmitAttempt(c echo.Context) error {
 
 	attempt, err := h.quizUC.SubmitAttempt(c.Request().Context(), attemptID, input.Answers)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, attempt)
@@ -348,12 +349,33 @@ func (h *QuizHandler) GetMyAttempts(c echo.Context) error {
 
 	attempts, err := h.quizUC.GetMyAttempts(c.Request().Context(), claims.UserID, quizID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get attempts")
+		return err
 	}
 
 	return response.Success(c, attempts)
 }
 
+// GetQuizAnalytics godoc
+// @Summary Get quiz analytics with per-option selection counts
+// @Tags Quizzes
+// @Security BearerAuth
+// @Param id path string true "Quiz ID"
+// @Success 200 {object} response.Response{data=quiz.QuizAnalytics}
+// @Router /quizzes/{id}/analytics [get]
+func (h *QuizHandler) GetQuizAnalytics(c echo.Context) error {
+	quizID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid quiz ID")
+	}
+
+	analytics, err := h.quizUC.GetQuizAnalytics(c.Request().Context(), quizID)
+	if err != nil {
+		return response.NotFound(c, "Quiz not found")
+	}
+
+	return response.Success(c, analytics)
+}
+
 // --- Assignment Handlers ---
 
 // GetAssignment godoc
@@ -419,7 +441,7 @@ func (h *QuizHandler) CreateAssignment(c echo.Context) error {
 
 	assignment, err := h.quizUC.CreateAssignment(c.Request().Context(), input)
 	if err != nil {
-		return response.InternalError(c, "Failed to create assignment")
+		return err
 	}
 
 	return response.Created(c, assignment)
@@ -439,7 +461,7 @@ func (h *QuizHandler) UpdateAssignment(c echo.Context) error {
 
 	assignment, err := h.quizUC.UpdateAssignment(c.Request().Context(), id, input)
 	if err != nil {
-		return response.InternalError(c, "Failed to update assignment")
+		return err
 	}
 
 	return response.Success(c, assignment)
@@ -453,7 +475,7 @@ func (h *QuizHandler) DeleteAssignment(c echo.Context) error {
 	}
 
 	if err := h.quizUC.DeleteAssignment(c.Request().Context(), id); err != nil {
-		return response.InternalError(c, "Failed to delete assignment")
+		return err
 	}
 
 	return response.NoContent(c)
@@ -487,7 +509,7 @@ func (h *QuizHandler) SubmitAssignment(c echo.Context) error {
 
 	submission, err := h.quizUC.SubmitAssignment(c.Request().Context(), claims.UserID, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, submission)
@@ -510,7 +532,7 @@ func (h *QuizHandler) GetMySubmission(c echo.Context) error {
 
 	submission, err := h.quizUC.GetMySubmission(c.Request().Context(), claims.UserID, assignmentID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get submission")
+		return err
 	}
 
 	if submission == nil {
@@ -525,6 +547,8 @@ func (h *QuizHandler) GetMySubmission(c echo.Context) error {
 // @Tags Assignments
 // @Security BearerAuth
 // @Param id path string true "Assignment ID"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /assignments/{id}/submissions [get]
 func (h *QuizHandler) GetSubmissions(c echo.Context) error {
@@ -533,12 +557,13 @@ func (h *QuizHandler) GetSubmissions(c echo.Context) error {
 		return response.BadRequest(c, "Invalid assignment ID")
 	}
 
-	submissions, total, err := h.quizUC.GetSubmissionsByAssignment(c.Request().Context(), assignmentID, 1, 50)
+	page, limit := ParsePagination(c)
+	submissions, total, err := h.quizUC.GetSubmissionsByAssignment(c.Request().Context(), assignmentID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get submissions")
+		return err
 	}
 
-	return response.Paginated(c, submissions, 1, 50, total)
+	return response.Paginated(c, submissions, page, limit, total)
 }
 
 // GradeSubmission godoc
@@ -566,7 +591,7 @@ func (h *QuizHandler) GradeSubmission(c echo.Context) error {
 
 	submission, err := h.quizUC.GradeSubmission(c.Request().Context(), submissionID, claims.UserID, input)
 	if err != nil {
-		return response.InternalError(c, "Failed to grade submission")
+		return err
 	}
 
 	return response.Success(c, submission)
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/recommendation"
+)
+
+// RecommendationHandler handles course recommendation HTTP requests
+type RecommendationHandler struct {
+	recommendationUC *recommendation.UseCase
+}
+
+// NewRecommendationHandler creates a new recommendation handler
+func NewRecommendationHandler(recommendationUC *recommendation.UseCase) *RecommendationHandler {
+	return &RecommendationHandler{recommendationUC: recommendationUC}
+}
+
+// RegisterRoutes registers recommendation routes
+func (h *RecommendationHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuthMW echo.MiddlewareFunc) {
+	g.GET("/recommendations", h.GetForUser, authMW)
+	g.GET("/courses/:id/recommendations", h.GetAlsoBought, optionalAuthMW)
+}
+
+// GetForUser godoc
+// @Summary Get personalized course recommendations
+// @Description Based on the categories and levels of the user's enrollments, weighted by recency. New users get featured and trending courses.
+// @Tags Recommendations
+// @Security BearerAuth
+// @Param limit query int false "Limit (default 10)"
+// @Success 200 {object} response.Response{data=[]domain.Course}
+// @Router /recommendations [get]
+func (h *RecommendationHandler) GetForUser(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	limit := 10
+	if l := c.QueryParam("limit"); l != "" {
+		if val, err := strconv.Atoi(l); err == nil {
+			limit = val
+		}
+	}
+
+	courses, err := h.recommendationUC.GetRecommendationsForUser(c.Request().Context(), claims.UserID, limit)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, courses)
+}
+
+// GetAlsoBought godoc
+// @Summary Get "students also bought" recommendations for a course
+// @Tags Recommendations
+// @Param id path string true "Course ID"
+// @Param limit query int false "Limit (default 6)"
+// @Success 200 {object} response.Response{data=[]domain.Course}
+// @Failure 404 {object} response.Response
+// @Router /courses/{id}/recommendations [get]
+func (h *RecommendationHandler) GetAlsoBought(c echo.Context) error {
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	limit := 6
+	if l := c.QueryParam("limit"); l != "" {
+		if val, err := strconv.Atoi(l); err == nil {
+			limit = val
+		}
+	}
+
+	var userID *uuid.UUID
+	if claims, ok := middleware.GetClaims(c); ok {
+		userID = &claims.UserID
+	}
+
+	courses, err := h.recommendationUC.GetAlsoBought(c.Request().Context(), courseID, userID, limit)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, courses)
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 
@@ -72,7 +71,7 @@ func (h *RefundHandler) RequestRefund(c echo.Context) error {
 // GetMyRefunds returns the current user's refunds
 func (h *RefundHandler) GetMyRefunds(c echo.Context) error {
 	userID := getUserIDFromContext(c)
-	page, limit := getPagination(c)
+	page, limit := ParsePaginationWithin(c, defaultPageLimit, 100)
 
 	refunds, total, err := h.refundUC.GetUserRefunds(c.Request().Context(), userID, page, limit)
 	if err != nil {
@@ -119,7 +118,7 @@ func (h *RefundHandler) GetRefund(c echo.Context) error {
 
 // GetAllRefunds returns all refunds (admin)
 func (h *RefundHandler) GetAllRefunds(c echo.Context) error {
-	page, limit := getPagination(c)
+	page, limit := ParsePaginationWithin(c, defaultPageLimit, 100)
 
 	var status *domain.RefundStatus
 	if s := c.QueryParam("status"); s != "" {
@@ -148,7 +147,7 @@ func (h *RefundHandler) GetAllRefunds(c echo.Context) error {
 
 // GetPendingRefunds returns pending refunds (admin)
 func (h *RefundHandler) GetPendingRefunds(c echo.Context) error {
-	page, limit := getPagination(c)
+	page, limit := ParsePaginationWithin(c, defaultPageLimit, 100)
 
 	refunds, total, err := h.refundUC.GetPendingRefunds(c.Request().Context(), page, limit)
 	if err != nil {
@@ -231,22 +230,3 @@ func (h *RefundHandler) RejectRefund(c echo.Context) error {
 		"data":    refund,
 	})
 }
-
-// Helper function for pagination
-func getPagination(c echo.Context) (int, int) {
-	page := 1
-	limit := 20
-
-	if p := c.QueryParam("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
-	}
-	if l := c.QueryParam("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
-	}
-
-	return page, limit
-}
@@ -25,6 +25,8 @@ func NewReportHandler(reportUC *reports.UseCase) *ReportHandler {
 
 // RegisterRoutes registers report routes
 func (h *ReportHandler) RegisterRoutes(g *echo.Group, authMW, adminMW echo.MiddlewareFunc) {
+	g.GET("/me/recently-viewed", h.GetRecentlyViewed, authMW)
+
 	r := g.Group("/reports", authMW)
 
 	// Recently Viewed
@@ -56,8 +58,8 @@ func (h *ReportHandler) TrackView(c echo.Context) error {
 		return response.BadRequest(c, "Invalid course ID")
 	}
 
-	if err := h.reportUC.TrackView(c.Request().Context(), claims.UserID, courseID); err != nil {
-		return response.InternalError(c, "Failed to track view")
+	if err := h.reportUC.RecordView(c.Request().Context(), claims.UserID, courseID); err != nil {
+		return err
 	}
 
 	return response.Success(c, nil)
@@ -69,6 +71,7 @@ func (h *ReportHandler) TrackView(c echo.Context) error {
 // @Security BearerAuth
 // @Param limit query int false "Limit"
 // @Success 200 {object} response.Response{data=[]domain.RecentlyViewed}
+// @Router /me/recently-viewed [get]
 // @Router /reports/recently-viewed [get]
 func (h *ReportHandler) GetRecentlyViewed(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
@@ -81,7 +84,7 @@ func (h *ReportHandler) GetRecentlyViewed(c echo.Context) error {
 
 	items, err := h.reportUC.GetRecentlyViewed(c.Request().Context(), claims.UserID, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get recently viewed")
+		return err
 	}
 
 	return response.Success(c, items)
@@ -96,7 +99,7 @@ func (h *ReportHandler) GetRecentlyViewed(c echo.Context) error {
 func (h *ReportHandler) ClearRecentlyViewed(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 	if err := h.reportUC.ClearRecentlyViewed(c.Request().Context(), claims.UserID); err != nil {
-		return response.InternalError(c, "Failed to clear recently viewed")
+		return err
 	}
 	return response.NoContent(c)
 }
@@ -118,7 +121,7 @@ func (h *ReportHandler) ExportData(c echo.Context) error {
 
 	result, err := h.reportUC.ExportData(c.Request().Context(), req)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	c.Response().Header().Set(echo.HeaderContentType, result.ContentType)
@@ -144,7 +147,7 @@ func (h *ReportHandler) CreateScheduledReport(c echo.Context) error {
 
 	created, err := h.reportUC.CreateScheduledReport(c.Request().Context(), claims.UserID, &report)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Created(c, created)
@@ -161,7 +164,7 @@ func (h *ReportHandler) GetMyScheduledReports(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
 	reports, err := h.reportUC.GetMyScheduledReports(c.Request().Context(), claims.UserID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get scheduled reports")
+		return err
 	}
 	return response.Success(c, reports)
 }
@@ -190,7 +193,7 @@ func (h *ReportHandler) UpdateScheduledReport(c echo.Context) error {
 
 	updated, err := h.reportUC.UpdateScheduledReport(c.Request().Context(), claims.UserID, id, &update)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, updated)
@@ -211,7 +214,7 @@ func (h *ReportHandler) DeleteScheduledReport(c echo.Context) error {
 	}
 
 	if err := h.reportUC.DeleteScheduledReport(c.Request().Context(), claims.UserID, id); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.NoContent(c)
@@ -24,7 +24,7 @@ func NewReviewHandler(reviewUC *review.UseCase) *ReviewHandler {
 // RegisterRoutes registers review routes
 func (h *ReviewHandler) RegisterRoutes(g *echo.Group, authMW, tutorMW echo.MiddlewareFunc) {
 	reviews := g.Group("/reviews")
-	reviews.GET("/course/:courseId", h.GetCourseReviews)
+	listRoute(reviews, "/course/:courseId", h.GetCourseReviews)
 	reviews.GET("/course/:courseId/summary", h.GetRatingSummary)
 	reviews.GET("/:id", h.GetReview)
 	reviews.GET("/course/:courseId/my", h.GetMyReview, authMW)
@@ -32,8 +32,15 @@ func (h *ReviewHandler) RegisterRoutes(g *echo.Group, authMW, tutorMW echo.Middl
 	reviews.PUT("/:id", h.UpdateReview, authMW)
 	reviews.DELETE("/:id", h.DeleteReview, authMW)
 	reviews.POST("/:id/vote", h.VoteReview, authMW)
-	reviews.POST("/:id/reply", h.ReplyToReview, authMW, tutorMW)
+	reviews.DELETE("/:id/vote", h.RemoveVote, authMW)
+	reviews.POST("/:id/response", h.RespondToReview, authMW, tutorMW)
+	reviews.POST("/:id/reply", h.ReplyToReview, authMW, tutorMW) // superseded by /response
 	reviews.PATCH("/:id/feature", h.FeatureReview, authMW, tutorMW)
+	reviews.POST("/:id/moderate", h.ModerateReview, authMW, tutorMW)
+
+	listRoute(g, "/admin/reviews/pending", h.ListPendingReviews, authMW, tutorMW)
+
+	g.GET("/courses/:id/rating-distribution", h.GetRatingDistribution)
 }
 
 // GetCourseReviews godoc
@@ -50,13 +57,11 @@ func (h *ReviewHandler) GetCourseReviews(c echo.Context) error {
 		return response.BadRequest(c, "Invalid course ID")
 	}
 
-	page := 1
-	limit := 10
-	// Parse query params if needed
+	page, limit := ParsePaginationWithin(c, 10, maxPageLimit)
 
 	reviews, total, err := h.reviewUC.GetCourseReviews(c.Request().Context(), courseID, page, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get reviews")
+		return err
 	}
 
 	return response.Paginated(c, reviews, page, limit, total)
@@ -76,12 +81,33 @@ func (h *ReviewHandler) GetRatingSummary(c echo.Context) error {
 
 	summary, err := h.reviewUC.GetCourseRatingSummary(c.Request().Context(), courseID)
 	if err != nil {
-		return response.InternalError(c, "Failed to get rating summary")
+		return err
 	}
 
 	return response.Success(c, summary)
 }
 
+// GetRatingDistribution godoc
+// @Summary Get star rating distribution for a course
+// @Tags Reviews
+// @Param id path string true "Course ID"
+// @Success 200 {object} response.Response{data=review.RatingDistribution}
+// @Failure 404 {object} response.Response
+// @Router /courses/{id}/rating-distribution [get]
+func (h *ReviewHandler) GetRatingDistribution(c echo.Context) error {
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid course ID")
+	}
+
+	distribution, err := h.reviewUC.GetRatingDistribution(c.Request().Context(), courseID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, distribution)
+}
+
 // GetReview godoc
 // @Summary Get review by ID
 // @Tags Reviews
@@ -133,6 +159,8 @@ func (h *ReviewHandler) GetMyReview(c echo.Context) error {
 // @Produce json
 // @Param request body review.CreateReviewInput true "Review data"
 // @Success 201 {object} response.Response{data=domain.CourseReview}
+// @Failure 403 {object} response.Response "Not enrolled, or not far enough through the course"
+// @Failure 409 {object} response.Response "Already reviewed; use PUT /reviews/{id}"
 // @Router /reviews [post]
 func (h *ReviewHandler) CreateReview(c echo.Context) error {
 	claims, _ := middleware.GetClaims(c)
@@ -148,7 +176,7 @@ func (h *ReviewHandler) CreateReview(c echo.Context) error {
 
 	reviewObj, err := h.reviewUC.CreateReview(c.Request().Context(), claims.UserID, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Created(c, reviewObj)
@@ -179,7 +207,7 @@ func (h *ReviewHandler) UpdateReview(c echo.Context) error {
 
 	reviewObj, err := h.reviewUC.UpdateReview(c.Request().Context(), id, claims.UserID, input)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.Success(c, reviewObj)
@@ -202,7 +230,7 @@ func (h *ReviewHandler) DeleteReview(c echo.Context) error {
 	isAdmin := claims.Role == domain.RoleAdmin
 
 	if err := h.reviewUC.DeleteReview(c.Request().Context(), id, claims.UserID, isAdmin); err != nil {
-		return response.BadRequest(c, err.Error())
+		return badRequest(c, err)
 	}
 
 	return response.NoContent(c)
@@ -210,17 +238,19 @@ func (h *ReviewHandler) DeleteReview(c echo.Context) error {
 
 // VoteReviewInput for voting on a review
 type VoteReviewInput struct {
-	IsHelpful bool `json:"is_helpful"`
+	IsHelpful *bool `json:"is_helpful" validate:"required"`
 }
 
 // VoteReview godoc
 // @Summary Vote on a review
+// @Description Records whether the review was helpful. Voting again changes the vote.
 // @Tags Reviews
 // @Security BearerAuth
 // @Accept json
+// @Produce json
 // @Param id path string true "Review ID"
 // @Param request body VoteReviewInput true "Vote"
-// @Success 200 {object} response.Response
+// @Success 200 {object} response.Response{data=review.VoteCounts}
 // @Router /reviews/{id}/vote [post]
 func (h *ReviewHandler) VoteReview(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
@@ -235,20 +265,86 @@ func (h *ReviewHandler) VoteReview(c echo.Context) error {
 		return response.BadRequest(c, "Invalid request body")
 	}
 
-	if err := h.reviewUC.VoteReview(c.Request().Context(), id, claims.UserID, input.IsHelpful); err != nil {
-		return response.BadRequest(c, err.Error())
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	counts, err := h.reviewUC.VoteReview(c.Request().Context(), id, claims.UserID, *input.IsHelpful)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, counts)
+}
+
+// RemoveVote godoc
+// @Summary Remove my vote on a review
+// @Tags Reviews
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Review ID"
+// @Success 200 {object} response.Response{data=review.VoteCounts}
+// @Router /reviews/{id}/vote [delete]
+func (h *ReviewHandler) RemoveVote(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid review ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+
+	counts, err := h.reviewUC.RemoveVote(c.Request().Context(), id, claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, counts)
+}
+
+// RespondToReview godoc
+// @Summary Respond to a review (instructor)
+// @Description Sets the course instructor's public response. Responding again replaces it.
+// @Tags Reviews
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Param request body review.RespondToReviewInput true "Response"
+// @Success 200 {object} response.Response{data=domain.CourseReview}
+// @Router /reviews/{id}/response [post]
+func (h *ReviewHandler) RespondToReview(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid review ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+
+	var input review.RespondToReviewInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	reviewObj, err := h.reviewUC.RespondToReview(c.Request().Context(), id, claims.UserID, input.Response)
+	if err != nil {
+		return err
 	}
 
-	return response.SuccessWithMessage(c, "Vote recorded", nil)
+	return response.Success(c, reviewObj)
 }
 
 // ReplyToReview godoc
 // @Summary Reply to a review (instructor)
+// @Description Deprecated: use POST /reviews/{id}/response
 // @Tags Reviews
 // @Security BearerAuth
 // @Accept json
 // @Param id path string true "Review ID"
-// @Param request body review.ReplyToReviewInput true "Reply"
+// @Param request body object{reply=string} true "Reply"
 // @Success 200 {object} response.Response{data=domain.CourseReview}
 // @Router /reviews/{id}/reply [post]
 func (h *ReviewHandler) ReplyToReview(c echo.Context) error {
@@ -259,7 +355,9 @@ func (h *ReviewHandler) ReplyToReview(c echo.Context) error {
 
 	claims, _ := middleware.GetClaims(c)
 
-	var input review.ReplyToReviewInput
+	var input struct {
+		Reply string `json:"reply" validate:"required,max=2000"`
+	}
 	if err := c.Bind(&input); err != nil {
 		return response.BadRequest(c, "Invalid request body")
 	}
@@ -268,9 +366,9 @@ func (h *ReviewHandler) ReplyToReview(c echo.Context) error {
 		return validator.FormatValidationErrors(err)
 	}
 
-	reviewObj, err := h.reviewUC.ReplyToReview(c.Request().Context(), id, claims.UserID, input.Reply)
+	reviewObj, err := h.reviewUC.RespondToReview(c.Request().Context(), id, claims.UserID, input.Reply)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, reviewObj)
@@ -302,8 +400,71 @@ func (h *ReviewHandler) FeatureReview(c echo.Context) error {
 	}
 
 	if err := h.reviewUC.FeatureReview(c.Request().Context(), id, input.Featured); err != nil {
-		return response.InternalError(c, "Failed to update review")
+		return err
 	}
 
 	return response.SuccessWithMessage(c, "Review updated", nil)
 }
+
+// ListPendingReviews godoc
+// @Summary List reviews waiting for moderation
+// @Description Admins see every pending review; instructors see those on their own courses.
+// @Tags Reviews
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} response.Response{data=[]domain.CourseReview}
+// @Router /admin/reviews/pending [get]
+func (h *ReviewHandler) ListPendingReviews(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	page, limit := ParsePagination(c)
+
+	var instructorID *uuid.UUID
+	if claims.Role != domain.RoleAdmin {
+		instructorID = &claims.UserID
+	}
+
+	reviews, total, err := h.reviewUC.ListPendingReviews(c.Request().Context(), instructorID, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return response.Paginated(c, reviews, page, limit, total)
+}
+
+// ModerateReview godoc
+// @Summary Approve or reject a review
+// @Tags Reviews
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Param request body review.ModerateReviewInput true "Decision"
+// @Success 200 {object} response.Response{data=domain.CourseReview}
+// @Router /reviews/{id}/moderate [post]
+func (h *ReviewHandler) ModerateReview(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid review ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+
+	var input review.ModerateReviewInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	reviewObj, err := h.reviewUC.ModerateReview(c.Request().Context(), id, claims.UserID, claims.Role == domain.RoleAdmin, domain.ReviewStatus(input.Status))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, reviewObj)
+}
@@ -22,11 +22,13 @@ func NewSearchHandler(searchUC *search.UseCase) *SearchHandler {
 }
 
 // RegisterRoutes registers search routes
-func (h *SearchHandler) RegisterRoutes(g *echo.Group, optionalAuthMW echo.MiddlewareFunc) {
+func (h *SearchHandler) RegisterRoutes(g *echo.Group, authMW, optionalAuthMW echo.MiddlewareFunc) {
 	s := g.Group("/search")
 	s.GET("", h.Search, optionalAuthMW)
 	s.GET("/suggestions", h.GetSuggestions)
 	s.GET("/trending", h.GetTrendingSearches)
+	s.GET("/recent", h.GetMyRecentSearches, authMW)
+	s.DELETE("/recent", h.ClearMySearchHistory, authMW)
 	s.GET("/facets", h.GetFacets)
 	s.GET("/related/:courseId", h.GetRelatedCourses)
 }
@@ -48,74 +50,19 @@ func (h *SearchHandler) RegisterRoutes(g *echo.Group, optionalAuthMW echo.Middle
 // @Success 200 {object} response.Response{data=search.SearchResult}
 // @Router /search [get]
 func (h *SearchHandler) Search(c echo.Context) error {
-	input := search.SearchInput{
-		Query:     c.QueryParam("q"),
-		SortBy:    c.QueryParam("sort_by"),
-		SortOrder: c.QueryParam("sort_order"),
-	}
+	input := parseSearchFilters(c)
+	input.SortBy = c.QueryParam("sort_by")
+	input.SortOrder = c.QueryParam("sort_order")
 
-	// Parse category ID
-	if catID := c.QueryParam("category_id"); catID != "" {
-		if id, err := uuid.Parse(catID); err == nil {
-			input.CategoryID = &id
-		}
-	}
+	input.Page, input.Limit = ParsePagination(c)
 
-	// Parse level
-	if level := c.QueryParam("level"); level != "" {
-		input.Level = &level
-	}
-
-	// Parse prices
-	if minPrice := c.QueryParam("min_price"); minPrice != "" {
-		if val, err := strconv.ParseFloat(minPrice, 64); err == nil {
-			input.MinPrice = &val
-		}
-	}
-	if maxPrice := c.QueryParam("max_price"); maxPrice != "" {
-		if val, err := strconv.ParseFloat(maxPrice, 64); err == nil {
-			input.MaxPrice = &val
-		}
-	}
-
-	// Parse rating
-	if minRating := c.QueryParam("min_rating"); minRating != "" {
-		if val, err := strconv.ParseFloat(minRating, 64); err == nil {
-			input.MinRating = &val
-		}
-	}
-
-	// Parse is_free
-	if isFree := c.QueryParam("is_free"); isFree == "true" {
-		free := true
-		input.IsFree = &free
-	}
-
-	// Parse pagination
-	input.Page = 1
-	input.Limit = 20
-	if page := c.QueryParam("page"); page != "" {
-		if val, err := strconv.Atoi(page); err == nil {
-			input.Page = val
-		}
-	}
-	if limit := c.QueryParam("limit"); limit != "" {
-		if val, err := strconv.Atoi(limit); err == nil {
-			input.Limit = val
-		}
+	if claims, ok := middleware.GetClaims(c); ok {
+		input.UserID = &claims.UserID
 	}
 
 	result, err := h.searchUC.Search(c.Request().Context(), input)
 	if err != nil {
-		return response.InternalError(c, "Search failed")
-	}
-
-	// Record search for analytics (optional)
-	claims, ok := middleware.GetClaims(c)
-	if ok && input.Query != "" {
-		go func() {
-			_ = h.searchUC.RecordSearch(c.Request().Context(), input.Query, &claims.UserID, result.Total)
-		}()
+		return err
 	}
 
 	return response.Success(c, result)
@@ -171,18 +118,66 @@ func (h *SearchHandler) GetTrendingSearches(c echo.Context) error {
 	return response.Success(c, trending)
 }
 
+// GetMyRecentSearches godoc
+// @Summary Get my recent searches
+// @Tags Search
+// @Security BearerAuth
+// @Param limit query int false "Limit (default 10)"
+// @Success 200 {object} response.Response{data=[]string}
+// @Router /search/recent [get]
+func (h *SearchHandler) GetMyRecentSearches(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	limit := 10
+	if l := c.QueryParam("limit"); l != "" {
+		if val, err := strconv.Atoi(l); err == nil {
+			limit = val
+		}
+	}
+
+	searches, err := h.searchUC.GetMyRecentSearches(c.Request().Context(), claims.UserID, limit)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, searches)
+}
+
+// ClearMySearchHistory godoc
+// @Summary Clear my search history
+// @Tags Search
+// @Security BearerAuth
+// @Success 204
+// @Router /search/recent [delete]
+func (h *SearchHandler) ClearMySearchHistory(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	if err := h.searchUC.ClearMySearchHistory(c.Request().Context(), claims.UserID); err != nil {
+		return err
+	}
+
+	return response.NoContent(c)
+}
+
 // GetFacets godoc
 // @Summary Get search facets for filtering
+// @Description Each facet is counted against the other active filters
 // @Tags Search
 // @Param q query string false "Search query to scope facets"
+// @Param category_id query string false "Category ID"
+// @Param level query string false "Level (beginner, intermediate, advanced)"
+// @Param min_price query number false "Minimum price"
+// @Param max_price query number false "Maximum price"
+// @Param min_rating query number false "Minimum rating"
+// @Param is_free query boolean false "Free courses only"
 // @Success 200 {object} response.Response{data=search.SearchFacets}
 // @Router /search/facets [get]
 func (h *SearchHandler) GetFacets(c echo.Context) error {
-	query := c.QueryParam("q")
+	input := parseSearchFilters(c)
 
-	facets, err := h.searchUC.GetSearchFacets(c.Request().Context(), query)
+	facets, err := h.searchUC.GetSearchFacets(c.Request().Context(), input)
 	if err != nil {
-		return response.InternalError(c, "Failed to get facets")
+		return err
 	}
 
 	return response.Success(c, facets)
@@ -210,8 +205,54 @@ func (h *SearchHandler) GetRelatedCourses(c echo.Context) error {
 
 	courses, err := h.searchUC.GetRelatedCourses(c.Request().Context(), courseID, limit)
 	if err != nil {
-		return response.InternalError(c, "Failed to get related courses")
+		return err
 	}
 
 	return response.Success(c, courses)
 }
+
+// parseSearchFilters reads the query and filter params shared by search and facets
+func parseSearchFilters(c echo.Context) search.SearchInput {
+	input := search.SearchInput{
+		Query: c.QueryParam("q"),
+	}
+
+	// Parse category ID
+	if catID := c.QueryParam("category_id"); catID != "" {
+		if id, err := uuid.Parse(catID); err == nil {
+			input.CategoryID = &id
+		}
+	}
+
+	// Parse level
+	if level := c.QueryParam("level"); level != "" {
+		input.Level = &level
+	}
+
+	// Parse prices
+	if minPrice := c.QueryParam("min_price"); minPrice != "" {
+		if val, err := strconv.ParseFloat(minPrice, 64); err == nil {
+			input.MinPrice = &val
+		}
+	}
+	if maxPrice := c.QueryParam("max_price"); maxPrice != "" {
+		if val, err := strconv.ParseFloat(maxPrice, 64); err == nil {
+			input.MaxPrice = &val
+		}
+	}
+
+	// Parse rating
+	if minRating := c.QueryParam("min_rating"); minRating != "" {
+		if val, err := strconv.ParseFloat(minRating, 64); err == nil {
+			input.MinRating = &val
+		}
+	}
+
+	// Parse is_free
+	if isFree := c.QueryParam("is_free"); isFree == "true" {
+		free := true
+		input.IsFree = &free
+	}
+
+	return input
+}
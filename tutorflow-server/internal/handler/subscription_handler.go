@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"io"
 	"net/http"
 
+	"github.com/stripe/stripe-go/v76/webhook"
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/service/payment"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -13,11 +16,12 @@ import (
 // SubscriptionHandler handles subscription-related HTTP requests
 type SubscriptionHandler struct {
 	subscriptionUC domain.SubscriptionUseCase
+	paymentSvc     *payment.Service
 }
 
 // NewSubscriptionHandler creates a new subscription handler
-func NewSubscriptionHandler(uc domain.SubscriptionUseCase) *SubscriptionHandler {
-	return &SubscriptionHandler{subscriptionUC: uc}
+func NewSubscriptionHandler(uc domain.SubscriptionUseCase, paymentSvc *payment.Service) *SubscriptionHandler {
+	return &SubscriptionHandler{subscriptionUC: uc, paymentSvc: paymentSvc}
 }
 
 // RegisterRoutes registers subscription routes
@@ -33,6 +37,8 @@ func (h *SubscriptionHandler) RegisterRoutes(e *echo.Group, authMiddleware echo.
 
 	// User subscription routes
 	subs := e.Group("/subscriptions", authMiddleware)
+	subs.GET("", h.GetMySubscription)
+	subs.POST("", h.Subscribe)
 	subs.GET("/my", h.GetMySubscription)
 	subs.POST("/subscribe", h.Subscribe)
 	subs.POST("/cancel", h.Cancel)
@@ -156,7 +162,8 @@ type SubscribeRequest struct {
 	Interval domain.SubscriptionInterval `json:"interval"`
 }
 
-// Subscribe creates a new subscription
+// Subscribe starts a subscription. Paid plans respond with a checkout_url to
+// send the user to.
 func (h *SubscriptionHandler) Subscribe(c echo.Context) error {
 	userID := getUserIDFromContext(c)
 
@@ -168,7 +175,7 @@ func (h *SubscriptionHandler) Subscribe(c echo.Context) error {
 		})
 	}
 
-	sub, err := h.subscriptionUC.Subscribe(c.Request().Context(), userID, req.PlanSlug, req.Interval)
+	checkout, err := h.subscriptionUC.Subscribe(c.Request().Context(), userID, req.PlanSlug, req.Interval)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"success": false,
@@ -178,7 +185,7 @@ func (h *SubscriptionHandler) Subscribe(c echo.Context) error {
 
 	return c.JSON(http.StatusCreated, map[string]interface{}{
 		"success": true,
-		"data":    sub,
+		"data":    checkout,
 	})
 }
 
@@ -249,19 +256,24 @@ func (h *SubscriptionHandler) ChangePlan(c echo.Context) error {
 
 // HandleStripeWebhook handles Stripe subscription webhooks
 func (h *SubscriptionHandler) HandleStripeWebhook(c echo.Context) error {
-	// In production, verify the webhook signature
-	var payload map[string]interface{}
-	if err := c.Bind(&payload); err != nil {
+	payload, err := io.ReadAll(c.Request().Body)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{
 			"success": false,
 			"error":   map[string]string{"message": "Invalid payload"},
 		})
 	}
 
-	eventType, _ := payload["type"].(string)
-	data, _ := payload["data"].(map[string]interface{})
+	event, err := webhook.ConstructEvent(payload, c.Request().Header.Get("Stripe-Signature"), h.paymentSvc.GetSubscriptionWebhookSecret())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error":   map[string]string{"message": "Invalid signature"},
+		})
+	}
 
-	if err := h.subscriptionUC.HandleWebhook(c.Request().Context(), eventType, data); err != nil {
+	data := map[string]interface{}{"object": event.Data.Object}
+	if err := h.subscriptionUC.HandleWebhook(c.Request().Context(), string(event.Type), data); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"success": false,
 			"error":   map[string]string{"message": err.Error()},
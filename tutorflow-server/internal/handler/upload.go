@@ -1,10 +1,19 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
 	"github.com/labstack/echo/v4"
 
-	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/validator"
 	"github.com/tutorflow/tutorflow-server/internal/service/storage"
 )
 
@@ -23,73 +32,42 @@ func (h *UploadHandler) RegisterRoutes(g *echo.Group, authMW echo.MiddlewareFunc
 	g.POST("/image", h.UploadImage, authMW)
 	g.POST("/video", h.UploadVideo, authMW)
 	g.POST("/document", h.UploadDocument, authMW)
+	g.POST("/presign", h.PresignUpload, authMW)
 }
 
 // UploadImage godoc
 // @Summary Upload image
+// @Description Stores the image as uploaded plus WebP copies resized to thumbnail (200px wide), card (640px) and full (1600px) widths.
 // @Tags Uploads
 // @Security BearerAuth
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "Image file"
-// @Param folder formData string false "Folder name"
-// @Success 200 {object} response.Response{data=map[string]string}
+// @Param folder formData string false "Folder name; must come before the file, or the upload is refused"
+// @Success 200 {object} response.Response{data=map[string]interface{}}
+// @Failure 400 {object} response.Response "Wrong file type, or content that doesn't match it"
+// @Failure 413 {object} response.Response
+// @Failure 422 {object} response.Response "Rejected by the upload scanner"
 // @Router /uploads/image [post]
 func (h *UploadHandler) UploadImage(c echo.Context) error {
-	file, err := c.FormFile("file")
-	if err != nil {
-		return response.BadRequest(c, "No file provided")
-	}
-
-	folder := c.FormValue("folder")
-	if folder == "" {
-		folder = "images"
-	}
-
-	claims, _ := middleware.GetClaims(c)
-	_ = claims // Could log who uploaded
-
-	url, err := h.storageSvc.UploadImage(c.Request().Context(), file, folder)
-	if err != nil {
-		return response.BadRequest(c, err.Error())
-	}
-
-	return response.Success(c, map[string]string{
-		"url":      url,
-		"filename": file.Filename,
-	})
+	return h.upload(c, storage.KindImage, "images")
 }
 
 // UploadVideo godoc
 // @Summary Upload video
+// @Description Stores the video in the caller's own upload folder, from where it can be registered for a lesson with POST /videos/lessons/{lessonId}/upload as file_url. The file itself isn't served; learners watch the transcoded lesson.
 // @Tags Uploads
 // @Security BearerAuth
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "Video file"
-// @Param folder formData string false "Folder name"
 // @Success 200 {object} response.Response{data=map[string]string}
+// @Failure 400 {object} response.Response "Wrong file type, content that doesn't match it, or a folder field"
+// @Failure 413 {object} response.Response
+// @Failure 422 {object} response.Response "Rejected by the upload scanner"
 // @Router /uploads/video [post]
 func (h *UploadHandler) UploadVideo(c echo.Context) error {
-	file, err := c.FormFile("file")
-	if err != nil {
-		return response.BadRequest(c, "No file provided")
-	}
-
-	folder := c.FormValue("folder")
-	if folder == "" {
-		folder = "videos"
-	}
-
-	url, err := h.storageSvc.UploadVideo(c.Request().Context(), file, folder)
-	if err != nil {
-		return response.BadRequest(c, err.Error())
-	}
-
-	return response.Success(c, map[string]string{
-		"url":      url,
-		"filename": file.Filename,
-	})
+	return h.upload(c, storage.KindVideo, domain.VideoUploadFolder(getUserIDFromContext(c)))
 }
 
 // UploadDocument godoc
@@ -99,27 +77,194 @@ func (h *UploadHandler) UploadVideo(c echo.Context) error {
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "Document file"
-// @Param folder formData string false "Folder name"
+// @Param folder formData string false "Folder name; must come before the file, or the upload is refused"
 // @Success 200 {object} response.Response{data=map[string]string}
+// @Failure 400 {object} response.Response "Wrong file type, or content that doesn't match it"
+// @Failure 413 {object} response.Response
+// @Failure 422 {object} response.Response "Rejected by the upload scanner"
 // @Router /uploads/document [post]
 func (h *UploadHandler) UploadDocument(c echo.Context) error {
-	file, err := c.FormFile("file")
+	return h.upload(c, storage.KindDocument, "documents")
+}
+
+// PresignUploadInput describes a file a client wants to upload directly
+type PresignUploadInput struct {
+	Kind        string `json:"kind" validate:"required,oneof=video"`
+	Filename    string `json:"filename" validate:"required"`
+	ContentType string `json:"content_type" validate:"required"`
+	Size        int64  `json:"size" validate:"required,gt=0"`
+}
+
+// PresignUpload godoc
+// @Summary Presign a direct video upload
+// @Description Returns a URL to PUT a video to, straight to object storage, with the headers it must be sent with. The video is stored in the caller's own upload folder, and its URL can then be registered with POST /videos/lessons/{lessonId}/upload as file_url. Images and documents must be uploaded through the API, which checks their content.
+// @Tags Uploads
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body PresignUploadInput true "File to upload"
+// @Success 200 {object} response.Response{data=storage.DirectUpload}
+// @Failure 400 {object} response.Response "Wrong file type, or a content type that doesn't match it"
+// @Failure 413 {object} response.Response
+// @Failure 501 {object} response.Response "Storage is local disk"
+// @Router /uploads/presign [post]
+func (h *UploadHandler) PresignUpload(c echo.Context) error {
+	var input PresignUploadInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	upload, err := h.storageSvc.PresignUpload(c.Request().Context(), getUserIDFromContext(c), storage.Kind(input.Kind), input.Filename, input.ContentType, input.Size)
+	if err != nil {
+		return badRequest(c, err)
+	}
+	return response.Success(c, upload)
+}
+
+// multipartOverhead allows for the form fields and part headers around a file
+const multipartOverhead = 1 << 20
+
+// upload streams the "file" part of a multipart form straight to storage, so
+// the file is never held in memory or written to a temporary file. Because
+// the file is stored as it arrives, the optional "folder" field must be sent
+// before it; a folder sent after the file is refused and the file deleted.
+// Videos always go in the uploader's own folder and refuse the field.
+func (h *UploadHandler) upload(c echo.Context, kind storage.Kind, folder string) error {
+	// Refuse files that are clearly too large before reading any of them
+	if c.Request().ContentLength > h.storageSvc.MaxSize(kind)+multipartOverhead {
+		return domain.ErrFileTooLarge
+	}
+
+	reader, err := c.Request().MultipartReader()
 	if err != nil {
 		return response.BadRequest(c, "No file provided")
 	}
 
-	folder := c.FormValue("folder")
-	if folder == "" {
-		folder = "documents"
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return response.BadRequest(c, "No file provided")
+		}
+		if err != nil {
+			return badRequest(c, err)
+		}
+
+		switch {
+		case part.FormName() == "folder":
+			if kind == storage.KindVideo {
+				return rejectFolder(c, kind)
+			}
+			value, err := io.ReadAll(io.LimitReader(part, 256))
+			if err != nil {
+				return badRequest(c, err)
+			}
+			if len(value) > 0 {
+				folder = string(value)
+			}
+		case part.FormName() == "file" && part.FileName() != "":
+			ctx := c.Request().Context()
+			stored, err := h.store(c, kind, folder, part)
+			if err != nil {
+				return badRequest(c, err)
+			}
+			if folderFollows(reader) {
+				_ = h.storageSvc.DeleteImage(ctx, stored)
+				return rejectFolder(c, kind)
+			}
+
+			data := map[string]interface{}{
+				"url":      stored.URL,
+				"filename": part.FileName(),
+			}
+			if kind == storage.KindImage {
+				data["variants"] = stored.Variants
+			}
+			return response.Success(c, data)
+		}
+	}
+}
+
+// folderFollows reports whether the rest of the form has a "folder" field
+func folderFollows(reader *multipart.Reader) bool {
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return false
+		}
+		if part.FormName() == "folder" {
+			return true
+		}
+	}
+}
+
+// rejectFolder answers a "folder" field that can't be honoured
+func rejectFolder(c echo.Context, kind storage.Kind) error {
+	if kind == storage.KindVideo {
+		return response.BadRequest(c, "Videos are stored in your own upload folder; folder can't be set")
 	}
+	return response.BadRequest(c, "folder must be sent before file")
+}
+
+// store saves an uploaded file, along with resized variants of images.
+// Other files come back as an image without variants, so either can be
+// deleted with DeleteImage.
+func (h *UploadHandler) store(c echo.Context, kind storage.Kind, folder string, part *multipart.Part) (*storage.Image, error) {
+	ctx := c.Request().Context()
+	contentType := part.Header.Get(echo.HeaderContentType)
 
-	url, err := h.storageSvc.UploadDocument(c.Request().Context(), file, folder)
+	if kind == storage.KindImage {
+		return h.storageSvc.StreamImage(ctx, folder, part.FileName(), contentType, part)
+	}
+
+	url, err := h.storageSvc.Stream(ctx, kind, folder, part.FileName(), contentType, part)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return nil, err
+	}
+	return &storage.Image{URL: url}, nil
+}
+
+// privatePrefixes hold files only handed out through their own authorized
+// endpoints, such as data exports and HLS segments. Lesson videos as
+// uploaded are only read by transcoding; learners get the HLS renditions.
+var privatePrefixes = []string{"exports/", "videos/hls/", "videos/originals/", "videos/uploads/"}
+
+// ServeFile serves a stored file that has no public URL of its own. Files in
+// a private bucket are redirected to a short-lived signed URL.
+func (h *UploadHandler) ServeFile(c echo.Context) error {
+	// Cleaned first so ".." can't reach a private prefix
+	name := strings.TrimPrefix(path.Clean("/"+c.Param("*")), "/")
+	if name == "" {
+		return domain.ErrFileNotFound
+	}
+	for _, prefix := range privatePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return domain.ErrFileNotFound
+		}
 	}
 
-	return response.Success(c, map[string]string{
-		"url":      url,
-		"filename": file.Filename,
-	})
+	ctx := c.Request().Context()
+	signed, err := h.storageSvc.SignedURL(ctx, name)
+	if err != nil {
+		return err
+	}
+	if signed != "" {
+		return c.Redirect(http.StatusFound, signed)
+	}
+
+	stream, contentType, err := h.storageSvc.GetFileStream(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if seeker, ok := stream.(io.ReadSeeker); ok {
+		// Local files support range requests, which video players rely on
+		c.Response().Header().Set(echo.HeaderContentType, contentType)
+		http.ServeContent(c.Response(), c.Request(), name, time.Time{}, seeker)
+		return nil
+	}
+	return c.Stream(http.StatusOK, contentType, stream)
 }
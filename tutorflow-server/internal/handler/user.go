@@ -24,17 +24,19 @@ func NewUserHandler(userUC *user.UseCase) *UserHandler {
 // RegisterRoutes registers user routes
 func (h *UserHandler) RegisterRoutes(g *echo.Group, authMW, adminMW, managerMW echo.MiddlewareFunc) {
 	// Admin routes
-	g.GET("", h.List, authMW, managerMW)
+	listRoute(g, "", h.List, authMW, managerMW)
 	g.POST("", h.Create, authMW, adminMW)
 	g.GET("/:id", h.GetByID, authMW)
 	g.PUT("/:id", h.Update, authMW)
 	g.DELETE("/:id", h.Delete, authMW, adminMW)
 	g.PATCH("/:id/status", h.UpdateStatus, authMW, managerMW)
 	g.PATCH("/:id/role", h.UpdateRole, authMW, adminMW)
+	g.POST("/:id/suspend", h.Suspend, authMW, adminMW)
+	g.POST("/:id/reactivate", h.Reactivate, authMW, adminMW)
 
 	// Tutor routes
 	tutors := g.Group("/tutors")
-	tutors.GET("", h.ListTutors)
+	listRoute(tutors, "", h.ListTutors)
 	tutors.GET("/:id", h.GetTutor)
 	tutors.PUT("/:id/profile", h.UpdateTutorProfile, authMW)
 }
@@ -56,6 +58,7 @@ func (h *UserHandler) List(c echo.Context) error {
 	if err := c.Bind(&input); err != nil {
 		return response.BadRequest(c, "Invalid query parameters")
 	}
+	input.Page, input.Limit = ParsePaginationWithin(c, defaultPageLimit, 100)
 
 	users, total, err := h.userUC.List(c.Request().Context(), input)
 	if err != nil {
@@ -204,13 +207,113 @@ func (h *UserHandler) UpdateStatus(c echo.Context) error {
 		return validator.FormatValidationErrors(err)
 	}
 
-	if err := h.userUC.UpdateStatus(c.Request().Context(), id, input); err != nil {
+	claims, _ := middleware.GetClaims(c)
+	if err := h.userUC.UpdateStatus(c.Request().Context(), claims.UserID, id, input); err != nil {
 		return err
 	}
 
 	return response.SuccessWithMessage(c, "Status updated successfully", nil)
 }
 
+// RegisterAccountRoutes registers the caller's own account routes
+func (h *UserHandler) RegisterAccountRoutes(g *echo.Group, authMW echo.MiddlewareFunc) {
+	g.DELETE("/me", h.DeleteMe, authMW)
+	g.POST("/me/deletion-request", h.RequestDeletion, authMW)
+}
+
+// RequestDeletion godoc
+// @Summary Email an account deletion link
+// @Description Emails a link that confirms deleting the account, for users who sign in through a provider and have no password. The link works for an hour and is passed to DELETE /me as the token.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /me/deletion-request [post]
+func (h *UserHandler) RequestDeletion(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	if err := h.userUC.RequestAccountDeletion(c.Request().Context(), claims.UserID); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "Deletion email sent", nil)
+}
+
+// DeleteMe godoc
+// @Summary Delete my account
+// @Description Anonymizes the account: personal details are removed, sessions revoked, active enrollments cancelled and subscriptions ended straight away. Orders are kept without personal details, and reviews and discussions show as from a deleted user. Confirm with the password, or with the token from POST /me/deletion-request.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body user.DeleteAccountInput true "Password or deletion link token"
+// @Success 204
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /me [delete]
+func (h *UserHandler) DeleteMe(c echo.Context) error {
+	claims, _ := middleware.GetClaims(c)
+
+	var input user.DeleteAccountInput
+	if err := c.Bind(&input); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	if err := validator.Validate(input); err != nil {
+		return validator.FormatValidationErrors(err)
+	}
+
+	if err := h.userUC.DeleteAccount(c.Request().Context(), claims.UserID, input); err != nil {
+		return err
+	}
+
+	return response.NoContent(c)
+}
+
+// Suspend godoc
+// @Summary Suspend user
+// @Description Blocks sign-in and API access, and hides the user's courses from listings, until reactivated
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response
+// @Router /users/{id}/suspend [post]
+func (h *UserHandler) Suspend(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid user ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.userUC.Suspend(c.Request().Context(), claims.UserID, id); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "User suspended", nil)
+}
+
+// Reactivate godoc
+// @Summary Reactivate suspended user
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response
+// @Router /users/{id}/reactivate [post]
+func (h *UserHandler) Reactivate(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid user ID")
+	}
+
+	claims, _ := middleware.GetClaims(c)
+	if err := h.userUC.Reactivate(c.Request().Context(), claims.UserID, id); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, "User reactivated", nil)
+}
+
 // UpdateRole godoc
 // @Summary Update user role
 // @Tags Users
@@ -236,7 +339,8 @@ func (h *UserHandler) UpdateRole(c echo.Context) error {
 		return validator.FormatValidationErrors(err)
 	}
 
-	if err := h.userUC.UpdateRole(c.Request().Context(), id, input); err != nil {
+	claims, _ := middleware.GetClaims(c)
+	if err := h.userUC.UpdateRole(c.Request().Context(), claims.UserID, id, input); err != nil {
 		return err
 	}
 
@@ -247,23 +351,26 @@ func (h *UserHandler) UpdateRole(c echo.Context) error {
 // @Summary List tutors
 // @Tags Users
 // @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
 // @Success 200 {object} response.Response
 // @Router /users/tutors [get]
 func (h *UserHandler) ListTutors(c echo.Context) error {
 	tutorRole := domain.RoleTutor
 	activeStatus := domain.StatusActive
 
+	page, limit := ParsePaginationWithin(c, 50, 100)
 	users, total, err := h.userUC.List(c.Request().Context(), user.ListInput{
 		Role:   &tutorRole,
 		Status: &activeStatus,
-		Page:   1,
-		Limit:  50,
+		Page:   page,
+		Limit:  limit,
 	})
 	if err != nil {
 		return err
 	}
 
-	return response.Paginated(c, users, 1, 50, total)
+	return response.Paginated(c, users, page, limit, total)
 }
 
 // GetTutor godoc
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/video"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -41,13 +43,20 @@ func (h *VideoHandler) RegisterRoutes(e *echo.Group, authMiddleware echo.Middlew
 	drm.GET("/devices", h.GetDevices)
 	drm.DELETE("/devices/:deviceId", h.RemoveDevice)
 
-	// Key delivery (no auth - uses videoId)
+	// Device management
+	me := e.Group("/me/devices", authMiddleware)
+	me.GET("", h.GetDevices)
+	me.PATCH("/:deviceId", h.RenameDevice)
+	me.DELETE("/:deviceId", h.RemoveDevice)
+
+	// Key delivery (no auth header - players pass the playback token)
 	e.GET("/drm/key/:videoId", h.GetEncryptionKey)
 
 	// Admin routes
 	admin := e.Group("/admin/videos", authMiddleware)
 	admin.POST("/:id/encrypt", h.EnableEncryption)
 	admin.POST("/:id/rotate-key", h.RotateKey)
+	admin.GET("/watermarks/:code", h.TraceWatermark)
 
 	// Stream Proxy
 	e.GET("/videos/stream/:videoId/*", h.ServeHLS)
@@ -55,7 +64,7 @@ func (h *VideoHandler) RegisterRoutes(e *echo.Group, authMiddleware echo.Middlew
 
 // UploadVideoRequest represents a video upload request
 type UploadVideoRequest struct {
-	FileURL string `json:"file_url"`
+	FileURL string `json:"file_url"` // a video the caller uploaded, with /uploads/video or a presigned URL
 }
 
 // UploadVideo uploads a video for a lesson
@@ -124,13 +133,10 @@ func (h *VideoHandler) UploadVideo(c echo.Context) error {
 			"error":   map[string]string{"message": "No video file uploaded and no file_url provided"},
 		})
 	}
-	asset, err := h.videoUC.UploadVideo(c.Request().Context(), lessonID, req.FileURL)
+	asset, err := h.videoUC.UploadVideo(c.Request().Context(), lessonID, getUserIDFromContext(c), req.FileURL)
 	if err != nil {
 		fmt.Printf("UploadVideo: UploadVideo failed: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   map[string]string{"message": err.Error()},
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, map[string]interface{}{
@@ -200,7 +206,7 @@ func (h *VideoHandler) GetPlaybackURL(c echo.Context) error {
 	userID := getUserIDFromContext(c)
 	deviceID := c.QueryParam("device_id")
 
-	url, err := h.videoUC.GetPlaybackURL(c.Request().Context(), lessonID, userID, deviceID)
+	grant, err := h.videoUC.GetPlaybackURL(c.Request().Context(), lessonID, userID, playbackDevice(c, deviceID))
 	if err != nil {
 		return c.JSON(http.StatusForbidden, map[string]interface{}{
 			"success": false,
@@ -210,9 +216,7 @@ func (h *VideoHandler) GetPlaybackURL(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"success": true,
-		"data": map[string]string{
-			"url": url,
-		},
+		"data":    grant,
 	})
 }
 
@@ -236,7 +240,7 @@ func (h *VideoHandler) AuthorizePlayback(c echo.Context) error {
 	}
 
 	// Validate device limit
-	if err := h.videoUC.ValidateDeviceLimit(c.Request().Context(), userID); err != nil {
+	if err := h.videoUC.ValidateDeviceLimit(c.Request().Context(), userID, req.DeviceID); err != nil {
 		return c.JSON(http.StatusForbidden, map[string]interface{}{
 			"success": false,
 			"error":   map[string]string{"message": err.Error()},
@@ -244,7 +248,10 @@ func (h *VideoHandler) AuthorizePlayback(c echo.Context) error {
 	}
 
 	// Generate signed URL
-	url, err := h.videoUC.GetPlaybackURL(c.Request().Context(), req.LessonID, userID, req.DeviceID)
+	grant, err := h.videoUC.GetPlaybackURL(c.Request().Context(), req.LessonID, userID, playbackDevice(c, req.DeviceID))
+	if lock, ok := err.(*domain.LessonLock); ok {
+		return lock
+	}
 	if err != nil {
 		return c.JSON(http.StatusForbidden, map[string]interface{}{
 			"success": false,
@@ -256,7 +263,9 @@ func (h *VideoHandler) AuthorizePlayback(c echo.Context) error {
 		"success": true,
 		"data": map[string]interface{}{
 			"authorized": true,
-			"signed_url": url,
+			"signed_url": grant.URL,
+			"expires_at": grant.ExpiresAt,
+			"watermark":  grant.Watermark,
 		},
 	})
 }
@@ -284,11 +293,17 @@ func (h *VideoHandler) Heartbeat(c echo.Context) error {
 	})
 }
 
-// GetDevices returns user's registered devices
+// GetDevices returns user's registered devices. The caller's device is
+// flagged as current when it sends its ID in device_id or X-Device-ID.
 func (h *VideoHandler) GetDevices(c echo.Context) error {
 	userID := getUserIDFromContext(c)
 
-	devices, err := h.videoUC.GetUserDevices(c.Request().Context(), userID)
+	currentDeviceID := c.QueryParam("device_id")
+	if currentDeviceID == "" {
+		currentDeviceID = c.Request().Header.Get("X-Device-ID")
+	}
+
+	devices, err := h.videoUC.GetUserDevices(c.Request().Context(), userID, currentDeviceID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"success": false,
@@ -302,6 +317,45 @@ func (h *VideoHandler) GetDevices(c echo.Context) error {
 	})
 }
 
+// RenameDeviceRequest represents a device rename request
+type RenameDeviceRequest struct {
+	Name string `json:"name"`
+}
+
+// RenameDevice renames one of the user's devices
+func (h *VideoHandler) RenameDevice(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+
+	var req RenameDeviceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error":   map[string]string{"message": "Invalid request body"},
+		})
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > video.MaxDeviceNameLength {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error":   map[string]string{"message": "Name must be between 1 and 100 characters"},
+		})
+	}
+
+	device, err := h.videoUC.RenameDevice(c.Request().Context(), userID, c.Param("deviceId"), name)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{
+			"success": false,
+			"error":   map[string]string{"message": err.Error()},
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    device,
+	})
+}
+
 // RemoveDevice removes a device from user's account
 func (h *VideoHandler) RemoveDevice(c echo.Context) error {
 	userID := getUserIDFromContext(c)
@@ -327,7 +381,13 @@ func (h *VideoHandler) GetEncryptionKey(c echo.Context) error {
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	key, err := h.videoUC.GetEncryptionKeyByVideoID(c.Request().Context(), videoID)
+	// The token is added to key URIs when the playlist is served
+	token := c.QueryParam("token")
+	if token == "" {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	key, err := h.videoUC.GetEncryptionKey(c.Request().Context(), videoID, token)
 	if err != nil {
 		return c.NoContent(http.StatusForbidden)
 	}
@@ -394,6 +454,37 @@ func (h *VideoHandler) RotateKey(c echo.Context) error {
 	})
 }
 
+// TraceWatermark returns the playback session behind a watermark code (admin)
+func (h *VideoHandler) TraceWatermark(c echo.Context) error {
+	if claims, ok := middleware.GetClaims(c); !ok || claims.Role != domain.RoleAdmin {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{
+			"success": false,
+			"error":   map[string]string{"message": "Admin access required"},
+		})
+	}
+
+	session, err := h.videoUC.TraceWatermark(c.Request().Context(), c.Param("code"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{
+			"success": false,
+			"error":   map[string]string{"message": err.Error()},
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"watermark_code":  session.WatermarkCode,
+			"user_id":         session.UserID,
+			"video_id":        session.VideoID,
+			"device_id":       session.DeviceID,
+			"issued_at":       session.CreatedAt,
+			"expires_at":      session.ExpiresAt,
+			"key_fetch_count": session.KeyFetchCount,
+		},
+	})
+}
+
 // ServeHLS serves HLS content from S3 with token validation and manifest rewriting
 func (h *VideoHandler) ServeHLS(c echo.Context) error {
 	videoID, err := uuid.Parse(c.Param("videoId"))
@@ -403,11 +494,16 @@ func (h *VideoHandler) ServeHLS(c echo.Context) error {
 	file := c.Param("*")
 	token := c.QueryParam("token")
 
+	// Only plain playlist and segment names inside the video's folder
+	if !video.ValidSegmentPath(file) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid path"})
+	}
+
 	// Validate token
 	if token == "" {
 		return c.JSON(http.StatusForbidden, map[string]string{"error": "missing token"})
 	}
-	if err := h.videoUC.ValidatePlayback(c.Request().Context(), token); err != nil {
+	if err := h.videoUC.ValidatePlayback(c.Request().Context(), videoID, token); err != nil {
 		return c.JSON(http.StatusForbidden, map[string]string{"error": "invalid or expired token"})
 	}
 
@@ -418,7 +514,7 @@ func (h *VideoHandler) ServeHLS(c echo.Context) error {
 	}
 	defer stream.Close()
 
-	// Rewrite m3u8 playlist to inject token into segments (key URI uses videoID directly)
+	// Rewrite m3u8 playlist to inject the token into segment and key URIs
 	if strings.HasSuffix(file, ".m3u8") {
 		content, err := io.ReadAll(stream)
 		if err != nil {
@@ -428,7 +524,7 @@ func (h *VideoHandler) ServeHLS(c echo.Context) error {
 		lines := strings.Split(string(content), "\n")
 		var newLines []string
 		for _, line := range lines {
-			// Keep key URI as is (uses videoID)
+			line = video.AddKeyToken(line, token)
 			if !strings.HasPrefix(line, "#") && strings.TrimSpace(line) != "" {
 				// Append token to segment URL
 				if strings.Contains(line, "?") {
@@ -446,3 +542,12 @@ func (h *VideoHandler) ServeHLS(c echo.Context) error {
 
 	return c.Stream(http.StatusOK, contentType, stream)
 }
+
+// playbackDevice describes the requesting client for device registration
+func playbackDevice(c echo.Context, deviceID string) domain.DeviceInfo {
+	return domain.DeviceInfo{
+		DeviceID:  deviceID,
+		UserAgent: c.Request().UserAgent(),
+		IP:        c.RealIP(),
+	}
+}
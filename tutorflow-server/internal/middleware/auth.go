@@ -1,8 +1,13 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
@@ -46,7 +51,7 @@ func AuthMiddleware(jwtManager *jwt.Manager) echo.MiddlewareFunc {
 			}
 
 			// Validate token
-			claims, err := jwtManager.ValidateToken(tokenString)
+			claims, err := jwtManager.ValidateAccessToken(tokenString)
 			if err != nil {
 				if err == jwt.ErrExpiredToken {
 					c.Logger().Errorf("Auth error: Token has expired for path %s", c.Path())
@@ -79,7 +84,7 @@ func OptionalAuthMiddleware(jwtManager *jwt.Manager) echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			claims, err := jwtManager.ValidateToken(parts[1])
+			claims, err := jwtManager.ValidateAccessToken(parts[1])
 			if err == nil {
 				c.Set(UserIDKey, claims.UserID)
 				c.Set(ClaimsKey, claims)
@@ -90,6 +95,88 @@ func OptionalAuthMiddleware(jwtManager *jwt.Manager) echo.MiddlewareFunc {
 	}
 }
 
+// AccountChecker looks up the status of a user's account
+type AccountChecker interface {
+	AccountStatus(ctx context.Context, userID uuid.UUID) (domain.UserStatus, error)
+}
+
+// maxCachedAccounts bounds the status cache; past it, expired entries are
+// dropped and then, if still full, the cache is cleared
+const maxCachedAccounts = 10000
+
+// RejectInactive refuses authenticated requests from suspended accounts with
+// domain.ErrAccountSuspended and from deleted ones with
+// domain.ErrTokenRevoked. Use it after AuthMiddleware: access tokens issued
+// before a suspension or deletion are otherwise valid until they expire.
+// Statuses are cached per instance for ttl so most requests skip the lookup,
+// which means a suspension can take up to ttl to stop a live access token;
+// refreshing is refused straight away.
+func RejectInactive(checker AccountChecker, ttl time.Duration) echo.MiddlewareFunc {
+	type cachedStatus struct {
+		status    domain.UserStatus
+		expiresAt time.Time
+	}
+	var (
+		mu    sync.Mutex
+		cache = make(map[uuid.UUID]cachedStatus)
+	)
+
+	status := func(ctx context.Context, userID uuid.UUID) (domain.UserStatus, error) {
+		now := time.Now()
+		mu.Lock()
+		entry, ok := cache[userID]
+		mu.Unlock()
+		if ok && now.Before(entry.expiresAt) {
+			return entry.status, nil
+		}
+
+		s, err := checker.AccountStatus(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+
+		mu.Lock()
+		if len(cache) >= maxCachedAccounts {
+			for id, e := range cache {
+				if !now.Before(e.expiresAt) {
+					delete(cache, id)
+				}
+			}
+			if len(cache) >= maxCachedAccounts {
+				clear(cache)
+			}
+		}
+		cache[userID] = cachedStatus{status: s, expiresAt: now.Add(ttl)}
+		mu.Unlock()
+		return s, nil
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := GetClaims(c)
+			if !ok {
+				return next(c)
+			}
+
+			s, err := status(c.Request().Context(), claims.UserID)
+			if err != nil {
+				if errors.Is(err, domain.ErrUserNotFound) {
+					return domain.ErrTokenRevoked
+				}
+				return err
+			}
+			switch s {
+			case domain.StatusSuspended:
+				return domain.ErrAccountSuspended
+			case domain.StatusDeleted:
+				return domain.ErrTokenRevoked
+			}
+
+			return next(c)
+		}
+	}
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(c echo.Context) (string, bool) {
 	id := c.Get(UserIDKey)
@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/jwt"
+)
+
+type accountStatuses struct {
+	statuses map[uuid.UUID]domain.UserStatus
+	lookups  int
+}
+
+func (a *accountStatuses) AccountStatus(ctx context.Context, userID uuid.UUID) (domain.UserStatus, error) {
+	a.lookups++
+	status, ok := a.statuses[userID]
+	if !ok {
+		return "", domain.ErrUserNotFound
+	}
+	return status, nil
+}
+
+func TestRejectInactive(t *testing.T) {
+	active, suspended, deleted, missing := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	accounts := &accountStatuses{statuses: map[uuid.UUID]domain.UserStatus{
+		active:    domain.StatusActive,
+		suspended: domain.StatusSuspended,
+		deleted:   domain.StatusDeleted,
+	}}
+
+	e := echo.New()
+	e.HTTPErrorHandler = middleware.ErrorHandler(zap.NewNop().Sugar())
+	signedIn := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if id, err := uuid.Parse(c.Request().Header.Get("X-User")); err == nil {
+				c.Set(middleware.ClaimsKey, &jwt.Claims{UserID: id})
+			}
+			return next(c)
+		}
+	}
+	e.Use(signedIn, middleware.RejectInactive(accounts, time.Minute))
+	e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+
+	request := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name string
+		user string
+		code int
+		body string
+	}{
+		{"anonymous", "", http.StatusNoContent, ""},
+		{"active", active.String(), http.StatusNoContent, ""},
+		{"suspended", suspended.String(), http.StatusForbidden, "USER_SUSPENDED"},
+		{"deleted", deleted.String(), http.StatusUnauthorized, "TOKEN_REVOKED"},
+		{"gone", missing.String(), http.StatusUnauthorized, "TOKEN_REVOKED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := request(tt.user)
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.body)
+		})
+	}
+
+	t.Run("statuses are cached", func(t *testing.T) {
+		before := accounts.lookups
+		request(active.String())
+		request(active.String())
+		assert.Equal(t, before, accounts.lookups)
+	})
+}
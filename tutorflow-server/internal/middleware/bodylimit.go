@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BodyLimit caps request bodies at limit bytes. Multipart requests carry file
+// uploads and may be up to uploadLimit instead; the storage service applies
+// the tighter limit for each kind of file as it reads the upload.
+//
+// Bodies that declare a larger Content-Length are refused straight away;
+// others fail with *http.MaxBytesError once they read past the limit, which
+// the error handler reports as 413.
+func BodyLimit(limit, uploadLimit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			max := limit
+			if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+				max = uploadLimit
+			}
+
+			if req.ContentLength > max {
+				return echo.ErrStatusRequestEntityTooLarge
+			}
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, max)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+)
+
+func TestBodyLimit(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = middleware.ErrorHandler(zap.NewNop().Sugar())
+	e.Use(middleware.BodyLimit(10, 100))
+	e.POST("/", func(c echo.Context) error {
+		if _, err := io.ReadAll(c.Request().Body); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		size        int
+		chunked     bool // no Content-Length, so the limit trips while reading
+		want        int
+	}{
+		{"small body", echo.MIMEApplicationJSON, 10, false, http.StatusNoContent},
+		{"declared too large", echo.MIMEApplicationJSON, 11, false, http.StatusRequestEntityTooLarge},
+		{"read too large", echo.MIMEApplicationJSON, 11, true, http.StatusRequestEntityTooLarge},
+		{"upload within its limit", echo.MIMEMultipartForm + "; boundary=x", 100, false, http.StatusNoContent},
+		{"upload too large", echo.MIMEMultipartForm + "; boundary=x", 101, true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", tt.size)))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+)
+
+// corsRoute is the CORS handling for paths under a prefix; a nil handler
+// sends no CORS headers at all
+type corsRoute struct {
+	prefix  string
+	handler echo.MiddlewareFunc
+}
+
+// CORS allows browsers on origins to call the API, with cfg's overrides for
+// particular routes. It refuses a "*" origin alongside credentials, which
+// would let any site make requests with a user's cookies.
+func CORS(origins []string, cfg config.CORSConfig) (echo.MiddlewareFunc, error) {
+	base, err := corsHandler(origins, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]corsRoute, 0, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		prefix := strings.TrimSuffix(route.Prefix, "/")
+		if !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("cors route %q: prefix must start with /", route.Prefix)
+		}
+		r := corsRoute{prefix: prefix}
+		if !route.Disabled {
+			routeOrigins := origins
+			if len(route.AllowedOrigins) > 0 {
+				routeOrigins = route.AllowedOrigins
+			}
+			if r.handler, err = corsHandler(routeOrigins, cfg); err != nil {
+				return nil, fmt.Errorf("cors route %s: %w", route.Prefix, err)
+			}
+		}
+		routes = append(routes, r)
+	}
+	// Longest prefix first, so the most specific override wins
+	sort.SliceStable(routes, func(i, j int) bool { return len(routes[i].prefix) > len(routes[j].prefix) })
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withBase := base(next)
+		withRoutes := make([]echo.HandlerFunc, len(routes))
+		for i, route := range routes {
+			withRoutes[i] = next
+			if route.handler != nil {
+				withRoutes[i] = route.handler(next)
+			}
+		}
+
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			for i, route := range routes {
+				if path == route.prefix || strings.HasPrefix(path, route.prefix+"/") {
+					return withRoutes[i](c)
+				}
+			}
+			return withBase(c)
+		}
+	}, nil
+}
+
+// corsHandler is echo's CORS middleware for origins with cfg's settings
+func corsHandler(origins []string, cfg config.CORSConfig) (echo.MiddlewareFunc, error) {
+	if cfg.AllowCredentials && slices.Contains(origins, "*") {
+		return nil, fmt.Errorf(`cors: origin "*" can't be combined with allow_credentials; list the origins instead`)
+	}
+
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     origins,
+		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "If-None-Match", "X-Session-ID"},
+		ExposeHeaders:    cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}), nil
+}
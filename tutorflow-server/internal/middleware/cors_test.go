@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+)
+
+func TestCORS(t *testing.T) {
+	cors, err := middleware.CORS([]string{"https://app.example.com"}, config.CORSConfig{
+		AllowCredentials: true,
+		ExposedHeaders:   []string{"ETag", "Retry-After"},
+		Routes: []config.CORSRoute{
+			{Prefix: "/api/v1/webhooks", Disabled: true},
+			{Prefix: "/api/v1/embed/", AllowedOrigins: []string{"https://partner.example.com"}},
+		},
+	})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(cors)
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/api/v1/courses", ok)
+	e.GET("/api/v1/webhooks/stripe/subscription", ok)
+	e.GET("/api/v1/webhooksish", ok)
+	e.GET("/api/v1/embed/player", ok)
+
+	tests := []struct {
+		name        string
+		path        string
+		origin      string
+		allowOrigin string
+	}{
+		{"allowed origin", "/api/v1/courses", "https://app.example.com", "https://app.example.com"},
+		{"other origin", "/api/v1/courses", "https://evil.example.com", ""},
+		{"disabled route", "/api/v1/webhooks/stripe/subscription", "https://app.example.com", ""},
+		{"prefix matches whole segments", "/api/v1/webhooksish", "https://app.example.com", "https://app.example.com"},
+		{"route origins replace the defaults", "/api/v1/embed/player", "https://partner.example.com", "https://partner.example.com"},
+		{"default origins don't apply to an overridden route", "/api/v1/embed/player", "https://app.example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(echo.HeaderOrigin, tt.origin)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.allowOrigin, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+			if tt.allowOrigin != "" {
+				assert.Equal(t, "true", rec.Header().Get(echo.HeaderAccessControlAllowCredentials))
+				assert.Equal(t, "ETag,Retry-After", rec.Header().Get(echo.HeaderAccessControlExposeHeaders))
+			}
+		})
+	}
+}
+
+func TestCORS_RejectsWildcardWithCredentials(t *testing.T) {
+	_, err := middleware.CORS([]string{"*"}, config.CORSConfig{AllowCredentials: true})
+	assert.Error(t, err)
+
+	_, err = middleware.CORS([]string{"https://app.example.com"}, config.CORSConfig{
+		AllowCredentials: true,
+		Routes:           []config.CORSRoute{{Prefix: "/public", AllowedOrigins: []string{"*"}}},
+	})
+	assert.Error(t, err, "route overrides are checked too")
+
+	_, err = middleware.CORS([]string{"*"}, config.CORSConfig{})
+	assert.NoError(t, err, "a wildcard is fine without credentials")
+}
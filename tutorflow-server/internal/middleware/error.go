@@ -1,15 +1,172 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"reflect"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
 )
 
+// errorMapping is how a domain error is reported to clients
+type errorMapping struct {
+	status  int
+	code    string
+	message string // the error's own text when empty
+}
+
+// domainErrors maps domain sentinel errors to their status and error code.
+// Clients branch on the codes, so they must not change once released; the
+// full list is documented in the README.
+var domainErrors = map[error]errorMapping{
+	// User errors
+	domain.ErrUserNotFound:       {http.StatusNotFound, "USER_NOT_FOUND", ""},
+	domain.ErrUserAlreadyExists:  {http.StatusConflict, "USER_EXISTS", ""},
+	domain.ErrUsernameTaken:      {http.StatusConflict, "USERNAME_TAKEN", ""},
+	domain.ErrInvalidCredentials: {http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid credentials"},
+	domain.ErrAccountSuspended:   {http.StatusForbidden, "USER_SUSPENDED", "Account suspended"},
+	domain.ErrAccountLocked:      {http.StatusForbidden, "ACCOUNT_LOCKED", ""},
+	domain.ErrUserInactive:       {http.StatusForbidden, "USER_INACTIVE", "Account inactive"},
+	domain.ErrEmailNotVerified:   {http.StatusForbidden, "EMAIL_NOT_VERIFIED", "Email not verified"},
+
+	// Auth errors
+	domain.ErrInvalidToken:            {http.StatusUnauthorized, "INVALID_TOKEN", ""},
+	domain.ErrTokenExpired:            {http.StatusUnauthorized, "TOKEN_EXPIRED", ""},
+	domain.ErrTokenRevoked:            {http.StatusUnauthorized, "TOKEN_REVOKED", ""},
+	domain.ErrRefreshTokenInvalid:     {http.StatusUnauthorized, "INVALID_REFRESH_TOKEN", ""},
+	domain.ErrRefreshTokenReused:      {http.StatusUnauthorized, "REFRESH_TOKEN_REUSED", "Refresh token was already used; all sessions have been signed out"},
+	domain.ErrSessionNotFound:         {http.StatusNotFound, "SESSION_NOT_FOUND", ""},
+	domain.ErrUnknownAuthProvider:     {http.StatusNotFound, "UNKNOWN_AUTH_PROVIDER", ""},
+	domain.ErrProviderEmailUnverified: {http.StatusForbidden, "PROVIDER_EMAIL_UNVERIFIED", ""},
+	domain.ErrVerificationInvalid:     {http.StatusBadRequest, "INVALID_VERIFICATION_TOKEN", ""},
+	domain.ErrResetTokenInvalid:       {http.StatusBadRequest, "INVALID_RESET_TOKEN", ""},
+	domain.ErrDeletionTokenInvalid:    {http.StatusBadRequest, "INVALID_DELETION_TOKEN", ""},
+	domain.ErrDataExportInvalid:       {http.StatusNotFound, "INVALID_EXPORT_LINK", ""},
+
+	// Course errors
+	domain.ErrCourseNotFound:        {http.StatusNotFound, "COURSE_NOT_FOUND", ""},
+	domain.ErrCourseNotPublished:    {http.StatusBadRequest, "COURSE_NOT_PUBLISHED", "Course is not available"},
+	domain.ErrNotCourseOwner:        {http.StatusForbidden, "NOT_COURSE_OWNER", "Access denied"},
+	domain.ErrInvalidEnrollmentCode: {http.StatusBadRequest, "INVALID_ENROLLMENT_CODE", "Invalid enrollment code"},
+	domain.ErrInvalidRevenueSplit:   {http.StatusBadRequest, "INVALID_REVENUE_SPLIT", ""},
+	domain.ErrInvalidDiscountPrice:  {http.StatusBadRequest, "INVALID_DISCOUNT_PRICE", ""},
+
+	// Review errors
+	domain.ErrReviewNotFound:         {http.StatusNotFound, "REVIEW_NOT_FOUND", ""},
+	domain.ErrAlreadyReviewed:        {http.StatusConflict, "ALREADY_REVIEWED", "You have already reviewed this course; update your existing review instead"},
+	domain.ErrReviewProgressRequired: {http.StatusForbidden, "REVIEW_PROGRESS_REQUIRED", ""},
+	domain.ErrCannotVoteOwnReview:    {http.StatusBadRequest, "CANNOT_VOTE_OWN_REVIEW", ""},
+
+	// Discussion errors
+	domain.ErrDiscussionNotFound: {http.StatusNotFound, "DISCUSSION_NOT_FOUND", ""},
+	domain.ErrReplyTooDeep:       {http.StatusBadRequest, "REPLY_TOO_DEEP", ""},
+
+	// Announcement errors
+	domain.ErrAnnouncementNotFound:  {http.StatusNotFound, "ANNOUNCEMENT_NOT_FOUND", ""},
+	domain.ErrAnnouncementPublished: {http.StatusConflict, "ANNOUNCEMENT_PUBLISHED", ""},
+
+	// Category errors
+	domain.ErrCategoryInUse:        {http.StatusConflict, "CATEGORY_IN_USE", ""},
+	domain.ErrInvalidCategoryOrder: {http.StatusBadRequest, "INVALID_CATEGORY_ORDER", ""},
+
+	// Enrollment errors
+	domain.ErrAlreadyEnrolled:     {http.StatusBadRequest, "ALREADY_ENROLLED", "Already enrolled"},
+	domain.ErrNotEnrolled:         {http.StatusForbidden, "NOT_ENROLLED", "Not enrolled in this course"},
+	domain.ErrEnrollmentExpired:   {http.StatusForbidden, "ENROLLMENT_EXPIRED", "Enrollment has expired"},
+	domain.ErrEnrollmentPaused:    {http.StatusForbidden, "ENROLLMENT_PAUSED", "Enrollment is paused"},
+	domain.ErrEnrollmentNotPaused: {http.StatusBadRequest, "ENROLLMENT_NOT_PAUSED", "Enrollment is not paused"},
+	domain.ErrPauseNotAllowed:     {http.StatusBadRequest, "PAUSE_NOT_ALLOWED", "Enrollment cannot be paused"},
+
+	// Content errors
+	domain.ErrLessonNotFound:      {http.StatusNotFound, "LESSON_NOT_FOUND", ""},
+	domain.ErrModuleNotFound:      {http.StatusNotFound, "MODULE_NOT_FOUND", ""},
+	domain.ErrNoAccess:            {http.StatusForbidden, "NO_ACCESS", "Access denied"},
+	domain.ErrContentLocked:       {http.StatusForbidden, "CONTENT_LOCKED", ""},
+	domain.ErrInvalidLessonOrder:  {http.StatusBadRequest, "INVALID_LESSON_ORDER", ""},
+	domain.ErrInvalidPrerequisite: {http.StatusBadRequest, "INVALID_PREREQUISITE", ""},
+
+	// Assessment errors
+	domain.ErrQuizNotFound:       {http.StatusNotFound, "QUIZ_NOT_FOUND", ""},
+	domain.ErrQuestionNotFound:   {http.StatusNotFound, "QUESTION_NOT_FOUND", ""},
+	domain.ErrQuizNotPublished:   {http.StatusBadRequest, "QUIZ_NOT_PUBLISHED", "Quiz is not available"},
+	domain.ErrAttemptNotFound:    {http.StatusNotFound, "ATTEMPT_NOT_FOUND", ""},
+	domain.ErrAttemptCompleted:   {http.StatusConflict, "ATTEMPT_COMPLETED", ""},
+	domain.ErrTimeLimitExceeded:  {http.StatusBadRequest, "TIME_LIMIT_EXCEEDED", ""},
+	domain.ErrAssignmentNotFound: {http.StatusNotFound, "ASSIGNMENT_NOT_FOUND", ""},
+	domain.ErrAssignmentPastDue:  {http.StatusBadRequest, "ASSIGNMENT_PAST_DUE", ""},
+	domain.ErrMaxAttemptsReached: {http.StatusBadRequest, "MAX_ATTEMPTS_REACHED", "Maximum attempts reached"},
+	domain.ErrSubmissionNotFound: {http.StatusNotFound, "SUBMISSION_NOT_FOUND", ""},
+
+	// Upload errors
+	domain.ErrFileTooLarge:            {http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", ""},
+	domain.ErrInvalidFileType:         {http.StatusBadRequest, "INVALID_FILE_TYPE", ""},
+	domain.ErrFileContentMismatch:     {http.StatusBadRequest, "FILE_CONTENT_MISMATCH", ""},
+	domain.ErrFileRejected:            {http.StatusUnprocessableEntity, "FILE_REJECTED", "File was rejected by the upload scanner"},
+	domain.ErrFileNotFound:            {http.StatusNotFound, "FILE_NOT_FOUND", "File not found"},
+	domain.ErrDirectUploadUnavailable: {http.StatusNotImplemented, "DIRECT_UPLOAD_UNAVAILABLE", "Direct uploads need object storage; upload through the API instead"},
+
+	// Payment errors
+	domain.ErrPaymentFailed:       {http.StatusPaymentRequired, "PAYMENT_FAILED", ""},
+	domain.ErrOrderNotFound:       {http.StatusNotFound, "ORDER_NOT_FOUND", ""},
+	domain.ErrCouponInvalid:       {http.StatusBadRequest, "COUPON_INVALID", "Invalid or expired coupon"},
+	domain.ErrCouponNotApplicable: {http.StatusBadRequest, "COUPON_NOT_APPLICABLE", "Coupon not applicable to this order"},
+	domain.ErrBundleNotAvailable:  {http.StatusBadRequest, "BUNDLE_NOT_AVAILABLE", ""},
+	domain.ErrBundleAlreadyOwned:  {http.StatusConflict, "BUNDLE_ALREADY_OWNED", ""},
+
+	// Streaming errors
+	domain.ErrDeviceLimitReached:    {http.StatusForbidden, "DEVICE_LIMIT_REACHED", ""},
+	domain.ErrConcurrentStreamLimit: {http.StatusTooManyRequests, "CONCURRENT_STREAM_LIMIT", ""},
+
+	// Messaging errors
+	domain.ErrBroadcastLimitReached: {http.StatusTooManyRequests, "BROADCAST_LIMIT_REACHED", "Broadcast limit reached. Please try again later."},
+	domain.ErrMessagingNotAllowed:   {http.StatusForbidden, "MESSAGING_NOT_ALLOWED", ""},
+
+	// Notification errors
+	domain.ErrNotificationFilterRequired: {http.StatusBadRequest, "NOTIFICATION_FILTER_REQUIRED", ""},
+
+	// Pagination errors
+	domain.ErrInvalidCursor: {http.StatusBadRequest, "INVALID_CURSOR", ""},
+
+	// Repositories that don't translate a missing row into their own error
+	gorm.ErrRecordNotFound: {http.StatusNotFound, "NOT_FOUND", "Resource not found"},
+
+	// Permission errors
+	domain.ErrForbidden:    {http.StatusForbidden, "FORBIDDEN", "Access denied"},
+	domain.ErrUnauthorized: {http.StatusUnauthorized, "UNAUTHORIZED", "Invalid credentials"},
+}
+
+// lookupDomainError finds the mapping for err or any error it wraps
+func lookupDomainError(err error) (errorMapping, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		// Errors like ValidationErrors can't be map keys, and aren't sentinels
+		if !reflect.TypeOf(err).Comparable() {
+			continue
+		}
+		if mapping, ok := domainErrors[err]; ok {
+			return mapping, true
+		}
+	}
+	return errorMapping{}, false
+}
+
+// IsDomainError reports whether the error handler has its own status and code
+// for err, so handlers should return it rather than a generic response
+func IsDomainError(err error) bool {
+	if _, ok := lookupDomainError(err); ok {
+		return true
+	}
+	var lock *domain.LessonLock
+	var notReady *domain.CourseNotReady
+	var invalid domain.ValidationErrors
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &lock) || errors.As(err, &notReady) || errors.As(err, &invalid) || errors.As(err, &tooLarge)
+}
+
 // ErrorHandler wraps the default Echo error handler
 func ErrorHandler(logger *zap.SugaredLogger) echo.HTTPErrorHandler {
 	return func(err error, c echo.Context) {
@@ -20,7 +177,6 @@ func ErrorHandler(logger *zap.SugaredLogger) echo.HTTPErrorHandler {
 		// Default values
 		code := http.StatusInternalServerError
 		message := "Internal server error"
-		var errorCode string
 		var details []response.ValidationError
 
 		// Handle Echo HTTP errors
@@ -32,52 +188,41 @@ func ErrorHandler(logger *zap.SugaredLogger) echo.HTTPErrorHandler {
 				message = http.StatusText(code)
 			}
 		}
+		errorCode := response.StatusCode(code)
 
-		// Handle Domain primary errors
-		switch err {
-		case domain.ErrUserNotFound, domain.ErrCourseNotFound, domain.ErrLessonNotFound, domain.ErrModuleNotFound, domain.ErrQuizNotFound, domain.ErrAssignmentNotFound, domain.ErrSubmissionNotFound, domain.ErrOrderNotFound:
-			code = http.StatusNotFound
-			message = err.Error()
-		case domain.ErrUserAlreadyExists:
-			code = http.StatusConflict
-			message = err.Error()
-			errorCode = "USER_EXISTS"
-		case domain.ErrInvalidCredentials, domain.ErrUnauthorized:
-			code = http.StatusUnauthorized
-			message = "Invalid credentials"
-		case domain.ErrForbidden, domain.ErrNoAccess, domain.ErrNotCourseOwner:
-			code = http.StatusForbidden
-			message = "Access denied"
-		case domain.ErrUserSuspended:
-			code = http.StatusForbidden
-			message = "Account suspended"
-			errorCode = "USER_SUSPENDED"
-		case domain.ErrUserInactive:
-			code = http.StatusForbidden
-			message = "Account inactive"
-			errorCode = "USER_INACTIVE"
-		case domain.ErrUserNotVerified:
-			code = http.StatusForbidden
-			message = "Email not verified"
-			errorCode = "EMAIL_NOT_VERIFIED"
-		case domain.ErrCourseNotPublished:
-			code = http.StatusBadRequest
-			message = "Course is not available"
-		case domain.ErrAlreadyEnrolled:
-			code = http.StatusBadRequest
-			message = "Already enrolled"
-		case domain.ErrEnrollmentExpired:
+		// Bodies cut off by BodyLimit, however deep the read that hit it
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			code = http.StatusRequestEntityTooLarge
+			message = "Request body too large"
+			errorCode = response.StatusCode(code)
+		}
+
+		// Handle domain errors, wrapped or not
+		if mapping, ok := lookupDomainError(err); ok {
+			code = mapping.status
+			errorCode = mapping.code
+			message = mapping.message
+			if message == "" {
+				message = err.Error()
+			}
+		}
+
+		// Locked lessons tell the UI what to show in place of the content
+		var data interface{}
+		if lock, ok := err.(*domain.LessonLock); ok {
 			code = http.StatusForbidden
-			message = "Enrollment has expired"
-		case domain.ErrMaxAttemptsReached:
-			code = http.StatusBadRequest
-			message = "Maximum attempts reached"
-		case domain.ErrCouponInvalid:
-			code = http.StatusBadRequest
-			message = "Invalid or expired coupon"
-		case domain.ErrCouponNotApplicable:
+			message = lock.Error()
+			errorCode = "LESSON_LOCKED"
+			data = lock
+		}
+
+		// Unpublishable courses carry the checklist items still missing
+		if notReady, ok := err.(*domain.CourseNotReady); ok {
 			code = http.StatusBadRequest
-			message = "Coupon not applicable to this order"
+			message = notReady.Error()
+			errorCode = "COURSE_NOT_READY"
+			data = notReady
 		}
 
 		// Handle Validation Errors
@@ -103,6 +248,7 @@ func ErrorHandler(logger *zap.SugaredLogger) echo.HTTPErrorHandler {
 		// Send standardized response
 		resp := response.Response{
 			Success: false,
+			Data:    data,
 			Error: &response.ErrorInfo{
 				Code:    errorCode,
 				Message: message,
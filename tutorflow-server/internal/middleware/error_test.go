@@ -0,0 +1,61 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+)
+
+func TestErrorHandler_Codes(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{"domain error", domain.ErrCourseNotFound, http.StatusNotFound, "COURSE_NOT_FOUND", "course not found"},
+		{"wrapped domain error", fmt.Errorf("loading course: %w", domain.ErrCouponInvalid), http.StatusBadRequest, "COUPON_INVALID", "Invalid or expired coupon"},
+		{"missing row", fmt.Errorf("loading module: %w", gorm.ErrRecordNotFound), http.StatusNotFound, "NOT_FOUND", "Resource not found"},
+		{"conflicting state", domain.ErrAttemptCompleted, http.StatusConflict, "ATTEMPT_COMPLETED", "attempt already completed"},
+		{"echo error", echo.NewHTTPError(http.StatusNotFound), http.StatusNotFound, "NOT_FOUND", "Not Found"},
+		{"unexpected error", errors.New("connection refused"), http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error"},
+		{"validation errors", domain.ValidationErrors{{Field: "title", Message: "is required"}}, http.StatusBadRequest, "VALIDATION_ERROR", "Validation failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+			middleware.ErrorHandler(zap.NewNop().Sugar())(tt.err, c)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantCode, resp.Error.Code)
+			assert.Equal(t, tt.wantMessage, resp.Error.Message)
+		})
+	}
+}
+
+func TestIsDomainError(t *testing.T) {
+	assert.True(t, middleware.IsDomainError(domain.ErrMaxAttemptsReached))
+	assert.True(t, middleware.IsDomainError(fmt.Errorf("submit: %w", domain.ErrMaxAttemptsReached)))
+	assert.True(t, middleware.IsDomainError(domain.ValidationErrors{{Field: "q"}}))
+	assert.True(t, middleware.IsDomainError(gorm.ErrRecordNotFound))
+	assert.False(t, middleware.IsDomainError(errors.New("quiz not found")))
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/tutorflow/tutorflow-server/internal/pkg/metrics"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+)
+
+// Metrics records the count and latency of each request by route and status
+func Metrics() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			// Errors are turned into responses here, so the status recorded
+			// is the one the client gets
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			status := strconv.Itoa(c.Response().Status)
+			method := c.Request().Method
+			metrics.HTTPRequests.WithLabelValues(method, route, status).Inc()
+			metrics.HTTPDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+			return nil
+		}
+	}
+}
+
+// MetricsAuth requires scrapers to send token as a bearer token. An empty
+// token leaves the endpoint open.
+func MetricsAuth(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if token == "" {
+				return next(c)
+			}
+			sent, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				return response.Unauthorized(c, "Invalid metrics token")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = middleware.ErrorHandler(zap.NewNop().Sugar())
+	e.Use(middleware.Metrics())
+	e.GET("/courses/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return domain.ErrCourseNotFound
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/courses/a", "/courses/b", "/courses/missing", "/nowhere"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	requests := func(route, status string) float64 {
+		return testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, route, status))
+	}
+	assert.Equal(t, 2.0, requests("/courses/:id", "200"), "requests are grouped by route")
+	assert.Equal(t, 1.0, requests("/courses/:id", "404"), "errors are recorded with the status sent")
+	assert.Equal(t, 1.0, requests("unmatched", "404"), "unknown paths share one series")
+}
+
+func TestMetricsAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"open without a token", "", "", http.StatusOK},
+		{"right token", "secret", "Bearer secret", http.StatusOK},
+		{"wrong token", "secret", "Bearer guess", http.StatusUnauthorized},
+		{"no token", "secret", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.GET("/metrics", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, middleware.MetricsAuth(tt.token))
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package middleware
 
 import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -9,97 +14,136 @@ import (
 	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
 )
 
-// RateLimiter configuration
+// RateLimiterConfig describes a token bucket: it holds up to BurstSize
+// requests and refills at RequestsPerMinute
 type RateLimiterConfig struct {
+	Scope             string // prefixes bucket keys so limiters can share a store
 	RequestsPerMinute int
 	BurstSize         int
 }
 
-// Simple in-memory rate limiter (use Redis in production)
-type RateLimiter struct {
-	mu      sync.RWMutex
-	clients map[string]*clientLimiter
-	config  RateLimiterConfig
+// RateLimitStore keeps the token buckets. MemoryStore only limits requests to
+// this process; a shared store such as Redis can implement it to limit across
+// instances.
+type RateLimitStore interface {
+	// Take removes a token from the key's bucket. If the bucket is empty it
+	// returns false and how long until the next token is available.
+	Take(key string, cfg RateLimiterConfig) (bool, time.Duration)
+}
+
+// MemoryStore is an in-process RateLimitStore
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
 	cleanup time.Duration
 }
 
-type clientLimiter struct {
-	tokens    int
+type bucket struct {
+	tokens    float64
 	lastCheck time.Time
 }
 
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(cfg RateLimiterConfig) *RateLimiter {
-	rl := &RateLimiter{
-		clients: make(map[string]*clientLimiter),
-		config:  cfg,
+// NewMemoryStore creates an in-memory bucket store. Buckets idle for a few
+// minutes are dropped; by then they have refilled anyway.
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		buckets: make(map[string]*bucket),
 		cleanup: 5 * time.Minute,
 	}
 
 	// Periodic cleanup of old entries
 	go func() {
-		ticker := time.NewTicker(rl.cleanup)
+		ticker := time.NewTicker(s.cleanup)
 		for range ticker.C {
-			rl.cleanupOldEntries()
+			s.cleanupOldEntries()
 		}
 	}()
 
-	return rl
+	return s
 }
 
-func (rl *RateLimiter) cleanupOldEntries() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+func (s *MemoryStore) cleanupOldEntries() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	threshold := time.Now().Add(-rl.cleanup)
-	for key, client := range rl.clients {
-		if client.lastCheck.Before(threshold) {
-			delete(rl.clients, key)
+	threshold := time.Now().Add(-s.cleanup)
+	for key, b := range s.buckets {
+		if b.lastCheck.Before(threshold) {
+			delete(s.buckets, key)
 		}
 	}
 }
 
-func (rl *RateLimiter) Allow(clientIP string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// Take implements RateLimitStore
+func (s *MemoryStore) Take(key string, cfg RateLimiterConfig) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	client, exists := rl.clients[clientIP]
 	now := time.Now()
+	burst := float64(cfg.BurstSize)
+	perSecond := float64(cfg.RequestsPerMinute) / 60
 
+	b, exists := s.buckets[key]
 	if !exists {
-		rl.clients[clientIP] = &clientLimiter{
-			tokens:    rl.config.BurstSize - 1,
-			lastCheck: now,
-		}
-		return true
+		b = &bucket{tokens: burst, lastCheck: now}
+		s.buckets[key] = b
 	}
 
-	// Calculate tokens to add based on time passed
-	elapsed := now.Sub(client.lastCheck)
-	tokensToAdd := int(elapsed.Minutes() * float64(rl.config.RequestsPerMinute))
+	// Refill for the time passed, keeping fractions so steady traffic below
+	// the rate is never rejected
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.lastCheck).Seconds()*perSecond)
+	b.lastCheck = now
 
-	client.tokens += tokensToAdd
-	if client.tokens > rl.config.BurstSize {
-		client.tokens = rl.config.BurstSize
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
 	}
-	client.lastCheck = now
-
-	if client.tokens > 0 {
-		client.tokens--
-		return true
+	if perSecond <= 0 {
+		return false, time.Minute
 	}
+	return false, time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+}
+
+// RateLimiter applies one bucket configuration over a store
+type RateLimiter struct {
+	store  RateLimitStore
+	config RateLimiterConfig
+}
 
-	return false
+// NewRateLimiter creates a rate limiter with its own in-memory store
+func NewRateLimiter(cfg RateLimiterConfig) *RateLimiter {
+	return NewRateLimiterWithStore(NewMemoryStore(), cfg)
 }
 
-// RateLimitMiddleware creates rate limiting middleware
+// NewRateLimiterWithStore creates a rate limiter backed by the given store.
+// Limiters sharing a store need different scopes.
+func NewRateLimiterWithStore(store RateLimitStore, cfg RateLimiterConfig) *RateLimiter {
+	return &RateLimiter{store: store, config: cfg}
+}
+
+// Allow takes a token for the key, returning how long to wait if none is left
+func (rl *RateLimiter) Allow(key string) (bool, time.Duration) {
+	return rl.store.Take(rl.config.Scope+":"+key, rl.config)
+}
+
+// RateLimitMiddleware creates rate limiting middleware. Requests are counted
+// per user once authenticated and per IP otherwise. Rejected requests get a
+// 429 with a Retry-After header.
 func RateLimitMiddleware(limiter *RateLimiter) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			clientIP := c.RealIP()
+			key := "ip:" + c.RealIP()
+			if claims, ok := GetClaims(c); ok {
+				key = "user:" + claims.UserID.String()
+			}
 
-			if !limiter.Allow(clientIP) {
-				return response.Error(c, 429, "Too many requests. Please try again later.")
+			if ok, wait := limiter.Allow(key); !ok {
+				retryAfter := int(math.Ceil(wait.Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				return response.ErrorWithCode(c, http.StatusTooManyRequests, "RATE_LIMITED", "Too many requests. Please try again later.")
 			}
 
 			return next(c)
@@ -107,9 +151,34 @@ func RateLimitMiddleware(limiter *RateLimiter) echo.MiddlewareFunc {
 	}
 }
 
+// IPExtractor returns how to find a request's client IP, which per-IP rate
+// limits key on. Without trusted proxies it is the connection's address, since
+// any client can set X-Forwarded-For. Behind proxies it is the address the
+// nearest untrusted hop forwarded for.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range trustedProxies {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", cidr, err)
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
 // DefaultRateLimiter creates a rate limiter with default settings
 func DefaultRateLimiter() *RateLimiter {
 	return NewRateLimiter(RateLimiterConfig{
+		Scope:             "default",
 		RequestsPerMinute: 60,
 		BurstSize:         20,
 	})
@@ -118,6 +187,7 @@ func DefaultRateLimiter() *RateLimiter {
 // StrictRateLimiter for sensitive endpoints (login, register)
 func StrictRateLimiter() *RateLimiter {
 	return NewRateLimiter(RateLimiterConfig{
+		Scope:             "strict",
 		RequestsPerMinute: 10,
 		BurstSize:         5,
 	})
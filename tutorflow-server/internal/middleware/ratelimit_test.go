@@ -0,0 +1,122 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/middleware"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/jwt"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("allows a burst, then waits for the refill", func(t *testing.T) {
+		store := middleware.NewMemoryStore()
+		cfg := middleware.RateLimiterConfig{RequestsPerMinute: 60, BurstSize: 3}
+
+		for i := 0; i < 3; i++ {
+			ok, _ := store.Take("k", cfg)
+			assert.True(t, ok, "request %d is within the burst", i+1)
+		}
+		ok, wait := store.Take("k", cfg)
+		assert.False(t, ok)
+		assert.InDelta(t, time.Second, wait, float64(50*time.Millisecond), "one token a second")
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		store := middleware.NewMemoryStore()
+		cfg := middleware.RateLimiterConfig{RequestsPerMinute: 60_000, BurstSize: 1}
+
+		ok, _ := store.Take("k", cfg)
+		require.True(t, ok)
+		ok, _ = store.Take("k", cfg)
+		require.False(t, ok)
+
+		time.Sleep(5 * time.Millisecond)
+		ok, _ = store.Take("k", cfg)
+		assert.True(t, ok)
+	})
+
+	t.Run("keys have their own buckets", func(t *testing.T) {
+		store := middleware.NewMemoryStore()
+		cfg := middleware.RateLimiterConfig{RequestsPerMinute: 1, BurstSize: 1}
+
+		ok, _ := store.Take("a", cfg)
+		assert.True(t, ok)
+		ok, _ = store.Take("b", cfg)
+		assert.True(t, ok)
+		ok, _ = store.Take("a", cfg)
+		assert.False(t, ok)
+	})
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	limiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{Scope: "test", RequestsPerMinute: 1, BurstSize: 1})
+	limited := middleware.RateLimitMiddleware(limiter)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	serve := func(remoteAddr string, userID *uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if userID != nil {
+			c.Set(middleware.ClaimsKey, &jwt.Claims{UserID: *userID})
+		}
+		require.NoError(t, limited(c))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, serve("203.0.113.1:1000", nil).Code)
+
+	rec := serve("203.0.113.1:2000", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "the same IP shares a bucket")
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, serve("203.0.113.2:1000", nil).Code, "another IP has its own bucket")
+
+	user := uuid.New()
+	assert.Equal(t, http.StatusOK, serve("203.0.113.1:3000", &user).Code, "signed-in users are counted per user, not per IP")
+	assert.Equal(t, http.StatusTooManyRequests, serve("203.0.113.3:1000", &user).Code, "even from another IP")
+}
+
+func TestIPExtractor(t *testing.T) {
+	request := func(remoteAddr, forwardedFor string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+		return req
+	}
+
+	t.Run("ignores forwarding headers without trusted proxies", func(t *testing.T) {
+		extract, err := middleware.IPExtractor(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "203.0.113.7", extract(request("203.0.113.7:1000", "198.51.100.1")))
+	})
+
+	t.Run("believes trusted proxies only", func(t *testing.T) {
+		extract, err := middleware.IPExtractor([]string{"10.0.0.0/8"})
+		require.NoError(t, err)
+		assert.Equal(t, "198.51.100.1", extract(request("10.1.2.3:1000", "198.51.100.1")))
+		assert.Equal(t, "198.51.100.1", extract(request("10.1.2.3:1000", "192.0.2.9, 198.51.100.1")),
+			"addresses the client put in front of the header are skipped")
+		assert.Equal(t, "203.0.113.7", extract(request("203.0.113.7:1000", "198.51.100.1")))
+		assert.Equal(t, "192.168.1.5", extract(request("192.168.1.5:1000", "198.51.100.1")),
+			"private ranges aren't trusted unless listed")
+	})
+
+	t.Run("rejects a malformed range", func(t *testing.T) {
+		_, err := middleware.IPExtractor([]string{"10.0.0.0"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tutorflow/tutorflow-server/internal/pkg/tracing"
+)
+
+// Tracing starts a span for each request, continuing the caller's trace if it
+// sent one, and tags it with the ID the RequestID middleware assigned so logs
+// and traces can be matched up. Probes and scrapes aren't traced.
+func Tracing() echo.MiddlewareFunc {
+	otelMW := otelecho.Middleware(tracing.ServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		path := c.Request().URL.Path
+		return strings.HasPrefix(path, "/health") || path == "/metrics"
+	}))
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return otelMW(func(c echo.Context) error {
+			if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+				trace.SpanFromContext(c.Request().Context()).SetAttributes(attribute.String("http.request.id", id))
+			}
+			return next(c)
+		})
+	}
+}
@@ -10,14 +10,27 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Storage  StorageConfig
-	Email    EmailConfig
-	Stripe   StripeConfig
-	Redis    RedisConfig
-	Push     PushConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	Auth       AuthConfig
+	OAuth      OAuthConfig
+	Storage    StorageConfig
+	Email      EmailConfig
+	Stripe     StripeConfig
+	Redis      RedisConfig
+	Push       PushConfig
+	Search     SearchConfig
+	Course     CourseConfig
+	Review     ReviewConfig
+	Discussion DiscussionConfig
+	Enrollment EnrollmentConfig
+	Video      VideoConfig
+	Messaging  MessagingConfig
+	Admin      AdminConfig
+	RateLimit  RateLimitConfig `mapstructure:"rate_limit"`
+	Metrics    MetricsConfig
+	CORS       CORSConfig `mapstructure:"cors"`
 }
 
 type ServerConfig struct {
@@ -26,6 +39,17 @@ type ServerConfig struct {
 	Version        string   `mapstructure:"version"`
 	AllowedOrigins []string `mapstructure:"allowed_origins"`
 	Environment    string   `mapstructure:"environment"`
+	BodyLimit      int64    `mapstructure:"body_limit"` // largest request body in bytes, except file uploads
+	// CheckDependencies adds storage and Stripe to the readiness check, which
+	// otherwise only pings the database
+	CheckDependencies bool `mapstructure:"check_dependencies"`
+	// ShutdownTimeout is how long shutdown waits for requests and background
+	// jobs, such as transcoding, to finish
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// TrustedProxies are the CIDR ranges of proxies whose X-Forwarded-For is
+	// believed. Without any, clients are identified by their connection's
+	// address and forwarding headers are ignored.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -53,6 +77,23 @@ type JWTConfig struct {
 	Issuer           string        `mapstructure:"issuer"`
 }
 
+type AuthConfig struct {
+	MaxFailedLogins int           `mapstructure:"max_failed_logins"` // failures within the window that lock an account; 0 disables lockout
+	LockoutWindow   time.Duration `mapstructure:"lockout_window"`    // how far back failures count, and so the longest a lock lasts
+	VerificationTTL time.Duration `mapstructure:"verification_ttl"`  // how long email verification links stay valid
+}
+
+type OAuthConfig struct {
+	CallbackURL string              `mapstructure:"callback_url"` // web app page that receives tokens after a provider sign-in
+	Google      OAuthProviderConfig `mapstructure:"google"`
+}
+
+type OAuthProviderConfig struct {
+	ClientID     string `mapstructure:"client_id"` // empty disables the provider
+	ClientSecret string `mapstructure:"client_secret"`
+	RedirectURL  string `mapstructure:"redirect_url"` // this API's callback, as registered with the provider
+}
+
 type StorageConfig struct {
 	Driver     string `mapstructure:"driver"` // local, s3
 	LocalPath  string `mapstructure:"local_path"`
@@ -63,6 +104,13 @@ type StorageConfig struct {
 	SecretKey  string `mapstructure:"secret_key"`
 	UseSSL     bool   `mapstructure:"use_ssl"`
 	CDNBaseURL string `mapstructure:"cdn_base_url"`
+
+	Private         bool          `mapstructure:"private"`           // bucket isn't publicly readable; files are served through signed URLs
+	SignedURLExpiry time.Duration `mapstructure:"signed_url_expiry"` // how long a signed URL works for
+
+	MaxImageSize    int64 `mapstructure:"max_image_size"` // upload limits in bytes
+	MaxVideoSize    int64 `mapstructure:"max_video_size"`
+	MaxDocumentSize int64 `mapstructure:"max_document_size"`
 }
 
 type EmailConfig struct {
@@ -72,12 +120,17 @@ type EmailConfig struct {
 	SMTPPassword string `mapstructure:"smtp_password"`
 	FromName     string `mapstructure:"from_name"`
 	FromEmail    string `mapstructure:"from_email"`
+	AppURL       string `mapstructure:"app_url"` // frontend base URL used for links in emails
 }
 
 type StripeConfig struct {
 	SecretKey      string `mapstructure:"secret_key"`
 	WebhookSecret  string `mapstructure:"webhook_secret"`
 	PublishableKey string `mapstructure:"publishable_key"`
+
+	// Stripe signs each webhook endpoint with its own secret
+	SubscriptionWebhookSecret string `mapstructure:"subscription_webhook_secret"` // for /api/v1/webhooks/stripe/subscription
+
 }
 
 type RedisConfig struct {
@@ -93,6 +146,88 @@ type PushConfig struct {
 	VAPIDSubject    string `mapstructure:"vapid_subject"` // mailto: or https:// URL
 }
 
+type SearchConfig struct {
+	RecordQueries    bool          `mapstructure:"record_queries"`     // disable to stop logging search terms
+	TrendingWindow   time.Duration `mapstructure:"trending_window"`    // lookback for trending searches
+	TrendingMinCount int           `mapstructure:"trending_min_count"` // hide queries seen fewer times than this
+	PriceBuckets     []float64     `mapstructure:"price_buckets"`      // upper bounds of the paid price-range facets
+}
+
+type CourseConfig struct {
+	TrashRetention time.Duration `mapstructure:"trash_retention"` // deleted courses can be restored for this long; 0 keeps them forever
+}
+
+type ReviewConfig struct {
+	AutoApprove        bool    `mapstructure:"auto_approve"`         // publish reviews straight away; off holds them for moderation
+	MinProgressPercent float64 `mapstructure:"min_progress_percent"` // course progress a learner needs before reviewing
+}
+
+type DiscussionConfig struct {
+	MaxReplyDepth int `mapstructure:"max_reply_depth"` // how deeply replies can nest under a top-level post
+}
+
+type EnrollmentConfig struct {
+	AllowSelfPause       bool          `mapstructure:"allow_self_pause"`       // let learners pause their own time-limited access
+	MaxPauseDuration     time.Duration `mapstructure:"max_pause_duration"`     // total pause time a learner can get back; 0 for unlimited
+	PositionSaveInterval time.Duration `mapstructure:"position_save_interval"` // video position writes closer together than this are dropped
+	AtRiskInactivity     time.Duration `mapstructure:"at_risk_inactivity"`     // learners with no lesson activity for this long may be at risk
+	AtRiskMaxProgress    float64       `mapstructure:"at_risk_max_progress"`   // ...if their progress percent is also below this
+	AtRiskNudgeCooldown  time.Duration `mapstructure:"at_risk_nudge_cooldown"` // a learner is reminded to continue at most this often
+}
+
+type MessagingConfig struct {
+	OpenMessaging bool `mapstructure:"open_messaging"` // let anyone message anyone, for open-community deployments
+}
+
+type AdminConfig struct {
+	StatsCacheTTL time.Duration `mapstructure:"stats_cache_ttl"` // dashboard figures are reused for this long; 0 always queries
+}
+
+type RateLimitConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	IP      RateLimitRule `mapstructure:"ip"`   // every API request, per client IP
+	User    RateLimitRule `mapstructure:"user"` // authenticated requests, per user
+	Auth    RateLimitRule `mapstructure:"auth"` // login, register and password changes
+}
+
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Token   string `mapstructure:"token"` // scrapers must send it as a bearer token; empty leaves /metrics open
+}
+
+// CORSConfig controls which browser origins may call the API. The origins
+// themselves are server.allowed_origins, shared with the WebSocket check.
+type CORSConfig struct {
+	AllowCredentials bool        `mapstructure:"allow_credentials"` // send cookies cross-origin; refused with a "*" origin
+	ExposedHeaders   []string    `mapstructure:"exposed_headers"`   // response headers scripts on other origins may read
+	MaxAge           int         `mapstructure:"max_age"`           // seconds browsers may cache a preflight response
+	Routes           []CORSRoute `mapstructure:"routes"`            // overrides for paths under a prefix; the longest match wins
+}
+
+type CORSRoute struct {
+	Prefix         string   `mapstructure:"prefix"`
+	Disabled       bool     `mapstructure:"disabled"`        // no CORS headers, e.g. for server-to-server webhooks
+	AllowedOrigins []string `mapstructure:"allowed_origins"` // replaces server.allowed_origins under the prefix
+}
+
+type RateLimitRule struct {
+	RequestsPerMinute int `mapstructure:"requests_per_minute"` // sustained rate the bucket refills at
+	Burst             int `mapstructure:"burst"`               // requests allowed at once before the rate applies
+}
+
+type VideoConfig struct {
+	KeyBaseURL        string        `mapstructure:"key_base_url"`        // public API origin used in playlist key URIs; empty keeps them relative
+	MaxConcurrentJobs int           `mapstructure:"max_concurrent_jobs"` // ffmpeg processes run at once
+	JobMaxAttempts    int           `mapstructure:"job_max_attempts"`    // tries before a transcoding job is marked failed
+	JobRetryBackoff   time.Duration `mapstructure:"job_retry_backoff"`   // delay before the first retry, doubled each time
+	JobPollInterval   time.Duration `mapstructure:"job_poll_interval"`   // how often the worker looks for due jobs
+	JobStaleAfter     time.Duration `mapstructure:"job_stale_after"`     // running jobs without a heartbeat this long are picked up again
+	Watermark         bool          `mapstructure:"watermark"`           // have players overlay a per-session watermark code
+	WatermarkOpacity  float64       `mapstructure:"watermark_opacity"`
+	URLRetention      time.Duration `mapstructure:"url_retention"`      // keep expired signed URLs this long for watermark tracing
+	DeviceStaleAfter  time.Duration `mapstructure:"device_stale_after"` // devices not seen this long are deactivated
+}
+
 func Load() (*Config, error) {
 	env := os.Getenv("APP_ENV")
 	if env == "" {
@@ -134,6 +269,10 @@ func setDefaults() {
 	viper.SetDefault("server.host", "0.0.0.0")
 	viper.SetDefault("server.version", "1.0.0")
 	viper.SetDefault("server.allowed_origins", []string{"http://localhost:3000"})
+	viper.SetDefault("server.body_limit", 2<<20)
+	viper.SetDefault("server.check_dependencies", false)
+	viper.SetDefault("server.shutdown_timeout", 30*time.Second)
+	viper.SetDefault("server.trusted_proxies", []string{})
 
 	// Database
 	viper.SetDefault("database.host", "localhost")
@@ -151,6 +290,15 @@ func setDefaults() {
 	viper.SetDefault("jwt.refresh_expires_in", 7*24*time.Hour)
 	viper.SetDefault("jwt.issuer", "tutorflow")
 
+	// Auth
+	viper.SetDefault("auth.max_failed_logins", 5)
+	viper.SetDefault("auth.lockout_window", 15*time.Minute)
+	viper.SetDefault("auth.verification_ttl", 24*time.Hour)
+
+	// OAuth
+	viper.SetDefault("oauth.callback_url", "http://localhost:3000/oauth/callback")
+	viper.SetDefault("oauth.google.redirect_url", "http://localhost:8080/api/v1/auth/oauth/google/callback")
+
 	// Storage
 	viper.SetDefault("storage.driver", "local")
 	viper.SetDefault("storage.local_path", "./uploads")
@@ -160,9 +308,82 @@ func setDefaults() {
 	viper.SetDefault("storage.s3_endpoint", "")
 	viper.SetDefault("storage.access_key", "")
 	viper.SetDefault("storage.secret_key", "")
+	viper.SetDefault("storage.private", false)
+	viper.SetDefault("storage.signed_url_expiry", 15*time.Minute)
+	viper.SetDefault("storage.max_image_size", 10<<20)
+	viper.SetDefault("storage.max_video_size", 500<<20)
+	viper.SetDefault("storage.max_document_size", 50<<20)
+
+	// Email
+	viper.SetDefault("email.app_url", "http://localhost:3000")
 
 	// Redis
 	viper.SetDefault("redis.host", "localhost")
 	viper.SetDefault("redis.port", "6379")
 	viper.SetDefault("redis.db", 0)
+
+	// Search
+	viper.SetDefault("search.record_queries", true)
+	viper.SetDefault("search.trending_window", 7*24*time.Hour)
+	viper.SetDefault("search.trending_min_count", 5)
+	viper.SetDefault("search.price_buckets", []float64{25, 50, 100})
+
+	// Course
+	viper.SetDefault("course.trash_retention", 30*24*time.Hour)
+
+	// Review
+	viper.SetDefault("review.auto_approve", true)
+	viper.SetDefault("review.min_progress_percent", 0)
+
+	// Discussion
+	viper.SetDefault("discussion.max_reply_depth", 3)
+
+	// Enrollment
+	viper.SetDefault("enrollment.allow_self_pause", true)
+	viper.SetDefault("enrollment.max_pause_duration", 30*24*time.Hour)
+	viper.SetDefault("enrollment.position_save_interval", 10*time.Second)
+	viper.SetDefault("enrollment.at_risk_inactivity", 14*24*time.Hour)
+	viper.SetDefault("enrollment.at_risk_max_progress", 50)
+	viper.SetDefault("enrollment.at_risk_nudge_cooldown", 7*24*time.Hour)
+
+	// Video
+	viper.SetDefault("video.key_base_url", "")
+	viper.SetDefault("video.max_concurrent_jobs", 2)
+	viper.SetDefault("video.job_max_attempts", 3)
+	viper.SetDefault("video.job_retry_backoff", time.Minute)
+	viper.SetDefault("video.job_poll_interval", 5*time.Second)
+	viper.SetDefault("video.job_stale_after", 5*time.Minute)
+	viper.SetDefault("video.watermark", false)
+	viper.SetDefault("video.watermark_opacity", 0.15)
+	viper.SetDefault("video.url_retention", 30*24*time.Hour)
+	viper.SetDefault("video.device_stale_after", 90*24*time.Hour)
+
+	// Messaging
+	viper.SetDefault("messaging.open_messaging", false)
+
+	// Admin
+	viper.SetDefault("admin.stats_cache_ttl", time.Minute)
+
+	// Rate limiting
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.ip.requests_per_minute", 300)
+	viper.SetDefault("rate_limit.ip.burst", 100)
+	viper.SetDefault("rate_limit.user.requests_per_minute", 120)
+	viper.SetDefault("rate_limit.user.burst", 60)
+	viper.SetDefault("rate_limit.auth.requests_per_minute", 10)
+	viper.SetDefault("rate_limit.auth.burst", 5)
+
+	// Metrics
+	viper.SetDefault("metrics.enabled", false)
+	viper.SetDefault("metrics.token", "")
+
+	// CORS
+	viper.SetDefault("cors.allow_credentials", true)
+	viper.SetDefault("cors.exposed_headers", []string{"ETag", "Retry-After", "X-Request-ID", "X-Total-Count", "X-Page", "X-Per-Page", "Link"})
+	viper.SetDefault("cors.max_age", 600)
+	viper.SetDefault("cors.routes", []map[string]any{
+		{"prefix": "/webhook", "disabled": true},
+		{"prefix": "/api/v1/orders/webhook", "disabled": true},
+		{"prefix": "/api/v1/webhooks", "disabled": true},
+	})
 }
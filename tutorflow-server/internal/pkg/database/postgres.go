@@ -39,12 +39,75 @@ func AutoMigrate(db *gorm.DB) error {
 		return err
 	}
 
+	// Concurrent posts could leave a user with several reviews of one course,
+	// which blocks the unique review index. Keep the newest and drop the rest
+	// with their votes; the hourly rating recompute corrects the averages.
+	if err := db.Exec(`
+		DO $$ BEGIN
+			IF to_regclass('course_reviews') IS NOT NULL THEN
+				IF to_regclass('review_votes') IS NOT NULL THEN
+					DELETE FROM review_votes WHERE review_id IN (
+						SELECT a.id FROM course_reviews a JOIN course_reviews b
+						ON a.user_id = b.user_id AND a.course_id = b.course_id
+							AND (a.created_at, a.id) < (b.created_at, b.id));
+				END IF;
+				DELETE FROM course_reviews a USING course_reviews b
+				WHERE a.user_id = b.user_id AND a.course_id = b.course_id
+					AND (a.created_at, a.id) < (b.created_at, b.id);
+			END IF;
+		END $$`).Error; err != nil {
+		return fmt.Errorf("failed to remove duplicate course reviews: %w", err)
+	}
+
+	// The unique vote index can't be built while duplicates remain
+	if err := db.Exec(`
+		DO $$ BEGIN
+			IF to_regclass('review_votes') IS NOT NULL THEN
+				DELETE FROM review_votes a USING review_votes b
+				WHERE a.review_id = b.review_id AND a.user_id = b.user_id
+					AND (a.created_at, a.id) < (b.created_at, b.id);
+			END IF;
+		END $$`).Error; err != nil {
+		return fmt.Errorf("failed to remove duplicate review votes: %w", err)
+	}
+
+	// Buying and creating courses now needs a verified email. Accounts from
+	// before verification existed (no token table yet) had no way to verify,
+	// so they are treated as verified once, when the table is first created.
+	if err := db.Exec(`
+		DO $$ BEGIN
+			IF to_regclass('users') IS NOT NULL AND to_regclass('email_verification_tokens') IS NULL THEN
+				UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+			END IF;
+		END $$`).Error; err != nil {
+		return fmt.Errorf("failed to backfill verified emails: %w", err)
+	}
+
+	// Orders confirmed twice used to pay instructors twice; keep the first
+	// earning per item and instructor so the unique index can be created
+	if err := db.Exec(`
+		DO $$ BEGIN
+			IF to_regclass('instructor_earnings') IS NOT NULL THEN
+				DELETE FROM instructor_earnings a USING instructor_earnings b
+				WHERE a.order_item_id = b.order_item_id AND a.instructor_id = b.instructor_id
+					AND (a.created_at, a.id) > (b.created_at, b.id);
+			END IF;
+		END $$`).Error; err != nil {
+		return fmt.Errorf("failed to remove duplicate instructor earnings: %w", err)
+	}
+
 	// Auto migrate all domain models
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		// Users
 		&domain.User{},
 		&domain.TutorProfile{},
 		&domain.RefreshToken{},
+		&domain.LoginAttempt{},
+		&domain.EmailVerificationToken{},
+		&domain.PasswordResetToken{},
+		&domain.AccountDeletionToken{},
+		&domain.LinkedAccount{},
+		&domain.DataExport{},
 		&domain.UserDevice{},
 
 		// Courses
@@ -55,6 +118,7 @@ func AutoMigrate(db *gorm.DB) error {
 		&domain.Lesson{},
 		&domain.VideoAsset{},
 		&domain.HLSVideoAsset{},
+		&domain.VideoJob{},
 		&domain.VideoQuality{},
 		&domain.VideoEncryption{},
 		&domain.SignedURL{},
@@ -69,6 +133,7 @@ func AutoMigrate(db *gorm.DB) error {
 		&domain.QuizQuestion{},
 		&domain.QuizOption{},
 		&domain.QuizAttempt{},
+		&domain.QuizAnswer{},
 		&domain.Assignment{},
 		&domain.Submission{},
 
@@ -81,6 +146,7 @@ func AutoMigrate(db *gorm.DB) error {
 		&domain.OrderItem{},
 		&domain.InstructorEarning{},
 		&domain.Payout{},
+		&domain.CourseInstructor{},
 
 		// Reviews
 		&domain.CourseReview{},
@@ -99,6 +165,8 @@ func AutoMigrate(db *gorm.DB) error {
 		&domain.Announcement{},
 		&domain.Discussion{},
 		&domain.Notification{},
+		&domain.NotificationPreference{},
+		&domain.NotificationDigest{},
 
 		// Certificates
 		&domain.Certificate{},
@@ -124,7 +192,50 @@ func AutoMigrate(db *gorm.DB) error {
 		&domain.PeerReviewAssignment{},
 		&domain.PeerReview{},
 		&domain.PeerReviewScore{},
-	)
+
+		// Messaging
+		&domain.Conversation{},
+		&domain.Message{},
+		&domain.MessageAttachment{},
+		&domain.MessageBroadcast{},
+
+		// Analytics
+		&domain.AnalyticsEvent{},
+		&domain.SearchLog{},
+		&domain.RecentlyViewed{},
+
+		// Audit
+		&domain.AuditLog{},
+	); err != nil {
+		return err
+	}
+
+	// Replies were stored without a depth before threads could nest
+	if err := db.Exec(`
+		WITH RECURSIVE thread AS (
+			SELECT id, 0 AS depth FROM discussions WHERE parent_id IS NULL
+			UNION ALL
+			SELECT d.id, t.depth + 1 FROM discussions d JOIN thread t ON d.parent_id = t.id
+		)
+		UPDATE discussions SET depth = thread.depth
+		FROM thread
+		WHERE discussions.id = thread.id AND discussions.depth <> thread.depth`).Error; err != nil {
+		return fmt.Errorf("failed to backfill discussion depths: %w", err)
+	}
+
+	// Reviews used to be stored as "published" before moderation existed
+	if err := db.Exec(`UPDATE course_reviews SET status = 'approved' WHERE status = 'published'`).Error; err != nil {
+		return fmt.Errorf("failed to migrate review statuses: %w", err)
+	}
+
+	// Announcements were scheduled by future-dating published_at before
+	// scheduled_for existed; mark them scheduled so they are delivered on time
+	if err := db.Exec(`UPDATE announcements SET scheduled_for = published_at WHERE scheduled_for IS NULL AND published_at > now()`).Error; err != nil {
+		return fmt.Errorf("failed to backfill announcement schedules: %w", err)
+	}
+
+	// Search columns and indexes depend on the tables above
+	return createSearchIndexes(db)
 }
 
 func createEnumTypes(db *gorm.DB) error {
@@ -133,7 +244,7 @@ func createEnumTypes(db *gorm.DB) error {
 		values []string
 	}{
 		{"user_role", []string{"admin", "manager", "tutor", "student"}},
-		{"user_status", []string{"active", "inactive", "suspended", "pending"}},
+		{"user_status", []string{"active", "inactive", "suspended", "pending", "deleted"}},
 		{"course_status", []string{"draft", "published", "archived"}},
 		{"course_level", []string{"beginner", "intermediate", "advanced"}},
 		{"lesson_type", []string{"video", "text", "quiz", "assignment", "resource"}},
@@ -144,7 +255,7 @@ func createEnumTypes(db *gorm.DB) error {
 		{"order_status", []string{"pending", "completed", "refunded", "failed"}},
 		{"payment_method", []string{"stripe", "paypal", "bank_transfer"}},
 		{"coupon_type", []string{"percentage", "fixed", "free"}},
-		{"notification_type", []string{"enrollment_approved", "new_lesson", "assignment_due", "grade_posted", "announcement", "message", "course_update", "payment_received", "review_received"}},
+		{"notification_type", []string{"enrollment_approved", "new_lesson", "assignment_due", "grade_posted", "announcement", "message", "course_update", "payment_received", "review_received", "mention", "answer_accepted", "review_response"}},
 	}
 
 	for _, e := range enums {
@@ -163,6 +274,44 @@ func createEnumTypes(db *gorm.DB) error {
 			if err := db.Exec(sql).Error; err != nil {
 				return fmt.Errorf("failed to create enum type %s: %w", e.name, err)
 			}
+			continue
+		}
+
+		// Types created by older releases may be missing newer values
+		for _, v := range e.values {
+			sql := fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS '%s'", e.name, v)
+			if err := db.Exec(sql).Error; err != nil {
+				return fmt.Errorf("failed to add %s to enum type %s: %w", v, e.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// createSearchIndexes adds the generated tsvector columns and the GIN indexes
+// used by course and message search. Statements are idempotent so they can run
+// on every start.
+func createSearchIndexes(db *gorm.DB) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`ALTER TABLE courses ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(short_description, '')), 'B') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'C')
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_courses_search_vector ON courses USING GIN (search_vector)`,
+		`CREATE INDEX IF NOT EXISTS idx_courses_title_trgm ON courses USING GIN (title gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_categories_name_trgm ON categories USING GIN (name gin_trgm_ops)`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			to_tsvector('english', coalesce(content, ''))
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector)`,
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to create search indexes: %w", err)
 		}
 	}
 
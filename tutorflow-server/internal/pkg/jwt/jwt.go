@@ -16,11 +16,24 @@ var (
 	ErrExpiredToken = errors.New("token has expired")
 )
 
+// Token types
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Default lifetimes used when the configured values are missing or invalid
+const (
+	defaultAccessExpiresIn  = 15 * time.Minute
+	defaultRefreshExpiresIn = 7 * 24 * time.Hour
+)
+
 // Claims represents JWT claims
 type Claims struct {
-	UserID uuid.UUID       `json:"user_id"`
-	Email  string          `json:"email"`
-	Role   domain.UserRole `json:"role"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Email     string          `json:"email"`
+	Role      domain.UserRole `json:"role"`
+	TokenType string          `json:"typ,omitempty"`
 	jwtlib.RegisteredClaims
 }
 
@@ -34,20 +47,44 @@ type Manager struct {
 
 // NewManager creates a new JWT manager
 func NewManager(cfg config.JWTConfig) *Manager {
+	accessExpiresIn := cfg.AccessExpiresIn
+	if accessExpiresIn <= 0 {
+		accessExpiresIn = defaultAccessExpiresIn
+	}
+	refreshExpiresIn := cfg.RefreshExpiresIn
+	if refreshExpiresIn <= 0 {
+		refreshExpiresIn = defaultRefreshExpiresIn
+	}
+	// A refresh token must outlive the access tokens it is used to renew
+	if refreshExpiresIn < accessExpiresIn {
+		refreshExpiresIn = accessExpiresIn
+	}
+
 	return &Manager{
 		secret:           []byte(cfg.Secret),
-		accessExpiresIn:  cfg.AccessExpiresIn,
-		refreshExpiresIn: cfg.RefreshExpiresIn,
+		accessExpiresIn:  accessExpiresIn,
+		refreshExpiresIn: refreshExpiresIn,
 		issuer:           cfg.Issuer,
 	}
 }
 
+// AccessExpiresIn returns the access token lifetime
+func (m *Manager) AccessExpiresIn() time.Duration {
+	return m.accessExpiresIn
+}
+
+// RefreshExpiresIn returns the refresh token lifetime
+func (m *Manager) RefreshExpiresIn() time.Duration {
+	return m.refreshExpiresIn
+}
+
 // GenerateAccessToken generates an access token
 func (m *Manager) GenerateAccessToken(user *domain.User) (string, error) {
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: TokenTypeAccess,
 		RegisteredClaims: jwtlib.RegisteredClaims{
 			ExpiresAt: jwtlib.NewNumericDate(time.Now().Add(m.accessExpiresIn)),
 			IssuedAt:  jwtlib.NewNumericDate(time.Now()),
@@ -65,9 +102,10 @@ func (m *Manager) GenerateRefreshToken(user *domain.User) (string, time.Time, er
 	expiresAt := time.Now().Add(m.refreshExpiresIn)
 
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: TokenTypeRefresh,
 		RegisteredClaims: jwtlib.RegisteredClaims{
 			ExpiresAt: jwtlib.NewNumericDate(expiresAt),
 			IssuedAt:  jwtlib.NewNumericDate(time.Now()),
@@ -92,7 +130,7 @@ func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
 			return nil, ErrInvalidToken
 		}
 		return m.secret, nil
-	})
+	}, jwtlib.WithIssuer(m.issuer), jwtlib.WithExpirationRequired())
 
 	if err != nil {
 		if errors.Is(err, jwtlib.ErrTokenExpired) {
@@ -109,6 +147,31 @@ func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// ValidateAccessToken validates a token and rejects refresh tokens.
+// Tokens issued before token types were added carry no type and are accepted.
+func (m *Manager) ValidateAccessToken(tokenString string) (*Claims, error) {
+	claims, err := m.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType == TokenTypeRefresh {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ValidateRefreshToken validates a token and rejects access tokens
+func (m *Manager) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := m.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType == TokenTypeAccess {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
 // TokenPair represents access and refresh tokens
 type TokenPair struct {
 	AccessToken  string    `json:"access_token"`
@@ -0,0 +1,82 @@
+// Package metrics holds the app's Prometheus metrics. Counters are package
+// level so any layer can record to them; they are only exposed when the
+// metrics endpoint is enabled.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "tutorflow"
+
+// Registry holds every metric the app exposes
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+// HTTP metrics, labelled by route pattern rather than raw path so IDs in
+// URLs don't each become a series
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Business metrics
+var (
+	OrdersCompleted = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_completed_total",
+		Help:      "Orders paid and completed.",
+	})
+
+	EnrollmentsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "enrollments_created_total",
+		Help:      "Course enrollments created, whether free, bought or by subscription.",
+	})
+
+	VideosProcessing = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "videos_processing",
+		Help:      "Videos being transcoded right now.",
+	})
+
+	VideoJobsFinished = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "video_jobs_finished_total",
+		Help:      "Transcoding attempts by outcome: completed, retrying, failed or interrupted.",
+	}, []string{"outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RegisterDB exposes db's connection pool stats
+func RegisterDB(db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,78 @@
+// Package pagination implements keyset (cursor) pagination for lists too
+// large or too busy for page offsets: deep offsets get slow, and rows
+// written between requests shift later pages.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// Cursor marks the last row of a page by its sort time and ID, the ID
+// breaking ties between rows with the same time. The zero Cursor is the
+// start of the list.
+type Cursor struct {
+	Time time.Time `json:"t"`
+	ID   uuid.UUID `json:"id"`
+}
+
+// Encode returns the cursor as an opaque, URL-safe string
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a cursor made by Encode. An empty string is the start of
+// the list.
+func Decode(s string) (*Cursor, error) {
+	var cursor Cursor
+	if s == "" {
+		return &cursor, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// FromRequest reads the cursor query parameter. It returns nil when the
+// request has none and wants offset pagination; an empty cursor asks for
+// the first page by cursor.
+func FromRequest(c echo.Context) (*Cursor, error) {
+	if !c.QueryParams().Has("cursor") {
+		return nil, nil
+	}
+	return Decode(c.QueryParam("cursor"))
+}
+
+// After narrows a query to the rows after the cursor, newest first by
+// timeColumn then idColumn. It fetches one row more than limit so Next can
+// tell whether another page follows.
+func After(query *gorm.DB, timeColumn, idColumn string, cursor Cursor, limit int) *gorm.DB {
+	if cursor.ID != uuid.Nil {
+		query = query.Where(fmt.Sprintf("(%s, %s) < (?, ?)", timeColumn, idColumn), cursor.Time, cursor.ID)
+	}
+	return query.Order(timeColumn + " DESC").Order(idColumn + " DESC").Limit(limit + 1)
+}
+
+// Next trims rows fetched by After to limit and returns the cursor for the
+// page after them, or an empty string on the last page
+func Next[T any](rows []T, limit int, key func(T) Cursor) ([]T, string) {
+	if len(rows) <= limit {
+		return rows, ""
+	}
+	rows = rows[:limit]
+	return rows, key(rows[limit-1]).Encode()
+}
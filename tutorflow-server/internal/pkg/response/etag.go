@@ -0,0 +1,44 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ETag builds a weak entity tag from the values a response is made from, so
+// it changes whenever any of them does
+func ETag(parts ...interface{}) string {
+	h := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%v\x00", part)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// Fresh sets the ETag header and reports whether the request's
+// If-None-Match shows the client already has this version
+func Fresh(c echo.Context, etag string) bool {
+	c.Response().Header().Set("ETag", etag)
+
+	match := c.Request().Header.Get("If-None-Match")
+	if match == "" {
+		return false
+	}
+	for _, tag := range strings.Split(match, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModified returns 304 Not Modified
+func NotModified(c echo.Context) error {
+	return c.NoContent(http.StatusNotModified)
+}
@@ -0,0 +1,45 @@
+package response_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+)
+
+func TestFresh(t *testing.T) {
+	etag := response.ETag("course", 42)
+	assert.Equal(t, etag, response.ETag("course", 42))
+	assert.NotEqual(t, etag, response.ETag("course", 43))
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{name: "no header"},
+		{name: "same tag", ifNoneMatch: etag, want: true},
+		{name: "strong form of the tag", ifNoneMatch: etag[2:], want: true},
+		{name: "one of several", ifNoneMatch: `W/"old", ` + etag, want: true},
+		{name: "any", ifNoneMatch: "*", want: true},
+		{name: "stale tag", ifNoneMatch: response.ETag("course", 41)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			assert.Equal(t, tt.want, response.Fresh(c, etag))
+			assert.Equal(t, etag, rec.Header().Get("ETag"))
+		})
+	}
+}
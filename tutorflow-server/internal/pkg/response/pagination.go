@@ -0,0 +1,53 @@
+package response
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Pagination headers, repeating the body's meta for HEAD requests and
+// clients that would rather not parse it
+const (
+	HeaderTotalCount = "X-Total-Count"
+	HeaderPage       = "X-Page"
+	HeaderPerPage    = "X-Per-Page"
+	HeaderLink       = "Link"
+)
+
+// setPageHeaders sets the counts and links to the first, previous, next and
+// last pages of an offset-paginated list
+func setPageHeaders(c echo.Context, page, perPage int, total int64, totalPages int) {
+	header := c.Response().Header()
+	header.Set(HeaderTotalCount, strconv.FormatInt(total, 10))
+	header.Set(HeaderPage, strconv.Itoa(page))
+	header.Set(HeaderPerPage, strconv.Itoa(perPage))
+
+	links := []string{pageLink(c, "page", "1", "first")}
+	if page > 1 {
+		links = append(links, pageLink(c, "page", strconv.Itoa(min(page-1, max(totalPages, 1))), "prev"))
+	}
+	if page < totalPages {
+		links = append(links, pageLink(c, "page", strconv.Itoa(page+1), "next"))
+	}
+	links = append(links, pageLink(c, "page", strconv.Itoa(max(totalPages, 1)), "last"))
+	header.Set(HeaderLink, strings.Join(links, ", "))
+}
+
+// setCursorHeaders links to the next page of a cursor-paginated list, if
+// there is one
+func setCursorHeaders(c echo.Context, nextCursor string) {
+	if nextCursor != "" {
+		c.Response().Header().Set(HeaderLink, pageLink(c, "cursor", nextCursor, "next"))
+	}
+}
+
+// pageLink is a Link header entry for the request's URL with param set to
+// value, relative to the request so it holds behind proxies
+func pageLink(c echo.Context, param, value, rel string) string {
+	query := c.Request().URL.Query()
+	query.Set(param, value)
+	return fmt.Sprintf(`<%s?%s>; rel="%s"`, c.Request().URL.Path, query.Encode(), rel)
+}
@@ -0,0 +1,76 @@
+package response_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tutorflow/tutorflow-server/internal/pkg/response"
+)
+
+func TestPaginated_Headers(t *testing.T) {
+	tests := []struct {
+		name  string
+		page  int
+		total int64
+		link  string
+	}{
+		{
+			name:  "middle page",
+			page:  2,
+			total: 45,
+			link: `</courses?level=beginner&limit=20&page=1>; rel="first", ` +
+				`</courses?level=beginner&limit=20&page=1>; rel="prev", ` +
+				`</courses?level=beginner&limit=20&page=3>; rel="next", ` +
+				`</courses?level=beginner&limit=20&page=3>; rel="last"`,
+		},
+		{
+			name:  "only page",
+			page:  1,
+			total: 5,
+			link: `</courses?level=beginner&limit=20&page=1>; rel="first", ` +
+				`</courses?level=beginner&limit=20&page=1>; rel="last"`,
+		},
+		{
+			name:  "past the end",
+			page:  9,
+			total: 0,
+			link: `</courses?level=beginner&limit=20&page=1>; rel="first", ` +
+				`</courses?level=beginner&limit=20&page=1>; rel="prev", ` +
+				`</courses?level=beginner&limit=20&page=1>; rel="last"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/courses?level=beginner&limit=20&page=2", nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			assert.NoError(t, response.Paginated(c, []string{}, tt.page, 20, tt.total))
+			assert.Equal(t, tt.link, rec.Header().Get(response.HeaderLink))
+			assert.Equal(t, "20", rec.Header().Get(response.HeaderPerPage))
+			assert.Contains(t, rec.Body.String(), `"per_page":20`, "the body keeps its meta")
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/courses?page=2", nil)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, response.Paginated(echo.New().NewContext(req, rec), []string{}, 2, 20, 45))
+	assert.Equal(t, "45", rec.Header().Get(response.HeaderTotalCount))
+	assert.Equal(t, "2", rec.Header().Get(response.HeaderPage))
+}
+
+func TestCursorPaginated_Headers(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/notifications?cursor=abc&limit=10", nil)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, response.CursorPaginated(echo.New().NewContext(req, rec), []string{}, "def"))
+	assert.Equal(t, `</notifications?cursor=def&limit=10>; rel="next"`, rec.Header().Get(response.HeaderLink))
+
+	rec = httptest.NewRecorder()
+	assert.NoError(t, response.CursorPaginated(echo.New().NewContext(req, rec), []string{}, ""))
+	assert.Empty(t, rec.Header().Get(response.HeaderLink), "the last page links nowhere")
+}
@@ -12,7 +12,7 @@ type Response struct {
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   *ErrorInfo  `json:"error,omitempty"`
-	Meta    *Meta       `json:"meta,omitempty"`
+	Meta    interface{} `json:"meta,omitempty"` // *Meta or *CursorMeta
 }
 
 // ErrorInfo contains error details
@@ -36,6 +36,11 @@ type Meta struct {
 	TotalPages int   `json:"total_pages"`
 }
 
+// CursorMeta contains cursor pagination info
+type CursorMeta struct {
+	NextCursor string `json:"next_cursor,omitempty"` // empty on the last page
+}
+
 // Success returns a success response
 func Success(c echo.Context, data interface{}) error {
 	return c.JSON(http.StatusOK, Response{
@@ -67,6 +72,7 @@ func Paginated(c echo.Context, data interface{}, page, perPage int, total int64)
 	if int(total)%perPage > 0 {
 		totalPages++
 	}
+	setPageHeaders(c, page, perPage, total, totalPages)
 
 	return c.JSON(http.StatusOK, Response{
 		Success: true,
@@ -80,16 +86,48 @@ func Paginated(c echo.Context, data interface{}, page, perPage int, total int64)
 	})
 }
 
-// Error returns an error response
-func Error(c echo.Context, status int, message string) error {
-	return c.JSON(status, Response{
-		Success: false,
-		Error: &ErrorInfo{
-			Message: message,
-		},
+// CursorPaginated returns a page of a cursor-paginated list
+func CursorPaginated(c echo.Context, data interface{}, nextCursor string) error {
+	setCursorHeaders(c, nextCursor)
+	return c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    data,
+		Meta:    &CursorMeta{NextCursor: nextCursor},
 	})
 }
 
+// statusCodes are the error codes of errors with nothing more specific to say
+// than their HTTP status
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            "BAD_REQUEST",
+	http.StatusUnauthorized:          "UNAUTHORIZED",
+	http.StatusPaymentRequired:       "PAYMENT_REQUIRED",
+	http.StatusForbidden:             "FORBIDDEN",
+	http.StatusNotFound:              "NOT_FOUND",
+	http.StatusMethodNotAllowed:      "METHOD_NOT_ALLOWED",
+	http.StatusConflict:              "CONFLICT",
+	http.StatusRequestEntityTooLarge: "PAYLOAD_TOO_LARGE",
+	http.StatusUnsupportedMediaType:  "UNSUPPORTED_MEDIA_TYPE",
+	http.StatusTooManyRequests:       "TOO_MANY_REQUESTS",
+	http.StatusServiceUnavailable:    "SERVICE_UNAVAILABLE",
+}
+
+// StatusCode returns the generic error code for an HTTP status
+func StatusCode(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return "INTERNAL_ERROR"
+	}
+	return "BAD_REQUEST"
+}
+
+// Error returns an error response with the generic code for its status
+func Error(c echo.Context, status int, message string) error {
+	return ErrorWithCode(c, status, StatusCode(status), message)
+}
+
 // ErrorWithCode returns an error response with error code
 func ErrorWithCode(c echo.Context, status int, code, message string) error {
 	return c.JSON(status, Response{
@@ -0,0 +1,178 @@
+// Package testdb gives tests a database to run repositories against: a real
+// Postgres when TEST_DATABASE_URL is set, or a fake driver that records the
+// SQL it is sent and answers from a callback.
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/tutorflow/tutorflow-server/internal/pkg/database"
+)
+
+var (
+	migrateOnce sync.Once
+	migrated    *gorm.DB
+	migrateErr  error
+)
+
+// Open connects to the Postgres named by TEST_DATABASE_URL, migrating it on
+// first use, and skips the test when the variable isn't set. Each test runs
+// in a transaction that is rolled back when it ends, so tests don't see each
+// other's rows.
+func Open(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	migrateOnce.Do(func() {
+		migrated, migrateErr = gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if migrateErr == nil {
+			migrateErr = database.AutoMigrate(migrated)
+		}
+	})
+	if migrateErr != nil {
+		t.Fatalf("test database: %v", migrateErr)
+	}
+
+	tx := migrated.Begin()
+	if tx.Error != nil {
+		t.Fatalf("test database: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// Query is a statement the fake driver received
+type Query struct {
+	SQL  string
+	Args []driver.NamedValue
+}
+
+// AnswerFunc returns the rows for a query sent to a fake database
+type AnswerFunc func(query string, args []driver.NamedValue) (driver.Rows, error)
+
+// Driver is a database/sql driver that records each statement and answers
+// queries with its AnswerFunc. Other statements affect no rows, and
+// transactions are accepted but change nothing.
+type Driver struct {
+	answer AnswerFunc
+
+	mu      sync.Mutex
+	queries []Query
+}
+
+var fakeDrivers atomic.Int64
+
+// Fake opens a gorm database over a new fake driver. A nil answer returns
+// no rows, or a zero count for count queries.
+func Fake(t *testing.T, answer AnswerFunc) (*gorm.DB, *Driver) {
+	t.Helper()
+	if answer == nil {
+		answer = func(query string, _ []driver.NamedValue) (driver.Rows, error) {
+			if strings.Contains(query, "count(") {
+				return Value("count", int64(0)), nil
+			}
+			return Rows([]string{"id"}), nil
+		}
+	}
+	d := &Driver{answer: answer}
+	name := fmt.Sprintf("testdb-fake-%d", fakeDrivers.Add(1))
+	sql.Register(name, d)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DriverName: name}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("fake database: %v", err)
+	}
+	return db, d
+}
+
+// Queries returns the statements received so far, oldest first
+func (d *Driver) Queries() []Query {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Query(nil), d.queries...)
+}
+
+// Reset forgets the statements received so far
+func (d *Driver) Reset() {
+	d.mu.Lock()
+	d.queries = nil
+	d.mu.Unlock()
+}
+
+func (d *Driver) Open(string) (driver.Conn, error) { return d, nil }
+
+func (d *Driver) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare not supported")
+}
+
+func (d *Driver) Close() error { return nil }
+
+func (d *Driver) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (d *Driver) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	d.mu.Lock()
+	d.queries = append(d.queries, Query{SQL: query, Args: args})
+	d.mu.Unlock()
+	return d.answer(query, args)
+}
+
+func (d *Driver) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	d.mu.Lock()
+	d.queries = append(d.queries, Query{SQL: query, Args: args})
+	d.mu.Unlock()
+	return driver.RowsAffected(0), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error { return nil }
+
+func (fakeTx) Rollback() error { return nil }
+
+// Rows is a result set of columns holding the given rows
+func Rows(columns []string, rows ...[]driver.Value) driver.Rows {
+	return &rowSet{columns: columns, rows: rows}
+}
+
+// Value is a result set of one row with one column
+func Value(column string, value driver.Value) driver.Rows {
+	return Rows([]string{column}, []driver.Value{value})
+}
+
+type rowSet struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *rowSet) Columns() []string { return r.columns }
+
+func (r *rowSet) Close() error { return nil }
+
+func (r *rowSet) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
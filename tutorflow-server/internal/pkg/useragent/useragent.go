@@ -0,0 +1,86 @@
+// Package useragent tells users' devices apart by their User-Agent header,
+// for the sign-in sessions and playback devices they can review
+package useragent
+
+import "strings"
+
+// Device types reported for a user agent
+const (
+	TypeDesktop = "desktop"
+	TypeMobile  = "mobile"
+	TypeTablet  = "tablet"
+	TypeTV      = "tv"
+	TypeUnknown = "unknown"
+)
+
+// Info is what can be told about a device from its User-Agent
+type Info struct {
+	Name    string // e.g. "Chrome on macOS"
+	Type    string
+	Browser string
+	OS      string
+}
+
+// Parse derives a readable device name, type, browser and OS from a
+// User-Agent header. It only needs to be good enough for a user to recognise
+// their own devices in a list, so it checks the common tokens in order of
+// specificity rather than parsing the header fully.
+func Parse(ua string) Info {
+	info := Info{Type: TypeUnknown}
+	if strings.TrimSpace(ua) == "" {
+		info.Name = "Unknown device"
+		return info
+	}
+	lower := strings.ToLower(ua)
+
+	// Edge and Opera include "chrome", and Chrome includes "safari"
+	switch {
+	case strings.Contains(lower, "edg/") || strings.Contains(lower, "edge/"):
+		info.Browser = "Edge"
+	case strings.Contains(lower, "opr/") || strings.Contains(lower, "opera"):
+		info.Browser = "Opera"
+	case strings.Contains(lower, "samsungbrowser/"):
+		info.Browser = "Samsung Internet"
+	case strings.Contains(lower, "firefox/") || strings.Contains(lower, "fxios/"):
+		info.Browser = "Firefox"
+	case strings.Contains(lower, "chrome/") || strings.Contains(lower, "crios/"):
+		info.Browser = "Chrome"
+	case strings.Contains(lower, "safari/"):
+		info.Browser = "Safari"
+	}
+
+	switch {
+	case strings.Contains(lower, "ipad"):
+		info.OS, info.Type = "iPadOS", TypeTablet
+	case strings.Contains(lower, "iphone") || strings.Contains(lower, "ipod"):
+		info.OS, info.Type = "iOS", TypeMobile
+	case strings.Contains(lower, "android"):
+		info.OS, info.Type = "Android", TypeTablet
+		if strings.Contains(lower, "mobile") {
+			info.Type = TypeMobile
+		}
+	case strings.Contains(lower, "smart-tv") || strings.Contains(lower, "smarttv") ||
+		strings.Contains(lower, "appletv") || strings.Contains(lower, "tizen") || strings.Contains(lower, "web0s"):
+		info.OS, info.Type = "TV", TypeTV
+	case strings.Contains(lower, "windows"):
+		info.OS, info.Type = "Windows", TypeDesktop
+	case strings.Contains(lower, "cros"):
+		info.OS, info.Type = "ChromeOS", TypeDesktop
+	case strings.Contains(lower, "mac os x") || strings.Contains(lower, "macintosh"):
+		info.OS, info.Type = "macOS", TypeDesktop
+	case strings.Contains(lower, "linux"):
+		info.OS, info.Type = "Linux", TypeDesktop
+	}
+
+	switch {
+	case info.Browser != "" && info.OS != "":
+		info.Name = info.Browser + " on " + info.OS
+	case info.Browser != "":
+		info.Name = info.Browser
+	case info.OS != "":
+		info.Name = info.OS + " device"
+	default:
+		info.Name = "Unknown device"
+	}
+	return info
+}
@@ -0,0 +1,31 @@
+package useragent_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tutorflow/tutorflow-server/internal/pkg/useragent"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		ua   string
+		name string
+		kind string
+	}{
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", "Chrome on macOS", useragent.TypeDesktop},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0", "Edge on Windows", useragent.TypeDesktop},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1", "Safari on iOS", useragent.TypeMobile},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36", "Chrome on Android", useragent.TypeMobile},
+		{"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", "Chrome on Android", useragent.TypeTablet},
+		{"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", "Firefox on Linux", useragent.TypeDesktop},
+		{"", "Unknown device", useragent.TypeUnknown},
+	}
+
+	for _, tc := range cases {
+		info := useragent.Parse(tc.ua)
+		assert.Equal(t, tc.name, info.Name, tc.ua)
+		assert.Equal(t, tc.kind, info.Type, tc.ua)
+	}
+}
@@ -61,6 +61,8 @@ func getErrorMessage(e validator.FieldError) string {
 	switch e.Tag() {
 	case "required":
 		return "This field is required"
+	case "required_without":
+		return "This field is required without " + e.Param()
 	case "email":
 		return "Must be a valid email address"
 	case "min":
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 )
 
 // UserRepository interface for user data access
@@ -13,12 +14,17 @@ type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetByUsernames(ctx context.Context, usernames []string) ([]domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, filters UserFilters) ([]domain.User, int64, error)
 	UpdateLastLogin(ctx context.Context, id uuid.UUID) error
 	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
 	VerifyEmail(ctx context.Context, id uuid.UUID) error
+	UpdateLastActive(ctx context.Context, id uuid.UUID, at time.Time) error
+	// Anonymize saves a user scrubbed with User.Anonymize, clears their
+	// remaining personal data and records entry, all or nothing
+	Anonymize(ctx context.Context, user *domain.User, entry *domain.AuditLog) error
 }
 
 type UserFilters struct {
@@ -41,22 +47,71 @@ type TutorProfileRepository interface {
 type RefreshTokenRepository interface {
 	Create(ctx context.Context, token *domain.RefreshToken) error
 	GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.RefreshToken, error)
+	ListActive(ctx context.Context, userID uuid.UUID) ([]domain.RefreshToken, error)
 	Revoke(ctx context.Context, id uuid.UUID) error
+	// Replace retires a token spent on a refresh, reporting false if it had
+	// already been revoked or spent by a concurrent refresh
+	Replace(ctx context.Context, id, replacedBy uuid.UUID) (bool, error)
 	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
 	DeleteExpired(ctx context.Context) error
 }
 
+// EmailVerificationRepository interface
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token *domain.EmailVerificationToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error)
+	DeleteForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// PasswordResetRepository interface
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *domain.PasswordResetToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error)
+	DeleteForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// AccountDeletionRepository interface
+type AccountDeletionRepository interface {
+	Create(ctx context.Context, token *domain.AccountDeletionToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*domain.AccountDeletionToken, error)
+	DeleteForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// LinkedAccountRepository interface
+type LinkedAccountRepository interface {
+	Create(ctx context.Context, account *domain.LinkedAccount) error
+	GetByProviderUser(ctx context.Context, provider domain.AuthProvider, providerUserID string) (*domain.LinkedAccount, error)
+}
+
+// LoginAttemptRepository interface
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, attempt *domain.LoginAttempt) error
+	GetRecent(ctx context.Context, email string, since time.Time) ([]domain.LoginAttempt, error)
+	DeleteForEmail(ctx context.Context, email string) error
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
 // CourseRepository interface
 type CourseRepository interface {
 	Create(ctx context.Context, course *domain.Course) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Course, error)
 	GetBySlug(ctx context.Context, slug string) (*domain.Course, error)
+	GetByEnrollmentCode(ctx context.Context, code string) (*domain.Course, error)
 	Update(ctx context.Context, course *domain.Course) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, filters CourseFilters) ([]domain.Course, int64, error)
 	GetByInstructor(ctx context.Context, instructorID uuid.UUID, page, limit int) ([]domain.Course, int64, error)
 	UpdateStats(ctx context.Context, id uuid.UUID) error
 	IncrementStudentCount(ctx context.Context, id uuid.UUID) error
+	SetEnrollmentCode(ctx context.Context, id uuid.UUID, code *string) error
+	GetInstructorSplits(ctx context.Context, courseID uuid.UUID) ([]domain.CourseInstructor, error)
+	SetInstructorSplits(ctx context.Context, courseID uuid.UUID, splits []domain.CourseInstructor) error
+	SetCategories(ctx context.Context, courseID uuid.UUID, categoryIDs []uuid.UUID) error
+	ListDeleted(ctx context.Context, instructorID *uuid.UUID, page, limit int) ([]domain.Course, int64, error)
+	GetDeletedByID(ctx context.Context, id uuid.UUID) (*domain.Course, error)
+	Restore(ctx context.Context, id uuid.UUID) error
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type CourseFilters struct {
@@ -69,10 +124,15 @@ type CourseFilters struct {
 	MinPrice     *float64
 	MaxPrice     *float64
 	MinRating    *float64
-	SortBy       string // "created_at", "price", "rating", "students"
-	SortOrder    string // "asc", "desc"
-	Page         int
-	Limit        int
+	// ActiveInstructorsOnly hides courses of suspended instructors
+	ActiveInstructorsOnly bool
+	SortBy                string // "created_at", "price", "rating", "students"
+	SortOrder             string // "asc", "desc"
+	Page                  int
+	Limit                 int
+	// Cursor pages newest first from this cursor instead of by Page, without
+	// counting the total
+	Cursor *pagination.Cursor
 }
 
 // CategoryRepository interface
@@ -84,6 +144,11 @@ type CategoryRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context) ([]domain.Category, error)
 	GetWithSubcategories(ctx context.Context) ([]domain.Category, error)
+	CountSubcategories(ctx context.Context, id uuid.UUID) (int64, error)
+	CountCourses(ctx context.Context, id uuid.UUID) (int64, error)
+	ForceDelete(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) error
+	GetByParent(ctx context.Context, parentID *uuid.UUID) ([]domain.Category, error)
+	Reorder(ctx context.Context, parentID *uuid.UUID, categoryIDs []uuid.UUID) error
 }
 
 // ModuleRepository interface
@@ -95,11 +160,13 @@ type ModuleRepository interface {
 	DeleteByCourse(ctx context.Context, courseID uuid.UUID) error
 	GetByCourse(ctx context.Context, courseID uuid.UUID) ([]domain.Module, error)
 	Reorder(ctx context.Context, courseID uuid.UUID, moduleIDs []uuid.UUID) error
+	SaveCurriculum(ctx context.Context, courseID uuid.UUID, modules []domain.Module) error
 }
 
 // LessonRepository interface
 type LessonRepository interface {
 	Create(ctx context.Context, lesson *domain.Lesson) error
+	CreateBatch(ctx context.Context, lessons []domain.Lesson) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Lesson, error)
 	Update(ctx context.Context, lesson *domain.Lesson) error
 	Delete(ctx context.Context, id uuid.UUID) error
@@ -119,6 +186,12 @@ type EnrollmentRepository interface {
 	GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Enrollment, int64, error)
 	UpdateProgress(ctx context.Context, id uuid.UUID, progress float64) error
 	GetStats(ctx context.Context, userID uuid.UUID) (*domain.StudentDashboardStats, error)
+	HasInstructor(ctx context.Context, userID, instructorID uuid.UUID) (bool, error)
+	GetCourseFunnel(ctx context.Context, courseID uuid.UUID) (*domain.CourseFunnel, error)
+	GetAtRisk(ctx context.Context, courseID uuid.UUID, inactiveSince time.Time, maxProgress float64) ([]domain.AtRiskStudent, error)
+	// MarkNudged records a nudge at now on those of the enrollments not
+	// nudged since cooldownSince, and returns their IDs
+	MarkNudged(ctx context.Context, ids []uuid.UUID, now, cooldownSince time.Time) ([]uuid.UUID, error)
 }
 
 type EnrollmentFilters struct {
@@ -127,6 +200,9 @@ type EnrollmentFilters struct {
 	Status   *domain.EnrollmentStatus
 	Page     int
 	Limit    int
+	// Cursor pages by enrollment date from this cursor instead of by Page,
+	// without counting the total
+	Cursor *pagination.Cursor
 }
 
 // LessonProgressRepository interface
@@ -136,6 +212,7 @@ type LessonProgressRepository interface {
 	GetByEnrollment(ctx context.Context, enrollmentID uuid.UUID) ([]domain.LessonProgress, error)
 	MarkComplete(ctx context.Context, enrollmentID, lessonID uuid.UUID) error
 	UpdateVideoPosition(ctx context.Context, enrollmentID, lessonID uuid.UUID, position int) error
+	CountCompletion(ctx context.Context, enrollmentID, courseID uuid.UUID) (completed, total int64, err error)
 }
 
 // OrderRepository interface
@@ -144,6 +221,9 @@ type OrderRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Order, error)
 	GetByOrderNumber(ctx context.Context, orderNumber string) (*domain.Order, error)
 	Update(ctx context.Context, order *domain.Order) error
+	// Complete marks a pending or failed order completed and reports whether
+	// this call did it, so concurrent confirmations fulfil it only once
+	Complete(ctx context.Context, order *domain.Order) (bool, error)
 	GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Order, int64, error)
 	GetByPaymentIntent(ctx context.Context, paymentIntentID string) (*domain.Order, error)
 }
@@ -180,6 +260,7 @@ type WishlistRepository interface {
 	Remove(ctx context.Context, userID, courseID uuid.UUID) error
 	GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Wishlist, int64, error)
 	Exists(ctx context.Context, userID, courseID uuid.UUID) (bool, error)
+	CountByCourse(ctx context.Context, courseID uuid.UUID, since *time.Time) (int64, error)
 }
 
 // CouponRepository interface
@@ -201,17 +282,44 @@ type ReviewRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.CourseReview, int64, error)
 	GetByUserAndCourse(ctx context.Context, userID, courseID uuid.UUID) (*domain.CourseReview, error)
-	Vote(ctx context.Context, reviewID, userID uuid.UUID, isHelpful bool) error
+	Vote(ctx context.Context, reviewID, userID uuid.UUID, isHelpful bool) (helpful, unhelpful int, err error)
+	RemoveVote(ctx context.Context, reviewID, userID uuid.UUID) (helpful, unhelpful int, err error)
+	GetRatingDistribution(ctx context.Context, courseID uuid.UUID) (map[int]int64, error)
+	ListPending(ctx context.Context, instructorID *uuid.UUID, page, limit int) ([]domain.CourseReview, int64, error)
+	RecomputeRatings(ctx context.Context) (int64, error)
 }
 
 // NotificationRepository interface
 type NotificationRepository interface {
 	Create(ctx context.Context, notification *domain.Notification) error
+	CreateBatch(ctx context.Context, notifications []domain.Notification) error
 	GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Notification, int64, error)
-	MarkAsRead(ctx context.Context, id uuid.UUID) error
+	// GetByUserAfter pages newest first from a cursor, fetching limit+1 rows
+	GetByUserAfter(ctx context.Context, userID uuid.UUID, cursor pagination.Cursor, limit int) ([]domain.Notification, error)
+	// MarkAsRead marks the user's unread notifications among ids as read,
+	// skipping IDs of other users' notifications, and returns how many changed
+	MarkAsRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int64, error)
 	MarkAllAsRead(ctx context.Context, userID uuid.UUID) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Delete deletes the user's notifications among ids, or all of them when
+	// ids is nil, created before before if it is set
+	Delete(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, before *time.Time) (int64, error)
 	GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetForDigest(ctx context.Context, userID uuid.UUID, since time.Time) ([]domain.Notification, error)
+	MarkDigested(ctx context.Context, ids []uuid.UUID, at time.Time) error
+}
+
+// NotificationPreferenceRepository interface
+type NotificationPreferenceRepository interface {
+	GetByUser(ctx context.Context, userID uuid.UUID) (domain.NotificationPreferences, error)
+	GetByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.NotificationPreferences, error)
+	Upsert(ctx context.Context, prefs []domain.NotificationPreference) error
+	GetDigest(ctx context.Context, userID uuid.UUID) (*domain.NotificationDigest, error)
+	// GetDigestFrequencies returns the frequency of each listed user who has
+	// chosen one; the rest are immediate
+	GetDigestFrequencies(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.DigestFrequency, error)
+	SetDigest(ctx context.Context, digest *domain.NotificationDigest) error
+	GetDueDigests(ctx context.Context, frequency domain.DigestFrequency, sentBefore time.Time) ([]domain.NotificationDigest, error)
+	MarkDigestSent(ctx context.Context, userID uuid.UUID, at time.Time) error
 }
 
 // QuizRepository interface
@@ -238,6 +346,33 @@ type QuizAttemptRepository interface {
 	GetByUserAndQuiz(ctx context.Context, userID, quizID uuid.UUID) ([]domain.QuizAttempt, error)
 	CountByUserAndQuiz(ctx context.Context, userID, quizID uuid.UUID) (int, error)
 	GetLatestByUserAndQuiz(ctx context.Context, userID, quizID uuid.UUID) (*domain.QuizAttempt, error)
+	CreateAnswers(ctx context.Context, answers []domain.QuizAnswer) error
+	GetAttemptSummary(ctx context.Context, quizID uuid.UUID) (*QuizAttemptSummary, error)
+	GetQuestionAnswerCounts(ctx context.Context, quizID uuid.UUID) ([]QuestionAnswerCount, error)
+	GetOptionSelectionCounts(ctx context.Context, quizID uuid.UUID) ([]OptionSelectionCount, error)
+}
+
+// QuizAttemptSummary aggregates the completed attempts of a quiz
+type QuizAttemptSummary struct {
+	Attempts          int64
+	Passed            int64
+	AveragePercentage float64
+}
+
+// QuestionAnswerCount is how many attempts answered a question, and correctly
+type QuestionAnswerCount struct {
+	QuestionID uuid.UUID
+	Answered   int64
+	Correct    int64
+}
+
+// OptionSelectionCount is how often an option was picked, overall and in
+// attempts that got the question wrong
+type OptionSelectionCount struct {
+	QuestionID uuid.UUID
+	OptionID   uuid.UUID
+	Selected   int64
+	Wrong      int64
 }
 
 // AssignmentRepository interface
@@ -268,10 +403,15 @@ type DiscussionRepository interface {
 	GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error)
 	GetByLesson(ctx context.Context, lessonID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error)
 	GetReplies(ctx context.Context, parentID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error)
+	// GetThread returns the replies nested under rootID, down to levels
+	// below it, as a flat list ordered by creation time
+	GetThread(ctx context.Context, rootID uuid.UUID, levels int) ([]domain.Discussion, error)
+	CountReplies(ctx context.Context, parentIDs []uuid.UUID) (map[uuid.UUID]int64, error)
 	Upvote(ctx context.Context, id uuid.UUID) error
 	RemoveUpvote(ctx context.Context, id uuid.UUID) error
 	MarkResolved(ctx context.Context, id uuid.UUID, resolved bool) error
 	Pin(ctx context.Context, id uuid.UUID, pinned bool) error
+	SetAcceptedReply(ctx context.Context, id uuid.UUID, replyID *uuid.UUID) error
 	CountByCourse(ctx context.Context, courseID uuid.UUID) (int64, error)
 	CountByLesson(ctx context.Context, lessonID uuid.UUID) (int64, error)
 }
@@ -281,6 +421,7 @@ type CertificateRepository interface {
 	Create(ctx context.Context, cert *domain.Certificate) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Certificate, error)
 	GetByNumber(ctx context.Context, number string) (*domain.Certificate, error)
+	GetByNumbers(ctx context.Context, numbers []string) ([]domain.Certificate, error)
 	GetByEnrollment(ctx context.Context, enrollmentID uuid.UUID) (*domain.Certificate, error)
 	Update(ctx context.Context, cert *domain.Certificate) error
 	GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Certificate, int64, error)
@@ -310,6 +451,16 @@ type FacetItem struct {
 	Count int64
 }
 
+// PriceRange is a price facet bucket. Max is exclusive and nil for the open-ended
+// bucket; a bucket whose Max equals Min matches that exact price. A course is
+// counted in the first bucket it matches.
+type PriceRange struct {
+	Label string
+	Value string
+	Min   float64
+	Max   *float64
+}
+
 // SearchFacets for filter UI
 type SearchFacets struct {
 	Categories  []FacetItem
@@ -320,10 +471,12 @@ type SearchFacets struct {
 // SearchRepository interface
 type SearchRepository interface {
 	SearchCourses(ctx context.Context, filters SearchFilters) ([]domain.Course, int64, error)
-	GetFacets(ctx context.Context, query string) (*SearchFacets, error)
+	GetFacets(ctx context.Context, filters SearchFilters, priceRanges []PriceRange) (*SearchFacets, error)
 	GetSuggestions(ctx context.Context, query string, limit int) ([]string, error)
-	GetTrendingSearches(ctx context.Context, limit int) ([]string, error)
+	GetTrendingSearches(ctx context.Context, since time.Time, minCount, limit int) ([]string, error)
 	RecordSearch(ctx context.Context, query string, userID *uuid.UUID, resultCount int64) error
+	GetRecentSearches(ctx context.Context, userID uuid.UUID, limit int) ([]string, error)
+	ClearUserSearches(ctx context.Context, userID uuid.UUID) error
 }
 
 // AnnouncementRepository interface
@@ -337,6 +490,13 @@ type AnnouncementRepository interface {
 	GetForUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Announcement, int64, error)
 	GetByAuthor(ctx context.Context, authorID uuid.UUID, page, limit int) ([]domain.Announcement, int64, error)
 	Pin(ctx context.Context, id uuid.UUID, pinned bool) error
+	// GetRecipients pages through the users an announcement goes to in ID
+	// order, starting after the given ID: the course's active learners, or
+	// every active account when courseID is nil
+	GetRecipients(ctx context.Context, courseID *uuid.UUID, after uuid.UUID, limit int) ([]domain.User, error)
+	Reschedule(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	DeleteScheduled(ctx context.Context, id uuid.UUID) (bool, error)
+	PublishDue(ctx context.Context, now time.Time) ([]domain.Announcement, error)
 }
 
 // MessageRepository interface
@@ -346,16 +506,26 @@ type MessageRepository interface {
 	GetConversationByID(ctx context.Context, id uuid.UUID) (*domain.Conversation, error)
 	GetConversationBetween(ctx context.Context, user1, user2 uuid.UUID) (*domain.Conversation, error)
 	GetUserConversations(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.ConversationWithUnread, int64, error)
+	GetConversationPartners(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
 	UpdateConversation(ctx context.Context, conv *domain.Conversation) error
 
 	// Messages
 	CreateMessage(ctx context.Context, msg *domain.Message) error
 	GetMessageByID(ctx context.Context, id uuid.UUID) (*domain.Message, error)
 	GetConversationMessages(ctx context.Context, convID uuid.UUID, page, limit int) ([]domain.Message, int64, error)
+	// GetConversationMessagesAfter pages newest first from a cursor, fetching limit+1 rows
+	GetConversationMessagesAfter(ctx context.Context, convID uuid.UUID, cursor pagination.Cursor, limit int) ([]domain.Message, error)
 	MarkAsRead(ctx context.Context, msgID uuid.UUID) error
 	MarkConversationAsRead(ctx context.Context, convID, userID uuid.UUID) error
 	GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64, error)
 	DeleteMessage(ctx context.Context, id uuid.UUID) error
+	SearchMessages(ctx context.Context, userID uuid.UUID, query string, page, limit int) ([]domain.MessageSearchResult, int64, error)
+
+	// Broadcasts
+	// ReserveBroadcast records a broadcast unless its sender has already sent
+	// limit since the given time, returning ErrBroadcastLimitReached
+	ReserveBroadcast(ctx context.Context, broadcast *domain.MessageBroadcast, since time.Time, limit int64) error
+	UpdateBroadcastCounts(ctx context.Context, broadcast *domain.MessageBroadcast) error
 }
 
 // PushSubscriptionRepository interface
@@ -416,6 +586,7 @@ type RecentlyViewedRepository interface {
 	GetByUser(ctx context.Context, userID uuid.UUID, limit int) ([]domain.RecentlyViewed, error)
 	Clear(ctx context.Context, userID uuid.UUID) error
 	Delete(ctx context.Context, userID, courseID uuid.UUID) error
+	Prune(ctx context.Context, userID uuid.UUID, keep int) error
 }
 
 // ScheduledReportRepository interface
@@ -438,6 +609,15 @@ type VideoRepository interface {
 	UpdateAsset(ctx context.Context, asset *domain.HLSVideoAsset) error
 	DeleteAsset(ctx context.Context, id uuid.UUID) error
 
+	// Transcoding jobs
+	EnqueueJob(ctx context.Context, videoID uuid.UUID) error
+	ClaimJob(ctx context.Context, staleBefore time.Time) (*domain.VideoJob, error)
+	GetJobByVideoID(ctx context.Context, videoID uuid.UUID) (*domain.VideoJob, error)
+	UpdateJob(ctx context.Context, job *domain.VideoJob) error
+	TouchJob(ctx context.Context, id uuid.UUID) error
+	DeleteJob(ctx context.Context, videoID uuid.UUID) error
+	EnqueueOrphanedAssets(ctx context.Context) (int64, error)
+
 	// Quality variants
 	CreateQuality(ctx context.Context, quality *domain.VideoQuality) error
 	GetQualitiesByVideoID(ctx context.Context, videoID uuid.UUID) ([]domain.VideoQuality, error)
@@ -450,8 +630,10 @@ type VideoRepository interface {
 	// Signed URLs
 	CreateSignedURL(ctx context.Context, signedURL *domain.SignedURL) error
 	GetSignedURLByToken(ctx context.Context, token string) (*domain.SignedURL, error)
-	MarkSignedURLUsed(ctx context.Context, token string) error
-	CleanupExpiredURLs(ctx context.Context) error
+	GetSignedURLByWatermark(ctx context.Context, code string) (*domain.SignedURL, error)
+	RecordKeyFetch(ctx context.Context, id uuid.UUID) error
+	ExpireDeviceSignedURLs(ctx context.Context, userID uuid.UUID, deviceID string) error
+	DeleteExpiredSignedURLs(ctx context.Context, expiredBefore time.Time) (int64, error)
 
 	// Device Sessions
 	CreateDeviceSession(ctx context.Context, session *domain.DeviceSession) error
@@ -460,6 +642,7 @@ type VideoRepository interface {
 	CountActiveDevices(ctx context.Context, userID uuid.UUID) (int64, error)
 	UpdateDeviceSession(ctx context.Context, session *domain.DeviceSession) error
 	DeactivateDeviceSession(ctx context.Context, id uuid.UUID) error
+	DeactivateStaleDeviceSessions(ctx context.Context, olderThan time.Time) (int64, error)
 }
 
 // SubscriptionRepository interface
@@ -476,6 +659,15 @@ type SubscriptionRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error)
 	GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error)
+	GetByStripeID(ctx context.Context, stripeSubscriptionID string) (*domain.Subscription, error)
+	// GetPendingByUserID returns the user's latest subscription still waiting
+	// for its first checkout, before Stripe has created one
+	GetPendingByUserID(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error)
+	// GetOpenByUserID returns the user's subscriptions that haven't ended:
+	// active, trialing, past due or waiting for checkout
+	GetOpenByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Subscription, error)
+	// Update saves the subscription and grants or revokes the enrollments it
+	// gave access to, to match its status and the courses its plan covers
 	Update(ctx context.Context, subscription *domain.Subscription) error
 	Cancel(ctx context.Context, id uuid.UUID) error
 	GetExpiringSubscriptions(ctx context.Context, days int) ([]domain.Subscription, error)
@@ -539,3 +731,73 @@ type PeerReviewRepository interface {
 	GetPendingSubmissionsForAssignment(ctx context.Context, lessonID uuid.UUID) ([]domain.Submission, error)
 	GetEligibleReviewers(ctx context.Context, lessonID, excludeUserID uuid.UUID) ([]domain.User, error)
 }
+
+// AnalyticsEventFilters for querying analytics events
+type AnalyticsEventFilters struct {
+	Type   *domain.AnalyticsEventType
+	UserID *uuid.UUID
+	From   *time.Time
+	To     *time.Time
+	Page   int
+	Limit  int
+}
+
+// AnalyticsEventCount is an aggregated count for a single event type
+type AnalyticsEventCount struct {
+	Type        domain.AnalyticsEventType `json:"type"`
+	Count       int64                     `json:"count"`
+	UniqueUsers int64                     `json:"unique_users"`
+}
+
+// AnalyticsEventRepository interface
+type AnalyticsEventRepository interface {
+	CreateBatch(ctx context.Context, events []domain.AnalyticsEvent) error
+	List(ctx context.Context, filters AnalyticsEventFilters) ([]domain.AnalyticsEvent, int64, error)
+	CountByType(ctx context.Context, filters AnalyticsEventFilters) ([]AnalyticsEventCount, error)
+}
+
+// DataExportRepository interface
+type DataExportRepository interface {
+	Create(ctx context.Context, export *domain.DataExport) error
+	Update(ctx context.Context, export *domain.DataExport) error
+	// GetPending returns the user's export still being prepared, or nil
+	GetPending(ctx context.Context, userID uuid.UUID) (*domain.DataExport, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.DataExport, error)
+	ListExpired(ctx context.Context, now time.Time) ([]domain.DataExport, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// AuditLogFilters for querying the audit log
+type AuditLogFilters struct {
+	ActorID *uuid.UUID
+	Action  *domain.AuditAction
+	From    *time.Time
+	To      *time.Time
+	Page    int
+	Limit   int
+}
+
+// AuditLogRepository interface
+type AuditLogRepository interface {
+	// Record saves the changed records and the entry in one transaction, so
+	// an administrative change is never made without its audit entry
+	Record(ctx context.Context, entry *domain.AuditLog, changes ...interface{}) error
+	List(ctx context.Context, filters AuditLogFilters) ([]domain.AuditLog, int64, error)
+}
+
+// RecommendationRepository interface
+type RecommendationRepository interface {
+	GetAlsoBought(ctx context.Context, courseID uuid.UUID, excludeUserID *uuid.UUID, limit int) ([]domain.Course, error)
+	GetPopularInSameCategories(ctx context.Context, courseID uuid.UUID, excludeUserID *uuid.UUID, excludeIDs []uuid.UUID, limit int) ([]domain.Course, error)
+	GetEnrollmentInterests(ctx context.Context, userID uuid.UUID) ([]EnrollmentInterest, error)
+	GetTopRatedInCategories(ctx context.Context, categoryIDs []uuid.UUID, excludeUserID uuid.UUID, limit int) ([]domain.Course, error)
+	GetTrending(ctx context.Context, excludeUserID *uuid.UUID, excludeIDs []uuid.UUID, limit int) ([]domain.Course, error)
+}
+
+// EnrollmentInterest is one category/level signal taken from a user's enrollment
+type EnrollmentInterest struct {
+	CourseID   uuid.UUID
+	CategoryID *uuid.UUID
+	Level      domain.CourseLevel
+	EnrolledAt time.Time
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+)
+
+type analyticsEventRepository struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsEventRepository creates a new analytics event repository
+func NewAnalyticsEventRepository(db *gorm.DB) repository.AnalyticsEventRepository {
+	return &analyticsEventRepository{db: db}
+}
+
+func (r *analyticsEventRepository) CreateBatch(ctx context.Context, events []domain.AnalyticsEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(events, 100).Error
+}
+
+func (r *analyticsEventRepository) List(ctx context.Context, filters repository.AnalyticsEventFilters) ([]domain.AnalyticsEvent, int64, error) {
+	var events []domain.AnalyticsEvent
+	var total int64
+
+	offset := (filters.Page - 1) * filters.Limit
+	query := r.applyFilters(r.db.WithContext(ctx).Model(&domain.AnalyticsEvent{}), filters)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("occurred_at DESC").
+		Offset(offset).Limit(filters.Limit).
+		Find(&events).Error
+
+	return events, total, err
+}
+
+func (r *analyticsEventRepository) CountByType(ctx context.Context, filters repository.AnalyticsEventFilters) ([]repository.AnalyticsEventCount, error) {
+	var counts []repository.AnalyticsEventCount
+
+	query := r.applyFilters(r.db.WithContext(ctx).Model(&domain.AnalyticsEvent{}), filters)
+	err := query.
+		Select("type, COUNT(*) as count, COUNT(DISTINCT user_id) as unique_users").
+		Group("type").
+		Order("count DESC").
+		Scan(&counts).Error
+
+	return counts, err
+}
+
+func (r *analyticsEventRepository) applyFilters(query *gorm.DB, filters repository.AnalyticsEventFilters) *gorm.DB {
+	if filters.Type != nil {
+		query = query.Where("type = ?", *filters.Type)
+	}
+	if filters.UserID != nil {
+		query = query.Where("user_id = ?", *filters.UserID)
+	}
+	if filters.From != nil {
+		query = query.Where("occurred_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("occurred_at <= ?", *filters.To)
+	}
+	return query
+}
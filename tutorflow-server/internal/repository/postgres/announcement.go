@@ -7,11 +7,20 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
 )
 
+// announcementOrder lists pinned announcements first, most recently pinned
+// on top, then the rest newest first
+const announcementOrder = "announcements.is_pinned DESC, announcements.pinned_at DESC NULLS LAST, announcements.published_at DESC"
+
+// announcementLive matches announcements learners can see: published and not
+// expired. Takes the current time twice.
+const announcementLive = "announcements.published_at <= ? AND (announcements.expires_at IS NULL OR announcements.expires_at > ?)"
+
 // AnnouncementRepository
 type announcementRepository struct {
 	db *gorm.DB
@@ -41,8 +50,12 @@ func (r *announcementRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 	return &announcement, nil
 }
 
+// Update saves an announcement's editable fields. Its schedule is left alone
+// so an edit can't undo a publish that happened in the meantime.
 func (r *announcementRepository) Update(ctx context.Context, announcement *domain.Announcement) error {
-	return r.db.WithContext(ctx).Save(announcement).Error
+	return r.db.WithContext(ctx).Model(announcement).
+		Select("title", "content", "is_pinned", "pinned_at", "send_email", "expires_at").
+		Updates(announcement).Error
 }
 
 func (r *announcementRepository) Delete(ctx context.Context, id uuid.UUID) error {
@@ -52,9 +65,11 @@ func (r *announcementRepository) Delete(ctx context.Context, id uuid.UUID) error
 func (r *announcementRepository) GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Announcement, int64, error) {
 	var announcements []domain.Announcement
 	var total int64
+	now := time.Now()
 
 	query := r.db.WithContext(ctx).Model(&domain.Announcement{}).
-		Where("course_id = ? AND published_at <= ?", courseID, time.Now())
+		Where("announcements.course_id = ?", courseID).
+		Where(announcementLive, now, now)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -63,7 +78,7 @@ func (r *announcementRepository) GetByCourse(ctx context.Context, courseID uuid.
 	offset := (page - 1) * limit
 	err := query.
 		Preload("Author").
-		Order("is_pinned DESC, published_at DESC").
+		Order(announcementOrder).
 		Offset(offset).
 		Limit(limit).
 		Find(&announcements).Error
@@ -74,9 +89,11 @@ func (r *announcementRepository) GetByCourse(ctx context.Context, courseID uuid.
 func (r *announcementRepository) GetGlobal(ctx context.Context, page, limit int) ([]domain.Announcement, int64, error) {
 	var announcements []domain.Announcement
 	var total int64
+	now := time.Now()
 
 	query := r.db.WithContext(ctx).Model(&domain.Announcement{}).
-		Where("course_id IS NULL AND published_at <= ?", time.Now())
+		Where("announcements.course_id IS NULL").
+		Where(announcementLive, now, now)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -85,7 +102,7 @@ func (r *announcementRepository) GetGlobal(ctx context.Context, page, limit int)
 	offset := (page - 1) * limit
 	err := query.
 		Preload("Author").
-		Order("is_pinned DESC, published_at DESC").
+		Order(announcementOrder).
 		Offset(offset).
 		Limit(limit).
 		Find(&announcements).Error
@@ -96,12 +113,13 @@ func (r *announcementRepository) GetGlobal(ctx context.Context, page, limit int)
 func (r *announcementRepository) GetForUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Announcement, int64, error) {
 	var announcements []domain.Announcement
 	var total int64
+	now := time.Now()
 
 	// Get announcements from enrolled courses + global announcements
 	query := r.db.WithContext(ctx).Model(&domain.Announcement{}).
 		Joins("LEFT JOIN enrollments ON enrollments.course_id = announcements.course_id").
 		Where("(enrollments.user_id = ? AND enrollments.status = ?) OR announcements.course_id IS NULL", userID, domain.EnrollmentStatusActive).
-		Where("announcements.published_at <= ?", time.Now())
+		Where(announcementLive, now, now)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -113,9 +131,9 @@ func (r *announcementRepository) GetForUser(ctx context.Context, userID uuid.UUI
 		Preload("Course").
 		Joins("LEFT JOIN enrollments ON enrollments.course_id = announcements.course_id").
 		Where("(enrollments.user_id = ? AND enrollments.status = ?) OR announcements.course_id IS NULL", userID, domain.EnrollmentStatusActive).
-		Where("announcements.published_at <= ?", time.Now()).
+		Where(announcementLive, now, now).
 		Group("announcements.id").
-		Order("announcements.is_pinned DESC, announcements.published_at DESC").
+		Order(announcementOrder).
 		Offset(offset).
 		Limit(limit).
 		Find(&announcements).Error
@@ -144,8 +162,60 @@ func (r *announcementRepository) GetByAuthor(ctx context.Context, authorID uuid.
 	return announcements, total, err
 }
 
+// Pin pins or unpins an announcement; pinning again moves it to the top
 func (r *announcementRepository) Pin(ctx context.Context, id uuid.UUID, pinned bool) error {
+	var pinnedAt *time.Time
+	if pinned {
+		now := time.Now()
+		pinnedAt = &now
+	}
 	return r.db.WithContext(ctx).Model(&domain.Announcement{}).
 		Where("id = ?", id).
-		Update("is_pinned", pinned).Error
+		Updates(map[string]interface{}{"is_pinned": pinned, "pinned_at": pinnedAt}).Error
+}
+
+func (r *announcementRepository) GetRecipients(ctx context.Context, courseID *uuid.UUID, after uuid.UUID, limit int) ([]domain.User, error) {
+	var users []domain.User
+	query := r.db.WithContext(ctx).Model(&domain.User{}).
+		Select("users.id", "users.email", "users.first_name", "users.last_name").
+		Where("users.id > ?", after)
+	if courseID != nil {
+		query = query.Joins("JOIN enrollments ON enrollments.user_id = users.id").
+			Where("enrollments.course_id = ? AND enrollments.status = ?", *courseID, domain.EnrollmentStatusActive).
+			Where("users.status <> ?", domain.StatusDeleted)
+	} else {
+		query = query.Where("users.status = ?", domain.StatusActive)
+	}
+	err := query.Order("users.id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// Reschedule moves a scheduled announcement to a new time, reporting false
+// if it has already been published
+func (r *announcementRepository) Reschedule(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.Announcement{}).
+		Where("id = ? AND scheduled_for IS NOT NULL", id).
+		Updates(map[string]interface{}{"scheduled_for": at, "published_at": at})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteScheduled deletes an announcement that has not been published yet,
+// reporting false if it already has
+func (r *announcementRepository) DeleteScheduled(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND scheduled_for IS NOT NULL", id).
+		Delete(&domain.Announcement{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// PublishDue publishes every scheduled announcement due by now and returns
+// them. Claiming them in one statement means each is published once even with
+// several API instances polling.
+func (r *announcementRepository) PublishDue(ctx context.Context, now time.Time) ([]domain.Announcement, error) {
+	var announcements []domain.Announcement
+	err := r.db.WithContext(ctx).Model(&announcements).
+		Clauses(clause.Returning{}).
+		Where("scheduled_for <= ?", now).
+		Updates(map[string]interface{}{"scheduled_for": nil, "published_at": now}).Error
+	return announcements, err
 }
@@ -0,0 +1,117 @@
+package postgres_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/testdb"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	repo "github.com/tutorflow/tutorflow-server/internal/repository/postgres"
+)
+
+// listQuery returns the paged SELECT a list method ran
+func listQuery(t *testing.T, log *testdb.Driver) string {
+	t.Helper()
+	for _, q := range log.Queries() {
+		if strings.Contains(q.SQL, `FROM "announcements"`) && strings.Contains(q.SQL, "LIMIT") {
+			return q.SQL
+		}
+	}
+	t.Fatalf("no paged announcement query in %v", log.Queries())
+	return ""
+}
+
+func TestAnnouncementRepository_LearnerListsOrderPinnedFirst(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	r := repo.NewAnnouncementRepository(db)
+	author := newUser(t, db)
+	course := newCourse(t, db, author, 0)
+	learner := newUser(t, db)
+	require.NoError(t, db.Create(&domain.Enrollment{UserID: learner.ID, CourseID: course.ID, Status: domain.EnrollmentStatusActive}).Error)
+
+	now := time.Now()
+	at := func(d time.Duration) *time.Time { v := now.Add(d); return &v }
+	announce := func(title string, published time.Duration, pinnedAt *time.Time, expires *time.Time) {
+		require.NoError(t, r.Create(ctx, &domain.Announcement{
+			CourseID:    &course.ID,
+			AuthorID:    author.ID,
+			Title:       title,
+			Content:     title,
+			IsPinned:    pinnedAt != nil,
+			PinnedAt:    pinnedAt,
+			PublishedAt: now.Add(published),
+			ExpiresAt:   expires,
+		}))
+	}
+	announce("old pin", -5*time.Hour, at(-4*time.Hour), nil)
+	announce("newest", -time.Hour, nil, nil)
+	announce("new pin", -6*time.Hour, at(-2*time.Hour), nil)
+	announce("oldest", -7*time.Hour, nil, nil)
+	announce("middle", -3*time.Hour, nil, nil)
+	announce("expired pin", -3*time.Hour, at(-time.Hour), at(-time.Minute))
+	announce("scheduled", time.Hour, nil, nil)
+
+	// Pinned announcements come first, most recently pinned first, then the
+	// rest newest first
+	want := []string{"new pin", "old pin", "newest", "middle", "oldest"}
+	titles := func(list []domain.Announcement) []string {
+		out := make([]string, len(list))
+		for i, a := range list {
+			out[i] = a.Title
+		}
+		return out
+	}
+
+	byCourse, total, err := r.GetByCourse(ctx, course.ID, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, want, titles(byCourse))
+	assert.Equal(t, int64(len(want)), total)
+
+	feed, _, err := r.GetForUser(ctx, learner.ID, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, want, titles(feed))
+}
+
+func TestAnnouncementRepository_LearnerListsHideExpired(t *testing.T) {
+	ctx := context.Background()
+	lists := map[string]func(repository.AnnouncementRepository) error{
+		"course": func(r repository.AnnouncementRepository) error {
+			_, _, err := r.GetByCourse(ctx, uuid.New(), 1, 20)
+			return err
+		},
+		"global": func(r repository.AnnouncementRepository) error {
+			_, _, err := r.GetGlobal(ctx, 1, 20)
+			return err
+		},
+		"feed": func(r repository.AnnouncementRepository) error {
+			_, _, err := r.GetForUser(ctx, uuid.New(), 1, 20)
+			return err
+		},
+	}
+
+	for name, list := range lists {
+		t.Run(name, func(t *testing.T) {
+			db, log := testdb.Fake(t, nil)
+			require.NoError(t, list(repo.NewAnnouncementRepository(db)))
+			assert.Contains(t, listQuery(t, log), "announcements.expires_at IS NULL OR announcements.expires_at >")
+		})
+	}
+}
+
+func TestAnnouncementRepository_AuthorSeesExpired(t *testing.T) {
+	db, log := testdb.Fake(t, nil)
+	_, _, err := repo.NewAnnouncementRepository(db).GetByAuthor(context.Background(), uuid.New(), 1, 20)
+	require.NoError(t, err)
+
+	query := listQuery(t, log)
+	assert.NotContains(t, query, "expires_at")
+	assert.NotContains(t, query, "published_at <=")
+}
@@ -131,6 +131,9 @@ func (r *quizRepository) GetQuestion(ctx context.Context, id uuid.UUID) (*domain
 		Where("id = ?", id).
 		First(&question).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrQuestionNotFound
+		}
 		return nil, err
 	}
 	return &question, nil
@@ -156,6 +159,9 @@ func (r *quizAttemptRepository) GetByID(ctx context.Context, id uuid.UUID) (*dom
 		Where("id = ?", id).
 		First(&attempt).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAttemptNotFound
+		}
 		return nil, err
 	}
 	return &attempt, nil
@@ -197,6 +203,51 @@ func (r *quizAttemptRepository) GetLatestByUserAndQuiz(ctx context.Context, user
 	return &attempt, nil
 }
 
+func (r *quizAttemptRepository) CreateAnswers(ctx context.Context, answers []domain.QuizAnswer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&answers).Error
+}
+
+func (r *quizAttemptRepository) GetAttemptSummary(ctx context.Context, quizID uuid.UUID) (*repository.QuizAttemptSummary, error) {
+	var summary repository.QuizAttemptSummary
+	err := r.db.WithContext(ctx).Model(&domain.QuizAttempt{}).
+		Select(`COUNT(*) AS attempts,
+			COUNT(*) FILTER (WHERE passed) AS passed,
+			COALESCE(AVG(percentage), 0) AS average_percentage`).
+		Where("quiz_id = ? AND completed_at IS NOT NULL", quizID).
+		Scan(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (r *quizAttemptRepository) GetQuestionAnswerCounts(ctx context.Context, quizID uuid.UUID) ([]repository.QuestionAnswerCount, error) {
+	var counts []repository.QuestionAnswerCount
+	err := r.db.WithContext(ctx).Model(&domain.QuizAnswer{}).
+		Select(`question_id,
+			COUNT(DISTINCT attempt_id) AS answered,
+			COUNT(DISTINCT attempt_id) FILTER (WHERE is_correct) AS correct`).
+		Where("quiz_id = ?", quizID).
+		Group("question_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *quizAttemptRepository) GetOptionSelectionCounts(ctx context.Context, quizID uuid.UUID) ([]repository.OptionSelectionCount, error) {
+	var counts []repository.OptionSelectionCount
+	err := r.db.WithContext(ctx).Model(&domain.QuizAnswer{}).
+		Select(`question_id, option_id,
+			COUNT(*) AS selected,
+			COUNT(*) FILTER (WHERE NOT is_correct) AS wrong`).
+		Where("quiz_id = ? AND option_id IS NOT NULL", quizID).
+		Group("question_id, option_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
 // AssignmentRepository
 type assignmentRepository struct {
 	db *gorm.DB
@@ -268,6 +319,9 @@ func (r *submissionRepository) GetByID(ctx context.Context, id uuid.UUID) (*doma
 		Where("id = ?", id).
 		First(&submission).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSubmissionNotFound
+		}
 		return nil, err
 	}
 	return &submission, nil
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+)
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *gorm.DB) repository.AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Record(ctx context.Context, entry *domain.AuditLog, changes ...interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			if err := tx.Save(change).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *auditLogRepository) List(ctx context.Context, filters repository.AuditLogFilters) ([]domain.AuditLog, int64, error) {
+	var entries []domain.AuditLog
+	var total int64
+
+	offset := (filters.Page - 1) * filters.Limit
+	query := r.db.WithContext(ctx).Model(&domain.AuditLog{})
+	if filters.ActorID != nil {
+		query = query.Where("actor_id = ?", *filters.ActorID)
+	}
+	if filters.Action != nil {
+		query = query.Where("action = ?", *filters.Action)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at <= ?", *filters.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Preload("Actor").
+		Order("created_at DESC").
+		Offset(offset).Limit(filters.Limit).
+		Find(&entries).Error
+
+	return entries, total, err
+}
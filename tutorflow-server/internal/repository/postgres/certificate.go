@@ -56,6 +56,19 @@ func (r *certificateRepository) GetByNumber(ctx context.Context, number string)
 	return &cert, nil
 }
 
+func (r *certificateRepository) GetByNumbers(ctx context.Context, numbers []string) ([]domain.Certificate, error) {
+	var certs []domain.Certificate
+	if len(numbers) == 0 {
+		return certs, nil
+	}
+	err := r.db.WithContext(ctx).
+		Preload("Enrollment.User").
+		Preload("Enrollment.Course").
+		Where("certificate_number IN ?", numbers).
+		Find(&certs).Error
+	return certs, err
+}
+
 func (r *certificateRepository) GetByEnrollment(ctx context.Context, enrollmentID uuid.UUID) (*domain.Certificate, error) {
 	var cert domain.Certificate
 	err := r.db.WithContext(ctx).
@@ -7,8 +7,11 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/metrics"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
 )
 
@@ -101,12 +104,89 @@ func (r *categoryRepository) GetWithSubcategories(ctx context.Context) ([]domain
 	var categories []domain.Category
 	err := r.db.WithContext(ctx).
 		Where("parent_id IS NULL").
-		Preload("Subcategories").
+		Preload("Subcategories", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order ASC, name ASC")
+		}).
 		Order("sort_order ASC, name ASC").
 		Find(&categories).Error
 	return categories, err
 }
 
+func (r *categoryRepository) CountSubcategories(ctx context.Context, id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *categoryRepository) CountCourses(ctx context.Context, id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.CourseCategory{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// GetByParent returns the categories directly under parentID, or the
+// top-level categories when it is nil
+func (r *categoryRepository) GetByParent(ctx context.Context, parentID *uuid.UUID) ([]domain.Category, error) {
+	var categories []domain.Category
+	query := r.db.WithContext(ctx)
+	if parentID != nil {
+		query = query.Where("parent_id = ?", *parentID)
+	} else {
+		query = query.Where("parent_id IS NULL")
+	}
+	err := query.Order("sort_order ASC, name ASC").Find(&categories).Error
+	return categories, err
+}
+
+func (r *categoryRepository) Reorder(ctx context.Context, parentID *uuid.UUID, categoryIDs []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range categoryIDs {
+			query := tx.Model(&domain.Category{}).Where("id = ?", id)
+			if parentID != nil {
+				query = query.Where("parent_id = ?", *parentID)
+			} else {
+				query = query.Where("parent_id IS NULL")
+			}
+			if err := query.Update("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ForceDelete deletes a category that is still in use. Its subcategories move
+// up to its parent, and its courses move to reassignTo when set.
+func (r *categoryRepository) ForceDelete(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var category domain.Category
+		if err := tx.Where("id = ?", id).First(&category).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.Category{}).
+			Where("parent_id = ?", id).
+			Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+
+		if reassignTo != nil {
+			// Courses already in the target category keep their single link
+			if err := tx.Exec(`
+				INSERT INTO course_categories (course_id, category_id)
+				SELECT course_id, ? FROM course_categories WHERE category_id = ?
+				ON CONFLICT DO NOTHING`, *reassignTo, id).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("category_id = ?", id).Delete(&domain.CourseCategory{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Category{}, "id = ?", id).Error
+	})
+}
+
 // ModuleRepository
 type moduleRepository struct {
 	db *gorm.DB
@@ -166,6 +246,111 @@ func (r *moduleRepository) Reorder(ctx context.Context, courseID uuid.UUID, modu
 	})
 }
 
+// SaveCurriculum makes a course's modules and lessons match the given ones
+// in one transaction. Modules and lessons whose ID already belongs to the
+// course are updated in place, so lesson progress keeps pointing at them;
+// others are created, and anything the course had that isn't listed is
+// deleted. Published flags, access settings and video links of a kept lesson
+// are left alone because the curriculum editor doesn't send them.
+func (r *moduleRepository) SaveCurriculum(ctx context.Context, courseID uuid.UUID, modules []domain.Module) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var moduleIDs, lessonIDs []uuid.UUID
+		if err := tx.Model(&domain.Module{}).Where("course_id = ?", courseID).Pluck("id", &moduleIDs).Error; err != nil {
+			return err
+		}
+		if len(moduleIDs) > 0 {
+			if err := tx.Model(&domain.Lesson{}).Where("module_id IN ?", moduleIDs).Pluck("id", &lessonIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		existingModules := make(map[uuid.UUID]bool, len(moduleIDs))
+		for _, id := range moduleIDs {
+			existingModules[id] = true
+		}
+		existingLessons := make(map[uuid.UUID]bool, len(lessonIDs))
+		for _, id := range lessonIDs {
+			existingLessons[id] = true
+		}
+		keepModules := make(map[uuid.UUID]bool)
+		keepLessons := make(map[uuid.UUID]bool)
+
+		for i := range modules {
+			m := &modules[i]
+			m.CourseID = courseID
+			if existingModules[m.ID] && !keepModules[m.ID] {
+				if err := tx.Model(&domain.Module{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+					"title":       m.Title,
+					"description": m.Description,
+					"sort_order":  m.SortOrder,
+				}).Error; err != nil {
+					return err
+				}
+			} else {
+				// IDs from elsewhere, or repeated, must not take over other rows
+				m.ID = uuid.Nil
+				if err := tx.Omit(clause.Associations).Create(m).Error; err != nil {
+					return err
+				}
+			}
+			keepModules[m.ID] = true
+
+			for j := range m.Lessons {
+				l := &m.Lessons[j]
+				l.ModuleID = m.ID
+				if existingLessons[l.ID] && !keepLessons[l.ID] {
+					updates := map[string]interface{}{
+						"module_id":   l.ModuleID,
+						"title":       l.Title,
+						"description": l.Description,
+						"content":     l.Content,
+						"lesson_type": l.LessonType,
+						"sort_order":  l.SortOrder,
+					}
+					if l.VideoURL != nil {
+						updates["video_url"] = l.VideoURL
+					}
+					if l.VideoDuration != nil && *l.VideoDuration > 0 {
+						updates["video_duration"] = l.VideoDuration
+					}
+					if err := tx.Model(&domain.Lesson{}).Where("id = ?", l.ID).Updates(updates).Error; err != nil {
+						return err
+					}
+				} else {
+					l.ID = uuid.Nil
+					if err := tx.Omit(clause.Associations).Create(l).Error; err != nil {
+						return err
+					}
+				}
+				keepLessons[l.ID] = true
+			}
+		}
+
+		var removedLessons, removedModules []uuid.UUID
+		for _, id := range lessonIDs {
+			if !keepLessons[id] {
+				removedLessons = append(removedLessons, id)
+			}
+		}
+		for _, id := range moduleIDs {
+			if !keepModules[id] {
+				removedModules = append(removedModules, id)
+			}
+		}
+		if len(removedLessons) > 0 {
+			if err := tx.Where("id IN ?", removedLessons).Delete(&domain.Lesson{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(removedModules) > 0 {
+			if err := tx.Where("id IN ?", removedModules).Delete(&domain.Module{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // LessonRepository
 type lessonRepository struct {
 	db *gorm.DB
@@ -179,6 +364,19 @@ func (r *lessonRepository) Create(ctx context.Context, lesson *domain.Lesson) er
 	return r.db.WithContext(ctx).Create(lesson).Error
 }
 
+// CreateBatch inserts lessons in order in one transaction; none are saved if
+// any insert fails
+func (r *lessonRepository) CreateBatch(ctx context.Context, lessons []domain.Lesson) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range lessons {
+			if err := tx.Create(&lessons[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (r *lessonRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Lesson, error) {
 	var lesson domain.Lesson
 	err := r.db.WithContext(ctx).Preload("Module").Where("id = ?", id).First(&lesson).Error
@@ -235,7 +433,12 @@ func NewEnrollmentRepository(db *gorm.DB) repository.EnrollmentRepository {
 }
 
 func (r *enrollmentRepository) Create(ctx context.Context, enrollment *domain.Enrollment) error {
-	return r.db.WithContext(ctx).Create(enrollment).Error
+	// Counted here as every way of enrolling ends up here
+	if err := r.db.WithContext(ctx).Create(enrollment).Error; err != nil {
+		return err
+	}
+	metrics.EnrollmentsCreated.Inc()
+	return nil
 }
 
 func (r *enrollmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Enrollment, error) {
@@ -285,6 +488,12 @@ func (r *enrollmentRepository) List(ctx context.Context, filters repository.Enro
 		query = query.Where("status = ?", *filters.Status)
 	}
 
+	if filters.Cursor != nil {
+		err := pagination.After(query.Preload("Course").Preload("User"), "enrolled_at", "id", *filters.Cursor, filters.Limit).
+			Find(&enrollments).Error
+		return enrollments, 0, err
+	}
+
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
@@ -354,6 +563,102 @@ func (r *enrollmentRepository) UpdateProgress(ctx context.Context, id uuid.UUID,
 		}).Error
 }
 
+// HasInstructor reports whether the user is enrolled in, or has completed, a
+// course the instructor owns or co-teaches
+func (r *enrollmentRepository) HasInstructor(ctx context.Context, userID, instructorID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Enrollment{}).
+		Joins("JOIN courses ON courses.id = enrollments.course_id").
+		Where("enrollments.user_id = ? AND enrollments.status IN ?", userID, []domain.EnrollmentStatus{domain.EnrollmentStatusActive, domain.EnrollmentStatusCompleted}).
+		Where("courses.instructor_id = ? OR EXISTS (SELECT 1 FROM course_instructors ci WHERE ci.course_id = courses.id AND ci.instructor_id = ?)", instructorID, instructorID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetCourseFunnel counts a course's learners, excluding cancelled
+// enrollments, at each progress milestone and through to completion
+func (r *enrollmentRepository) GetCourseFunnel(ctx context.Context, courseID uuid.UUID) (*domain.CourseFunnel, error) {
+	funnel := &domain.CourseFunnel{CourseID: courseID}
+	enrollments := func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&domain.Enrollment{}).
+			Where("course_id = ? AND status <> ?", courseID, domain.EnrollmentStatusCancelled)
+	}
+
+	if err := enrollments().Count(&funnel.Enrolled).Error; err != nil {
+		return nil, err
+	}
+
+	for _, milestone := range domain.FunnelMilestones {
+		step := domain.FunnelStep{Progress: milestone}
+		if err := enrollments().Where("progress_percent >= ?", milestone).Count(&step.Learners).Error; err != nil {
+			return nil, err
+		}
+		funnel.Steps = append(funnel.Steps, step)
+	}
+
+	if err := enrollments().Where("completed_at IS NOT NULL").Count(&funnel.Completed).Error; err != nil {
+		return nil, err
+	}
+
+	if err := enrollments().Where("completed_at IS NOT NULL").
+		Select("COALESCE(AVG(EXTRACT(EPOCH FROM (completed_at - enrolled_at)) / 86400), 0)").
+		Scan(&funnel.AvgDaysToCompletion).Error; err != nil {
+		return nil, err
+	}
+
+	return funnel, nil
+}
+
+// GetAtRisk returns the course's active learners below maxProgress whose
+// last lesson activity, or enrollment if they never started, is before
+// inactiveSince. The longest inactive come first.
+func (r *enrollmentRepository) GetAtRisk(ctx context.Context, courseID uuid.UUID, inactiveSince time.Time, maxProgress float64) ([]domain.AtRiskStudent, error) {
+	var students []domain.AtRiskStudent
+	err := r.db.WithContext(ctx).Model(&domain.Enrollment{}).
+		Select(`
+			enrollments.id as enrollment_id,
+			enrollments.user_id,
+			users.first_name,
+			users.last_name,
+			users.email,
+			enrollments.progress_percent as progress,
+			enrollments.enrolled_at,
+			activity.last_accessed_at,
+			enrollments.last_nudged_at
+		`).
+		Joins("JOIN users ON users.id = enrollments.user_id").
+		Joins(`LEFT JOIN (
+			SELECT enrollment_id, MAX(updated_at) as last_accessed_at FROM lesson_progresses GROUP BY enrollment_id
+		) activity ON activity.enrollment_id = enrollments.id`).
+		Where("enrollments.course_id = ? AND enrollments.status = ?", courseID, domain.EnrollmentStatusActive).
+		Where("enrollments.progress_percent < ?", maxProgress).
+		Where("COALESCE(activity.last_accessed_at, enrollments.enrolled_at) < ?", inactiveSince).
+		Order("COALESCE(activity.last_accessed_at, enrollments.enrolled_at) ASC").
+		Scan(&students).Error
+	return students, err
+}
+
+// MarkNudged claims the enrollments in one statement, so a learner is nudged
+// once even when the instructor sends the nudge twice at the same time
+func (r *enrollmentRepository) MarkNudged(ctx context.Context, ids []uuid.UUID, now, cooldownSince time.Time) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var enrollments []domain.Enrollment
+	err := r.db.WithContext(ctx).Model(&enrollments).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("id IN ? AND (last_nudged_at IS NULL OR last_nudged_at < ?)", ids, cooldownSince).
+		Update("last_nudged_at", now).Error
+	if err != nil {
+		return nil, err
+	}
+	claimed := make([]uuid.UUID, len(enrollments))
+	for i, e := range enrollments {
+		claimed[i] = e.ID
+	}
+	return claimed, nil
+}
+
 func (r *enrollmentRepository) GetStats(ctx context.Context, userID uuid.UUID) (*domain.StudentDashboardStats, error) {
 	var stats domain.StudentDashboardStats
 
@@ -459,3 +764,21 @@ func (r *lessonProgressRepository) UpdateVideoPosition(ctx context.Context, enro
 		Assign(map[string]interface{}{"video_position": position}).
 		FirstOrCreate(progress).Error
 }
+
+// CountCompletion counts the published lessons in a course and how many of
+// them the enrollment has completed. Unpublished lessons are ignored.
+func (r *lessonProgressRepository) CountCompletion(ctx context.Context, enrollmentID, courseID uuid.UUID) (int64, int64, error) {
+	var counts struct {
+		Completed int64
+		Total     int64
+	}
+	err := r.db.WithContext(ctx).
+		Table("lessons").
+		Select(`COUNT(*) AS total,
+			COUNT(lesson_progresses.id) FILTER (WHERE lesson_progresses.is_completed) AS completed`).
+		Joins("JOIN modules ON modules.id = lessons.module_id").
+		Joins("LEFT JOIN lesson_progresses ON lesson_progresses.lesson_id = lessons.id AND lesson_progresses.enrollment_id = ?", enrollmentID).
+		Where("modules.course_id = ? AND lessons.is_published = ?", courseID, true).
+		Scan(&counts).Error
+	return counts.Completed, counts.Total, err
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gosimple/slug"
 	"gorm.io/gorm"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
 )
 
@@ -36,7 +38,8 @@ func (r *courseRepository) generateUniqueSlug(ctx context.Context, title string)
 
 	for {
 		var count int64
-		r.db.WithContext(ctx).Model(&domain.Course{}).Where("slug = ?", finalSlug).Count(&count)
+		// Trashed courses keep their slug so they can be restored
+		r.db.WithContext(ctx).Unscoped().Model(&domain.Course{}).Where("slug = ?", finalSlug).Count(&count)
 		if count == 0 {
 			break
 		}
@@ -93,6 +96,21 @@ func (r *courseRepository) GetBySlug(ctx context.Context, slug string) (*domain.
 	return &course, nil
 }
 
+func (r *courseRepository) GetByEnrollmentCode(ctx context.Context, code string) (*domain.Course, error) {
+	var course domain.Course
+	err := r.db.WithContext(ctx).
+		Where("enrollment_code = ?", code).
+		First(&course).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCourseNotFound
+		}
+		return nil, err
+	}
+	return &course, nil
+}
+
 func (r *courseRepository) Update(ctx context.Context, course *domain.Course) error {
 	return r.db.WithContext(ctx).Save(course).Error
 }
@@ -137,6 +155,15 @@ func (r *courseRepository) List(ctx context.Context, filters repository.CourseFi
 	if filters.MinRating != nil {
 		query = query.Where("rating >= ?", *filters.MinRating)
 	}
+	if filters.ActiveInstructorsOnly {
+		query = activeInstructorsOnly(query)
+	}
+
+	if filters.Cursor != nil {
+		err := pagination.After(query.Preload("Instructor").Preload("Categories"), "courses.created_at", "courses.id", *filters.Cursor, filters.Limit).
+			Find(&courses).Error
+		return courses, 0, err
+	}
 
 	// Count total
 	if err := query.Count(&total).Error; err != nil {
@@ -234,3 +261,160 @@ func (r *courseRepository) IncrementStudentCount(ctx context.Context, id uuid.UU
 		Where("id = ?", id).
 		UpdateColumn("total_students", gorm.Expr("total_students + 1")).Error
 }
+
+func (r *courseRepository) SetEnrollmentCode(ctx context.Context, id uuid.UUID, code *string) error {
+	return r.db.WithContext(ctx).Model(&domain.Course{}).
+		Where("id = ?", id).
+		UpdateColumn("enrollment_code", code).Error
+}
+
+func (r *courseRepository) GetInstructorSplits(ctx context.Context, courseID uuid.UUID) ([]domain.CourseInstructor, error) {
+	var splits []domain.CourseInstructor
+	err := r.db.WithContext(ctx).
+		Preload("Instructor").
+		Where("course_id = ?", courseID).
+		Order("share_percent DESC, created_at ASC").
+		Find(&splits).Error
+	return splits, err
+}
+
+func (r *courseRepository) SetInstructorSplits(ctx context.Context, courseID uuid.UUID, splits []domain.CourseInstructor) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("course_id = ?", courseID).Delete(&domain.CourseInstructor{}).Error; err != nil {
+			return err
+		}
+		if len(splits) == 0 {
+			return nil
+		}
+		for i := range splits {
+			splits[i].CourseID = courseID
+		}
+		return tx.Omit("Instructor").Create(&splits).Error
+	})
+}
+
+// SetCategories replaces the categories a course is filed under
+func (r *courseRepository) SetCategories(ctx context.Context, courseID uuid.UUID, categoryIDs []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("course_id = ?", courseID).Delete(&domain.CourseCategory{}).Error; err != nil {
+			return err
+		}
+		if len(categoryIDs) == 0 {
+			return nil
+		}
+		links := make([]domain.CourseCategory, len(categoryIDs))
+		for i, id := range categoryIDs {
+			links[i] = domain.CourseCategory{CourseID: courseID, CategoryID: id}
+		}
+		return tx.Create(&links).Error
+	})
+}
+
+// ListDeleted returns soft-deleted courses, most recently deleted first.
+// instructorID limits the list to one instructor's courses.
+func (r *courseRepository) ListDeleted(ctx context.Context, instructorID *uuid.UUID, page, limit int) ([]domain.Course, int64, error) {
+	var courses []domain.Course
+	var total int64
+
+	query := r.db.WithContext(ctx).Unscoped().Model(&domain.Course{}).Where("deleted_at IS NOT NULL")
+	if instructorID != nil {
+		query = query.Where("instructor_id = ?", *instructorID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Order("deleted_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&courses).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return courses, total, nil
+}
+
+func (r *courseRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*domain.Course, error) {
+	var course domain.Course
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&course).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCourseNotFound
+		}
+		return nil, err
+	}
+	return &course, nil
+}
+
+func (r *courseRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&domain.Course{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrCourseNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted permanently removes courses trashed before deletedBefore,
+// along with their curriculum and catalogue links. Courses anyone enrolled
+// in or ordered stay soft-deleted so learner and billing history survive.
+func (r *courseRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Unscoped().Model(&domain.Course{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+		Where("NOT EXISTS (SELECT 1 FROM enrollments WHERE enrollments.course_id = courses.id)").
+		Where("NOT EXISTS (SELECT 1 FROM order_items WHERE order_items.course_id = courses.id)").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, id := range ids {
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			tx = tx.Unscoped()
+			modules := tx.Model(&domain.Module{}).Select("id").Where("course_id = ?", id)
+			if err := tx.Where("module_id IN (?)", modules).Delete(&domain.Lesson{}).Error; err != nil {
+				return err
+			}
+			for _, model := range []interface{}{
+				&domain.Module{},
+				&domain.CourseCategory{},
+				&domain.CourseInstructor{},
+				&domain.CartItem{},
+				&domain.Wishlist{},
+				&domain.RecentlyViewed{},
+				&domain.BundleCourse{},
+				&domain.LearningPathCourse{},
+			} {
+				if err := tx.Where("course_id = ?", id).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Delete(&domain.Course{}, "id = ?", id).Error
+		})
+		// Anything else still pointing at the course blocks the purge; it
+		// stays in the trash and is retried on the next run
+		if err == nil {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// activeInstructorsOnly hides courses whose instructor is suspended. The
+// courses are kept and show again once the instructor is reactivated.
+func activeInstructorsOnly(query *gorm.DB) *gorm.DB {
+	return query.Where("courses.instructor_id NOT IN (SELECT id FROM users WHERE status = ?)", domain.StatusSuspended)
+}
@@ -0,0 +1,72 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/testdb"
+	repo "github.com/tutorflow/tutorflow-server/internal/repository/postgres"
+)
+
+func TestModuleRepository_SaveCurriculum(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	r := repo.NewModuleRepository(db)
+
+	instructor := newUser(t, db)
+	course := newCourse(t, db, instructor, 0)
+	other := newCourse(t, db, instructor, 0)
+
+	newModule := func(course *domain.Course, title string, lessons ...string) *domain.Module {
+		m := &domain.Module{CourseID: course.ID, Title: title}
+		for i, l := range lessons {
+			m.Lessons = append(m.Lessons, domain.Lesson{Title: l, LessonType: domain.LessonTypeText, SortOrder: i})
+		}
+		require.NoError(t, db.Create(m).Error)
+		return m
+	}
+	basics := newModule(course, "Basics", "Installing Go", "Hello world")
+	extras := newModule(course, "Extras", "Tooling")
+	foreign := newModule(other, "Elsewhere", "Not yours")
+
+	kept := basics.Lessons[0]
+	err := r.SaveCurriculum(ctx, course.ID, []domain.Module{{
+		ID:    basics.ID,
+		Title: "Getting started",
+		Lessons: []domain.Lesson{
+			{ID: kept.ID, Title: "Installing Go (updated)", LessonType: domain.LessonTypeText, SortOrder: 0},
+			{ID: uuid.New(), Title: "Your first program", LessonType: domain.LessonTypeText, SortOrder: 1},
+			{ID: foreign.Lessons[0].ID, Title: "Taken over", LessonType: domain.LessonTypeText, SortOrder: 2},
+		},
+	}})
+	require.NoError(t, err)
+
+	var modules []domain.Module
+	require.NoError(t, db.Preload("Lessons", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order") }).
+		Where("course_id = ?", course.ID).Find(&modules).Error)
+	require.Len(t, modules, 1, "modules left out are deleted")
+	assert.Equal(t, basics.ID, modules[0].ID, "listed modules are updated in place")
+	assert.Equal(t, "Getting started", modules[0].Title)
+
+	lessons := modules[0].Lessons
+	require.Len(t, lessons, 3)
+	assert.Equal(t, kept.ID, lessons[0].ID, "edited lessons keep their ID, so progress still applies")
+	assert.Equal(t, "Installing Go (updated)", lessons[0].Title)
+	assert.NotEqual(t, foreign.Lessons[0].ID, lessons[2].ID, "another course's lesson ID is inserted as a new lesson")
+	assert.Equal(t, "Taken over", lessons[2].Title)
+
+	var count int64
+	require.NoError(t, db.Model(&domain.Lesson{}).Where("id IN ?", []uuid.UUID{basics.Lessons[1].ID, extras.Lessons[0].ID}).Count(&count).Error)
+	assert.Zero(t, count, "lessons left out are deleted")
+
+	var untouched domain.Lesson
+	require.NoError(t, db.First(&untouched, "id = ?", foreign.Lessons[0].ID).Error)
+	assert.Equal(t, "Not yours", untouched.Title)
+	assert.Equal(t, foreign.ID, untouched.ModuleID)
+}
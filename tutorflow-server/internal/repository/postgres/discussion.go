@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
@@ -47,11 +48,23 @@ func (r *discussionRepository) Update(ctx context.Context, discussion *domain.Di
 }
 
 func (r *discussionRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	// Delete replies first
-	if err := r.db.WithContext(ctx).Where("parent_id = ?", id).Delete(&domain.Discussion{}).Error; err != nil {
-		return err
-	}
-	return r.db.WithContext(ctx).Delete(&domain.Discussion{}, "id = ?", id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// A deleted answer stops being the accepted one
+		if err := tx.Model(&domain.Discussion{}).
+			Where("accepted_reply_id = ?", id).
+			Update("accepted_reply_id", nil).Error; err != nil {
+			return err
+		}
+
+		// Replies can nest, so take the whole subtree with the post
+		return tx.Exec(`
+			WITH RECURSIVE thread AS (
+				SELECT id FROM discussions WHERE id = ?
+				UNION ALL
+				SELECT d.id FROM discussions d JOIN thread t ON d.parent_id = t.id
+			)
+			DELETE FROM discussions WHERE id IN (SELECT id FROM thread)`, id).Error
+	})
 }
 
 func (r *discussionRepository) GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error) {
@@ -114,10 +127,17 @@ func (r *discussionRepository) GetReplies(ctx context.Context, parentID uuid.UUI
 		return nil, 0, err
 	}
 
+	// The accepted answer leads the first page
+	acceptedFirst := clause.OrderBy{Expression: clause.Expr{
+		SQL:                "id = (SELECT accepted_reply_id FROM discussions WHERE id = ?) DESC NULLS LAST, created_at ASC",
+		Vars:               []interface{}{parentID},
+		WithoutParentheses: true,
+	}}
+
 	offset := (page - 1) * limit
 	err := query.
 		Preload("User").
-		Order("created_at ASC").
+		Order(acceptedFirst).
 		Offset(offset).
 		Limit(limit).
 		Find(&replies).Error
@@ -125,6 +145,57 @@ func (r *discussionRepository) GetReplies(ctx context.Context, parentID uuid.UUI
 	return replies, total, err
 }
 
+func (r *discussionRepository) GetThread(ctx context.Context, rootID uuid.UUID, levels int) ([]domain.Discussion, error) {
+	var thread []domain.Discussion
+	parentIDs := []uuid.UUID{rootID}
+
+	// One query per level keeps the User preload and stops at the depth limit
+	for level := 0; level < levels && len(parentIDs) > 0; level++ {
+		var replies []domain.Discussion
+		err := r.db.WithContext(ctx).
+			Preload("User").
+			Where("parent_id IN ?", parentIDs).
+			Order("created_at ASC").
+			Find(&replies).Error
+		if err != nil {
+			return nil, err
+		}
+
+		parentIDs = parentIDs[:0]
+		for _, reply := range replies {
+			parentIDs = append(parentIDs, reply.ID)
+		}
+		thread = append(thread, replies...)
+	}
+
+	return thread, nil
+}
+
+func (r *discussionRepository) CountReplies(ctx context.Context, parentIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentID uuid.UUID
+		Count    int64
+	}
+	err := r.db.WithContext(ctx).Model(&domain.Discussion{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ?", parentIDs).
+		Group("parent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ParentID] = row.Count
+	}
+	return counts, nil
+}
+
 func (r *discussionRepository) Upvote(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Model(&domain.Discussion{}).
 		Where("id = ?", id).
@@ -149,6 +220,12 @@ func (r *discussionRepository) Pin(ctx context.Context, id uuid.UUID, pinned boo
 		Update("is_pinned", pinned).Error
 }
 
+func (r *discussionRepository) SetAcceptedReply(ctx context.Context, id uuid.UUID, replyID *uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&domain.Discussion{}).
+		Where("id = ?", id).
+		Update("accepted_reply_id", replyID).Error
+}
+
 func (r *discussionRepository) CountByCourse(ctx context.Context, courseID uuid.UUID) (int64, error) {
 	var count int64
 	err := r.db.WithContext(ctx).Model(&domain.Discussion{}).
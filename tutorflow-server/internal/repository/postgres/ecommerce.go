@@ -190,6 +190,16 @@ func (r *wishlistRepository) Exists(ctx context.Context, userID, courseID uuid.U
 	return count > 0, err
 }
 
+func (r *wishlistRepository) CountByCourse(ctx context.Context, courseID uuid.UUID, since *time.Time) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&domain.Wishlist{}).Where("course_id = ?", courseID)
+	if since != nil {
+		query = query.Where("added_at >= ?", *since)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
 // CouponRepository
 type couponRepository struct {
 	db *gorm.DB
@@ -301,6 +311,22 @@ func (r *orderRepository) Update(ctx context.Context, order *domain.Order) error
 	return r.db.WithContext(ctx).Save(order).Error
 }
 
+func (r *orderRepository) Complete(ctx context.Context, order *domain.Order) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&domain.Order{}).
+		Where("id = ? AND status IN ?", order.ID, []domain.OrderStatus{domain.OrderStatusPending, domain.OrderStatusFailed}).
+		Updates(map[string]interface{}{"status": domain.OrderStatusCompleted, "paid_at": now})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	order.Status = domain.OrderStatusCompleted
+	order.PaidAt = &now
+	return true, nil
+}
+
 func (r *orderRepository) GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Order, int64, error) {
 	var orders []domain.Order
 	var total int64
@@ -0,0 +1,45 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/testdb"
+	repo "github.com/tutorflow/tutorflow-server/internal/repository/postgres"
+)
+
+func TestEnrollmentRepository_MarkNudged_HonoursCooldown(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	r := repo.NewEnrollmentRepository(db)
+
+	course := newCourse(t, db, newUser(t, db), 0)
+	lastWeek := time.Now().Add(-8 * 24 * time.Hour)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	enroll := func(nudgedAt *time.Time) uuid.UUID {
+		e := &domain.Enrollment{
+			UserID:       newUser(t, db).ID,
+			CourseID:     course.ID,
+			Status:       domain.EnrollmentStatusActive,
+			LastNudgedAt: nudgedAt,
+		}
+		require.NoError(t, db.Create(e).Error)
+		return e.ID
+	}
+	never, longAgo, recently := enroll(nil), enroll(&lastWeek), enroll(&yesterday)
+
+	now := time.Now()
+	claimed, err := r.MarkNudged(ctx, []uuid.UUID{never, longAgo, recently}, now, now.Add(-7*24*time.Hour))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{never, longAgo}, claimed)
+
+	claimed, err = r.MarkNudged(ctx, []uuid.UUID{never, longAgo, recently}, now, now.Add(-7*24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "a second nudge straight away reaches nobody")
+}
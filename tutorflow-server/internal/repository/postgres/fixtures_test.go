@@ -0,0 +1,42 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// newUser stores a user with the required fields filled in
+func newUser(t *testing.T, db *gorm.DB) *domain.User {
+	t.Helper()
+	user := &domain.User{
+		ID:           uuid.New(),
+		Email:        uuid.NewString() + "@example.com",
+		PasswordHash: "x",
+		FirstName:    "Test",
+		LastName:     "User",
+		Status:       domain.StatusActive,
+	}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
+
+// newCourse stores a published course by instructor in the given
+// subscription tier
+func newCourse(t *testing.T, db *gorm.DB, instructor *domain.User, tier int) *domain.Course {
+	t.Helper()
+	course := &domain.Course{
+		ID:               uuid.New(),
+		Title:            "Course",
+		Slug:             uuid.NewString(),
+		InstructorID:     instructor.ID,
+		Status:           domain.CourseStatusPublished,
+		SubscriptionTier: tier,
+	}
+	require.NoError(t, db.Create(course).Error)
+	return course
+}
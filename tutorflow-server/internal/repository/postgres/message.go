@@ -3,12 +3,17 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"html"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
 )
 
@@ -114,6 +119,16 @@ func (r *messageRepository) GetUserConversations(ctx context.Context, userID uui
 	return result, total, nil
 }
 
+// GetConversationPartners returns everyone the user has a conversation with
+func (r *messageRepository) GetConversationPartners(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var partners []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&domain.Conversation{}).
+		Select("CASE WHEN participant1 = ? THEN participant2 ELSE participant1 END", userID).
+		Where("participant1 = ? OR participant2 = ?", userID, userID).
+		Scan(&partners).Error
+	return partners, err
+}
+
 func (r *messageRepository) UpdateConversation(ctx context.Context, conv *domain.Conversation) error {
 	return r.db.WithContext(ctx).Save(conv).Error
 }
@@ -136,6 +151,7 @@ func (r *messageRepository) GetMessageByID(ctx context.Context, id uuid.UUID) (*
 	var msg domain.Message
 	err := r.db.WithContext(ctx).
 		Preload("Sender").
+		Preload("Attachments").
 		Where("id = ?", id).
 		First(&msg).Error
 	if err != nil {
@@ -160,6 +176,7 @@ func (r *messageRepository) GetConversationMessages(ctx context.Context, convID
 	offset := (page - 1) * limit
 	err := query.
 		Preload("Sender").
+		Preload("Attachments").
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(limit).
@@ -168,6 +185,16 @@ func (r *messageRepository) GetConversationMessages(ctx context.Context, convID
 	return messages, total, err
 }
 
+func (r *messageRepository) GetConversationMessagesAfter(ctx context.Context, convID uuid.UUID, cursor pagination.Cursor, limit int) ([]domain.Message, error) {
+	var messages []domain.Message
+	query := r.db.WithContext(ctx).
+		Preload("Sender").
+		Preload("Attachments").
+		Where("conversation_id = ?", convID)
+	err := pagination.After(query, "created_at", "id", cursor, limit).Find(&messages).Error
+	return messages, err
+}
+
 func (r *messageRepository) MarkAsRead(ctx context.Context, msgID uuid.UUID) error {
 	now := time.Now()
 	return r.db.WithContext(ctx).Model(&domain.Message{}).
@@ -193,5 +220,122 @@ func (r *messageRepository) GetUnreadCount(ctx context.Context, userID uuid.UUID
 }
 
 func (r *messageRepository) DeleteMessage(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&domain.Message{}, "id = ?", id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", id).Delete(&domain.MessageAttachment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Message{}, "id = ?", id).Error
+	})
+}
+
+// Snippet markers passed to ts_headline. They can't appear in message text, so
+// the snippet can be HTML-escaped before they are turned into <mark> tags.
+const (
+	snippetStart = "\x01"
+	snippetStop  = "\x02"
+)
+
+// SearchMessages full-text searches the messages in the user's conversations,
+// best matches first
+func (r *messageRepository) SearchMessages(ctx context.Context, userID uuid.UUID, query string, page, limit int) ([]domain.MessageSearchResult, int64, error) {
+	base := r.db.WithContext(ctx).Model(&domain.Message{}).
+		Joins("JOIN conversations ON conversations.id = messages.conversation_id").
+		Where("(conversations.participant1 = ? OR conversations.participant2 = ?)", userID, userID).
+		Where("messages.search_vector @@ plainto_tsquery('english', ?)", query)
+
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var hits []struct {
+		ID      uuid.UUID
+		Snippet string
+	}
+	headlineOptions := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=1, MaxWords=20, MinWords=5", snippetStart, snippetStop)
+	err := base.
+		Select("messages.id, ts_headline('english', messages.content, plainto_tsquery('english', ?), ?) AS snippet", query, headlineOptions).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(messages.search_vector, plainto_tsquery('english', ?)) DESC, messages.created_at DESC",
+			Vars:               []interface{}{query},
+			WithoutParentheses: true,
+		}}).
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Scan(&hits).Error
+	if err != nil || len(hits) == 0 {
+		return nil, total, err
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	var messages []domain.Message
+	if err := r.db.WithContext(ctx).
+		Preload("Sender").
+		Preload("Attachments").
+		Preload("Conversation.User1").
+		Preload("Conversation.User2").
+		Where("id IN ?", ids).
+		Find(&messages).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uuid.UUID]domain.Message, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+	}
+
+	results := make([]domain.MessageSearchResult, 0, len(hits))
+	for _, h := range hits {
+		m, ok := byID[h.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, domain.MessageSearchResult{
+			Message:      m,
+			Conversation: m.Conversation,
+			Snippet:      highlightSnippet(h.Snippet),
+		})
+	}
+	return results, total, nil
+}
+
+// highlightSnippet escapes a ts_headline snippet for HTML and marks the matches
+func highlightSnippet(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, snippetStart, "<mark>")
+	return strings.ReplaceAll(s, snippetStop, "</mark>")
+}
+
+// Broadcasts
+
+// ReserveBroadcast counts and inserts under a lock on the sender's row, so
+// concurrent broadcasts from one instructor can't all pass the limit
+func (r *messageRepository) ReserveBroadcast(ctx context.Context, broadcast *domain.MessageBroadcast, since time.Time, limit int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sender domain.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").First(&sender, "id = ?", broadcast.SenderID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&domain.MessageBroadcast{}).
+			Where("sender_id = ? AND created_at > ?", broadcast.SenderID, since).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= limit {
+			return domain.ErrBroadcastLimitReached
+		}
+		return tx.Create(broadcast).Error
+	})
+}
+
+func (r *messageRepository) UpdateBroadcastCounts(ctx context.Context, broadcast *domain.MessageBroadcast) error {
+	return r.db.WithContext(ctx).Model(broadcast).Updates(map[string]interface{}{
+		"recipient_count": broadcast.RecipientCount,
+		"failed_count":    broadcast.FailedCount,
+	}).Error
 }
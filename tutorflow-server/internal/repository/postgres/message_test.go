@@ -0,0 +1,42 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/testdb"
+	repo "github.com/tutorflow/tutorflow-server/internal/repository/postgres"
+)
+
+func TestMessageRepository_ReserveBroadcast(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	r := repo.NewMessageRepository(db)
+
+	instructor := newUser(t, db)
+	course := newCourse(t, db, instructor, 0)
+	since := time.Now().Add(-time.Hour)
+	reserve := func() (*domain.MessageBroadcast, error) {
+		b := &domain.MessageBroadcast{SenderID: instructor.ID, CourseID: course.ID, Content: "hello"}
+		return b, r.ReserveBroadcast(ctx, b, since, 2)
+	}
+
+	first, err := reserve()
+	require.NoError(t, err)
+	_, err = reserve()
+	require.NoError(t, err)
+	_, err = reserve()
+	assert.ErrorIs(t, err, domain.ErrBroadcastLimitReached)
+
+	first.RecipientCount, first.FailedCount = 3, 1
+	require.NoError(t, r.UpdateBroadcastCounts(ctx, first))
+	var stored domain.MessageBroadcast
+	require.NoError(t, db.First(&stored, "id = ?", first.ID).Error)
+	assert.Equal(t, 3, stored.RecipientCount)
+	assert.Equal(t, 1, stored.FailedCount)
+}
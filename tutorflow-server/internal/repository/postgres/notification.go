@@ -2,12 +2,16 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
 )
 
@@ -24,6 +28,13 @@ func (r *notificationRepository) Create(ctx context.Context, notification *domai
 	return r.db.WithContext(ctx).Create(notification).Error
 }
 
+func (r *notificationRepository) CreateBatch(ctx context.Context, notifications []domain.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(notifications, 500).Error
+}
+
 func (r *notificationRepository) GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Notification, int64, error) {
 	var notifications []domain.Notification
 	var total int64
@@ -39,11 +50,19 @@ func (r *notificationRepository) GetByUser(ctx context.Context, userID uuid.UUID
 	return notifications, total, err
 }
 
-func (r *notificationRepository) MarkAsRead(ctx context.Context, id uuid.UUID) error {
+func (r *notificationRepository) GetByUserAfter(ctx context.Context, userID uuid.UUID, cursor pagination.Cursor, limit int) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	err := pagination.After(query, "created_at", "id", cursor, limit).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) MarkAsRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int64, error) {
 	now := time.Now()
-	return r.db.WithContext(ctx).Model(&domain.Notification{}).
-		Where("id = ?", id).
-		Update("read_at", now).Error
+	result := r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("user_id = ? AND id IN ? AND read_at IS NULL", userID, ids).
+		Update("read_at", now)
+	return result.RowsAffected, result.Error
 }
 
 func (r *notificationRepository) MarkAllAsRead(ctx context.Context, userID uuid.UUID) error {
@@ -53,8 +72,16 @@ func (r *notificationRepository) MarkAllAsRead(ctx context.Context, userID uuid.
 		Update("read_at", now).Error
 }
 
-func (r *notificationRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&domain.Notification{}, "id = ?", id).Error
+func (r *notificationRepository) Delete(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, before *time.Time) (int64, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+	result := query.Delete(&domain.Notification{})
+	return result.RowsAffected, result.Error
 }
 
 func (r *notificationRepository) GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64, error) {
@@ -65,6 +92,116 @@ func (r *notificationRepository) GetUnreadCount(ctx context.Context, userID uuid
 	return count, err
 }
 
+// GetForDigest returns the user's unread notifications created since the
+// given time that have not gone out in a digest yet, oldest first
+func (r *notificationRepository) GetForDigest(ctx context.Context, userID uuid.UUID, since time.Time) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND read_at IS NULL AND digested_at IS NULL AND created_at > ?", userID, since).
+		Order("created_at ASC").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) MarkDigested(ctx context.Context, ids []uuid.UUID, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("id IN ?", ids).
+		Update("digested_at", at).Error
+}
+
+// NotificationPreferenceRepository
+type notificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferenceRepository(db *gorm.DB) repository.NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{db: db}
+}
+
+func (r *notificationPreferenceRepository) GetByUser(ctx context.Context, userID uuid.UUID) (domain.NotificationPreferences, error) {
+	var prefs domain.NotificationPreferences
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&prefs).Error
+	return prefs, err
+}
+
+// GetByUsers returns the stored choices of each listed user; users without
+// any are left out
+func (r *notificationPreferenceRepository) GetByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.NotificationPreferences, error) {
+	var prefs domain.NotificationPreferences
+	if err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	byUser := make(map[uuid.UUID]domain.NotificationPreferences)
+	for _, pref := range prefs {
+		byUser[pref.UserID] = append(byUser[pref.UserID], pref)
+	}
+	return byUser, nil
+}
+
+func (r *notificationPreferenceRepository) Upsert(ctx context.Context, prefs []domain.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&prefs).Error
+}
+
+// GetDigest returns the user's digest setting, defaulting to immediate
+func (r *notificationPreferenceRepository) GetDigest(ctx context.Context, userID uuid.UUID) (*domain.NotificationDigest, error) {
+	var digest domain.NotificationDigest
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&digest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &domain.NotificationDigest{UserID: userID, Frequency: domain.DigestImmediate}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &digest, nil
+}
+
+func (r *notificationPreferenceRepository) GetDigestFrequencies(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.DigestFrequency, error) {
+	var digests []domain.NotificationDigest
+	if err := r.db.WithContext(ctx).Select("user_id", "frequency").Where("user_id IN ?", userIDs).Find(&digests).Error; err != nil {
+		return nil, err
+	}
+	frequencies := make(map[uuid.UUID]domain.DigestFrequency, len(digests))
+	for _, digest := range digests {
+		frequencies[digest.UserID] = digest.Frequency
+	}
+	return frequencies, nil
+}
+
+// SetDigest stores the user's digest frequency. When the digest was last
+// sent is kept, so switching frequency doesn't resend the same window.
+func (r *notificationPreferenceRepository) SetDigest(ctx context.Context, digest *domain.NotificationDigest) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"frequency", "updated_at"}),
+	}).Create(digest).Error
+}
+
+// GetDueDigests returns the users on the given frequency whose last digest
+// went out before sentBefore, or who have never had one
+func (r *notificationPreferenceRepository) GetDueDigests(ctx context.Context, frequency domain.DigestFrequency, sentBefore time.Time) ([]domain.NotificationDigest, error) {
+	var digests []domain.NotificationDigest
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("frequency = ? AND (last_sent_at IS NULL OR last_sent_at <= ?)", frequency, sentBefore).
+		Find(&digests).Error
+	return digests, err
+}
+
+func (r *notificationPreferenceRepository) MarkDigestSent(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.NotificationDigest{}).
+		Where("user_id = ?", userID).
+		Update("last_sent_at", at).Error
+}
+
 // ReviewRepository
 type reviewRepository struct {
 	db *gorm.DB
@@ -74,39 +211,81 @@ func NewReviewRepository(db *gorm.DB) repository.ReviewRepository {
 	return &reviewRepository{db: db}
 }
 
+// Create stores the review, returning ErrAlreadyReviewed when the user has
+// already reviewed the course, including when a concurrent post got there first
 func (r *reviewRepository) Create(ctx context.Context, review *domain.CourseReview) error {
-	if err := r.db.WithContext(ctx).Create(review).Error; err != nil {
-		return err
-	}
-	// Update course rating
-	return r.updateCourseRating(ctx, review.CourseID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "course_id"}},
+			DoNothing: true,
+		}).Create(review)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrAlreadyReviewed
+		}
+		if review.Status != domain.ReviewStatusApproved {
+			return nil
+		}
+		return adjustCourseRating(tx, review.CourseID, review.Rating, 1)
+	})
 }
 
 func (r *reviewRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.CourseReview, error) {
 	var review domain.CourseReview
 	err := r.db.WithContext(ctx).Preload("User").Where("id = ?", id).First(&review).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrReviewNotFound
+		}
 		return nil, err
 	}
 	return &review, nil
 }
 
 func (r *reviewRepository) Update(ctx context.Context, review *domain.CourseReview) error {
-	if err := r.db.WithContext(ctx).Save(review).Error; err != nil {
-		return err
-	}
-	return r.updateCourseRating(ctx, review.CourseID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before domain.CourseReview
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "course_id", "rating", "status").
+			First(&before, "id = ?", review.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(review).Error; err != nil {
+			return err
+		}
+
+		// Take the old version out of the course rating and put the new one in
+		var sum float64
+		var count int
+		if before.Status == domain.ReviewStatusApproved {
+			sum, count = sum-before.Rating, count-1
+		}
+		if review.Status == domain.ReviewStatusApproved {
+			sum, count = sum+review.Rating, count+1
+		}
+		if sum == 0 && count == 0 {
+			return nil
+		}
+		return adjustCourseRating(tx, review.CourseID, sum, count)
+	})
 }
 
 func (r *reviewRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	var review domain.CourseReview
-	if err := r.db.WithContext(ctx).First(&review, "id = ?", id).Error; err != nil {
-		return err
-	}
-	if err := r.db.WithContext(ctx).Delete(&review).Error; err != nil {
-		return err
-	}
-	return r.updateCourseRating(ctx, review.CourseID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var review domain.CourseReview
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&review).Error; err != nil {
+			return err
+		}
+		if review.Status != domain.ReviewStatusApproved {
+			return nil
+		}
+		return adjustCourseRating(tx, review.CourseID, -review.Rating, -1)
+	})
 }
 
 func (r *reviewRepository) GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.CourseReview, int64, error) {
@@ -114,7 +293,7 @@ func (r *reviewRepository) GetByCourse(ctx context.Context, courseID uuid.UUID,
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&domain.CourseReview{}).
-		Where("course_id = ? AND status = ?", courseID, "published")
+		Where("course_id = ? AND status = ?", courseID, domain.ReviewStatusApproved)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -142,60 +321,164 @@ func (r *reviewRepository) GetByUserAndCourse(ctx context.Context, userID, cours
 	return &review, nil
 }
 
-func (r *reviewRepository) Vote(ctx context.Context, reviewID, userID uuid.UUID, isHelpful bool) error {
-	vote := &domain.ReviewVote{
-		ReviewID:  reviewID,
-		UserID:    userID,
-		IsHelpful: isHelpful,
+// Vote records a user's vote on a review, replacing any earlier vote, and
+// returns the review's new vote counts
+func (r *reviewRepository) Vote(ctx context.Context, reviewID, userID uuid.UUID, isHelpful bool) (int, int, error) {
+	var helpful, unhelpful int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		vote := &domain.ReviewVote{
+			ReviewID:  reviewID,
+			UserID:    userID,
+			IsHelpful: isHelpful,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "review_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"is_helpful", "updated_at"}),
+		}).Create(vote).Error; err != nil {
+			return err
+		}
+
+		var err error
+		helpful, unhelpful, err = updateVoteCounts(tx, reviewID)
+		return err
+	})
+	return helpful, unhelpful, err
+}
+
+// RemoveVote withdraws a user's vote on a review and returns the review's
+// new vote counts
+func (r *reviewRepository) RemoveVote(ctx context.Context, reviewID, userID uuid.UUID) (int, int, error) {
+	var helpful, unhelpful int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("review_id = ? AND user_id = ?", reviewID, userID).
+			Delete(&domain.ReviewVote{}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		helpful, unhelpful, err = updateVoteCounts(tx, reviewID)
+		return err
+	})
+	return helpful, unhelpful, err
+}
+
+func (r *reviewRepository) GetRatingDistribution(ctx context.Context, courseID uuid.UUID) (map[int]int64, error) {
+	var rows []struct {
+		Stars int
+		Count int64
 	}
 
-	// Upsert vote
-	err := r.db.WithContext(ctx).
-		Where(domain.ReviewVote{ReviewID: reviewID, UserID: userID}).
-		Assign(vote).
-		FirstOrCreate(vote).Error
+	err := r.db.WithContext(ctx).Model(&domain.CourseReview{}).
+		Select("FLOOR(rating)::int as stars, COUNT(*) as count").
+		Where("course_id = ? AND status = ?", courseID, domain.ReviewStatusApproved).
+		Group("stars").
+		Scan(&rows).Error
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	distribution := map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	for _, row := range rows {
+		if row.Stars >= 1 && row.Stars <= 5 {
+			distribution[row.Stars] = row.Count
+		}
+	}
+	return distribution, nil
+}
+
+// ListPending returns reviews waiting for moderation, oldest first.
+// instructorID limits them to that instructor's courses.
+func (r *reviewRepository) ListPending(ctx context.Context, instructorID *uuid.UUID, page, limit int) ([]domain.CourseReview, int64, error) {
+	var reviews []domain.CourseReview
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.CourseReview{}).
+		Where("course_reviews.status = ?", domain.ReviewStatusPending)
+	if instructorID != nil {
+		query = query.Joins("JOIN courses ON courses.id = course_reviews.course_id").
+			Where("courses.instructor_id = ?", *instructorID)
 	}
 
-	// Update counts
-	return r.updateVoteCounts(ctx, reviewID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("User").
+		Preload("Course").
+		Order("course_reviews.created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&reviews).Error
+
+	return reviews, total, err
 }
 
-func (r *reviewRepository) updateCourseRating(ctx context.Context, courseID uuid.UUID) error {
-	var result struct {
-		AvgRating float64
-		Count     int64
+// adjustCourseRating folds a change of ratingDelta across countDelta
+// approved reviews into a course's stored average, without rescanning its
+// reviews. The course row is locked so concurrent reviews don't lose updates.
+func adjustCourseRating(tx *gorm.DB, courseID uuid.UUID, ratingDelta float64, countDelta int) error {
+	var course domain.Course
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "rating", "total_reviews").
+		First(&course, "id = ?", courseID).Error; err != nil {
+		return err
 	}
 
-	r.db.WithContext(ctx).Model(&domain.CourseReview{}).
-		Select("AVG(rating) as avg_rating, COUNT(*) as count").
-		Where("course_id = ? AND status = ?", courseID, "published").
-		Scan(&result)
+	total := course.TotalReviews + countDelta
+	rating := 0.0
+	if total > 0 {
+		rating = (course.Rating*float64(course.TotalReviews) + ratingDelta) / float64(total)
+		rating = math.Min(math.Max(rating, 0), 5)
+	} else {
+		total = 0
+	}
 
-	return r.db.WithContext(ctx).Model(&domain.Course{}).
+	return tx.Model(&domain.Course{}).Unscoped().
 		Where("id = ?", courseID).
 		Updates(map[string]interface{}{
-			"rating":        result.AvgRating,
-			"total_reviews": result.Count,
+			"rating":        rating,
+			"total_reviews": total,
 		}).Error
 }
 
-func (r *reviewRepository) updateVoteCounts(ctx context.Context, reviewID uuid.UUID) error {
-	var helpful, unhelpful int64
-
-	r.db.WithContext(ctx).Model(&domain.ReviewVote{}).
-		Where("review_id = ? AND is_helpful = ?", reviewID, true).
-		Count(&helpful)
+// RecomputeRatings recalculates every course's rating from its approved
+// reviews, correcting drift from incremental updates. Only courses whose
+// stored values are off are written; it returns how many were.
+func (r *reviewRepository) RecomputeRatings(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		UPDATE courses c
+		SET rating = s.avg_rating, total_reviews = s.review_count
+		FROM (
+			SELECT courses.id, COALESCE(ROUND(AVG(r.rating), 2), 0) AS avg_rating, COUNT(r.id) AS review_count
+			FROM courses
+			LEFT JOIN course_reviews r ON r.course_id = courses.id AND r.status = ?
+			GROUP BY courses.id
+		) s
+		WHERE c.id = s.id AND (c.rating <> s.avg_rating OR c.total_reviews <> s.review_count)`,
+		domain.ReviewStatusApproved)
+	return result.RowsAffected, result.Error
+}
 
-	r.db.WithContext(ctx).Model(&domain.ReviewVote{}).
-		Where("review_id = ? AND is_helpful = ?", reviewID, false).
-		Count(&unhelpful)
+// updateVoteCounts stores a review's helpful and unhelpful totals
+func updateVoteCounts(tx *gorm.DB, reviewID uuid.UUID) (int, int, error) {
+	var counts struct {
+		Helpful   int
+		Unhelpful int
+	}
+	if err := tx.Model(&domain.ReviewVote{}).
+		Select("COUNT(*) FILTER (WHERE is_helpful) AS helpful, COUNT(*) FILTER (WHERE NOT is_helpful) AS unhelpful").
+		Where("review_id = ?", reviewID).
+		Scan(&counts).Error; err != nil {
+		return 0, 0, err
+	}
 
-	return r.db.WithContext(ctx).Model(&domain.CourseReview{}).
+	err := tx.Model(&domain.CourseReview{}).
 		Where("id = ?", reviewID).
 		Updates(map[string]interface{}{
-			"helpful_count":   helpful,
-			"unhelpful_count": unhelpful,
+			"helpful_count":   counts.Helpful,
+			"unhelpful_count": counts.Unhelpful,
 		}).Error
+	return counts.Helpful, counts.Unhelpful, err
 }
@@ -0,0 +1,72 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/testdb"
+	repo "github.com/tutorflow/tutorflow-server/internal/repository/postgres"
+)
+
+func TestOrderRepository_Complete_OnlyOnce(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	r := repo.NewOrderRepository(db)
+
+	order := &domain.Order{
+		OrderNumber: domain.GenerateOrderNumber(),
+		UserID:      newUser(t, db).ID,
+		Status:      domain.OrderStatusPending,
+		Subtotal:    40,
+		Total:       40,
+	}
+	require.NoError(t, r.Create(ctx, order))
+
+	completed, err := r.Complete(ctx, order)
+	require.NoError(t, err)
+	assert.True(t, completed)
+	assert.Equal(t, domain.OrderStatusCompleted, order.Status)
+	assert.NotNil(t, order.PaidAt)
+
+	// A second confirmation, holding the order as it was loaded before the
+	// first one finished, must not complete it again
+	stale := &domain.Order{ID: order.ID, Status: domain.OrderStatusPending}
+	completed, err = r.Complete(ctx, stale)
+	require.NoError(t, err)
+	assert.False(t, completed)
+	assert.Equal(t, domain.OrderStatusPending, stale.Status)
+}
+
+func TestEarningRepository_RejectsDuplicateEarning(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	r := repo.NewEarningRepository(db)
+
+	instructor := newUser(t, db)
+	course := newCourse(t, db, instructor, 0)
+	order := &domain.Order{
+		OrderNumber: domain.GenerateOrderNumber(),
+		UserID:      newUser(t, db).ID,
+		Subtotal:    40,
+		Total:       40,
+		Items:       []domain.OrderItem{{CourseID: course.ID, Price: 40, InstructorShare: 28}},
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	earning := func() *domain.InstructorEarning {
+		return &domain.InstructorEarning{
+			ID:           uuid.New(),
+			InstructorID: instructor.ID,
+			OrderItemID:  order.Items[0].ID,
+			Amount:       28,
+			PlatformFee:  12,
+		}
+	}
+	require.NoError(t, r.Create(ctx, earning()))
+	assert.Error(t, r.Create(ctx, earning()))
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+)
+
+type recommendationRepository struct {
+	db *gorm.DB
+}
+
+// NewRecommendationRepository creates a new recommendation repository
+func NewRecommendationRepository(db *gorm.DB) repository.RecommendationRepository {
+	return &recommendationRepository{db: db}
+}
+
+func (r *recommendationRepository) GetAlsoBought(ctx context.Context, courseID uuid.UUID, excludeUserID *uuid.UUID, limit int) ([]domain.Course, error) {
+	var rows []struct {
+		CourseID uuid.UUID
+		CoCount  int64
+	}
+
+	// Count how many completed orders contain both the seed course and each other course
+	query := r.db.WithContext(ctx).
+		Table("order_items AS seed").
+		Select("other.course_id, COUNT(DISTINCT other.order_id) AS co_count").
+		Joins("JOIN orders ON orders.id = seed.order_id AND orders.status = ?", domain.OrderStatusCompleted).
+		Joins("JOIN order_items AS other ON other.order_id = seed.order_id AND other.course_id <> seed.course_id").
+		Joins("JOIN courses ON courses.id = other.course_id AND courses.status = ? AND courses.deleted_at IS NULL", domain.CourseStatusPublished).
+		Scopes(activeInstructorsOnly).
+		Where("seed.course_id = ?", courseID)
+
+	if excludeUserID != nil {
+		query = query.Where("other.course_id NOT IN (?)",
+			r.db.Model(&domain.Enrollment{}).Select("course_id").Where("user_id = ?", *excludeUserID))
+	}
+
+	err := query.
+		Group("other.course_id").
+		Order("co_count DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.CourseID
+	}
+
+	var courses []domain.Course
+	if err := r.db.WithContext(ctx).
+		Preload("Instructor").
+		Preload("Categories").
+		Where("id IN ?", ids).
+		Find(&courses).Error; err != nil {
+		return nil, err
+	}
+
+	// Restore co-purchase ranking
+	byID := make(map[uuid.UUID]domain.Course, len(courses))
+	for _, c := range courses {
+		byID[c.ID] = c
+	}
+	ordered := make([]domain.Course, 0, len(courses))
+	for _, id := range ids {
+		if c, ok := byID[id]; ok {
+			ordered = append(ordered, c)
+		}
+	}
+
+	return ordered, nil
+}
+
+func (r *recommendationRepository) GetPopularInSameCategories(ctx context.Context, courseID uuid.UUID, excludeUserID *uuid.UUID, excludeIDs []uuid.UUID, limit int) ([]domain.Course, error) {
+	var courses []domain.Course
+
+	categoryIDs := r.db.Model(&domain.CourseCategory{}).Select("category_id").Where("course_id = ?", courseID)
+	sameCategory := r.db.Model(&domain.CourseCategory{}).Select("course_id").Where("category_id IN (?)", categoryIDs)
+
+	query := r.db.WithContext(ctx).
+		Preload("Instructor").
+		Preload("Categories").
+		Where("status = ?", domain.CourseStatusPublished).
+		Scopes(activeInstructorsOnly).
+		Where("id <> ?", courseID).
+		Where("id IN (?)", sameCategory)
+
+	if len(excludeIDs) > 0 {
+		query = query.Where("id NOT IN ?", excludeIDs)
+	}
+	if excludeUserID != nil {
+		query = query.Where("id NOT IN (?)",
+			r.db.Model(&domain.Enrollment{}).Select("course_id").Where("user_id = ?", *excludeUserID))
+	}
+
+	err := query.
+		Order("total_students DESC, rating DESC").
+		Limit(limit).
+		Find(&courses).Error
+
+	return courses, err
+}
+
+func (r *recommendationRepository) GetEnrollmentInterests(ctx context.Context, userID uuid.UUID) ([]repository.EnrollmentInterest, error) {
+	var interests []repository.EnrollmentInterest
+
+	// One row per enrollment and category; uncategorized courses still contribute their level
+	err := r.db.WithContext(ctx).
+		Table("enrollments").
+		Select("enrollments.course_id, course_categories.category_id, courses.level, enrollments.enrolled_at").
+		Joins("JOIN courses ON courses.id = enrollments.course_id").
+		Joins("LEFT JOIN course_categories ON course_categories.course_id = enrollments.course_id").
+		Where("enrollments.user_id = ?", userID).
+		Where("enrollments.status IN ?", []domain.EnrollmentStatus{domain.EnrollmentStatusActive, domain.EnrollmentStatusCompleted}).
+		Order("enrollments.enrolled_at DESC").
+		Scan(&interests).Error
+
+	return interests, err
+}
+
+func (r *recommendationRepository) GetTopRatedInCategories(ctx context.Context, categoryIDs []uuid.UUID, excludeUserID uuid.UUID, limit int) ([]domain.Course, error) {
+	var courses []domain.Course
+	if len(categoryIDs) == 0 {
+		return courses, nil
+	}
+
+	inCategories := r.db.Model(&domain.CourseCategory{}).Select("course_id").Where("category_id IN ?", categoryIDs)
+
+	err := r.db.WithContext(ctx).
+		Preload("Instructor").
+		Preload("Categories").
+		Where("status = ?", domain.CourseStatusPublished).
+		Scopes(activeInstructorsOnly).
+		Where("id IN (?)", inCategories).
+		Where("id NOT IN (?)",
+			r.db.Model(&domain.Enrollment{}).Select("course_id").Where("user_id = ?", excludeUserID)).
+		Order("rating DESC, total_reviews DESC, total_students DESC").
+		Limit(limit).
+		Find(&courses).Error
+
+	return courses, err
+}
+
+func (r *recommendationRepository) GetTrending(ctx context.Context, excludeUserID *uuid.UUID, excludeIDs []uuid.UUID, limit int) ([]domain.Course, error) {
+	var courses []domain.Course
+
+	query := r.db.WithContext(ctx).
+		Preload("Instructor").
+		Preload("Categories").
+		Where("status = ?", domain.CourseStatusPublished).
+		Scopes(activeInstructorsOnly)
+
+	if len(excludeIDs) > 0 {
+		query = query.Where("id NOT IN ?", excludeIDs)
+	}
+	if excludeUserID != nil {
+		query = query.Where("id NOT IN (?)",
+			r.db.Model(&domain.Enrollment{}).Select("course_id").Where("user_id = ?", *excludeUserID))
+	}
+
+	err := query.
+		Order("is_featured DESC, total_students DESC, rating DESC").
+		Limit(limit).
+		Find(&courses).Error
+
+	return courses, err
+}
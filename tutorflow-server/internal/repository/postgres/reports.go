@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
@@ -22,37 +23,28 @@ func NewRecentlyViewedRepository(db *gorm.DB) repository.RecentlyViewedRepositor
 }
 
 func (r *recentlyViewedRepository) Track(ctx context.Context, userID, courseID uuid.UUID) error {
-	// Check if already exists
-	var existing domain.RecentlyViewed
-	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND course_id = ?", userID, courseID).
-		First(&existing).Error
-
-	if err == nil {
-		// Update existing
-		existing.ViewedAt = time.Now()
-		return r.db.WithContext(ctx).Save(&existing).Error
-	}
-
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return err
-	}
-
-	// Create new
+	// Upsert so re-viewing a course moves it to the top instead of adding a duplicate
 	rv := &domain.RecentlyViewed{
 		UserID:   userID,
 		CourseID: courseID,
 		ViewedAt: time.Now(),
 	}
-	return r.db.WithContext(ctx).Create(rv).Error
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "course_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"viewed_at"}),
+		}).
+		Create(rv).Error
 }
 
 func (r *recentlyViewedRepository) GetByUser(ctx context.Context, userID uuid.UUID, limit int) ([]domain.RecentlyViewed, error) {
 	var items []domain.RecentlyViewed
 	err := r.db.WithContext(ctx).
 		Preload("Course").
-		Where("user_id = ?", userID).
-		Order("viewed_at DESC").
+		Preload("Course.Instructor").
+		Joins("JOIN courses ON courses.id = recently_vieweds.course_id AND courses.deleted_at IS NULL").
+		Where("recently_vieweds.user_id = ?", userID).
+		Order("recently_vieweds.viewed_at DESC").
 		Limit(limit).
 		Find(&items).Error
 	return items, err
@@ -68,6 +60,18 @@ func (r *recentlyViewedRepository) Delete(ctx context.Context, userID, courseID
 		Delete(&domain.RecentlyViewed{}, "user_id = ? AND course_id = ?", userID, courseID).Error
 }
 
+func (r *recentlyViewedRepository) Prune(ctx context.Context, userID uuid.UUID, keep int) error {
+	recent := r.db.Model(&domain.RecentlyViewed{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("viewed_at DESC").
+		Limit(keep)
+
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND id NOT IN (?)", userID, recent).
+		Delete(&domain.RecentlyViewed{}).Error
+}
+
 // ScheduledReportRepository
 type scheduledReportRepository struct {
 	db *gorm.DB
@@ -0,0 +1,36 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/testdb"
+	repo "github.com/tutorflow/tutorflow-server/internal/repository/postgres"
+)
+
+func TestReviewRepository_Create(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	r := repo.NewReviewRepository(db)
+
+	instructor := newUser(t, db)
+	learner := newUser(t, db)
+	course := newCourse(t, db, instructor, 0)
+	review := func(rating float64) *domain.CourseReview {
+		return &domain.CourseReview{CourseID: course.ID, UserID: learner.ID, Rating: rating, Status: domain.ReviewStatusApproved}
+	}
+
+	require.NoError(t, r.Create(ctx, review(4)))
+	assert.ErrorIs(t, r.Create(ctx, review(1)), domain.ErrAlreadyReviewed)
+
+	var count int64
+	require.NoError(t, db.Model(&domain.CourseReview{}).Where("course_id = ?", course.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	var stored domain.Course
+	require.NoError(t, db.First(&stored, "id = ?", course.ID).Error)
+	assert.Equal(t, 1, stored.TotalReviews, "the rejected review isn't counted")
+}
@@ -2,11 +2,14 @@ package postgres
 
 import (
 	"context"
-	"fmt"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
@@ -28,38 +31,7 @@ func (r *searchRepository) SearchCourses(ctx context.Context, filters repository
 	query := r.db.WithContext(ctx).Model(&domain.Course{}).
 		Where("status = ?", domain.CourseStatusPublished)
 
-	// Full-text search using PostgreSQL
-	if filters.Query != "" {
-		searchQuery := formatSearchQuery(filters.Query)
-		query = query.Where(
-			"to_tsvector('english', title || ' ' || COALESCE(short_description, '') || ' ' || COALESCE(description, '')) @@ to_tsquery('english', ?)",
-			searchQuery,
-		)
-	}
-
-	// Apply filters
-	if filters.CategoryID != nil {
-		query = query.Joins("JOIN course_categories cc ON cc.course_id = courses.id").
-			Where("cc.category_id = ?", *filters.CategoryID)
-	}
-	if filters.Level != nil {
-		query = query.Where("level = ?", *filters.Level)
-	}
-	if filters.MinPrice != nil {
-		query = query.Where("price >= ?", *filters.MinPrice)
-	}
-	if filters.MaxPrice != nil {
-		query = query.Where("price <= ?", *filters.MaxPrice)
-	}
-	if filters.MinRating != nil {
-		query = query.Where("rating >= ?", *filters.MinRating)
-	}
-	if filters.IsFree != nil && *filters.IsFree {
-		query = query.Where("is_free = true OR price = 0")
-	}
-	if filters.ExcludeID != nil {
-		query = query.Where("id != ?", *filters.ExcludeID)
-	}
+	query = applySearchFilters(query, filters)
 
 	// Count total
 	if err := query.Count(&total).Error; err != nil {
@@ -86,7 +58,7 @@ func (r *searchRepository) SearchCourses(ctx context.Context, filters repository
 	return courses, total, nil
 }
 
-func (r *searchRepository) getOrderClause(sortBy, sortOrder, query string) string {
+func (r *searchRepository) getOrderClause(sortBy, sortOrder, query string) interface{} {
 	direction := "DESC"
 	if sortOrder == "asc" {
 		direction = "ASC"
@@ -94,9 +66,8 @@ func (r *searchRepository) getOrderClause(sortBy, sortOrder, query string) strin
 
 	switch sortBy {
 	case "relevance":
-		if query != "" {
-			searchQuery := formatSearchQuery(query)
-			return fmt.Sprintf("ts_rank(to_tsvector('english', title || ' ' || COALESCE(short_description, '')), to_tsquery('english', '%s')) DESC", searchQuery)
+		if strings.TrimSpace(query) != "" {
+			return relevanceOrder(query)
 		}
 		return "created_at DESC"
 	case "rating":
@@ -110,11 +81,17 @@ func (r *searchRepository) getOrderClause(sortBy, sortOrder, query string) strin
 	case "popular":
 		return "total_students DESC, rating DESC"
 	default:
+		// Rank by relevance whenever there is a query and no explicit sort
+		if strings.TrimSpace(query) != "" {
+			return relevanceOrder(query)
+		}
 		return "created_at DESC"
 	}
 }
 
-func (r *searchRepository) GetFacets(ctx context.Context, query string) (*repository.SearchFacets, error) {
+// GetFacets counts each facet against the active filters except its own, so
+// the counts show what selecting another value would return.
+func (r *searchRepository) GetFacets(ctx context.Context, filters repository.SearchFilters, priceRanges []repository.PriceRange) (*repository.SearchFacets, error) {
 	facets := &repository.SearchFacets{}
 
 	// Category facets
@@ -124,25 +101,24 @@ func (r *searchRepository) GetFacets(ctx context.Context, query string) (*reposi
 		Count      int64
 	}
 
+	categoryFilters := filters
+	categoryFilters.CategoryID = nil
+
 	categoryQuery := r.db.WithContext(ctx).
 		Table("courses").
 		Select("categories.id as category_id, categories.name, COUNT(*) as count").
 		Joins("JOIN course_categories cc ON cc.course_id = courses.id").
 		Joins("JOIN categories ON categories.id = cc.category_id").
-		Where("courses.status = ?", domain.CourseStatusPublished)
-
-	if query != "" {
-		searchQuery := formatSearchQuery(query)
-		categoryQuery = categoryQuery.Where(
-			"to_tsvector('english', courses.title || ' ' || COALESCE(courses.short_description, '')) @@ to_tsquery('english', ?)",
-			searchQuery,
-		)
-	}
+		Where("courses.status = ? AND courses.deleted_at IS NULL", domain.CourseStatusPublished)
 
-	categoryQuery.Group("categories.id, categories.name").
+	err := applySearchFilters(categoryQuery, categoryFilters).
+		Group("categories.id, categories.name").
 		Order("count DESC").
 		Limit(10).
-		Scan(&categoryFacets)
+		Scan(&categoryFacets).Error
+	if err != nil {
+		return nil, err
+	}
 
 	for _, cf := range categoryFacets {
 		facets.Categories = append(facets.Categories, repository.FacetItem{
@@ -158,21 +134,22 @@ func (r *searchRepository) GetFacets(ctx context.Context, query string) (*reposi
 		Count int64
 	}
 
+	levelFilters := filters
+	levelFilters.Level = nil
+
 	levelQuery := r.db.WithContext(ctx).
 		Table("courses").
-		Select("level, COUNT(*) as count").
-		Where("status = ?", domain.CourseStatusPublished)
+		Select("courses.level, COUNT(*) as count").
+		Where("courses.status = ? AND courses.deleted_at IS NULL", domain.CourseStatusPublished)
 
-	if query != "" {
-		searchQuery := formatSearchQuery(query)
-		levelQuery = levelQuery.Where(
-			"to_tsvector('english', title || ' ' || COALESCE(short_description, '')) @@ to_tsquery('english', ?)",
-			searchQuery,
-		)
+	err = applySearchFilters(levelQuery, levelFilters).
+		Group("courses.level").
+		Order("count DESC").
+		Scan(&levelFacets).Error
+	if err != nil {
+		return nil, err
 	}
 
-	levelQuery.Group("level").Order("count DESC").Scan(&levelFacets)
-
 	for _, lf := range levelFacets {
 		if lf.Level != "" {
 			facets.Levels = append(facets.Levels, repository.FacetItem{
@@ -184,105 +161,250 @@ func (r *searchRepository) GetFacets(ctx context.Context, query string) (*reposi
 	}
 
 	// Price range facets
-	facets.PriceRanges = []repository.FacetItem{
-		{Label: "Free", Value: "free", Count: 0},
-		{Label: "$0 - $25", Value: "0-25", Count: 0},
-		{Label: "$25 - $50", Value: "25-50", Count: 0},
-		{Label: "$50 - $100", Value: "50-100", Count: 0},
-		{Label: "$100+", Value: "100+", Count: 0},
+	if len(priceRanges) == 0 {
+		return facets, nil
+	}
+
+	priceFilters := filters
+	priceFilters.MinPrice = nil
+	priceFilters.MaxPrice = nil
+	priceFilters.IsFree = nil
+
+	// Assign every course to the first bucket it falls into
+	var bucketSQL strings.Builder
+	var bucketVars []interface{}
+	bucketSQL.WriteString("CASE")
+	for i, pr := range priceRanges {
+		switch {
+		case pr.Max != nil && *pr.Max == pr.Min:
+			bucketSQL.WriteString(" WHEN courses.price = ?")
+			bucketVars = append(bucketVars, pr.Min)
+		case pr.Max != nil:
+			bucketSQL.WriteString(" WHEN courses.price >= ? AND courses.price < ?")
+			bucketVars = append(bucketVars, pr.Min, *pr.Max)
+		default:
+			bucketSQL.WriteString(" WHEN courses.price >= ?")
+			bucketVars = append(bucketVars, pr.Min)
+		}
+		bucketSQL.WriteString(" THEN " + strconv.Itoa(i))
+	}
+	bucketSQL.WriteString(" END")
+
+	var priceFacets []struct {
+		Bucket *int
+		Count  int64
+	}
+
+	priceQuery := r.db.WithContext(ctx).
+		Table("courses").
+		Select(bucketSQL.String()+" AS bucket, COUNT(*) as count", bucketVars...).
+		Where("courses.status = ? AND courses.deleted_at IS NULL", domain.CourseStatusPublished)
+
+	err = applySearchFilters(priceQuery, priceFilters).
+		Group("bucket").
+		Scan(&priceFacets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(priceRanges))
+	for _, pf := range priceFacets {
+		if pf.Bucket != nil && *pf.Bucket < len(counts) {
+			counts[*pf.Bucket] = pf.Count
+		}
+	}
+	for i, pr := range priceRanges {
+		facets.PriceRanges = append(facets.PriceRanges, repository.FacetItem{
+			Label: pr.Label,
+			Value: pr.Value,
+			Count: counts[i],
+		})
 	}
 
 	return facets, nil
 }
 
-func (r *searchRepository) GetSuggestions(ctx context.Context, query string, limit int) ([]string, error) {
-	var suggestions []string
+// applySearchFilters applies the text query and all set filters to a courses query
+func applySearchFilters(query *gorm.DB, filters repository.SearchFilters) *gorm.DB {
+	query = activeInstructorsOnly(query)
 
-	searchQuery := formatPrefixQuery(query)
+	// Full-text search using PostgreSQL
+	query = applyTextSearch(query, filters.Query)
 
+	// A subquery instead of a join keeps counts correct for courses in several categories
+	if filters.CategoryID != nil {
+		query = query.Where("courses.id IN (?)",
+			query.Session(&gorm.Session{NewDB: true}).
+				Table("course_categories").
+				Select("course_id").
+				Where("category_id = ?", *filters.CategoryID))
+	}
+	if filters.Level != nil {
+		query = query.Where("courses.level = ?", *filters.Level)
+	}
+	if filters.MinPrice != nil {
+		query = query.Where("courses.price >= ?", *filters.MinPrice)
+	}
+	if filters.MaxPrice != nil {
+		query = query.Where("courses.price <= ?", *filters.MaxPrice)
+	}
+	if filters.MinRating != nil {
+		query = query.Where("courses.rating >= ?", *filters.MinRating)
+	}
+	if filters.IsFree != nil && *filters.IsFree {
+		query = query.Where("courses.price = 0")
+	}
+	if filters.ExcludeID != nil {
+		query = query.Where("courses.id <> ?", *filters.ExcludeID)
+	}
+	return query
+}
+
+func (r *searchRepository) GetSuggestions(ctx context.Context, query string, limit int) ([]string, error) {
+	query = strings.TrimSpace(query)
+	pattern := "%" + escapeLike(query) + "%"
+
+	// Candidates come from course titles and category names. "<%" is the
+	// pg_trgm word-similarity operator, so misspellings still match and the
+	// trigram indexes can be used.
+	var titles []string
 	err := r.db.WithContext(ctx).
 		Model(&domain.Course{}).
-		Select("DISTINCT title").
+		Scopes(activeInstructorsOnly).
 		Where("status = ?", domain.CourseStatusPublished).
-		Where("to_tsvector('english', title) @@ to_tsquery('english', ?)", searchQuery).
+		Where("title ILIKE ? OR ? <% title", pattern, query).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "word_similarity(?, title) DESC",
+			Vars:               []interface{}{query},
+			WithoutParentheses: true,
+		}}).
 		Limit(limit).
-		Pluck("title", &suggestions).Error
+		Pluck("title", &titles).Error
+	if err != nil {
+		return nil, err
+	}
 
+	var categories []string
+	err = r.db.WithContext(ctx).
+		Model(&domain.Category{}).
+		Where("name ILIKE ? OR ? <% name", pattern, query).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "word_similarity(?, name) DESC",
+			Vars:               []interface{}{query},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Pluck("name", &categories).Error
 	if err != nil {
 		return nil, err
 	}
 
-	return suggestions, nil
+	return append(titles, categories...), nil
 }
 
-func (r *searchRepository) GetTrendingSearches(ctx context.Context, limit int) ([]string, error) {
+func (r *searchRepository) GetTrendingSearches(ctx context.Context, since time.Time, minCount, limit int) ([]string, error) {
 	var searches []string
 
 	err := r.db.WithContext(ctx).
-		Table("search_logs").
-		Select("query, COUNT(*) as count").
-		Where("created_at > NOW() - INTERVAL '7 days'").
+		Model(&domain.SearchLog{}).
+		Select("query").
+		Where("created_at > ? AND query <> ''", since).
 		Group("query").
-		Order("count DESC").
+		Having("COUNT(*) >= ?", minCount).
+		Order("COUNT(*) DESC").
 		Limit(limit).
 		Pluck("query", &searches).Error
 
 	if err != nil {
-		// If search_logs table doesn't exist, return empty
-		return []string{}, nil
+		return nil, err
 	}
 
 	return searches, nil
 }
 
 func (r *searchRepository) RecordSearch(ctx context.Context, query string, userID *uuid.UUID, resultCount int64) error {
-	// Create search log entry (table may not exist yet)
-	_ = r.db.WithContext(ctx).Exec(
-		"INSERT INTO search_logs (query, user_id, result_count, created_at) VALUES (?, ?, ?, NOW())",
-		query, userID, resultCount,
-	)
-	return nil
+	log := &domain.SearchLog{
+		Query:       query,
+		UserID:      userID,
+		ResultCount: resultCount,
+	}
+	return r.db.WithContext(ctx).Create(log).Error
 }
 
-// formatSearchQuery formats for PostgreSQL to_tsquery
-func formatSearchQuery(query string) string {
-	words := strings.Fields(strings.TrimSpace(query))
-	if len(words) == 0 {
-		return ""
-	}
+func (r *searchRepository) GetRecentSearches(ctx context.Context, userID uuid.UUID, limit int) ([]string, error) {
+	var searches []string
 
-	// Escape special characters and join with &
-	escaped := make([]string, len(words))
-	for i, word := range words {
-		// Remove special tsquery characters
-		word = strings.ReplaceAll(word, "'", "")
-		word = strings.ReplaceAll(word, "&", "")
-		word = strings.ReplaceAll(word, "|", "")
-		word = strings.ReplaceAll(word, "!", "")
-		word = strings.ReplaceAll(word, "(", "")
-		word = strings.ReplaceAll(word, ")", "")
-		if word != "" {
-			escaped[i] = word
-		}
+	err := r.db.WithContext(ctx).
+		Model(&domain.SearchLog{}).
+		Select("query").
+		Where("user_id = ? AND query <> ''", userID).
+		Group("query").
+		Order("MAX(created_at) DESC").
+		Limit(limit).
+		Pluck("query", &searches).Error
+
+	if err != nil {
+		return nil, err
 	}
 
-	return strings.Join(escaped, " & ")
+	return searches, nil
+}
+
+func (r *searchRepository) ClearUserSearches(ctx context.Context, userID uuid.UUID) error {
+	// Detach rather than delete so aggregate trending counts are unaffected
+	return r.db.WithContext(ctx).
+		Model(&domain.SearchLog{}).
+		Where("user_id = ?", userID).
+		Update("user_id", nil).Error
 }
 
-// formatPrefixQuery formats for prefix matching (autocomplete)
-func formatPrefixQuery(query string) string {
-	words := strings.Fields(strings.TrimSpace(query))
-	if len(words) == 0 {
-		return ""
+// minFullTextQueryLength is the shortest query that goes through the tsvector
+// index. Shorter queries stem poorly, so they fall back to substring matching.
+const minFullTextQueryLength = 3
+
+// applyTextSearch restricts a courses query to rows matching the search text
+func applyTextSearch(query *gorm.DB, text string) *gorm.DB {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return query
 	}
 
-	escaped := make([]string, len(words))
-	for i, word := range words {
-		word = strings.ReplaceAll(word, "'", "")
-		if word != "" {
-			escaped[i] = word + ":*"
-		}
+	if isShortQuery(text) {
+		pattern := "%" + escapeLike(text) + "%"
+		return query.Where(
+			"(courses.title ILIKE ? OR courses.short_description ILIKE ?)",
+			pattern, pattern,
+		)
+	}
+
+	return query.Where("courses.search_vector @@ plainto_tsquery('english', ?)", text)
+}
+
+// relevanceOrder ranks matches by ts_rank, or by trigram similarity for short queries
+func relevanceOrder(text string) clause.OrderBy {
+	text = strings.TrimSpace(text)
+	if isShortQuery(text) {
+		return clause.OrderBy{Expression: clause.Expr{
+			SQL:                "similarity(courses.title, ?) DESC, courses.total_students DESC",
+			Vars:               []interface{}{text},
+			WithoutParentheses: true,
+		}}
 	}
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                "ts_rank(courses.search_vector, plainto_tsquery('english', ?)) DESC, courses.total_students DESC",
+		Vars:               []interface{}{text},
+		WithoutParentheses: true,
+	}}
+}
+
+func isShortQuery(text string) bool {
+	return utf8.RuneCountInString(text) < minFullTextQueryLength
+}
 
-	return strings.Join(escaped, " & ")
+// escapeLike escapes LIKE wildcards in user input
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "%", `\%`)
+	s = strings.ReplaceAll(s, "_", `\_`)
+	return s
 }
@@ -83,7 +83,8 @@ func (r *subscriptionRepository) GetActiveByUserID(ctx context.Context, userID u
 	// Find the most recent active subscription
 	err := r.db.WithContext(ctx).
 		Preload("Plan").
-		Where("user_id = ? AND status = ? AND current_period_end > ?", userID, domain.SubscriptionStatusActive, time.Now()).
+		Where("user_id = ? AND status IN ? AND current_period_end > ?", userID,
+			[]domain.SubscriptionStatus{domain.SubscriptionStatusActive, domain.SubscriptionStatusTrialing}, time.Now()).
 		Order("created_at DESC").
 		First(&sub).Error
 
@@ -93,8 +94,74 @@ func (r *subscriptionRepository) GetActiveByUserID(ctx context.Context, userID u
 	return &sub, nil
 }
 
+func (r *subscriptionRepository) GetByStripeID(ctx context.Context, stripeSubscriptionID string) (*domain.Subscription, error) {
+	var sub domain.Subscription
+	err := r.db.WithContext(ctx).Preload("Plan").First(&sub, "stripe_subscription_id = ?", stripeSubscriptionID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *subscriptionRepository) GetPendingByUserID(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error) {
+	var sub domain.Subscription
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status = ? AND stripe_subscription_id = ''", userID, domain.SubscriptionStatusIncomplete).
+		Order("created_at DESC").
+		First(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *subscriptionRepository) GetOpenByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Subscription, error) {
+	var subs []domain.Subscription
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status IN ?", userID, []domain.SubscriptionStatus{
+			domain.SubscriptionStatusActive,
+			domain.SubscriptionStatusTrialing,
+			domain.SubscriptionStatusPastDue,
+			domain.SubscriptionStatusIncomplete,
+		}).
+		Find(&subs).Error
+	return subs, err
+}
+
 func (r *subscriptionRepository) Update(ctx context.Context, subscription *domain.Subscription) error {
-	return r.db.WithContext(ctx).Save(subscription).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "Plan").Save(subscription).Error; err != nil {
+			return err
+		}
+
+		// Completed enrollments keep their status either way
+		if !subscription.GrantsAccess() {
+			return tx.Model(&domain.Enrollment{}).
+				Where("subscription_id = ? AND status = ?", subscription.ID, domain.EnrollmentStatusActive).
+				Update("status", domain.EnrollmentStatusExpired).Error
+		}
+
+		// The plan may have changed, so each course is checked against the
+		// current one: a downgrade expires what it no longer covers and an
+		// upgrade brings it back
+		var plan domain.SubscriptionPlan
+		if err := tx.First(&plan, "id = ?", subscription.PlanID).Error; err != nil {
+			return err
+		}
+		covered := tx.Model(&domain.Course{}).Select("id")
+		if !plan.AllCourses {
+			covered = covered.Where("subscription_tier > 0 AND subscription_tier <= ?", plan.Tier)
+		}
+
+		if err := tx.Model(&domain.Enrollment{}).
+			Where("subscription_id = ? AND status = ? AND course_id NOT IN (?)", subscription.ID, domain.EnrollmentStatusActive, covered).
+			Update("status", domain.EnrollmentStatusExpired).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Enrollment{}).
+			Where("subscription_id = ? AND status = ? AND course_id IN (?)", subscription.ID, domain.EnrollmentStatusExpired, covered).
+			Update("status", domain.EnrollmentStatusActive).Error
+	})
 }
 
 func (r *subscriptionRepository) Cancel(ctx context.Context, id uuid.UUID) error {
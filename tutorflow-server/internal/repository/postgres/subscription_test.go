@@ -0,0 +1,77 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/testdb"
+	repo "github.com/tutorflow/tutorflow-server/internal/repository/postgres"
+)
+
+func TestSubscriptionRepository_Update_RechecksCoverageOnPlanChange(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	r := repo.NewSubscriptionRepository(db)
+
+	basic := &domain.SubscriptionPlan{Name: "Basic", Slug: "basic-test", Tier: 1, IsActive: true}
+	pro := &domain.SubscriptionPlan{Name: "Pro", Slug: "pro-test", Tier: 2, IsActive: true}
+	require.NoError(t, r.CreatePlan(ctx, basic))
+	require.NoError(t, r.CreatePlan(ctx, pro))
+
+	instructor := newUser(t, db)
+	learner := newUser(t, db)
+	sub := &domain.Subscription{
+		UserID:             learner.ID,
+		PlanID:             pro.ID,
+		Status:             domain.SubscriptionStatusActive,
+		Interval:           domain.SubscriptionIntervalMonthly,
+		CurrentPeriodStart: time.Now(),
+		CurrentPeriodEnd:   time.Now().AddDate(0, 1, 0),
+	}
+	require.NoError(t, r.Create(ctx, sub))
+
+	enroll := func(course *domain.Course, status domain.EnrollmentStatus) *domain.Enrollment {
+		e := &domain.Enrollment{UserID: learner.ID, CourseID: course.ID, Status: status, SubscriptionID: &sub.ID}
+		require.NoError(t, db.Create(e).Error)
+		return e
+	}
+	inBasic := enroll(newCourse(t, db, instructor, 1), domain.EnrollmentStatusActive)
+	proOnly := enroll(newCourse(t, db, instructor, 2), domain.EnrollmentStatusActive)
+	finished := enroll(newCourse(t, db, instructor, 2), domain.EnrollmentStatusCompleted)
+
+	status := func(e *domain.Enrollment) domain.EnrollmentStatus {
+		var got domain.Enrollment
+		require.NoError(t, db.First(&got, "id = ?", e.ID).Error)
+		return got.Status
+	}
+
+	// Downgrading loses the courses only the old plan covered
+	sub.PlanID = basic.ID
+	require.NoError(t, r.Update(ctx, sub))
+	assert.Equal(t, domain.EnrollmentStatusActive, status(inBasic))
+	assert.Equal(t, domain.EnrollmentStatusExpired, status(proOnly))
+	assert.Equal(t, domain.EnrollmentStatusCompleted, status(finished))
+
+	// A renewal on the lower plan doesn't bring them back
+	sub.CurrentPeriodEnd = time.Now().AddDate(0, 2, 0)
+	require.NoError(t, r.Update(ctx, sub))
+	assert.Equal(t, domain.EnrollmentStatusExpired, status(proOnly))
+
+	// Upgrading again does
+	sub.PlanID = pro.ID
+	require.NoError(t, r.Update(ctx, sub))
+	assert.Equal(t, domain.EnrollmentStatusActive, status(inBasic))
+	assert.Equal(t, domain.EnrollmentStatusActive, status(proOnly))
+
+	// Losing access expires everything still active
+	sub.Status = domain.SubscriptionStatusCanceled
+	require.NoError(t, r.Update(ctx, sub))
+	assert.Equal(t, domain.EnrollmentStatusExpired, status(inBasic))
+	assert.Equal(t, domain.EnrollmentStatusExpired, status(proOnly))
+	assert.Equal(t, domain.EnrollmentStatusCompleted, status(finished))
+}
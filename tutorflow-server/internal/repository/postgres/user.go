@@ -48,6 +48,15 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	return &user, nil
 }
 
+func (r *userRepository) GetByUsernames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	var users []domain.User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := r.db.WithContext(ctx).Where("username IN ?", usernames).Find(&users).Error
+	return users, err
+}
+
 func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
 }
@@ -90,6 +99,10 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) erro
 	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", id).Update("last_login_at", now).Error
 }
 
+func (r *userRepository) UpdateLastActive(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", id).Update("last_active_at", at).Error
+}
+
 func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
 	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", id).Update("password_hash", passwordHash).Error
 }
@@ -102,6 +115,67 @@ func (r *userRepository) VerifyEmail(ctx context.Context, id uuid.UUID) error {
 	}).Error
 }
 
+// Anonymize saves the scrubbed user and removes what else identifies them in
+// one transaction. Rows other users or accounting rely on (orders, reviews,
+// discussions, messages) are kept and now point at the placeholder.
+func (r *userRepository) Anonymize(ctx context.Context, user *domain.User, entry *domain.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.TutorProfile{}).Where("user_id = ?", user.ID).
+			Update("payout_email", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Enrollment{}).
+			Where("user_id = ? AND status IN ?", user.ID, []domain.EnrollmentStatus{domain.EnrollmentStatusActive, domain.EnrollmentStatusPending}).
+			Update("status", domain.EnrollmentStatusCancelled).Error; err != nil {
+			return err
+		}
+		// Orders stay for accounting; the refund reason is free text the user wrote
+		if err := tx.Model(&domain.Order{}).Where("user_id = ? AND refund_reason IS NOT NULL", user.ID).
+			Update("refund_reason", nil).Error; err != nil {
+			return err
+		}
+		// Expiring prepared exports lets the hourly purge delete their files
+		if err := tx.Model(&domain.DataExport{}).Where("user_id = ?", user.ID).
+			Update("expires_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("cart_id IN (?)", tx.Model(&domain.Cart{}).Select("id").Where("user_id = ?", user.ID)).
+			Delete(&domain.CartItem{}).Error; err != nil {
+			return err
+		}
+
+		personal := []interface{}{
+			&domain.Cart{},
+			&domain.Wishlist{},
+			&domain.LinkedAccount{},
+			&domain.EmailVerificationToken{},
+			&domain.PasswordResetToken{},
+			&domain.AccountDeletionToken{},
+			&domain.PushSubscription{},
+			&domain.UserDevice{},
+			&domain.Notification{},
+			&domain.NotificationPreference{},
+			&domain.NotificationDigest{},
+		}
+		for _, model := range personal {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(entry).Error
+	})
+}
+
 // RefreshToken repository
 type refreshTokenRepository struct {
 	db *gorm.DB
@@ -127,11 +201,46 @@ func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string
 	return &token, nil
 }
 
+func (r *refreshTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListActive returns the user's unrevoked, unexpired tokens, most recently used first
+func (r *refreshTokenRepository) ListActive(ctx context.Context, userID uuid.UUID) ([]domain.RefreshToken, error) {
+	var tokens []domain.RefreshToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
 func (r *refreshTokenRepository) Revoke(ctx context.Context, id uuid.UUID) error {
 	now := time.Now()
 	return r.db.WithContext(ctx).Model(&domain.RefreshToken{}).Where("id = ?", id).Update("revoked_at", now).Error
 }
 
+// Replace revokes a token spent on a refresh, recording the token issued for
+// it. Only a token that is still live is replaced, so of two refreshes racing
+// with the same token just one succeeds.
+func (r *refreshTokenRepository) Replace(ctx context.Context, id, replacedBy uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":  time.Now(),
+			"replaced_by": replacedBy,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
 func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
 	now := time.Now()
 	return r.db.WithContext(ctx).Model(&domain.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now).Error
@@ -140,3 +249,205 @@ func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uu
 func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) error {
 	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&domain.RefreshToken{}).Error
 }
+
+// EmailVerification repository
+type emailVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationRepository(db *gorm.DB) repository.EmailVerificationRepository {
+	return &emailVerificationRepository{db: db}
+}
+
+func (r *emailVerificationRepository) Create(ctx context.Context, token *domain.EmailVerificationToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *emailVerificationRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error) {
+	var token domain.EmailVerificationToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrVerificationInvalid
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *emailVerificationRepository) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.EmailVerificationToken{}).Error
+}
+
+// PasswordReset repository
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) repository.PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *passwordResetRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	var token domain.PasswordResetToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrResetTokenInvalid
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.PasswordResetToken{}).Error
+}
+
+// AccountDeletion repository
+type accountDeletionRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountDeletionRepository(db *gorm.DB) repository.AccountDeletionRepository {
+	return &accountDeletionRepository{db: db}
+}
+
+func (r *accountDeletionRepository) Create(ctx context.Context, token *domain.AccountDeletionToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *accountDeletionRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.AccountDeletionToken, error) {
+	var token domain.AccountDeletionToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrDeletionTokenInvalid
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *accountDeletionRepository) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.AccountDeletionToken{}).Error
+}
+
+// LinkedAccount repository
+type linkedAccountRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkedAccountRepository(db *gorm.DB) repository.LinkedAccountRepository {
+	return &linkedAccountRepository{db: db}
+}
+
+func (r *linkedAccountRepository) Create(ctx context.Context, account *domain.LinkedAccount) error {
+	return r.db.WithContext(ctx).Create(account).Error
+}
+
+// GetByProviderUser returns nil when the provider account isn't linked yet
+func (r *linkedAccountRepository) GetByProviderUser(ctx context.Context, provider domain.AuthProvider, providerUserID string) (*domain.LinkedAccount, error) {
+	var account domain.LinkedAccount
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND provider_user_id = ?", provider, providerUserID).
+		First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// LoginAttempt repository
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) repository.LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) Create(ctx context.Context, attempt *domain.LoginAttempt) error {
+	return r.db.WithContext(ctx).Create(attempt).Error
+}
+
+// GetRecent returns the email's failed logins since the given time, newest first
+func (r *loginAttemptRepository) GetRecent(ctx context.Context, email string, since time.Time) ([]domain.LoginAttempt, error) {
+	var attempts []domain.LoginAttempt
+	err := r.db.WithContext(ctx).
+		Where("email = ? AND created_at > ?", email, since).
+		Order("created_at DESC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *loginAttemptRepository) DeleteForEmail(ctx context.Context, email string) error {
+	return r.db.WithContext(ctx).Where("email = ?", email).Delete(&domain.LoginAttempt{}).Error
+}
+
+func (r *loginAttemptRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&domain.LoginAttempt{}).Error
+}
+
+// DataExport repository
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+// NewDataExportRepository creates a new data export repository
+func NewDataExportRepository(db *gorm.DB) repository.DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *domain.DataExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *dataExportRepository) Update(ctx context.Context, export *domain.DataExport) error {
+	return r.db.WithContext(ctx).Save(export).Error
+}
+
+func (r *dataExportRepository) GetPending(ctx context.Context, userID uuid.UUID) (*domain.DataExport, error) {
+	var export domain.DataExport
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status = ?", userID, domain.DataExportPending).
+		First(&export).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.DataExport, error) {
+	var export domain.DataExport
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&export).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrDataExportInvalid
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepository) ListExpired(ctx context.Context, now time.Time) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	err := r.db.WithContext(ctx).
+		Where("expires_at < ? OR (status = ? AND created_at < ?)", now, domain.DataExportFailed, now.Add(-24*time.Hour)).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *dataExportRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&domain.DataExport{}, "id = ?", id).Error
+}
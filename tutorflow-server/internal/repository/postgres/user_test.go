@@ -0,0 +1,39 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/testdb"
+	repo "github.com/tutorflow/tutorflow-server/internal/repository/postgres"
+)
+
+func TestUserRepository_Anonymize_SignsOut(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	r := repo.NewUserRepository(db)
+
+	user := newUser(t, db)
+	session := &domain.RefreshToken{UserID: user.ID, TokenHash: uuid.NewString(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, db.Create(session).Error)
+	require.NoError(t, db.Create(&domain.AccountDeletionToken{
+		UserID: user.ID, TokenHash: uuid.NewString(), ExpiresAt: time.Now().Add(time.Hour),
+	}).Error)
+
+	entry := domain.NewAuditLog(user.ID, domain.AuditUserDelete, "user", user.ID, nil, nil)
+	user.Anonymize("x")
+	require.NoError(t, r.Anonymize(ctx, user, entry))
+
+	require.NoError(t, db.First(session, "id = ?", session.ID).Error)
+	assert.NotNil(t, session.RevokedAt, "every session is revoked")
+
+	var links int64
+	require.NoError(t, db.Model(&domain.AccountDeletionToken{}).Where("user_id = ?", user.ID).Count(&links).Error)
+	assert.Zero(t, links, "the deletion link is used up")
+}
@@ -2,10 +2,12 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
@@ -51,6 +53,96 @@ func (r *videoRepository) DeleteAsset(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&domain.HLSVideoAsset{}, "id = ?", id).Error
 }
 
+// Job methods
+
+// EnqueueJob queues a video for transcoding, resetting any earlier job for it
+func (r *videoRepository) EnqueueJob(ctx context.Context, videoID uuid.UUID) error {
+	now := time.Now()
+	job := &domain.VideoJob{VideoID: videoID, Status: domain.VideoJobPending, RunAfter: now}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "video_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"status":       domain.VideoJobPending,
+			"attempts":     0,
+			"last_error":   "",
+			"run_after":    now,
+			"heartbeat_at": nil,
+			"started_at":   nil,
+			"completed_at": nil,
+			"updated_at":   now,
+		}),
+	}).Create(job).Error
+}
+
+// ClaimJob marks the next due job as running and returns it, or nil when
+// there is nothing to do. Due jobs are pending ones past their retry delay
+// and running ones whose heartbeat is older than staleBefore. Row locks are
+// skipped so several workers can claim concurrently.
+func (r *videoRepository) ClaimJob(ctx context.Context, staleBefore time.Time) (*domain.VideoJob, error) {
+	var claimed *domain.VideoJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var job domain.VideoJob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_after <= ?) OR (status = ? AND heartbeat_at < ?)",
+				domain.VideoJobPending, now, domain.VideoJobRunning, staleBefore).
+			Order("run_after ASC").
+			First(&job).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		job.Status = domain.VideoJobRunning
+		job.Attempts++
+		job.HeartbeatAt = &now
+		job.StartedAt = &now
+		if err := tx.Save(&job).Error; err != nil {
+			return err
+		}
+		claimed = &job
+		return nil
+	})
+	return claimed, err
+}
+
+func (r *videoRepository) GetJobByVideoID(ctx context.Context, videoID uuid.UUID) (*domain.VideoJob, error) {
+	var job domain.VideoJob
+	err := r.db.WithContext(ctx).First(&job, "video_id = ?", videoID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *videoRepository) UpdateJob(ctx context.Context, job *domain.VideoJob) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+// TouchJob records that a running job is still alive
+func (r *videoRepository) TouchJob(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&domain.VideoJob{}).Where("id = ?", id).Update("heartbeat_at", time.Now()).Error
+}
+
+func (r *videoRepository) DeleteJob(ctx context.Context, videoID uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&domain.VideoJob{}, "video_id = ?", videoID).Error
+}
+
+// EnqueueOrphanedAssets creates jobs for unfinished assets that have none,
+// such as uploads left behind by the old in-process processing
+func (r *videoRepository) EnqueueOrphanedAssets(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		INSERT INTO video_jobs (video_id, status, run_after)
+		SELECT a.id, ?, NOW()
+		FROM hls_video_assets a
+		WHERE a.status IN (?, ?)
+		AND NOT EXISTS (SELECT 1 FROM video_jobs j WHERE j.video_id = a.id)`,
+		domain.VideoJobPending, domain.VideoStatusPending, domain.VideoStatusProcessing)
+	return result.RowsAffected, result.Error
+}
+
 // Quality methods
 func (r *videoRepository) CreateQuality(ctx context.Context, quality *domain.VideoQuality) error {
 	return r.db.WithContext(ctx).Create(quality).Error
@@ -94,14 +186,39 @@ func (r *videoRepository) GetSignedURLByToken(ctx context.Context, token string)
 	return &signedURL, nil
 }
 
-func (r *videoRepository) MarkSignedURLUsed(ctx context.Context, token string) error {
+// GetSignedURLByWatermark returns the latest session issued a watermark code
+func (r *videoRepository) GetSignedURLByWatermark(ctx context.Context, code string) (*domain.SignedURL, error) {
+	var signedURL domain.SignedURL
+	err := r.db.WithContext(ctx).Where("watermark_code = ?", code).Order("created_at DESC").First(&signedURL).Error
+	if err != nil {
+		return nil, err
+	}
+	return &signedURL, nil
+}
+
+// RecordKeyFetch counts a key fetch against a playback session
+func (r *videoRepository) RecordKeyFetch(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&domain.SignedURL{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"key_fetch_count":   gorm.Expr("key_fetch_count + 1"),
+		"last_key_fetch_at": now,
+		"used_at":           gorm.Expr("COALESCE(used_at, ?)", now),
+	}).Error
+}
+
+// ExpireDeviceSignedURLs ends every live playback session on a device. Rows
+// are kept rather than deleted so their watermark codes stay traceable.
+func (r *videoRepository) ExpireDeviceSignedURLs(ctx context.Context, userID uuid.UUID, deviceID string) error {
 	now := time.Now()
-	// Using map to force update even if zero value (though used_at is pointer so it's fine)
-	return r.db.WithContext(ctx).Model(&domain.SignedURL{}).Where("token = ?", token).Update("used_at", now).Error
+	return r.db.WithContext(ctx).Model(&domain.SignedURL{}).
+		Where("user_id = ? AND device_id = ? AND expires_at > ?", userID, deviceID, now).
+		Update("expires_at", now).Error
 }
 
-func (r *videoRepository) CleanupExpiredURLs(ctx context.Context) error {
-	return r.db.WithContext(ctx).Delete(&domain.SignedURL{}, "expires_at < ?", time.Now()).Error
+// DeleteExpiredSignedURLs removes signed URLs that expired before the cutoff
+func (r *videoRepository) DeleteExpiredSignedURLs(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Delete(&domain.SignedURL{}, "expires_at < ?", expiredBefore)
+	return result.RowsAffected, result.Error
 }
 
 // Device Session methods
@@ -137,3 +254,12 @@ func (r *videoRepository) UpdateDeviceSession(ctx context.Context, session *doma
 func (r *videoRepository) DeactivateDeviceSession(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Model(&domain.DeviceSession{}).Where("id = ?", id).Update("is_active", false).Error
 }
+
+// DeactivateStaleDeviceSessions deactivates devices not seen since olderThan
+// so they stop counting against the device limit
+func (r *videoRepository) DeactivateStaleDeviceSessions(ctx context.Context, olderThan time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&domain.DeviceSession{}).
+		Where("is_active = ? AND last_seen_at < ?", true, olderThan).
+		Update("is_active", false)
+	return result.RowsAffected, result.Error
+}
@@ -2,11 +2,20 @@ package email
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
 	"net/smtp"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/tracing"
 )
 
 // Service handles email sending
@@ -35,7 +44,7 @@ type EmailData struct {
 }
 
 // Send sends an email
-func (s *Service) Send(to, subject, body string) error {
+func (s *Service) Send(ctx context.Context, to, subject, body string) error {
 	if s.cfg.SMTPHost == "" {
 		// Skip if email not configured
 		return nil
@@ -44,14 +53,11 @@ func (s *Service) Send(to, subject, body string) error {
 	from := s.cfg.FromEmail
 	msg := s.buildMessage(from, to, subject, body)
 
-	auth := smtp.PlainAuth("", s.cfg.SMTPUser, s.cfg.SMTPPassword, s.cfg.SMTPHost)
-	addr := fmt.Sprintf("%s:%d", s.cfg.SMTPHost, s.cfg.SMTPPort)
-
-	return smtp.SendMail(addr, auth, from, []string{to}, msg)
+	return s.sendMail(ctx, from, to, msg)
 }
 
 // SendHTML sends an HTML email
-func (s *Service) SendHTML(to, subject, htmlBody string) error {
+func (s *Service) SendHTML(ctx context.Context, to, subject, htmlBody string) error {
 	if s.cfg.SMTPHost == "" {
 		return nil
 	}
@@ -59,10 +65,27 @@ func (s *Service) SendHTML(to, subject, htmlBody string) error {
 	from := s.cfg.FromEmail
 	msg := s.buildHTMLMessage(from, to, subject, htmlBody)
 
+	return s.sendMail(ctx, from, to, msg)
+}
+
+// sendMail delivers msg over SMTP in a span under ctx, so a send shows in the
+// trace of the request or job that asked for it
+func (s *Service) sendMail(ctx context.Context, from, to string, msg []byte) error {
+	_, span := otel.Tracer(tracing.ServiceName).Start(ctx, "smtp.SendMail",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("server.address", s.cfg.SMTPHost)),
+	)
+	defer span.End()
+
 	auth := smtp.PlainAuth("", s.cfg.SMTPUser, s.cfg.SMTPPassword, s.cfg.SMTPHost)
 	addr := fmt.Sprintf("%s:%d", s.cfg.SMTPHost, s.cfg.SMTPPort)
 
-	return smtp.SendMail(addr, auth, from, []string{to}, msg)
+	err := smtp.SendMail(addr, auth, from, []string{to}, msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "send failed")
+	}
+	return err
 }
 
 func (s *Service) buildMessage(from, to, subject, body string) []byte {
@@ -99,12 +122,19 @@ func (s *Service) loadTemplates() {
 	s.templates["certificate"] = template.Must(template.New("certificate").Parse(certificateTemplate))
 	s.templates["assignment"] = template.Must(template.New("assignment").Parse(assignmentTemplate))
 	s.templates["grade"] = template.Must(template.New("grade").Parse(gradeTemplate))
+	s.templates["digest"] = template.Must(template.New("digest").Parse(digestTemplate))
+	s.templates["verify_email"] = template.Must(template.New("verify_email").Parse(verifyEmailTemplate))
+	s.templates["account_locked"] = template.Must(template.New("account_locked").Parse(accountLockedTemplate))
+	s.templates["data_export"] = template.Must(template.New("data_export").Parse(dataExportTemplate))
+	s.templates["account_deletion"] = template.Must(template.New("account_deletion").Parse(accountDeletionTemplate))
+	s.templates["nudge"] = template.Must(template.New("nudge").Parse(nudgeTemplate))
+	s.templates["announcement"] = template.Must(template.New("announcement").Parse(announcementTemplate))
 }
 
 // --- Pre-built Email Methods ---
 
 // SendWelcome sends welcome email to new user
-func (s *Service) SendWelcome(to, name string) error {
+func (s *Service) SendWelcome(ctx context.Context, to, name string) error {
 	data := map[string]interface{}{
 		"Name":        name,
 		"CompanyName": s.cfg.FromName,
@@ -113,11 +143,11 @@ func (s *Service) SendWelcome(to, name string) error {
 	if err != nil {
 		return err
 	}
-	return s.SendHTML(to, "Welcome to TutorFlow!", body)
+	return s.SendHTML(ctx, to, "Welcome to TutorFlow!", body)
 }
 
 // SendPasswordReset sends password reset email
-func (s *Service) SendPasswordReset(to, name, resetURL string) error {
+func (s *Service) SendPasswordReset(ctx context.Context, to, name, resetURL string) error {
 	data := map[string]interface{}{
 		"Name":        name,
 		"ResetURL":    resetURL,
@@ -127,11 +157,82 @@ func (s *Service) SendPasswordReset(to, name, resetURL string) error {
 	if err != nil {
 		return err
 	}
-	return s.SendHTML(to, "Reset Your Password", body)
+	return s.SendHTML(ctx, to, "Reset Your Password", body)
+}
+
+// SendEmailVerification asks a new user to confirm their email address
+func (s *Service) SendEmailVerification(ctx context.Context, to, name, verifyURL string) error {
+	data := map[string]interface{}{
+		"Name":        name,
+		"VerifyURL":   verifyURL,
+		"CompanyName": s.cfg.FromName,
+	}
+	body, err := s.renderTemplate("verify_email", data)
+	if err != nil {
+		return err
+	}
+	return s.SendHTML(ctx, to, "Verify Your Email", body)
+}
+
+// SendAccountSetup invites a user whose account was created for them to choose
+// a password, linking to the forgot-password page with their email filled in
+func (s *Service) SendAccountSetup(ctx context.Context, to, name string) error {
+	return s.SendPasswordReset(ctx, to, name, s.AppLink("/forgot-password?email="+url.QueryEscape(to)))
+}
+
+// SendAccountLocked tells a user their account was locked after repeated
+// failed logins, linking to the forgot-password page in case it wasn't them
+func (s *Service) SendAccountLocked(ctx context.Context, to, name, unlockAt string) error {
+	data := map[string]interface{}{
+		"Name":        name,
+		"UnlockAt":    unlockAt,
+		"ResetURL":    s.AppLink("/forgot-password?email=" + url.QueryEscape(to)),
+		"CompanyName": s.cfg.FromName,
+	}
+	body, err := s.renderTemplate("account_locked", data)
+	if err != nil {
+		return err
+	}
+	return s.SendHTML(ctx, to, "Your Account Has Been Locked", body)
+}
+
+// SendDataExportReady links the user to the copy of their data they asked for
+func (s *Service) SendDataExportReady(ctx context.Context, to, name, downloadURL, expiresAt string) error {
+	data := map[string]interface{}{
+		"Name":        name,
+		"DownloadURL": downloadURL,
+		"ExpiresAt":   expiresAt,
+		"CompanyName": s.cfg.FromName,
+	}
+	body, err := s.renderTemplate("data_export", data)
+	if err != nil {
+		return err
+	}
+	return s.SendHTML(ctx, to, "Your Data Export Is Ready", body)
+}
+
+// SendAccountDeletion links the user to the page that confirms deleting
+// their account
+func (s *Service) SendAccountDeletion(ctx context.Context, to, name, confirmURL string) error {
+	data := map[string]interface{}{
+		"Name":        name,
+		"ConfirmURL":  confirmURL,
+		"CompanyName": s.cfg.FromName,
+	}
+	body, err := s.renderTemplate("account_deletion", data)
+	if err != nil {
+		return err
+	}
+	return s.SendHTML(ctx, to, "Confirm Deleting Your Account", body)
+}
+
+// AppLink builds an absolute link to a page of the web app
+func (s *Service) AppLink(path string) string {
+	return strings.TrimRight(s.cfg.AppURL, "/") + path
 }
 
 // SendEnrollmentConfirmation sends enrollment confirmation
-func (s *Service) SendEnrollmentConfirmation(to, name, courseName, courseURL string) error {
+func (s *Service) SendEnrollmentConfirmation(ctx context.Context, to, name, courseName, courseURL string) error {
 	data := map[string]interface{}{
 		"Name":        name,
 		"CourseName":  courseName,
@@ -142,11 +243,50 @@ func (s *Service) SendEnrollmentConfirmation(to, name, courseName, courseURL str
 	if err != nil {
 		return err
 	}
-	return s.SendHTML(to, fmt.Sprintf("You're enrolled in %s!", courseName), body)
+	return s.SendHTML(ctx, to, fmt.Sprintf("You're enrolled in %s!", courseName), body)
+}
+
+// SendCourseNudge encourages a learner who has stalled to pick a course back
+// up. message is an optional note from the instructor.
+func (s *Service) SendCourseNudge(ctx context.Context, to, name, courseName, courseURL, message string) error {
+	data := map[string]interface{}{
+		"Name":        name,
+		"CourseName":  courseName,
+		"CourseURL":   courseURL,
+		"Message":     message,
+		"CompanyName": s.cfg.FromName,
+	}
+	body, err := s.renderTemplate("nudge", data)
+	if err != nil {
+		return err
+	}
+	return s.SendHTML(ctx, to, fmt.Sprintf("Pick up where you left off in %s", courseName), body)
+}
+
+// SendAnnouncement emails an announcement. courseName is empty for
+// platform-wide announcements.
+func (s *Service) SendAnnouncement(ctx context.Context, to, name, courseName, title, content, link string) error {
+	data := map[string]interface{}{
+		"Name":        name,
+		"CourseName":  courseName,
+		"Title":       title,
+		"Content":     content,
+		"Link":        link,
+		"CompanyName": s.cfg.FromName,
+	}
+	body, err := s.renderTemplate("announcement", data)
+	if err != nil {
+		return err
+	}
+	subject := title
+	if courseName != "" {
+		subject = fmt.Sprintf("%s: %s", courseName, title)
+	}
+	return s.SendHTML(ctx, to, subject, body)
 }
 
 // SendPaymentReceipt sends payment receipt
-func (s *Service) SendPaymentReceipt(to, name, orderNumber string, amount float64, items []string) error {
+func (s *Service) SendPaymentReceipt(ctx context.Context, to, name, orderNumber string, amount float64, items []string) error {
 	data := map[string]interface{}{
 		"Name":        name,
 		"OrderNumber": orderNumber,
@@ -158,11 +298,11 @@ func (s *Service) SendPaymentReceipt(to, name, orderNumber string, amount float6
 	if err != nil {
 		return err
 	}
-	return s.SendHTML(to, fmt.Sprintf("Payment Receipt - Order %s", orderNumber), body)
+	return s.SendHTML(ctx, to, fmt.Sprintf("Payment Receipt - Order %s", orderNumber), body)
 }
 
 // SendCertificate sends certificate notification
-func (s *Service) SendCertificate(to, name, courseName, certNumber, verifyURL string) error {
+func (s *Service) SendCertificate(ctx context.Context, to, name, courseName, certNumber, verifyURL string) error {
 	data := map[string]interface{}{
 		"Name":            name,
 		"CourseName":      courseName,
@@ -174,11 +314,11 @@ func (s *Service) SendCertificate(to, name, courseName, certNumber, verifyURL st
 	if err != nil {
 		return err
 	}
-	return s.SendHTML(to, fmt.Sprintf("Congratulations! Certificate for %s", courseName), body)
+	return s.SendHTML(ctx, to, fmt.Sprintf("Congratulations! Certificate for %s", courseName), body)
 }
 
 // SendAssignmentDue sends assignment reminder
-func (s *Service) SendAssignmentDue(to, name, assignmentTitle, courseName, dueDate string) error {
+func (s *Service) SendAssignmentDue(ctx context.Context, to, name, assignmentTitle, courseName, dueDate string) error {
 	data := map[string]interface{}{
 		"Name":            name,
 		"AssignmentTitle": assignmentTitle,
@@ -190,11 +330,11 @@ func (s *Service) SendAssignmentDue(to, name, assignmentTitle, courseName, dueDa
 	if err != nil {
 		return err
 	}
-	return s.SendHTML(to, fmt.Sprintf("Assignment Due: %s", assignmentTitle), body)
+	return s.SendHTML(ctx, to, fmt.Sprintf("Assignment Due: %s", assignmentTitle), body)
 }
 
 // SendGradePosted sends grade notification
-func (s *Service) SendGradePosted(to, name, itemTitle, courseName string, score float64, maxScore float64) error {
+func (s *Service) SendGradePosted(ctx context.Context, to, name, itemTitle, courseName string, score float64, maxScore float64) error {
 	data := map[string]interface{}{
 		"Name":        name,
 		"ItemTitle":   itemTitle,
@@ -208,7 +348,39 @@ func (s *Service) SendGradePosted(to, name, itemTitle, courseName string, score
 	if err != nil {
 		return err
 	}
-	return s.SendHTML(to, fmt.Sprintf("Grade Posted: %s", itemTitle), body)
+	return s.SendHTML(ctx, to, fmt.Sprintf("Grade Posted: %s", itemTitle), body)
+}
+
+// DigestItem is one notification listed in a digest email
+type DigestItem struct {
+	Title   string
+	Message string
+	Link    string
+	Time    string
+}
+
+// SendDigest sends a batch of notifications as one email. more is how many
+// further notifications were left out of the list.
+func (s *Service) SendDigest(ctx context.Context, to, name, period string, items []DigestItem, more int) error {
+	data := map[string]interface{}{
+		"Name":        name,
+		"Period":      period,
+		"Items":       items,
+		"More":        more,
+		"InboxURL":    s.AppLink("/notifications"),
+		"SettingsURL": s.AppLink("/settings/notifications"),
+		"CompanyName": s.cfg.FromName,
+	}
+	body, err := s.renderTemplate("digest", data)
+	if err != nil {
+		return err
+	}
+	total := len(items) + more
+	subject := fmt.Sprintf("Your %s summary: %d new notification", period, total)
+	if total != 1 {
+		subject += "s"
+	}
+	return s.SendHTML(ctx, to, subject, body)
 }
 
 // renderTemplate renders an email template
@@ -514,3 +686,247 @@ const gradeTemplate = `
 </body>
 </html>
 `
+
+const digestTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8">
+  <style>
+    body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; margin: 0; padding: 0; background: #f3f4f6; }
+    .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+    .header { background: linear-gradient(135deg, #6366f1 0%, #8b5cf6 100%); color: white; padding: 30px; text-align: center; border-radius: 8px 8px 0 0; }
+    .content { background: #fff; padding: 30px; border: 1px solid #e5e7eb; }
+    .button { display: inline-block; background: #6366f1; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 20px 0; }
+    .footer { background: #f9fafb; padding: 20px; text-align: center; font-size: 12px; color: #6b7280; border-radius: 0 0 8px 8px; border: 1px solid #e5e7eb; border-top: none; }
+    .item { border-bottom: 1px solid #e5e7eb; padding: 12px 0; }
+    .item:last-child { border-bottom: none; }
+    .item small { color: #6b7280; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <h1>Your {{.Period}} summary</h1>
+    </div>
+    <div class="content">
+      <h2>Hi {{.Name}},</h2>
+      <p>Here's what happened since your last summary:</p>
+      {{range .Items}}
+      <div class="item">
+        <strong>{{if .Link}}<a href="{{.Link}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</strong>
+        {{if .Message}}<p>{{.Message}}</p>{{end}}
+        <small>{{.Time}}</small>
+      </div>
+      {{end}}
+      {{if .More}}<p>…and {{.More}} more.</p>{{end}}
+      <a href="{{.InboxURL}}" class="button">View All Notifications</a>
+      <p><small>You're receiving this summary instead of individual emails. <a href="{{.SettingsURL}}">Change how often</a>.</small></p>
+    </div>
+    <div class="footer">
+      <p>© 2024 {{.CompanyName}}. All rights reserved.</p>
+    </div>
+  </div>
+</body>
+</html>
+`
+
+const accountLockedTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8">
+  <style>
+    body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; margin: 0; padding: 0; background: #f3f4f6; }
+    .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+    .header { background: #ef4444; color: white; padding: 30px; text-align: center; border-radius: 8px 8px 0 0; }
+    .content { background: #fff; padding: 30px; border: 1px solid #e5e7eb; }
+    .button { display: inline-block; background: #ef4444; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 20px 0; }
+    .footer { background: #f9fafb; padding: 20px; text-align: center; font-size: 12px; color: #6b7280; border-radius: 0 0 8px 8px; border: 1px solid #e5e7eb; border-top: none; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <h1>Account Locked</h1>
+    </div>
+    <div class="content">
+      <h2>Hi {{.Name}},</h2>
+      <p>There were several failed attempts to sign in to your account, so we've locked it until {{.UnlockAt}}.</p>
+      <p>If that was you, you can try again after then. If it wasn't, someone may be trying to guess your password and we recommend choosing a new one:</p>
+      <a href="{{.ResetURL}}" class="button">Reset Password</a>
+    </div>
+    <div class="footer">
+      <p>© 2024 {{.CompanyName}}. All rights reserved.</p>
+    </div>
+  </div>
+</body>
+</html>
+`
+
+const verifyEmailTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8">
+  <style>
+    body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; margin: 0; padding: 0; background: #f3f4f6; }
+    .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+    .header { background: #4f46e5; color: white; padding: 30px; text-align: center; border-radius: 8px 8px 0 0; }
+    .content { background: #fff; padding: 30px; border: 1px solid #e5e7eb; }
+    .button { display: inline-block; background: #4f46e5; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 20px 0; }
+    .footer { background: #f9fafb; padding: 20px; text-align: center; font-size: 12px; color: #6b7280; border-radius: 0 0 8px 8px; border: 1px solid #e5e7eb; border-top: none; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <h1>Verify Your Email</h1>
+    </div>
+    <div class="content">
+      <h2>Hi {{.Name}},</h2>
+      <p>Please confirm this is your email address so you can buy courses and create your own:</p>
+      <a href="{{.VerifyURL}}" class="button">Verify Email</a>
+      <p><small>If you didn't create an account, you can safely ignore this email.</small></p>
+    </div>
+    <div class="footer">
+      <p>© 2024 {{.CompanyName}}. All rights reserved.</p>
+    </div>
+  </div>
+</body>
+</html>
+`
+
+const dataExportTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8">
+  <style>
+    body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; margin: 0; padding: 0; background: #f3f4f6; }
+    .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+    .header { background: #4f46e5; color: white; padding: 30px; text-align: center; border-radius: 8px 8px 0 0; }
+    .content { background: #fff; padding: 30px; border: 1px solid #e5e7eb; }
+    .button { display: inline-block; background: #4f46e5; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 20px 0; }
+    .footer { background: #f9fafb; padding: 20px; text-align: center; font-size: 12px; color: #6b7280; border-radius: 0 0 8px 8px; border: 1px solid #e5e7eb; border-top: none; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <h1>Your Data Export</h1>
+    </div>
+    <div class="content">
+      <h2>Hi {{.Name}},</h2>
+      <p>The copy of your personal data you requested is ready. The link works until {{.ExpiresAt}}:</p>
+      <a href="{{.DownloadURL}}" class="button">Download My Data</a>
+      <p><small>If you didn't ask for this, please contact support.</small></p>
+    </div>
+    <div class="footer">
+      <p>© 2024 {{.CompanyName}}. All rights reserved.</p>
+    </div>
+  </div>
+</body>
+</html>
+`
+
+const accountDeletionTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8">
+  <style>
+    body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; margin: 0; padding: 0; background: #f3f4f6; }
+    .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+    .header { background: #ef4444; color: white; padding: 30px; text-align: center; border-radius: 8px 8px 0 0; }
+    .content { background: #fff; padding: 30px; border: 1px solid #e5e7eb; }
+    .button { display: inline-block; background: #ef4444; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 20px 0; }
+    .footer { background: #f9fafb; padding: 20px; text-align: center; font-size: 12px; color: #6b7280; border-radius: 0 0 8px 8px; border: 1px solid #e5e7eb; border-top: none; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <h1>Delete Your Account</h1>
+    </div>
+    <div class="content">
+      <h2>Hi {{.Name}},</h2>
+      <p>We received a request to delete your account. Your personal details will be removed and you will lose access to your courses. Click the button below to confirm:</p>
+      <a href="{{.ConfirmURL}}" class="button">Delete My Account</a>
+      <p><small>This link will expire in 1 hour. If you didn't request this, you can safely ignore this email and your account will stay as it is.</small></p>
+    </div>
+    <div class="footer">
+      <p>© 2024 {{.CompanyName}}. All rights reserved.</p>
+    </div>
+  </div>
+</body>
+</html>
+`
+
+const nudgeTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8">
+  <style>
+    body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; margin: 0; padding: 0; background: #f3f4f6; }
+    .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+    .header { background: #4f46e5; color: white; padding: 30px; text-align: center; border-radius: 8px 8px 0 0; }
+    .content { background: #fff; padding: 30px; border: 1px solid #e5e7eb; }
+    .note { background: #f9fafb; border-left: 4px solid #4f46e5; padding: 12px 16px; margin: 16px 0; }
+    .button { display: inline-block; background: #4f46e5; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 20px 0; }
+    .footer { background: #f9fafb; padding: 20px; text-align: center; font-size: 12px; color: #6b7280; border-radius: 0 0 8px 8px; border: 1px solid #e5e7eb; border-top: none; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <h1>We Miss You!</h1>
+    </div>
+    <div class="content">
+      <h2>Hi {{.Name}},</h2>
+      <p>It's been a while since you worked on <strong>{{.CourseName}}</strong>. A few minutes today is all it takes to get going again.</p>
+      {{if .Message}}<div class="note">{{.Message}}</div>{{end}}
+      <a href="{{.CourseURL}}" class="button">Continue Learning</a>
+    </div>
+    <div class="footer">
+      <p>© 2024 {{.CompanyName}}. All rights reserved.</p>
+    </div>
+  </div>
+</body>
+</html>
+`
+
+const announcementTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8">
+  <style>
+    body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; margin: 0; padding: 0; background: #f3f4f6; }
+    .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+    .header { background: #4f46e5; color: white; padding: 30px; text-align: center; border-radius: 8px 8px 0 0; }
+    .content { background: #fff; padding: 30px; border: 1px solid #e5e7eb; }
+    .announcement { white-space: pre-line; }
+    .button { display: inline-block; background: #4f46e5; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 20px 0; }
+    .footer { background: #f9fafb; padding: 20px; text-align: center; font-size: 12px; color: #6b7280; border-radius: 0 0 8px 8px; border: 1px solid #e5e7eb; border-top: none; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <h1>{{if .CourseName}}{{.CourseName}}{{else}}Announcement{{end}}</h1>
+    </div>
+    <div class="content">
+      <h2>Hi {{.Name}},</h2>
+      <h3>{{.Title}}</h3>
+      <p class="announcement">{{.Content}}</p>
+      <a href="{{.Link}}" class="button">View Announcement</a>
+    </div>
+    <div class="footer">
+      <p>© 2024 {{.CompanyName}}. All rights reserved.</p>
+    </div>
+  </div>
+</body>
+</html>
+`
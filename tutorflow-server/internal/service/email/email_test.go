@@ -0,0 +1,40 @@
+package email_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
+)
+
+func TestSend_TracedUnderCaller(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	// A port nothing listens on, so the send fails straight away
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	svc := email.NewService(config.EmailConfig{SMTPHost: "127.0.0.1", SMTPPort: port, FromEmail: "noreply@example.com"})
+	ctx, request := provider.Tracer("test").Start(context.Background(), "POST /auth/forgot-password")
+	assert.Error(t, svc.Send(ctx, "sam@example.com", "Hello", "Hi"))
+	request.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "smtp.SendMail", spans[0].Name())
+	assert.Equal(t, request.SpanContext().SpanID(), spans[0].Parent().SpanID(), "the send is part of the request's trace")
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// UserData is everything held about a user, as handed over on a data export
+// request
+type UserData struct {
+	ExportedAt   time.Time             `json:"exported_at"`
+	Profile      domain.User           `json:"profile"`
+	TutorProfile *domain.TutorProfile  `json:"tutor_profile,omitempty"`
+	Enrollments  []domain.Enrollment   `json:"enrollments"`
+	Orders       []domain.Order        `json:"orders"`
+	Reviews      []domain.CourseReview `json:"reviews"`
+	Discussions  []domain.Discussion   `json:"discussions"`
+	Messages     []domain.Message      `json:"messages"`
+	Certificates []domain.Certificate  `json:"certificates"`
+}
+
+// CountUserData returns how many records an export of the user would contain
+func (s *Service) CountUserData(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var total int64
+	for _, q := range []struct {
+		model interface{}
+		where string
+	}{
+		{&domain.Enrollment{}, "user_id = ?"},
+		{&domain.Order{}, "user_id = ?"},
+		{&domain.CourseReview{}, "user_id = ?"},
+		{&domain.Discussion{}, "user_id = ?"},
+		{&domain.Message{}, "sender_id = ?"},
+	} {
+		var n int64
+		if err := s.db.WithContext(ctx).Model(q.model).Where(q.where, userID).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// ExportUserData assembles the user's personal data into one JSON file
+func (s *Service) ExportUserData(ctx context.Context, userID uuid.UUID) (*ExportResult, error) {
+	data := UserData{ExportedAt: time.Now()}
+	db := s.db.WithContext(ctx)
+
+	if err := db.Where("id = ?", userID).First(&data.Profile).Error; err != nil {
+		return nil, err
+	}
+
+	var tutor domain.TutorProfile
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&tutor).Error; err != nil {
+		return nil, err
+	} else if tutor.ID != uuid.Nil {
+		data.TutorProfile = &tutor
+	}
+
+	queries := []*gorm.DB{
+		db.Where("user_id = ?", userID).Order("enrolled_at").Find(&data.Enrollments),
+		db.Preload("Items").Where("user_id = ?", userID).Order("created_at").Find(&data.Orders),
+		db.Where("user_id = ?", userID).Order("created_at").Find(&data.Reviews),
+		db.Where("user_id = ?", userID).Order("created_at").Find(&data.Discussions),
+		db.Preload("Attachments").Where("sender_id = ?", userID).Order("created_at").Find(&data.Messages),
+		db.Joins("JOIN enrollments ON enrollments.id = certificates.enrollment_id").
+			Where("enrollments.user_id = ?", userID).Order("certificates.issued_at").Find(&data.Certificates),
+	}
+	for _, q := range queries {
+		if q.Error != nil {
+			return nil, q.Error
+		}
+	}
+
+	body, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExportResult{
+		Filename:    fmt.Sprintf("tutorflow-data-%s.json", data.ExportedAt.Format("2006-01-02")),
+		ContentType: "application/json",
+		Data:        body,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/tracing"
+)
+
+const (
+	googleAuthURL     = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+)
+
+// Google signs users in with their Google account
+type Google struct {
+	cfg    config.OAuthProviderConfig
+	client *http.Client
+}
+
+// NewGoogle creates a Google provider
+func NewGoogle(cfg config.OAuthProviderConfig) *Google {
+	return &Google{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}}
+}
+
+// AuthCodeURL implements Provider
+func (g *Google) AuthCodeURL(state string) string {
+	params := url.Values{
+		"client_id":     {g.cfg.ClientID},
+		"redirect_uri":  {g.cfg.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+		"prompt":        {"select_account"},
+	}
+	return googleAuthURL + "?" + params.Encode()
+}
+
+// Exchange implements Provider
+func (g *Google) Exchange(ctx context.Context, code string) (*Profile, error) {
+	form := url.Values{
+		"code":          {code},
+		"client_id":     {g.cfg.ClientID},
+		"client_secret": {g.cfg.ClientSecret},
+		"redirect_uri":  {g.cfg.RedirectURL},
+		"grant_type":    {"authorization_code"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := g.do(req, &token); err != nil {
+		return nil, err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, googleUserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+		Picture       string `json:"picture"`
+	}
+	if err := g.do(req, &info); err != nil {
+		return nil, err
+	}
+	if info.Sub == "" {
+		return nil, ErrExchangeFailed
+	}
+
+	return &Profile{
+		ProviderUserID: info.Sub,
+		Email:          info.Email,
+		EmailVerified:  info.EmailVerified,
+		FirstName:      info.GivenName,
+		LastName:       info.FamilyName,
+		AvatarURL:      info.Picture,
+	}, nil
+}
+
+// do sends the request and decodes a successful JSON response into out
+func (g *Google) do(req *http.Request, out interface{}) error {
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: google returned %s", ErrExchangeFailed, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
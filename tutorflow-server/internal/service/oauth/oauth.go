@@ -0,0 +1,29 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrExchangeFailed is returned when the provider rejects the code or the
+// profile can't be read
+var ErrExchangeFailed = errors.New("oauth exchange failed")
+
+// Profile is what a provider tells us about the signed-in user
+type Profile struct {
+	ProviderUserID string
+	Email          string
+	EmailVerified  bool
+	FirstName      string
+	LastName       string
+	AvatarURL      string
+}
+
+// Provider runs the authorization code flow with one identity provider
+type Provider interface {
+	// AuthCodeURL is where to send the browser to sign in. state comes back
+	// unchanged on the callback.
+	AuthCodeURL(state string) string
+	// Exchange trades the code from the callback for the user's profile
+	Exchange(ctx context.Context, code string) (*Profile, error)
+}
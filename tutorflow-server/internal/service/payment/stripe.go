@@ -3,31 +3,47 @@ package payment
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/balance"
 	"github.com/stripe/stripe-go/v76/checkout/session"
 	"github.com/stripe/stripe-go/v76/paymentintent"
+	"github.com/stripe/stripe-go/v76/subscription"
 
 	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/tracing"
 )
 
 // Service handles Stripe payment operations
 type Service struct {
-	secretKey     string
-	webhookSecret string
-	successURL    string
-	cancelURL     string
+	secretKey              string
+	webhookSecret          string
+	successURL             string
+	cancelURL              string
+	subscriptionSuccessURL string
+	subscriptionCancelURL  string
+
+	subscriptionWebhookSecret string
 }
 
 // NewService creates a new payment service
 func NewService(cfg config.StripeConfig) *Service {
 	stripe.Key = cfg.SecretKey
+	// Stripe's default timeout, with calls traced as part of the request
+	stripe.SetHTTPClient(&http.Client{Timeout: 80 * time.Second, Transport: tracing.Transport(nil)})
 	return &Service{
 		secretKey:     cfg.SecretKey,
 		webhookSecret: cfg.WebhookSecret,
 		successURL:    "http://localhost:3000/checkout/success?session_id={CHECKOUT_SESSION_ID}",
 		cancelURL:     "http://localhost:3000/checkout/cancel",
+
+		subscriptionSuccessURL: "http://localhost:3000/dashboard?subscription=success",
+		subscriptionCancelURL:  "http://localhost:3000/pricing",
+
+		subscriptionWebhookSecret: cfg.SubscriptionWebhookSecret,
 	}
 }
 
@@ -102,12 +118,96 @@ func (s *Service) CreateCheckoutSession(ctx context.Context, input CreateCheckou
 		},
 	}
 
+	params.Context = ctx
+
 	return session.New(params)
 }
 
+// SubscriptionCheckoutInput for starting a subscription
+type SubscriptionCheckoutInput struct {
+	CustomerEmail  string
+	PriceID        string // Stripe recurring price
+	SubscriptionID string // ours, echoed back in the subscription's metadata
+}
+
+// CreateSubscriptionCheckout creates a Stripe Checkout session that starts a
+// subscription, returning the URL to send the customer to
+func (s *Service) CreateSubscriptionCheckout(ctx context.Context, input SubscriptionCheckoutInput) (string, error) {
+	metadata := map[string]string{
+		"subscription_id": input.SubscriptionID,
+	}
+	params := &stripe.CheckoutSessionParams{
+		CustomerEmail: stripe.String(input.CustomerEmail),
+		Mode:          stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{Price: stripe.String(input.PriceID), Quantity: stripe.Int64(1)},
+		},
+		SubscriptionData: &stripe.CheckoutSessionSubscriptionDataParams{
+			Metadata: metadata,
+		},
+		SuccessURL: stripe.String(s.subscriptionSuccessURL),
+		CancelURL:  stripe.String(s.subscriptionCancelURL),
+		Metadata:   metadata,
+	}
+	params.Context = ctx
+
+	sess, err := session.New(params)
+	if err != nil {
+		return "", err
+	}
+	return sess.URL, nil
+}
+
+// mutationContext keeps the request's trace but not its cancellation, so a
+// client hanging up mid-call doesn't leave us unsure whether Stripe made a
+// change. The HTTP client's timeout still bounds the call.
+func mutationContext(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// SetCancelAtPeriodEnd schedules a Stripe subscription to end when the paid
+// period does, or undoes that
+func (s *Service) SetCancelAtPeriodEnd(ctx context.Context, stripeSubscriptionID string, cancel bool) error {
+	_, err := subscription.Update(stripeSubscriptionID, &stripe.SubscriptionParams{
+		Params:            stripe.Params{Context: mutationContext(ctx)},
+		CancelAtPeriodEnd: stripe.Bool(cancel),
+	})
+	return err
+}
+
+// CancelSubscription ends a Stripe subscription straight away rather than
+// at the end of the paid period
+func (s *Service) CancelSubscription(ctx context.Context, stripeSubscriptionID string) error {
+	_, err := subscription.Cancel(stripeSubscriptionID, &stripe.SubscriptionCancelParams{
+		Params: stripe.Params{Context: mutationContext(ctx)},
+	})
+	return err
+}
+
+// ChangeSubscriptionPrice moves a Stripe subscription to another price,
+// prorating the current period
+func (s *Service) ChangeSubscriptionPrice(ctx context.Context, stripeSubscriptionID, priceID string) error {
+	sub, err := subscription.Get(stripeSubscriptionID, &stripe.SubscriptionParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return err
+	}
+	if sub.Items == nil || len(sub.Items.Data) == 0 {
+		return fmt.Errorf("stripe subscription %s has no items", stripeSubscriptionID)
+	}
+
+	_, err = subscription.Update(stripeSubscriptionID, &stripe.SubscriptionParams{
+		Params: stripe.Params{Context: mutationContext(ctx)},
+		Items: []*stripe.SubscriptionItemsParams{
+			{ID: stripe.String(sub.Items.Data[0].ID), Price: stripe.String(priceID)},
+		},
+		ProrationBehavior: stripe.String("create_prorations"),
+	})
+	return err
+}
+
 // GetCheckoutSession retrieves a Stripe Checkout session
 func (s *Service) GetCheckoutSession(ctx context.Context, sessionID string) (*stripe.CheckoutSession, error) {
-	return session.Get(sessionID, nil)
+	return session.Get(sessionID, &stripe.CheckoutSessionParams{Params: stripe.Params{Context: ctx}})
 }
 
 // CreatePaymentIntentInput for payment intent
@@ -137,22 +237,24 @@ func (s *Service) CreatePaymentIntent(ctx context.Context, input CreatePaymentIn
 		},
 	}
 
+	params.Context = ctx
+
 	return paymentintent.New(params)
 }
 
 // GetPaymentIntent retrieves a payment intent
 func (s *Service) GetPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
-	return paymentintent.Get(paymentIntentID, nil)
+	return paymentintent.Get(paymentIntentID, &stripe.PaymentIntentParams{Params: stripe.Params{Context: ctx}})
 }
 
 // ConfirmPaymentIntent confirms a payment intent
 func (s *Service) ConfirmPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
-	return paymentintent.Confirm(paymentIntentID, nil)
+	return paymentintent.Confirm(paymentIntentID, &stripe.PaymentIntentConfirmParams{Params: stripe.Params{Context: mutationContext(ctx)}})
 }
 
 // CancelPaymentIntent cancels a payment intent
 func (s *Service) CancelPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
-	return paymentintent.Cancel(paymentIntentID, nil)
+	return paymentintent.Cancel(paymentIntentID, &stripe.PaymentIntentCancelParams{Params: stripe.Params{Context: mutationContext(ctx)}})
 }
 
 // CreateRefund creates a refund for a payment
@@ -163,6 +265,7 @@ func (s *Service) CreateRefund(ctx context.Context, paymentIntentID string, amou
 	if amount > 0 {
 		params.Amount = stripe.Int64(amount)
 	}
+	params.Context = mutationContext(ctx)
 
 	// Use the refund API
 	refund := &stripe.Refund{}
@@ -175,3 +278,15 @@ func (s *Service) CreateRefund(ctx context.Context, paymentIntentID string, amou
 func (s *Service) GetWebhookSecret() string {
 	return s.webhookSecret
 }
+
+// GetSubscriptionWebhookSecret returns the secret for verifying the
+// subscription webhook endpoint, which Stripe signs separately
+func (s *Service) GetSubscriptionWebhookSecret() string {
+	return s.subscriptionWebhookSecret
+}
+
+// Ping checks Stripe is reachable and accepts the secret key
+func (s *Service) Ping(ctx context.Context) error {
+	_, err := balance.Get(&stripe.BalanceParams{Params: stripe.Params{Context: ctx}})
+	return err
+}
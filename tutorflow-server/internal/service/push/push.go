@@ -29,13 +29,15 @@ type Config struct {
 type Service struct {
 	cfg      Config
 	pushRepo repository.PushSubscriptionRepository
+	prefRepo repository.NotificationPreferenceRepository
 }
 
 // NewService creates a new push notification service
-func NewService(cfg Config, pushRepo repository.PushSubscriptionRepository) *Service {
+func NewService(cfg Config, pushRepo repository.PushSubscriptionRepository, prefRepo repository.NotificationPreferenceRepository) *Service {
 	return &Service{
 		cfg:      cfg,
 		pushRepo: pushRepo,
+		prefRepo: prefRepo,
 	}
 }
 
@@ -113,8 +115,14 @@ func (s *Service) GetUserSubscriptions(ctx context.Context, userID uuid.UUID) ([
 	return s.pushRepo.GetByUser(ctx, userID)
 }
 
-// SendToUser sends a push notification to all user's devices
-func (s *Service) SendToUser(ctx context.Context, userID uuid.UUID, notification domain.PushNotification) error {
+// SendToUser sends a push notification to all user's devices, unless the
+// user has turned off push for the category
+func (s *Service) SendToUser(ctx context.Context, userID uuid.UUID, category domain.NotificationCategory, notification domain.PushNotification) error {
+	prefs, _ := s.prefRepo.GetByUser(ctx, userID)
+	if !prefs.Allows(category, domain.NotificationChannelPush) {
+		return nil
+	}
+
 	subs, err := s.pushRepo.GetByUser(ctx, userID)
 	if err != nil {
 		return err
@@ -135,10 +143,10 @@ func (s *Service) SendToUser(ctx context.Context, userID uuid.UUID, notification
 }
 
 // SendToUsers sends a push notification to multiple users
-func (s *Service) SendToUsers(ctx context.Context, userIDs []uuid.UUID, notification domain.PushNotification) error {
+func (s *Service) SendToUsers(ctx context.Context, userIDs []uuid.UUID, category domain.NotificationCategory, notification domain.PushNotification) error {
 	for _, userID := range userIDs {
 		go func(uid uuid.UUID) {
-			_ = s.SendToUser(ctx, uid, notification)
+			_ = s.SendToUser(ctx, uid, category, notification)
 		}(userID)
 	}
 	return nil
@@ -206,7 +214,7 @@ func GenerateVAPIDKeys() (publicKey, privateKey string, err error) {
 
 // NotifyNewMessage sends a notification for a new message
 func (s *Service) NotifyNewMessage(ctx context.Context, userID uuid.UUID, senderName, preview string) error {
-	return s.SendToUser(ctx, userID, domain.PushNotification{
+	return s.SendToUser(ctx, userID, domain.NotificationCategoryMessage, domain.PushNotification{
 		Title: fmt.Sprintf("New message from %s", senderName),
 		Body:  preview,
 		Icon:  "/icons/message.png",
@@ -217,7 +225,7 @@ func (s *Service) NotifyNewMessage(ctx context.Context, userID uuid.UUID, sender
 
 // NotifyNewEnrollment sends a notification for a new enrollment
 func (s *Service) NotifyNewEnrollment(ctx context.Context, userID uuid.UUID, courseName string) error {
-	return s.SendToUser(ctx, userID, domain.PushNotification{
+	return s.SendToUser(ctx, userID, domain.NotificationCategoryEnrollment, domain.PushNotification{
 		Title: "Enrollment Confirmed!",
 		Body:  fmt.Sprintf("You're now enrolled in %s", courseName),
 		Icon:  "/icons/course.png",
@@ -228,7 +236,7 @@ func (s *Service) NotifyNewEnrollment(ctx context.Context, userID uuid.UUID, cou
 
 // NotifyNewGrade sends a notification for a new grade
 func (s *Service) NotifyNewGrade(ctx context.Context, userID uuid.UUID, itemTitle string, score float64) error {
-	return s.SendToUser(ctx, userID, domain.PushNotification{
+	return s.SendToUser(ctx, userID, domain.NotificationCategoryGrade, domain.PushNotification{
 		Title: "Grade Posted",
 		Body:  fmt.Sprintf("%s: %.1f points", itemTitle, score),
 		Icon:  "/icons/grade.png",
@@ -239,7 +247,7 @@ func (s *Service) NotifyNewGrade(ctx context.Context, userID uuid.UUID, itemTitl
 
 // NotifyAnnouncement sends a notification for a new announcement
 func (s *Service) NotifyAnnouncement(ctx context.Context, userID uuid.UUID, title, courseName string) error {
-	return s.SendToUser(ctx, userID, domain.PushNotification{
+	return s.SendToUser(ctx, userID, domain.NotificationCategoryCourse, domain.PushNotification{
 		Title: "New Announcement",
 		Body:  fmt.Sprintf("%s: %s", courseName, title),
 		Icon:  "/icons/announcement.png",
@@ -250,7 +258,7 @@ func (s *Service) NotifyAnnouncement(ctx context.Context, userID uuid.UUID, titl
 
 // NotifyCertificate sends a notification for a new certificate
 func (s *Service) NotifyCertificate(ctx context.Context, userID uuid.UUID, courseName string) error {
-	return s.SendToUser(ctx, userID, domain.PushNotification{
+	return s.SendToUser(ctx, userID, domain.NotificationCategoryEnrollment, domain.PushNotification{
 		Title: "🎉 Certificate Earned!",
 		Body:  fmt.Sprintf("Congratulations! You completed %s", courseName),
 		Icon:  "/icons/certificate.png",
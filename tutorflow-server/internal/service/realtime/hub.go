@@ -0,0 +1,76 @@
+package realtime
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// subscriberBuffer is how many events a slow connection can fall behind
+// before further events to it are dropped
+const subscriberBuffer = 16
+
+// Event is a message pushed to a connected user
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Hub fans events out to the open connections of each user. It only knows
+// about connections to this process.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[uuid.UUID]map[chan Event]struct{}
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uuid.UUID]map[chan Event]struct{})}
+}
+
+// Subscribe opens a connection for the user. The returned function must be
+// called when the connection closes; it closes the channel.
+func (h *Hub) Subscribe(userID uuid.UUID) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan Event]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends the event to every open connection of the user. It never
+// blocks: users with no connection get nothing, and a connection whose
+// buffer is full misses the event.
+func (h *Hub) Publish(userID uuid.UUID, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[userID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Connected reports whether the user has any open connection
+func (h *Hub) Connected(userID uuid.UUID) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs[userID]) > 0
+}
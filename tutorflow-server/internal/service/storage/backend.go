@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// Backend is where the storage service keeps files. Paths are slash-separated
+// and relative to the backend's root, e.g. "images/<name>.png".
+type Backend interface {
+	// Put stores r at path; size is -1 when it isn't known up front
+	Put(ctx context.Context, path string, r io.Reader, size int64, contentType string) error
+	// Open returns the file at path and its content type, or
+	// domain.ErrFileNotFound
+	Open(ctx context.Context, path string) (io.ReadCloser, string, error)
+	Delete(ctx context.Context, path string) error
+	// DeleteAll removes every file under prefix
+	DeleteAll(ctx context.Context, prefix string) error
+	Exists(ctx context.Context, path string) bool
+
+	// PublicURL returns the permanent URL a file can be fetched from
+	// directly, or "" when the app has to serve it
+	PublicURL(path string) string
+	// SignedURL returns a URL that lets anyone fetch a private file until it
+	// expires, or "" if the backend has no such URLs
+	SignedURL(ctx context.Context, path string, expiry time.Duration) (string, error)
+	// SignedPutURL returns a URL a client can PUT a file of exactly size bytes
+	// and contentType to until it expires, or "" if the backend has no such URLs
+	SignedPutURL(ctx context.Context, path, contentType string, size int64, expiry time.Duration) (string, error)
+	// LocalPath returns where a file is on this machine's disk, or "" if the
+	// backend doesn't keep files locally
+	LocalPath(path string) string
+
+	// Ping checks files can be stored
+	Ping(ctx context.Context) error
+}
+
+// localBackend keeps files in a directory on local disk. The app serves them
+// itself, so they are lost with the container unless the directory is a
+// mounted volume.
+type localBackend struct {
+	root string
+}
+
+// NewLocalBackend stores files under root
+func NewLocalBackend(root string) Backend {
+	return &localBackend{root: root}
+}
+
+func (b *localBackend) Put(ctx context.Context, path string, r io.Reader, size int64, contentType string) error {
+	fullPath := b.LocalPath(path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	dst, err := os.Create(fullPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(fullPath)
+	}
+	return err
+}
+
+func (b *localBackend) Open(ctx context.Context, path string) (io.ReadCloser, string, error) {
+	f, err := os.Open(b.LocalPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", domain.ErrFileNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return f, contentTypeOf(path), nil
+}
+
+func (b *localBackend) Delete(ctx context.Context, path string) error {
+	return os.Remove(b.LocalPath(path))
+}
+
+func (b *localBackend) DeleteAll(ctx context.Context, prefix string) error {
+	return os.RemoveAll(b.LocalPath(prefix))
+}
+
+func (b *localBackend) Exists(ctx context.Context, path string) bool {
+	_, err := os.Stat(b.LocalPath(path))
+	return err == nil
+}
+
+func (b *localBackend) PublicURL(path string) string {
+	return ""
+}
+
+func (b *localBackend) SignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
+	return "", nil
+}
+
+func (b *localBackend) SignedPutURL(ctx context.Context, path, contentType string, size int64, expiry time.Duration) (string, error) {
+	return "", nil
+}
+
+// LocalPath keeps paths inside the root, however many ".." they contain
+func (b *localBackend) LocalPath(p string) string {
+	return filepath.Join(b.root, filepath.FromSlash(path.Clean("/"+p)))
+}
+
+func (b *localBackend) Ping(ctx context.Context) error {
+	return os.MkdirAll(b.root, 0755)
+}
+
+// contentTypeOf guesses a file's content type from its extension
+func contentTypeOf(p string) string {
+	switch {
+	case strings.HasSuffix(p, ".m3u8"):
+		return "application/vnd.apple.mpegurl"
+	case strings.HasSuffix(p, ".ts"):
+		return "video/MP2T"
+	}
+	if contentType := mime.TypeByExtension(path.Ext(p)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,101 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/service/storage"
+)
+
+func TestLocalBackend(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	backend := storage.NewLocalBackend(dir)
+
+	t.Run("keeps paths inside the root", func(t *testing.T) {
+		assert.Equal(t, filepath.Join(dir, "etc", "passwd"), backend.LocalPath("../../etc/passwd"))
+		assert.Equal(t, filepath.Join(dir, "images", "a.png"), backend.LocalPath("/images/../images/a.png"))
+	})
+
+	t.Run("reads back what was put", func(t *testing.T) {
+		require.NoError(t, backend.Put(ctx, "videos/hls/1/index.m3u8", strings.NewReader("#EXTM3U"), -1, ""))
+		assert.True(t, backend.Exists(ctx, "videos/hls/1/index.m3u8"))
+
+		stream, contentType, err := backend.Open(ctx, "videos/hls/1/index.m3u8")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(stream)
+		stream.Close()
+		assert.Equal(t, "#EXTM3U", string(data))
+		assert.Equal(t, "application/vnd.apple.mpegurl", contentType)
+
+		require.NoError(t, backend.DeleteAll(ctx, "videos/hls/1"))
+		assert.False(t, backend.Exists(ctx, "videos/hls/1/index.m3u8"))
+	})
+
+	t.Run("reports missing files", func(t *testing.T) {
+		_, _, err := backend.Open(ctx, "images/missing.png")
+		assert.ErrorIs(t, err, domain.ErrFileNotFound)
+	})
+
+	t.Run("has no URLs of its own", func(t *testing.T) {
+		assert.Empty(t, backend.PublicURL("images/a.png"))
+		url, err := backend.SignedURL(ctx, "images/a.png", 0)
+		require.NoError(t, err)
+		assert.Empty(t, url)
+	})
+}
+
+func TestPathFromURL(t *testing.T) {
+	local, _ := newLocalService(t, storage.NopScanner{})
+	cdn := storage.NewServiceWithBackend(config.StorageConfig{CDNBaseURL: "https://cdn.example.com/"},
+		storage.NewLocalBackend(t.TempDir()), storage.NopScanner{})
+
+	tests := []struct {
+		name string
+		svc  *storage.Service
+		url  string
+		path string
+		ok   bool
+	}{
+		{"served by the app", local, "/uploads/images/a.png", "images/a.png", true},
+		{"stored before /uploads", local, "/images/a.png", "images/a.png", true},
+		{"elsewhere", local, "https://example.com/a.png", "", false},
+		{"protocol-relative", local, "//example.com/a.png", "", false},
+		{"through the CDN", cdn, "https://cdn.example.com/images/a.png", "images/a.png", true},
+		{"not through the CDN", cdn, "https://example.com/images/a.png", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, ok := tt.svc.PathFromURL(tt.url)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.path, path)
+		})
+	}
+}
+
+func TestUploadHLSFiles(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newLocalService(t, storage.NopScanner{})
+
+	out := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(out, "index.m3u8"), []byte("#EXTM3U"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(out, "segment_000.ts"), []byte("segment"), 0644))
+
+	require.NoError(t, svc.UploadHLSFiles(ctx, out, "videos/hls/1"))
+
+	stream, contentType, err := svc.GetFileStream(ctx, "videos/hls/1/segment_000.ts")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(stream)
+	stream.Close()
+	assert.Equal(t, "segment", string(data))
+	assert.Equal(t, "video/MP2T", contentType)
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif" // decoders for the image formats uploads accept
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// ImageVariant is a resized WebP copy made of every uploaded image
+type ImageVariant struct {
+	Name  string
+	Width int // images narrower than this keep their own width
+}
+
+// ImageVariants are the sizes made of each uploaded image. Each is stored
+// next to the original as <original name>-<variant>.webp.
+var ImageVariants = []ImageVariant{
+	{Name: "thumbnail", Width: 200},
+	{Name: "card", Width: 640},
+	{Name: "full", Width: 1600},
+}
+
+// maxImagePixels caps the images decoded for resizing. Decoding holds every
+// pixel in memory, 64 MB at this size, so a small file can't expand into
+// gigabytes and a few uploads at once stay affordable. It still fits a 12 MP
+// phone photo or a 4K frame.
+const maxImagePixels = 16_000_000
+
+// Image is an uploaded image and its resized variants
+type Image struct {
+	URL      string            `json:"url"`      // the original, as uploaded
+	Variants map[string]string `json:"variants"` // variant name -> URL
+}
+
+// StreamImage stores an uploaded image like Stream, along with a WebP copy in
+// each of the ImageVariants sizes. Files that don't decode as an image are
+// rejected.
+func (s *Service) StreamImage(ctx context.Context, folder, filename, contentType string, r io.Reader) (*Image, error) {
+	var img image.Image
+	decode := func(r io.Reader) error {
+		var head bytes.Buffer
+		cfg, _, err := image.DecodeConfig(io.TeeReader(r, &head))
+		if err != nil {
+			return fmt.Errorf("%w: not a readable image", domain.ErrFileContentMismatch)
+		}
+		if cfg.Width*cfg.Height > maxImagePixels {
+			return fmt.Errorf("%w: max %d megapixels", domain.ErrFileTooLarge, maxImagePixels/1_000_000)
+		}
+		img, _, err = image.Decode(io.MultiReader(&head, r))
+		if err != nil {
+			return fmt.Errorf("%w: not a readable image", domain.ErrFileContentMismatch)
+		}
+		return nil
+	}
+
+	path, err := s.store(ctx, KindImage, folder, filename, contentType, r, decode)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := []string{path}
+	result := &Image{URL: s.publicURL(path), Variants: make(map[string]string, len(ImageVariants))}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, variant := range ImageVariants {
+		var buf bytes.Buffer
+		err := nativewebp.Encode(&buf, resize(img, variant.Width), nil)
+		variantPath := base + "-" + variant.Name + ".webp"
+		if err == nil {
+			err = s.backend.Put(ctx, variantPath, &buf, int64(buf.Len()), "image/webp")
+		}
+		if err != nil {
+			for _, p := range stored {
+				_ = s.backend.Delete(ctx, p)
+			}
+			return nil, err
+		}
+		stored = append(stored, variantPath)
+		result.Variants[variant.Name] = s.publicURL(variantPath)
+	}
+	return result, nil
+}
+
+// UploadImageVariants is StreamImage for a file from an already parsed form
+func (s *Service) UploadImageVariants(ctx context.Context, file *multipart.FileHeader, folder string) (*Image, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return s.StreamImage(ctx, folder, file.Filename, file.Header.Get("Content-Type"), src)
+}
+
+// DeleteImage deletes a stored image along with its variants
+func (s *Service) DeleteImage(ctx context.Context, img *Image) error {
+	err := s.DeleteFile(ctx, img.URL)
+	for _, url := range img.Variants {
+		if verr := s.DeleteFile(ctx, url); err == nil {
+			err = verr
+		}
+	}
+	return err
+}
+
+// resize scales img down to width, keeping its aspect ratio
+func resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
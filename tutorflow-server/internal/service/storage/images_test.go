@@ -0,0 +1,107 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/service/storage"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x*height/width, color.NRGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// withDimensions rewrites a PNG's header to claim another size
+func withDimensions(data []byte, width, height uint32) []byte {
+	out := append([]byte{}, data...)
+	ihdr := out[12:29] // chunk type and 13 bytes of data
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	binary.BigEndian.PutUint32(out[29:], crc32.ChecksumIEEE(ihdr))
+	return out
+}
+
+func TestStreamImage(t *testing.T) {
+	ctx := context.Background()
+	svc, stored := newLocalService(t, storage.NopScanner{})
+
+	t.Run("stores the original and resized WebP variants", func(t *testing.T) {
+		original := encodePNG(t, 800, 400)
+		img, err := svc.StreamImage(ctx, "thumbnails", "cover.png", "image/png", bytes.NewReader(original))
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(svc.GetFilePath(img.URL))
+		require.NoError(t, err)
+		assert.Equal(t, original, data)
+
+		base := strings.TrimSuffix(img.URL, ".png")
+		widths := map[string]int{"thumbnail": 200, "card": 640, "full": 800}
+		require.Len(t, img.Variants, len(widths))
+		for name, width := range widths {
+			url := img.Variants[name]
+			assert.Equal(t, base+"-"+name+".webp", url)
+
+			f, err := os.Open(svc.GetFilePath(url))
+			require.NoError(t, err)
+			cfg, err := webp.DecodeConfig(f)
+			f.Close()
+			require.NoError(t, err, name)
+			assert.Equal(t, width, cfg.Width, name)
+			assert.Equal(t, width/2, cfg.Height, name)
+		}
+		assert.Len(t, stored(), 4)
+
+		require.NoError(t, svc.DeleteImage(ctx, img))
+		assert.Empty(t, stored(), "variants are deleted with the original")
+	})
+
+	t.Run("rejects files that don't decode", func(t *testing.T) {
+		corrupt := append([]byte("\x89PNG\r\n\x1a\n"), strings.Repeat("\x00", 64)...)
+		_, err := svc.StreamImage(ctx, "thumbnails", "cover.png", "image/png", bytes.NewReader(corrupt))
+		assert.ErrorIs(t, err, domain.ErrFileContentMismatch)
+		assert.Empty(t, stored())
+	})
+
+	t.Run("rejects non-images", func(t *testing.T) {
+		_, err := svc.StreamImage(ctx, "thumbnails", "cover.pdf", "application/pdf", bytes.NewReader(pdfHeader))
+		assert.ErrorIs(t, err, domain.ErrInvalidFileType)
+		assert.Empty(t, stored())
+	})
+
+	t.Run("rejects images too large to decode", func(t *testing.T) {
+		huge := withDimensions(encodePNG(t, 10, 10), 20000, 20000)
+		_, err := svc.StreamImage(ctx, "thumbnails", "cover.png", "image/png", bytes.NewReader(huge))
+		assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+		assert.Empty(t, stored())
+	})
+
+	t.Run("rejects images just over the cap from their header", func(t *testing.T) {
+		// Decoding would fail on the 10x10 pixel data, so a size error means
+		// the header alone was enough
+		over := withDimensions(encodePNG(t, 10, 10), 4001, 4000)
+		_, err := svc.StreamImage(ctx, "thumbnails", "cover.png", "image/png", bytes.NewReader(over))
+		assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+		assert.NotErrorIs(t, err, domain.ErrFileContentMismatch)
+		assert.Empty(t, stored())
+	})
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// DirectUpload is a presigned request a client uploads a file with, straight
+// to storage rather than through the API
+type DirectUpload struct {
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"` // must be sent exactly as given
+	Key       string            `json:"key"`     // where the file will be stored
+	URL       string            `json:"url"`     // the file's URL once uploaded
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// PresignUpload returns a signed URL for userID to upload one video of the
+// given name, type and size directly to storage, into their
+// domain.VideoUploadFolder. The bucket refuses uploads of any other type or
+// size, but it can't sniff or scan them. Only videos may be uploaded this way,
+// as transcoding reads them before they are served; images and documents go
+// through the API.
+func (s *Service) PresignUpload(ctx context.Context, userID uuid.UUID, kind Kind, filename, contentType string, size int64) (*DirectUpload, error) {
+	if kind != KindVideo {
+		return nil, fmt.Errorf("%w: only videos can be uploaded directly", domain.ErrInvalidFileType)
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !slices.Contains(allowedExts[kind], ext) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidFileType, ext)
+	}
+	if max := s.MaxSize(kind); size > max {
+		return nil, fmt.Errorf("%w: max %dMB", domain.ErrFileTooLarge, max>>20)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || family(mediaType) != "video" {
+		return nil, fmt.Errorf("%w: %s isn't a %s type", domain.ErrFileContentMismatch, contentType, kind)
+	}
+
+	key := newPath(domain.VideoUploadFolder(userID), ext)
+
+	expiry := s.cfg.SignedURLExpiry
+	uploadURL, err := s.backend.SignedPutURL(ctx, key, mediaType, size, expiry)
+	if err != nil {
+		return nil, err
+	}
+	if uploadURL == "" {
+		return nil, domain.ErrDirectUploadUnavailable
+	}
+
+	return &DirectUpload{
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type":   mediaType,
+			"Content-Length": strconv.FormatInt(size, 10),
+		},
+		Key:       key,
+		URL:       s.publicURL(key),
+		ExpiresAt: time.Now().Add(expiry),
+	}, nil
+}
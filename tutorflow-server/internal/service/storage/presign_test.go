@@ -0,0 +1,78 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/service/storage"
+)
+
+// presigningBackend signs URLs that spell out what they allow
+type presigningBackend struct {
+	storage.Backend
+}
+
+func (presigningBackend) PublicURL(path string) string {
+	return "https://bucket.example.com/" + path
+}
+
+func (presigningBackend) SignedPutURL(ctx context.Context, path, contentType string, size int64, expiry time.Duration) (string, error) {
+	return fmt.Sprintf("https://bucket.example.com/%s?type=%s&size=%d&expiry=%s", path, contentType, size, expiry), nil
+}
+
+func TestPresignUpload(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.StorageConfig{
+		MaxImageSize:    1 << 20,
+		MaxVideoSize:    2 << 20,
+		MaxDocumentSize: 1 << 20,
+		SignedURLExpiry: 15 * time.Minute,
+	}
+	svc := storage.NewServiceWithBackend(cfg, presigningBackend{storage.NewLocalBackend(t.TempDir())}, storage.NopScanner{})
+	userID := uuid.New()
+
+	t.Run("signs the type and size", func(t *testing.T) {
+		upload, err := svc.PresignUpload(ctx, userID, storage.KindVideo, "lecture.MP4", "video/mp4", 2<<20)
+		require.NoError(t, err)
+		assert.Regexp(t, `^videos/uploads/`+userID.String()+`/[0-9a-f-]+-\d+\.mp4$`, upload.Key)
+		assert.Equal(t, "https://bucket.example.com/"+upload.Key+"?type=video/mp4&size=2097152&expiry=15m0s", upload.UploadURL)
+		assert.Equal(t, "PUT", upload.Method)
+		assert.Equal(t, map[string]string{"Content-Type": "video/mp4", "Content-Length": "2097152"}, upload.Headers)
+		assert.Equal(t, "https://bucket.example.com/"+upload.Key, upload.URL)
+	})
+
+	t.Run("only signs videos", func(t *testing.T) {
+		// Images and documents are sniffed and scanned on the way in, which
+		// a direct upload would skip
+		_, err := svc.PresignUpload(ctx, userID, storage.KindImage, "a.png", "image/png", 10)
+		assert.ErrorIs(t, err, domain.ErrInvalidFileType)
+
+		_, err = svc.PresignUpload(ctx, userID, storage.KindDocument, "a.pdf", "application/pdf", 10)
+		assert.ErrorIs(t, err, domain.ErrInvalidFileType)
+	})
+
+	t.Run("validates the file", func(t *testing.T) {
+		_, err := svc.PresignUpload(ctx, userID, storage.KindVideo, "lecture.exe", "video/mp4", 10)
+		assert.ErrorIs(t, err, domain.ErrInvalidFileType)
+
+		_, err = svc.PresignUpload(ctx, userID, storage.KindVideo, "lecture.mp4", "video/mp4", 2<<20+1)
+		assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+
+		_, err = svc.PresignUpload(ctx, userID, storage.KindVideo, "lecture.mp4", "application/octet-stream", 10)
+		assert.ErrorIs(t, err, domain.ErrFileContentMismatch)
+	})
+
+	t.Run("needs object storage", func(t *testing.T) {
+		local, _ := newLocalService(t, storage.NopScanner{})
+		_, err := local.PresignUpload(ctx, userID, storage.KindVideo, "lecture.mp4", "video/mp4", 10)
+		assert.ErrorIs(t, err, domain.ErrDirectUploadUnavailable)
+	})
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+)
+
+// s3Backend keeps files in an S3-compatible bucket, so app containers can be
+// replaced without losing them
+type s3Backend struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string // where public objects are fetched from
+	private bool
+}
+
+// NewS3Backend stores files in cfg.S3Bucket, creating the bucket if needed.
+// Private buckets hand out signed URLs rather than public ones.
+func NewS3Backend(cfg config.StorageConfig) (Backend, error) {
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure bucket exists
+	exists, err := client.BucketExists(context.Background(), cfg.S3Bucket)
+	if err == nil && !exists {
+		_ = client.MakeBucket(context.Background(), cfg.S3Bucket, minio.MakeBucketOptions{Region: cfg.S3Region})
+	}
+
+	protocol := "https"
+	if !cfg.UseSSL {
+		protocol = "http"
+	}
+	return &s3Backend{
+		client:  client,
+		bucket:  cfg.S3Bucket,
+		baseURL: fmt.Sprintf("%s://%s/%s", protocol, cfg.S3Endpoint, cfg.S3Bucket),
+		private: cfg.Private,
+	}, nil
+}
+
+func (b *s3Backend) Put(ctx context.Context, path string, r io.Reader, size int64, contentType string) error {
+	opts := minio.PutObjectOptions{ContentType: contentType}
+	if size < 0 {
+		// Unknown sizes are sent as a multipart upload; small parts keep the
+		// buffer per upload down
+		opts.PartSize = streamPartSize
+	}
+	_, err := b.client.PutObject(ctx, b.bucket, path, r, size, opts)
+	return err
+}
+
+func (b *s3Backend) Open(ctx context.Context, path string) (io.ReadCloser, string, error) {
+	obj, err := b.client.GetObject(ctx, b.bucket, path, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, "", err
+	}
+
+	// GetObject is lazy; Stat is where a missing object shows up
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == minio.NoSuchKey {
+			return nil, "", domain.ErrFileNotFound
+		}
+		return nil, "", err
+	}
+	return obj, info.ContentType, nil
+}
+
+func (b *s3Backend) Delete(ctx context.Context, path string) error {
+	return b.client.RemoveObject(ctx, b.bucket, path, minio.RemoveObjectOptions{})
+}
+
+func (b *s3Backend) DeleteAll(ctx context.Context, prefix string) error {
+	objectsCh := make(chan minio.ObjectInfo)
+
+	// Send object names that are needed to be removed to objectsCh
+	go func() {
+		defer close(objectsCh)
+		for object := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+			if object.Err != nil {
+				return
+			}
+			objectsCh <- object
+		}
+	}()
+
+	errorCh := b.client.RemoveObjects(ctx, b.bucket, objectsCh, minio.RemoveObjectsOptions{
+		GovernanceBypass: true,
+	})
+
+	for err := range errorCh {
+		if err.Err != nil {
+			return err.Err
+		}
+	}
+	return nil
+}
+
+func (b *s3Backend) Exists(ctx context.Context, path string) bool {
+	_, err := b.client.StatObject(ctx, b.bucket, path, minio.StatObjectOptions{})
+	return err == nil
+}
+
+func (b *s3Backend) PublicURL(path string) string {
+	if b.private {
+		return ""
+	}
+	return b.baseURL + "/" + path
+}
+
+func (b *s3Backend) SignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
+	if !b.private {
+		return "", nil
+	}
+	u, err := b.client.PresignedGetObject(ctx, b.bucket, path, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (b *s3Backend) SignedPutURL(ctx context.Context, path, contentType string, size int64, expiry time.Duration) (string, error) {
+	// Signing the headers makes the bucket refuse any other type or size
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+	headers.Set("Content-Length", strconv.FormatInt(size, 10))
+	u, err := b.client.PresignHeader(ctx, http.MethodPut, b.bucket, path, expiry, nil, headers)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (b *s3Backend) LocalPath(path string) string {
+	return ""
+}
+
+func (b *s3Backend) Ping(ctx context.Context) error {
+	exists, err := b.client.BucketExists(ctx, b.bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", b.bucket)
+	}
+	return nil
+}
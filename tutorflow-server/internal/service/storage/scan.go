@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// Scanner checks an upload before it is kept, for example with an antivirus
+// engine. Scan reads the file as it is being stored; returning an error
+// wrapping domain.ErrFileRejected turns the upload away, and any other error
+// fails it, so an unavailable scanner never lets files through unchecked.
+type Scanner interface {
+	Scan(ctx context.Context, filename string, r io.Reader) error
+}
+
+// NopScanner accepts every file
+type NopScanner struct{}
+
+func (NopScanner) Scan(ctx context.Context, filename string, r io.Reader) error {
+	return nil
+}
+
+// sniffLen is how much of a file http.DetectContentType looks at
+const sniffLen = 512
+
+// sniffedTypes lists the content types http.DetectContentType may report for
+// each allowed extension. Formats it can't recognise, like older Office files
+// and most QuickTime movies, come back as application/octet-stream.
+var sniffedTypes = map[string][]string{
+	".jpg":  {"image/jpeg"},
+	".jpeg": {"image/jpeg"},
+	".png":  {"image/png"},
+	".gif":  {"image/gif"},
+	".webp": {"image/webp"},
+	".mp4":  {"video/mp4"},
+	".mov":  {"video/mp4", "application/octet-stream"},
+	".avi":  {"video/avi"},
+	".mkv":  {"video/webm"},
+	".webm": {"video/webm"},
+	".pdf":  {"application/pdf"},
+	".doc":  {"application/octet-stream"},
+	".ppt":  {"application/octet-stream"},
+	".xls":  {"application/octet-stream"},
+	".docx": {"application/zip"},
+	".pptx": {"application/zip"},
+	".xlsx": {"application/zip"},
+	".zip":  {"application/zip"},
+	".txt":  {"text/plain"},
+}
+
+// executableMagic starts Windows, Linux and macOS executables, none of which
+// http.DetectContentType tells apart from other binary data
+var executableMagic = [][]byte{
+	[]byte("MZ"),
+	[]byte("\x7fELF"),
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe},
+}
+
+// checkContent checks the start of a file is what its extension and declared
+// content type say it is, and returns the content type to store it with
+func checkContent(ext, declared string, head []byte) (string, error) {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !slices.Contains(sniffedTypes[ext], sniffed) {
+		return "", fmt.Errorf("%w: %s content in a %s file", domain.ErrFileContentMismatch, sniffed, ext)
+	}
+	if sniffed == "application/octet-stream" {
+		for _, magic := range executableMagic {
+			if bytes.HasPrefix(head, magic) {
+				return "", fmt.Errorf("%w: executable content in a %s file", domain.ErrFileContentMismatch, ext)
+			}
+		}
+	}
+
+	// A declared type only has to agree on what sort of file it is, as
+	// clients name the same formats differently (Office files are zips)
+	declared, _, _ = mime.ParseMediaType(declared)
+	if declared == "" || declared == "application/octet-stream" {
+		return sniffed, nil
+	}
+	if family(declared) != family(sniffed) {
+		return "", fmt.Errorf("%w: declared %s but found %s", domain.ErrFileContentMismatch, declared, sniffed)
+	}
+	if sniffed == "application/octet-stream" {
+		return declared, nil
+	}
+	return sniffed, nil
+}
+
+// family is the top-level part of a media type, such as "image"
+func family(mediaType string) string {
+	top, _, _ := strings.Cut(mediaType, "/")
+	return top
+}
@@ -1,70 +1,65 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
 
+	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
 )
 
+// ServePrefix is where the app serves stored files that have no public URL
+// of their own: local files, and redirects to signed URLs for private buckets
+const ServePrefix = "/uploads/"
+
 // Service handles file storage operations
 type Service struct {
-	cfg         config.StorageConfig
-	basePath    string
-	cdnBase     string
-	minioClient *minio.Client
-}
-
-// GetBucket returns the configured S3 bucket name
-func (s *Service) GetBucket() string {
-	return s.cfg.S3Bucket
-}
-
-// GetBasePath returns the local storage base path
-func (s *Service) GetBasePath() string {
-	return s.basePath
+	cfg     config.StorageConfig
+	backend Backend
+	cdnBase string
+	scanner Scanner
 }
 
-// NewService creates a new storage service
+// NewService creates a new storage service that stores uploads unscanned
 func NewService(cfg config.StorageConfig) *Service {
-	svc := &Service{
-		cfg:      cfg,
-		basePath: cfg.LocalPath,
-		cdnBase:  cfg.CDNBaseURL,
-	}
+	return NewServiceWithScanner(cfg, NopScanner{})
+}
 
+// NewServiceWithScanner creates a storage service that runs every upload
+// through scanner before keeping it. Files go to local disk, or to an
+// S3-compatible bucket when the driver is "s3".
+func NewServiceWithScanner(cfg config.StorageConfig, scanner Scanner) *Service {
+	backend := NewLocalBackend(cfg.LocalPath)
 	if cfg.Driver == "s3" {
-		// Initialize MinIO client object
-		useSSL := cfg.UseSSL
-		minioClient, err := minio.New(cfg.S3Endpoint, &minio.Options{
-			Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
-			Secure: useSSL,
-			Region: cfg.S3Region,
-		})
+		var err error
+		backend, err = NewS3Backend(cfg)
 		if err != nil {
 			// Fatal error - cannot proceed with S3 storage
 			panic(fmt.Sprintf("Failed to initialize MinIO client: %v", err))
 		}
-		svc.minioClient = minioClient
-
-		// Ensure bucket exists
-		exists, err := minioClient.BucketExists(context.Background(), cfg.S3Bucket)
-		if err == nil && !exists {
-			_ = minioClient.MakeBucket(context.Background(), cfg.S3Bucket, minio.MakeBucketOptions{})
-		}
 	}
+	return NewServiceWithBackend(cfg, backend, scanner)
+}
 
-	return svc
+// NewServiceWithBackend creates a storage service that keeps files in backend
+func NewServiceWithBackend(cfg config.StorageConfig, backend Backend, scanner Scanner) *Service {
+	return &Service{
+		cfg:     cfg,
+		backend: backend,
+		cdnBase: strings.TrimSuffix(cfg.CDNBaseURL, "/"),
+		scanner: scanner,
+	}
 }
 
 // UploadFile uploads a file and returns the URL
@@ -79,214 +74,275 @@ func (s *Service) UploadFile(ctx context.Context, file *multipart.FileHeader, fo
 	ext := filepath.Ext(file.Filename)
 	filename := fmt.Sprintf("%s-%d%s", uuid.New().String(), time.Now().UnixNano(), ext)
 	path := fmt.Sprintf("%s/%s", folder, filename)
-	fmt.Printf("StorageService: UploadFile starting. Folder: %s, Path: %s, Driver: %s\n", folder, path, s.cfg.Driver)
-
-	if s.cfg.Driver == "s3" && s.minioClient != nil {
-		fmt.Printf("StorageService: Uploading to S3 bucket %s...\n", s.cfg.S3Bucket)
-		// Upload to S3
-		info, err := s.minioClient.PutObject(ctx, s.cfg.S3Bucket, path, src, file.Size, minio.PutObjectOptions{
-			ContentType: file.Header.Get("Content-Type"),
-		})
-		if err != nil {
-			fmt.Printf("StorageService: S3 PutObject failed: %v\n", err)
-			return "", err
-		}
-		fmt.Printf("StorageService: S3 PutObject successful. Info: %+v\n", info)
-
-		// Return URL (CDN or S3)
-		if s.cdnBase != "" {
-			url := fmt.Sprintf("%s/%s", s.cdnBase, path)
-			fmt.Printf("StorageService: Returning CDN URL: %s\n", url)
-			return url, nil
-		}
 
-		protocol := "https"
-		if !s.cfg.UseSSL {
-			protocol = "http"
-		}
-		url := fmt.Sprintf("%s://%s/%s/%s", protocol, s.cfg.S3Endpoint, s.cfg.S3Bucket, info.Key)
-		fmt.Printf("StorageService: Returning S3 URL: %s\n", url)
-		return url, nil
+	if err := s.backend.Put(ctx, path, src, file.Size, file.Header.Get("Content-Type")); err != nil {
+		return "", err
 	}
+	return s.publicURL(path), nil
+}
 
-	// Local Storage Fallback
-	// Create directory if not exists
-	dir := filepath.Join(s.basePath, folder)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", err
+// PutFile stores generated content at path, which is relative to the bucket or
+// local base path. Unlike uploads it returns no public URL; read it back with
+// GetFileStream.
+func (s *Service) PutFile(ctx context.Context, path string, data []byte, contentType string) error {
+	return s.backend.Put(ctx, path, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// Kind is a category of upload, each with its own formats and size limit
+type Kind string
+
+const (
+	KindImage    Kind = "image"
+	KindVideo    Kind = "video"
+	KindDocument Kind = "document"
+)
+
+var allowedExts = map[Kind][]string{
+	KindImage:    {".jpg", ".jpeg", ".png", ".gif", ".webp"},
+	KindVideo:    {".mp4", ".mov", ".avi", ".mkv", ".webm"},
+	KindDocument: {".pdf", ".doc", ".docx", ".ppt", ".pptx", ".xls", ".xlsx", ".txt", ".zip"},
+}
+
+// MaxSize returns the largest file of a kind that can be uploaded, in bytes
+func (s *Service) MaxSize(kind Kind) int64 {
+	switch kind {
+	case KindImage:
+		return s.cfg.MaxImageSize
+	case KindVideo:
+		return s.cfg.MaxVideoSize
+	default:
+		return s.cfg.MaxDocumentSize
 	}
+}
 
-	// Create destination file
-	dstPath := filepath.Join(dir, filename)
-	dst, err := os.Create(dstPath)
+// Stream stores an uploaded file as it is read, without holding it in memory
+// or spooling it to a temporary file first, and returns its URL. The file must
+// be within the kind's size limit, its content must match its extension and
+// declared type, and the scanner must pass it; otherwise nothing is kept.
+func (s *Service) Stream(ctx context.Context, kind Kind, folder, filename, contentType string, r io.Reader) (string, error) {
+	path, err := s.store(ctx, kind, folder, filename, contentType, r)
 	if err != nil {
 		return "", err
 	}
-	defer dst.Close()
+	return s.publicURL(path), nil
+}
 
-	// Copy file
-	if _, err := io.Copy(dst, src); err != nil {
-		return "", err
+// newPath returns a unique path for a new file in folder
+func newPath(folder, ext string) string {
+	return fmt.Sprintf("%s/%s-%d%s", folder, uuid.New().String(), time.Now().UnixNano(), ext)
+}
+
+// sideReader reads an upload alongside it being stored; an error from it
+// removes the stored file and fails the upload
+type sideReader func(r io.Reader) error
+
+// store does the work of Stream and returns the stored file's path
+func (s *Service) store(ctx context.Context, kind Kind, folder, filename, contentType string, r io.Reader, readers ...sideReader) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !slices.Contains(allowedExts[kind], ext) {
+		return "", fmt.Errorf("%w: %s", domain.ErrInvalidFileType, ext)
 	}
 
-	// Return URL
-	return fmt.Sprintf("%s/%s/%s", s.cdnBase, folder, filename), nil
-}
+	max := s.MaxSize(kind)
+	body := &limitedReader{r: r, left: max}
+	tooLarge := fmt.Errorf("%w: max %dMB", domain.ErrFileTooLarge, max>>20)
 
-// UploadImage uploads an image with validation
-func (s *Service) UploadImage(ctx context.Context, file *multipart.FileHeader, folder string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
-
-	valid := false
-	for _, allowed := range allowedExts {
-		if ext == allowed {
-			valid = true
-			break
+	// The first bytes are enough to tell what the file really is
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if body.exceeded {
+			return "", tooLarge
 		}
+		return "", err
 	}
-	if !valid {
-		return "", fmt.Errorf("invalid image format: %s", ext)
+	head = head[:n]
+	contentType, err = checkContent(ext, contentType, head)
+	if err != nil {
+		return "", err
 	}
 
-	// Check file size (10MB max)
-	if file.Size > 10*1024*1024 {
-		return "", fmt.Errorf("file too large: max 10MB")
+	path := newPath(folder, ext)
+
+	// The scanner, and any other readers, see the file alongside the upload
+	// so it is still only read once. Each drains what it doesn't read so the
+	// upload isn't held up by a reader that stopped early.
+	readers = append([]sideReader{func(r io.Reader) error {
+		return s.scanner.Scan(ctx, filename, r)
+	}}, readers...)
+	results := make([]chan error, len(readers))
+	pipes := make([]*io.PipeWriter, len(readers))
+	outs := make([]io.Writer, len(readers))
+	for i, read := range readers {
+		pr, pw := io.Pipe()
+		pipes[i], outs[i] = pw, pw
+		results[i] = make(chan error, 1)
+		go func() {
+			err := read(pr)
+			_, _ = io.Copy(io.Discard, pr)
+			results[i] <- err
+		}()
 	}
 
-	return s.UploadFile(ctx, file, folder)
-}
-
-// UploadVideo uploads a video file
-func (s *Service) UploadVideo(ctx context.Context, file *multipart.FileHeader, folder string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedExts := []string{".mp4", ".mov", ".avi", ".mkv", ".webm"}
-
-	valid := false
-	for _, allowed := range allowedExts {
-		if ext == allowed {
-			valid = true
-			break
+	err = s.backend.Put(ctx, path, io.TeeReader(io.MultiReader(bytes.NewReader(head), body), io.MultiWriter(outs...)), -1, contentType)
+	var readErr error
+	for i, pw := range pipes {
+		pw.CloseWithError(err)
+		if rerr := <-results[i]; readErr == nil {
+			readErr = rerr
+		}
+	}
+	if err != nil {
+		if body.exceeded {
+			return "", tooLarge
 		}
+		return "", err
 	}
-	if !valid {
-		return "", fmt.Errorf("invalid video format: %s", ext)
+	if readErr != nil {
+		_ = s.backend.Delete(ctx, path)
+		return "", readErr
 	}
+	return path, nil
+}
 
-	// Check file size (500MB max)
-	if file.Size > 500*1024*1024 {
-		return "", fmt.Errorf("file too large: max 500MB")
+// publicURL returns the URL a stored file is served from: through the CDN if
+// there is one, straight from a public bucket, or otherwise by the app under
+// ServePrefix
+func (s *Service) publicURL(path string) string {
+	if s.cdnBase != "" {
+		return s.cdnBase + "/" + path
 	}
-
-	return s.UploadFile(ctx, file, folder)
+	if url := s.backend.PublicURL(path); url != "" {
+		return url
+	}
+	return ServePrefix + path
 }
 
-// UploadDocument uploads a document file
-func (s *Service) UploadDocument(ctx context.Context, file *multipart.FileHeader, folder string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedExts := []string{".pdf", ".doc", ".docx", ".ppt", ".pptx", ".xls", ".xlsx", ".txt", ".zip"}
-
-	valid := false
-	for _, allowed := range allowedExts {
-		if ext == allowed {
-			valid = true
-			break
-		}
+// PathFromURL returns the storage path of a file from a URL this service
+// handed out, or false if the URL points somewhere else
+func (s *Service) PathFromURL(url string) (string, bool) {
+	prefixes := []string{ServePrefix}
+	if s.cdnBase != "" {
+		prefixes = append(prefixes, s.cdnBase+"/")
+	}
+	if base := s.backend.PublicURL(""); base != "" {
+		prefixes = append(prefixes, base)
 	}
-	if !valid {
-		return "", fmt.Errorf("invalid document format: %s", ext)
+	for _, prefix := range prefixes {
+		if path, ok := strings.CutPrefix(url, prefix); ok && path != "" {
+			return path, true
+		}
 	}
 
-	// Check file size (50MB max)
-	if file.Size > 50*1024*1024 {
-		return "", fmt.Errorf("file too large: max 50MB")
+	// Local files stored before they were served under ServePrefix
+	if strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "//") && len(url) > 1 {
+		return url[1:], true
 	}
+	return "", false
+}
 
-	return s.UploadFile(ctx, file, folder)
+// SignedURL returns a short-lived URL for a file in a private bucket, or ""
+// when the file has to be fetched another way
+func (s *Service) SignedURL(ctx context.Context, path string) (string, error) {
+	return s.backend.SignedURL(ctx, path, s.cfg.SignedURLExpiry)
 }
 
-// DeleteFile deletes a file by URL
-func (s *Service) DeleteFile(ctx context.Context, url string) error {
-	// Extract path from URL
-	if url == "" {
-		return nil
-	}
+// LocalPath returns where a stored file is on local disk, or "" if storage
+// isn't local
+func (s *Service) LocalPath(path string) string {
+	return s.backend.LocalPath(path)
+}
 
-	path := strings.TrimPrefix(url, s.cdnBase+"/")
+// Ping checks the backend can store files
+func (s *Service) Ping(ctx context.Context) error {
+	return s.backend.Ping(ctx)
+}
 
-	if s.cfg.Driver == "s3" && s.minioClient != nil {
-		return s.minioClient.RemoveObject(ctx, s.cfg.S3Bucket, path, minio.RemoveObjectOptions{})
-	}
+// UploadImage uploads an image with validation
+func (s *Service) UploadImage(ctx context.Context, file *multipart.FileHeader, folder string) (string, error) {
+	return s.uploadKind(ctx, KindImage, file, folder)
+}
 
-	fullPath := filepath.Join(s.basePath, path)
-	return os.Remove(fullPath)
+// UploadVideo uploads a video file
+func (s *Service) UploadVideo(ctx context.Context, file *multipart.FileHeader, folder string) (string, error) {
+	return s.uploadKind(ctx, KindVideo, file, folder)
 }
 
-// DeleteFolder deletes a folder and all its contents
-func (s *Service) DeleteFolder(ctx context.Context, path string) error {
-	if path == "" {
-		return nil
+// UploadDocument uploads a document file
+func (s *Service) UploadDocument(ctx context.Context, file *multipart.FileHeader, folder string) (string, error) {
+	return s.uploadKind(ctx, KindDocument, file, folder)
+}
+
+// uploadKind stores a file from an already parsed form with a kind's checks
+func (s *Service) uploadKind(ctx context.Context, kind Kind, file *multipart.FileHeader, folder string) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
 	}
+	defer src.Close()
 
-	if s.cfg.Driver == "s3" && s.minioClient != nil {
-		objectsCh := make(chan minio.ObjectInfo)
+	return s.Stream(ctx, kind, folder, file.Filename, file.Header.Get("Content-Type"), src)
+}
 
-		// Send object names that are needed to be removed to objectsCh
-		go func() {
-			defer close(objectsCh)
-			for object := range s.minioClient.ListObjects(ctx, s.cfg.S3Bucket, minio.ListObjectsOptions{Prefix: path, Recursive: true}) {
-				if object.Err != nil {
-					return
-				}
-				objectsCh <- object
-			}
-		}()
+// streamPartSize is the S3 part size for uploads of unknown length
+const streamPartSize = 16 << 20
 
-		errorCh := s.minioClient.RemoveObjects(ctx, s.cfg.S3Bucket, objectsCh, minio.RemoveObjectsOptions{
-			GovernanceBypass: true,
-		})
+// limitedReader fails once more than left bytes are read, rather than ending
+// early like io.LimitReader, so an oversized file is never stored truncated
+type limitedReader struct {
+	r        io.Reader
+	left     int64
+	exceeded bool
+}
 
-		for err := range errorCh {
-			if err.Err != nil {
-				return err.Err
-			}
-		}
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.left+1 {
+		p = p[:l.left+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.left {
+		l.exceeded = true
+		return 0, domain.ErrFileTooLarge
+	}
+	l.left -= int64(n)
+	return n, err
+}
+
+// DeleteFile deletes a file by URL
+func (s *Service) DeleteFile(ctx context.Context, url string) error {
+	path, ok := s.PathFromURL(url)
+	if !ok {
 		return nil
 	}
+	return s.backend.Delete(ctx, path)
+}
 
-	fullPath := filepath.Join(s.basePath, path)
-	return os.RemoveAll(fullPath)
+// DeleteFolder deletes a folder and all its contents
+func (s *Service) DeleteFolder(ctx context.Context, path string) error {
+	if path == "" {
+		return nil
+	}
+	return s.backend.DeleteAll(ctx, path)
 }
 
 // GetFilePath returns the local file path from URL (only works for local driver)
 func (s *Service) GetFilePath(url string) string {
-	path := strings.TrimPrefix(url, s.cdnBase+"/")
-	return filepath.Join(s.basePath, path)
+	path, ok := s.PathFromURL(url)
+	if !ok {
+		return ""
+	}
+	return s.backend.LocalPath(path)
 }
 
 // FileExists checks if a file exists
 func (s *Service) FileExists(url string) bool {
-	if s.cfg.Driver == "s3" && s.minioClient != nil {
-		// Optimization: assume it exists if we have URL, or do a StatObject
-		// For now, let's just return true if URL is valid to avoid latency
-		return url != ""
-	}
-	path := s.GetFilePath(url)
-	_, err := os.Stat(path)
-	return err == nil
+	path, ok := s.PathFromURL(url)
+	return ok && s.backend.Exists(context.Background(), path)
 }
 
-// UploadHLSFiles uploads all files in a directory to S3 (recursive)
-// Used for uploading HLS segments and playlist
-func (s *Service) UploadHLSFiles(ctx context.Context, localDir string, s3Prefix string) error {
-	if s.cfg.Driver != "s3" {
-		// If driver is local, we verify files are in the right place or move them.
-		// For this implementation, we assume if processing happened locally in the expected folder,
-		// they are already there if we aligned paths.
-		// However, video processing writes to random temp dir.
-		return nil
-	}
-
+// UploadHLSFiles stores every file under localDir at the same relative path
+// under prefix, where GetFileStream serves HLS playlists and segments from
+func (s *Service) UploadHLSFiles(ctx context.Context, localDir string, prefix string) error {
 	return filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -300,62 +356,18 @@ func (s *Service) UploadHLSFiles(ctx context.Context, localDir string, s3Prefix
 			return err
 		}
 
-		s3Path := filepath.Join(s3Prefix, relPath)
-
-		// Open file
 		file, err := os.Open(path)
 		if err != nil {
 			return err
 		}
 		defer file.Close()
 
-		// Guess content type
-		contentType := "application/octet-stream"
-		if strings.HasSuffix(path, ".m3u8") {
-			contentType = "application/vnd.apple.mpegurl" // or application/x-mpegURL
-		} else if strings.HasSuffix(path, ".ts") {
-			contentType = "video/MP2T"
-		} else if strings.HasSuffix(path, ".key") {
-			contentType = "application/octet-stream"
-		}
-
-		// Upload
-		_, err = s.minioClient.PutObject(ctx, s.cfg.S3Bucket, s3Path, file, info.Size(), minio.PutObjectOptions{
-			ContentType: contentType,
-		})
-		return err
+		return s.backend.Put(ctx, prefix+"/"+filepath.ToSlash(relPath), file, info.Size(), contentTypeOf(path))
 	})
 }
 
-// GetFileStream returns a stream of the file content
+// GetFileStream returns a stream of the file content, or
+// domain.ErrFileNotFound
 func (s *Service) GetFileStream(ctx context.Context, path string) (io.ReadCloser, string, error) {
-	if s.cfg.Driver == "s3" && s.minioClient != nil {
-		obj, err := s.minioClient.GetObject(ctx, s.cfg.S3Bucket, path, minio.GetObjectOptions{})
-		if err != nil {
-			return nil, "", err
-		}
-
-		info, err := obj.Stat()
-		if err != nil {
-			return nil, "", err
-		}
-
-		return obj, info.ContentType, nil
-	}
-
-	// Local fallback
-	fullPath := filepath.Join(s.basePath, path)
-	f, err := os.Open(fullPath)
-	if err != nil {
-		return nil, "", err
-	}
-
-	contentType := "application/octet-stream"
-	if strings.HasSuffix(path, ".m3u8") {
-		contentType = "application/vnd.apple.mpegurl"
-	} else if strings.HasSuffix(path, ".ts") {
-		contentType = "video/MP2T"
-	}
-
-	return f, contentType, nil
+	return s.backend.Open(ctx, path)
 }
@@ -0,0 +1,169 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/service/storage"
+)
+
+// Enough of each format for content sniffing to recognise it
+var (
+	pngHeader = []byte("\x89PNG\r\n\x1a\n")
+	mp4Header = []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+	pdfHeader = []byte("%PDF-1.7\n")
+	exeHeader = []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff")
+)
+
+// file is a header padded out to size bytes
+func file(header []byte, size int) io.Reader {
+	return io.MultiReader(bytes.NewReader(header), strings.NewReader(strings.Repeat("\x00", size-len(header))))
+}
+
+func newLocalService(t *testing.T, scanner storage.Scanner) (*storage.Service, func() []string) {
+	dir := t.TempDir()
+	svc := storage.NewServiceWithScanner(config.StorageConfig{
+		Driver:          "local",
+		LocalPath:       dir,
+		MaxImageSize:    1 << 20,
+		MaxVideoSize:    2 << 20,
+		MaxDocumentSize: 1 << 20,
+	}, scanner)
+	stored := func() []string {
+		files, _ := filepath.Glob(filepath.Join(dir, "*", "*"))
+		return files
+	}
+	return svc, stored
+}
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	svc, stored := newLocalService(t, storage.NopScanner{})
+
+	t.Run("stores a file within the limit", func(t *testing.T) {
+		url, err := svc.Stream(ctx, storage.KindImage, "images", "avatar.PNG", "image/png", file(pngHeader, 1<<20))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(url, "/uploads/images/"))
+		assert.True(t, strings.HasSuffix(url, ".png"))
+
+		data, err := os.ReadFile(svc.GetFilePath(url))
+		require.NoError(t, err)
+		assert.Len(t, data, 1<<20)
+		assert.True(t, bytes.HasPrefix(data, pngHeader))
+		require.NoError(t, os.Remove(svc.GetFilePath(url)))
+	})
+
+	t.Run("each kind has its own limit", func(t *testing.T) {
+		_, err := svc.Stream(ctx, storage.KindImage, "images", "banner.png", "image/png", file(pngHeader, 1<<20+1))
+		assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+		assert.EqualError(t, err, "file is too large: max 1MB")
+		assert.Empty(t, stored(), "partial upload should be removed")
+
+		url, err := svc.Stream(ctx, storage.KindVideo, "videos", "intro.mp4", "video/mp4", file(mp4Header, 1<<20+1))
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(svc.GetFilePath(url)))
+	})
+
+	t.Run("rejects other formats", func(t *testing.T) {
+		_, err := svc.Stream(ctx, storage.KindImage, "images", "script.sh", "text/plain", strings.NewReader("echo"))
+		assert.ErrorIs(t, err, domain.ErrInvalidFileType)
+		assert.Empty(t, stored())
+	})
+}
+
+func TestStream_Content(t *testing.T) {
+	ctx := context.Background()
+	svc, stored := newLocalService(t, storage.NopScanner{})
+
+	tests := []struct {
+		name     string
+		filename string
+		declared string
+		content  []byte
+		wantErr  bool
+	}{
+		{"matching pdf", "notes.pdf", "application/pdf", pdfHeader, false},
+		{"no declared type", "notes.pdf", "", pdfHeader, false},
+		{"plain text", "notes.txt", "text/plain; charset=utf-8", []byte("Week 1 reading list"), false},
+		{"executable named as a pdf", "notes.pdf", "application/pdf", exeHeader, true},
+		{"executable named as an old Word file", "notes.doc", "application/msword", exeHeader, true},
+		{"image named as a pdf", "notes.pdf", "application/pdf", pngHeader, true},
+		{"pdf declared as an image", "notes.pdf", "image/png", pdfHeader, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := svc.Stream(ctx, storage.KindDocument, "documents", tt.filename, tt.declared, bytes.NewReader(tt.content))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrFileContentMismatch)
+				assert.Empty(t, stored())
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, os.Remove(svc.GetFilePath(url)))
+		})
+	}
+}
+
+// signatureScanner rejects files containing a signature, and can stop
+// reading early like real scanners do once they find a match
+type signatureScanner struct {
+	signature []byte
+	err       error // returned instead of scanning
+	scanned   []string
+}
+
+func (s *signatureScanner) Scan(ctx context.Context, filename string, r io.Reader) error {
+	s.scanned = append(s.scanned, filename)
+	if s.err != nil {
+		return s.err
+	}
+	buf := make([]byte, len(s.signature))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil
+	}
+	if bytes.Equal(buf, s.signature) {
+		return fmt.Errorf("%w: %s matched", domain.ErrFileRejected, filename)
+	}
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+func TestStream_Scanner(t *testing.T) {
+	ctx := context.Background()
+	infected := append(append([]byte{}, pdfHeader...), "X5O!P%@AP"...)
+	scanner := &signatureScanner{signature: infected}
+	svc, stored := newLocalService(t, scanner)
+
+	t.Run("clean file is kept", func(t *testing.T) {
+		url, err := svc.Stream(ctx, storage.KindDocument, "assignments", "essay.pdf", "application/pdf", file(pdfHeader, 64<<10))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"essay.pdf"}, scanner.scanned)
+		require.NoError(t, os.Remove(svc.GetFilePath(url)))
+	})
+
+	t.Run("rejected file is removed", func(t *testing.T) {
+		_, err := svc.Stream(ctx, storage.KindDocument, "assignments", "essay.pdf", "application/pdf", file(infected, 64<<10))
+		assert.ErrorIs(t, err, domain.ErrFileRejected)
+		assert.Empty(t, stored())
+	})
+
+	t.Run("scanner failure fails the upload", func(t *testing.T) {
+		scanner.err = errors.New("scanner unavailable")
+		_, err := svc.Stream(ctx, storage.KindDocument, "assignments", "essay.pdf", "application/pdf", file(pdfHeader, 64<<10))
+		assert.EqualError(t, err, "scanner unavailable")
+		assert.Empty(t, stored())
+	})
+}
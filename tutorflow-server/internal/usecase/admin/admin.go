@@ -2,22 +2,78 @@ package admin
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
 )
 
 // UseCase defines admin dashboard business logic
 type UseCase struct {
-	db *gorm.DB
+	db        *gorm.DB
+	auditRepo repository.AuditLogRepository
+	cache     *statsCache
 }
 
-// NewUseCase creates a new admin use case
-func NewUseCase(db *gorm.DB) *UseCase {
-	return &UseCase{db: db}
+// NewUseCase creates a new admin use case. Dashboard figures are cached for
+// cacheTTL; zero disables caching.
+func NewUseCase(db *gorm.DB, auditRepo repository.AuditLogRepository, cacheTTL time.Duration) *UseCase {
+	return &UseCase{db: db, auditRepo: auditRepo, cache: newStatsCache(cacheTTL)}
+}
+
+// maxRangeSpan bounds custom date ranges so one request can't walk years of
+// orders
+const maxRangeSpan = 2 * 366 * 24 * time.Hour
+
+// DateRange narrows analytics to records created from From up to, but not
+// including, To. The zero value keeps each endpoint's default window.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// IsZero reports whether no range was requested
+func (r DateRange) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+// Validate checks the range runs forwards and isn't too long
+func (r DateRange) Validate() error {
+	if r.To.Before(r.From) {
+		return errors.New("from must not be after to")
+	}
+	if r.To.Sub(r.From) > maxRangeSpan {
+		return errors.New("date range can span at most two years")
+	}
+	return nil
+}
+
+// WholeDays widens the range to whole UTC days, from the start of From's day
+// up to the end of To's. Ranges ending "now" then share a cache entry for the
+// rest of the day instead of each request caching its own.
+func (r DateRange) WholeDays() DateRange {
+	if r.IsZero() {
+		return r
+	}
+	const day = 24 * time.Hour
+	to := r.To.Truncate(day)
+	if to.Before(r.To) {
+		to = to.Add(day)
+	}
+	return DateRange{From: r.From.Truncate(day).UTC(), To: to.UTC()}
+}
+
+// cacheKey distinguishes cached results for different ranges
+func (r DateRange) cacheKey() string {
+	if r.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(":%d-%d", r.From.Unix(), r.To.Unix())
 }
 
 // DashboardStats contains main dashboard metrics
@@ -26,6 +82,7 @@ type DashboardStats struct {
 	Courses     CourseStats     `json:"courses"`
 	Revenue     RevenueStats    `json:"revenue"`
 	Enrollments EnrollmentStats `json:"enrollments"`
+	Range       *RangeStats     `json:"range,omitempty"` // only when a date range is requested
 }
 
 type UserStats struct {
@@ -65,8 +122,28 @@ type EnrollmentStats struct {
 	EnrollmentsWeek   int64   `json:"enrollments_week"`
 }
 
-// GetDashboardStats returns main dashboard statistics
-func (uc *UseCase) GetDashboardStats(ctx context.Context) (*DashboardStats, error) {
+// RangeStats covers a requested date range
+type RangeStats struct {
+	From             time.Time `json:"from"`
+	To               time.Time `json:"to"`
+	NewUsers         int64     `json:"new_users"`
+	Revenue          float64   `json:"revenue"`
+	Orders           int64     `json:"orders"`
+	AvgOrderValue    float64   `json:"avg_order_value"`
+	Enrollments      int64     `json:"enrollments"`
+	CompletedCourses int64     `json:"completed_courses"`
+}
+
+// GetDashboardStats returns main dashboard statistics, from the cache unless
+// refresh is set. A date range adds figures for that range alongside the
+// usual today/week/month ones.
+func (uc *UseCase) GetDashboardStats(ctx context.Context, rng DateRange, refresh bool) (*DashboardStats, error) {
+	return cached(uc.cache, "dashboard"+rng.cacheKey(), refresh, func() (*DashboardStats, error) {
+		return uc.dashboardStats(ctx, rng)
+	})
+}
+
+func (uc *UseCase) dashboardStats(ctx context.Context, rng DateRange) (*DashboardStats, error) {
 	stats := &DashboardStats{}
 
 	// User stats
@@ -94,19 +171,19 @@ func (uc *UseCase) GetDashboardStats(ctx context.Context) (*DashboardStats, erro
 
 	// Revenue stats
 	var totalRevenue struct{ Sum float64 }
-	uc.db.WithContext(ctx).Model(&domain.Order{}).Select("COALESCE(SUM(total_amount), 0) as sum").Where("status = ?", domain.OrderStatusCompleted).Scan(&totalRevenue)
+	uc.db.WithContext(ctx).Model(&domain.Order{}).Select("COALESCE(SUM(total), 0) as sum").Where("status = ?", domain.OrderStatusCompleted).Scan(&totalRevenue)
 	stats.Revenue.TotalRevenue = totalRevenue.Sum
 
 	var revenueToday struct{ Sum float64 }
-	uc.db.WithContext(ctx).Model(&domain.Order{}).Select("COALESCE(SUM(total_amount), 0) as sum").Where("status = ? AND created_at >= ?", domain.OrderStatusCompleted, today).Scan(&revenueToday)
+	uc.db.WithContext(ctx).Model(&domain.Order{}).Select("COALESCE(SUM(total), 0) as sum").Where("status = ? AND created_at >= ?", domain.OrderStatusCompleted, today).Scan(&revenueToday)
 	stats.Revenue.RevenueToday = revenueToday.Sum
 
 	var revenueWeek struct{ Sum float64 }
-	uc.db.WithContext(ctx).Model(&domain.Order{}).Select("COALESCE(SUM(total_amount), 0) as sum").Where("status = ? AND created_at >= ?", domain.OrderStatusCompleted, weekAgo).Scan(&revenueWeek)
+	uc.db.WithContext(ctx).Model(&domain.Order{}).Select("COALESCE(SUM(total), 0) as sum").Where("status = ? AND created_at >= ?", domain.OrderStatusCompleted, weekAgo).Scan(&revenueWeek)
 	stats.Revenue.RevenueWeek = revenueWeek.Sum
 
 	var revenueMonth struct{ Sum float64 }
-	uc.db.WithContext(ctx).Model(&domain.Order{}).Select("COALESCE(SUM(total_amount), 0) as sum").Where("status = ? AND created_at >= ?", domain.OrderStatusCompleted, monthAgo).Scan(&revenueMonth)
+	uc.db.WithContext(ctx).Model(&domain.Order{}).Select("COALESCE(SUM(total), 0) as sum").Where("status = ? AND created_at >= ?", domain.OrderStatusCompleted, monthAgo).Scan(&revenueMonth)
 	stats.Revenue.RevenueMonth = revenueMonth.Sum
 
 	uc.db.WithContext(ctx).Model(&domain.Order{}).Where("status = ?", domain.OrderStatusCompleted).Count(&stats.Revenue.TotalOrders)
@@ -125,26 +202,85 @@ func (uc *UseCase) GetDashboardStats(ctx context.Context) (*DashboardStats, erro
 		stats.Enrollments.CompletionRate = float64(stats.Enrollments.CompletedCourses) / float64(stats.Enrollments.TotalEnrollments) * 100
 	}
 
+	if !rng.IsZero() {
+		stats.Range = uc.rangeStats(ctx, rng)
+	}
+
 	return stats, nil
 }
 
+func (uc *UseCase) rangeStats(ctx context.Context, rng DateRange) *RangeStats {
+	stats := &RangeStats{From: rng.From, To: rng.To}
+
+	uc.db.WithContext(ctx).Model(&domain.User{}).Where("created_at >= ? AND created_at < ?", rng.From, rng.To).Count(&stats.NewUsers)
+
+	var revenue struct{ Sum float64 }
+	uc.db.WithContext(ctx).Model(&domain.Order{}).Select("COALESCE(SUM(total), 0) as sum").Where("status = ? AND created_at >= ? AND created_at < ?", domain.OrderStatusCompleted, rng.From, rng.To).Scan(&revenue)
+	stats.Revenue = revenue.Sum
+	uc.db.WithContext(ctx).Model(&domain.Order{}).Where("status = ? AND created_at >= ? AND created_at < ?", domain.OrderStatusCompleted, rng.From, rng.To).Count(&stats.Orders)
+	if stats.Orders > 0 {
+		stats.AvgOrderValue = stats.Revenue / float64(stats.Orders)
+	}
+
+	uc.db.WithContext(ctx).Model(&domain.Enrollment{}).Where("enrolled_at >= ? AND enrolled_at < ?", rng.From, rng.To).Count(&stats.Enrollments)
+	uc.db.WithContext(ctx).Model(&domain.Enrollment{}).Where("completed_at >= ? AND completed_at < ?", rng.From, rng.To).Count(&stats.CompletedCourses)
+
+	return stats
+}
+
 // RevenueChartData for time series charts
 type RevenueChartData struct {
-	Labels []string  `json:"labels"`
-	Values []float64 `json:"values"`
+	Labels   []string  `json:"labels"`
+	Values   []float64 `json:"values"`
+	Interval string    `json:"interval,omitempty"` // bucket size for date ranges: day, week or month
 }
 
-// GetRevenueChart returns revenue over time
-func (uc *UseCase) GetRevenueChart(ctx context.Context, period string) (*RevenueChartData, error) {
+// GetRevenueChart returns revenue over time, from the cache unless refresh is
+// set. A date range replaces period and is bucketed by day, week or month
+// depending on its length.
+func (uc *UseCase) GetRevenueChart(ctx context.Context, period string, rng DateRange, refresh bool) (*RevenueChartData, error) {
+	if !rng.IsZero() {
+		return cached(uc.cache, "revenue-chart"+rng.cacheKey(), refresh, func() (*RevenueChartData, error) {
+			return uc.rangeRevenueChart(ctx, rng)
+		})
+	}
+
+	if period != "week" && period != "year" {
+		period = "month"
+	}
+	return cached(uc.cache, "revenue-chart:"+period, refresh, func() (*RevenueChartData, error) {
+		return uc.revenueChart(ctx, period)
+	})
+}
+
+// orderRevenue totals completed orders placed from start up to end
+func (uc *UseCase) orderRevenue(ctx context.Context) func(start, end time.Time) float64 {
+	return func(start, end time.Time) float64 {
+		var revenue struct{ Sum float64 }
+		uc.db.WithContext(ctx).Model(&domain.Order{}).
+			Select("COALESCE(SUM(total), 0) as sum").
+			Where("status = ? AND created_at >= ? AND created_at < ?", domain.OrderStatusCompleted, start, end).
+			Scan(&revenue)
+		return revenue.Sum
+	}
+}
+
+func (uc *UseCase) rangeRevenueChart(ctx context.Context, rng DateRange) (*RevenueChartData, error) {
+	return rangeChart(rng, uc.orderRevenue(ctx)), nil
+}
+
+func (uc *UseCase) revenueChart(ctx context.Context, period string) (*RevenueChartData, error) {
+	return periodChart(period, uc.orderRevenue(ctx)), nil
+}
+
+// periodChart has one bucket per day over the last week, month or year
+func periodChart(period string, sum func(start, end time.Time) float64) *RevenueChartData {
 	var days int
 	var format string
 	switch period {
 	case "week":
 		days = 7
 		format = "Mon"
-	case "month":
-		days = 30
-		format = "Jan 02"
 	case "year":
 		days = 365
 		format = "Jan"
@@ -164,18 +300,46 @@ func (uc *UseCase) GetRevenueChart(ctx context.Context, period string) (*Revenue
 		startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 		endOfDay := startOfDay.AddDate(0, 0, 1)
 
-		var revenue struct{ Sum float64 }
-		uc.db.WithContext(ctx).Model(&domain.Order{}).
-			Select("COALESCE(SUM(total_amount), 0) as sum").
-			Where("status = ? AND created_at >= ? AND created_at < ?", domain.OrderStatusCompleted, startOfDay, endOfDay).
-			Scan(&revenue)
-
 		idx := days - 1 - i
 		data.Labels[idx] = date.Format(format)
-		data.Values[idx] = revenue.Sum
+		data.Values[idx] = sum(startOfDay, endOfDay)
+	}
+
+	return data
+}
+
+// rangeChart buckets a date range by day, week or month depending on its
+// length
+func rangeChart(rng DateRange, sum func(start, end time.Time) float64) *RevenueChartData {
+	span := rng.To.Sub(rng.From)
+	data := &RevenueChartData{}
+	var next func(time.Time) time.Time
+	var format string
+	switch {
+	case span <= 62*24*time.Hour:
+		data.Interval = "day"
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+		format = "Jan 02"
+	case span <= 366*24*time.Hour:
+		data.Interval = "week"
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+		format = "Jan 02"
+	default:
+		data.Interval = "month"
+		next = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		format = "Jan 2006"
+	}
+
+	for start := rng.From; start.Before(rng.To); start = next(start) {
+		end := next(start)
+		if end.After(rng.To) {
+			end = rng.To
+		}
+		data.Labels = append(data.Labels, start.Format(format))
+		data.Values = append(data.Values, sum(start, end))
 	}
 
-	return data, nil
+	return data
 }
 
 // TopCourse represents a top performing course
@@ -188,20 +352,35 @@ type TopCourse struct {
 	Rating        float64   `json:"rating"`
 }
 
-// GetTopCourses returns top performing courses
-func (uc *UseCase) GetTopCourses(ctx context.Context, limit int, sortBy string) ([]TopCourse, error) {
+// GetTopCourses returns top performing courses, from the cache unless refresh
+// is set. A date range limits revenue to orders placed within it.
+func (uc *UseCase) GetTopCourses(ctx context.Context, limit int, sortBy string, rng DateRange, refresh bool) ([]TopCourse, error) {
 	if limit < 1 || limit > 50 {
 		limit = 10
 	}
+	if sortBy != "revenue" && sortBy != "rating" {
+		sortBy = "enrollments"
+	}
+	key := fmt.Sprintf("top-courses:%s:%d", sortBy, limit) + rng.cacheKey()
+	return cached(uc.cache, key, refresh, func() ([]TopCourse, error) {
+		return uc.topCourses(ctx, limit, sortBy, rng)
+	})
+}
 
+func (uc *UseCase) topCourses(ctx context.Context, limit int, sortBy string, rng DateRange) ([]TopCourse, error) {
 	orderClause := "total_students DESC"
 	switch sortBy {
 	case "revenue":
 		orderClause = "revenue DESC"
 	case "rating":
 		orderClause = "rating DESC"
-	case "enrollments":
-		orderClause = "total_students DESC"
+	}
+
+	orderJoin := "LEFT JOIN orders ON orders.id = order_items.order_id AND orders.status = ?"
+	joinArgs := []interface{}{domain.OrderStatusCompleted}
+	if !rng.IsZero() {
+		orderJoin += " AND orders.created_at >= ? AND orders.created_at < ?"
+		joinArgs = append(joinArgs, rng.From, rng.To)
 	}
 
 	var courses []TopCourse
@@ -213,11 +392,11 @@ func (uc *UseCase) GetTopCourses(ctx context.Context, limit int, sortBy string)
 			CONCAT(users.first_name, ' ', users.last_name) as instructor,
 			courses.total_students,
 			courses.rating,
-			COALESCE(SUM(order_items.price), 0) as revenue
+			COALESCE(SUM(order_items.price) FILTER (WHERE orders.id IS NOT NULL), 0) as revenue
 		`).
 		Joins("LEFT JOIN users ON users.id = courses.instructor_id").
 		Joins("LEFT JOIN order_items ON order_items.course_id = courses.id").
-		Joins("LEFT JOIN orders ON orders.id = order_items.order_id AND orders.status = ?", domain.OrderStatusCompleted).
+		Joins(orderJoin, joinArgs...).
 		Where("courses.status = ?", domain.CourseStatusPublished).
 		Group("courses.id, users.first_name, users.last_name").
 		Order(orderClause).
@@ -237,11 +416,19 @@ type TopInstructor struct {
 	AvgRating     float64   `json:"avg_rating"`
 }
 
-// GetTopInstructors returns top performing instructors
-func (uc *UseCase) GetTopInstructors(ctx context.Context, limit int) ([]TopInstructor, error) {
+// GetTopInstructors returns top performing instructors, from the cache unless
+// refresh is set
+func (uc *UseCase) GetTopInstructors(ctx context.Context, limit int, refresh bool) ([]TopInstructor, error) {
 	if limit < 1 || limit > 50 {
 		limit = 10
 	}
+	key := fmt.Sprintf("top-instructors:%d", limit)
+	return cached(uc.cache, key, refresh, func() ([]TopInstructor, error) {
+		return uc.topInstructors(ctx, limit)
+	})
+}
+
+func (uc *UseCase) topInstructors(ctx context.Context, limit int) ([]TopInstructor, error) {
 
 	var instructors []TopInstructor
 	err := uc.db.WithContext(ctx).
@@ -274,21 +461,27 @@ type RecentOrder struct {
 	CreatedAt   time.Time `json:"created_at"`
 }
 
-// GetRecentOrders returns recent orders
-func (uc *UseCase) GetRecentOrders(ctx context.Context, limit int) ([]RecentOrder, error) {
+// GetRecentOrders returns recent orders, optionally only those placed within
+// a date range
+func (uc *UseCase) GetRecentOrders(ctx context.Context, limit int, rng DateRange) ([]RecentOrder, error) {
 	if limit < 1 || limit > 50 {
 		limit = 10
 	}
 
+	query := uc.db.WithContext(ctx)
+	if !rng.IsZero() {
+		query = query.Where("orders.created_at >= ? AND orders.created_at < ?", rng.From, rng.To)
+	}
+
 	var orders []RecentOrder
-	err := uc.db.WithContext(ctx).
+	err := query.
 		Table("orders").
 		Select(`
 			orders.id,
 			orders.order_number,
 			CONCAT(users.first_name, ' ', users.last_name) as user_name,
 			users.email as user_email,
-			orders.total_amount,
+			orders.total AS total_amount,
 			orders.status,
 			orders.created_at
 		`).
@@ -357,3 +550,14 @@ func (uc *UseCase) GetSystemHealth(ctx context.Context) (*SystemHealth, error) {
 
 	return health, nil
 }
+
+// ListAuditLogs returns audit entries, newest first
+func (uc *UseCase) ListAuditLogs(ctx context.Context, filters repository.AuditLogFilters) ([]domain.AuditLog, int64, error) {
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 || filters.Limit > 100 {
+		filters.Limit = 50
+	}
+	return uc.auditRepo.List(ctx, filters)
+}
@@ -0,0 +1,307 @@
+package admin_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/testdb"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/admin"
+)
+
+// ordersTable answers the dashboard's order aggregates from memory.
+// Columns are resolved against the Order schema, so a query naming a column
+// the table doesn't have fails the way Postgres would.
+type ordersTable struct {
+	*testdb.Driver
+	orders []domain.Order
+}
+
+var (
+	sumPattern   = regexp.MustCompile(`SUM\((\w+)\)`)
+	tablePattern = regexp.MustCompile(`FROM "(\w+)"`)
+	orderSchema  = func() *schema.Schema {
+		s, err := schema.Parse(&domain.Order{}, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			panic(err)
+		}
+		return s
+	}()
+)
+
+func (d *ordersTable) answer(query string, args []driver.NamedValue) (driver.Rows, error) {
+	// Listings come back empty; only aggregates are modelled
+	if strings.Contains(query, "ORDER BY") {
+		return testdb.Rows([]string{"id"}), nil
+	}
+
+	table := tablePattern.FindStringSubmatch(query)
+	if table == nil || table[1] != "orders" {
+		return testdb.Value("count", int64(0)), nil
+	}
+
+	matching := d.matching(args)
+	sum := sumPattern.FindStringSubmatch(query)
+	if sum == nil {
+		return testdb.Value("count", int64(len(matching))), nil
+	}
+
+	field := orderSchema.LookUpField(sum[1])
+	if field == nil {
+		return nil, fmt.Errorf("column %q does not exist", sum[1])
+	}
+	var total float64
+	for _, o := range matching {
+		v, _ := field.ValueOf(context.Background(), reflect.ValueOf(o))
+		total += v.(float64)
+	}
+	return testdb.Value("sum", total), nil
+}
+
+// matching filters by "status = ? [AND created_at >= ? [AND created_at < ?]]"
+func (d *ordersTable) matching(args []driver.NamedValue) []domain.Order {
+	var out []domain.Order
+	for _, o := range d.orders {
+		if len(args) > 0 && string(o.Status) != args[0].Value.(string) {
+			continue
+		}
+		if len(args) > 1 && o.CreatedAt.Before(args[1].Value.(time.Time)) {
+			continue
+		}
+		if len(args) > 2 && !o.CreatedAt.Before(args[2].Value.(time.Time)) {
+			continue
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+var fakeOrders *ordersTable
+
+func newUseCase(t *testing.T, orders []domain.Order, cacheTTL time.Duration) *admin.UseCase {
+	fakeOrders = &ordersTable{orders: orders}
+	db, log := testdb.Fake(t, fakeOrders.answer)
+	fakeOrders.Driver = log
+	return admin.NewUseCase(db, nil, cacheTTL)
+}
+
+func TestGetDashboardStats_RevenueSumsCompletedOrderTotals(t *testing.T) {
+	now := time.Now()
+	orders := []domain.Order{
+		{Status: domain.OrderStatusCompleted, Subtotal: 60, Total: 49.99, CreatedAt: now},
+		{Status: domain.OrderStatusCompleted, Subtotal: 100, Total: 100, CreatedAt: now.AddDate(0, 0, -10)},
+		{Status: domain.OrderStatusPending, Subtotal: 30, Total: 30, CreatedAt: now},
+		{Status: domain.OrderStatusRefunded, Subtotal: 20, Total: 20, CreatedAt: now},
+	}
+
+	stats, err := newUseCase(t, orders, 0).GetDashboardStats(context.Background(), admin.DateRange{}, false)
+	require.NoError(t, err)
+
+	assert.InDelta(t, 149.99, stats.Revenue.TotalRevenue, 0.001)
+	assert.InDelta(t, 49.99, stats.Revenue.RevenueToday, 0.001)
+	assert.InDelta(t, 49.99, stats.Revenue.RevenueWeek, 0.001)
+	assert.InDelta(t, 149.99, stats.Revenue.RevenueMonth, 0.001)
+	assert.Equal(t, int64(2), stats.Revenue.TotalOrders)
+	assert.InDelta(t, 74.995, stats.Revenue.AvgOrderValue, 0.001)
+}
+
+func TestGetRevenueChart_SumsCompletedOrderTotalsPerDay(t *testing.T) {
+	now := time.Now()
+	orders := []domain.Order{
+		{Status: domain.OrderStatusCompleted, Total: 49.99, CreatedAt: now},
+		{Status: domain.OrderStatusCompleted, Total: 25, CreatedAt: now},
+		{Status: domain.OrderStatusPending, Total: 30, CreatedAt: now},
+	}
+
+	chart, err := newUseCase(t, orders, 0).GetRevenueChart(context.Background(), "week", admin.DateRange{}, false)
+	require.NoError(t, err)
+
+	require.Len(t, chart.Values, 7)
+	assert.InDelta(t, 74.99, chart.Values[6], 0.001)
+	for _, v := range chart.Values[:6] {
+		assert.Zero(t, v)
+	}
+}
+
+func TestGetDashboardStats_Cached(t *testing.T) {
+	orders := []domain.Order{{Status: domain.OrderStatusCompleted, Total: 40, CreatedAt: time.Now()}}
+	uc := newUseCase(t, orders, time.Minute)
+	ctx := context.Background()
+
+	first, err := uc.GetDashboardStats(ctx, admin.DateRange{}, false)
+	require.NoError(t, err)
+	queries := len(fakeOrders.Queries())
+	require.NotZero(t, queries)
+
+	// A new order isn't seen until the cache is bypassed
+	fakeOrders.orders = append(fakeOrders.orders, domain.Order{Status: domain.OrderStatusCompleted, Total: 60, CreatedAt: time.Now()})
+
+	cachedStats, err := uc.GetDashboardStats(ctx, admin.DateRange{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, queries, len(fakeOrders.Queries()))
+	assert.InDelta(t, first.Revenue.TotalRevenue, cachedStats.Revenue.TotalRevenue, 0.001)
+
+	refreshed, err := uc.GetDashboardStats(ctx, admin.DateRange{}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2*queries, len(fakeOrders.Queries()))
+	assert.InDelta(t, 100, refreshed.Revenue.TotalRevenue, 0.001)
+}
+
+func TestGetRevenueChart_CachedPerPeriod(t *testing.T) {
+	orders := []domain.Order{{Status: domain.OrderStatusCompleted, Total: 40, CreatedAt: time.Now()}}
+	uc := newUseCase(t, orders, time.Minute)
+	ctx := context.Background()
+
+	week, err := uc.GetRevenueChart(ctx, "week", admin.DateRange{}, false)
+	require.NoError(t, err)
+	month, err := uc.GetRevenueChart(ctx, "month", admin.DateRange{}, false)
+	require.NoError(t, err)
+	assert.Len(t, week.Values, 7)
+	assert.Len(t, month.Values, 30)
+	assert.Equal(t, 37, len(fakeOrders.Queries()))
+
+	// Unknown periods fall back to the month chart and share its entry
+	again, err := uc.GetRevenueChart(ctx, "", admin.DateRange{}, false)
+	require.NoError(t, err)
+	assert.Len(t, again.Values, 30)
+	assert.Equal(t, 37, len(fakeOrders.Queries()))
+}
+
+func TestGetInstructorRevenueChart_CacheIsBounded(t *testing.T) {
+	uc := newUseCase(t, nil, time.Hour)
+	ctx := context.Background()
+	instructorID := uuid.New()
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	chart := func(i int) {
+		rng := admin.DateRange{From: day.AddDate(0, 0, i), To: day.AddDate(0, 0, i+1)}
+		_, err := uc.GetInstructorRevenueChart(ctx, instructorID, "", rng, false)
+		require.NoError(t, err)
+	}
+
+	// One tutor asking for many ranges pushes out the oldest entries
+	// rather than growing the cache without limit
+	for i := 0; i <= 1000; i++ {
+		chart(i)
+	}
+	queries := len(fakeOrders.Queries())
+	chart(1000)
+	assert.Equal(t, queries, len(fakeOrders.Queries()), "the newest range is still cached")
+	chart(0)
+	assert.Greater(t, len(fakeOrders.Queries()), queries, "the oldest range was evicted")
+}
+
+func TestGetDashboardStats_DateRange(t *testing.T) {
+	now := time.Now()
+	orders := []domain.Order{
+		{Status: domain.OrderStatusCompleted, Total: 100, CreatedAt: now.AddDate(0, 0, -40)},
+		{Status: domain.OrderStatusCompleted, Total: 50, CreatedAt: now.AddDate(0, 0, -10)},
+		{Status: domain.OrderStatusCompleted, Total: 30, CreatedAt: now.AddDate(0, 0, -5)},
+		{Status: domain.OrderStatusPending, Total: 70, CreatedAt: now.AddDate(0, 0, -5)},
+		{Status: domain.OrderStatusCompleted, Total: 20, CreatedAt: now},
+	}
+	rng := admin.DateRange{From: now.AddDate(0, 0, -15), To: now.AddDate(0, 0, -1)}
+
+	stats, err := newUseCase(t, orders, 0).GetDashboardStats(context.Background(), rng, false)
+	require.NoError(t, err)
+
+	require.NotNil(t, stats.Range)
+	assert.InDelta(t, 80, stats.Range.Revenue, 0.001)
+	assert.Equal(t, int64(2), stats.Range.Orders)
+	assert.InDelta(t, 40, stats.Range.AvgOrderValue, 0.001)
+	// The all-time figures are unchanged
+	assert.InDelta(t, 200, stats.Revenue.TotalRevenue, 0.001)
+}
+
+func TestGetRevenueChart_DateRangeBuckets(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	orders := []domain.Order{
+		{Status: domain.OrderStatusCompleted, Total: 10, CreatedAt: from.AddDate(0, 0, 2)},
+		{Status: domain.OrderStatusCompleted, Total: 15, CreatedAt: from.AddDate(0, 0, 100)},
+		{Status: domain.OrderStatusCompleted, Total: 25, CreatedAt: from.AddDate(0, 0, 390)},
+	}
+
+	tests := []struct {
+		name     string
+		days     int
+		interval string
+		buckets  int
+		total    float64
+	}{
+		{"daily up to two months", 7, "day", 7, 10},
+		{"weekly up to a year", 120, "week", 18, 25},
+		{"monthly beyond a year", 400, "month", 14, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := admin.DateRange{From: from, To: from.AddDate(0, 0, tt.days)}
+			chart, err := newUseCase(t, orders, 0).GetRevenueChart(context.Background(), "", rng, false)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.interval, chart.Interval)
+			assert.Len(t, chart.Values, tt.buckets)
+			assert.Len(t, chart.Labels, tt.buckets)
+			var total float64
+			for _, v := range chart.Values {
+				total += v
+			}
+			assert.InDelta(t, tt.total, total, 0.001)
+		})
+	}
+}
+
+func TestDateRange_Validate(t *testing.T) {
+	now := time.Now()
+	assert.NoError(t, admin.DateRange{From: now.AddDate(-1, 0, 0), To: now}.Validate())
+	assert.Error(t, admin.DateRange{From: now, To: now.AddDate(0, 0, -1)}.Validate())
+	assert.Error(t, admin.DateRange{From: now.AddDate(-3, 0, 0), To: now}.Validate())
+}
+
+func TestDateRange_WholeDays(t *testing.T) {
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	rng := admin.DateRange{From: day.Add(9 * time.Hour), To: day.AddDate(0, 0, 5).Add(17*time.Hour + 3*time.Second)}
+	assert.Equal(t, admin.DateRange{From: day, To: day.AddDate(0, 0, 6)}, rng.WholeDays())
+
+	// Ranges ending at different times of the same day are the same range
+	later := admin.DateRange{From: rng.From, To: rng.To.Add(time.Hour)}
+	assert.Equal(t, rng.WholeDays(), later.WholeDays())
+
+	aligned := admin.DateRange{From: day, To: day.AddDate(0, 0, 1)}
+	assert.Equal(t, aligned, aligned.WholeDays())
+	assert.True(t, admin.DateRange{}.WholeDays().IsZero())
+}
+
+func TestGetInstructorDashboard_ScopedToInstructor(t *testing.T) {
+	instructorID := uuid.New()
+	uc := newUseCase(t, nil, 0)
+
+	_, err := uc.GetInstructorDashboard(context.Background(), instructorID, false)
+	require.NoError(t, err)
+	_, err = uc.GetInstructorRevenueChart(context.Background(), instructorID, "week", admin.DateRange{}, false)
+	require.NoError(t, err)
+
+	// Every query must be filtered by the instructor, or one instructor
+	// could see another's figures
+	require.NotEmpty(t, fakeOrders.Queries())
+	for i, query := range fakeOrders.Queries() {
+		var scoped bool
+		for _, arg := range query.Args {
+			if arg.Value == instructorID.String() {
+				scoped = true
+			}
+		}
+		assert.True(t, scoped, "query %d is not scoped to the instructor", i)
+	}
+}
@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCacheEntries bounds the cache, which any tutor can add entries to by
+// asking for their own charts over new date ranges
+const maxCacheEntries = 1000
+
+// statsCache keeps recently computed dashboard results in memory so admins
+// reloading the page don't rerun every aggregate. Each API instance has its
+// own copy.
+type statsCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+func newStatsCache(ttl time.Duration) *statsCache {
+	return &statsCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+// cached returns the value stored under key, or loads and stores it when
+// missing, expired or refresh is set. Failed loads are not cached.
+func cached[T any](c *statsCache, key string, refresh bool, load func() (T, error)) (T, error) {
+	if c.ttl <= 0 {
+		return load()
+	}
+
+	now := time.Now()
+	if !refresh {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		c.mu.Unlock()
+		if ok && now.Before(entry.expiresAt) {
+			return entry.value.(T), nil
+		}
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	c.mu.Lock()
+	c.evictExpired(now)
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCacheEntries {
+		c.evictOldest()
+	}
+	c.entries[key] = cacheEntry{value: value, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// evictExpired drops entries that can no longer be served, so keys that
+// aren't asked for again don't stay in memory. The caller holds c.mu.
+func (c *statsCache) evictExpired(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// evictOldest drops the entry that would expire first to make room for a
+// new one. The caller holds c.mu.
+func (c *statsCache) evictOldest() {
+	var oldest string
+	var expiresAt time.Time
+	for key, entry := range c.entries {
+		if oldest == "" || entry.expiresAt.Before(expiresAt) {
+			oldest, expiresAt = key, entry.expiresAt
+		}
+	}
+	delete(c.entries, oldest)
+}
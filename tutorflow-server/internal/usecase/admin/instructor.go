@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// instructorCourses matches courses an instructor owns or co-teaches. Every
+// instructor dashboard query is scoped through it or through the
+// instructor's own earnings.
+const instructorCourses = "(courses.instructor_id = ? OR courses.id IN (SELECT course_id FROM course_instructors WHERE instructor_id = ?))"
+
+// InstructorDashboard is an instructor's view of their own courses
+type InstructorDashboard struct {
+	TotalCourses      int64                   `json:"total_courses"`
+	PublishedCourses  int64                   `json:"published_courses"`
+	TotalStudents     int64                   `json:"total_students"`
+	Revenue           float64                 `json:"revenue"` // the instructor's share of sales, all time
+	RevenueMonth      float64                 `json:"revenue_month"`
+	AvgRating         float64                 `json:"avg_rating"`
+	RecentEnrollments []InstructorEnrollment  `json:"recent_enrollments"`
+	Courses           []InstructorCourseStats `json:"courses"`
+}
+
+// InstructorEnrollment is a recent enrollment in one of the instructor's
+// courses
+type InstructorEnrollment struct {
+	CourseID    uuid.UUID `json:"course_id"`
+	CourseTitle string    `json:"course_title"`
+	StudentName string    `json:"student_name"`
+	EnrolledAt  time.Time `json:"enrolled_at"`
+}
+
+// InstructorCourseStats breaks the dashboard down by course
+type InstructorCourseStats struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	Status   string    `json:"status"`
+	Students int64     `json:"students"`
+	Rating   float64   `json:"rating"`
+	Revenue  float64   `json:"revenue"` // the instructor's share for this course
+}
+
+// GetInstructorDashboard returns statistics for the courses instructorID
+// teaches, from the cache unless refresh is set
+func (uc *UseCase) GetInstructorDashboard(ctx context.Context, instructorID uuid.UUID, refresh bool) (*InstructorDashboard, error) {
+	return cached(uc.cache, "instructor-dashboard:"+instructorID.String(), refresh, func() (*InstructorDashboard, error) {
+		return uc.instructorDashboard(ctx, instructorID)
+	})
+}
+
+func (uc *UseCase) instructorDashboard(ctx context.Context, instructorID uuid.UUID) (*InstructorDashboard, error) {
+	stats := &InstructorDashboard{}
+	monthAgo := time.Now().AddDate(0, -1, 0)
+
+	uc.db.WithContext(ctx).Model(&domain.Course{}).Where(instructorCourses, instructorID, instructorID).Count(&stats.TotalCourses)
+	uc.db.WithContext(ctx).Model(&domain.Course{}).Where(instructorCourses, instructorID, instructorID).
+		Where("status = ?", domain.CourseStatusPublished).Count(&stats.PublishedCourses)
+
+	var avgRating struct{ Avg float64 }
+	uc.db.WithContext(ctx).Model(&domain.Course{}).Select("COALESCE(AVG(rating), 0) as avg").
+		Where(instructorCourses, instructorID, instructorID).Where("rating > 0").Scan(&avgRating)
+	stats.AvgRating = avgRating.Avg
+
+	uc.db.WithContext(ctx).Model(&domain.Enrollment{}).
+		Joins("JOIN courses ON courses.id = enrollments.course_id").
+		Where(instructorCourses, instructorID, instructorID).
+		Distinct("enrollments.user_id").Count(&stats.TotalStudents)
+
+	stats.Revenue = uc.instructorRevenue(ctx, instructorID)(time.Time{}, time.Now())
+	stats.RevenueMonth = uc.instructorRevenue(ctx, instructorID)(monthAgo, time.Now())
+
+	err := uc.db.WithContext(ctx).Model(&domain.Enrollment{}).
+		Select(`
+			enrollments.course_id,
+			courses.title as course_title,
+			CONCAT(users.first_name, ' ', users.last_name) as student_name,
+			enrollments.enrolled_at
+		`).
+		Joins("JOIN courses ON courses.id = enrollments.course_id").
+		Joins("JOIN users ON users.id = enrollments.user_id").
+		Where(instructorCourses, instructorID, instructorID).
+		Order("enrollments.enrolled_at DESC").
+		Limit(10).
+		Scan(&stats.RecentEnrollments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.db.WithContext(ctx).Model(&domain.Course{}).
+		Select(`
+			courses.id,
+			courses.title,
+			courses.status,
+			courses.rating,
+			(SELECT COUNT(*) FROM enrollments WHERE enrollments.course_id = courses.id) as students,
+			(SELECT COALESCE(SUM(instructor_earnings.amount), 0) FROM instructor_earnings
+				JOIN order_items ON order_items.id = instructor_earnings.order_item_id
+				WHERE order_items.course_id = courses.id AND instructor_earnings.instructor_id = ?) as revenue
+		`, instructorID).
+		Where(instructorCourses, instructorID, instructorID).
+		Order("students DESC").
+		Scan(&stats.Courses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetInstructorRevenueChart returns instructorID's earnings over time in the
+// same shape as the admin revenue chart
+func (uc *UseCase) GetInstructorRevenueChart(ctx context.Context, instructorID uuid.UUID, period string, rng DateRange, refresh bool) (*RevenueChartData, error) {
+	key := "instructor-revenue-chart:" + instructorID.String()
+	if !rng.IsZero() {
+		return cached(uc.cache, key+rng.cacheKey(), refresh, func() (*RevenueChartData, error) {
+			return rangeChart(rng, uc.instructorRevenue(ctx, instructorID)), nil
+		})
+	}
+
+	if period != "week" && period != "year" {
+		period = "month"
+	}
+	return cached(uc.cache, key+":"+period, refresh, func() (*RevenueChartData, error) {
+		return periodChart(period, uc.instructorRevenue(ctx, instructorID)), nil
+	})
+}
+
+// instructorRevenue totals the instructor's earnings recorded from start up
+// to end
+func (uc *UseCase) instructorRevenue(ctx context.Context, instructorID uuid.UUID) func(start, end time.Time) float64 {
+	return func(start, end time.Time) float64 {
+		var revenue struct{ Sum float64 }
+		uc.db.WithContext(ctx).Model(&domain.InstructorEarning{}).
+			Select("COALESCE(SUM(amount), 0) as sum").
+			Where("instructor_id = ? AND created_at >= ? AND created_at < ?", instructorID, start, end).
+			Scan(&revenue)
+		return revenue.Sum
+	}
+}
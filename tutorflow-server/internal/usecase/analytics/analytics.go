@@ -0,0 +1,118 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+)
+
+const (
+	// maxPayloadBytes caps the size of a single event payload
+	maxPayloadBytes = 4096
+	// maxClockSkew is how far in the future a client timestamp may be
+	maxClockSkew = 5 * time.Minute
+	// maxEventAge is how old a buffered client event may be
+	maxEventAge = 7 * 24 * time.Hour
+)
+
+// UseCase defines analytics event business logic
+type UseCase struct {
+	eventRepo repository.AnalyticsEventRepository
+}
+
+// NewUseCase creates a new analytics use case
+func NewUseCase(eventRepo repository.AnalyticsEventRepository) *UseCase {
+	return &UseCase{eventRepo: eventRepo}
+}
+
+// EventInput is a single client-side event
+type EventInput struct {
+	Type       domain.AnalyticsEventType `json:"type" validate:"required"`
+	Payload    json.RawMessage           `json:"payload,omitempty"`
+	OccurredAt *time.Time                `json:"occurred_at,omitempty"`
+}
+
+// TrackInput is a batch of client-side events
+type TrackInput struct {
+	SessionID *string      `json:"session_id,omitempty" validate:"omitempty,max=100"`
+	Events    []EventInput `json:"events" validate:"required,min=1,max=50,dive"`
+}
+
+// Track validates and stores a batch of events for an optional user
+func (uc *UseCase) Track(ctx context.Context, userID *uuid.UUID, input TrackInput) (int, error) {
+	now := time.Now()
+	events := make([]domain.AnalyticsEvent, 0, len(input.Events))
+	var errs domain.ValidationErrors
+
+	for i, e := range input.Events {
+		field := fmt.Sprintf("events[%d]", i)
+
+		if !e.Type.IsValid() {
+			errs = append(errs, domain.ValidationError{Field: field + ".type", Message: "Unknown event type"})
+			continue
+		}
+
+		var payload *string
+		if len(e.Payload) > 0 && string(e.Payload) != "null" {
+			if len(e.Payload) > maxPayloadBytes {
+				errs = append(errs, domain.ValidationError{Field: field + ".payload", Message: "Payload is too large"})
+				continue
+			}
+			var obj map[string]interface{}
+			if err := json.Unmarshal(e.Payload, &obj); err != nil {
+				errs = append(errs, domain.ValidationError{Field: field + ".payload", Message: "Payload must be a JSON object"})
+				continue
+			}
+			s := string(e.Payload)
+			payload = &s
+		}
+
+		occurredAt := now
+		if e.OccurredAt != nil {
+			if e.OccurredAt.After(now.Add(maxClockSkew)) || e.OccurredAt.Before(now.Add(-maxEventAge)) {
+				errs = append(errs, domain.ValidationError{Field: field + ".occurred_at", Message: "Timestamp is out of range"})
+				continue
+			}
+			occurredAt = *e.OccurredAt
+		}
+
+		events = append(events, domain.AnalyticsEvent{
+			UserID:     userID,
+			SessionID:  input.SessionID,
+			Type:       e.Type,
+			Payload:    payload,
+			OccurredAt: occurredAt,
+		})
+	}
+
+	if len(errs) > 0 {
+		return 0, errs
+	}
+
+	if err := uc.eventRepo.CreateBatch(ctx, events); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// ListEvents returns stored events for admins
+func (uc *UseCase) ListEvents(ctx context.Context, filters repository.AnalyticsEventFilters) ([]domain.AnalyticsEvent, int64, error) {
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 || filters.Limit > 100 {
+		filters.Limit = 50
+	}
+	return uc.eventRepo.List(ctx, filters)
+}
+
+// GetSummary returns event counts grouped by type
+func (uc *UseCase) GetSummary(ctx context.Context, filters repository.AnalyticsEventFilters) ([]repository.AnalyticsEventCount, error) {
+	return uc.eventRepo.CountByType(ctx, filters)
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
 )
 
 // UseCase defines announcement business logic
@@ -16,7 +18,9 @@ type UseCase struct {
 	announcementRepo repository.AnnouncementRepository
 	courseRepo       repository.CourseRepository
 	enrollmentRepo   repository.EnrollmentRepository
-	notificationRepo repository.NotificationRepository
+	notifier         *notification.Notifier
+	prefRepo         repository.NotificationPreferenceRepository
+	emailSvc         *email.Service
 }
 
 // NewUseCase creates a new announcement use case
@@ -24,19 +28,32 @@ func NewUseCase(
 	announcementRepo repository.AnnouncementRepository,
 	courseRepo repository.CourseRepository,
 	enrollmentRepo repository.EnrollmentRepository,
-	notificationRepo repository.NotificationRepository,
+	notifier *notification.Notifier,
+	prefRepo repository.NotificationPreferenceRepository,
+	emailSvc *email.Service,
 ) *UseCase {
 	return &UseCase{
 		announcementRepo: announcementRepo,
 		courseRepo:       courseRepo,
 		enrollmentRepo:   enrollmentRepo,
-		notificationRepo: notificationRepo,
+		notifier:         notifier,
+		prefRepo:         prefRepo,
+		emailSvc:         emailSvc,
 	}
 }
 
-// GetAnnouncement returns an announcement by ID
-func (uc *UseCase) GetAnnouncement(ctx context.Context, id uuid.UUID) (*domain.Announcement, error) {
-	return uc.announcementRepo.GetByID(ctx, id)
+// GetAnnouncement returns an announcement by ID. Announcements that are still
+// scheduled or have expired are only visible to their author and admins.
+func (uc *UseCase) GetAnnouncement(ctx context.Context, id, userID uuid.UUID, isAdmin bool) (*domain.Announcement, error) {
+	announcement, err := uc.announcementRepo.GetByID(ctx, id)
+	if err != nil || announcement == nil {
+		return announcement, err
+	}
+	hidden := announcement.IsScheduled() || announcement.IsExpired(time.Now())
+	if hidden && announcement.AuthorID != userID && !isAdmin {
+		return nil, nil
+	}
+	return announcement, nil
 }
 
 // GetCourseAnnouncements returns announcements for a course
@@ -72,7 +89,8 @@ func (uc *UseCase) GetMyFeed(ctx context.Context, userID uuid.UUID, page, limit
 	return uc.announcementRepo.GetForUser(ctx, userID, page, limit)
 }
 
-// GetMyAnnouncements returns announcements created by an instructor
+// GetMyAnnouncements returns announcements created by an instructor,
+// including ones still scheduled or already expired
 func (uc *UseCase) GetMyAnnouncements(ctx context.Context, authorID uuid.UUID, page, limit int) ([]domain.Announcement, int64, error) {
 	if page < 1 {
 		page = 1
@@ -85,13 +103,28 @@ func (uc *UseCase) GetMyAnnouncements(ctx context.Context, authorID uuid.UUID, p
 
 // CreateAnnouncementInput for creating an announcement
 type CreateAnnouncementInput struct {
-	CourseID    *uuid.UUID `json:"course_id,omitempty"`
-	Title       string     `json:"title" validate:"required,min=5,max=255"`
-	Content     string     `json:"content" validate:"required,min=10,max=10000"`
-	IsPinned    bool       `json:"is_pinned,omitempty"`
+	CourseID     *uuid.UUID `json:"course_id,omitempty"`
+	Title        string     `json:"title" validate:"required,min=5,max=255"`
+	Content      string     `json:"content" validate:"required,min=10,max=10000"`
+	IsPinned     bool       `json:"is_pinned,omitempty"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"` // publish later instead of straight away
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`    // hide from learners after this
+	SendEmail    bool       `json:"send_email,omitempty"`    // also email recipients who get notifications by email straight away
+
+	// Deprecated: ScheduledAt is the old name for ScheduledFor. A time that
+	// has already passed publishes straight away, as it always did.
 	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
 }
 
+// schedule returns when the announcement should be published, or nil to
+// publish it now
+func (input CreateAnnouncementInput) schedule() *time.Time {
+	if input.ScheduledFor == nil && input.ScheduledAt != nil && input.ScheduledAt.After(time.Now()) {
+		return input.ScheduledAt
+	}
+	return input.ScheduledFor
+}
+
 // CreateAnnouncement creates an announcement
 func (uc *UseCase) CreateAnnouncement(ctx context.Context, authorID uuid.UUID, isAdmin bool, input CreateAnnouncementInput) (*domain.Announcement, error) {
 	// Verify permissions
@@ -111,59 +144,52 @@ func (uc *UseCase) CreateAnnouncement(ctx context.Context, authorID uuid.UUID, i
 		}
 	}
 
-	publishedAt := time.Now()
-	if input.ScheduledAt != nil && input.ScheduledAt.After(time.Now()) {
-		publishedAt = *input.ScheduledAt
-	}
-
 	announcement := &domain.Announcement{
 		CourseID:    input.CourseID,
 		AuthorID:    authorID,
 		Title:       input.Title,
 		Content:     input.Content,
 		IsPinned:    input.IsPinned,
-		PublishedAt: publishedAt,
+		SendEmail:   input.SendEmail,
+		PublishedAt: time.Now(),
+		ExpiresAt:   input.ExpiresAt,
+	}
+	if announcement.IsPinned {
+		announcement.PinnedAt = &announcement.PublishedAt
+	}
+
+	// A scheduled announcement stays hidden until PublishDue picks it up
+	if at := input.schedule(); at != nil {
+		if err := validateSchedule(*at); err != nil {
+			return nil, err
+		}
+		announcement.ScheduledFor = at
+		announcement.PublishedAt = *at
+	}
+	if err := validateExpiry(announcement); err != nil {
+		return nil, err
 	}
 
 	if err := uc.announcementRepo.Create(ctx, announcement); err != nil {
 		return nil, err
 	}
 
-	// Send notifications to enrolled students (async for course announcements)
-	if input.CourseID != nil && publishedAt.Before(time.Now().Add(time.Minute)) {
-		go uc.notifyEnrolledStudents(context.Background(), *input.CourseID, announcement.Title)
+	// Deliver in the background so large audiences don't hold up the request
+	if !announcement.IsScheduled() {
+		go uc.deliver(context.WithoutCancel(ctx), announcement)
 	}
 
 	return uc.announcementRepo.GetByID(ctx, announcement.ID)
 }
 
-// notifyEnrolledStudents sends notifications to enrolled students
-func (uc *UseCase) notifyEnrolledStudents(ctx context.Context, courseID uuid.UUID, title string) {
-	course, _ := uc.courseRepo.GetByID(ctx, courseID)
-	if course == nil {
-		return
-	}
-
-	enrollments, _, _ := uc.enrollmentRepo.GetByCourse(ctx, courseID, 1, 1000)
-	for _, e := range enrollments {
-		if e.Status == domain.EnrollmentStatusActive {
-			notification := &domain.Notification{
-				UserID: e.UserID,
-				Type:   domain.NotificationAnnouncement,
-				Title:  "New Announcement",
-			}
-			msg := fmt.Sprintf("New announcement in %s: %s", course.Title, title)
-			notification.Message = &msg
-			_ = uc.notificationRepo.Create(ctx, notification)
-		}
-	}
-}
-
 // UpdateAnnouncementInput for updating an announcement
 type UpdateAnnouncementInput struct {
-	Title    string `json:"title" validate:"omitempty,min=5,max=255"`
-	Content  string `json:"content" validate:"omitempty,min=10,max=10000"`
-	IsPinned *bool  `json:"is_pinned,omitempty"`
+	Title        string     `json:"title" validate:"omitempty,min=5,max=255"`
+	Content      string     `json:"content" validate:"omitempty,min=10,max=10000"`
+	IsPinned     *bool      `json:"is_pinned,omitempty"`
+	SendEmail    *bool      `json:"send_email,omitempty"`    // only while scheduled
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"` // reschedule; only while scheduled
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
 }
 
 // UpdateAnnouncement updates an announcement
@@ -183,8 +209,47 @@ func (uc *UseCase) UpdateAnnouncement(ctx context.Context, id, userID uuid.UUID,
 	if input.Content != "" {
 		announcement.Content = input.Content
 	}
-	if input.IsPinned != nil {
+	if input.IsPinned != nil && *input.IsPinned != announcement.IsPinned {
 		announcement.IsPinned = *input.IsPinned
+		announcement.PinnedAt = nil
+		if announcement.IsPinned {
+			now := time.Now()
+			announcement.PinnedAt = &now
+		}
+	}
+	if input.SendEmail != nil || input.ScheduledFor != nil {
+		if !announcement.IsScheduled() {
+			return nil, domain.ErrAnnouncementPublished
+		}
+		if input.SendEmail != nil {
+			announcement.SendEmail = *input.SendEmail
+		}
+	}
+
+	if input.ScheduledFor != nil {
+		if err := validateSchedule(*input.ScheduledFor); err != nil {
+			return nil, err
+		}
+		announcement.ScheduledFor = input.ScheduledFor
+		announcement.PublishedAt = *input.ScheduledFor
+	}
+	if input.ExpiresAt != nil {
+		announcement.ExpiresAt = input.ExpiresAt
+	}
+	if input.ScheduledFor != nil || input.ExpiresAt != nil {
+		if err := validateExpiry(announcement); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.ScheduledFor != nil {
+		ok, err := uc.announcementRepo.Reschedule(ctx, id, *input.ScheduledFor)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, domain.ErrAnnouncementPublished
+		}
 	}
 
 	if err := uc.announcementRepo.Update(ctx, announcement); err != nil {
@@ -194,6 +259,59 @@ func (uc *UseCase) UpdateAnnouncement(ctx context.Context, id, userID uuid.UUID,
 	return announcement, nil
 }
 
+// CancelScheduled deletes an announcement before its scheduled time
+func (uc *UseCase) CancelScheduled(ctx context.Context, id, userID uuid.UUID, isAdmin bool) error {
+	announcement, err := uc.announcementRepo.GetByID(ctx, id)
+	if err != nil || announcement == nil {
+		return domain.ErrAnnouncementNotFound
+	}
+
+	if announcement.AuthorID != userID && !isAdmin {
+		return domain.ErrForbidden
+	}
+
+	ok, err := uc.announcementRepo.DeleteScheduled(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrAnnouncementPublished
+	}
+	return nil
+}
+
+// PublishDue publishes the scheduled announcements whose time has come and
+// delivers them to their audience. It returns how many were published.
+func (uc *UseCase) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := uc.announcementRepo.PublishDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	for i := range due {
+		uc.deliver(ctx, &due[i])
+	}
+	return len(due), nil
+}
+
+// validateExpiry checks an announcement doesn't expire before it is shown
+func validateExpiry(announcement *domain.Announcement) error {
+	if announcement.ExpiresAt == nil {
+		return nil
+	}
+	if announcement.IsExpired(time.Now()) || !announcement.ExpiresAt.After(announcement.PublishedAt) {
+		return domain.ValidationErrors{{Field: "expires_at", Message: "must be in the future and after the announcement is published"}}
+	}
+	return nil
+}
+
+// validateSchedule checks a scheduled time is still ahead
+func validateSchedule(at time.Time) error {
+	if !at.After(time.Now()) {
+		return domain.ValidationErrors{{Field: "scheduled_for", Message: "must be in the future"}}
+	}
+	return nil
+}
+
 // DeleteAnnouncement deletes an announcement
 func (uc *UseCase) DeleteAnnouncement(ctx context.Context, id, userID uuid.UUID, isAdmin bool) error {
 	announcement, err := uc.announcementRepo.GetByID(ctx, id)
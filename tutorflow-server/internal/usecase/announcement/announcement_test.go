@@ -0,0 +1,315 @@
+package announcement_test
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/announcement"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
+)
+
+// The fakes embed the repository interfaces so only the methods these tests
+// use need implementing; anything else panics.
+
+type fakeAnnouncements struct {
+	repository.AnnouncementRepository
+	created    *domain.Announcement
+	recipients []domain.User // sorted by ID
+}
+
+func (r *fakeAnnouncements) Create(ctx context.Context, a *domain.Announcement) error {
+	a.ID = uuid.New()
+	r.created = a
+	return nil
+}
+
+func (r *fakeAnnouncements) GetByID(ctx context.Context, id uuid.UUID) (*domain.Announcement, error) {
+	return r.created, nil
+}
+
+func (r *fakeAnnouncements) GetRecipients(ctx context.Context, courseID *uuid.UUID, after uuid.UUID, limit int) ([]domain.User, error) {
+	var page []domain.User
+	for _, user := range r.recipients {
+		if bytes.Compare(user.ID[:], after[:]) > 0 && len(page) < limit {
+			page = append(page, user)
+		}
+	}
+	return page, nil
+}
+
+func (r *fakeAnnouncements) DeleteScheduled(ctx context.Context, id uuid.UUID) (bool, error) {
+	if r.created == nil || !r.created.IsScheduled() {
+		return false, nil
+	}
+	r.created = nil
+	return true, nil
+}
+
+func (r *fakeAnnouncements) PublishDue(ctx context.Context, now time.Time) ([]domain.Announcement, error) {
+	if r.created == nil || !r.created.IsScheduled() || r.created.ScheduledFor.After(now) {
+		return nil, nil
+	}
+	r.created.ScheduledFor = nil
+	r.created.PublishedAt = now
+	return []domain.Announcement{*r.created}, nil
+}
+
+type fakeCourses struct {
+	repository.CourseRepository
+	course *domain.Course
+}
+
+func (r *fakeCourses) GetByID(ctx context.Context, id uuid.UUID) (*domain.Course, error) {
+	return r.course, nil
+}
+
+type fakeNotifications struct {
+	repository.NotificationRepository
+	mu      sync.Mutex
+	created []domain.Notification
+	batches chan int
+}
+
+func (r *fakeNotifications) CreateBatch(ctx context.Context, notifications []domain.Notification) error {
+	r.mu.Lock()
+	r.created = append(r.created, notifications...)
+	r.mu.Unlock()
+	r.batches <- len(notifications)
+	return nil
+}
+
+type fakePrefs struct {
+	repository.NotificationPreferenceRepository
+	prefs map[uuid.UUID]domain.NotificationPreferences
+}
+
+func (r *fakePrefs) GetByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.NotificationPreferences, error) {
+	return r.prefs, nil
+}
+
+func (r *fakePrefs) GetDigestFrequencies(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.DigestFrequency, error) {
+	return nil, nil
+}
+
+func users(n int) []domain.User {
+	out := make([]domain.User, n)
+	for i := range out {
+		out[i] = domain.User{ID: uuid.New(), Email: "learner@example.com", FirstName: "Learner"}
+	}
+	sort.Slice(out, func(i, j int) bool { return bytes.Compare(out[i].ID[:], out[j].ID[:]) < 0 })
+	return out
+}
+
+// waitForBatches returns the sizes of the next n notification batches
+func waitForBatches(t *testing.T, notifications *fakeNotifications, n int) []int {
+	t.Helper()
+	var sizes []int
+	for len(sizes) < n {
+		select {
+		case size := <-notifications.batches:
+			sizes = append(sizes, size)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d notification batches, want %d", len(sizes), n)
+		}
+	}
+	return sizes
+}
+
+func TestCreateAnnouncement_DeliversToCourseInBatches(t *testing.T) {
+	course := &domain.Course{ID: uuid.New(), Title: "Go Basics"}
+	learners := users(501)
+	optedOut := learners[10].ID
+
+	announcements := &fakeAnnouncements{recipients: learners}
+	notifications := &fakeNotifications{batches: make(chan int, 4)}
+	prefs := &fakePrefs{prefs: map[uuid.UUID]domain.NotificationPreferences{
+		optedOut: {{UserID: optedOut, Category: domain.NotificationCategoryCourse, Channel: domain.NotificationChannelInApp, Enabled: false}},
+	}}
+	uc := announcement.NewUseCase(announcements, &fakeCourses{course: course}, nil, notification.NewNotifier(notifications, prefs, nil), prefs, email.NewService(config.EmailConfig{}))
+
+	_, err := uc.CreateAnnouncement(context.Background(), uuid.New(), true, announcement.CreateAnnouncementInput{
+		CourseID:  &course.ID,
+		Title:     "Office hours moved",
+		Content:   "Office hours are on Thursday this week.",
+		SendEmail: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{499, 1}, waitForBatches(t, notifications, 2))
+	for _, n := range notifications.created {
+		assert.NotEqual(t, optedOut, n.UserID)
+		assert.Equal(t, domain.NotificationAnnouncement, n.Type)
+		assert.Equal(t, "New announcement in Go Basics: Office hours moved", *n.Message)
+		assert.JSONEq(t, `{"link": "/announcements/`+announcements.created.ID.String()+`"}`, *n.Data)
+	}
+}
+
+func TestCreateAnnouncement_DeliversGlobalToAllUsers(t *testing.T) {
+	everyone := users(3)
+	announcements := &fakeAnnouncements{recipients: everyone}
+	notifications := &fakeNotifications{batches: make(chan int, 1)}
+	uc := announcement.NewUseCase(announcements, nil, nil, notification.NewNotifier(notifications, nil, nil), &fakePrefs{}, nil)
+
+	_, err := uc.CreateAnnouncement(context.Background(), uuid.New(), true, announcement.CreateAnnouncementInput{
+		Title:   "Scheduled maintenance",
+		Content: "The site will be down for an hour on Sunday.",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{3}, waitForBatches(t, notifications, 1))
+	for i, n := range notifications.created {
+		assert.Equal(t, everyone[i].ID, n.UserID)
+		assert.Equal(t, "Scheduled maintenance", *n.Message)
+	}
+}
+
+func TestCreateAnnouncement_Scheduled(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	announcements := &fakeAnnouncements{recipients: users(2)}
+	notifications := &fakeNotifications{batches: make(chan int, 1)}
+	uc := announcement.NewUseCase(announcements, nil, nil, notification.NewNotifier(notifications, nil, nil), &fakePrefs{}, nil)
+
+	t.Run("time must be in the future", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute)
+		_, err := uc.CreateAnnouncement(ctx, authorID, true, announcement.CreateAnnouncementInput{
+			Title:        "Scheduled maintenance",
+			Content:      "The site will be down for an hour on Sunday.",
+			ScheduledFor: &past,
+		})
+		var verrs domain.ValidationErrors
+		require.ErrorAs(t, err, &verrs)
+		assert.Equal(t, "scheduled_for", verrs[0].Field)
+	})
+
+	at := time.Now().Add(time.Hour)
+	created, err := uc.CreateAnnouncement(ctx, authorID, true, announcement.CreateAnnouncementInput{
+		Title:        "Scheduled maintenance",
+		Content:      "The site will be down for an hour on Sunday.",
+		ScheduledFor: &at,
+	})
+	require.NoError(t, err)
+	require.True(t, created.IsScheduled())
+
+	t.Run("hidden from everyone but the author", func(t *testing.T) {
+		got, err := uc.GetAnnouncement(ctx, created.ID, uuid.New(), false)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+
+		got, err = uc.GetAnnouncement(ctx, created.ID, authorID, false)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, got.ID)
+	})
+
+	t.Run("not published before its time", func(t *testing.T) {
+		n, err := uc.PublishDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Empty(t, notifications.created)
+	})
+
+	t.Run("published and delivered once due", func(t *testing.T) {
+		n, err := uc.PublishDue(ctx, at.Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []int{2}, waitForBatches(t, notifications, 1))
+		assert.False(t, announcements.created.IsScheduled())
+	})
+
+	t.Run("can no longer be cancelled", func(t *testing.T) {
+		err := uc.CancelScheduled(ctx, created.ID, authorID, false)
+		assert.ErrorIs(t, err, domain.ErrAnnouncementPublished)
+		assert.NotNil(t, announcements.created)
+	})
+}
+
+func TestCancelScheduled(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	announcements := &fakeAnnouncements{}
+	uc := announcement.NewUseCase(announcements, nil, nil, nil, nil, nil)
+
+	at := time.Now().Add(time.Hour)
+	created, err := uc.CreateAnnouncement(ctx, authorID, true, announcement.CreateAnnouncementInput{
+		Title:        "Scheduled maintenance",
+		Content:      "The site will be down for an hour on Sunday.",
+		ScheduledFor: &at,
+	})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, uc.CancelScheduled(ctx, created.ID, uuid.New(), false), domain.ErrForbidden)
+	require.NoError(t, uc.CancelScheduled(ctx, created.ID, authorID, false))
+	assert.Nil(t, announcements.created)
+	assert.ErrorIs(t, uc.CancelScheduled(ctx, created.ID, authorID, false), domain.ErrAnnouncementNotFound)
+}
+
+func TestCreateAnnouncement_ScheduledAtAlias(t *testing.T) {
+	ctx := context.Background()
+	announcements := &fakeAnnouncements{}
+	uc := announcement.NewUseCase(announcements, nil, nil, nil, nil, nil)
+
+	at := time.Now().Add(time.Hour)
+	created, err := uc.CreateAnnouncement(ctx, uuid.New(), true, announcement.CreateAnnouncementInput{
+		Title:       "Scheduled maintenance",
+		Content:     "The site will be down for an hour on Sunday.",
+		ScheduledAt: &at,
+	})
+	require.NoError(t, err)
+	require.True(t, created.IsScheduled())
+	assert.True(t, at.Equal(*created.ScheduledFor))
+}
+
+func TestCreateAnnouncement_Expiry(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	announcements := &fakeAnnouncements{}
+	uc := announcement.NewUseCase(announcements, nil, nil, notification.NewNotifier(&fakeNotifications{batches: make(chan int, 1)}, nil, nil), &fakePrefs{}, nil)
+
+	t.Run("must expire after it is published", func(t *testing.T) {
+		at := time.Now().Add(2 * time.Hour)
+		expires := at.Add(-time.Hour)
+		_, err := uc.CreateAnnouncement(ctx, authorID, true, announcement.CreateAnnouncementInput{
+			Title:        "Scheduled maintenance",
+			Content:      "The site will be down for an hour on Sunday.",
+			ScheduledFor: &at,
+			ExpiresAt:    &expires,
+		})
+		var verrs domain.ValidationErrors
+		require.ErrorAs(t, err, &verrs)
+		assert.Equal(t, "expires_at", verrs[0].Field)
+	})
+
+	expires := time.Now().Add(time.Hour)
+	created, err := uc.CreateAnnouncement(ctx, authorID, true, announcement.CreateAnnouncementInput{
+		Title:     "Scheduled maintenance",
+		Content:   "The site will be down for an hour on Sunday.",
+		IsPinned:  true,
+		ExpiresAt: &expires,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, created.PinnedAt)
+
+	// Once expired, learners no longer see it but the author still does
+	past := time.Now().Add(-time.Minute)
+	announcements.created.ExpiresAt = &past
+
+	got, err := uc.GetAnnouncement(ctx, created.ID, uuid.New(), false)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	got, err = uc.GetAnnouncement(ctx, created.ID, authorID, false)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, got.ID)
+}
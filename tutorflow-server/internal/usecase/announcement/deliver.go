@@ -0,0 +1,101 @@
+package announcement
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// recipientBatchSize is how many recipients are loaded, checked against
+// their preferences and notified per round of queries
+const recipientBatchSize = 500
+
+// deliver notifies everyone an announcement is for, a batch at a time: the
+// course's active learners, or every active user for a global announcement.
+// Each gets an in-app notification if they allow them and, if the
+// announcement asks for it, an email if they allow those and aren't on a
+// digest, which already picks the notification up.
+func (uc *UseCase) deliver(ctx context.Context, announcement *domain.Announcement) {
+	courseName := ""
+	if announcement.CourseID != nil {
+		course, _ := uc.courseRepo.GetByID(ctx, *announcement.CourseID)
+		if course == nil {
+			return
+		}
+		courseName = course.Title
+	}
+
+	message := announcement.Title
+	if courseName != "" {
+		message = fmt.Sprintf("New announcement in %s: %s", courseName, announcement.Title)
+	}
+	link := "/announcements/" + announcement.ID.String()
+	data, _ := json.Marshal(map[string]string{"link": link})
+
+	after := uuid.Nil
+	for {
+		users, err := uc.announcementRepo.GetRecipients(ctx, announcement.CourseID, after, recipientBatchSize)
+		if err != nil || len(users) == 0 {
+			return
+		}
+		after = users[len(users)-1].ID
+
+		ids := make([]uuid.UUID, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+		}
+		// Without preferences we can't tell who opted out, so skip the batch
+		prefs, err := uc.prefRepo.GetByUsers(ctx, ids)
+		if err != nil {
+			continue
+		}
+
+		notifications := make([]domain.Notification, 0, len(users))
+		for _, user := range users {
+			if prefs[user.ID].Allows(domain.NotificationCategoryCourse, domain.NotificationChannelInApp) {
+				notifications = append(notifications, domain.Notification{
+					UserID:  user.ID,
+					Type:    domain.NotificationAnnouncement,
+					Title:   "New Announcement",
+					Message: stringPtr(message),
+					Data:    stringPtr(string(data)),
+				})
+			}
+		}
+		_ = uc.notifier.NotifyBatch(ctx, notifications)
+
+		if announcement.SendEmail && uc.emailSvc != nil {
+			uc.emailBatch(ctx, users, ids, prefs, announcement, courseName, uc.emailSvc.AppLink(link))
+		}
+
+		if len(users) < recipientBatchSize {
+			return
+		}
+	}
+}
+
+// emailBatch emails the announcement to the users in a batch who take their
+// course notifications by email as they happen
+func (uc *UseCase) emailBatch(ctx context.Context, users []domain.User, ids []uuid.UUID, prefs map[uuid.UUID]domain.NotificationPreferences, announcement *domain.Announcement, courseName, link string) {
+	frequencies, err := uc.prefRepo.GetDigestFrequencies(ctx, ids)
+	if err != nil {
+		return
+	}
+	for _, user := range users {
+		if frequency, ok := frequencies[user.ID]; ok && frequency != domain.DigestImmediate {
+			continue
+		}
+		if !prefs[user.ID].Allows(domain.NotificationCategoryCourse, domain.NotificationChannelEmail) {
+			continue
+		}
+		_ = uc.emailSvc.SendAnnouncement(ctx, user.Email, user.FirstName, courseName, announcement.Title, announcement.Content, link)
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -7,28 +7,52 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/jwt"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
+	"github.com/tutorflow/tutorflow-server/internal/service/oauth"
 )
 
 // UseCase defines auth business logic
 type UseCase struct {
-	userRepo   repository.UserRepository
-	tokenRepo  repository.RefreshTokenRepository
-	jwtManager *jwt.Manager
+	userRepo         repository.UserRepository
+	tokenRepo        repository.RefreshTokenRepository
+	attemptRepo      repository.LoginAttemptRepository
+	verificationRepo repository.EmailVerificationRepository
+	resetRepo        repository.PasswordResetRepository
+	linkedRepo       repository.LinkedAccountRepository
+	jwtManager       *jwt.Manager
+	emailSvc         *email.Service
+	providers        map[domain.AuthProvider]oauth.Provider
+	cfg              config.AuthConfig
 }
 
 // NewUseCase creates a new auth use case
 func NewUseCase(
 	userRepo repository.UserRepository,
 	tokenRepo repository.RefreshTokenRepository,
+	attemptRepo repository.LoginAttemptRepository,
+	verificationRepo repository.EmailVerificationRepository,
+	resetRepo repository.PasswordResetRepository,
+	linkedRepo repository.LinkedAccountRepository,
 	jwtManager *jwt.Manager,
+	emailSvc *email.Service,
+	providers map[domain.AuthProvider]oauth.Provider,
+	cfg config.AuthConfig,
 ) *UseCase {
 	return &UseCase{
-		userRepo:   userRepo,
-		tokenRepo:  tokenRepo,
-		jwtManager: jwtManager,
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		attemptRepo:      attemptRepo,
+		verificationRepo: verificationRepo,
+		resetRepo:        resetRepo,
+		linkedRepo:       linkedRepo,
+		jwtManager:       jwtManager,
+		emailSvc:         emailSvc,
+		providers:        providers,
+		cfg:              cfg,
 	}
 }
 
@@ -38,6 +62,7 @@ type RegisterInput struct {
 	Password  string `json:"password" validate:"required,password"`
 	FirstName string `json:"first_name" validate:"required,min=2,max=100"`
 	LastName  string `json:"last_name" validate:"required,min=2,max=100"`
+	Client    `json:"-"`
 }
 
 // RegisterOutput returned after registration
@@ -74,6 +99,9 @@ func (uc *UseCase) Register(ctx context.Context, input RegisterInput) (*Register
 		return nil, err
 	}
 
+	// A failure here isn't fatal; the user can ask for another link
+	_ = uc.sendVerification(ctx, user)
+
 	// Generate tokens
 	tokens, err := uc.jwtManager.GenerateTokenPair(user)
 	if err != nil {
@@ -81,7 +109,7 @@ func (uc *UseCase) Register(ctx context.Context, input RegisterInput) (*Register
 	}
 
 	// Store refresh token
-	if err := uc.storeRefreshToken(ctx, user.ID, tokens.RefreshToken, tokens.ExpiresAt); err != nil {
+	if _, err := uc.storeRefreshToken(ctx, newSession(user.ID, input.Client), tokens.RefreshToken, tokens.ExpiresAt); err != nil {
 		return nil, err
 	}
 
@@ -95,6 +123,7 @@ func (uc *UseCase) Register(ctx context.Context, input RegisterInput) (*Register
 type LoginInput struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	Client   `json:"-"`
 }
 
 // LoginOutput returned after login
@@ -103,22 +132,42 @@ type LoginOutput struct {
 	Tokens *jwt.TokenPair `json:"tokens"`
 }
 
-// Login authenticates a user
+// Login authenticates a user. Too many recent failures for the email lock
+// it, returning domain.ErrAccountLocked whatever the password.
 func (uc *UseCase) Login(ctx context.Context, input LoginInput) (*LoginOutput, error) {
+	failures, err := uc.recentFailures(ctx, input.Email)
+	if err != nil {
+		return nil, err
+	}
+	if uc.locked(failures) {
+		return nil, domain.ErrAccountLocked
+	}
+
 	// Find user
 	user, err := uc.userRepo.GetByEmail(ctx, input.Email)
 	if err != nil {
+		uc.recordFailure(ctx, input, nil, failures)
 		return nil, domain.ErrInvalidCredentials
 	}
 
 	// Check password
 	if !hash.CheckPassword(input.Password, user.PasswordHash) {
+		uc.recordFailure(ctx, input, user, failures)
 		return nil, domain.ErrInvalidCredentials
 	}
 
+	if len(failures) > 0 {
+		_ = uc.attemptRepo.DeleteForEmail(ctx, normalizeEmail(input.Email))
+	}
+
+	return uc.signIn(ctx, user, input.Client)
+}
+
+// signIn starts a session for an authenticated user
+func (uc *UseCase) signIn(ctx context.Context, user *domain.User, client Client) (*LoginOutput, error) {
 	// Check user status
 	if user.Status == domain.StatusSuspended {
-		return nil, domain.ErrUserSuspended
+		return nil, domain.ErrAccountSuspended
 	}
 	if user.Status == domain.StatusInactive {
 		return nil, domain.ErrUserInactive
@@ -131,7 +180,7 @@ func (uc *UseCase) Login(ctx context.Context, input LoginInput) (*LoginOutput, e
 	}
 
 	// Store refresh token
-	if err := uc.storeRefreshToken(ctx, user.ID, tokens.RefreshToken, tokens.ExpiresAt); err != nil {
+	if _, err := uc.storeRefreshToken(ctx, newSession(user.ID, client), tokens.RefreshToken, tokens.ExpiresAt); err != nil {
 		return nil, err
 	}
 
@@ -147,6 +196,7 @@ func (uc *UseCase) Login(ctx context.Context, input LoginInput) (*LoginOutput, e
 // RefreshInput for token refresh
 type RefreshInput struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
+	Client       `json:"-"`
 }
 
 // RefreshOutput returned after token refresh
@@ -154,11 +204,16 @@ type RefreshOutput struct {
 	Tokens *jwt.TokenPair `json:"tokens"`
 }
 
-// Refresh generates new access token from refresh token
+// Refresh swaps a refresh token for a new token pair. Each refresh token
+// works once; if a spent one comes back it has been copied, so every session
+// of the user is revoked.
 func (uc *UseCase) Refresh(ctx context.Context, input RefreshInput) (*RefreshOutput, error) {
 	// Validate refresh token
-	claims, err := uc.jwtManager.ValidateToken(input.RefreshToken)
+	claims, err := uc.jwtManager.ValidateRefreshToken(input.RefreshToken)
 	if err != nil {
+		if err == jwt.ErrExpiredToken {
+			return nil, domain.ErrTokenExpired
+		}
 		return nil, domain.ErrRefreshTokenInvalid
 	}
 
@@ -169,7 +224,16 @@ func (uc *UseCase) Refresh(ctx context.Context, input RefreshInput) (*RefreshOut
 		return nil, domain.ErrRefreshTokenInvalid
 	}
 
-	if !storedToken.IsValid() {
+	if storedToken.ReplacedBy != nil {
+		if err := uc.tokenRepo.RevokeAllForUser(ctx, storedToken.UserID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrRefreshTokenReused
+	}
+	if storedToken.IsExpired() {
+		return nil, domain.ErrTokenExpired
+	}
+	if storedToken.IsRevoked() {
 		return nil, domain.ErrRefreshTokenInvalid
 	}
 
@@ -178,9 +242,9 @@ func (uc *UseCase) Refresh(ctx context.Context, input RefreshInput) (*RefreshOut
 	if err != nil {
 		return nil, err
 	}
-
-	// Revoke old token
-	_ = uc.tokenRepo.Revoke(ctx, storedToken.ID)
+	if user.Status == domain.StatusSuspended {
+		return nil, domain.ErrAccountSuspended
+	}
 
 	// Generate new tokens
 	tokens, err := uc.jwtManager.GenerateTokenPair(user)
@@ -188,10 +252,25 @@ func (uc *UseCase) Refresh(ctx context.Context, input RefreshInput) (*RefreshOut
 		return nil, err
 	}
 
-	// Store new refresh token
-	if err := uc.storeRefreshToken(ctx, user.ID, tokens.RefreshToken, tokens.ExpiresAt); err != nil {
+	// Store new refresh token and retire the old one
+	session := newSession(user.ID, input.Client)
+	session.SignedInAt = storedToken.SignedInAt
+	newToken, err := uc.storeRefreshToken(ctx, session, tokens.RefreshToken, tokens.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	replaced, err := uc.tokenRepo.Replace(ctx, storedToken.ID, newToken.ID)
+	if err != nil {
 		return nil, err
 	}
+	if !replaced {
+		// Another refresh spent the token between our read and now; treat it
+		// as reuse, which also revokes the token just stored
+		if err := uc.tokenRepo.RevokeAllForUser(ctx, storedToken.UserID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrRefreshTokenReused
+	}
 
 	return &RefreshOutput{Tokens: tokens}, nil
 }
@@ -212,6 +291,16 @@ func (uc *UseCase) LogoutAll(ctx context.Context, userID uuid.UUID) error {
 	return uc.tokenRepo.RevokeAllForUser(ctx, userID)
 }
 
+// AccountStatus returns the status of the user's account. Access tokens
+// outlive a suspension or deletion, so authenticated requests check it.
+func (uc *UseCase) AccountStatus(ctx context.Context, userID uuid.UUID) (domain.UserStatus, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return user.Status, nil
+}
+
 // GetCurrentUser gets user by ID
 func (uc *UseCase) GetCurrentUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
 	return uc.userRepo.GetByID(ctx, userID)
@@ -250,12 +339,85 @@ func (uc *UseCase) ChangePassword(ctx context.Context, userID uuid.UUID, input C
 	return uc.tokenRepo.RevokeAllForUser(ctx, userID)
 }
 
-func (uc *UseCase) storeRefreshToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) error {
-	tokenHash := hash.HashToken(token)
-	refreshToken := &domain.RefreshToken{
-		UserID:    userID,
-		TokenHash: tokenHash,
-		ExpiresAt: expiresAt,
+// IntrospectInput for token introspection
+type IntrospectInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// IntrospectOutput describes a presented token. Only Active is set when the
+// token is invalid, expired, revoked or belongs to a disabled account.
+type IntrospectOutput struct {
+	Active    bool            `json:"active"`
+	TokenType string          `json:"token_type,omitempty"`
+	UserID    *uuid.UUID      `json:"user_id,omitempty"`
+	Email     string          `json:"email,omitempty"`
+	Role      domain.UserRole `json:"role,omitempty"`
+	IssuedAt  *time.Time      `json:"issued_at,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	ExpiresIn int64           `json:"expires_in,omitempty"` // seconds remaining
+}
+
+// Introspect reports whether a token is currently valid and returns its claims
+func (uc *UseCase) Introspect(ctx context.Context, input IntrospectInput) (*IntrospectOutput, error) {
+	inactive := &IntrospectOutput{Active: false}
+
+	claims, err := uc.jwtManager.ValidateToken(input.Token)
+	if err != nil {
+		return inactive, nil
+	}
+
+	tokenType := claims.TokenType
+	if tokenType == "" {
+		tokenType = jwt.TokenTypeAccess
+	}
+
+	// Refresh tokens can be revoked before they expire
+	if tokenType == jwt.TokenTypeRefresh {
+		storedToken, err := uc.tokenRepo.GetByHash(ctx, hash.HashToken(input.Token))
+		if err != nil || !storedToken.IsValid() {
+			return inactive, nil
+		}
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return inactive, nil
+		}
+		return nil, err
+	}
+	if user.Status == domain.StatusSuspended || user.Status == domain.StatusInactive {
+		return inactive, nil
+	}
+
+	output := &IntrospectOutput{
+		Active:    true,
+		TokenType: tokenType,
+		UserID:    &claims.UserID,
+		Email:     claims.Email,
+		Role:      claims.Role,
+	}
+	if claims.IssuedAt != nil {
+		issuedAt := claims.IssuedAt.Time
+		output.IssuedAt = &issuedAt
+	}
+	if claims.ExpiresAt != nil {
+		expiresAt := claims.ExpiresAt.Time
+		output.ExpiresAt = &expiresAt
+		output.ExpiresIn = int64(time.Until(expiresAt).Seconds())
+	}
+
+	return output, nil
+}
+
+// storeRefreshToken saves the token for the session
+func (uc *UseCase) storeRefreshToken(ctx context.Context, session domain.RefreshToken, token string, expiresAt time.Time) (*domain.RefreshToken, error) {
+	refreshToken := session
+	refreshToken.ID = uuid.New()
+	refreshToken.TokenHash = hash.HashToken(token)
+	refreshToken.ExpiresAt = expiresAt
+	if err := uc.tokenRepo.Create(ctx, &refreshToken); err != nil {
+		return nil, err
 	}
-	return uc.tokenRepo.Create(ctx, refreshToken)
+	return &refreshToken, nil
 }
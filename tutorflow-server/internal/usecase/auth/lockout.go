@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// normalizeEmail is the key failed logins are tracked under
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// recentFailures returns the failed logins for the email still inside the
+// lockout window, newest first
+func (uc *UseCase) recentFailures(ctx context.Context, email string) ([]domain.LoginAttempt, error) {
+	if uc.cfg.MaxFailedLogins <= 0 {
+		return nil, nil
+	}
+	return uc.attemptRepo.GetRecent(ctx, normalizeEmail(email), time.Now().Add(-uc.cfg.LockoutWindow))
+}
+
+// locked reports whether the recent failures are enough to lock the account
+func (uc *UseCase) locked(failures []domain.LoginAttempt) bool {
+	return uc.cfg.MaxFailedLogins > 0 && len(failures) >= uc.cfg.MaxFailedLogins
+}
+
+// recordFailure stores a failed login. When it is the one that locks the
+// account, the owner is told by email in case someone else is guessing.
+// user is nil for emails with no account; they are locked all the same so
+// the response doesn't reveal which emails are registered.
+func (uc *UseCase) recordFailure(ctx context.Context, input LoginInput, user *domain.User, failures []domain.LoginAttempt) {
+	if uc.cfg.MaxFailedLogins <= 0 {
+		return
+	}
+
+	attempt := &domain.LoginAttempt{
+		Email:     normalizeEmail(input.Email),
+		IPAddress: input.IPAddress,
+		CreatedAt: time.Now(),
+	}
+	if err := uc.attemptRepo.Create(ctx, attempt); err != nil {
+		return
+	}
+
+	failures = append([]domain.LoginAttempt{*attempt}, failures...)
+	if user == nil || len(failures) != uc.cfg.MaxFailedLogins || uc.emailSvc == nil {
+		return
+	}
+
+	// The lock lifts when the oldest of these failures leaves the window
+	unlockAt := failures[len(failures)-1].CreatedAt.Add(uc.cfg.LockoutWindow)
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		_ = uc.emailSvc.SendAccountLocked(ctx, user.Email, user.FirstName, unlockAt.UTC().Format("Jan 2, 2006 15:04 MST"))
+	}()
+}
+
+// PurgeLoginAttempts deletes failed logins too old to count towards a lockout
+func (uc *UseCase) PurgeLoginAttempts(ctx context.Context) error {
+	return uc.attemptRepo.DeleteBefore(ctx, time.Now().Add(-uc.cfg.LockoutWindow))
+}
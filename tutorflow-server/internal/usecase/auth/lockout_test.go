@@ -0,0 +1,222 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/jwt"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/auth"
+)
+
+// MockUserRepository is a mock implementation of UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	args := m.Called(ctx, usernames)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filters repository.UserFilters) ([]domain.User, int64, error) {
+	args := m.Called(ctx, filters)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return m.Called(ctx, id, passwordHash).Error(0)
+}
+
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdateLastActive(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+func (m *MockUserRepository) Anonymize(ctx context.Context, u *domain.User, entry *domain.AuditLog) error {
+	return m.Called(ctx, u, entry).Error(0)
+}
+
+// MockRefreshTokenRepository is a mock implementation of RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	return m.Called(ctx, token).Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, id)
+	token, _ := args.Get(0).(*domain.RefreshToken)
+	return token, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) ListActive(ctx context.Context, userID uuid.UUID) ([]domain.RefreshToken, error) {
+	args := m.Called(ctx, userID)
+	tokens, _ := args.Get(0).([]domain.RefreshToken)
+	return tokens, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockRefreshTokenRepository) Replace(ctx context.Context, id, replacedBy uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id, replacedBy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *MockRefreshTokenRepository) DeleteExpired(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+// MockLoginAttemptRepository is a mock implementation of LoginAttemptRepository
+type MockLoginAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptRepository) Create(ctx context.Context, attempt *domain.LoginAttempt) error {
+	return m.Called(ctx, attempt).Error(0)
+}
+
+func (m *MockLoginAttemptRepository) GetRecent(ctx context.Context, email string, since time.Time) ([]domain.LoginAttempt, error) {
+	args := m.Called(ctx, email, since)
+	attempts, _ := args.Get(0).([]domain.LoginAttempt)
+	return attempts, args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) DeleteForEmail(ctx context.Context, email string) error {
+	return m.Called(ctx, email).Error(0)
+}
+
+func (m *MockLoginAttemptRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	return m.Called(ctx, before).Error(0)
+}
+
+var lockoutConfig = config.AuthConfig{MaxFailedLogins: 3, LockoutWindow: 15 * time.Minute}
+
+func failures(n int) []domain.LoginAttempt {
+	attempts := make([]domain.LoginAttempt, n)
+	for i := range attempts {
+		attempts[i] = domain.LoginAttempt{Email: "ana@example.com", CreatedAt: time.Now().Add(-time.Duration(i) * time.Minute)}
+	}
+	return attempts
+}
+
+func TestAuthUseCase_Login_Lockout(t *testing.T) {
+	ctx := context.Background()
+	passwordHash, err := hash.HashPassword("Correct-horse1")
+	assert.NoError(t, err)
+	user := &domain.User{ID: uuid.New(), Email: "ana@example.com", FirstName: "Ana", PasswordHash: passwordHash, Role: domain.RoleStudent, Status: domain.StatusActive}
+
+	t.Run("locked accounts are refused even with the right password", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		attemptRepo := new(MockLoginAttemptRepository)
+		attemptRepo.On("GetRecent", ctx, "ana@example.com", mock.Anything).Return(failures(3), nil)
+		uc := auth.NewUseCase(userRepo, nil, attemptRepo, nil, nil, nil, nil, nil, nil, lockoutConfig)
+
+		_, err := uc.Login(ctx, auth.LoginInput{Email: "Ana@Example.com", Password: "Correct-horse1"})
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+		userRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong passwords are recorded", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+		attemptRepo := new(MockLoginAttemptRepository)
+		attemptRepo.On("GetRecent", ctx, user.Email, mock.Anything).Return(failures(1), nil)
+		attemptRepo.On("Create", ctx, mock.MatchedBy(func(a *domain.LoginAttempt) bool {
+			return a.Email == user.Email && a.IPAddress == "203.0.113.7"
+		})).Return(nil)
+		uc := auth.NewUseCase(userRepo, nil, attemptRepo, nil, nil, nil, nil, nil, nil, lockoutConfig)
+
+		_, err := uc.Login(ctx, auth.LoginInput{Email: user.Email, Password: "wrong", Client: auth.Client{IPAddress: "203.0.113.7"}})
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		attemptRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown emails are tracked too", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+		attemptRepo := new(MockLoginAttemptRepository)
+		attemptRepo.On("GetRecent", ctx, "nobody@example.com", mock.Anything).Return(nil, nil)
+		attemptRepo.On("Create", ctx, mock.Anything).Return(nil)
+		uc := auth.NewUseCase(userRepo, nil, attemptRepo, nil, nil, nil, nil, nil, nil, lockoutConfig)
+
+		_, err := uc.Login(ctx, auth.LoginInput{Email: "nobody@example.com", Password: "guess"})
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		attemptRepo.AssertCalled(t, "Create", ctx, mock.Anything)
+	})
+
+	t.Run("a successful login clears failures", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+		userRepo.On("UpdateLastLogin", ctx, user.ID).Return(nil)
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("Create", ctx, mock.Anything).Return(nil)
+		attemptRepo := new(MockLoginAttemptRepository)
+		attemptRepo.On("GetRecent", ctx, user.Email, mock.Anything).Return(failures(2), nil)
+		attemptRepo.On("DeleteForEmail", ctx, user.Email).Return(nil)
+		jwtManager := jwt.NewManager(config.JWTConfig{Secret: "test-secret-that-is-long-enough-32"})
+		uc := auth.NewUseCase(userRepo, tokenRepo, attemptRepo, nil, nil, nil, jwtManager, nil, nil, lockoutConfig)
+
+		out, err := uc.Login(ctx, auth.LoginInput{Email: user.Email, Password: "Correct-horse1"})
+		assert.NoError(t, err)
+		assert.NotNil(t, out.Tokens)
+		attemptRepo.AssertCalled(t, "DeleteForEmail", ctx, user.Email)
+		attemptRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
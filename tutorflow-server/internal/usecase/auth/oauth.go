@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+)
+
+// OAuthURL is where to send the browser to sign in with the provider
+func (uc *UseCase) OAuthURL(provider domain.AuthProvider, state string) (string, error) {
+	p, ok := uc.providers[provider]
+	if !ok {
+		return "", domain.ErrUnknownAuthProvider
+	}
+	return p.AuthCodeURL(state), nil
+}
+
+// OAuthLogin signs in with the code from the provider's callback. A provider
+// account seen before signs in to the user it was linked to. Otherwise it is
+// linked to the user with the same email, or a new user is created; either
+// way only if the provider has verified the email.
+//
+// An existing account whose email was never verified may have been
+// registered by someone else in the owner's name, so before linking it its
+// password is replaced and its sessions are signed out.
+func (uc *UseCase) OAuthLogin(ctx context.Context, provider domain.AuthProvider, code string, client Client) (*LoginOutput, error) {
+	p, ok := uc.providers[provider]
+	if !ok {
+		return nil, domain.ErrUnknownAuthProvider
+	}
+
+	profile, err := p.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := uc.linkedRepo.GetByProviderUser(ctx, provider, profile.ProviderUserID)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil {
+		user, err := uc.userRepo.GetByID(ctx, linked.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return uc.signIn(ctx, user, client)
+	}
+
+	if profile.Email == "" || !profile.EmailVerified {
+		return nil, domain.ErrProviderEmailUnverified
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, profile.Email)
+	switch {
+	case err == domain.ErrUserNotFound:
+		if user, err = uc.createOAuthUser(ctx, profile.Email, profile.FirstName, profile.LastName, profile.AvatarURL); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !user.IsEmailVerified():
+		// The provider has just proven the address is theirs, and not
+		// necessarily whoever set the password
+		passwordHash, err := randomPasswordHash()
+		if err != nil {
+			return nil, err
+		}
+		if err := uc.userRepo.UpdatePassword(ctx, user.ID, passwordHash); err != nil {
+			return nil, err
+		}
+		if err := uc.tokenRepo.RevokeAllForUser(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		if err := uc.userRepo.VerifyEmail(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.linkedRepo.Create(ctx, &domain.LinkedAccount{
+		UserID:         user.ID,
+		Provider:       provider,
+		ProviderUserID: profile.ProviderUserID,
+		Email:          profile.Email,
+	}); err != nil {
+		return nil, err
+	}
+
+	return uc.signIn(ctx, user, client)
+}
+
+// createOAuthUser registers a user who signed up through a provider. They get
+// an unguessable password and can set a real one with forgot-password.
+func (uc *UseCase) createOAuthUser(ctx context.Context, email, firstName, lastName, avatarURL string) (*domain.User, error) {
+	passwordHash, err := randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	// Names are required, and providers don't always share them
+	if strings.TrimSpace(firstName) == "" {
+		firstName = strings.SplitN(email, "@", 2)[0]
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		Email:           email,
+		PasswordHash:    passwordHash,
+		FirstName:       firstName,
+		LastName:        lastName,
+		Role:            domain.RoleStudent,
+		Status:          domain.StatusActive,
+		EmailVerifiedAt: &now,
+	}
+	if avatarURL != "" {
+		user.AvatarURL = &avatarURL
+	}
+
+	if err := uc.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// randomPasswordHash hashes a password nobody knows, for accounts that sign
+// in through a provider
+func randomPasswordHash() (string, error) {
+	password, err := hash.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	return hash.HashPassword(password)
+}
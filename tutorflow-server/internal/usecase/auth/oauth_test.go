@@ -0,0 +1,150 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/jwt"
+	"github.com/tutorflow/tutorflow-server/internal/service/oauth"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/auth"
+)
+
+// MockLinkedAccountRepository is a mock implementation of LinkedAccountRepository
+type MockLinkedAccountRepository struct {
+	mock.Mock
+}
+
+func (m *MockLinkedAccountRepository) Create(ctx context.Context, account *domain.LinkedAccount) error {
+	return m.Called(ctx, account).Error(0)
+}
+
+func (m *MockLinkedAccountRepository) GetByProviderUser(ctx context.Context, provider domain.AuthProvider, providerUserID string) (*domain.LinkedAccount, error) {
+	args := m.Called(ctx, provider, providerUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LinkedAccount), args.Error(1)
+}
+
+// fakeProvider returns the same profile for any code
+type fakeProvider struct {
+	profile *oauth.Profile
+}
+
+func (p fakeProvider) AuthCodeURL(state string) string {
+	return "https://provider.test/auth?state=" + state
+}
+
+func (p fakeProvider) Exchange(ctx context.Context, code string) (*oauth.Profile, error) {
+	return p.profile, nil
+}
+
+func TestAuthUseCase_OAuthLogin(t *testing.T) {
+	ctx := context.Background()
+	jwtManager := jwt.NewManager(config.JWTConfig{Secret: "test-secret-that-is-long-enough-32"})
+	profile := &oauth.Profile{ProviderUserID: "g-123", Email: "ana@example.com", EmailVerified: true, FirstName: "Ana"}
+	providers := map[domain.AuthProvider]oauth.Provider{domain.AuthProviderGoogle: fakeProvider{profile: profile}}
+
+	newUseCaseWithTokens := func(userRepo *MockUserRepository, linkedRepo *MockLinkedAccountRepository, tokenRepo *MockRefreshTokenRepository) *auth.UseCase {
+		tokenRepo.On("Create", ctx, mock.Anything).Return(nil)
+		userRepo.On("UpdateLastLogin", ctx, mock.Anything).Return(nil)
+		return auth.NewUseCase(userRepo, tokenRepo, nil, nil, nil, linkedRepo, jwtManager, nil, providers, lockoutConfig)
+	}
+	newUseCase := func(userRepo *MockUserRepository, linkedRepo *MockLinkedAccountRepository) *auth.UseCase {
+		return newUseCaseWithTokens(userRepo, linkedRepo, new(MockRefreshTokenRepository))
+	}
+
+	t.Run("signs in the user the account is linked to", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), Email: "other@example.com", Role: domain.RoleStudent, Status: domain.StatusActive}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+		linkedRepo := new(MockLinkedAccountRepository)
+		linkedRepo.On("GetByProviderUser", ctx, domain.AuthProviderGoogle, "g-123").Return(&domain.LinkedAccount{UserID: user.ID}, nil)
+
+		output, err := newUseCase(userRepo, linkedRepo).OAuthLogin(ctx, domain.AuthProviderGoogle, "code", auth.Client{})
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, output.User.ID)
+		assert.NotEmpty(t, output.Tokens.RefreshToken)
+		linkedRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("links to an existing verified account with the same email", func(t *testing.T) {
+		verifiedAt := time.Now()
+		user := &domain.User{ID: uuid.New(), Email: profile.Email, Role: domain.RoleStudent, Status: domain.StatusActive, EmailVerifiedAt: &verifiedAt}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", ctx, profile.Email).Return(user, nil)
+		linkedRepo := new(MockLinkedAccountRepository)
+		linkedRepo.On("GetByProviderUser", ctx, domain.AuthProviderGoogle, "g-123").Return(nil, nil)
+		linkedRepo.On("Create", ctx, mock.MatchedBy(func(a *domain.LinkedAccount) bool {
+			return a.UserID == user.ID && a.Provider == domain.AuthProviderGoogle && a.ProviderUserID == "g-123"
+		})).Return(nil)
+
+		output, err := newUseCase(userRepo, linkedRepo).OAuthLogin(ctx, domain.AuthProviderGoogle, "code", auth.Client{})
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, output.User.ID)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+		linkedRepo.AssertExpectations(t)
+	})
+
+	t.Run("takes an unverified account back from whoever registered it", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), Email: profile.Email, PasswordHash: "set-by-someone-else", Role: domain.RoleStudent, Status: domain.StatusActive}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", ctx, profile.Email).Return(user, nil)
+		userRepo.On("UpdatePassword", ctx, user.ID, mock.MatchedBy(func(hash string) bool {
+			return hash != "" && hash != user.PasswordHash
+		})).Return(nil)
+		userRepo.On("VerifyEmail", ctx, user.ID).Return(nil)
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("RevokeAllForUser", ctx, user.ID).Return(nil)
+		linkedRepo := new(MockLinkedAccountRepository)
+		linkedRepo.On("GetByProviderUser", ctx, domain.AuthProviderGoogle, "g-123").Return(nil, nil)
+		linkedRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+		output, err := newUseCaseWithTokens(userRepo, linkedRepo, tokenRepo).OAuthLogin(ctx, domain.AuthProviderGoogle, "code", auth.Client{})
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, output.User.ID)
+		userRepo.AssertExpectations(t)
+		tokenRepo.AssertCalled(t, "RevokeAllForUser", ctx, user.ID)
+		linkedRepo.AssertExpectations(t)
+	})
+
+	t.Run("creates a verified user for a new email", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", ctx, profile.Email).Return(nil, domain.ErrUserNotFound)
+		userRepo.On("Create", ctx, mock.AnythingOfType("*domain.User")).Return(nil)
+		linkedRepo := new(MockLinkedAccountRepository)
+		linkedRepo.On("GetByProviderUser", ctx, domain.AuthProviderGoogle, "g-123").Return(nil, nil)
+		linkedRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+		output, err := newUseCase(userRepo, linkedRepo).OAuthLogin(ctx, domain.AuthProviderGoogle, "code", auth.Client{})
+		assert.NoError(t, err)
+		assert.Equal(t, profile.Email, output.User.Email)
+		assert.True(t, output.User.IsEmailVerified())
+		assert.Equal(t, domain.RoleStudent, output.User.Role)
+		linkedRepo.AssertExpectations(t)
+	})
+
+	t.Run("refuses an email the provider has not verified", func(t *testing.T) {
+		unverified := map[domain.AuthProvider]oauth.Provider{domain.AuthProviderGoogle: fakeProvider{profile: &oauth.Profile{ProviderUserID: "g-456", Email: "ana@example.com"}}}
+		linkedRepo := new(MockLinkedAccountRepository)
+		linkedRepo.On("GetByProviderUser", ctx, domain.AuthProviderGoogle, "g-456").Return(nil, nil)
+		uc := auth.NewUseCase(nil, nil, nil, nil, nil, linkedRepo, nil, nil, unverified, lockoutConfig)
+
+		_, err := uc.OAuthLogin(ctx, domain.AuthProviderGoogle, "code", auth.Client{})
+		assert.ErrorIs(t, err, domain.ErrProviderEmailUnverified)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		uc := auth.NewUseCase(nil, nil, nil, nil, nil, nil, nil, nil, providers, lockoutConfig)
+
+		_, err := uc.OAuthURL("github", "state")
+		assert.ErrorIs(t, err, domain.ErrUnknownAuthProvider)
+	})
+}
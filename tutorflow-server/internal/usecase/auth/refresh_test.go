@@ -0,0 +1,113 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/jwt"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/auth"
+)
+
+func TestAuthUseCase_Refresh(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Email: "ana@example.com", Role: domain.RoleStudent, Status: domain.StatusActive}
+	jwtManager := jwt.NewManager(config.JWTConfig{Secret: "test-secret-that-is-long-enough-32"})
+	pair, err := jwtManager.GenerateTokenPair(user)
+	assert.NoError(t, err)
+	input := auth.RefreshInput{RefreshToken: pair.RefreshToken}
+	tokenHash := hash.HashToken(pair.RefreshToken)
+
+	t.Run("rotates the token", func(t *testing.T) {
+		stored := &domain.RefreshToken{ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("GetByHash", ctx, tokenHash).Return(stored, nil)
+		var issued *domain.RefreshToken
+		tokenRepo.On("Create", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			issued = args.Get(1).(*domain.RefreshToken)
+		}).Return(nil)
+		tokenRepo.On("Replace", ctx, stored.ID, mock.Anything).Return(true, nil)
+		uc := auth.NewUseCase(userRepo, tokenRepo, nil, nil, nil, nil, jwtManager, nil, nil, lockoutConfig)
+
+		out, err := uc.Refresh(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, hash.HashToken(out.Tokens.RefreshToken), issued.TokenHash)
+		tokenRepo.AssertCalled(t, "Replace", ctx, stored.ID, issued.ID)
+	})
+
+	t.Run("losing a race to spend the token counts as reuse", func(t *testing.T) {
+		stored := &domain.RefreshToken{ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("GetByHash", ctx, tokenHash).Return(stored, nil)
+		tokenRepo.On("Create", ctx, mock.Anything).Return(nil)
+		tokenRepo.On("Replace", ctx, stored.ID, mock.Anything).Return(false, nil)
+		tokenRepo.On("RevokeAllForUser", ctx, user.ID).Return(nil)
+		uc := auth.NewUseCase(userRepo, tokenRepo, nil, nil, nil, nil, jwtManager, nil, nil, lockoutConfig)
+
+		_, err := uc.Refresh(ctx, input)
+		assert.ErrorIs(t, err, domain.ErrRefreshTokenReused)
+		tokenRepo.AssertCalled(t, "RevokeAllForUser", ctx, user.ID)
+	})
+
+	t.Run("suspended account", func(t *testing.T) {
+		suspended := *user
+		suspended.Status = domain.StatusSuspended
+		stored := &domain.RefreshToken{ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, user.ID).Return(&suspended, nil)
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("GetByHash", ctx, tokenHash).Return(stored, nil)
+		uc := auth.NewUseCase(userRepo, tokenRepo, nil, nil, nil, nil, jwtManager, nil, nil, lockoutConfig)
+
+		_, err := uc.Refresh(ctx, input)
+		assert.ErrorIs(t, err, domain.ErrAccountSuspended)
+		tokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("reusing a spent token revokes every session", func(t *testing.T) {
+		next := uuid.New()
+		revokedAt := time.Now()
+		stored := &domain.RefreshToken{ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt, ReplacedBy: &next}
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("GetByHash", ctx, tokenHash).Return(stored, nil)
+		tokenRepo.On("RevokeAllForUser", ctx, user.ID).Return(nil)
+		uc := auth.NewUseCase(nil, tokenRepo, nil, nil, nil, nil, jwtManager, nil, nil, lockoutConfig)
+
+		_, err := uc.Refresh(ctx, input)
+		assert.ErrorIs(t, err, domain.ErrRefreshTokenReused)
+		tokenRepo.AssertCalled(t, "RevokeAllForUser", ctx, user.ID)
+		tokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("logged out tokens are just invalid", func(t *testing.T) {
+		revokedAt := time.Now()
+		stored := &domain.RefreshToken{ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("GetByHash", ctx, tokenHash).Return(stored, nil)
+		uc := auth.NewUseCase(nil, tokenRepo, nil, nil, nil, nil, jwtManager, nil, nil, lockoutConfig)
+
+		_, err := uc.Refresh(ctx, input)
+		assert.ErrorIs(t, err, domain.ErrRefreshTokenInvalid)
+		tokenRepo.AssertNotCalled(t, "RevokeAllForUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("expired tokens", func(t *testing.T) {
+		stored := &domain.RefreshToken{ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(-time.Minute)}
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("GetByHash", ctx, tokenHash).Return(stored, nil)
+		uc := auth.NewUseCase(nil, tokenRepo, nil, nil, nil, nil, jwtManager, nil, nil, lockoutConfig)
+
+		_, err := uc.Refresh(ctx, input)
+		assert.ErrorIs(t, err, domain.ErrTokenExpired)
+	})
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+)
+
+// passwordResetTTL is how long a reset link works, as the email promises
+const passwordResetTTL = time.Hour
+
+// ForgotPasswordInput for requesting a reset link
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPassword emails a reset link if the email has an account. It
+// succeeds either way so callers can't tell which emails are registered.
+func (uc *UseCase) ForgotPassword(ctx context.Context, input ForgotPasswordInput) error {
+	user, err := uc.userRepo.GetByEmail(ctx, input.Email)
+	if err != nil {
+		return nil
+	}
+
+	token, tokenHash, err := hash.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	// Only the newest link works
+	if err := uc.resetRepo.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.resetRepo.Create(ctx, &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}); err != nil {
+		return err
+	}
+
+	if uc.emailSvc == nil {
+		return nil
+	}
+	resetURL := uc.emailSvc.AppLink("/reset-password?token=" + url.QueryEscape(token))
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		_ = uc.emailSvc.SendPasswordReset(ctx, user.Email, user.FirstName, resetURL)
+	}()
+	return nil
+}
+
+// ResetPasswordInput for choosing a new password with a reset link
+type ResetPasswordInput struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,password"`
+}
+
+// ResetPassword sets a new password using a reset token. The token is used
+// up, every session is signed out and any login lockout is cleared.
+func (uc *UseCase) ResetPassword(ctx context.Context, input ResetPasswordInput) error {
+	reset, err := uc.resetRepo.GetByHash(ctx, hash.HashToken(input.Token))
+	if err != nil {
+		return err
+	}
+	if time.Now().After(reset.ExpiresAt) {
+		return domain.ErrResetTokenInvalid
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, reset.UserID)
+	if err != nil {
+		return err
+	}
+
+	newHash, err := hash.HashPassword(input.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := uc.userRepo.UpdatePassword(ctx, user.ID, newHash); err != nil {
+		return err
+	}
+
+	if err := uc.resetRepo.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	_ = uc.attemptRepo.DeleteForEmail(ctx, normalizeEmail(user.Email))
+	return uc.tokenRepo.RevokeAllForUser(ctx, user.ID)
+}
@@ -0,0 +1,101 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/auth"
+)
+
+// MockPasswordResetRepository is a mock implementation of PasswordResetRepository
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	return m.Called(ctx, token).Error(0)
+}
+
+func (m *MockPasswordResetRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	token, _ := args.Get(0).(*domain.PasswordResetToken)
+	return token, args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func TestAuthUseCase_ForgotPassword(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown emails succeed without issuing a token", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+		resetRepo := new(MockPasswordResetRepository)
+		uc := auth.NewUseCase(userRepo, nil, nil, nil, resetRepo, nil, nil, nil, nil, lockoutConfig)
+
+		assert.NoError(t, uc.ForgotPassword(ctx, auth.ForgotPasswordInput{Email: "nobody@example.com"}))
+		resetRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("issues a token that expires in an hour", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), Email: "ana@example.com"}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+		resetRepo := new(MockPasswordResetRepository)
+		resetRepo.On("DeleteForUser", ctx, user.ID).Return(nil)
+		resetRepo.On("Create", ctx, mock.MatchedBy(func(tok *domain.PasswordResetToken) bool {
+			return tok.UserID == user.ID && tok.TokenHash != "" &&
+				time.Until(tok.ExpiresAt) > 59*time.Minute && time.Until(tok.ExpiresAt) <= time.Hour
+		})).Return(nil)
+		uc := auth.NewUseCase(userRepo, nil, nil, nil, resetRepo, nil, nil, nil, nil, lockoutConfig)
+
+		assert.NoError(t, uc.ForgotPassword(ctx, auth.ForgotPasswordInput{Email: user.Email}))
+		resetRepo.AssertExpectations(t)
+	})
+}
+
+func TestAuthUseCase_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Email: "Ana@example.com"}
+	input := auth.ResetPasswordInput{Token: "abc", NewPassword: "New-password1"}
+
+	t.Run("sets the password and signs out every session", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+		userRepo.On("UpdatePassword", ctx, user.ID, mock.MatchedBy(func(h string) bool {
+			return hash.CheckPassword(input.NewPassword, h)
+		})).Return(nil)
+		resetRepo := new(MockPasswordResetRepository)
+		resetRepo.On("GetByHash", ctx, hash.HashToken("abc")).Return(&domain.PasswordResetToken{UserID: user.ID, ExpiresAt: time.Now().Add(time.Minute)}, nil)
+		resetRepo.On("DeleteForUser", ctx, user.ID).Return(nil)
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("RevokeAllForUser", ctx, user.ID).Return(nil)
+		attemptRepo := new(MockLoginAttemptRepository)
+		attemptRepo.On("DeleteForEmail", ctx, "ana@example.com").Return(nil)
+		uc := auth.NewUseCase(userRepo, tokenRepo, attemptRepo, nil, resetRepo, nil, nil, nil, nil, lockoutConfig)
+
+		assert.NoError(t, uc.ResetPassword(ctx, input))
+		userRepo.AssertExpectations(t)
+		resetRepo.AssertExpectations(t)
+		tokenRepo.AssertExpectations(t)
+		attemptRepo.AssertExpectations(t)
+	})
+
+	t.Run("expired tokens are rejected", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		resetRepo := new(MockPasswordResetRepository)
+		resetRepo.On("GetByHash", ctx, hash.HashToken("abc")).Return(&domain.PasswordResetToken{UserID: user.ID, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+		uc := auth.NewUseCase(userRepo, nil, nil, nil, resetRepo, nil, nil, nil, nil, lockoutConfig)
+
+		assert.ErrorIs(t, uc.ResetPassword(ctx, input), domain.ErrResetTokenInvalid)
+		userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/useragent"
+)
+
+// Client is the device a request came from. Handlers fill it in and it is
+// recorded on the sessions the request starts.
+type Client struct {
+	IPAddress string
+	UserAgent string
+}
+
+// maxUserAgentLength matches the column size
+const maxUserAgentLength = 512
+
+// newSession describes a session starting now from the client
+func newSession(userID uuid.UUID, client Client) domain.RefreshToken {
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return domain.RefreshToken{
+		UserID:     userID,
+		SignedInAt: time.Now(),
+		Device:     useragent.Parse(userAgent).Name,
+		UserAgent:  userAgent,
+		IPAddress:  client.IPAddress,
+	}
+}
+
+// ListSessions returns the user's signed-in sessions
+func (uc *UseCase) ListSessions(ctx context.Context, userID uuid.UUID) ([]domain.RefreshToken, error) {
+	return uc.tokenRepo.ListActive(ctx, userID)
+}
+
+// RevokeSession signs one of the user's sessions out
+func (uc *UseCase) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := uc.tokenRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID || !session.IsValid() {
+		return domain.ErrSessionNotFound
+	}
+	return uc.tokenRepo.Revoke(ctx, session.ID)
+}
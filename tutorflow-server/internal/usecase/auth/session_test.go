@@ -0,0 +1,73 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/jwt"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/auth"
+)
+
+const chromeOnMac = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func TestAuthUseCase_Login_RecordsSession(t *testing.T) {
+	ctx := context.Background()
+	passwordHash, err := hash.HashPassword("Correct-horse1")
+	assert.NoError(t, err)
+	user := &domain.User{ID: uuid.New(), Email: "ana@example.com", PasswordHash: passwordHash, Role: domain.RoleStudent, Status: domain.StatusActive}
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	userRepo.On("UpdateLastLogin", ctx, user.ID).Return(nil)
+	attemptRepo := new(MockLoginAttemptRepository)
+	attemptRepo.On("GetRecent", ctx, user.Email, mock.Anything).Return(nil, nil)
+	tokenRepo := new(MockRefreshTokenRepository)
+	tokenRepo.On("Create", ctx, mock.Anything).Return(nil)
+	jwtManager := jwt.NewManager(config.JWTConfig{Secret: "test-secret-that-is-long-enough-32"})
+	uc := auth.NewUseCase(userRepo, tokenRepo, attemptRepo, nil, nil, nil, jwtManager, nil, nil, lockoutConfig)
+
+	_, err = uc.Login(ctx, auth.LoginInput{
+		Email:    user.Email,
+		Password: "Correct-horse1",
+		Client:   auth.Client{IPAddress: "203.0.113.7", UserAgent: chromeOnMac},
+	})
+	assert.NoError(t, err)
+
+	session := tokenRepo.Calls[0].Arguments.Get(1).(*domain.RefreshToken)
+	assert.Equal(t, "Chrome on macOS", session.Device)
+	assert.Equal(t, chromeOnMac, session.UserAgent)
+	assert.Equal(t, "203.0.113.7", session.IPAddress)
+	assert.WithinDuration(t, time.Now(), session.SignedInAt, time.Minute)
+}
+
+func TestAuthUseCase_RevokeSession(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	session := &domain.RefreshToken{ID: uuid.New(), UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}
+
+	t.Run("revokes the user's session", func(t *testing.T) {
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("GetByID", ctx, session.ID).Return(session, nil)
+		tokenRepo.On("Revoke", ctx, session.ID).Return(nil)
+		uc := auth.NewUseCase(nil, tokenRepo, nil, nil, nil, nil, nil, nil, nil, lockoutConfig)
+
+		assert.NoError(t, uc.RevokeSession(ctx, userID, session.ID))
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("other users' sessions are not found", func(t *testing.T) {
+		tokenRepo := new(MockRefreshTokenRepository)
+		tokenRepo.On("GetByID", ctx, session.ID).Return(session, nil)
+		uc := auth.NewUseCase(nil, tokenRepo, nil, nil, nil, nil, nil, nil, nil, lockoutConfig)
+
+		assert.ErrorIs(t, uc.RevokeSession(ctx, uuid.New(), session.ID), domain.ErrSessionNotFound)
+		tokenRepo.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+)
+
+// sendVerification emails the user a fresh link to verify their address,
+// replacing any earlier ones
+func (uc *UseCase) sendVerification(ctx context.Context, user *domain.User) error {
+	token, tokenHash, err := hash.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.verificationRepo.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.verificationRepo.Create(ctx, &domain.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(uc.cfg.VerificationTTL),
+	}); err != nil {
+		return err
+	}
+
+	if uc.emailSvc == nil {
+		return nil
+	}
+	verifyURL := uc.emailSvc.AppLink("/verify-email?token=" + url.QueryEscape(token))
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		_ = uc.emailSvc.SendEmailVerification(ctx, user.Email, user.FirstName, verifyURL)
+	}()
+	return nil
+}
+
+// VerifyEmail marks the email of the user the token was sent to as verified
+func (uc *UseCase) VerifyEmail(ctx context.Context, token string) error {
+	verification, err := uc.verificationRepo.GetByHash(ctx, hash.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if time.Now().After(verification.ExpiresAt) {
+		return domain.ErrVerificationInvalid
+	}
+
+	if err := uc.userRepo.VerifyEmail(ctx, verification.UserID); err != nil {
+		return err
+	}
+	return uc.verificationRepo.DeleteForUser(ctx, verification.UserID)
+}
+
+// ResendVerification emails the user a new verification link
+func (uc *UseCase) ResendVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified() {
+		return domain.ValidationErrors{{Field: "email", Message: "email is already verified"}}
+	}
+	return uc.sendVerification(ctx, user)
+}
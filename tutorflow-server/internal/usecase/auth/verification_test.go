@@ -0,0 +1,76 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/auth"
+)
+
+// MockEmailVerificationRepository is a mock implementation of EmailVerificationRepository
+type MockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationRepository) Create(ctx context.Context, token *domain.EmailVerificationToken) error {
+	return m.Called(ctx, token).Error(0)
+}
+
+func (m *MockEmailVerificationRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error) {
+	args := m.Called(ctx, tokenHash)
+	token, _ := args.Get(0).(*domain.EmailVerificationToken)
+	return token, args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func TestAuthUseCase_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("valid token verifies the user", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("VerifyEmail", ctx, userID).Return(nil)
+		verificationRepo := new(MockEmailVerificationRepository)
+		verificationRepo.On("GetByHash", ctx, hash.HashToken("abc")).Return(&domain.EmailVerificationToken{UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		verificationRepo.On("DeleteForUser", ctx, userID).Return(nil)
+		uc := auth.NewUseCase(userRepo, nil, nil, verificationRepo, nil, nil, nil, nil, nil, lockoutConfig)
+
+		assert.NoError(t, uc.VerifyEmail(ctx, "abc"))
+		userRepo.AssertExpectations(t)
+		verificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		verificationRepo := new(MockEmailVerificationRepository)
+		verificationRepo.On("GetByHash", ctx, hash.HashToken("abc")).Return(&domain.EmailVerificationToken{UserID: userID, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+		uc := auth.NewUseCase(userRepo, nil, nil, verificationRepo, nil, nil, nil, nil, nil, lockoutConfig)
+
+		assert.ErrorIs(t, uc.VerifyEmail(ctx, "abc"), domain.ErrVerificationInvalid)
+		userRepo.AssertNotCalled(t, "VerifyEmail", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthUseCase_ResendVerification_AlreadyVerified(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	user := &domain.User{ID: uuid.New(), EmailVerifiedAt: &verifiedAt}
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	verificationRepo := new(MockEmailVerificationRepository)
+	uc := auth.NewUseCase(userRepo, nil, nil, verificationRepo, nil, nil, nil, nil, nil, lockoutConfig)
+
+	var verrs domain.ValidationErrors
+	assert.ErrorAs(t, uc.ResendVerification(ctx, user.ID), &verrs)
+	verificationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -12,24 +12,18 @@ import (
 )
 
 type bundleUseCase struct {
-	bundleRepo     repository.BundleRepository
-	courseRepo     repository.CourseRepository
-	orderRepo      repository.OrderRepository
-	enrollmentRepo repository.EnrollmentRepository
+	bundleRepo repository.BundleRepository
+	courseRepo repository.CourseRepository
 }
 
 // NewUseCase creates a new bundle use case
 func NewUseCase(
 	bundleRepo repository.BundleRepository,
 	courseRepo repository.CourseRepository,
-	orderRepo repository.OrderRepository,
-	enrollmentRepo repository.EnrollmentRepository,
 ) domain.BundleUseCase {
 	return &bundleUseCase{
-		bundleRepo:     bundleRepo,
-		courseRepo:     courseRepo,
-		orderRepo:      orderRepo,
-		enrollmentRepo: enrollmentRepo,
+		bundleRepo: bundleRepo,
+		courseRepo: courseRepo,
 	}
 }
 
@@ -120,6 +114,7 @@ func (uc *bundleUseCase) UpdateBundle(ctx context.Context, bundle *domain.Bundle
 	existing.StartDate = bundle.StartDate
 	existing.EndDate = bundle.EndDate
 	existing.MaxPurchases = bundle.MaxPurchases
+	existing.DeductOwnedCourses = bundle.DeductOwnedCourses
 	existing.UpdatedAt = time.Now()
 
 	return uc.bundleRepo.Update(ctx, existing)
@@ -188,68 +183,6 @@ func (uc *bundleUseCase) RemoveCourseFromBundle(ctx context.Context, bundleID, c
 	return uc.bundleRepo.RemoveCourse(ctx, bundleID, courseID)
 }
 
-// PurchaseBundle purchases a bundle and enrolls user in all courses
-func (uc *bundleUseCase) PurchaseBundle(
-	ctx context.Context,
-	userID, bundleID uuid.UUID,
-) (*domain.BundlePurchase, error) {
-	bundle, err := uc.bundleRepo.GetByID(ctx, bundleID)
-	if err != nil {
-		return nil, errors.New("bundle not found")
-	}
-
-	if !bundle.IsAvailable() {
-		return nil, errors.New("bundle is not available")
-	}
-
-	// Get bundle courses
-	courses, err := uc.bundleRepo.GetCourses(ctx, bundleID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create order for the bundle
-	order := &domain.Order{
-		UserID:      userID,
-		OrderNumber: domain.GenerateOrderNumber(),
-		Subtotal:    bundle.OriginalPrice,
-		Discount:    bundle.Savings(),
-		Total:       bundle.BundlePrice,
-		Status:      domain.OrderStatusCompleted,
-	}
-
-	if err := uc.orderRepo.Create(ctx, order); err != nil {
-		return nil, err
-	}
-
-	// Create enrollments for each course
-	for _, course := range courses {
-		enrollment := &domain.Enrollment{
-			UserID:   userID,
-			CourseID: course.ID,
-		}
-		uc.enrollmentRepo.Create(ctx, enrollment)
-	}
-
-	// Record purchase
-	purchase := &domain.BundlePurchase{
-		BundleID: bundleID,
-		UserID:   userID,
-		OrderID:  order.ID,
-		Price:    bundle.BundlePrice,
-	}
-
-	if err := uc.bundleRepo.RecordPurchase(ctx, purchase); err != nil {
-		return nil, err
-	}
-
-	// Increment purchase count
-	uc.bundleRepo.IncrementPurchaseCount(ctx, bundleID)
-
-	purchase.Bundle = bundle
-	return purchase, nil
-}
-
 // GetUserBundles returns user's purchased bundles
 func (uc *bundleUseCase) GetUserBundles(ctx context.Context, userID uuid.UUID) ([]domain.BundlePurchase, error) {
 	return uc.bundleRepo.GetUserPurchases(ctx, userID)
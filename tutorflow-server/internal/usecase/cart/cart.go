@@ -62,7 +62,7 @@ func (uc *UseCase) AddToCart(ctx context.Context, userID *uuid.UUID, sessionID *
 	// Check if user is already enrolled
 	if userID != nil {
 		enrollment, _ := uc.enrollmentRepo.GetByUserAndCourse(ctx, *userID, input.CourseID)
-		if enrollment != nil && enrollment.CanAccess() {
+		if enrollment != nil && (enrollment.CanAccess() || enrollment.IsPaused()) {
 			return nil, domain.ErrAlreadyEnrolled
 		}
 	}
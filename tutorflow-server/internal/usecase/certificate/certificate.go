@@ -3,6 +3,7 @@ package certificate
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 
@@ -42,6 +43,49 @@ func (uc *UseCase) VerifyCertificate(ctx context.Context, certificateNumber stri
 		return nil, fmt.Errorf("certificate not found")
 	}
 
+	return toVerification(cert), nil
+}
+
+// VerifyBatchInput for verifying several certificates at once
+type VerifyBatchInput struct {
+	CertificateNumbers []string `json:"certificate_numbers" validate:"required,min=1,max=100,dive,required,max=50"`
+}
+
+// VerifyCertificates verifies many certificate numbers in one lookup. Results
+// follow the input order; unknown numbers come back with Valid set to false.
+func (uc *UseCase) VerifyCertificates(ctx context.Context, numbers []string) ([]CertificateVerification, error) {
+	lookup := make([]string, 0, len(numbers))
+	seen := make(map[string]bool, len(numbers))
+	for _, n := range numbers {
+		n = strings.TrimSpace(n)
+		if n != "" && !seen[n] {
+			seen[n] = true
+			lookup = append(lookup, n)
+		}
+	}
+
+	certs, err := uc.certRepo.GetByNumbers(ctx, lookup)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]*domain.Certificate, len(certs))
+	for i := range certs {
+		found[certs[i].CertificateNumber] = &certs[i]
+	}
+
+	results := make([]CertificateVerification, 0, len(numbers))
+	for _, n := range numbers {
+		n = strings.TrimSpace(n)
+		if cert, ok := found[n]; ok {
+			results = append(results, *toVerification(cert))
+			continue
+		}
+		results = append(results, CertificateVerification{Valid: false, CertificateNumber: n})
+	}
+	return results, nil
+}
+
+func toVerification(cert *domain.Certificate) *CertificateVerification {
 	var userName, courseName string
 	if cert.Enrollment != nil {
 		if cert.Enrollment.User != nil {
@@ -58,7 +102,7 @@ func (uc *UseCase) VerifyCertificate(ctx context.Context, certificateNumber stri
 		HolderName:        userName,
 		CourseName:        courseName,
 		IssuedAt:          cert.IssuedAt,
-	}, nil
+	}
 }
 
 // CertificateVerification response for public verification
@@ -104,6 +148,10 @@ func (uc *UseCase) IssueCertificate(ctx context.Context, enrollmentID uuid.UUID)
 		return nil, fmt.Errorf("enrollment is not completed")
 	}
 
+	if course, err := uc.courseRepo.GetByID(ctx, enrollment.CourseID); err == nil && !course.CertificateEnabled {
+		return nil, fmt.Errorf("this course does not offer a certificate")
+	}
+
 	// Create certificate
 	cert := &domain.Certificate{
 		EnrollmentID:      enrollmentID,
@@ -0,0 +1,67 @@
+package course_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+	"go.uber.org/zap"
+)
+
+// MockAuditLogRepository is a mock implementation of AuditLogRepository
+type MockAuditLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogRepository) Record(ctx context.Context, entry *domain.AuditLog, changes ...interface{}) error {
+	return m.Called(ctx, entry, changes).Error(0)
+}
+
+func (m *MockAuditLogRepository) List(ctx context.Context, filters repository.AuditLogFilters) ([]domain.AuditLog, int64, error) {
+	args := m.Called(ctx, filters)
+	entries, _ := args.Get(0).([]domain.AuditLog)
+	return entries, args.Get(1).(int64), args.Error(2)
+}
+
+func TestCourseUseCase_Archive(t *testing.T) {
+	ctx := context.Background()
+	instructorID, adminID := uuid.New(), uuid.New()
+	newCourse := func() *domain.Course {
+		return &domain.Course{ID: uuid.New(), InstructorID: instructorID, Status: domain.CourseStatusPublished}
+	}
+
+	t.Run("instructor archiving their course is not audited", func(t *testing.T) {
+		crs := newCourse()
+		courseRepo := new(MockCourseRepository)
+		courseRepo.On("GetByID", ctx, crs.ID).Return(crs, nil)
+		courseRepo.On("Update", ctx, crs).Return(nil)
+		auditRepo := new(MockAuditLogRepository)
+		uc := course.NewUseCase(courseRepo, nil, nil, nil, nil, nil, nil, auditRepo, config.CourseConfig{}, zap.NewNop().Sugar())
+
+		assert.NoError(t, uc.Archive(ctx, crs.ID, instructorID, false))
+		assert.Equal(t, domain.CourseStatusArchived, crs.Status)
+		auditRepo.AssertNotCalled(t, "Record", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("admin takedown is saved with an audit entry", func(t *testing.T) {
+		crs := newCourse()
+		courseRepo := new(MockCourseRepository)
+		courseRepo.On("GetByID", ctx, crs.ID).Return(crs, nil)
+		auditRepo := new(MockAuditLogRepository)
+		auditRepo.On("Record", ctx, mock.MatchedBy(func(e *domain.AuditLog) bool {
+			return e.ActorID == adminID && e.Action == domain.AuditCourseTakedown && e.TargetID == crs.ID &&
+				*e.Before == `{"status":"published"}` && *e.After == `{"status":"archived"}`
+		}), []interface{}{crs}).Return(nil)
+		uc := course.NewUseCase(courseRepo, nil, nil, nil, nil, nil, nil, auditRepo, config.CourseConfig{}, zap.NewNop().Sugar())
+
+		assert.NoError(t, uc.Archive(ctx, crs.ID, adminID, true))
+		auditRepo.AssertExpectations(t)
+		courseRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
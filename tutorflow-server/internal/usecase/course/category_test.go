@@ -0,0 +1,221 @@
+package course_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockCategoryRepository is a mock implementation of CategoryRepository
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) Create(ctx context.Context, category *domain.Category) error {
+	return m.Called(ctx, category).Error(0)
+}
+
+func (m *MockCategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	args := m.Called(ctx, id)
+	category, _ := args.Get(0).(*domain.Category)
+	return category, args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	args := m.Called(ctx, slug)
+	category, _ := args.Get(0).(*domain.Category)
+	return category, args.Error(1)
+}
+
+func (m *MockCategoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	return m.Called(ctx, category).Error(0)
+}
+
+func (m *MockCategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetWithSubcategories(ctx context.Context) ([]domain.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) CountSubcategories(ctx context.Context, id uuid.UUID) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCategoryRepository) CountCourses(ctx context.Context, id uuid.UUID) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCategoryRepository) ForceDelete(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) error {
+	return m.Called(ctx, id, reassignTo).Error(0)
+}
+
+func (m *MockCategoryRepository) GetByParent(ctx context.Context, parentID *uuid.UUID) ([]domain.Category, error) {
+	args := m.Called(ctx, parentID)
+	return args.Get(0).([]domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) Reorder(ctx context.Context, parentID *uuid.UUID, categoryIDs []uuid.UUID) error {
+	return m.Called(ctx, parentID, categoryIDs).Error(0)
+}
+
+func TestCourseUseCase_Create_PersistsCategories(t *testing.T) {
+	ctx := context.Background()
+	instructorID, webID, goID := uuid.New(), uuid.New(), uuid.New()
+
+	courseRepo := new(MockCourseRepository)
+	categoryRepo := new(MockCategoryRepository)
+	categoryRepo.On("GetByID", ctx, webID).Return(&domain.Category{ID: webID}, nil)
+	categoryRepo.On("GetByID", ctx, goID).Return(&domain.Category{ID: goID}, nil)
+	courseRepo.On("Create", ctx, mock.Anything).Return(nil)
+	courseRepo.On("SetCategories", ctx, mock.Anything, []uuid.UUID{webID, goID}).Return(nil)
+
+	uc := course.NewUseCase(courseRepo, categoryRepo, nil, nil, nil, verifiedUsers(), nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+	// The singular field is still honoured, and repeats are dropped
+	single := goID.String()
+	_, err := uc.Create(ctx, instructorID, course.CreateInput{
+		Title:       "Building APIs in Go",
+		Level:       "beginner",
+		CategoryIDs: []string{webID.String(), goID.String()},
+		CategoryID:  &single,
+	})
+	assert.NoError(t, err)
+	courseRepo.AssertExpectations(t)
+}
+
+func TestCourseUseCase_Create_UnknownCategory(t *testing.T) {
+	ctx := context.Background()
+	missing := uuid.New()
+
+	courseRepo := new(MockCourseRepository)
+	categoryRepo := new(MockCategoryRepository)
+	categoryRepo.On("GetByID", ctx, missing).Return(nil, gorm.ErrRecordNotFound)
+
+	uc := course.NewUseCase(courseRepo, categoryRepo, nil, nil, nil, verifiedUsers(), nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+	_, err := uc.Create(ctx, uuid.New(), course.CreateInput{
+		Title:       "Building APIs in Go",
+		Level:       "beginner",
+		CategoryIDs: []string{missing.String()},
+	})
+	assert.IsType(t, domain.ValidationErrors{}, err)
+	courseRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCourseUseCase_DeleteCategory(t *testing.T) {
+	ctx := context.Background()
+	id, target := uuid.New(), uuid.New()
+
+	setup := func(subcategories, courses int64) (*course.UseCase, *MockCategoryRepository) {
+		categoryRepo := new(MockCategoryRepository)
+		categoryRepo.On("GetByID", ctx, id).Return(&domain.Category{ID: id}, nil)
+		categoryRepo.On("GetByID", ctx, target).Return(&domain.Category{ID: target}, nil)
+		categoryRepo.On("CountSubcategories", ctx, id).Return(subcategories, nil)
+		categoryRepo.On("CountCourses", ctx, id).Return(courses, nil)
+		uc := course.NewUseCase(nil, categoryRepo, nil, nil, nil, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+		return uc, categoryRepo
+	}
+
+	t.Run("unused categories are deleted", func(t *testing.T) {
+		uc, categoryRepo := setup(0, 0)
+		categoryRepo.On("Delete", ctx, id).Return(nil)
+
+		assert.NoError(t, uc.DeleteCategory(ctx, id, course.DeleteCategoryInput{}))
+		categoryRepo.AssertCalled(t, "Delete", ctx, id)
+	})
+
+	t.Run("courses block the delete", func(t *testing.T) {
+		uc, categoryRepo := setup(0, 3)
+
+		err := uc.DeleteCategory(ctx, id, course.DeleteCategoryInput{})
+		assert.ErrorIs(t, err, domain.ErrCategoryInUse)
+		categoryRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("subcategories block the delete", func(t *testing.T) {
+		uc, categoryRepo := setup(2, 0)
+
+		err := uc.DeleteCategory(ctx, id, course.DeleteCategoryInput{})
+		assert.ErrorIs(t, err, domain.ErrCategoryInUse)
+		categoryRepo.AssertNotCalled(t, "ForceDelete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("force needs somewhere to put the courses", func(t *testing.T) {
+		uc, categoryRepo := setup(0, 3)
+
+		err := uc.DeleteCategory(ctx, id, course.DeleteCategoryInput{Force: true})
+		assert.ErrorIs(t, err, domain.ErrCategoryInUse)
+		categoryRepo.AssertNotCalled(t, "ForceDelete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("force with only subcategories", func(t *testing.T) {
+		uc, categoryRepo := setup(2, 0)
+		categoryRepo.On("ForceDelete", ctx, id, (*uuid.UUID)(nil)).Return(nil)
+
+		assert.NoError(t, uc.DeleteCategory(ctx, id, course.DeleteCategoryInput{Force: true}))
+		categoryRepo.AssertCalled(t, "ForceDelete", ctx, id, (*uuid.UUID)(nil))
+	})
+
+	t.Run("force reassigns the courses", func(t *testing.T) {
+		uc, categoryRepo := setup(1, 3)
+		categoryRepo.On("ForceDelete", ctx, id, &target).Return(nil)
+
+		assert.NoError(t, uc.DeleteCategory(ctx, id, course.DeleteCategoryInput{Force: true, ReassignTo: &target}))
+		categoryRepo.AssertExpectations(t)
+	})
+
+	t.Run("cannot reassign to itself", func(t *testing.T) {
+		uc, categoryRepo := setup(0, 3)
+
+		err := uc.DeleteCategory(ctx, id, course.DeleteCategoryInput{Force: true, ReassignTo: &id})
+		assert.IsType(t, domain.ValidationErrors{}, err)
+		categoryRepo.AssertNotCalled(t, "ForceDelete", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCourseUseCase_ReorderCategories(t *testing.T) {
+	ctx := context.Background()
+	parentID, a, b := uuid.New(), uuid.New(), uuid.New()
+	siblings := []domain.Category{{ID: a, ParentID: &parentID}, {ID: b, ParentID: &parentID}}
+
+	t.Run("orders the subcategories of a parent", func(t *testing.T) {
+		categoryRepo := new(MockCategoryRepository)
+		categoryRepo.On("GetByParent", ctx, &parentID).Return(siblings, nil)
+		categoryRepo.On("Reorder", ctx, &parentID, []uuid.UUID{b, a}).Return(nil)
+		uc := course.NewUseCase(nil, categoryRepo, nil, nil, nil, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+		_, err := uc.ReorderCategories(ctx, &parentID, []uuid.UUID{b, a})
+		assert.NoError(t, err)
+		categoryRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects incomplete or foreign lists", func(t *testing.T) {
+		categoryRepo := new(MockCategoryRepository)
+		categoryRepo.On("GetByParent", ctx, &parentID).Return(siblings, nil)
+		uc := course.NewUseCase(nil, categoryRepo, nil, nil, nil, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+		for _, ids := range [][]uuid.UUID{{a}, {a, a}, {a, uuid.New()}} {
+			_, err := uc.ReorderCategories(ctx, &parentID, ids)
+			assert.ErrorIs(t, err, domain.ErrInvalidCategoryOrder)
+		}
+		categoryRepo.AssertNotCalled(t, "Reorder", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
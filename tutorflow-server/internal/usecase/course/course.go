@@ -2,15 +2,18 @@ package course
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gosimple/slug"
+	"go.uber.org/zap"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
 )
 
@@ -21,6 +24,11 @@ type UseCase struct {
 	moduleRepo     repository.ModuleRepository
 	lessonRepo     repository.LessonRepository
 	enrollmentRepo repository.EnrollmentRepository
+	userRepo       repository.UserRepository
+	wishlistRepo   repository.WishlistRepository
+	auditRepo      repository.AuditLogRepository
+	cfg            config.CourseConfig
+	logger         *zap.SugaredLogger
 }
 
 // NewUseCase creates a new course use case
@@ -30,6 +38,11 @@ func NewUseCase(
 	moduleRepo repository.ModuleRepository,
 	lessonRepo repository.LessonRepository,
 	enrollmentRepo repository.EnrollmentRepository,
+	userRepo repository.UserRepository,
+	wishlistRepo repository.WishlistRepository,
+	auditRepo repository.AuditLogRepository,
+	cfg config.CourseConfig,
+	logger *zap.SugaredLogger,
 ) *UseCase {
 	return &UseCase{
 		courseRepo:     courseRepo,
@@ -37,6 +50,11 @@ func NewUseCase(
 		moduleRepo:     moduleRepo,
 		lessonRepo:     lessonRepo,
 		enrollmentRepo: enrollmentRepo,
+		userRepo:       userRepo,
+		wishlistRepo:   wishlistRepo,
+		auditRepo:      auditRepo,
+		cfg:            cfg,
+		logger:         logger,
 	}
 }
 
@@ -58,6 +76,32 @@ type ListInput struct {
 
 // List returns paginated courses
 func (uc *UseCase) List(ctx context.Context, input ListInput, isPublicOnly bool) ([]domain.Course, int64, error) {
+	return uc.courseRepo.List(ctx, listFilters(input, isPublicOnly))
+}
+
+// ListAfter returns the courses after a cursor, newest first, and the
+// cursor for the page after them. Other sort orders can't be paged by cursor.
+func (uc *UseCase) ListAfter(ctx context.Context, input ListInput, isPublicOnly bool, cursor pagination.Cursor) ([]domain.Course, string, error) {
+	if input.SortBy != "" && (input.SortBy != "created_at" || input.SortOrder == "asc") {
+		return nil, "", domain.ValidationErrors{{Field: "cursor", Message: "only courses sorted newest first can be paged by cursor"}}
+	}
+
+	filters := listFilters(input, isPublicOnly)
+	filters.Cursor = &cursor
+	courses, _, err := uc.courseRepo.List(ctx, filters)
+	if err != nil {
+		return nil, "", err
+	}
+
+	courses, next := pagination.Next(courses, filters.Limit, func(c domain.Course) pagination.Cursor {
+		return pagination.Cursor{Time: c.CreatedAt, ID: c.ID}
+	})
+	return courses, next, nil
+}
+
+// listFilters turns list input into repository filters, defaulting the page
+// size and limiting the public catalog to published courses
+func listFilters(input ListInput, isPublicOnly bool) repository.CourseFilters {
 	if input.Page < 1 {
 		input.Page = 1
 	}
@@ -82,11 +126,11 @@ func (uc *UseCase) List(ctx context.Context, input ListInput, isPublicOnly bool)
 	if isPublicOnly {
 		published := domain.CourseStatusPublished
 		filters.Status = &published
+		filters.ActiveInstructorsOnly = true
 	} else if input.Status != nil {
 		filters.Status = input.Status
 	}
-
-	return uc.courseRepo.List(ctx, filters)
+	return filters
 }
 
 // GetByID returns a course by ID
@@ -99,6 +143,52 @@ func (uc *UseCase) GetBySlug(ctx context.Context, slugStr string) (*domain.Cours
 	return uc.courseRepo.GetBySlug(ctx, slugStr)
 }
 
+// GetCourseFunnel shows how many of a course's learners reached each progress
+// milestone and completed it. A course nobody has enrolled in gets a funnel
+// with every step at zero.
+func (uc *UseCase) GetCourseFunnel(ctx context.Context, courseID uuid.UUID) (*domain.CourseFunnel, error) {
+	funnel, err := uc.enrollmentRepo.GetCourseFunnel(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if funnel.Enrolled > 0 {
+		for i := range funnel.Steps {
+			funnel.Steps[i].Percent = float64(funnel.Steps[i].Learners) / float64(funnel.Enrolled) * 100
+		}
+		funnel.CompletionRate = float64(funnel.Completed) / float64(funnel.Enrolled) * 100
+	}
+	return funnel, nil
+}
+
+// GetWishlistCount returns how many learners have the course on their wishlist
+func (uc *UseCase) GetWishlistCount(ctx context.Context, courseID uuid.UUID) (int64, error) {
+	return uc.wishlistRepo.CountByCourse(ctx, courseID, nil)
+}
+
+// WishlistStats is the instructor's aggregate view of a course's wishlist
+type WishlistStats struct {
+	CourseID   uuid.UUID `json:"course_id"`
+	Total      int64     `json:"total"`
+	Last30Days int64     `json:"last_30_days"`
+}
+
+// GetWishlistStats returns wishlist totals for a course without exposing who
+func (uc *UseCase) GetWishlistStats(ctx context.Context, courseID uuid.UUID) (*WishlistStats, error) {
+	total, err := uc.wishlistRepo.CountByCourse(ctx, courseID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -30)
+	recent, err := uc.wishlistRepo.CountByCourse(ctx, courseID, &since)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WishlistStats{CourseID: courseID, Total: total, Last30Days: recent}, nil
+}
+
 // GetByInstructor returns courses by instructor
 func (uc *UseCase) GetByInstructor(ctx context.Context, instructorID uuid.UUID, page, limit int) ([]domain.Course, int64, error) {
 	if page < 1 {
@@ -112,19 +202,22 @@ func (uc *UseCase) GetByInstructor(ctx context.Context, instructorID uuid.UUID,
 
 // CreateInput for creating a course
 type CreateInput struct {
-	Title            string        `json:"title" form:"title" validate:"required,min=5,max=255"`
-	Description      *string       `json:"description" form:"description"`
-	ShortDescription *string       `json:"short_description" form:"short_description" validate:"omitempty,max=500"`
-	ThumbnailURL     *string       `json:"thumbnail_url" form:"thumbnail_url"`
-	Level            string        `json:"level" form:"level" validate:"required,oneof=beginner intermediate advanced"`
-	Price            float64       `json:"price" form:"price" validate:"gte=0"`
-	DiscountPrice    *float64      `json:"discount_price" form:"discount_price" validate:"omitempty,gte=0"`
-	CategoryIDs      []string      `json:"category_ids" form:"category_ids"`
-	CategoryID       *string       `json:"-" form:"category_id"`
-	Requirements     []string      `json:"requirements" form:"requirements"`
-	WhatYouLearn     []string      `json:"what_you_learn" form:"what_you_learn"`
-	Language         string        `json:"language" form:"language"`
-	Modules          []ModuleInput `json:"modules" form:"-"`
+	Title              string            `json:"title" form:"title" validate:"required,min=5,max=255"`
+	Description        *string           `json:"description" form:"description"`
+	ShortDescription   *string           `json:"short_description" form:"short_description" validate:"omitempty,max=500"`
+	ThumbnailURL       *string           `json:"thumbnail_url" form:"thumbnail_url"`
+	ThumbnailVariants  map[string]string `json:"-"` // set by the handler with ThumbnailURL when it stores an uploaded thumbnail
+	Level              string            `json:"level" form:"level" validate:"required,oneof=beginner intermediate advanced"`
+	Price              float64           `json:"price" form:"price" validate:"gte=0"`
+	DiscountPrice      *float64          `json:"discount_price" form:"discount_price" validate:"omitempty,gte=0"`
+	CategoryIDs        []string          `json:"category_ids" form:"category_ids"`
+	CategoryID         *string           `json:"category_id" form:"category_id"` // older clients send a single category
+	Requirements       []string          `json:"requirements" form:"requirements"`
+	WhatYouLearn       []string          `json:"what_you_learn" form:"what_you_learn"`
+	Language           string            `json:"language" form:"language"`
+	CertificateEnabled *bool             `json:"certificate_enabled" form:"certificate_enabled"`
+	SubscriptionTier   int               `json:"subscription_tier" form:"subscription_tier" validate:"gte=0"` // 0 keeps the course out of subscriptions
+	Modules            []ModuleInput     `json:"modules" form:"-"`
 }
 
 type ModuleInput struct {
@@ -146,30 +239,52 @@ type LessonInput struct {
 	VideoURL        *string `json:"video_url"`
 }
 
+// CanCreate returns ErrEmailNotVerified unless the instructor has confirmed
+// their email, so callers can check before storing anything for a new course
+func (uc *UseCase) CanCreate(ctx context.Context, instructorID uuid.UUID) error {
+	instructor, err := uc.userRepo.GetByID(ctx, instructorID)
+	if err != nil {
+		return err
+	}
+	if !instructor.IsEmailVerified() {
+		return domain.ErrEmailNotVerified
+	}
+	return nil
+}
+
 // Create creates a new course
 func (uc *UseCase) Create(ctx context.Context, instructorID uuid.UUID, input CreateInput) (*domain.Course, error) {
+	if err := uc.CanCreate(ctx, instructorID); err != nil {
+		return nil, err
+	}
+
 	courseSlug := slug.Make(input.Title)
 
-	// Handle both plural and singular categories for flexibility
-	categoryIDs := input.CategoryIDs
-	if input.CategoryID != nil && *input.CategoryID != "" {
-		categoryIDs = append(categoryIDs, *input.CategoryID)
+	categoryIDs, err := uc.resolveCategoryIDs(ctx, input.CategoryIDs, input.CategoryID)
+	if err != nil {
+		return nil, err
 	}
 
 	course := &domain.Course{
-		Title:            input.Title,
-		Slug:             courseSlug,
-		Description:      input.Description,
-		ShortDescription: input.ShortDescription,
-		ThumbnailURL:     input.ThumbnailURL,
-		InstructorID:     instructorID,
-		Status:           domain.CourseStatusDraft,
-		Level:            domain.CourseLevel(input.Level),
-		Price:            input.Price,
-		DiscountPrice:    input.DiscountPrice,
-		Requirements:     input.Requirements,
-		WhatYouLearn:     input.WhatYouLearn,
-		Language:         input.Language,
+		Title:             input.Title,
+		Slug:              courseSlug,
+		Description:       input.Description,
+		ShortDescription:  input.ShortDescription,
+		ThumbnailURL:      input.ThumbnailURL,
+		ThumbnailVariants: input.ThumbnailVariants,
+		InstructorID:      instructorID,
+		Status:            domain.CourseStatusDraft,
+		Level:             domain.CourseLevel(input.Level),
+		Price:             input.Price,
+		DiscountPrice:     input.DiscountPrice,
+		Requirements:      input.Requirements,
+		WhatYouLearn:      input.WhatYouLearn,
+		Language:          input.Language,
+		SubscriptionTier:  input.SubscriptionTier,
+	}
+
+	if err := course.ValidatePricing(); err != nil {
+		return nil, err
 	}
 
 	if course.Language == "" {
@@ -180,6 +295,20 @@ func (uc *UseCase) Create(ctx context.Context, instructorID uuid.UUID, input Cre
 		return nil, err
 	}
 
+	if len(categoryIDs) > 0 {
+		if err := uc.courseRepo.SetCategories(ctx, course.ID, categoryIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create skips false because the column defaults to true, so write it explicitly
+	if input.CertificateEnabled != nil && !*input.CertificateEnabled {
+		course.CertificateEnabled = false
+		if err := uc.courseRepo.Update(ctx, course); err != nil {
+			return nil, err
+		}
+	}
+
 	// Save curriculum if provided
 	if len(input.Modules) > 0 {
 		if err := uc.saveCurriculum(ctx, course.ID, input.Modules); err != nil {
@@ -192,19 +321,25 @@ func (uc *UseCase) Create(ctx context.Context, instructorID uuid.UUID, input Cre
 
 // UpdateInput for updating a course
 type UpdateInput struct {
-	Title            *string       `json:"title" form:"title" validate:"omitempty,min=5,max=255"`
-	Description      *string       `json:"description" form:"description"`
-	ShortDescription *string       `json:"short_description" form:"short_description" validate:"omitempty,max=500"`
-	ThumbnailURL     *string       `json:"thumbnail_url" form:"thumbnail_url" validate:"omitempty,url"`
-	PreviewVideoURL  *string       `json:"preview_video_url" form:"preview_video_url" validate:"omitempty,url"`
-	Level            *string       `json:"level" form:"level" validate:"omitempty,oneof=beginner intermediate advanced"`
-	Price            *float64      `json:"price" form:"price" validate:"omitempty,gte=0"`
-	DiscountPrice    *float64      `json:"discount_price" form:"discount_price" validate:"omitempty,gte=0"`
-	Requirements     []string      `json:"requirements" form:"requirements"`
-	WhatYouLearn     []string      `json:"what_you_learn" form:"what_you_learn"`
-	Language         *string       `json:"language" form:"language"`
-	IsFeatured       *bool         `json:"is_featured" form:"is_featured"`
-	Modules          []ModuleInput `json:"modules" form:"-"`
+	Title              *string           `json:"title" form:"title" validate:"omitempty,min=5,max=255"`
+	Description        *string           `json:"description" form:"description"`
+	ShortDescription   *string           `json:"short_description" form:"short_description" validate:"omitempty,max=500"`
+	ThumbnailURL       *string           `json:"thumbnail_url" form:"thumbnail_url" validate:"omitempty,url"`
+	ThumbnailVariants  map[string]string `json:"-"` // set by the handler with ThumbnailURL when it stores an uploaded thumbnail
+	PreviewVideoURL    *string           `json:"preview_video_url" form:"preview_video_url" validate:"omitempty,url"`
+	Level              *string           `json:"level" form:"level" validate:"omitempty,oneof=beginner intermediate advanced"`
+	Price              *float64          `json:"price" form:"price" validate:"omitempty,gte=0"`
+	DiscountPrice      *float64          `json:"discount_price" form:"discount_price" validate:"omitempty,gte=0"`
+	Requirements       []string          `json:"requirements" form:"requirements"`
+	WhatYouLearn       []string          `json:"what_you_learn" form:"what_you_learn"`
+	Language           *string           `json:"language" form:"language"`
+	IsFeatured         *bool             `json:"is_featured" form:"is_featured"`
+	CertificateEnabled *bool             `json:"certificate_enabled" form:"certificate_enabled"`
+	PublicDiscussions  *bool             `json:"public_discussions" form:"public_discussions"`
+	SubscriptionTier   *int              `json:"subscription_tier" form:"subscription_tier" validate:"omitempty,gte=0"`
+	CategoryIDs        []string          `json:"category_ids" form:"category_ids"` // replaces the course's categories when set
+	CategoryID         *string           `json:"category_id" form:"category_id"`
+	Modules            []ModuleInput     `json:"modules" form:"-"`
 }
 
 // Update updates a course
@@ -225,7 +360,9 @@ func (uc *UseCase) Update(ctx context.Context, id uuid.UUID, input UpdateInput)
 		course.ShortDescription = input.ShortDescription
 	}
 	if input.ThumbnailURL != nil {
+		// A linked thumbnail has no variants of its own
 		course.ThumbnailURL = input.ThumbnailURL
+		course.ThumbnailVariants = input.ThumbnailVariants
 	}
 	if input.PreviewVideoURL != nil {
 		course.PreviewVideoURL = input.PreviewVideoURL
@@ -248,13 +385,40 @@ func (uc *UseCase) Update(ctx context.Context, id uuid.UUID, input UpdateInput)
 	if input.Language != nil {
 		course.Language = *input.Language
 	}
-	// Prevent GORM from re-saving old modules that we want to replace
+	if input.CertificateEnabled != nil {
+		course.CertificateEnabled = *input.CertificateEnabled
+	}
+	if input.PublicDiscussions != nil {
+		course.PublicDiscussions = *input.PublicDiscussions
+	}
+	if input.SubscriptionTier != nil {
+		course.SubscriptionTier = *input.SubscriptionTier
+	}
+	if err := course.ValidatePricing(); err != nil {
+		return nil, err
+	}
+	replaceCategories := input.CategoryIDs != nil || input.CategoryID != nil
+	var categoryIDs []uuid.UUID
+	if replaceCategories {
+		if categoryIDs, err = uc.resolveCategoryIDs(ctx, input.CategoryIDs, input.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Prevent GORM from re-saving old modules and categories that we want to replace
 	course.Modules = nil
+	course.Categories = nil
 
 	if err := uc.courseRepo.Update(ctx, course); err != nil {
 		return nil, err
 	}
 
+	if replaceCategories {
+		if err := uc.courseRepo.SetCategories(ctx, course.ID, categoryIDs); err != nil {
+			return nil, err
+		}
+	}
+
 	// Save curriculum if provided (this handles deletions, creations, and stats updates)
 	if len(input.Modules) > 0 {
 		if err := uc.saveCurriculum(ctx, course.ID, input.Modules); err != nil {
@@ -266,61 +430,52 @@ func (uc *UseCase) Update(ctx context.Context, id uuid.UUID, input UpdateInput)
 	return uc.courseRepo.GetByID(ctx, course.ID)
 }
 
-func (uc *UseCase) saveCurriculum(ctx context.Context, courseID uuid.UUID, modules []ModuleInput) error {
-	f, _ := os.OpenFile("/tmp/course_debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if f != nil {
-		defer f.Close()
-		fmt.Fprintf(f, "[%v] saveCurriculum: started for courseID=%s, received %d modules\n", time.Now().Format("15:04:05"), courseID, len(modules))
+// resolveCategoryIDs merges the plural and singular category fields into a
+// list of existing category IDs without duplicates
+func (uc *UseCase) resolveCategoryIDs(ctx context.Context, ids []string, single *string) ([]uuid.UUID, error) {
+	if single != nil && *single != "" {
+		ids = append(ids, *single)
 	}
 
-	oldModules, err := uc.moduleRepo.GetByCourse(ctx, courseID)
-	if err == nil {
-		if f != nil {
-			fmt.Fprintf(f, "[%v] saveCurriculum: found %d old modules to delete\n", time.Now().Format("15:04:05"), len(oldModules))
+	resolved := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, raw := range ids {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, domain.ValidationErrors{{Field: "category_ids", Message: "invalid category ID: " + raw}}
 		}
-		for _, m := range oldModules {
-			if err := uc.lessonRepo.DeleteByModule(ctx, m.ID); err != nil {
-				if f != nil {
-					fmt.Fprintf(f, "[%v] saveCurriculum: FAILED deleting lessons for module %s: %v\n", time.Now().Format("15:04:05"), m.ID, err)
-				}
-				return err
-			}
-			if err := uc.moduleRepo.Delete(ctx, m.ID); err != nil {
-				if f != nil {
-					fmt.Fprintf(f, "[%v] saveCurriculum: FAILED deleting module %s: %v\n", time.Now().Format("15:04:05"), m.ID, err)
-				}
-				return err
-			}
+		if seen[id] {
+			continue
 		}
-	} else {
-		if f != nil {
-			fmt.Fprintf(f, "[%v] saveCurriculum: error fetching old modules: %v\n", time.Now().Format("15:04:05"), err)
+		if _, err := uc.categoryRepo.GetByID(ctx, id); err != nil {
+			return nil, domain.ValidationErrors{{Field: "category_ids", Message: "unknown category: " + raw}}
 		}
+		seen[id] = true
+		resolved = append(resolved, id)
 	}
+	return resolved, nil
+}
 
-	for i, mInput := range modules {
-		moduleID := uuid.New()
-		module := &domain.Module{
-			ID:          moduleID,
-			CourseID:    courseID,
+// saveCurriculum makes the course's modules and lessons match the input.
+// Entries carrying the ID of an existing module or lesson keep it, so
+// learners' progress survives edits; entries without one are created.
+func (uc *UseCase) saveCurriculum(ctx context.Context, courseID uuid.UUID, inputs []ModuleInput) error {
+	uc.logger.Debugw("Saving curriculum", "course_id", courseID, "modules", len(inputs))
+
+	modules := make([]domain.Module, len(inputs))
+	for i, mInput := range inputs {
+		modules[i] = domain.Module{
+			ID:          parseInputID(mInput.ID),
 			Title:       mInput.Title,
 			Description: mInput.Description,
 			SortOrder:   i,
 			IsPublished: true,
+			Lessons:     make([]domain.Lesson, len(mInput.Lessons)),
 		}
-
-		if err := uc.moduleRepo.Create(ctx, module); err != nil {
-			if f != nil {
-				fmt.Fprintf(f, "[%v] saveCurriculum: FAILED creating module %d: %v\n", time.Now().Format("15:04:05"), i, err)
-			}
-			return err
-		}
-
 		for j, lInput := range mInput.Lessons {
 			durationSec := lInput.DurationMinutes * 60
-			lesson := &domain.Lesson{
-				ID:            uuid.New(),
-				ModuleID:      moduleID,
+			modules[i].Lessons[j] = domain.Lesson{
+				ID:            parseInputID(lInput.ID),
 				Title:         lInput.Title,
 				Description:   lInput.Description,
 				Content:       lInput.Content,
@@ -330,21 +485,27 @@ func (uc *UseCase) saveCurriculum(ctx context.Context, courseID uuid.UUID, modul
 				VideoDuration: &durationSec,
 				IsPublished:   true,
 			}
-			if err := uc.lessonRepo.Create(ctx, lesson); err != nil {
-				if f != nil {
-					fmt.Fprintf(f, "[%v] saveCurriculum: FAILED creating lesson %d in module %d: %v\n", time.Now().Format("15:04:05"), j, i, err)
-				}
-				return err
-			}
 		}
 	}
 
-	if f != nil {
-		fmt.Fprintf(f, "[%v] saveCurriculum: COMPLETED successfully\n", time.Now().Format("15:04:05"))
+	if err := uc.moduleRepo.SaveCurriculum(ctx, courseID, modules); err != nil {
+		uc.logger.Debugw("Saving curriculum failed", "course_id", courseID, "error", err)
+		return err
 	}
+
 	return uc.courseRepo.UpdateStats(ctx, courseID)
 }
 
+// parseInputID reads the ID of a curriculum entry. Editors send temporary
+// client-side IDs for new entries, which come back as uuid.Nil.
+func parseInputID(id string) uuid.UUID {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil
+	}
+	return parsed
+}
+
 // ValidateOwnership checks if user owns the course
 func (uc *UseCase) ValidateOwnership(ctx context.Context, courseID, userID uuid.UUID) error {
 	course, err := uc.courseRepo.GetByID(ctx, courseID)
@@ -359,35 +520,202 @@ func (uc *UseCase) ValidateOwnership(ctx context.Context, courseID, userID uuid.
 	return nil
 }
 
-// Publish publishes a course
+// GetPublishChecklist reports which publishing requirements a course meets
+func (uc *UseCase) GetPublishChecklist(ctx context.Context, id uuid.UUID) (*domain.PublishChecklist, error) {
+	course, err := uc.courseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return uc.publishChecklist(ctx, course)
+}
+
+func (uc *UseCase) publishChecklist(ctx context.Context, course *domain.Course) (*domain.PublishChecklist, error) {
+	modules, err := uc.GetCurriculum(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	hasPublishedLesson := false
+	for _, m := range modules {
+		for _, l := range m.Lessons {
+			if l.IsPublished {
+				hasPublishedLesson = true
+			}
+		}
+	}
+
+	return course.CheckPublishReadiness(hasPublishedLesson), nil
+}
+
+// Publish publishes a course once it meets every publishing requirement
 func (uc *UseCase) Publish(ctx context.Context, id uuid.UUID) error {
 	course, err := uc.courseRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	// Validate course has content
-	modules, err := uc.moduleRepo.GetByCourse(ctx, id)
+	checklist, err := uc.publishChecklist(ctx, course)
 	if err != nil {
 		return err
 	}
-	if len(modules) == 0 {
-		return domain.ErrContentLocked
+	if !checklist.Ready {
+		return &domain.CourseNotReady{CourseID: course.ID, Missing: checklist.Missing}
 	}
 
 	course.Status = domain.CourseStatusPublished
 	return uc.courseRepo.Update(ctx, course)
 }
 
-// Archive archives a course
-func (uc *UseCase) Archive(ctx context.Context, id uuid.UUID) error {
+// Archive archives a course. An admin archiving someone else's course is a
+// takedown and is recorded in the audit log.
+func (uc *UseCase) Archive(ctx context.Context, id, actorID uuid.UUID, isAdmin bool) error {
 	course, err := uc.courseRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
+	before := course.Status
 	course.Status = domain.CourseStatusArchived
-	return uc.courseRepo.Update(ctx, course)
+	if !isAdmin || course.InstructorID == actorID {
+		return uc.courseRepo.Update(ctx, course)
+	}
+
+	entry := domain.NewAuditLog(actorID, domain.AuditCourseTakedown, "course", id,
+		map[string]domain.CourseStatus{"status": before},
+		map[string]domain.CourseStatus{"status": course.Status})
+	return uc.auditRepo.Record(ctx, entry, course)
+}
+
+// enrollmentCodeAlphabet omits characters that are easily confused (0/O, 1/I)
+const enrollmentCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const enrollmentCodeLength = 8
+
+// GetEnrollmentCode returns the current enrollment code of a course, if any
+func (uc *UseCase) GetEnrollmentCode(ctx context.Context, id uuid.UUID) (*string, error) {
+	course, err := uc.courseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return course.EnrollmentCode, nil
+}
+
+// RotateEnrollmentCode generates a new enrollment code, invalidating the previous one
+func (uc *UseCase) RotateEnrollmentCode(ctx context.Context, id uuid.UUID) (string, error) {
+	if _, err := uc.courseRepo.GetByID(ctx, id); err != nil {
+		return "", err
+	}
+
+	code, err := generateEnrollmentCode()
+	if err != nil {
+		return "", err
+	}
+
+	if err := uc.courseRepo.SetEnrollmentCode(ctx, id, &code); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// DisableEnrollmentCode removes the enrollment code so it can no longer be used
+func (uc *UseCase) DisableEnrollmentCode(ctx context.Context, id uuid.UUID) error {
+	if _, err := uc.courseRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return uc.courseRepo.SetEnrollmentCode(ctx, id, nil)
+}
+
+func generateEnrollmentCode() (string, error) {
+	b := make([]byte, enrollmentCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = enrollmentCodeAlphabet[int(b[i])%len(enrollmentCodeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// CoInstructorInput is one instructor's share of the course revenue
+type CoInstructorInput struct {
+	InstructorID uuid.UUID `json:"instructor_id" validate:"required"`
+	SharePercent float64   `json:"share_percent" validate:"required,gt=0,lte=100"`
+}
+
+// SetCoInstructorsInput for configuring co-teaching revenue splits
+type SetCoInstructorsInput struct {
+	Instructors []CoInstructorInput `json:"instructors" validate:"required,min=1,max=10,dive"`
+}
+
+// GetCoInstructors returns the revenue splits of a course. Courses without
+// co-instructors report the owner with the full share.
+func (uc *UseCase) GetCoInstructors(ctx context.Context, courseID uuid.UUID) ([]domain.CourseInstructor, error) {
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	splits, err := uc.courseRepo.GetInstructorSplits(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(splits) == 0 {
+		splits = []domain.CourseInstructor{{
+			CourseID:     course.ID,
+			InstructorID: course.InstructorID,
+			SharePercent: 100,
+			Instructor:   course.Instructor,
+		}}
+	}
+	return splits, nil
+}
+
+// SetCoInstructors replaces the revenue splits of a course. The owner must be
+// listed, every co-instructor must be an instructor account and the shares
+// must sum to 100 percent.
+func (uc *UseCase) SetCoInstructors(ctx context.Context, courseID uuid.UUID, input SetCoInstructorsInput) ([]domain.CourseInstructor, error) {
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	splits := make([]domain.CourseInstructor, len(input.Instructors))
+	hasOwner := false
+	for i, in := range input.Instructors {
+		if in.InstructorID == course.InstructorID {
+			hasOwner = true
+		} else {
+			user, err := uc.userRepo.GetByID(ctx, in.InstructorID)
+			if err != nil {
+				return nil, err
+			}
+			if user.Role != domain.RoleTutor && user.Role != domain.RoleAdmin {
+				return nil, domain.ErrInvalidRevenueSplit
+			}
+		}
+		splits[i] = domain.CourseInstructor{
+			CourseID:     courseID,
+			InstructorID: in.InstructorID,
+			SharePercent: in.SharePercent,
+		}
+	}
+
+	if !hasOwner {
+		return nil, domain.ErrInvalidRevenueSplit
+	}
+	if err := domain.ValidateRevenueSplits(splits); err != nil {
+		return nil, err
+	}
+
+	// A sole owner at 100% is the default, so there is nothing to store
+	if len(splits) == 1 {
+		splits = nil
+	}
+	if err := uc.courseRepo.SetInstructorSplits(ctx, courseID, splits); err != nil {
+		return nil, err
+	}
+
+	return uc.GetCoInstructors(ctx, courseID)
 }
 
 // Delete soft-deletes a course
@@ -395,6 +723,58 @@ func (uc *UseCase) Delete(ctx context.Context, id uuid.UUID) error {
 	return uc.courseRepo.Delete(ctx, id)
 }
 
+// TrashedCourse is a soft-deleted course that can still be restored
+type TrashedCourse struct {
+	domain.Course
+	DeletedAt  time.Time  `json:"deleted_at"`
+	PurgeAfter *time.Time `json:"purge_after,omitempty"`
+}
+
+// ListTrash returns soft-deleted courses. instructorID limits the list to one
+// instructor's courses; admins pass nil to see all of them.
+func (uc *UseCase) ListTrash(ctx context.Context, instructorID *uuid.UUID, page, limit int) ([]TrashedCourse, int64, error) {
+	courses, total, err := uc.courseRepo.ListDeleted(ctx, instructorID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	trashed := make([]TrashedCourse, len(courses))
+	for i, c := range courses {
+		trashed[i] = TrashedCourse{Course: c, DeletedAt: c.DeletedAt.Time}
+		if uc.cfg.TrashRetention > 0 {
+			purgeAfter := c.DeletedAt.Time.Add(uc.cfg.TrashRetention)
+			trashed[i].PurgeAfter = &purgeAfter
+		}
+	}
+	return trashed, total, nil
+}
+
+// Restore brings a soft-deleted course back. Only its owner or an admin may
+// restore it, so the caller's ID is checked unless isAdmin is set.
+func (uc *UseCase) Restore(ctx context.Context, id, userID uuid.UUID, isAdmin bool) (*domain.Course, error) {
+	course, err := uc.courseRepo.GetDeletedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && course.InstructorID != userID {
+		return nil, domain.ErrNotCourseOwner
+	}
+
+	if err := uc.courseRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	return uc.courseRepo.GetByID(ctx, id)
+}
+
+// PurgeTrash permanently removes courses that have been in the trash longer
+// than the retention window
+func (uc *UseCase) PurgeTrash(ctx context.Context) (int64, error) {
+	if uc.cfg.TrashRetention <= 0 {
+		return 0, nil
+	}
+	return uc.courseRepo.PurgeDeleted(ctx, time.Now().Add(-uc.cfg.TrashRetention))
+}
+
 // GetCurriculum returns full course curriculum with modules and lessons
 func (uc *UseCase) GetCurriculum(ctx context.Context, courseID uuid.UUID) ([]domain.Module, error) {
 	modules, err := uc.moduleRepo.GetByCourse(ctx, courseID)
@@ -519,6 +899,9 @@ type CreateLessonInput struct {
 	AccessType  string  `json:"access_type" validate:"required,oneof=free enrolled premium"`
 	VideoURL    *string `json:"video_url" validate:"omitempty,url"`
 	IsPreview   bool    `json:"is_preview"`
+
+	PrerequisiteLessonID    *uuid.UUID `json:"prerequisite_lesson_id"`
+	DripDaysAfterEnrollment *int       `json:"drip_days_after_enrollment" validate:"omitempty,gte=0,lte=3650"`
 }
 
 // CreateLesson creates a new lesson
@@ -527,16 +910,24 @@ func (uc *UseCase) CreateLesson(ctx context.Context, moduleID uuid.UUID, input C
 	lessons, _ := uc.lessonRepo.GetByModule(ctx, moduleID)
 	sortOrder := len(lessons)
 
+	if input.PrerequisiteLessonID != nil {
+		if err := uc.validatePrerequisite(ctx, moduleID, uuid.Nil, *input.PrerequisiteLessonID); err != nil {
+			return nil, err
+		}
+	}
+
 	lesson := &domain.Lesson{
-		ModuleID:    moduleID,
-		Title:       input.Title,
-		Description: input.Description,
-		Content:     input.Content,
-		LessonType:  domain.LessonType(input.LessonType),
-		AccessType:  domain.ContentAccess(input.AccessType),
-		VideoURL:    input.VideoURL,
-		IsPreview:   input.IsPreview,
-		SortOrder:   sortOrder,
+		ModuleID:                moduleID,
+		Title:                   input.Title,
+		Description:             input.Description,
+		Content:                 input.Content,
+		LessonType:              domain.LessonType(input.LessonType),
+		AccessType:              domain.ContentAccess(input.AccessType),
+		VideoURL:                input.VideoURL,
+		IsPreview:               input.IsPreview,
+		SortOrder:               sortOrder,
+		PrerequisiteLessonID:    input.PrerequisiteLessonID,
+		DripDaysAfterEnrollment: input.DripDaysAfterEnrollment,
 	}
 
 	if err := uc.lessonRepo.Create(ctx, lesson); err != nil {
@@ -552,6 +943,58 @@ func (uc *UseCase) CreateLesson(ctx context.Context, moduleID uuid.UUID, input C
 	return lesson, nil
 }
 
+// BulkCreateLessonsInput for creating several lessons at once
+type BulkCreateLessonsInput struct {
+	Lessons []CreateLessonInput `json:"lessons" validate:"required,min=1,max=100,dive"`
+}
+
+// BulkCreateLessons appends lessons to a module in the given order. They are
+// created together, so a failure leaves the module unchanged.
+func (uc *UseCase) BulkCreateLessons(ctx context.Context, courseID, moduleID uuid.UUID, input BulkCreateLessonsInput) ([]domain.Lesson, error) {
+	module, err := uc.moduleRepo.GetByID(ctx, moduleID)
+	if err != nil {
+		return nil, err
+	}
+	if module.CourseID != courseID {
+		return nil, domain.ErrModuleNotFound
+	}
+
+	existing, err := uc.lessonRepo.GetByModule(ctx, moduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	lessons := make([]domain.Lesson, len(input.Lessons))
+	for i, l := range input.Lessons {
+		if l.PrerequisiteLessonID != nil {
+			if err := uc.validatePrerequisite(ctx, moduleID, uuid.Nil, *l.PrerequisiteLessonID); err != nil {
+				return nil, err
+			}
+		}
+		lessons[i] = domain.Lesson{
+			ModuleID:                moduleID,
+			Title:                   l.Title,
+			Description:             l.Description,
+			Content:                 l.Content,
+			LessonType:              domain.LessonType(l.LessonType),
+			AccessType:              domain.ContentAccess(l.AccessType),
+			VideoURL:                l.VideoURL,
+			IsPreview:               l.IsPreview,
+			SortOrder:               len(existing) + i,
+			PrerequisiteLessonID:    l.PrerequisiteLessonID,
+			DripDaysAfterEnrollment: l.DripDaysAfterEnrollment,
+		}
+	}
+
+	if err := uc.lessonRepo.CreateBatch(ctx, lessons); err != nil {
+		return nil, err
+	}
+
+	_ = uc.courseRepo.UpdateStats(ctx, courseID)
+
+	return lessons, nil
+}
+
 // UpdateLessonInput for updating a lesson
 type UpdateLessonInput struct {
 	Title       *string `json:"title" validate:"omitempty,min=3,max=255"`
@@ -561,6 +1004,10 @@ type UpdateLessonInput struct {
 	AccessType  *string `json:"access_type" validate:"omitempty,oneof=free enrolled premium"`
 	IsPublished *bool   `json:"is_published"`
 	IsPreview   *bool   `json:"is_preview"`
+
+	// A nil UUID removes the prerequisite and zero days removes the drip delay
+	PrerequisiteLessonID    *uuid.UUID `json:"prerequisite_lesson_id"`
+	DripDaysAfterEnrollment *int       `json:"drip_days_after_enrollment" validate:"omitempty,gte=0,lte=3650"`
 }
 
 // UpdateLesson updates a lesson
@@ -591,6 +1038,24 @@ func (uc *UseCase) UpdateLesson(ctx context.Context, id uuid.UUID, input UpdateL
 	if input.IsPreview != nil {
 		lesson.IsPreview = *input.IsPreview
 	}
+	if input.PrerequisiteLessonID != nil {
+		if *input.PrerequisiteLessonID == uuid.Nil {
+			lesson.PrerequisiteLessonID = nil
+		} else {
+			if err := uc.validatePrerequisite(ctx, lesson.ModuleID, lesson.ID, *input.PrerequisiteLessonID); err != nil {
+				return nil, err
+			}
+			lesson.PrerequisiteLessonID = input.PrerequisiteLessonID
+		}
+	}
+	if input.DripDaysAfterEnrollment != nil {
+		if *input.DripDaysAfterEnrollment == 0 {
+			lesson.DripDaysAfterEnrollment = nil
+		} else {
+			lesson.DripDaysAfterEnrollment = input.DripDaysAfterEnrollment
+		}
+	}
+	lesson.Module = nil
 
 	if err := uc.lessonRepo.Update(ctx, lesson); err != nil {
 		return nil, err
@@ -599,6 +1064,34 @@ func (uc *UseCase) UpdateLesson(ctx context.Context, id uuid.UUID, input UpdateL
 	return lesson, nil
 }
 
+// validatePrerequisite checks that prerequisiteID is a different lesson in
+// the same course as the module. lessonID is uuid.Nil for a new lesson.
+func (uc *UseCase) validatePrerequisite(ctx context.Context, moduleID, lessonID, prerequisiteID uuid.UUID) error {
+	if prerequisiteID == lessonID {
+		return domain.ErrInvalidPrerequisite
+	}
+
+	module, err := uc.moduleRepo.GetByID(ctx, moduleID)
+	if err != nil {
+		return err
+	}
+	prerequisite, err := uc.lessonRepo.GetByID(ctx, prerequisiteID)
+	if err != nil || prerequisite.Module == nil || prerequisite.Module.CourseID != module.CourseID {
+		return domain.ErrInvalidPrerequisite
+	}
+
+	// Follow the chain so two lessons can't end up waiting on each other
+	for seen := 0; prerequisite.PrerequisiteLessonID != nil && seen < 1000; seen++ {
+		if *prerequisite.PrerequisiteLessonID == lessonID {
+			return domain.ErrInvalidPrerequisite
+		}
+		if prerequisite, err = uc.lessonRepo.GetByID(ctx, *prerequisite.PrerequisiteLessonID); err != nil {
+			break
+		}
+	}
+	return nil
+}
+
 // DeleteLesson deletes a lesson
 func (uc *UseCase) DeleteLesson(ctx context.Context, id uuid.UUID) error {
 	lesson, err := uc.lessonRepo.GetByID(ctx, id)
@@ -619,11 +1112,128 @@ func (uc *UseCase) DeleteLesson(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// GetModule returns a module by ID
+func (uc *UseCase) GetModule(ctx context.Context, id uuid.UUID) (*domain.Module, error) {
+	return uc.moduleRepo.GetByID(ctx, id)
+}
+
+// GetLessonsByModule returns a module's lessons in sort order
+func (uc *UseCase) GetLessonsByModule(ctx context.Context, moduleID uuid.UUID) ([]domain.Lesson, error) {
+	if _, err := uc.moduleRepo.GetByID(ctx, moduleID); err != nil {
+		return nil, err
+	}
+	return uc.lessonRepo.GetByModule(ctx, moduleID)
+}
+
+// ReorderLessons sets the order of a module's lessons and returns them in
+// the new order. lessonIDs must contain every lesson in the module once.
+func (uc *UseCase) ReorderLessons(ctx context.Context, moduleID uuid.UUID, lessonIDs []uuid.UUID) ([]domain.Lesson, error) {
+	lessons, err := uc.lessonRepo.GetByModule(ctx, moduleID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lessonIDs) != len(lessons) {
+		return nil, domain.ErrInvalidLessonOrder
+	}
+
+	inModule := make(map[uuid.UUID]bool, len(lessons))
+	for _, l := range lessons {
+		inModule[l.ID] = true
+	}
+	seen := make(map[uuid.UUID]bool, len(lessonIDs))
+	for _, id := range lessonIDs {
+		if !inModule[id] || seen[id] {
+			return nil, domain.ErrInvalidLessonOrder
+		}
+		seen[id] = true
+	}
+
+	if err := uc.lessonRepo.Reorder(ctx, moduleID, lessonIDs); err != nil {
+		return nil, err
+	}
+
+	return uc.lessonRepo.GetByModule(ctx, moduleID)
+}
+
 // GetLesson returns a lesson by ID
 func (uc *UseCase) GetLesson(ctx context.Context, id uuid.UUID) (*domain.Lesson, error) {
 	return uc.lessonRepo.GetByID(ctx, id)
 }
 
+// AdjacentLessons holds the lessons either side of one in course order.
+// Either is nil at the start or end of the course.
+type AdjacentLessons struct {
+	Previous *domain.Lesson `json:"previous"`
+	Next     *domain.Lesson `json:"next"`
+}
+
+// GetAdjacentLessons returns the previous and next published lessons around
+// lessonID, crossing module boundaries in module then lesson sort order.
+// Lessons the user can't open are skipped: without course access (owner or
+// active enrollment) only free and preview lessons count. userID is nil for
+// anonymous requests.
+func (uc *UseCase) GetAdjacentLessons(ctx context.Context, lessonID uuid.UUID, userID *uuid.UUID) (*AdjacentLessons, error) {
+	lesson, err := uc.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	if lesson.Module == nil {
+		return nil, domain.ErrLessonNotFound
+	}
+	courseID := lesson.Module.CourseID
+
+	hasAccess := false
+	if userID != nil {
+		hasAccess, _ = uc.CanAccessCourse(ctx, courseID, *userID)
+	}
+	if !lesson.IsPublished && !hasAccess {
+		return nil, domain.ErrLessonNotFound
+	}
+
+	modules, err := uc.GetCurriculum(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flatten the curriculum; the current lesson stays in even if it's
+	// unpublished so an instructor previewing a draft can still navigate
+	var ordered []domain.Lesson
+	current := -1
+	for _, m := range modules {
+		for _, l := range m.Lessons {
+			if l.ID == lessonID {
+				current = len(ordered)
+			} else if !l.IsPublished {
+				continue
+			}
+			ordered = append(ordered, l)
+		}
+	}
+	if current == -1 {
+		return nil, domain.ErrLessonNotFound
+	}
+
+	canOpen := func(l *domain.Lesson) bool {
+		return hasAccess || l.IsFreeAccess()
+	}
+
+	result := &AdjacentLessons{}
+	for i := current - 1; i >= 0; i-- {
+		if canOpen(&ordered[i]) {
+			result.Previous = &ordered[i]
+			break
+		}
+	}
+	for i := current + 1; i < len(ordered); i++ {
+		if canOpen(&ordered[i]) {
+			result.Next = &ordered[i]
+			break
+		}
+	}
+
+	return result, nil
+}
+
 // --- Category Management ---
 
 // ListCategories returns all categories
@@ -684,7 +1294,78 @@ func (uc *UseCase) UpdateCategory(ctx context.Context, id uuid.UUID, input Creat
 	return category, nil
 }
 
-// DeleteCategory deletes a category
-func (uc *UseCase) DeleteCategory(ctx context.Context, id uuid.UUID) error {
-	return uc.categoryRepo.Delete(ctx, id)
+// ReorderCategories sets the order of the categories under parentID, or of
+// the top-level categories when it is nil. categoryIDs must list every one of
+// them exactly once, so siblings are ordered independently of other levels.
+func (uc *UseCase) ReorderCategories(ctx context.Context, parentID *uuid.UUID, categoryIDs []uuid.UUID) ([]domain.Category, error) {
+	siblings, err := uc.categoryRepo.GetByParent(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(categoryIDs) != len(siblings) {
+		return nil, domain.ErrInvalidCategoryOrder
+	}
+
+	underParent := make(map[uuid.UUID]bool, len(siblings))
+	for _, c := range siblings {
+		underParent[c.ID] = true
+	}
+	seen := make(map[uuid.UUID]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if !underParent[id] || seen[id] {
+			return nil, domain.ErrInvalidCategoryOrder
+		}
+		seen[id] = true
+	}
+
+	if err := uc.categoryRepo.Reorder(ctx, parentID, categoryIDs); err != nil {
+		return nil, err
+	}
+
+	return uc.categoryRepo.GetByParent(ctx, parentID)
+}
+
+// DeleteCategoryInput controls what happens to a category that is in use
+type DeleteCategoryInput struct {
+	Force      bool
+	ReassignTo *uuid.UUID // where the category's courses go when forced
+}
+
+// DeleteCategory deletes a category. One that still has courses or
+// subcategories is only deleted when forced: its subcategories move up to its
+// parent and its courses move to input.ReassignTo, which is required if there
+// are any.
+func (uc *UseCase) DeleteCategory(ctx context.Context, id uuid.UUID, input DeleteCategoryInput) error {
+	if _, err := uc.categoryRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	subcategories, err := uc.categoryRepo.CountSubcategories(ctx, id)
+	if err != nil {
+		return err
+	}
+	courses, err := uc.categoryRepo.CountCourses(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if subcategories == 0 && courses == 0 {
+		return uc.categoryRepo.Delete(ctx, id)
+	}
+	if !input.Force {
+		return domain.ErrCategoryInUse
+	}
+
+	if input.ReassignTo != nil {
+		if *input.ReassignTo == id {
+			return domain.ValidationErrors{{Field: "reassignTo", Message: "cannot reassign courses to the category being deleted"}}
+		}
+		if _, err := uc.categoryRepo.GetByID(ctx, *input.ReassignTo); err != nil {
+			return domain.ValidationErrors{{Field: "reassignTo", Message: "unknown category"}}
+		}
+	} else if courses > 0 {
+		return domain.ErrCategoryInUse
+	}
+
+	return uc.categoryRepo.ForceDelete(ctx, id, input.ReassignTo)
 }
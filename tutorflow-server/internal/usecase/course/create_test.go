@@ -0,0 +1,101 @@
+package course_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+	"go.uber.org/zap"
+)
+
+// MockUserRepository is a mock implementation of UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	args := m.Called(ctx, usernames)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filters repository.UserFilters) ([]domain.User, int64, error) {
+	args := m.Called(ctx, filters)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return m.Called(ctx, id, passwordHash).Error(0)
+}
+
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdateLastActive(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+func (m *MockUserRepository) Anonymize(ctx context.Context, u *domain.User, entry *domain.AuditLog) error {
+	return m.Called(ctx, u, entry).Error(0)
+}
+
+// verifiedUsers returns a user repository where every user has verified
+// their email
+func verifiedUsers() *MockUserRepository {
+	verifiedAt := time.Now()
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", mock.Anything, mock.Anything).Return(&domain.User{EmailVerifiedAt: &verifiedAt}, nil)
+	return userRepo
+}
+
+func TestCourseUseCase_Create_RequiresVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	instructorID := uuid.New()
+
+	courseRepo := new(MockCourseRepository)
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", ctx, instructorID).Return(&domain.User{ID: instructorID}, nil)
+
+	uc := course.NewUseCase(courseRepo, nil, nil, nil, nil, userRepo, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+	_, err := uc.Create(ctx, instructorID, course.CreateInput{Title: "Building APIs in Go", Level: "beginner"})
+	assert.ErrorIs(t, err, domain.ErrEmailNotVerified)
+	courseRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,190 @@
+package course_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+	"go.uber.org/zap"
+)
+
+// MockCourseRepository is a mock implementation of CourseRepository
+type MockCourseRepository struct {
+	mock.Mock
+}
+
+func (m *MockCourseRepository) Create(ctx context.Context, c *domain.Course) error {
+	return m.Called(ctx, c).Error(0)
+}
+
+func (m *MockCourseRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Course, error) {
+	args := m.Called(ctx, id)
+	c, _ := args.Get(0).(*domain.Course)
+	return c, args.Error(1)
+}
+
+func (m *MockCourseRepository) GetBySlug(ctx context.Context, slug string) (*domain.Course, error) {
+	args := m.Called(ctx, slug)
+	c, _ := args.Get(0).(*domain.Course)
+	return c, args.Error(1)
+}
+
+func (m *MockCourseRepository) GetByEnrollmentCode(ctx context.Context, code string) (*domain.Course, error) {
+	args := m.Called(ctx, code)
+	c, _ := args.Get(0).(*domain.Course)
+	return c, args.Error(1)
+}
+
+func (m *MockCourseRepository) Update(ctx context.Context, c *domain.Course) error {
+	return m.Called(ctx, c).Error(0)
+}
+
+func (m *MockCourseRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCourseRepository) List(ctx context.Context, filters repository.CourseFilters) ([]domain.Course, int64, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]domain.Course), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCourseRepository) GetByInstructor(ctx context.Context, instructorID uuid.UUID, page, limit int) ([]domain.Course, int64, error) {
+	args := m.Called(ctx, instructorID, page, limit)
+	return args.Get(0).([]domain.Course), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCourseRepository) UpdateStats(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCourseRepository) IncrementStudentCount(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCourseRepository) SetEnrollmentCode(ctx context.Context, id uuid.UUID, code *string) error {
+	return m.Called(ctx, id, code).Error(0)
+}
+
+func (m *MockCourseRepository) GetInstructorSplits(ctx context.Context, courseID uuid.UUID) ([]domain.CourseInstructor, error) {
+	args := m.Called(ctx, courseID)
+	return args.Get(0).([]domain.CourseInstructor), args.Error(1)
+}
+
+func (m *MockCourseRepository) SetInstructorSplits(ctx context.Context, courseID uuid.UUID, splits []domain.CourseInstructor) error {
+	return m.Called(ctx, courseID, splits).Error(0)
+}
+
+func (m *MockCourseRepository) SetCategories(ctx context.Context, courseID uuid.UUID, categoryIDs []uuid.UUID) error {
+	return m.Called(ctx, courseID, categoryIDs).Error(0)
+}
+
+func (m *MockCourseRepository) ListDeleted(ctx context.Context, instructorID *uuid.UUID, page, limit int) ([]domain.Course, int64, error) {
+	args := m.Called(ctx, instructorID, page, limit)
+	return args.Get(0).([]domain.Course), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCourseRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*domain.Course, error) {
+	args := m.Called(ctx, id)
+	c, _ := args.Get(0).(*domain.Course)
+	return c, args.Error(1)
+}
+
+func (m *MockCourseRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCourseRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockModuleRepository is a mock implementation of ModuleRepository
+type MockModuleRepository struct {
+	mock.Mock
+}
+
+func (m *MockModuleRepository) Create(ctx context.Context, module *domain.Module) error {
+	return m.Called(ctx, module).Error(0)
+}
+
+func (m *MockModuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Module, error) {
+	args := m.Called(ctx, id)
+	module, _ := args.Get(0).(*domain.Module)
+	return module, args.Error(1)
+}
+
+func (m *MockModuleRepository) Update(ctx context.Context, module *domain.Module) error {
+	return m.Called(ctx, module).Error(0)
+}
+
+func (m *MockModuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockModuleRepository) DeleteByCourse(ctx context.Context, courseID uuid.UUID) error {
+	return m.Called(ctx, courseID).Error(0)
+}
+
+func (m *MockModuleRepository) GetByCourse(ctx context.Context, courseID uuid.UUID) ([]domain.Module, error) {
+	args := m.Called(ctx, courseID)
+	return args.Get(0).([]domain.Module), args.Error(1)
+}
+
+func (m *MockModuleRepository) Reorder(ctx context.Context, courseID uuid.UUID, moduleIDs []uuid.UUID) error {
+	return m.Called(ctx, courseID, moduleIDs).Error(0)
+}
+
+func (m *MockModuleRepository) SaveCurriculum(ctx context.Context, courseID uuid.UUID, modules []domain.Module) error {
+	return m.Called(ctx, courseID, modules).Error(0)
+}
+
+// Which saved IDs are kept, inserted or deleted is decided by the repository;
+// see TestModuleRepository_SaveCurriculum. This checks the use case passes the
+// IDs through to it.
+func TestCourseUseCase_Update_PassesLessonIDs(t *testing.T) {
+	ctx := context.Background()
+	courseID, moduleID, lessonID := uuid.New(), uuid.New(), uuid.New()
+
+	courseRepo := new(MockCourseRepository)
+	moduleRepo := new(MockModuleRepository)
+	courseRepo.On("GetByID", ctx, courseID).Return(&domain.Course{ID: courseID}, nil)
+	courseRepo.On("Update", ctx, mock.Anything).Return(nil)
+	courseRepo.On("UpdateStats", ctx, courseID).Return(nil)
+
+	var saved []domain.Module
+	moduleRepo.On("SaveCurriculum", ctx, courseID, mock.Anything).
+		Run(func(args mock.Arguments) { saved = args.Get(2).([]domain.Module) }).
+		Return(nil)
+
+	uc := course.NewUseCase(courseRepo, nil, moduleRepo, nil, nil, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+	_, err := uc.Update(ctx, courseID, course.UpdateInput{
+		Modules: []course.ModuleInput{{
+			ID:    moduleID.String(),
+			Title: "Getting started",
+			Lessons: []course.LessonInput{
+				{ID: lessonID.String(), Title: "Installing Go (updated)", Type: "video"},
+				{ID: "new-1", Title: "Your first program", Type: "text"},
+			},
+		}},
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, saved, 1) && assert.Len(t, saved[0].Lessons, 2) {
+		assert.Equal(t, moduleID, saved[0].ID)
+
+		edited := saved[0].Lessons[0]
+		assert.Equal(t, lessonID, edited.ID, "edited lessons keep their ID")
+		assert.Equal(t, "Installing Go (updated)", edited.Title)
+
+		assert.Equal(t, uuid.Nil, saved[0].Lessons[1].ID, "client-side IDs are treated as new lessons")
+		assert.Equal(t, 1, saved[0].Lessons[1].SortOrder)
+	}
+}
@@ -0,0 +1,76 @@
+package course_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+)
+
+// funnelEnrollments returns a fixed funnel; other enrollment methods panic
+type funnelEnrollments struct {
+	repository.EnrollmentRepository
+	funnel *domain.CourseFunnel
+}
+
+func (r *funnelEnrollments) GetCourseFunnel(ctx context.Context, courseID uuid.UUID) (*domain.CourseFunnel, error) {
+	return r.funnel, nil
+}
+
+func TestCourseUseCase_GetCourseFunnel(t *testing.T) {
+	ctx := context.Background()
+	courseID := uuid.New()
+	steps := func(learners ...int64) []domain.FunnelStep {
+		var out []domain.FunnelStep
+		for i, n := range learners {
+			out = append(out, domain.FunnelStep{Progress: domain.FunnelMilestones[i], Learners: n})
+		}
+		return out
+	}
+
+	t.Run("percentages are of enrolled learners", func(t *testing.T) {
+		repo := &funnelEnrollments{funnel: &domain.CourseFunnel{
+			CourseID:            courseID,
+			Enrolled:            8,
+			Steps:               steps(6, 4, 2, 1),
+			Completed:           1,
+			AvgDaysToCompletion: 12.5,
+		}}
+		uc := course.NewUseCase(nil, nil, nil, nil, repo, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+		funnel, err := uc.GetCourseFunnel(ctx, courseID)
+		require.NoError(t, err)
+
+		require.Len(t, funnel.Steps, 4)
+		assert.Equal(t, []float64{75, 50, 25, 12.5}, []float64{
+			funnel.Steps[0].Percent, funnel.Steps[1].Percent, funnel.Steps[2].Percent, funnel.Steps[3].Percent,
+		})
+		assert.Equal(t, 12.5, funnel.CompletionRate)
+		assert.Equal(t, 12.5, funnel.AvgDaysToCompletion)
+	})
+
+	t.Run("course without enrollments has empty steps", func(t *testing.T) {
+		repo := &funnelEnrollments{funnel: &domain.CourseFunnel{CourseID: courseID, Steps: steps(0, 0, 0, 0)}}
+		uc := course.NewUseCase(nil, nil, nil, nil, repo, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+		funnel, err := uc.GetCourseFunnel(ctx, courseID)
+		require.NoError(t, err)
+
+		require.Len(t, funnel.Steps, 4)
+		for i, step := range funnel.Steps {
+			assert.Equal(t, domain.FunnelMilestones[i], step.Progress)
+			assert.Zero(t, step.Learners)
+			assert.Zero(t, step.Percent)
+		}
+		assert.Zero(t, funnel.CompletionRate)
+		assert.Zero(t, funnel.AvgDaysToCompletion)
+	})
+}
@@ -0,0 +1,75 @@
+package course_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+)
+
+func TestCourseUseCase_ListAfter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	courses := []domain.Course{
+		{ID: uuid.New(), CreatedAt: now},
+		{ID: uuid.New(), CreatedAt: now.Add(-time.Hour)},
+		{ID: uuid.New(), CreatedAt: now.Add(-2 * time.Hour)},
+	}
+
+	t.Run("more courses follow", func(t *testing.T) {
+		courseRepo := new(MockCourseRepository)
+		courseRepo.On("List", ctx, mock.MatchedBy(func(f repository.CourseFilters) bool {
+			return f.Cursor != nil && f.Limit == 2 && *f.Status == domain.CourseStatusPublished
+		})).Return(courses, int64(0), nil)
+		uc := course.NewUseCase(courseRepo, nil, nil, nil, nil, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+		page, next, err := uc.ListAfter(ctx, course.ListInput{Limit: 2}, true, pagination.Cursor{})
+		require.NoError(t, err)
+		assert.Equal(t, courses[:2], page)
+
+		cursor, err := pagination.Decode(next)
+		require.NoError(t, err)
+		assert.Equal(t, courses[1].ID, cursor.ID)
+		assert.True(t, courses[1].CreatedAt.Equal(cursor.Time))
+	})
+
+	t.Run("last page has no cursor", func(t *testing.T) {
+		courseRepo := new(MockCourseRepository)
+		courseRepo.On("List", ctx, mock.Anything).Return(courses, int64(0), nil)
+		uc := course.NewUseCase(courseRepo, nil, nil, nil, nil, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+		page, next, err := uc.ListAfter(ctx, course.ListInput{Limit: 3}, true, pagination.Cursor{})
+		require.NoError(t, err)
+		assert.Len(t, page, 3)
+		assert.Empty(t, next)
+	})
+
+	t.Run("only newest first", func(t *testing.T) {
+		courseRepo := new(MockCourseRepository)
+		uc := course.NewUseCase(courseRepo, nil, nil, nil, nil, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+		_, _, err := uc.ListAfter(ctx, course.ListInput{SortBy: "price"}, true, pagination.Cursor{})
+		var verrs domain.ValidationErrors
+		require.ErrorAs(t, err, &verrs)
+		assert.Equal(t, "cursor", verrs[0].Field)
+		courseRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, s := range []string{"not-base64!", "bm90IGpzb24", pagination.Cursor{Time: time.Now()}.Encode()} {
+		_, err := pagination.Decode(s)
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor, s)
+	}
+}
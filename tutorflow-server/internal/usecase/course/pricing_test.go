@@ -0,0 +1,82 @@
+package course_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+)
+
+func price(p float64) *float64 { return &p }
+
+func TestCourseUseCase_Create_DiscountPrice(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		price    float64
+		discount *float64
+		wantErr  bool
+	}{
+		{name: "discount below price", price: 50, discount: price(30)},
+		{name: "discount equal to price", price: 50, discount: price(50)},
+		{name: "free course", price: 0, discount: price(0)},
+		{name: "discount above price", price: 50, discount: price(80), wantErr: true},
+		{name: "negative discount", price: 50, discount: price(-5), wantErr: true},
+		{name: "discount on a free course", price: 0, discount: price(10), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courseRepo := new(MockCourseRepository)
+			courseRepo.On("Create", ctx, mock.Anything).Return(nil)
+			uc := course.NewUseCase(courseRepo, nil, nil, nil, nil, verifiedUsers(), nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+			created, err := uc.Create(ctx, uuid.New(), course.CreateInput{
+				Title:         "Building APIs in Go",
+				Level:         "beginner",
+				Price:         tt.price,
+				DiscountPrice: tt.discount,
+			})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidDiscountPrice)
+				courseRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, *tt.discount, created.GetEffectivePrice())
+		})
+	}
+}
+
+func TestCourseUseCase_Update_PriceBelowDiscount(t *testing.T) {
+	ctx := context.Background()
+	existing := &domain.Course{ID: uuid.New(), Price: 50, DiscountPrice: price(40)}
+
+	courseRepo := new(MockCourseRepository)
+	courseRepo.On("GetByID", ctx, existing.ID).Return(existing, nil)
+	uc := course.NewUseCase(courseRepo, nil, nil, nil, nil, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+	_, err := uc.Update(ctx, existing.ID, course.UpdateInput{Price: price(30)})
+	assert.ErrorIs(t, err, domain.ErrInvalidDiscountPrice)
+	courseRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestCourse_GetEffectivePrice(t *testing.T) {
+	assert.Equal(t, 30.0, (&domain.Course{Price: 50, DiscountPrice: price(30)}).GetEffectivePrice())
+	assert.Equal(t, 50.0, (&domain.Course{Price: 50, DiscountPrice: price(50)}).GetEffectivePrice())
+	assert.Equal(t, 50.0, (&domain.Course{Price: 50}).GetEffectivePrice())
+	assert.Equal(t, 0.0, (&domain.Course{Price: 0, DiscountPrice: price(0)}).GetEffectivePrice())
+
+	// Discounts saved before validation never raise or zero out the price
+	assert.Equal(t, 50.0, (&domain.Course{Price: 50, DiscountPrice: price(80)}).GetEffectivePrice())
+	assert.Equal(t, 50.0, (&domain.Course{Price: 50, DiscountPrice: price(-5)}).GetEffectivePrice())
+}
@@ -0,0 +1,50 @@
+package course_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/course"
+	"go.uber.org/zap"
+)
+
+func TestCourse_CheckPublishReadiness(t *testing.T) {
+	thumb, desc, blank := "https://cdn.example.com/c.png", "Learn Go", "  "
+
+	ready := (&domain.Course{ThumbnailURL: &thumb, Description: &desc}).CheckPublishReadiness(true)
+	assert.True(t, ready.Ready)
+	assert.Empty(t, ready.Missing)
+	assert.Len(t, ready.Items, len(domain.PublishRequirements))
+
+	notReady := (&domain.Course{Description: &blank, Price: -1}).CheckPublishReadiness(false)
+	assert.False(t, notReady.Ready)
+	assert.Equal(t, domain.PublishRequirements, notReady.Missing)
+}
+
+func TestCourseUseCase_Publish_NotReady(t *testing.T) {
+	ctx := context.Background()
+	courseID := uuid.New()
+
+	courseRepo := new(MockCourseRepository)
+	moduleRepo := new(MockModuleRepository)
+	courseRepo.On("GetByID", ctx, courseID).Return(&domain.Course{ID: courseID, Status: domain.CourseStatusDraft}, nil)
+	moduleRepo.On("GetByCourse", ctx, courseID).Return([]domain.Module{}, nil)
+
+	uc := course.NewUseCase(courseRepo, nil, moduleRepo, nil, nil, nil, nil, nil, config.CourseConfig{}, zap.NewNop().Sugar())
+
+	err := uc.Publish(ctx, courseID)
+	var notReady *domain.CourseNotReady
+	if assert.ErrorAs(t, err, &notReady) {
+		assert.Equal(t, []domain.PublishRequirement{
+			domain.PublishRequirementThumbnail,
+			domain.PublishRequirementDescription,
+			domain.PublishRequirementPublishedLesson,
+		}, notReady.Missing)
+	}
+	courseRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
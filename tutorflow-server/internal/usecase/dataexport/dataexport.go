@@ -0,0 +1,207 @@
+package dataexport
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
+	"github.com/tutorflow/tutorflow-server/internal/service/export"
+	"github.com/tutorflow/tutorflow-server/internal/service/storage"
+)
+
+// syncLimit is how many records an export can have and still be built while
+// the user waits; larger accounts are exported in the background
+const syncLimit = 1000
+
+// linkTTL is how long the emailed download link works
+const linkTTL = 7 * 24 * time.Hour
+
+// FileStore keeps prepared exports until they are downloaded
+type FileStore interface {
+	PutFile(ctx context.Context, path string, data []byte, contentType string) error
+	GetFileStream(ctx context.Context, path string) (io.ReadCloser, string, error)
+	DeleteFile(ctx context.Context, path string) error
+}
+
+// Exporter assembles a user's personal data
+type Exporter interface {
+	CountUserData(ctx context.Context, userID uuid.UUID) (int64, error)
+	ExportUserData(ctx context.Context, userID uuid.UUID) (*export.ExportResult, error)
+}
+
+var (
+	_ FileStore = (*storage.Service)(nil)
+	_ Exporter  = (*export.Service)(nil)
+)
+
+// UseCase defines personal data export business logic
+type UseCase struct {
+	exportRepo repository.DataExportRepository
+	userRepo   repository.UserRepository
+	exporter   Exporter
+	files      FileStore
+	emailSvc   *email.Service
+	logger     *zap.SugaredLogger
+}
+
+// NewUseCase creates a new data export use case
+func NewUseCase(
+	exportRepo repository.DataExportRepository,
+	userRepo repository.UserRepository,
+	exporter Exporter,
+	files FileStore,
+	emailSvc *email.Service,
+	logger *zap.SugaredLogger,
+) *UseCase {
+	return &UseCase{
+		exportRepo: exportRepo,
+		userRepo:   userRepo,
+		exporter:   exporter,
+		files:      files,
+		emailSvc:   emailSvc,
+		logger:     logger,
+	}
+}
+
+// Result is either the export itself or, for large accounts, the background
+// job preparing it
+type Result struct {
+	File *export.ExportResult
+	Job  *domain.DataExport
+}
+
+// Request exports userID's personal data for requestedBy, who is the user or
+// an admin acting for them. Background exports are emailed to the user.
+func (uc *UseCase) Request(ctx context.Context, requestedBy, userID uuid.UUID) (*Result, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := uc.exporter.CountUserData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if records <= syncLimit {
+		file, err := uc.exporter.ExportUserData(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{File: file}, nil
+	}
+
+	// One background export at a time per user
+	job, err := uc.exportRepo.GetPending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job != nil {
+		return &Result{Job: job}, nil
+	}
+
+	job = &domain.DataExport{UserID: userID, RequestedBy: requestedBy, Status: domain.DataExportPending}
+	if err := uc.exportRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	go uc.prepare(context.WithoutCancel(ctx), *job, user)
+
+	return &Result{Job: job}, nil
+}
+
+// prepare builds and stores a background export, then emails the link
+func (uc *UseCase) prepare(ctx context.Context, job domain.DataExport, user *domain.User) {
+	token, err := uc.store(ctx, &job)
+	if err != nil {
+		uc.logger.Errorf("Data export %s failed: %v", job.ID, err)
+		job.Status = domain.DataExportFailed
+		if err := uc.exportRepo.Update(ctx, &job); err != nil {
+			uc.logger.Errorf("Failed to mark data export %s failed: %v", job.ID, err)
+		}
+		return
+	}
+
+	if uc.emailSvc != nil {
+		link := uc.emailSvc.AppLink("/data-export?token=" + url.QueryEscape(token))
+		if err := uc.emailSvc.SendDataExportReady(ctx, user.Email, user.FirstName, link, job.ExpiresAt.Format("January 2, 2006")); err != nil {
+			uc.logger.Errorf("Failed to email data export %s: %v", job.ID, err)
+		}
+	}
+}
+
+// store writes the export file and marks the job ready, returning the
+// download token
+func (uc *UseCase) store(ctx context.Context, job *domain.DataExport) (string, error) {
+	file, err := uc.exporter.ExportUserData(ctx, job.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	path := "exports/" + job.ID.String() + ".json"
+	if err := uc.files.PutFile(ctx, path, file.Data, file.ContentType); err != nil {
+		return "", err
+	}
+
+	token, tokenHash, err := hash.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(linkTTL)
+	job.Status = domain.DataExportReady
+	job.FilePath = path
+	job.TokenHash = &tokenHash
+	job.ExpiresAt = &expiresAt
+	job.CompletedAt = &now
+	if err := uc.exportRepo.Update(ctx, job); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Download opens a prepared export by the token from its emailed link
+func (uc *UseCase) Download(ctx context.Context, token string) (io.ReadCloser, string, error) {
+	job, err := uc.exportRepo.GetByTokenHash(ctx, hash.HashToken(token))
+	if err != nil {
+		return nil, "", err
+	}
+	if job.ExpiresAt == nil || time.Now().After(*job.ExpiresAt) {
+		return nil, "", domain.ErrDataExportInvalid
+	}
+
+	stream, _, err := uc.files.GetFileStream(ctx, job.FilePath)
+	if err != nil {
+		return nil, "", err
+	}
+	return stream, "tutorflow-data-" + job.CompletedAt.Format("2006-01-02") + ".json", nil
+}
+
+// PurgeExpired deletes exports whose links have expired, and failed ones,
+// along with their files
+func (uc *UseCase) PurgeExpired(ctx context.Context) (int, error) {
+	expired, err := uc.exportRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, job := range expired {
+		if job.FilePath != "" {
+			if err := uc.files.DeleteFile(ctx, job.FilePath); err != nil {
+				uc.logger.Warnf("Failed to delete data export file %s: %v", job.FilePath, err)
+			}
+		}
+		if err := uc.exportRepo.Delete(ctx, job.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
@@ -0,0 +1,259 @@
+package dataexport_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/export"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/dataexport"
+)
+
+// MockDataExportRepository is a mock implementation of DataExportRepository
+type MockDataExportRepository struct {
+	mock.Mock
+}
+
+func (m *MockDataExportRepository) Create(ctx context.Context, e *domain.DataExport) error {
+	args := m.Called(ctx, e)
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return args.Error(0)
+}
+
+func (m *MockDataExportRepository) Update(ctx context.Context, e *domain.DataExport) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockDataExportRepository) GetPending(ctx context.Context, userID uuid.UUID) (*domain.DataExport, error) {
+	args := m.Called(ctx, userID)
+	e, _ := args.Get(0).(*domain.DataExport)
+	return e, args.Error(1)
+}
+
+func (m *MockDataExportRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.DataExport, error) {
+	args := m.Called(ctx, tokenHash)
+	e, _ := args.Get(0).(*domain.DataExport)
+	return e, args.Error(1)
+}
+
+func (m *MockDataExportRepository) ListExpired(ctx context.Context, now time.Time) ([]domain.DataExport, error) {
+	args := m.Called(ctx, now)
+	exports, _ := args.Get(0).([]domain.DataExport)
+	return exports, args.Error(1)
+}
+
+func (m *MockDataExportRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+// MockUserRepository is a mock implementation of UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	args := m.Called(ctx, usernames)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filters repository.UserFilters) ([]domain.User, int64, error) {
+	args := m.Called(ctx, filters)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return m.Called(ctx, id, passwordHash).Error(0)
+}
+
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdateLastActive(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+func (m *MockUserRepository) Anonymize(ctx context.Context, u *domain.User, entry *domain.AuditLog) error {
+	return m.Called(ctx, u, entry).Error(0)
+}
+
+// fakeExporter reports a fixed record count
+type fakeExporter struct {
+	records int64
+}
+
+func (f *fakeExporter) CountUserData(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return f.records, nil
+}
+
+func (f *fakeExporter) ExportUserData(ctx context.Context, userID uuid.UUID) (*export.ExportResult, error) {
+	return &export.ExportResult{Data: []byte(`{}`), Filename: "tutorflow-data.json", ContentType: "application/json"}, nil
+}
+
+// memoryFiles keeps stored files in a map
+type memoryFiles struct {
+	files map[string][]byte
+}
+
+func (f *memoryFiles) PutFile(ctx context.Context, path string, data []byte, contentType string) error {
+	f.files[path] = data
+	return nil
+}
+
+func (f *memoryFiles) GetFileStream(ctx context.Context, path string) (io.ReadCloser, string, error) {
+	return io.NopCloser(strings.NewReader(string(f.files[path]))), "application/json", nil
+}
+
+func (f *memoryFiles) DeleteFile(ctx context.Context, path string) error {
+	delete(f.files, path)
+	return nil
+}
+
+func setup(records int64) (*dataexport.UseCase, *MockDataExportRepository, *MockUserRepository, *memoryFiles) {
+	exportRepo := new(MockDataExportRepository)
+	userRepo := new(MockUserRepository)
+	files := &memoryFiles{files: map[string][]byte{}}
+	uc := dataexport.NewUseCase(exportRepo, userRepo, &fakeExporter{records: records}, files, nil, zap.NewNop().Sugar())
+	return uc, exportRepo, userRepo, files
+}
+
+func TestRequest(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	user := &domain.User{ID: userID, Email: "student@example.com", FirstName: "Sam"}
+
+	t.Run("small account is exported immediately", func(t *testing.T) {
+		uc, exportRepo, userRepo, _ := setup(10)
+		userRepo.On("GetByID", ctx, userID).Return(user, nil)
+
+		result, err := uc.Request(ctx, userID, userID)
+
+		require.NoError(t, err)
+		require.NotNil(t, result.File)
+		assert.Nil(t, result.Job)
+		exportRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("large account is prepared in the background", func(t *testing.T) {
+		uc, exportRepo, userRepo, files := setup(5000)
+		userRepo.On("GetByID", ctx, userID).Return(user, nil)
+		exportRepo.On("GetPending", ctx, userID).Return(nil, nil)
+		exportRepo.On("Create", ctx, mock.AnythingOfType("*domain.DataExport")).Return(nil)
+
+		ready := make(chan *domain.DataExport, 1)
+		exportRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.DataExport")).
+			Run(func(args mock.Arguments) { ready <- args.Get(1).(*domain.DataExport) }).
+			Return(nil)
+
+		adminID := uuid.New()
+		result, err := uc.Request(ctx, adminID, userID)
+
+		require.NoError(t, err)
+		assert.Nil(t, result.File)
+		require.NotNil(t, result.Job)
+		assert.Equal(t, domain.DataExportPending, result.Job.Status)
+		assert.Equal(t, adminID, result.Job.RequestedBy)
+
+		select {
+		case job := <-ready:
+			assert.Equal(t, domain.DataExportReady, job.Status)
+			assert.NotNil(t, job.TokenHash)
+			assert.True(t, job.ExpiresAt.After(time.Now()))
+			assert.Contains(t, files.files, job.FilePath)
+		case <-time.After(time.Second):
+			t.Fatal("export was not prepared")
+		}
+	})
+
+	t.Run("pending export is reused", func(t *testing.T) {
+		uc, exportRepo, userRepo, _ := setup(5000)
+		pending := &domain.DataExport{ID: uuid.New(), UserID: userID, Status: domain.DataExportPending}
+		userRepo.On("GetByID", ctx, userID).Return(user, nil)
+		exportRepo.On("GetPending", ctx, userID).Return(pending, nil)
+
+		result, err := uc.Request(ctx, userID, userID)
+
+		require.NoError(t, err)
+		assert.Equal(t, pending, result.Job)
+		exportRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDownload(t *testing.T) {
+	ctx := context.Background()
+	token, tokenHash, err := hash.GenerateSecureToken(32)
+	require.NoError(t, err)
+
+	t.Run("valid link streams the file", func(t *testing.T) {
+		uc, exportRepo, _, files := setup(0)
+		files.files["exports/1.json"] = []byte(`{"profile":{}}`)
+		completed := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		expires := time.Now().Add(time.Hour)
+		exportRepo.On("GetByTokenHash", ctx, tokenHash).Return(&domain.DataExport{
+			Status: domain.DataExportReady, FilePath: "exports/1.json", ExpiresAt: &expires, CompletedAt: &completed,
+		}, nil)
+
+		stream, filename, err := uc.Download(ctx, token)
+
+		require.NoError(t, err)
+		defer stream.Close()
+		data, _ := io.ReadAll(stream)
+		assert.Equal(t, `{"profile":{}}`, string(data))
+		assert.Equal(t, "tutorflow-data-2026-03-01.json", filename)
+	})
+
+	t.Run("expired link is rejected", func(t *testing.T) {
+		uc, exportRepo, _, _ := setup(0)
+		expired := time.Now().Add(-time.Hour)
+		exportRepo.On("GetByTokenHash", ctx, tokenHash).Return(&domain.DataExport{
+			Status: domain.DataExportReady, FilePath: "exports/1.json", ExpiresAt: &expired,
+		}, nil)
+
+		_, _, err := uc.Download(ctx, token)
+
+		assert.ErrorIs(t, err, domain.ErrDataExportInvalid)
+	})
+}
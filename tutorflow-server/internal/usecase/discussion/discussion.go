@@ -2,12 +2,16 @@ package discussion
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/google/uuid"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
 )
 
 // UseCase defines discussion business logic
@@ -15,57 +19,211 @@ type UseCase struct {
 	discussionRepo repository.DiscussionRepository
 	enrollmentRepo repository.EnrollmentRepository
 	courseRepo     repository.CourseRepository
+	lessonRepo     repository.LessonRepository
+	userRepo       repository.UserRepository
+	notifier       *notification.Notifier
+	config         config.DiscussionConfig
 }
 
+// maxMentionsPerPost caps how many people one post can notify
+const maxMentionsPerPost = 10
+
 // NewUseCase creates a new discussion use case
 func NewUseCase(
 	discussionRepo repository.DiscussionRepository,
 	enrollmentRepo repository.EnrollmentRepository,
 	courseRepo repository.CourseRepository,
+	lessonRepo repository.LessonRepository,
+	userRepo repository.UserRepository,
+	notifier *notification.Notifier,
+	cfg config.DiscussionConfig,
 ) *UseCase {
 	return &UseCase{
 		discussionRepo: discussionRepo,
 		enrollmentRepo: enrollmentRepo,
 		courseRepo:     courseRepo,
+		lessonRepo:     lessonRepo,
+		userRepo:       userRepo,
+		notifier:       notifier,
+		config:         cfg,
+	}
+}
+
+// checkAccess returns ErrNotEnrolled unless the user can take part in the
+// course's discussions: admins, the instructor and enrolled learners can.
+// Courses with public discussions also let any signed-in user read them.
+func (uc *UseCase) checkAccess(ctx context.Context, courseID, userID uuid.UUID, isAdmin, write bool) error {
+	if isAdmin {
+		return nil
+	}
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return err
+	}
+	if uc.isCourseMember(ctx, course, userID) || (!write && course.PublicDiscussions) {
+		return nil
+	}
+	return domain.ErrNotEnrolled
+}
+
+// getAccessible loads a discussion the user is allowed to see
+func (uc *UseCase) getAccessible(ctx context.Context, id, userID uuid.UUID, isAdmin, write bool) (*domain.Discussion, error) {
+	discussion, err := uc.discussionRepo.GetByID(ctx, id)
+	if err != nil || discussion == nil {
+		return discussion, err
+	}
+	if err := uc.checkAccess(ctx, discussion.CourseID, userID, isAdmin, write); err != nil {
+		return nil, err
+	}
+	return discussion, nil
+}
+
+// GetDiscussion returns a discussion with its replies nested as a tree down
+// to the maximum reply depth
+func (uc *UseCase) GetDiscussion(ctx context.Context, id, userID uuid.UUID, isAdmin bool) (*domain.Discussion, error) {
+	root, err := uc.getAccessible(ctx, id, userID, isAdmin, false)
+	if err != nil || root == nil {
+		return root, err
+	}
+
+	thread, err := uc.discussionRepo.GetThread(ctx, root.ID, uc.config.MaxReplyDepth-root.Depth)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uuid.UUID][]domain.Discussion)
+	for _, reply := range thread {
+		children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+	}
+	var attach func(d *domain.Discussion)
+	attach = func(d *domain.Discussion) {
+		d.Replies = children[d.ID]
+		for i := range d.Replies {
+			attach(&d.Replies[i])
+		}
+	}
+	attach(root)
+
+	// The accepted answer is shown ahead of the other replies
+	if root.AcceptedReplyID != nil {
+		sort.SliceStable(root.Replies, func(i, j int) bool {
+			return root.Replies[i].ID == *root.AcceptedReplyID && root.Replies[j].ID != *root.AcceptedReplyID
+		})
+	}
+
+	if err := uc.setReplyCounts(ctx, []*domain.Discussion{root}); err != nil {
+		return nil, err
 	}
+	return root, nil
 }
 
-// GetDiscussion returns a discussion with replies
-func (uc *UseCase) GetDiscussion(ctx context.Context, id uuid.UUID) (*domain.Discussion, error) {
-	return uc.discussionRepo.GetByID(ctx, id)
+// setReplyCounts fills ReplyCount on the given discussions and every reply
+// loaded beneath them
+func (uc *UseCase) setReplyCounts(ctx context.Context, discussions []*domain.Discussion) error {
+	var nodes []*domain.Discussion
+	var collect func(d *domain.Discussion)
+	collect = func(d *domain.Discussion) {
+		nodes = append(nodes, d)
+		for i := range d.Replies {
+			collect(&d.Replies[i])
+		}
+	}
+	for _, d := range discussions {
+		collect(d)
+	}
+
+	ids := make([]uuid.UUID, len(nodes))
+	for i, d := range nodes {
+		ids[i] = d.ID
+	}
+	counts, err := uc.discussionRepo.CountReplies(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, d := range nodes {
+		d.ReplyCount = counts[d.ID]
+	}
+	return nil
+}
+
+// setPageReplyCounts is setReplyCounts for a page of discussions
+func (uc *UseCase) setPageReplyCounts(ctx context.Context, discussions []domain.Discussion) error {
+	ptrs := make([]*domain.Discussion, len(discussions))
+	for i := range discussions {
+		ptrs[i] = &discussions[i]
+	}
+	return uc.setReplyCounts(ctx, ptrs)
 }
 
 // GetCourseDiscussions returns discussions for a course
-func (uc *UseCase) GetCourseDiscussions(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error) {
+func (uc *UseCase) GetCourseDiscussions(ctx context.Context, courseID, userID uuid.UUID, isAdmin bool, page, limit int) ([]domain.Discussion, int64, error) {
+	if err := uc.checkAccess(ctx, courseID, userID, isAdmin, false); err != nil {
+		return nil, 0, err
+	}
 	if page < 1 {
 		page = 1
 	}
 	if limit < 1 || limit > 50 {
 		limit = 20
 	}
-	return uc.discussionRepo.GetByCourse(ctx, courseID, page, limit)
+	discussions, total, err := uc.discussionRepo.GetByCourse(ctx, courseID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := uc.setPageReplyCounts(ctx, discussions); err != nil {
+		return nil, 0, err
+	}
+	return discussions, total, nil
 }
 
 // GetLessonDiscussions returns Q&A for a specific lesson
-func (uc *UseCase) GetLessonDiscussions(ctx context.Context, lessonID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error) {
+func (uc *UseCase) GetLessonDiscussions(ctx context.Context, lessonID, userID uuid.UUID, isAdmin bool, page, limit int) ([]domain.Discussion, int64, error) {
+	lesson, err := uc.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := uc.checkAccess(ctx, lesson.Module.CourseID, userID, isAdmin, false); err != nil {
+		return nil, 0, err
+	}
 	if page < 1 {
 		page = 1
 	}
 	if limit < 1 || limit > 50 {
 		limit = 20
 	}
-	return uc.discussionRepo.GetByLesson(ctx, lessonID, page, limit)
+	discussions, total, err := uc.discussionRepo.GetByLesson(ctx, lessonID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := uc.setPageReplyCounts(ctx, discussions); err != nil {
+		return nil, 0, err
+	}
+	return discussions, total, nil
 }
 
 // GetReplies returns replies for a discussion
-func (uc *UseCase) GetReplies(ctx context.Context, discussionID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error) {
+func (uc *UseCase) GetReplies(ctx context.Context, discussionID, userID uuid.UUID, isAdmin bool, page, limit int) ([]domain.Discussion, int64, error) {
+	parent, err := uc.getAccessible(ctx, discussionID, userID, isAdmin, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	if parent == nil {
+		return []domain.Discussion{}, 0, nil
+	}
 	if page < 1 {
 		page = 1
 	}
 	if limit < 1 || limit > 50 {
 		limit = 20
 	}
-	return uc.discussionRepo.GetReplies(ctx, discussionID, page, limit)
+	discussions, total, err := uc.discussionRepo.GetReplies(ctx, discussionID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := uc.setPageReplyCounts(ctx, discussions); err != nil {
+		return nil, 0, err
+	}
+	return discussions, total, nil
 }
 
 // CreateDiscussionInput for creating a discussion
@@ -77,14 +235,14 @@ type CreateDiscussionInput struct {
 }
 
 // CreateDiscussion creates a new discussion or reply
-func (uc *UseCase) CreateDiscussion(ctx context.Context, userID uuid.UUID, input CreateDiscussionInput) (*domain.Discussion, error) {
-	// Verify user is enrolled in the course
-	enrollment, err := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, input.CourseID)
-	if err != nil || enrollment == nil {
-		// Check if user is the instructor
-		course, _ := uc.courseRepo.GetByID(ctx, input.CourseID)
-		if course == nil || course.InstructorID != userID {
-			return nil, fmt.Errorf("you must be enrolled in the course to participate in discussions")
+func (uc *UseCase) CreateDiscussion(ctx context.Context, userID uuid.UUID, isAdmin bool, input CreateDiscussionInput) (*domain.Discussion, error) {
+	if err := uc.checkAccess(ctx, input.CourseID, userID, isAdmin, true); err != nil {
+		return nil, err
+	}
+	if input.LessonID != nil && input.ParentID == nil {
+		lesson, err := uc.lessonRepo.GetByID(ctx, *input.LessonID)
+		if err != nil || lesson.Module == nil || lesson.Module.CourseID != input.CourseID {
+			return nil, domain.ValidationErrors{{Field: "lesson_id", Message: "lesson not found in this course"}}
 		}
 	}
 
@@ -96,14 +254,101 @@ func (uc *UseCase) CreateDiscussion(ctx context.Context, userID uuid.UUID, input
 		Content:  input.Content,
 	}
 
+	if input.ParentID != nil {
+		parent, err := uc.discussionRepo.GetByID(ctx, *input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || parent.CourseID != input.CourseID {
+			return nil, domain.ValidationErrors{{Field: "parent_id", Message: "parent discussion not found in this course"}}
+		}
+		if parent.Depth+1 > uc.config.MaxReplyDepth {
+			return nil, domain.ErrReplyTooDeep
+		}
+		// Replies stay on their thread's lesson
+		discussion.LessonID = parent.LessonID
+		discussion.Depth = parent.Depth + 1
+	}
+
 	if err := uc.discussionRepo.Create(ctx, discussion); err != nil {
 		return nil, err
 	}
 
+	uc.notifyMentions(ctx, discussion)
+
 	// Return with user info
 	return uc.discussionRepo.GetByID(ctx, discussion.ID)
 }
 
+// notifyMentions tells each @-mentioned member of the course about the post.
+// Unknown handles, people outside the course and the author are skipped.
+func (uc *UseCase) notifyMentions(ctx context.Context, discussion *domain.Discussion) {
+	usernames := discussion.Mentions()
+	if len(usernames) == 0 {
+		return
+	}
+	if len(usernames) > maxMentionsPerPost {
+		usernames = usernames[:maxMentionsPerPost]
+	}
+
+	course, _ := uc.courseRepo.GetByID(ctx, discussion.CourseID)
+	if course == nil {
+		return
+	}
+	users, err := uc.userRepo.GetByUsernames(ctx, usernames)
+	if err != nil {
+		return
+	}
+
+	author := "Someone"
+	if u, _ := uc.userRepo.GetByID(ctx, discussion.UserID); u != nil {
+		author = u.FullName()
+	}
+
+	data := notificationData(course, discussion)
+	message := fmt.Sprintf("%s mentioned you in a discussion in %s", author, course.Title)
+
+	for _, u := range users {
+		if u.ID == discussion.UserID || !uc.isCourseMember(ctx, course, u.ID) {
+			continue
+		}
+		_ = uc.notifier.Notify(ctx, &domain.Notification{
+			UserID:  u.ID,
+			Type:    domain.NotificationMention,
+			Title:   "You were mentioned",
+			Message: &message,
+			Data:    data,
+		})
+	}
+}
+
+// notificationData links a notification to a discussion on the learn page
+func notificationData(course *domain.Course, discussion *domain.Discussion) *string {
+	link := fmt.Sprintf("/learn/%s?discussion=%s", course.Slug, discussion.ID)
+	if discussion.LessonID != nil {
+		link += "&lesson=" + discussion.LessonID.String()
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"discussion_id": discussion.ID.String(),
+		"course_id":     course.ID.String(),
+		"link":          link,
+	})
+	dataStr := string(data)
+	return &dataStr
+}
+
+// isCourseMember reports whether the user teaches or is enrolled in the course
+func (uc *UseCase) isCourseMember(ctx context.Context, course *domain.Course, userID uuid.UUID) bool {
+	if course.InstructorID == userID {
+		return true
+	}
+	enrollment, err := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, course.ID)
+	if err != nil || enrollment == nil {
+		return false
+	}
+	return enrollment.Status == domain.EnrollmentStatusActive || enrollment.Status == domain.EnrollmentStatusCompleted
+}
+
 // UpdateDiscussionInput for updating content
 type UpdateDiscussionInput struct {
 	Content string `json:"content" validate:"required,min=5,max=5000"`
@@ -149,12 +394,20 @@ func (uc *UseCase) DeleteDiscussion(ctx context.Context, id, userID uuid.UUID, i
 }
 
 // Upvote adds an upvote to a discussion
-func (uc *UseCase) Upvote(ctx context.Context, id uuid.UUID) error {
+func (uc *UseCase) Upvote(ctx context.Context, id, userID uuid.UUID, isAdmin bool) error {
+	discussion, err := uc.getAccessible(ctx, id, userID, isAdmin, true)
+	if err != nil || discussion == nil {
+		return err
+	}
 	return uc.discussionRepo.Upvote(ctx, id)
 }
 
 // RemoveUpvote removes an upvote from a discussion
-func (uc *UseCase) RemoveUpvote(ctx context.Context, id uuid.UUID) error {
+func (uc *UseCase) RemoveUpvote(ctx context.Context, id, userID uuid.UUID, isAdmin bool) error {
+	discussion, err := uc.getAccessible(ctx, id, userID, isAdmin, true)
+	if err != nil || discussion == nil {
+		return err
+	}
 	return uc.discussionRepo.RemoveUpvote(ctx, id)
 }
 
@@ -219,6 +472,71 @@ func (uc *UseCase) Unpin(ctx context.Context, id, userID uuid.UUID) error {
 	return uc.discussionRepo.Pin(ctx, id, false)
 }
 
+// AcceptAnswer marks a direct reply as the accepted answer to a question,
+// replacing any earlier choice, and lets the reply's author know
+func (uc *UseCase) AcceptAnswer(ctx context.Context, id, replyID, userID uuid.UUID, isAdmin bool) (*domain.Discussion, error) {
+	question, course, err := uc.getForAnswer(ctx, id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := uc.discussionRepo.GetByID(ctx, replyID)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil || reply.ParentID == nil || *reply.ParentID != question.ID {
+		return nil, domain.ValidationErrors{{Field: "reply_id", Message: "must be a direct reply to this discussion"}}
+	}
+
+	alreadyAccepted := question.AcceptedReplyID != nil && *question.AcceptedReplyID == reply.ID
+	if err := uc.discussionRepo.SetAcceptedReply(ctx, question.ID, &reply.ID); err != nil {
+		return nil, err
+	}
+
+	if !alreadyAccepted && reply.UserID != userID {
+		message := fmt.Sprintf("Your reply in %s was accepted as the answer", course.Title)
+		_ = uc.notifier.Notify(ctx, &domain.Notification{
+			UserID:  reply.UserID,
+			Type:    domain.NotificationAnswerAccepted,
+			Title:   "Answer Accepted",
+			Message: &message,
+			Data:    notificationData(course, question),
+		})
+	}
+
+	return uc.GetDiscussion(ctx, question.ID, userID, isAdmin)
+}
+
+// ClearAnswer removes the accepted answer from a question
+func (uc *UseCase) ClearAnswer(ctx context.Context, id, userID uuid.UUID, isAdmin bool) error {
+	question, _, err := uc.getForAnswer(ctx, id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return uc.discussionRepo.SetAcceptedReply(ctx, question.ID, nil)
+}
+
+// getForAnswer loads a question the user may choose the answer for: its
+// asker, the course instructor or an admin
+func (uc *UseCase) getForAnswer(ctx context.Context, id, userID uuid.UUID, isAdmin bool) (*domain.Discussion, *domain.Course, error) {
+	question, err := uc.discussionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if question == nil {
+		return nil, nil, domain.ErrDiscussionNotFound
+	}
+
+	course, err := uc.courseRepo.GetByID(ctx, question.CourseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isAdmin && question.UserID != userID && course.InstructorID != userID {
+		return nil, nil, domain.ErrForbidden
+	}
+	return question, course, nil
+}
+
 // GetStats returns discussion stats
 type DiscussionStats struct {
 	TotalQuestions int64 `json:"total_questions"`
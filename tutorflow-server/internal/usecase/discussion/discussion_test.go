@@ -0,0 +1,663 @@
+package discussion_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/discussion"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
+)
+
+// MockDiscussionRepository is a mock implementation of DiscussionRepository
+type MockDiscussionRepository struct {
+	mock.Mock
+}
+
+func (m *MockDiscussionRepository) Create(ctx context.Context, d *domain.Discussion) error {
+	return m.Called(ctx, d).Error(0)
+}
+
+func (m *MockDiscussionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Discussion, error) {
+	args := m.Called(ctx, id)
+	d, _ := args.Get(0).(*domain.Discussion)
+	return d, args.Error(1)
+}
+
+func (m *MockDiscussionRepository) Update(ctx context.Context, d *domain.Discussion) error {
+	return m.Called(ctx, d).Error(0)
+}
+
+func (m *MockDiscussionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockDiscussionRepository) GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error) {
+	args := m.Called(ctx, courseID, page, limit)
+	return args.Get(0).([]domain.Discussion), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockDiscussionRepository) GetByLesson(ctx context.Context, lessonID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error) {
+	args := m.Called(ctx, lessonID, page, limit)
+	return args.Get(0).([]domain.Discussion), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockDiscussionRepository) GetReplies(ctx context.Context, parentID uuid.UUID, page, limit int) ([]domain.Discussion, int64, error) {
+	args := m.Called(ctx, parentID, page, limit)
+	return args.Get(0).([]domain.Discussion), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockDiscussionRepository) GetThread(ctx context.Context, rootID uuid.UUID, levels int) ([]domain.Discussion, error) {
+	args := m.Called(ctx, rootID, levels)
+	return args.Get(0).([]domain.Discussion), args.Error(1)
+}
+
+func (m *MockDiscussionRepository) CountReplies(ctx context.Context, parentIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	args := m.Called(ctx, parentIDs)
+	return args.Get(0).(map[uuid.UUID]int64), args.Error(1)
+}
+
+func (m *MockDiscussionRepository) Upvote(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockDiscussionRepository) RemoveUpvote(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockDiscussionRepository) MarkResolved(ctx context.Context, id uuid.UUID, resolved bool) error {
+	return m.Called(ctx, id, resolved).Error(0)
+}
+
+func (m *MockDiscussionRepository) Pin(ctx context.Context, id uuid.UUID, pinned bool) error {
+	return m.Called(ctx, id, pinned).Error(0)
+}
+
+func (m *MockDiscussionRepository) SetAcceptedReply(ctx context.Context, id uuid.UUID, replyID *uuid.UUID) error {
+	return m.Called(ctx, id, replyID).Error(0)
+}
+
+func (m *MockDiscussionRepository) CountByCourse(ctx context.Context, courseID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, courseID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDiscussionRepository) CountByLesson(ctx context.Context, lessonID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, lessonID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockEnrollmentRepository is a mock implementation of EnrollmentRepository
+type MockEnrollmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockEnrollmentRepository) Create(ctx context.Context, e *domain.Enrollment) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockEnrollmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Enrollment, error) {
+	args := m.Called(ctx, id)
+	e, _ := args.Get(0).(*domain.Enrollment)
+	return e, args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetByUserAndCourse(ctx context.Context, userID, courseID uuid.UUID) (*domain.Enrollment, error) {
+	args := m.Called(ctx, userID, courseID)
+	e, _ := args.Get(0).(*domain.Enrollment)
+	return e, args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) Update(ctx context.Context, e *domain.Enrollment) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockEnrollmentRepository) List(ctx context.Context, filters repository.EnrollmentFilters) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, courseID, page, limit)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) UpdateProgress(ctx context.Context, id uuid.UUID, progress float64) error {
+	return m.Called(ctx, id, progress).Error(0)
+}
+
+func (m *MockEnrollmentRepository) GetStats(ctx context.Context, userID uuid.UUID) (*domain.StudentDashboardStats, error) {
+	args := m.Called(ctx, userID)
+	s, _ := args.Get(0).(*domain.StudentDashboardStats)
+	return s, args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) HasInstructor(ctx context.Context, userID, instructorID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID, instructorID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetCourseFunnel(ctx context.Context, courseID uuid.UUID) (*domain.CourseFunnel, error) {
+	args := m.Called(ctx, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CourseFunnel), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetAtRisk(ctx context.Context, courseID uuid.UUID, inactiveSince time.Time, maxProgress float64) ([]domain.AtRiskStudent, error) {
+	args := m.Called(ctx, courseID, inactiveSince, maxProgress)
+	return args.Get(0).([]domain.AtRiskStudent), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) MarkNudged(ctx context.Context, ids []uuid.UUID, now, cooldownSince time.Time) ([]uuid.UUID, error) {
+	args := m.Called(ctx, ids, now, cooldownSince)
+	claimed, _ := args.Get(0).([]uuid.UUID)
+	return claimed, args.Error(1)
+}
+
+// MockCourseRepository is a mock implementation of CourseRepository
+type MockCourseRepository struct {
+	mock.Mock
+}
+
+func (m *MockCourseRepository) Create(ctx context.Context, c *domain.Course) error {
+	return m.Called(ctx, c).Error(0)
+}
+
+func (m *MockCourseRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Course, error) {
+	args := m.Called(ctx, id)
+	c, _ := args.Get(0).(*domain.Course)
+	return c, args.Error(1)
+}
+
+func (m *MockCourseRepository) GetBySlug(ctx context.Context, slug string) (*domain.Course, error) {
+	args := m.Called(ctx, slug)
+	c, _ := args.Get(0).(*domain.Course)
+	return c, args.Error(1)
+}
+
+func (m *MockCourseRepository) GetByEnrollmentCode(ctx context.Context, code string) (*domain.Course, error) {
+	args := m.Called(ctx, code)
+	c, _ := args.Get(0).(*domain.Course)
+	return c, args.Error(1)
+}
+
+func (m *MockCourseRepository) Update(ctx context.Context, c *domain.Course) error {
+	return m.Called(ctx, c).Error(0)
+}
+
+func (m *MockCourseRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCourseRepository) List(ctx context.Context, filters repository.CourseFilters) ([]domain.Course, int64, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]domain.Course), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCourseRepository) GetByInstructor(ctx context.Context, instructorID uuid.UUID, page, limit int) ([]domain.Course, int64, error) {
+	args := m.Called(ctx, instructorID, page, limit)
+	return args.Get(0).([]domain.Course), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCourseRepository) UpdateStats(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCourseRepository) IncrementStudentCount(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCourseRepository) SetEnrollmentCode(ctx context.Context, id uuid.UUID, code *string) error {
+	return m.Called(ctx, id, code).Error(0)
+}
+
+func (m *MockCourseRepository) GetInstructorSplits(ctx context.Context, courseID uuid.UUID) ([]domain.CourseInstructor, error) {
+	args := m.Called(ctx, courseID)
+	return args.Get(0).([]domain.CourseInstructor), args.Error(1)
+}
+
+func (m *MockCourseRepository) SetInstructorSplits(ctx context.Context, courseID uuid.UUID, splits []domain.CourseInstructor) error {
+	return m.Called(ctx, courseID, splits).Error(0)
+}
+
+func (m *MockCourseRepository) SetCategories(ctx context.Context, courseID uuid.UUID, categoryIDs []uuid.UUID) error {
+	return m.Called(ctx, courseID, categoryIDs).Error(0)
+}
+
+func (m *MockCourseRepository) ListDeleted(ctx context.Context, instructorID *uuid.UUID, page, limit int) ([]domain.Course, int64, error) {
+	args := m.Called(ctx, instructorID, page, limit)
+	return args.Get(0).([]domain.Course), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCourseRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*domain.Course, error) {
+	args := m.Called(ctx, id)
+	c, _ := args.Get(0).(*domain.Course)
+	return c, args.Error(1)
+}
+
+func (m *MockCourseRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCourseRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockUserRepository is a mock implementation of UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	args := m.Called(ctx, usernames)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filters repository.UserFilters) ([]domain.User, int64, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]domain.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return m.Called(ctx, id, passwordHash).Error(0)
+}
+
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdateLastActive(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+func (m *MockUserRepository) Anonymize(ctx context.Context, u *domain.User, entry *domain.AuditLog) error {
+	return m.Called(ctx, u, entry).Error(0)
+}
+
+// MockNotificationRepository is a mock implementation of NotificationRepository
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(ctx context.Context, n *domain.Notification) error {
+	return m.Called(ctx, n).Error(0)
+}
+
+func (m *MockNotificationRepository) GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Notification, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	return args.Get(0).([]domain.Notification), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockNotificationRepository) GetByUserAfter(ctx context.Context, userID uuid.UUID, cursor pagination.Cursor, limit int) ([]domain.Notification, error) {
+	args := m.Called(ctx, userID, cursor, limit)
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkAsRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID, ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkAllAsRead(ctx context.Context, userID uuid.UUID) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *MockNotificationRepository) Delete(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, before *time.Time) (int64, error) {
+	args := m.Called(ctx, userID, ids, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepository) GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepository) GetForDigest(ctx context.Context, userID uuid.UUID, since time.Time) ([]domain.Notification, error) {
+	args := m.Called(ctx, userID, since)
+	notifications, _ := args.Get(0).([]domain.Notification)
+	return notifications, args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkDigested(ctx context.Context, ids []uuid.UUID, at time.Time) error {
+	return m.Called(ctx, ids, at).Error(0)
+}
+
+func (m *MockNotificationRepository) CreateBatch(ctx context.Context, notifications []domain.Notification) error {
+	return m.Called(ctx, notifications).Error(0)
+}
+
+// MockNotificationPreferenceRepository is a mock implementation of NotificationPreferenceRepository
+type MockNotificationPreferenceRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationPreferenceRepository) GetByUser(ctx context.Context, userID uuid.UUID) (domain.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	prefs, _ := args.Get(0).(domain.NotificationPreferences)
+	return prefs, args.Error(1)
+}
+
+func (m *MockNotificationPreferenceRepository) Upsert(ctx context.Context, prefs []domain.NotificationPreference) error {
+	return m.Called(ctx, prefs).Error(0)
+}
+
+func (m *MockNotificationPreferenceRepository) GetDigest(ctx context.Context, userID uuid.UUID) (*domain.NotificationDigest, error) {
+	args := m.Called(ctx, userID)
+	digest, _ := args.Get(0).(*domain.NotificationDigest)
+	return digest, args.Error(1)
+}
+
+func (m *MockNotificationPreferenceRepository) SetDigest(ctx context.Context, digest *domain.NotificationDigest) error {
+	return m.Called(ctx, digest).Error(0)
+}
+
+func (m *MockNotificationPreferenceRepository) GetDueDigests(ctx context.Context, frequency domain.DigestFrequency, sentBefore time.Time) ([]domain.NotificationDigest, error) {
+	args := m.Called(ctx, frequency, sentBefore)
+	digests, _ := args.Get(0).([]domain.NotificationDigest)
+	return digests, args.Error(1)
+}
+
+func (m *MockNotificationPreferenceRepository) MarkDigestSent(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return m.Called(ctx, userID, at).Error(0)
+}
+
+func (m *MockNotificationPreferenceRepository) GetByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.NotificationPreferences, error) {
+	args := m.Called(ctx, userIDs)
+	prefs, _ := args.Get(0).(map[uuid.UUID]domain.NotificationPreferences)
+	return prefs, args.Error(1)
+}
+
+func (m *MockNotificationPreferenceRepository) GetDigestFrequencies(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.DigestFrequency, error) {
+	args := m.Called(ctx, userIDs)
+	frequencies, _ := args.Get(0).(map[uuid.UUID]domain.DigestFrequency)
+	return frequencies, args.Error(1)
+}
+
+func TestDiscussionUseCase_GetDiscussion_Tree(t *testing.T) {
+	ctx := context.Background()
+	root := &domain.Discussion{ID: uuid.New()}
+	reply := domain.Discussion{ID: uuid.New(), ParentID: &root.ID, Depth: 1}
+	nested := domain.Discussion{ID: uuid.New(), ParentID: &reply.ID, Depth: 2}
+	sibling := domain.Discussion{ID: uuid.New(), ParentID: &root.ID, Depth: 1}
+
+	discussionRepo := new(MockDiscussionRepository)
+	discussionRepo.On("GetByID", ctx, root.ID).Return(root, nil)
+	discussionRepo.On("GetThread", ctx, root.ID, 2).Return([]domain.Discussion{reply, nested, sibling}, nil)
+	discussionRepo.On("CountReplies", ctx, mock.Anything).
+		Return(map[uuid.UUID]int64{root.ID: 2, reply.ID: 1, nested.ID: 4}, nil)
+
+	uc := discussion.NewUseCase(discussionRepo, nil, nil, nil, nil, nil, config.DiscussionConfig{MaxReplyDepth: 2})
+
+	got, err := uc.GetDiscussion(ctx, root.ID, uuid.New(), true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got.ReplyCount)
+	if assert.Len(t, got.Replies, 2) {
+		assert.Equal(t, reply.ID, got.Replies[0].ID)
+		assert.Equal(t, sibling.ID, got.Replies[1].ID)
+		assert.Equal(t, int64(0), got.Replies[1].ReplyCount)
+		if assert.Len(t, got.Replies[0].Replies, 1) {
+			leaf := got.Replies[0].Replies[0]
+			assert.Equal(t, nested.ID, leaf.ID)
+			assert.Empty(t, leaf.Replies, "nothing is loaded past the depth limit")
+			assert.Equal(t, int64(4), leaf.ReplyCount, "counts still show there is more to fetch")
+		}
+	}
+}
+
+func TestDiscussionUseCase_CreateDiscussion_Depth(t *testing.T) {
+	ctx := context.Background()
+	userID, courseID := uuid.New(), uuid.New()
+	lessonID := uuid.New()
+
+	setup := func(parent *domain.Discussion) (*discussion.UseCase, *MockDiscussionRepository) {
+		discussionRepo := new(MockDiscussionRepository)
+		enrollRepo := new(MockEnrollmentRepository)
+		courseRepo := new(MockCourseRepository)
+		courseRepo.On("GetByID", ctx, courseID).Return(&domain.Course{ID: courseID, InstructorID: uuid.New()}, nil)
+		enrollRepo.On("GetByUserAndCourse", ctx, userID, courseID).Return(&domain.Enrollment{Status: domain.EnrollmentStatusActive}, nil)
+		discussionRepo.On("GetByID", ctx, parent.ID).Return(parent, nil)
+		return discussion.NewUseCase(discussionRepo, enrollRepo, courseRepo, nil, nil, nil, config.DiscussionConfig{MaxReplyDepth: 3}), discussionRepo
+	}
+
+	t.Run("nests under the parent", func(t *testing.T) {
+		parent := &domain.Discussion{ID: uuid.New(), CourseID: courseID, LessonID: &lessonID, Depth: 2}
+		uc, discussionRepo := setup(parent)
+		var created *domain.Discussion
+		discussionRepo.On("Create", ctx, mock.Anything).
+			Run(func(args mock.Arguments) {
+				created = args.Get(1).(*domain.Discussion)
+				created.ID = uuid.New()
+			}).
+			Return(nil)
+		discussionRepo.On("GetByID", ctx, mock.Anything).Return(&domain.Discussion{}, nil)
+
+		_, err := uc.CreateDiscussion(ctx, userID, false, discussion.CreateDiscussionInput{
+			CourseID: courseID, ParentID: &parent.ID, Content: "Same question here",
+		})
+		assert.NoError(t, err)
+		if assert.NotNil(t, created) {
+			assert.Equal(t, 3, created.Depth)
+			assert.Equal(t, &lessonID, created.LessonID, "replies stay on the thread's lesson")
+		}
+	})
+
+	t.Run("rejects replies past the limit", func(t *testing.T) {
+		parent := &domain.Discussion{ID: uuid.New(), CourseID: courseID, Depth: 3}
+		uc, discussionRepo := setup(parent)
+
+		_, err := uc.CreateDiscussion(ctx, userID, false, discussion.CreateDiscussionInput{
+			CourseID: courseID, ParentID: &parent.ID, Content: "One level too far",
+		})
+		assert.ErrorIs(t, err, domain.ErrReplyTooDeep)
+		discussionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects parents from another course", func(t *testing.T) {
+		parent := &domain.Discussion{ID: uuid.New(), CourseID: uuid.New()}
+		uc, _ := setup(parent)
+
+		_, err := uc.CreateDiscussion(ctx, userID, false, discussion.CreateDiscussionInput{
+			CourseID: courseID, ParentID: &parent.ID, Content: "Wrong thread",
+		})
+		var verrs domain.ValidationErrors
+		assert.ErrorAs(t, err, &verrs)
+	})
+}
+
+func TestDiscussion_Mentions(t *testing.T) {
+	d := &domain.Discussion{Content: "@Ana and @bob, see @ana's note. Mail ana@example.com or ping @x and @carol_99!"}
+	assert.Equal(t, []string{"ana", "bob", "carol_99"}, d.Mentions(),
+		"handles are lowercased and deduped; emails and too-short handles are ignored")
+}
+
+func TestDiscussionUseCase_CreateDiscussion_Mentions(t *testing.T) {
+	ctx := context.Background()
+	authorID, courseID := uuid.New(), uuid.New()
+	course := &domain.Course{ID: courseID, Title: "Go Basics", Slug: "go-basics", InstructorID: uuid.New()}
+	learner := domain.User{ID: uuid.New()}
+	instructor := domain.User{ID: course.InstructorID}
+	outsider := domain.User{ID: uuid.New()}
+	author := domain.User{ID: authorID, FirstName: "Sam", LastName: "Lee"}
+
+	discussionRepo := new(MockDiscussionRepository)
+	enrollRepo := new(MockEnrollmentRepository)
+	courseRepo := new(MockCourseRepository)
+	userRepo := new(MockUserRepository)
+	notificationRepo := new(MockNotificationRepository)
+
+	discussionRepo.On("Create", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { args.Get(1).(*domain.Discussion).ID = uuid.New() }).
+		Return(nil)
+	discussionRepo.On("GetByID", ctx, mock.Anything).Return(&domain.Discussion{}, nil)
+	enrollRepo.On("GetByUserAndCourse", ctx, authorID, courseID).Return(&domain.Enrollment{Status: domain.EnrollmentStatusActive}, nil)
+	enrollRepo.On("GetByUserAndCourse", ctx, learner.ID, courseID).Return(&domain.Enrollment{Status: domain.EnrollmentStatusActive}, nil)
+	enrollRepo.On("GetByUserAndCourse", ctx, outsider.ID, courseID).Return(nil, domain.ErrNotEnrolled)
+	courseRepo.On("GetByID", ctx, courseID).Return(course, nil)
+	userRepo.On("GetByUsernames", ctx, []string{"learner", "teacher", "stranger", "sam"}).
+		Return([]domain.User{learner, instructor, outsider, author}, nil)
+	userRepo.On("GetByID", ctx, authorID).Return(&author, nil)
+
+	// The instructor has turned off in-app discussion notifications
+	prefRepo := new(MockNotificationPreferenceRepository)
+	prefRepo.On("GetByUser", ctx, instructor.ID).Return(domain.NotificationPreferences{
+		{Category: domain.NotificationCategoryDiscussion, Channel: domain.NotificationChannelInApp, Enabled: false},
+	}, nil)
+	prefRepo.On("GetByUser", ctx, mock.Anything).Return(nil, nil)
+
+	var notified []uuid.UUID
+	notificationRepo.On("Create", ctx, mock.Anything).
+		Run(func(args mock.Arguments) {
+			n := args.Get(1).(*domain.Notification)
+			assert.Equal(t, domain.NotificationMention, n.Type)
+			assert.Contains(t, *n.Message, "Sam Lee")
+			assert.Contains(t, *n.Data, "/learn/go-basics?discussion=")
+			notified = append(notified, n.UserID)
+		}).
+		Return(nil)
+
+	uc := discussion.NewUseCase(discussionRepo, enrollRepo, courseRepo, nil, userRepo, notification.NewNotifier(notificationRepo, prefRepo, nil), config.DiscussionConfig{MaxReplyDepth: 3})
+	_, err := uc.CreateDiscussion(ctx, authorID, false, discussion.CreateDiscussionInput{
+		CourseID: courseID,
+		Content:  "@learner @teacher @stranger @sam @learner what do you think?",
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{learner.ID}, notified,
+		"only course members who want it are notified, once each, and never the author")
+}
+
+func TestDiscussionUseCase_Access(t *testing.T) {
+	ctx := context.Background()
+	userID, instructorID := uuid.New(), uuid.New()
+	private := &domain.Course{ID: uuid.New(), InstructorID: instructorID}
+	public := &domain.Course{ID: uuid.New(), InstructorID: instructorID, PublicDiscussions: true}
+
+	discussionRepo := new(MockDiscussionRepository)
+	enrollRepo := new(MockEnrollmentRepository)
+	courseRepo := new(MockCourseRepository)
+	for _, c := range []*domain.Course{private, public} {
+		courseRepo.On("GetByID", ctx, c.ID).Return(c, nil)
+		enrollRepo.On("GetByUserAndCourse", ctx, userID, c.ID).Return(nil, domain.ErrNotEnrolled)
+		discussionRepo.On("GetByCourse", ctx, c.ID, 1, 20).Return([]domain.Discussion{}, int64(0), nil)
+	}
+	discussionRepo.On("CountReplies", ctx, mock.Anything).Return(map[uuid.UUID]int64{}, nil)
+	uc := discussion.NewUseCase(discussionRepo, enrollRepo, courseRepo, nil, nil, nil, config.DiscussionConfig{MaxReplyDepth: 3})
+
+	_, _, err := uc.GetCourseDiscussions(ctx, private.ID, userID, false, 1, 20)
+	assert.ErrorIs(t, err, domain.ErrNotEnrolled, "outsiders can't read private discussions")
+
+	_, _, err = uc.GetCourseDiscussions(ctx, private.ID, instructorID, false, 1, 20)
+	assert.NoError(t, err, "the instructor can")
+
+	_, _, err = uc.GetCourseDiscussions(ctx, private.ID, userID, true, 1, 20)
+	assert.NoError(t, err, "admins can")
+
+	_, _, err = uc.GetCourseDiscussions(ctx, public.ID, userID, false, 1, 20)
+	assert.NoError(t, err, "public discussions are readable by anyone signed in")
+
+	_, err = uc.CreateDiscussion(ctx, userID, false, discussion.CreateDiscussionInput{CourseID: public.ID, Content: "Can I join in?"})
+	assert.ErrorIs(t, err, domain.ErrNotEnrolled, "but only members can post")
+	discussionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestDiscussionUseCase_AcceptAnswer(t *testing.T) {
+	ctx := context.Background()
+	askerID, helperID, instructorID := uuid.New(), uuid.New(), uuid.New()
+	course := &domain.Course{ID: uuid.New(), Title: "Go Basics", Slug: "go-basics", InstructorID: instructorID}
+	question := &domain.Discussion{ID: uuid.New(), CourseID: course.ID, UserID: askerID}
+	first := domain.Discussion{ID: uuid.New(), ParentID: &question.ID, UserID: askerID, Depth: 1}
+	answer := &domain.Discussion{ID: uuid.New(), ParentID: &question.ID, UserID: helperID, Depth: 1}
+	nested := &domain.Discussion{ID: uuid.New(), ParentID: &answer.ID, UserID: helperID, Depth: 2}
+
+	setup := func() (*discussion.UseCase, *MockDiscussionRepository, *MockNotificationRepository) {
+		discussionRepo := new(MockDiscussionRepository)
+		courseRepo := new(MockCourseRepository)
+		notificationRepo := new(MockNotificationRepository)
+		enrollRepo := new(MockEnrollmentRepository)
+		enrollRepo.On("GetByUserAndCourse", ctx, askerID, course.ID).Return(&domain.Enrollment{Status: domain.EnrollmentStatusActive}, nil)
+		courseRepo.On("GetByID", ctx, course.ID).Return(course, nil)
+		discussionRepo.On("GetByID", ctx, question.ID).Return(question, nil)
+		discussionRepo.On("GetByID", ctx, answer.ID).Return(answer, nil)
+		discussionRepo.On("GetByID", ctx, nested.ID).Return(nested, nil)
+		discussionRepo.On("GetThread", ctx, question.ID, 3).Return([]domain.Discussion{first, *answer}, nil)
+		discussionRepo.On("CountReplies", ctx, mock.Anything).Return(map[uuid.UUID]int64{}, nil)
+		prefRepo := new(MockNotificationPreferenceRepository)
+		prefRepo.On("GetByUser", ctx, mock.Anything).Return(nil, nil)
+		uc := discussion.NewUseCase(discussionRepo, enrollRepo, courseRepo, nil, nil, notification.NewNotifier(notificationRepo, prefRepo, nil), config.DiscussionConfig{MaxReplyDepth: 3})
+		return uc, discussionRepo, notificationRepo
+	}
+
+	t.Run("the asker accepts and the helper is notified", func(t *testing.T) {
+		uc, discussionRepo, notificationRepo := setup()
+		discussionRepo.On("SetAcceptedReply", ctx, question.ID, &answer.ID).
+			Run(func(mock.Arguments) { question.AcceptedReplyID = &answer.ID }).
+			Return(nil)
+		notificationRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == helperID && n.Type == domain.NotificationAnswerAccepted
+		})).Return(nil)
+		defer func() { question.AcceptedReplyID = nil }()
+
+		got, err := uc.AcceptAnswer(ctx, question.ID, answer.ID, askerID, false)
+		assert.NoError(t, err)
+		if assert.Len(t, got.Replies, 2) {
+			assert.Equal(t, answer.ID, got.Replies[0].ID, "the accepted answer comes first")
+		}
+		notificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("other learners can't accept", func(t *testing.T) {
+		uc, discussionRepo, _ := setup()
+		_, err := uc.AcceptAnswer(ctx, question.ID, answer.ID, helperID, false)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		discussionRepo.AssertNotCalled(t, "SetAcceptedReply", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("only direct replies can be accepted", func(t *testing.T) {
+		uc, _, _ := setup()
+		_, err := uc.AcceptAnswer(ctx, question.ID, nested.ID, instructorID, false)
+		var verrs domain.ValidationErrors
+		assert.ErrorAs(t, err, &verrs)
+	})
+}
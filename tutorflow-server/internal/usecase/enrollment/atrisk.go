@@ -0,0 +1,102 @@
+package enrollment
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// NudgeInput for reminding at-risk learners
+type NudgeInput struct {
+	Message string `json:"message" validate:"omitempty,max=1000"` // optional note from the instructor
+}
+
+// GetAtRiskStudents lists the course's active learners who have had no lesson
+// activity for the configured window while still below the configured
+// progress
+func (uc *UseCase) GetAtRiskStudents(ctx context.Context, courseID uuid.UUID) ([]domain.AtRiskStudent, error) {
+	since := time.Now().Add(-uc.cfg.AtRiskInactivity)
+	return uc.enrollmentRepo.GetAtRisk(ctx, courseID, since, uc.cfg.AtRiskMaxProgress)
+}
+
+// NudgeAtRiskStudents sends every at-risk learner in the course a reminder to
+// pick it back up, with the instructor's note if one is given. The reminder is
+// emailed and added to their notifications as their preferences allow.
+// Learners nudged within the configured cooldown are skipped. It returns how
+// many learners were nudged.
+func (uc *UseCase) NudgeAtRiskStudents(ctx context.Context, courseID uuid.UUID, input NudgeInput) (int, error) {
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return 0, domain.ErrCourseNotFound
+	}
+
+	students, err := uc.GetAtRiskStudents(ctx, courseID)
+	if err != nil {
+		return 0, err
+	}
+
+	students, err = uc.claimNudges(ctx, students)
+	if err != nil {
+		return 0, err
+	}
+
+	body := "It's been a while since you worked on " + course.Title + ". Pick up where you left off!"
+	if input.Message != "" {
+		body = input.Message
+	}
+
+	for _, student := range students {
+		_ = uc.notifier.Notify(ctx, &domain.Notification{
+			UserID:  student.UserID,
+			Type:    domain.NotificationCourseUpdate,
+			Title:   "Continue " + course.Title,
+			Message: stringPtr(body),
+		})
+
+		if uc.emailSvc == nil {
+			continue
+		}
+		if !uc.notifier.EmailsNow(ctx, student.UserID, domain.NotificationCategoryCourse) {
+			continue
+		}
+		courseURL := uc.emailSvc.AppLink("/courses/" + course.Slug)
+		go func() {
+			_ = uc.emailSvc.SendCourseNudge(context.WithoutCancel(ctx), student.Email, student.FirstName, course.Title, courseURL, input.Message)
+		}()
+	}
+
+	return len(students), nil
+}
+
+// claimNudges records the nudge on the students' enrollments and returns the
+// students who weren't already nudged within the cooldown
+func (uc *UseCase) claimNudges(ctx context.Context, students []domain.AtRiskStudent) ([]domain.AtRiskStudent, error) {
+	if len(students) == 0 {
+		return nil, nil
+	}
+	ids := make([]uuid.UUID, len(students))
+	for i, student := range students {
+		ids[i] = student.EnrollmentID
+	}
+
+	now := time.Now()
+	claimed, err := uc.enrollmentRepo.MarkNudged(ctx, ids, now, now.Add(-uc.cfg.AtRiskNudgeCooldown))
+	if err != nil {
+		return nil, err
+	}
+	due := make(map[uuid.UUID]bool, len(claimed))
+	for _, id := range claimed {
+		due[id] = true
+	}
+
+	var nudge []domain.AtRiskStudent
+	for _, student := range students {
+		if due[student.EnrollmentID] {
+			nudge = append(nudge, student)
+		}
+	}
+	return nudge, nil
+}
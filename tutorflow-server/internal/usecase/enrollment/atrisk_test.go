@@ -0,0 +1,132 @@
+package enrollment_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/enrollment"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
+)
+
+type atRiskCourses struct {
+	repository.CourseRepository
+	course *domain.Course
+}
+
+func (r *atRiskCourses) GetByID(ctx context.Context, id uuid.UUID) (*domain.Course, error) {
+	return r.course, nil
+}
+
+type atRiskNotifications struct {
+	repository.NotificationRepository
+	created []domain.Notification
+}
+
+func (r *atRiskNotifications) Create(ctx context.Context, n *domain.Notification) error {
+	r.created = append(r.created, *n)
+	return nil
+}
+
+// atRiskPrefs has every learner on the default preferences
+type atRiskPrefs struct {
+	repository.NotificationPreferenceRepository
+}
+
+func (r *atRiskPrefs) GetByUser(ctx context.Context, userID uuid.UUID) (domain.NotificationPreferences, error) {
+	return nil, nil
+}
+
+func (r *atRiskPrefs) GetDigest(ctx context.Context, userID uuid.UUID) (*domain.NotificationDigest, error) {
+	return &domain.NotificationDigest{UserID: userID, Frequency: domain.DigestImmediate}, nil
+}
+
+func TestEnrollmentUseCase_GetAtRiskStudents(t *testing.T) {
+	courseID := uuid.New()
+	students := []domain.AtRiskStudent{{EnrollmentID: uuid.New(), UserID: uuid.New(), Progress: 10}}
+
+	mockRepo := new(MockEnrollmentRepository)
+	mockRepo.On("GetAtRisk", mock.Anything, courseID, mock.MatchedBy(func(since time.Time) bool {
+		return time.Since(since).Round(time.Hour) == 7*24*time.Hour
+	}), 30.0).Return(students, nil)
+
+	uc := enrollment.NewUseCase(mockRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, config.EnrollmentConfig{
+		AtRiskInactivity:  7 * 24 * time.Hour,
+		AtRiskMaxProgress: 30,
+	})
+
+	got, err := uc.GetAtRiskStudents(context.Background(), courseID)
+	require.NoError(t, err)
+	assert.Equal(t, students, got)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestEnrollmentUseCase_NudgeAtRiskStudents(t *testing.T) {
+	course := &domain.Course{ID: uuid.New(), Title: "Go Basics", Slug: "go-basics"}
+	students := []domain.AtRiskStudent{
+		{EnrollmentID: uuid.New(), UserID: uuid.New(), FirstName: "Sam"},
+		{EnrollmentID: uuid.New(), UserID: uuid.New(), FirstName: "Alex"},
+	}
+
+	// claimed is the enrollments not nudged within the cooldown
+	newUseCase := func(notifications *atRiskNotifications, claimed ...uuid.UUID) *enrollment.UseCase {
+		if claimed == nil {
+			claimed = []uuid.UUID{students[0].EnrollmentID, students[1].EnrollmentID}
+		}
+		mockRepo := new(MockEnrollmentRepository)
+		mockRepo.On("GetAtRisk", mock.Anything, course.ID, mock.Anything, mock.Anything).Return(students, nil)
+		mockRepo.On("MarkNudged", mock.Anything, []uuid.UUID{students[0].EnrollmentID, students[1].EnrollmentID}, mock.Anything,
+			mock.MatchedBy(func(since time.Time) bool {
+				return time.Since(since).Round(time.Hour) == 7*24*time.Hour
+			})).Return(claimed, nil)
+		prefs := &atRiskPrefs{}
+		return enrollment.NewUseCase(mockRepo, nil, &atRiskCourses{course: course}, nil, notification.NewNotifier(notifications, prefs, nil),
+			prefs, nil, nil, nil, nil, config.EnrollmentConfig{
+				AtRiskInactivity:    14 * 24 * time.Hour,
+				AtRiskMaxProgress:   50,
+				AtRiskNudgeCooldown: 7 * 24 * time.Hour,
+			})
+	}
+
+	t.Run("every at-risk learner is notified", func(t *testing.T) {
+		notifications := &atRiskNotifications{}
+
+		nudged, err := newUseCase(notifications).NudgeAtRiskStudents(context.Background(), course.ID, enrollment.NudgeInput{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, nudged)
+		require.Len(t, notifications.created, 2)
+		assert.Equal(t, students[0].UserID, notifications.created[0].UserID)
+		assert.Equal(t, students[1].UserID, notifications.created[1].UserID)
+		assert.Contains(t, *notifications.created[0].Message, "Go Basics")
+	})
+
+	t.Run("instructor note replaces the default message", func(t *testing.T) {
+		notifications := &atRiskNotifications{}
+
+		_, err := newUseCase(notifications).NudgeAtRiskStudents(context.Background(), course.ID, enrollment.NudgeInput{Message: "Module 3 is the hard part, you've got this"})
+		require.NoError(t, err)
+
+		require.Len(t, notifications.created, 2)
+		assert.Equal(t, "Module 3 is the hard part, you've got this", *notifications.created[0].Message)
+	})
+
+	t.Run("learners nudged within the cooldown are skipped", func(t *testing.T) {
+		notifications := &atRiskNotifications{}
+
+		nudged, err := newUseCase(notifications, students[1].EnrollmentID).NudgeAtRiskStudents(context.Background(), course.ID, enrollment.NudgeInput{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, nudged)
+		require.Len(t, notifications.created, 1)
+		assert.Equal(t, students[1].UserID, notifications.created[0].UserID)
+	})
+}
@@ -3,12 +3,18 @@ package enrollment
 import (
 	"context"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
 )
 
 // UseCase defines enrollment business logic
@@ -17,7 +23,13 @@ type UseCase struct {
 	progressRepo     repository.LessonProgressRepository
 	courseRepo       repository.CourseRepository
 	lessonRepo       repository.LessonRepository
-	notificationRepo repository.NotificationRepository
+	notifier         *notification.Notifier
+	prefRepo         repository.NotificationPreferenceRepository
+	userRepo         repository.UserRepository
+	certRepo         repository.CertificateRepository
+	subscriptionRepo repository.SubscriptionRepository
+	emailSvc         *email.Service
+	cfg              config.EnrollmentConfig
 }
 
 // NewUseCase creates a new enrollment use case
@@ -26,14 +38,26 @@ func NewUseCase(
 	progressRepo repository.LessonProgressRepository,
 	courseRepo repository.CourseRepository,
 	lessonRepo repository.LessonRepository,
-	notificationRepo repository.NotificationRepository,
+	notifier *notification.Notifier,
+	prefRepo repository.NotificationPreferenceRepository,
+	userRepo repository.UserRepository,
+	certRepo repository.CertificateRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	emailSvc *email.Service,
+	cfg config.EnrollmentConfig,
 ) *UseCase {
 	return &UseCase{
 		enrollmentRepo:   enrollmentRepo,
 		progressRepo:     progressRepo,
 		courseRepo:       courseRepo,
 		lessonRepo:       lessonRepo,
-		notificationRepo: notificationRepo,
+		notifier:         notifier,
+		prefRepo:         prefRepo,
+		userRepo:         userRepo,
+		certRepo:         certRepo,
+		subscriptionRepo: subscriptionRepo,
+		emailSvc:         emailSvc,
+		cfg:              cfg,
 	}
 }
 
@@ -48,6 +72,26 @@ type ListInput struct {
 
 // List returns paginated enrollments
 func (uc *UseCase) List(ctx context.Context, input ListInput) ([]domain.Enrollment, int64, error) {
+	return uc.enrollmentRepo.List(ctx, listFilters(input))
+}
+
+// ListAfter returns the enrollments after a cursor, most recent first, and
+// the cursor for the page after them
+func (uc *UseCase) ListAfter(ctx context.Context, input ListInput, cursor pagination.Cursor) ([]domain.Enrollment, string, error) {
+	filters := listFilters(input)
+	filters.Cursor = &cursor
+	enrollments, _, err := uc.enrollmentRepo.List(ctx, filters)
+	if err != nil {
+		return nil, "", err
+	}
+
+	enrollments, next := pagination.Next(enrollments, filters.Limit, func(e domain.Enrollment) pagination.Cursor {
+		return pagination.Cursor{Time: e.EnrolledAt, ID: e.ID}
+	})
+	return enrollments, next, nil
+}
+
+func listFilters(input ListInput) repository.EnrollmentFilters {
 	if input.Page < 1 {
 		input.Page = 1
 	}
@@ -55,13 +99,13 @@ func (uc *UseCase) List(ctx context.Context, input ListInput) ([]domain.Enrollme
 		input.Limit = 20
 	}
 
-	return uc.enrollmentRepo.List(ctx, repository.EnrollmentFilters{
+	return repository.EnrollmentFilters{
 		UserID:   input.UserID,
 		CourseID: input.CourseID,
 		Status:   input.Status,
 		Page:     input.Page,
 		Limit:    input.Limit,
-	})
+	}
 }
 
 // GetMyEnrollments returns current user's enrollments
@@ -113,11 +157,7 @@ type EnrollInput struct {
 
 // Enroll enrolls a user in a course
 func (uc *UseCase) Enroll(ctx context.Context, userID uuid.UUID, input EnrollInput) (*domain.Enrollment, error) {
-	// Check if already enrolled
 	existing, _ := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, input.CourseID)
-	if existing != nil {
-		return nil, domain.ErrAlreadyEnrolled
-	}
 
 	// Get course
 	course, err := uc.courseRepo.GetByID(ctx, input.CourseID)
@@ -125,21 +165,32 @@ func (uc *UseCase) Enroll(ctx context.Context, userID uuid.UUID, input EnrollInp
 		return nil, domain.ErrCourseNotFound
 	}
 
+	// Check if already enrolled
+	if existing != nil {
+		return uc.renewSubscriptionAccess(ctx, existing, course)
+	}
+
 	if course.Status != domain.CourseStatusPublished {
 		return nil, domain.ErrCourseNotPublished
 	}
 
 	// Determine enrollment status
 	status := domain.EnrollmentStatusActive
+	var subscriptionID *uuid.UUID
 	if course.Price > 0 {
-		// Paid course requires payment - handled separately
-		status = domain.EnrollmentStatusPending
+		if sub := uc.coveringSubscription(ctx, userID, course); sub != nil {
+			subscriptionID = &sub.ID
+		} else {
+			// Paid course requires payment - handled separately
+			status = domain.EnrollmentStatusPending
+		}
 	}
 
 	enrollment := &domain.Enrollment{
-		UserID:   userID,
-		CourseID: input.CourseID,
-		Status:   status,
+		UserID:         userID,
+		CourseID:       input.CourseID,
+		Status:         status,
+		SubscriptionID: subscriptionID,
 	}
 
 	if status == domain.EnrollmentStatusActive {
@@ -159,6 +210,170 @@ func (uc *UseCase) Enroll(ctx context.Context, userID uuid.UUID, input EnrollInp
 	return enrollment, nil
 }
 
+// EnrollWithCodeInput for enrolling with a course enrollment code
+type EnrollWithCodeInput struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// EnrollWithCode enrolls a user in the course that owns the given enrollment code.
+// The code acts as an invitation, so the enrollment is free and the course does
+// not need to be published; only archived courses are rejected.
+func (uc *UseCase) EnrollWithCode(ctx context.Context, userID uuid.UUID, code string) (*domain.Enrollment, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, domain.ErrInvalidEnrollmentCode
+	}
+
+	course, err := uc.courseRepo.GetByEnrollmentCode(ctx, code)
+	if err != nil {
+		if err == domain.ErrCourseNotFound {
+			return nil, domain.ErrInvalidEnrollmentCode
+		}
+		return nil, err
+	}
+
+	if course.Status == domain.CourseStatusArchived {
+		return nil, domain.ErrCourseNotPublished
+	}
+
+	existing, _ := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, course.ID)
+	if existing != nil {
+		return nil, domain.ErrAlreadyEnrolled
+	}
+
+	now := time.Now()
+	enrollment := &domain.Enrollment{
+		UserID:    userID,
+		CourseID:  course.ID,
+		Status:    domain.EnrollmentStatusActive,
+		StartedAt: &now,
+	}
+
+	if err := uc.enrollmentRepo.Create(ctx, enrollment); err != nil {
+		return nil, err
+	}
+
+	_ = uc.courseRepo.IncrementStudentCount(ctx, course.ID)
+
+	return enrollment, nil
+}
+
+// BulkEnrollInput for assigning course seats to several users
+type BulkEnrollInput struct {
+	CourseID uuid.UUID   `json:"course_id" validate:"required"`
+	UserIDs  []uuid.UUID `json:"user_ids" validate:"required,min=1,max=500"`
+}
+
+// Bulk enrollment result statuses
+const (
+	BulkEnrollStatusEnrolled = "enrolled"
+	BulkEnrollStatusSkipped  = "skipped"
+	BulkEnrollStatusFailed   = "failed"
+)
+
+// BulkEnrollResult is the outcome for a single user
+type BulkEnrollResult struct {
+	UserID       uuid.UUID  `json:"user_id"`
+	Status       string     `json:"status"`
+	EnrollmentID *uuid.UUID `json:"enrollment_id,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// BulkEnrollOutput summarizes a bulk enrollment
+type BulkEnrollOutput struct {
+	CourseID uuid.UUID          `json:"course_id"`
+	Enrolled int                `json:"enrolled"`
+	Skipped  int                `json:"skipped"`
+	Failed   int                `json:"failed"`
+	Results  []BulkEnrollResult `json:"results"`
+}
+
+// BulkEnroll creates active enrollments for a list of users, e.g. for corporate
+// seat assignment. Users with an active or completed enrollment are skipped and
+// pending or lapsed enrollments are reactivated. A failure for one user does not
+// stop the rest.
+func (uc *UseCase) BulkEnroll(ctx context.Context, courseID uuid.UUID, userIDs []uuid.UUID) (*BulkEnrollOutput, error) {
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status != domain.CourseStatusPublished {
+		return nil, domain.ErrCourseNotPublished
+	}
+
+	output := &BulkEnrollOutput{CourseID: courseID, Results: make([]BulkEnrollResult, 0, len(userIDs))}
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		result := uc.enrollSeat(ctx, courseID, userID)
+		switch result.Status {
+		case BulkEnrollStatusEnrolled:
+			output.Enrolled++
+		case BulkEnrollStatusSkipped:
+			output.Skipped++
+		default:
+			output.Failed++
+		}
+		output.Results = append(output.Results, result)
+	}
+
+	return output, nil
+}
+
+func (uc *UseCase) enrollSeat(ctx context.Context, courseID, userID uuid.UUID) BulkEnrollResult {
+	result := BulkEnrollResult{UserID: userID, Status: BulkEnrollStatusFailed}
+
+	if _, err := uc.userRepo.GetByID(ctx, userID); err != nil {
+		result.Error = domain.ErrUserNotFound.Error()
+		return result
+	}
+
+	now := time.Now()
+	existing, _ := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, courseID)
+	if existing != nil {
+		if existing.IsActive() || existing.IsCompleted() {
+			result.Status = BulkEnrollStatusSkipped
+			result.EnrollmentID = &existing.ID
+			result.Error = domain.ErrAlreadyEnrolled.Error()
+			return result
+		}
+
+		existing.Status = domain.EnrollmentStatusActive
+		existing.StartedAt = &now
+		existing.ExpiresAt = nil
+		if err := uc.enrollmentRepo.Update(ctx, existing); err != nil {
+			result.Error = "failed to activate enrollment"
+			return result
+		}
+		_ = uc.courseRepo.IncrementStudentCount(ctx, courseID)
+
+		result.Status = BulkEnrollStatusEnrolled
+		result.EnrollmentID = &existing.ID
+		return result
+	}
+
+	enrollment := &domain.Enrollment{
+		UserID:    userID,
+		CourseID:  courseID,
+		Status:    domain.EnrollmentStatusActive,
+		StartedAt: &now,
+	}
+	if err := uc.enrollmentRepo.Create(ctx, enrollment); err != nil {
+		result.Error = "failed to create enrollment"
+		return result
+	}
+	_ = uc.courseRepo.IncrementStudentCount(ctx, courseID)
+
+	result.Status = BulkEnrollStatusEnrolled
+	result.EnrollmentID = &enrollment.ID
+	return result
+}
+
 // ActivateEnrollment activates a pending enrollment (after payment)
 func (uc *UseCase) ActivateEnrollment(ctx context.Context, id uuid.UUID, orderID *uuid.UUID) error {
 	enrollment, err := uc.enrollmentRepo.GetByID(ctx, id)
@@ -179,7 +394,7 @@ func (uc *UseCase) ActivateEnrollment(ctx context.Context, id uuid.UUID, orderID
 	_ = uc.courseRepo.IncrementStudentCount(ctx, enrollment.CourseID)
 
 	// Send notification
-	_ = uc.notificationRepo.Create(ctx, &domain.Notification{
+	_ = uc.notifier.Notify(ctx, &domain.Notification{
 		UserID:  enrollment.UserID,
 		Type:    domain.NotificationEnrollmentApproved,
 		Title:   "Enrollment Confirmed",
@@ -207,12 +422,10 @@ func (uc *UseCase) Complete(ctx context.Context, id uuid.UUID) error {
 		return err
 	}
 
-	now := time.Now()
-	enrollment.Status = domain.EnrollmentStatusCompleted
-	enrollment.CompletedAt = &now
-	enrollment.Progress = 100
-
-	return uc.enrollmentRepo.Update(ctx, enrollment)
+	if enrollment.IsCompleted() {
+		return nil
+	}
+	return uc.completeEnrollment(ctx, enrollment)
 }
 
 // MarkLessonCompleteInput for marking lesson as complete
@@ -241,32 +454,31 @@ func (uc *UseCase) MarkLessonComplete(ctx context.Context, userID, courseID uuid
 		return domain.ErrNotEnrolled
 	}
 
+	if enrollment.IsPaused() {
+		return domain.ErrEnrollmentPaused
+	}
 	if !enrollment.CanAccess() {
 		return domain.ErrEnrollmentExpired
 	}
 
-	// Mark lesson as complete
-	if err := uc.progressRepo.MarkComplete(ctx, enrollment.ID, lessonID); err != nil {
-		return err
-	}
-
-	// Calculate new progress
-	progress, err := uc.calculateProgress(ctx, enrollment.ID, courseID)
+	lesson, err := uc.lessonRepo.GetByID(ctx, lessonID)
 	if err != nil {
 		return err
 	}
-
-	// Update enrollment progress
-	if err := uc.enrollmentRepo.UpdateProgress(ctx, enrollment.ID, progress); err != nil {
+	if lesson.Module == nil || lesson.Module.CourseID != courseID {
+		return domain.ErrLessonNotFound
+	}
+	if err := uc.lessonLock(ctx, enrollment, lesson); err != nil {
 		return err
 	}
 
-	// Check if course is completed
-	if progress >= 100 {
-		return uc.Complete(ctx, enrollment.ID)
+	// Mark lesson as complete
+	if err := uc.progressRepo.MarkComplete(ctx, enrollment.ID, lessonID); err != nil {
+		return err
 	}
 
-	return nil
+	_, err = uc.RecalculateProgress(ctx, enrollment)
+	return err
 }
 
 // UpdateVideoPositionInput for updating video position
@@ -282,6 +494,9 @@ func (uc *UseCase) UpdateVideoPosition(ctx context.Context, userID, courseID uui
 		return domain.ErrNotEnrolled
 	}
 
+	if enrollment.IsPaused() {
+		return domain.ErrEnrollmentPaused
+	}
 	if !enrollment.CanAccess() {
 		return domain.ErrEnrollmentExpired
 	}
@@ -294,40 +509,160 @@ func (uc *UseCase) GetProgress(ctx context.Context, enrollmentID uuid.UUID) ([]d
 	return uc.progressRepo.GetByEnrollment(ctx, enrollmentID)
 }
 
-// calculateProgress calculates completion percentage
-func (uc *UseCase) calculateProgress(ctx context.Context, enrollmentID, courseID uuid.UUID) (float64, error) {
-	// Get all lessons in course
-	course, err := uc.courseRepo.GetByID(ctx, courseID)
+// RecalculateProgress recomputes an enrollment's progress from its completed
+// published lessons and completes the enrollment once every lesson is done.
+// Completed enrollments keep their status even if lessons are added later.
+func (uc *UseCase) RecalculateProgress(ctx context.Context, enrollment *domain.Enrollment) (float64, error) {
+	if enrollment.IsCompleted() {
+		return enrollment.Progress, nil
+	}
+
+	completed, total, err := uc.progressRepo.CountCompletion(ctx, enrollment.ID, enrollment.CourseID)
 	if err != nil {
 		return 0, err
 	}
 
-	totalLessons := course.TotalLessons
-	if totalLessons == 0 {
-		return 100, nil
+	progress := ProgressPercent(completed, total)
+
+	if err := uc.enrollmentRepo.UpdateProgress(ctx, enrollment.ID, progress); err != nil {
+		return 0, err
+	}
+	enrollment.Progress = progress
+
+	if progress >= 100 {
+		if err := uc.completeEnrollment(ctx, enrollment); err != nil {
+			return 0, err
+		}
+	}
+	return progress, nil
+}
+
+// ProgressPercent returns completed/total as a percentage rounded to two
+// decimals. A course without published lessons has no progress to make.
+func ProgressPercent(completed, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	if completed >= total {
+		return 100
+	}
+	return math.Round(float64(completed)/float64(total)*10000) / 100
+}
+
+// completeEnrollment marks a finished enrollment completed, tells the learner
+// and issues a certificate when the course offers one
+func (uc *UseCase) completeEnrollment(ctx context.Context, enrollment *domain.Enrollment) error {
+	now := time.Now()
+	enrollment.Status = domain.EnrollmentStatusCompleted
+	enrollment.CompletedAt = &now
+	enrollment.Progress = 100
+
+	if err := uc.enrollmentRepo.Update(ctx, enrollment); err != nil {
+		return err
+	}
+
+	message := "Congratulations! You have completed the course."
+	course, _ := uc.courseRepo.GetByID(ctx, enrollment.CourseID)
+	if course != nil {
+		message = fmt.Sprintf("Congratulations! You have completed %s.", course.Title)
+	}
+	_ = uc.notifier.Notify(ctx, &domain.Notification{
+		UserID:  enrollment.UserID,
+		Type:    domain.NotificationCourseUpdate,
+		Title:   "Course Completed",
+		Message: stringPtr(message),
+	})
+
+	// The learner can still request the certificate if issuing fails here
+	if course != nil && course.CertificateEnabled {
+		_ = uc.issueCertificate(ctx, enrollment, course)
+	}
+
+	return nil
+}
+
+// issueCertificate creates the enrollment's certificate and emails it. It is a
+// no-op when one already exists, so completing again never duplicates it.
+func (uc *UseCase) issueCertificate(ctx context.Context, enrollment *domain.Enrollment, course *domain.Course) error {
+	if existing, _ := uc.certRepo.GetByEnrollment(ctx, enrollment.ID); existing != nil {
+		return nil
 	}
 
-	// Get completed lessons
-	progress, err := uc.progressRepo.GetByEnrollment(ctx, enrollmentID)
+	cert := &domain.Certificate{
+		EnrollmentID:      enrollment.ID,
+		CertificateNumber: domain.GenerateCertificateNumber(),
+	}
+	if err := uc.certRepo.Create(ctx, cert); err != nil {
+		return err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, enrollment.UserID)
+	if err != nil || user == nil || uc.emailSvc == nil {
+		return nil
+	}
+	if !uc.notifier.EmailsNow(ctx, user.ID, domain.NotificationCategoryEnrollment) {
+		return nil
+	}
+	verifyURL := uc.emailSvc.AppLink("/certificates/verify/" + cert.CertificateNumber)
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		_ = uc.emailSvc.SendCertificate(ctx, user.Email, user.FirstName, course.Title, cert.CertificateNumber, verifyURL)
+	}()
+
+	return nil
+}
+
+// CheckLessonAccess returns nil if the user may open the lesson. Learners
+// get ErrNotEnrolled, an enrollment state error, or a *domain.LessonLock when
+// a prerequisite or drip date is holding the lesson back. Previews and the
+// course owner are never locked.
+func (uc *UseCase) CheckLessonAccess(ctx context.Context, userID, lessonID uuid.UUID) error {
+	lesson, err := uc.lessonRepo.GetByID(ctx, lessonID)
 	if err != nil {
-		return 0, err
+		return err
+	}
+	if lesson.IsPreview {
+		return nil
+	}
+	if lesson.Module == nil {
+		return domain.ErrLessonNotFound
 	}
 
-	completedCount := 0
-	for _, p := range progress {
-		if p.IsCompleted {
-			completedCount++
+	course, err := uc.courseRepo.GetByID(ctx, lesson.Module.CourseID)
+	if err == nil && course.InstructorID == userID {
+		return nil
+	}
+
+	enrollment, err := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, lesson.Module.CourseID)
+	if err != nil || enrollment == nil {
+		// Subscribers can open covered courses before enrolling
+		if uc.coveringSubscription(ctx, userID, course) != nil {
+			return nil
 		}
+		return domain.ErrNotEnrolled
+	}
+	if enrollment.IsPaused() {
+		return domain.ErrEnrollmentPaused
+	}
+	if !enrollment.CanAccess() && uc.coveringSubscription(ctx, userID, course) == nil {
+		return domain.ErrEnrollmentExpired
 	}
 
-	return float64(completedCount) / float64(totalLessons) * 100, nil
+	return uc.lessonLock(ctx, enrollment, lesson)
 }
 
-// CanAccessLesson checks if user can access a specific lesson
-func (uc *UseCase) CanAccessLesson(ctx context.Context, userID, lessonID uuid.UUID) (bool, error) {
-	// TODO: Get lesson and check access type
-	// For now, check if enrolled in the lesson's course
-	return true, nil
+// lessonLock returns the lesson's lock for an enrollment, or nil if it's open
+func (uc *UseCase) lessonLock(ctx context.Context, enrollment *domain.Enrollment, lesson *domain.Lesson) error {
+	prerequisiteDone := true
+	if lesson.PrerequisiteLessonID != nil {
+		progress, err := uc.progressRepo.GetByEnrollmentAndLesson(ctx, enrollment.ID, *lesson.PrerequisiteLessonID)
+		prerequisiteDone = err == nil && progress != nil && progress.IsCompleted
+	}
+
+	if lock := lesson.LockFor(enrollment.EnrolledAt, prerequisiteDone, time.Now()); lock != nil {
+		return lock
+	}
+	return nil
 }
 
 func stringPtr(s string) *string {
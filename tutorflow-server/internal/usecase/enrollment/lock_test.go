@@ -0,0 +1,76 @@
+package enrollment_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/enrollment"
+)
+
+func TestLesson_LockFor(t *testing.T) {
+	enrolledAt := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	prereq := uuid.New()
+	days := 7
+
+	t.Run("unlocked by default", func(t *testing.T) {
+		l := &domain.Lesson{}
+		assert.Nil(t, l.LockFor(enrolledAt, false, enrolledAt))
+	})
+
+	t.Run("prerequisite", func(t *testing.T) {
+		l := &domain.Lesson{PrerequisiteLessonID: &prereq}
+		lock := l.LockFor(enrolledAt, false, enrolledAt)
+		if assert.NotNil(t, lock) {
+			assert.Equal(t, domain.LessonLockPrerequisite, lock.Reason)
+			assert.Equal(t, &prereq, lock.PrerequisiteLessonID)
+			assert.True(t, errors.Is(lock, domain.ErrContentLocked))
+		}
+		assert.Nil(t, l.LockFor(enrolledAt, true, enrolledAt))
+	})
+
+	t.Run("drip", func(t *testing.T) {
+		l := &domain.Lesson{DripDaysAfterEnrollment: &days}
+		lock := l.LockFor(enrolledAt, true, enrolledAt.AddDate(0, 0, 6))
+		if assert.NotNil(t, lock) {
+			assert.Equal(t, domain.LessonLockDrip, lock.Reason)
+			assert.Equal(t, enrolledAt.AddDate(0, 0, 7), *lock.UnlocksAt)
+		}
+		assert.Nil(t, l.LockFor(enrolledAt, true, enrolledAt.AddDate(0, 0, 7)))
+	})
+
+	t.Run("previews never lock", func(t *testing.T) {
+		l := &domain.Lesson{IsPreview: true, PrerequisiteLessonID: &prereq, DripDaysAfterEnrollment: &days}
+		assert.Nil(t, l.LockFor(enrolledAt, false, enrolledAt))
+	})
+}
+
+func TestEnrollmentUseCase_MarkLessonComplete_Locked(t *testing.T) {
+	ctx := context.Background()
+	userID, courseID, prereq := uuid.New(), uuid.New(), uuid.New()
+	lesson := &domain.Lesson{ID: uuid.New(), PrerequisiteLessonID: &prereq, Module: &domain.Module{CourseID: courseID}}
+	enroll := &domain.Enrollment{ID: uuid.New(), Status: domain.EnrollmentStatusActive, EnrolledAt: time.Now()}
+
+	enrollRepo := new(MockEnrollmentRepository)
+	lessonRepo := new(MockLessonRepository)
+	progressRepo := new(MockLessonProgressRepository)
+	enrollRepo.On("GetByUserAndCourse", ctx, userID, courseID).Return(enroll, nil)
+	lessonRepo.On("GetByID", ctx, lesson.ID).Return(lesson, nil)
+	progressRepo.On("GetByEnrollmentAndLesson", ctx, enroll.ID, prereq).
+		Return(&domain.LessonProgress{IsCompleted: false}, nil)
+
+	uc := enrollment.NewUseCase(enrollRepo, progressRepo, nil, lessonRepo, nil, nil, nil, nil, nil, nil, config.EnrollmentConfig{})
+
+	err := uc.MarkLessonComplete(ctx, userID, courseID, lesson.ID)
+	var lock *domain.LessonLock
+	if assert.ErrorAs(t, err, &lock) {
+		assert.Equal(t, domain.LessonLockPrerequisite, lock.Reason)
+	}
+	progressRepo.AssertNotCalled(t, "MarkComplete", mock.Anything, mock.Anything, mock.Anything)
+}
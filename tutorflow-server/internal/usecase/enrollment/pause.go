@@ -0,0 +1,87 @@
+package enrollment
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// PauseEnrollment freezes the access clock on a time-limited enrollment.
+// Learners pausing their own enrollment are bound by the pause policy;
+// admins and managers are not.
+func (uc *UseCase) PauseEnrollment(ctx context.Context, id uuid.UUID, role domain.UserRole) (*domain.Enrollment, error) {
+	enrollment, err := uc.enrollmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if enrollment.IsPaused() {
+		return nil, domain.ErrEnrollmentPaused
+	}
+	// Lifetime access has no clock to freeze
+	if !enrollment.IsActive() || enrollment.ExpiresAt == nil {
+		return nil, domain.ErrPauseNotAllowed
+	}
+	if enrollment.IsExpired() {
+		return nil, domain.ErrEnrollmentExpired
+	}
+
+	if !isStaff(role) {
+		if !uc.cfg.AllowSelfPause {
+			return nil, domain.ErrPauseNotAllowed
+		}
+		if remaining, limited := uc.pauseAllowance(enrollment); limited && remaining <= 0 {
+			return nil, domain.ErrPauseNotAllowed
+		}
+	}
+
+	enrollment.Pause(time.Now())
+	if err := uc.enrollmentRepo.Update(ctx, enrollment); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+// ResumeEnrollment restarts the access clock and extends ExpiresAt by the
+// time spent paused. Learners get back at most their remaining allowance.
+func (uc *UseCase) ResumeEnrollment(ctx context.Context, id uuid.UUID, role domain.UserRole) (*domain.Enrollment, error) {
+	enrollment, err := uc.enrollmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enrollment.IsPaused() {
+		return nil, domain.ErrEnrollmentNotPaused
+	}
+
+	maxCredit := time.Duration(math.MaxInt64)
+	if !isStaff(role) {
+		if remaining, limited := uc.pauseAllowance(enrollment); limited {
+			maxCredit = remaining
+		}
+	}
+
+	enrollment.Resume(time.Now(), maxCredit)
+	if err := uc.enrollmentRepo.Update(ctx, enrollment); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+// pauseAllowance returns how much pause time the learner can still get back,
+// and false when the policy sets no limit
+func (uc *UseCase) pauseAllowance(enrollment *domain.Enrollment) (time.Duration, bool) {
+	if uc.cfg.MaxPauseDuration <= 0 {
+		return 0, false
+	}
+	used := time.Duration(enrollment.PausedSeconds) * time.Second
+	return uc.cfg.MaxPauseDuration - used, true
+}
+
+func isStaff(role domain.UserRole) bool {
+	return role == domain.RoleAdmin || role == domain.RoleManager
+}
@@ -0,0 +1,169 @@
+package enrollment_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/enrollment"
+)
+
+// MockEnrollmentRepository is a mock implementation of EnrollmentRepository
+type MockEnrollmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockEnrollmentRepository) Create(ctx context.Context, e *domain.Enrollment) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockEnrollmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Enrollment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.Enrollment), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetByUserAndCourse(ctx context.Context, userID, courseID uuid.UUID) (*domain.Enrollment, error) {
+	args := m.Called(ctx, userID, courseID)
+	return args.Get(0).(*domain.Enrollment), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) Update(ctx context.Context, e *domain.Enrollment) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockEnrollmentRepository) List(ctx context.Context, filters repository.EnrollmentFilters) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, courseID, page, limit)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) UpdateProgress(ctx context.Context, id uuid.UUID, progress float64) error {
+	return m.Called(ctx, id, progress).Error(0)
+}
+
+func (m *MockEnrollmentRepository) GetStats(ctx context.Context, userID uuid.UUID) (*domain.StudentDashboardStats, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*domain.StudentDashboardStats), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) HasInstructor(ctx context.Context, userID, instructorID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID, instructorID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetCourseFunnel(ctx context.Context, courseID uuid.UUID) (*domain.CourseFunnel, error) {
+	args := m.Called(ctx, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CourseFunnel), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetAtRisk(ctx context.Context, courseID uuid.UUID, inactiveSince time.Time, maxProgress float64) ([]domain.AtRiskStudent, error) {
+	args := m.Called(ctx, courseID, inactiveSince, maxProgress)
+	return args.Get(0).([]domain.AtRiskStudent), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) MarkNudged(ctx context.Context, ids []uuid.UUID, now, cooldownSince time.Time) ([]uuid.UUID, error) {
+	args := m.Called(ctx, ids, now, cooldownSince)
+	claimed, _ := args.Get(0).([]uuid.UUID)
+	return claimed, args.Error(1)
+}
+
+func TestEnrollment_PauseGivesTimeBack(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := start.Add(30 * 24 * time.Hour)
+	e := &domain.Enrollment{Status: domain.EnrollmentStatusActive, ExpiresAt: &expiresAt}
+
+	e.Pause(start.Add(10 * 24 * time.Hour))
+	assert.False(t, e.CanAccess(), "paused enrollments cannot access content")
+	assert.False(t, e.IsExpired(), "the clock is frozen while paused")
+
+	week := 7 * 24 * time.Hour
+	credited := e.Resume(start.Add(10*24*time.Hour+week), time.Duration(math.MaxInt64))
+
+	assert.Equal(t, week, credited)
+	assert.Equal(t, start.Add(37*24*time.Hour), *e.ExpiresAt)
+	assert.Equal(t, int64(week/time.Second), e.PausedSeconds)
+	assert.False(t, e.IsPaused())
+}
+
+func TestEnrollment_ResumeCapsCredit(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(24 * time.Hour)
+	pausedAt := now.Add(-10 * 24 * time.Hour)
+	e := &domain.Enrollment{Status: domain.EnrollmentStatusActive, ExpiresAt: &expiresAt, PausedAt: &pausedAt}
+
+	credited := e.Resume(now, 2*24*time.Hour)
+	assert.Equal(t, 2*24*time.Hour, credited)
+	assert.Equal(t, now.Add(3*24*time.Hour), *e.ExpiresAt)
+}
+
+func TestEnrollmentUseCase_PauseEnrollment_Policy(t *testing.T) {
+	expiresAt := time.Now().Add(30 * 24 * time.Hour)
+	id := uuid.New()
+
+	newEnrollment := func() *domain.Enrollment {
+		return &domain.Enrollment{ID: id, Status: domain.EnrollmentStatusActive, ExpiresAt: &expiresAt}
+	}
+
+	t.Run("self pause disabled", func(t *testing.T) {
+		mockRepo := new(MockEnrollmentRepository)
+		uc := enrollment.NewUseCase(mockRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, config.EnrollmentConfig{})
+		mockRepo.On("GetByID", mock.Anything, id).Return(newEnrollment(), nil)
+
+		_, err := uc.PauseEnrollment(context.Background(), id, domain.RoleStudent)
+		assert.ErrorIs(t, err, domain.ErrPauseNotAllowed)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("admin bypasses policy", func(t *testing.T) {
+		mockRepo := new(MockEnrollmentRepository)
+		uc := enrollment.NewUseCase(mockRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, config.EnrollmentConfig{})
+		mockRepo.On("GetByID", mock.Anything, id).Return(newEnrollment(), nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		paused, err := uc.PauseEnrollment(context.Background(), id, domain.RoleAdmin)
+		assert.NoError(t, err)
+		assert.True(t, paused.IsPaused())
+	})
+
+	t.Run("allowance used up", func(t *testing.T) {
+		mockRepo := new(MockEnrollmentRepository)
+		uc := enrollment.NewUseCase(mockRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, config.EnrollmentConfig{
+			AllowSelfPause:   true,
+			MaxPauseDuration: 7 * 24 * time.Hour,
+		})
+		used := newEnrollment()
+		used.PausedSeconds = int64(7 * 24 * time.Hour / time.Second)
+		mockRepo.On("GetByID", mock.Anything, id).Return(used, nil)
+
+		_, err := uc.PauseEnrollment(context.Background(), id, domain.RoleStudent)
+		assert.ErrorIs(t, err, domain.ErrPauseNotAllowed)
+	})
+
+	t.Run("lifetime access", func(t *testing.T) {
+		mockRepo := new(MockEnrollmentRepository)
+		uc := enrollment.NewUseCase(mockRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, config.EnrollmentConfig{AllowSelfPause: true})
+		mockRepo.On("GetByID", mock.Anything, id).Return(&domain.Enrollment{ID: id, Status: domain.EnrollmentStatusActive}, nil)
+
+		_, err := uc.PauseEnrollment(context.Background(), id, domain.RoleStudent)
+		assert.ErrorIs(t, err, domain.ErrPauseNotAllowed)
+	})
+}
@@ -0,0 +1,95 @@
+package enrollment
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// SaveLessonPositionInput for saving where a learner is in a lesson video
+type SaveLessonPositionInput struct {
+	Position int `json:"position" validate:"gte=0"`
+}
+
+// LessonPosition is the stored playback position for a lesson. Saved is false
+// when a write was skipped because the previous one was too recent.
+type LessonPosition struct {
+	LessonID  uuid.UUID  `json:"lesson_id"`
+	Position  int        `json:"position"` // seconds
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Saved     bool       `json:"saved"`
+}
+
+// SaveLessonPosition records the video position for the user's enrollment in
+// the lesson's course. Players report position every few seconds, so writes
+// closer together than the configured interval are dropped; the position is
+// then stale by at most that interval when resuming on another device.
+func (uc *UseCase) SaveLessonPosition(ctx context.Context, userID, lessonID uuid.UUID, position int) (*LessonPosition, error) {
+	lesson, enrollment, err := uc.lessonEnrollment(ctx, userID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enrollment.IsPaused() {
+		return nil, domain.ErrEnrollmentPaused
+	}
+	if !enrollment.CanAccess() {
+		return nil, domain.ErrEnrollmentExpired
+	}
+	if err := uc.lessonLock(ctx, enrollment, lesson); err != nil {
+		return nil, err
+	}
+
+	if lesson.VideoDuration != nil && *lesson.VideoDuration > 0 && position > *lesson.VideoDuration {
+		position = *lesson.VideoDuration
+	}
+
+	if existing, err := uc.progressRepo.GetByEnrollmentAndLesson(ctx, enrollment.ID, lessonID); err == nil && existing != nil {
+		if uc.cfg.PositionSaveInterval > 0 && time.Since(existing.UpdatedAt) < uc.cfg.PositionSaveInterval {
+			return &LessonPosition{LessonID: lessonID, Position: existing.VideoPosition, UpdatedAt: &existing.UpdatedAt}, nil
+		}
+	}
+
+	if err := uc.progressRepo.UpdateVideoPosition(ctx, enrollment.ID, lessonID, position); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &LessonPosition{LessonID: lessonID, Position: position, UpdatedAt: &now, Saved: true}, nil
+}
+
+// GetLessonPosition returns the last saved video position for a lesson, or
+// zero when the learner hasn't started it
+func (uc *UseCase) GetLessonPosition(ctx context.Context, userID, lessonID uuid.UUID) (*LessonPosition, error) {
+	_, enrollment, err := uc.lessonEnrollment(ctx, userID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LessonPosition{LessonID: lessonID}
+	if progress, err := uc.progressRepo.GetByEnrollmentAndLesson(ctx, enrollment.ID, lessonID); err == nil && progress != nil {
+		result.Position = progress.VideoPosition
+		result.UpdatedAt = &progress.UpdatedAt
+	}
+	return result, nil
+}
+
+// lessonEnrollment loads a lesson and the user's enrollment in its course
+func (uc *UseCase) lessonEnrollment(ctx context.Context, userID, lessonID uuid.UUID) (*domain.Lesson, *domain.Enrollment, error) {
+	lesson, err := uc.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if lesson.Module == nil {
+		return nil, nil, domain.ErrLessonNotFound
+	}
+
+	enrollment, err := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, lesson.Module.CourseID)
+	if err != nil || enrollment == nil {
+		return nil, nil, domain.ErrNotEnrolled
+	}
+	return lesson, enrollment, nil
+}
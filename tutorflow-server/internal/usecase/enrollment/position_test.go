@@ -0,0 +1,156 @@
+package enrollment_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/enrollment"
+	"gorm.io/gorm"
+)
+
+// MockLessonRepository is a mock implementation of LessonRepository
+type MockLessonRepository struct {
+	mock.Mock
+}
+
+func (m *MockLessonRepository) Create(ctx context.Context, l *domain.Lesson) error {
+	return m.Called(ctx, l).Error(0)
+}
+
+func (m *MockLessonRepository) CreateBatch(ctx context.Context, lessons []domain.Lesson) error {
+	return m.Called(ctx, lessons).Error(0)
+}
+
+func (m *MockLessonRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Lesson, error) {
+	args := m.Called(ctx, id)
+	l, _ := args.Get(0).(*domain.Lesson)
+	return l, args.Error(1)
+}
+
+func (m *MockLessonRepository) Update(ctx context.Context, l *domain.Lesson) error {
+	return m.Called(ctx, l).Error(0)
+}
+
+func (m *MockLessonRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockLessonRepository) DeleteByModule(ctx context.Context, moduleID uuid.UUID) error {
+	return m.Called(ctx, moduleID).Error(0)
+}
+
+func (m *MockLessonRepository) GetByModule(ctx context.Context, moduleID uuid.UUID) ([]domain.Lesson, error) {
+	args := m.Called(ctx, moduleID)
+	return args.Get(0).([]domain.Lesson), args.Error(1)
+}
+
+func (m *MockLessonRepository) Reorder(ctx context.Context, moduleID uuid.UUID, lessonIDs []uuid.UUID) error {
+	return m.Called(ctx, moduleID, lessonIDs).Error(0)
+}
+
+// MockLessonProgressRepository is a mock implementation of LessonProgressRepository
+type MockLessonProgressRepository struct {
+	mock.Mock
+}
+
+func (m *MockLessonProgressRepository) Upsert(ctx context.Context, p *domain.LessonProgress) error {
+	return m.Called(ctx, p).Error(0)
+}
+
+func (m *MockLessonProgressRepository) GetByEnrollmentAndLesson(ctx context.Context, enrollmentID, lessonID uuid.UUID) (*domain.LessonProgress, error) {
+	args := m.Called(ctx, enrollmentID, lessonID)
+	p, _ := args.Get(0).(*domain.LessonProgress)
+	return p, args.Error(1)
+}
+
+func (m *MockLessonProgressRepository) GetByEnrollment(ctx context.Context, enrollmentID uuid.UUID) ([]domain.LessonProgress, error) {
+	args := m.Called(ctx, enrollmentID)
+	return args.Get(0).([]domain.LessonProgress), args.Error(1)
+}
+
+func (m *MockLessonProgressRepository) MarkComplete(ctx context.Context, enrollmentID, lessonID uuid.UUID) error {
+	return m.Called(ctx, enrollmentID, lessonID).Error(0)
+}
+
+func (m *MockLessonProgressRepository) UpdateVideoPosition(ctx context.Context, enrollmentID, lessonID uuid.UUID, position int) error {
+	return m.Called(ctx, enrollmentID, lessonID, position).Error(0)
+}
+
+func (m *MockLessonProgressRepository) CountCompletion(ctx context.Context, enrollmentID, courseID uuid.UUID) (int64, int64, error) {
+	args := m.Called(ctx, enrollmentID, courseID)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+func TestEnrollmentUseCase_SaveLessonPosition(t *testing.T) {
+	ctx := context.Background()
+	userID, courseID := uuid.New(), uuid.New()
+	duration := 600
+	lesson := &domain.Lesson{ID: uuid.New(), VideoDuration: &duration, Module: &domain.Module{CourseID: courseID}}
+	enroll := &domain.Enrollment{ID: uuid.New(), Status: domain.EnrollmentStatusActive}
+	cfg := config.EnrollmentConfig{PositionSaveInterval: 10 * time.Second}
+
+	setup := func(progress *domain.LessonProgress) (*enrollment.UseCase, *MockLessonProgressRepository) {
+		enrollRepo := new(MockEnrollmentRepository)
+		lessonRepo := new(MockLessonRepository)
+		progressRepo := new(MockLessonProgressRepository)
+		lessonRepo.On("GetByID", ctx, lesson.ID).Return(lesson, nil)
+		enrollRepo.On("GetByUserAndCourse", ctx, userID, courseID).Return(enroll, nil)
+		progressRepo.On("GetByEnrollmentAndLesson", ctx, enroll.ID, lesson.ID).Return(progress, nil)
+		return enrollment.NewUseCase(enrollRepo, progressRepo, nil, lessonRepo, nil, nil, nil, nil, nil, nil, cfg), progressRepo
+	}
+
+	t.Run("writes and clamps to the video length", func(t *testing.T) {
+		uc, progressRepo := setup(nil)
+		progressRepo.On("UpdateVideoPosition", ctx, enroll.ID, lesson.ID, duration).Return(nil)
+
+		pos, err := uc.SaveLessonPosition(ctx, userID, lesson.ID, 900)
+		assert.NoError(t, err)
+		assert.True(t, pos.Saved)
+		assert.Equal(t, duration, pos.Position)
+		progressRepo.AssertExpectations(t)
+	})
+
+	t.Run("skips writes inside the interval", func(t *testing.T) {
+		uc, progressRepo := setup(&domain.LessonProgress{VideoPosition: 120, UpdatedAt: time.Now().Add(-3 * time.Second)})
+
+		pos, err := uc.SaveLessonPosition(ctx, userID, lesson.ID, 125)
+		assert.NoError(t, err)
+		assert.False(t, pos.Saved)
+		assert.Equal(t, 120, pos.Position, "the stored position is returned")
+		progressRepo.AssertNotCalled(t, "UpdateVideoPosition", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("writes once the interval has passed", func(t *testing.T) {
+		uc, progressRepo := setup(&domain.LessonProgress{VideoPosition: 120, UpdatedAt: time.Now().Add(-time.Minute)})
+		progressRepo.On("UpdateVideoPosition", ctx, enroll.ID, lesson.ID, 180).Return(nil)
+
+		pos, err := uc.SaveLessonPosition(ctx, userID, lesson.ID, 180)
+		assert.NoError(t, err)
+		assert.True(t, pos.Saved)
+		progressRepo.AssertExpectations(t)
+	})
+}
+
+func TestEnrollmentUseCase_LessonPosition_NotEnrolled(t *testing.T) {
+	ctx := context.Background()
+	userID, courseID := uuid.New(), uuid.New()
+	lesson := &domain.Lesson{ID: uuid.New(), Module: &domain.Module{CourseID: courseID}}
+
+	enrollRepo := new(MockEnrollmentRepository)
+	lessonRepo := new(MockLessonRepository)
+	lessonRepo.On("GetByID", ctx, lesson.ID).Return(lesson, nil)
+	enrollRepo.On("GetByUserAndCourse", ctx, userID, courseID).Return((*domain.Enrollment)(nil), gorm.ErrRecordNotFound)
+	uc := enrollment.NewUseCase(enrollRepo, nil, nil, lessonRepo, nil, nil, nil, nil, nil, nil, config.EnrollmentConfig{})
+
+	_, err := uc.GetLessonPosition(ctx, userID, lesson.ID)
+	assert.ErrorIs(t, err, domain.ErrNotEnrolled)
+
+	_, err = uc.SaveLessonPosition(ctx, userID, lesson.ID, 10)
+	assert.ErrorIs(t, err, domain.ErrNotEnrolled)
+}
@@ -0,0 +1,17 @@
+package enrollment_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/enrollment"
+)
+
+func TestProgressPercent(t *testing.T) {
+	assert.Equal(t, 0.0, enrollment.ProgressPercent(0, 0), "courses without lessons must not divide by zero")
+	assert.Equal(t, 0.0, enrollment.ProgressPercent(0, 12))
+	assert.Equal(t, 33.33, enrollment.ProgressPercent(1, 3))
+	assert.Equal(t, 66.67, enrollment.ProgressPercent(2, 3))
+	assert.Equal(t, 100.0, enrollment.ProgressPercent(3, 3))
+	assert.Equal(t, 100.0, enrollment.ProgressPercent(4, 3), "progress is capped at 100")
+}
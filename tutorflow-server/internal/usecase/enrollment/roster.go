@@ -0,0 +1,273 @@
+package enrollment
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"net/mail"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+)
+
+const (
+	// MaxRosterFileSize caps the size of an uploaded roster CSV
+	MaxRosterFileSize = 1 << 20
+	// maxRosterRows caps how many students one import may create or enroll
+	maxRosterRows = 1000
+)
+
+// Roster import account statuses
+const (
+	RosterAccountCreated  = "created"
+	RosterAccountExisting = "existing"
+	RosterAccountFailed   = "failed"
+)
+
+// RosterRow is a parsed roster line
+type RosterRow struct {
+	Line      int
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+// RosterRowResult is the outcome of importing a single roster line
+type RosterRowResult struct {
+	Line       int        `json:"line"`
+	Email      string     `json:"email"`
+	Account    string     `json:"account"`
+	Enrollment string     `json:"enrollment,omitempty"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// RosterImportReport summarizes a roster import
+type RosterImportReport struct {
+	CourseID uuid.UUID         `json:"course_id"`
+	Created  int               `json:"created"`
+	Existing int               `json:"existing"`
+	Failed   int               `json:"failed"`
+	Enrolled int               `json:"enrolled"`
+	Rows     []RosterRowResult `json:"rows"`
+}
+
+// ParseRoster reads a roster CSV. Columns are email, first name and last name;
+// names are optional. A first row containing an "email" cell is a header and
+// lets the columns appear in any order, with "name" accepted as a full name.
+func ParseRoster(r io.Reader) ([]RosterRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	emailCol, firstCol, lastCol, nameCol := 0, 1, 2, -1
+	var rows []RosterRow
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, rosterError("Invalid CSV file")
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first && isRosterHeader(record) {
+			emailCol, firstCol, lastCol = -1, -1, -1
+			for i, h := range record {
+				switch headerName(h) {
+				case "email":
+					emailCol = i
+				case "first_name", "first name", "firstname":
+					firstCol = i
+				case "last_name", "last name", "lastname":
+					lastCol = i
+				case "name", "full_name", "full name":
+					nameCol = i
+				}
+			}
+			continue
+		}
+
+		row := RosterRow{
+			Line:      line,
+			Email:     strings.ToLower(column(record, emailCol)),
+			FirstName: column(record, firstCol),
+			LastName:  column(record, lastCol),
+		}
+		if full := column(record, nameCol); full != "" && row.FirstName == "" && row.LastName == "" {
+			parts := strings.Fields(full)
+			row.FirstName = parts[0]
+			row.LastName = strings.Join(parts[1:], " ")
+		}
+
+		// Skip rows of empty cells that spreadsheet exports often leave at the end
+		if row.Email == "" && row.FirstName == "" && row.LastName == "" {
+			continue
+		}
+
+		rows = append(rows, row)
+		if len(rows) > maxRosterRows {
+			return nil, rosterError("Roster has too many rows")
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil, rosterError("Roster is empty")
+	}
+	return rows, nil
+}
+
+func isRosterHeader(record []string) bool {
+	for _, h := range record {
+		if headerName(h) == "email" {
+			return true
+		}
+	}
+	return false
+}
+
+func headerName(h string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+}
+
+func rosterError(message string) error {
+	return domain.ValidationErrors{{Field: "file", Message: message}}
+}
+
+func column(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// ValidEmail reports whether s is a bare email address
+func ValidEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && strings.Contains(s[strings.LastIndex(s, "@"):], ".")
+}
+
+// ImportRoster creates accounts for unknown emails and enrolls every listed
+// student in the course. New users get a random password and an email to set
+// their own. Invalid rows are reported without stopping the import.
+func (uc *UseCase) ImportRoster(ctx context.Context, courseID uuid.UUID, r io.Reader) (*RosterImportReport, error) {
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status != domain.CourseStatusPublished {
+		return nil, domain.ErrCourseNotPublished
+	}
+
+	rows, err := ParseRoster(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RosterImportReport{CourseID: courseID, Rows: make([]RosterRowResult, 0, len(rows))}
+	seen := make(map[string]bool, len(rows))
+	var created []*domain.User
+
+	for _, row := range rows {
+		result := RosterRowResult{Line: row.Line, Email: row.Email, Account: RosterAccountFailed}
+
+		switch {
+		case !ValidEmail(row.Email):
+			result.Error = "invalid email address"
+		case seen[row.Email]:
+			result.Error = "duplicate email in file"
+		default:
+			seen[row.Email] = true
+
+			user, isNew, err := uc.findOrCreateStudent(ctx, row)
+			if err != nil {
+				result.Error = "failed to create account"
+				break
+			}
+
+			result.UserID = &user.ID
+			result.Account = RosterAccountExisting
+			if isNew {
+				result.Account = RosterAccountCreated
+				created = append(created, user)
+			}
+
+			seat := uc.enrollSeat(ctx, courseID, user.ID)
+			result.Enrollment = seat.Status
+			if seat.Status == BulkEnrollStatusFailed {
+				result.Error = seat.Error
+			}
+			if seat.Status == BulkEnrollStatusEnrolled {
+				report.Enrolled++
+			}
+		}
+
+		switch result.Account {
+		case RosterAccountCreated:
+			report.Created++
+		case RosterAccountExisting:
+			report.Existing++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	// Send invitations off the request path; SMTP is slow for large rosters
+	if len(created) > 0 && uc.emailSvc != nil {
+		ctx := context.WithoutCancel(ctx)
+		go func() {
+			for _, user := range created {
+				_ = uc.emailSvc.SendAccountSetup(ctx, user.Email, user.FirstName)
+			}
+		}()
+	}
+
+	return report, nil
+}
+
+func (uc *UseCase) findOrCreateStudent(ctx context.Context, row RosterRow) (*domain.User, bool, error) {
+	if existing, _ := uc.userRepo.GetByEmail(ctx, row.Email); existing != nil {
+		return existing, false, nil
+	}
+
+	// A random value that is not a bcrypt hash can never match a login, so the
+	// account stays locked until the user sets a password via the setup email.
+	// Skipping bcrypt keeps large imports fast.
+	token, err := hash.GenerateRandomToken(32)
+	if err != nil {
+		return nil, false, err
+	}
+	passwordHash := "!" + token
+
+	firstName, lastName := row.FirstName, row.LastName
+	if firstName == "" {
+		firstName = row.Email[:strings.Index(row.Email, "@")]
+	}
+
+	user := &domain.User{
+		Email:        row.Email,
+		PasswordHash: passwordHash,
+		FirstName:    truncate(firstName, 100),
+		LastName:     truncate(lastName, 100),
+		Role:         domain.RoleStudent,
+		Status:       domain.StatusActive,
+	}
+	if err := uc.userRepo.Create(ctx, user); err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) > max {
+		return string(runes[:max])
+	}
+	return s
+}
@@ -0,0 +1,43 @@
+package enrollment_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/enrollment"
+)
+
+func TestParseRoster_WithHeader(t *testing.T) {
+	csv := "\ufeffName,Email\nJane Doe,Jane@Example.com\n\n,bob@example.com\n"
+
+	rows, err := enrollment.ParseRoster(strings.NewReader(csv))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "jane@example.com", rows[0].Email)
+	assert.Equal(t, "Jane", rows[0].FirstName)
+	assert.Equal(t, "Doe", rows[0].LastName)
+	assert.Equal(t, 4, rows[1].Line)
+}
+
+func TestParseRoster_Positional(t *testing.T) {
+	rows, err := enrollment.ParseRoster(strings.NewReader("ann@example.com,Ann,Lee\nnot-an-email\n"))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "Ann", rows[0].FirstName)
+	assert.Equal(t, "Lee", rows[0].LastName)
+	assert.False(t, enrollment.ValidEmail(rows[1].Email))
+}
+
+func TestParseRoster_Empty(t *testing.T) {
+	_, err := enrollment.ParseRoster(strings.NewReader("email\n"))
+	assert.IsType(t, domain.ValidationErrors{}, err)
+}
+
+func TestValidEmail(t *testing.T) {
+	assert.True(t, enrollment.ValidEmail("user@example.com"))
+	assert.False(t, enrollment.ValidEmail("User <user@example.com>"))
+	assert.False(t, enrollment.ValidEmail("user@localhost"))
+	assert.False(t, enrollment.ValidEmail(""))
+}
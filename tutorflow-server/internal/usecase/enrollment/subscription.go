@@ -0,0 +1,51 @@
+package enrollment
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+)
+
+// coveringSubscription returns the user's subscription if it currently
+// includes the course, or nil
+func (uc *UseCase) coveringSubscription(ctx context.Context, userID uuid.UUID, course *domain.Course) *domain.Subscription {
+	if course == nil {
+		return nil
+	}
+	sub, err := uc.subscriptionRepo.GetActiveByUserID(ctx, userID)
+	if err != nil || sub == nil || sub.Plan == nil {
+		return nil
+	}
+	if !sub.GrantsAccess() || !sub.Plan.Covers(course) {
+		return nil
+	}
+	return sub
+}
+
+// renewSubscriptionAccess handles enrolling in a course the user is already
+// enrolled in. Access that lapsed with an earlier subscription comes back
+// under a current one, keeping progress; anything else is ErrAlreadyEnrolled.
+func (uc *UseCase) renewSubscriptionAccess(ctx context.Context, existing *domain.Enrollment, course *domain.Course) (*domain.Enrollment, error) {
+	if existing.SubscriptionID == nil || existing.Status != domain.EnrollmentStatusExpired {
+		return nil, domain.ErrAlreadyEnrolled
+	}
+
+	sub := uc.coveringSubscription(ctx, existing.UserID, course)
+	if sub == nil {
+		return nil, domain.ErrAlreadyEnrolled
+	}
+
+	existing.Status = domain.EnrollmentStatusActive
+	existing.SubscriptionID = &sub.ID
+	if existing.StartedAt == nil {
+		now := time.Now()
+		existing.StartedAt = &now
+	}
+	if err := uc.enrollmentRepo.Update(ctx, existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
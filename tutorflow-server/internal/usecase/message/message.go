@@ -3,27 +3,189 @@ package message
 import (
 	"context"
 	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/realtime"
+	"github.com/tutorflow/tutorflow-server/internal/service/storage"
 )
 
+const (
+	// maxBroadcastsPerWindow limits how many course broadcasts an instructor can send
+	maxBroadcastsPerWindow = 5
+	broadcastWindow        = 24 * time.Hour
+	broadcastPageSize      = 500
+
+	maxAttachments    = 5
+	maxAttachmentSize = 25 * 1024 * 1024
+	attachmentFolder  = "messages/attachments"
+)
+
+// attachmentTypes maps the file extensions that can be attached to a message
+// to the content type they are stored with
+var attachmentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".pdf":  "application/pdf",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".ppt":  "application/vnd.ms-powerpoint",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".txt":  "text/plain",
+	".zip":  "application/zip",
+}
+
+// Events pushed to connected participants
+const (
+	EventMessage  = "message"  // data is a domain.Message
+	EventRead     = "read"     // data is a ReadReceipt
+	EventTyping   = "typing"   // data is a TypingIndicator
+	EventPresence = "presence" // data is a domain.Presence
+)
+
+// ReadReceipt tells a sender that the other participant read their messages
+type ReadReceipt struct {
+	ConversationID uuid.UUID  `json:"conversation_id"`
+	MessageID      *uuid.UUID `json:"message_id,omitempty"` // unset when the whole conversation was read
+	ReaderID       uuid.UUID  `json:"reader_id"`
+	ReadAt         time.Time  `json:"read_at"`
+}
+
+// TypingIndicator tells a participant the other one is typing. It is only
+// pushed, never stored.
+type TypingIndicator struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	UserID         uuid.UUID `json:"user_id"`
+}
+
 // UseCase defines messaging business logic
 type UseCase struct {
-	messageRepo repository.MessageRepository
-	userRepo    repository.UserRepository
+	messageRepo    repository.MessageRepository
+	userRepo       repository.UserRepository
+	courseRepo     repository.CourseRepository
+	enrollmentRepo repository.EnrollmentRepository
+	storageSvc     *storage.Service
+	hub            *realtime.Hub
+	cfg            config.MessagingConfig
 }
 
 // NewUseCase creates a new message use case
 func NewUseCase(
 	messageRepo repository.MessageRepository,
 	userRepo repository.UserRepository,
+	courseRepo repository.CourseRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	storageSvc *storage.Service,
+	hub *realtime.Hub,
+	cfg config.MessagingConfig,
 ) *UseCase {
 	return &UseCase{
-		messageRepo: messageRepo,
-		userRepo:    userRepo,
+		messageRepo:    messageRepo,
+		userRepo:       userRepo,
+		courseRepo:     courseRepo,
+		enrollmentRepo: enrollmentRepo,
+		storageSvc:     storageSvc,
+		hub:            hub,
+		cfg:            cfg,
+	}
+}
+
+// Connect streams events from the user's conversations until the returned
+// function is called. The user's conversation partners are told when they
+// come online with their first connection and go offline with their last.
+func (uc *UseCase) Connect(ctx context.Context, userID uuid.UUID) (<-chan realtime.Event, func()) {
+	first := !uc.hub.Connected(userID)
+	events, unsubscribe := uc.hub.Subscribe(userID)
+	if first {
+		uc.updatePresence(ctx, userID, true)
+	}
+
+	return events, func() {
+		unsubscribe()
+		if !uc.hub.Connected(userID) {
+			uc.updatePresence(ctx, userID, false)
+		}
+	}
+}
+
+// updatePresence records the user as active now and, unless they hide their
+// status, tells their conversation partners whether they are online
+func (uc *UseCase) updatePresence(ctx context.Context, userID uuid.UUID, online bool) {
+	now := time.Now()
+	_ = uc.userRepo.UpdateLastActive(ctx, userID, now)
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil || user.HidePresence {
+		return
+	}
+	partners, err := uc.messageRepo.GetConversationPartners(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	event := realtime.Event{Type: EventPresence, Data: domain.Presence{UserID: userID, Online: online, LastActiveAt: &now}}
+	for _, partnerID := range partners {
+		uc.hub.Publish(partnerID, event)
+	}
+}
+
+// GetPresence returns whether the user is online and when they were last
+// active. Users who hide their status always appear offline to others.
+func (uc *UseCase) GetPresence(ctx context.Context, viewerID, userID uuid.UUID) (*domain.Presence, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	presence := &domain.Presence{UserID: userID}
+	if user.HidePresence && viewerID != userID {
+		return presence, nil
+	}
+	presence.Online = uc.hub.Connected(userID)
+	presence.LastActiveAt = user.LastActiveAt
+	return presence, nil
+}
+
+// Typing tells the other participant of the conversation that the user is typing
+func (uc *UseCase) Typing(ctx context.Context, userID, convID uuid.UUID) error {
+	conv, err := uc.messageRepo.GetConversationByID(ctx, convID)
+	if err != nil || conv == nil {
+		return fmt.Errorf("conversation not found")
+	}
+	if !conv.HasParticipant(userID) {
+		return fmt.Errorf("access denied")
+	}
+
+	uc.publish(conv, realtime.Event{Type: EventTyping, Data: TypingIndicator{
+		ConversationID: conv.ID,
+		UserID:         userID,
+	}}, conv.GetOtherParticipant(userID))
+	return nil
+}
+
+// publish pushes the event to those of the given users who take part in the
+// conversation
+func (uc *UseCase) publish(conv *domain.Conversation, event realtime.Event, userIDs ...uuid.UUID) {
+	if uc.hub == nil {
+		return
+	}
+	for _, userID := range userIDs {
+		if conv.HasParticipant(userID) {
+			uc.hub.Publish(userID, event)
+		}
 	}
 }
 
@@ -71,6 +233,14 @@ func (uc *UseCase) GetOrCreateConversation(ctx context.Context, userID, otherUse
 		return conv, nil
 	}
 
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkCanMessage(ctx, user, otherUser); err != nil {
+		return nil, err
+	}
+
 	// Create new conversation
 	conv = &domain.Conversation{
 		Participant1: userID,
@@ -84,6 +254,27 @@ func (uc *UseCase) GetOrCreateConversation(ctx context.Context, userID, otherUse
 	return uc.messageRepo.GetConversationByID(ctx, conv.ID)
 }
 
+// checkCanMessage returns ErrMessagingNotAllowed unless the sender may start a
+// conversation with the recipient: a learner with an instructor of one of
+// their courses, or the other way round. Admins and managers may message
+// anyone, as may everyone when messaging is open.
+func (uc *UseCase) checkCanMessage(ctx context.Context, sender, recipient *domain.User) error {
+	if uc.cfg.OpenMessaging || sender.IsAdmin() || sender.IsManager() {
+		return nil
+	}
+
+	for _, pair := range [][2]uuid.UUID{{sender.ID, recipient.ID}, {recipient.ID, sender.ID}} {
+		ok, err := uc.enrollmentRepo.HasInstructor(ctx, pair[0], pair[1])
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return domain.ErrMessagingNotAllowed
+}
+
 // GetMessages returns messages in a conversation
 func (uc *UseCase) GetMessages(ctx context.Context, userID, convID uuid.UUID, page, limit int) ([]domain.Message, int64, error) {
 	// Verify access
@@ -106,6 +297,50 @@ func (uc *UseCase) GetMessages(ctx context.Context, userID, convID uuid.UUID, pa
 	return uc.messageRepo.GetConversationMessages(ctx, convID, page, limit)
 }
 
+// GetMessagesAfter returns a conversation's messages after a cursor, newest
+// first, and the cursor for the page after them
+func (uc *UseCase) GetMessagesAfter(ctx context.Context, userID, convID uuid.UUID, cursor pagination.Cursor, limit int) ([]domain.Message, string, error) {
+	conv, err := uc.messageRepo.GetConversationByID(ctx, convID)
+	if err != nil || conv == nil {
+		return nil, "", fmt.Errorf("conversation not found")
+	}
+
+	if conv.Participant1 != userID && conv.Participant2 != userID {
+		return nil, "", fmt.Errorf("access denied")
+	}
+
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	messages, err := uc.messageRepo.GetConversationMessagesAfter(ctx, convID, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	messages, next := pagination.Next(messages, limit, func(m domain.Message) pagination.Cursor {
+		return pagination.Cursor{Time: m.CreatedAt, ID: m.ID}
+	})
+	return messages, next, nil
+}
+
+// SearchMessages full-text searches the messages in the user's conversations
+func (uc *UseCase) SearchMessages(ctx context.Context, userID uuid.UUID, query string, page, limit int) ([]domain.MessageSearchResult, int64, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, domain.ValidationErrors{{Field: "q", Message: "search query is required"}}
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	return uc.messageRepo.SearchMessages(ctx, userID, query, page, limit)
+}
+
 // SendMessageInput for sending a message
 type SendMessageInput struct {
 	ConversationID *uuid.UUID `json:"conversation_id,omitempty"`
@@ -116,41 +351,135 @@ type SendMessageInput struct {
 
 // SendMessage sends a message
 func (uc *UseCase) SendMessage(ctx context.Context, senderID uuid.UUID, input SendMessageInput) (*domain.Message, error) {
-	var convID uuid.UUID
+	return uc.sendMessage(ctx, senderID, input, nil)
+}
+
+// SendWithAttachments sends a message with files attached. The message text
+// may be empty. Files and the sender's right to post in the conversation are
+// checked before anything is stored, files are stored with the same content
+// and scanner checks as other uploads, and removed again if the message can't
+// be sent.
+func (uc *UseCase) SendWithAttachments(ctx context.Context, senderID uuid.UUID, input SendMessageInput, files []*multipart.FileHeader) (*domain.Message, error) {
+	if len(files) == 0 {
+		return nil, domain.ValidationErrors{{Field: "files", Message: "attach at least one file"}}
+	}
+	if len(files) > maxAttachments {
+		return nil, domain.ValidationErrors{{Field: "files", Message: fmt.Sprintf("at most %d files per message", maxAttachments)}}
+	}
+	var errs domain.ValidationErrors
+	if len(input.Content) > 5000 {
+		errs = append(errs, domain.ValidationError{Field: "content", Message: "must be at most 5000 characters"})
+	}
+	for _, file := range files {
+		if _, ok := attachmentTypes[strings.ToLower(filepath.Ext(file.Filename))]; !ok {
+			errs = append(errs, domain.ValidationError{Field: file.Filename, Message: "file type not allowed"})
+		} else if file.Size > maxAttachmentSize {
+			errs = append(errs, domain.ValidationError{Field: file.Filename, Message: fmt.Sprintf("file too large: max %dMB", maxAttachmentSize/1024/1024)})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	conv, err := uc.conversationFor(ctx, senderID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make([]domain.MessageAttachment, 0, len(files))
+	for _, file := range files {
+		contentType := attachmentTypes[strings.ToLower(filepath.Ext(file.Filename))]
+		upload := uc.storageSvc.UploadDocument
+		if strings.HasPrefix(contentType, "image/") {
+			upload = uc.storageSvc.UploadImage
+		}
+		url, err := upload(ctx, file, attachmentFolder)
+		if err != nil {
+			uc.removeAttachmentFiles(ctx, attachments)
+			return nil, err
+		}
+		attachments = append(attachments, domain.MessageAttachment{
+			FileURL:     url,
+			FileName:    filepath.Base(file.Filename),
+			FileSize:    file.Size,
+			ContentType: contentType,
+		})
+	}
+
+	msg, err := uc.postMessage(ctx, conv, senderID, input, attachments)
+	if err != nil {
+		uc.removeAttachmentFiles(ctx, attachments)
+		return nil, err
+	}
+	return msg, nil
+}
+
+// removeAttachmentFiles deletes attachments' files from storage
+func (uc *UseCase) removeAttachmentFiles(ctx context.Context, attachments []domain.MessageAttachment) {
+	for _, a := range attachments {
+		_ = uc.storageSvc.DeleteFile(ctx, a.FileURL)
+	}
+}
+
+func (uc *UseCase) sendMessage(ctx context.Context, senderID uuid.UUID, input SendMessageInput, attachments []domain.MessageAttachment) (*domain.Message, error) {
+	conv, err := uc.conversationFor(ctx, senderID, input)
+	if err != nil {
+		return nil, err
+	}
+	return uc.postMessage(ctx, conv, senderID, input, attachments)
+}
+
+// conversationFor returns the conversation a message goes to, checking the
+// sender may post in it. A conversation with a new recipient is started if
+// the sender may message them.
+func (uc *UseCase) conversationFor(ctx context.Context, senderID uuid.UUID, input SendMessageInput) (*domain.Conversation, error) {
+	var conv *domain.Conversation
 
 	if input.ConversationID != nil {
 		// Verify access to conversation
-		conv, err := uc.messageRepo.GetConversationByID(ctx, *input.ConversationID)
+		var err error
+		conv, err = uc.messageRepo.GetConversationByID(ctx, *input.ConversationID)
 		if err != nil || conv == nil {
 			return nil, fmt.Errorf("conversation not found")
 		}
 		if conv.Participant1 != senderID && conv.Participant2 != senderID {
 			return nil, fmt.Errorf("access denied")
 		}
-		convID = conv.ID
 	} else if input.RecipientID != nil {
 		// Get or create conversation
-		conv, err := uc.GetOrCreateConversation(ctx, senderID, *input.RecipientID)
+		var err error
+		conv, err = uc.GetOrCreateConversation(ctx, senderID, *input.RecipientID)
 		if err != nil {
 			return nil, err
 		}
-		convID = conv.ID
 	} else {
 		return nil, fmt.Errorf("conversation_id or recipient_id required")
 	}
+	return conv, nil
+}
 
+// postMessage saves a message in conv and pushes it to both participants
+func (uc *UseCase) postMessage(ctx context.Context, conv *domain.Conversation, senderID uuid.UUID, input SendMessageInput, attachments []domain.MessageAttachment) (*domain.Message, error) {
 	msg := &domain.Message{
-		ConversationID: convID,
+		ConversationID: conv.ID,
 		SenderID:       senderID,
 		Content:        input.Content,
 		AttachmentURL:  input.AttachmentURL,
+		Attachments:    attachments,
 	}
 
 	if err := uc.messageRepo.CreateMessage(ctx, msg); err != nil {
 		return nil, err
 	}
 
-	return uc.messageRepo.GetMessageByID(ctx, msg.ID)
+	created, err := uc.messageRepo.GetMessageByID(ctx, msg.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The sender's other connections get it too, so every open tab stays in sync
+	uc.publish(conv, realtime.Event{Type: EventMessage, Data: created}, conv.Participant1, conv.Participant2)
+	return created, nil
 }
 
 // MarkAsRead marks a message as read
@@ -170,7 +499,17 @@ func (uc *UseCase) MarkAsRead(ctx context.Context, userID, msgID uuid.UUID) erro
 		return nil // Can't mark own message as read
 	}
 
-	return uc.messageRepo.MarkAsRead(ctx, msgID)
+	if err := uc.messageRepo.MarkAsRead(ctx, msgID); err != nil {
+		return err
+	}
+
+	uc.publish(conv, realtime.Event{Type: EventRead, Data: ReadReceipt{
+		ConversationID: conv.ID,
+		MessageID:      &msg.ID,
+		ReaderID:       userID,
+		ReadAt:         time.Now(),
+	}}, msg.SenderID)
+	return nil
 }
 
 // MarkConversationAsRead marks all messages in a conversation as read
@@ -184,7 +523,16 @@ func (uc *UseCase) MarkConversationAsRead(ctx context.Context, userID, convID uu
 		return fmt.Errorf("access denied")
 	}
 
-	return uc.messageRepo.MarkConversationAsRead(ctx, convID, userID)
+	if err := uc.messageRepo.MarkConversationAsRead(ctx, convID, userID); err != nil {
+		return err
+	}
+
+	uc.publish(conv, realtime.Event{Type: EventRead, Data: ReadReceipt{
+		ConversationID: conv.ID,
+		ReaderID:       userID,
+		ReadAt:         time.Now(),
+	}}, conv.GetOtherParticipant(userID))
+	return nil
 }
 
 // GetUnreadCount returns total unread messages for a user
@@ -203,5 +551,111 @@ func (uc *UseCase) DeleteMessage(ctx context.Context, userID, msgID uuid.UUID) e
 		return fmt.Errorf("you can only delete your own messages")
 	}
 
-	return uc.messageRepo.DeleteMessage(ctx, msgID)
+	if err := uc.messageRepo.DeleteMessage(ctx, msgID); err != nil {
+		return err
+	}
+	uc.removeAttachmentFiles(ctx, msg.Attachments)
+	return nil
+}
+
+// BroadcastInput for messaging every student in a course
+type BroadcastInput struct {
+	Content string `json:"content" validate:"required,min=1,max=5000"`
+}
+
+// BroadcastToCourse sends a direct message from the instructor to every active
+// enrollee, reusing existing conversations. Each broadcast is recorded for audit
+// before anything is sent, and instructors are limited to
+// maxBroadcastsPerWindow per broadcastWindow.
+func (uc *UseCase) BroadcastToCourse(ctx context.Context, instructorID, courseID uuid.UUID, body string) (*domain.MessageBroadcast, error) {
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.InstructorID != instructorID {
+		return nil, domain.ErrNotCourseOwner
+	}
+
+	broadcast := &domain.MessageBroadcast{
+		SenderID: instructorID,
+		CourseID: courseID,
+		Content:  body,
+	}
+	if err := uc.messageRepo.ReserveBroadcast(ctx, broadcast, time.Now().Add(-broadcastWindow), maxBroadcastsPerWindow); err != nil {
+		return nil, err
+	}
+
+	// A reserved broadcast goes out in full even if the caller goes away, and
+	// its counts are recorded even if listing the course fails part way
+	ctx = context.WithoutCancel(ctx)
+	sendErr := uc.sendBroadcast(ctx, broadcast)
+	if err := uc.messageRepo.UpdateBroadcastCounts(ctx, broadcast); err != nil {
+		return nil, err
+	}
+	if sendErr != nil {
+		return nil, sendErr
+	}
+
+	return broadcast, nil
+}
+
+// sendBroadcast messages the course's active enrollees, counting who was
+// reached on the broadcast
+func (uc *UseCase) sendBroadcast(ctx context.Context, broadcast *domain.MessageBroadcast) error {
+	status := domain.EnrollmentStatusActive
+	for page := 1; ; page++ {
+		enrollments, _, err := uc.enrollmentRepo.List(ctx, repository.EnrollmentFilters{
+			CourseID: &broadcast.CourseID,
+			Status:   &status,
+			Page:     page,
+			Limit:    broadcastPageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, e := range enrollments {
+			if e.UserID == broadcast.SenderID {
+				continue
+			}
+			if err := uc.sendBroadcastMessage(ctx, broadcast.SenderID, e.UserID, broadcast.CourseID, broadcast.Content); err != nil {
+				broadcast.FailedCount++
+				continue
+			}
+			broadcast.RecipientCount++
+		}
+
+		if len(enrollments) < broadcastPageSize {
+			return nil
+		}
+	}
+}
+
+func (uc *UseCase) sendBroadcastMessage(ctx context.Context, senderID, recipientID, courseID uuid.UUID, body string) error {
+	conv, err := uc.messageRepo.GetConversationBetween(ctx, senderID, recipientID)
+	if err != nil {
+		return err
+	}
+	if conv == nil {
+		conv = &domain.Conversation{
+			CourseID:     &courseID,
+			Participant1: senderID,
+			Participant2: recipientID,
+		}
+		if err := uc.messageRepo.CreateConversation(ctx, conv); err != nil {
+			return err
+		}
+	}
+
+	msg := &domain.Message{
+		ConversationID: conv.ID,
+		SenderID:       senderID,
+		Content:        body,
+	}
+	if err := uc.messageRepo.CreateMessage(ctx, msg); err != nil {
+		return err
+	}
+
+	uc.publish(conv, realtime.Event{Type: EventMessage, Data: msg}, recipientID)
+	return nil
 }
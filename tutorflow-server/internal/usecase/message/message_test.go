@@ -0,0 +1,652 @@
+package message_test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/realtime"
+	"github.com/tutorflow/tutorflow-server/internal/service/storage"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/message"
+)
+
+// MockMessageRepository is a mock implementation of MessageRepository
+type MockMessageRepository struct {
+	mock.Mock
+}
+
+func (m *MockMessageRepository) CreateConversation(ctx context.Context, conv *domain.Conversation) error {
+	return m.Called(ctx, conv).Error(0)
+}
+
+func (m *MockMessageRepository) GetConversationByID(ctx context.Context, id uuid.UUID) (*domain.Conversation, error) {
+	args := m.Called(ctx, id)
+	conv, _ := args.Get(0).(*domain.Conversation)
+	return conv, args.Error(1)
+}
+
+func (m *MockMessageRepository) GetConversationBetween(ctx context.Context, user1, user2 uuid.UUID) (*domain.Conversation, error) {
+	args := m.Called(ctx, user1, user2)
+	conv, _ := args.Get(0).(*domain.Conversation)
+	return conv, args.Error(1)
+}
+
+func (m *MockMessageRepository) GetUserConversations(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.ConversationWithUnread, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	convs, _ := args.Get(0).([]domain.ConversationWithUnread)
+	return convs, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockMessageRepository) GetConversationPartners(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(ctx, userID)
+	partners, _ := args.Get(0).([]uuid.UUID)
+	return partners, args.Error(1)
+}
+
+func (m *MockMessageRepository) UpdateConversation(ctx context.Context, conv *domain.Conversation) error {
+	return m.Called(ctx, conv).Error(0)
+}
+
+func (m *MockMessageRepository) CreateMessage(ctx context.Context, msg *domain.Message) error {
+	return m.Called(ctx, msg).Error(0)
+}
+
+func (m *MockMessageRepository) GetMessageByID(ctx context.Context, id uuid.UUID) (*domain.Message, error) {
+	args := m.Called(ctx, id)
+	msg, _ := args.Get(0).(*domain.Message)
+	return msg, args.Error(1)
+}
+
+func (m *MockMessageRepository) GetConversationMessages(ctx context.Context, convID uuid.UUID, page, limit int) ([]domain.Message, int64, error) {
+	args := m.Called(ctx, convID, page, limit)
+	msgs, _ := args.Get(0).([]domain.Message)
+	return msgs, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockMessageRepository) GetConversationMessagesAfter(ctx context.Context, convID uuid.UUID, cursor pagination.Cursor, limit int) ([]domain.Message, error) {
+	args := m.Called(ctx, convID, cursor, limit)
+	msgs, _ := args.Get(0).([]domain.Message)
+	return msgs, args.Error(1)
+}
+
+func (m *MockMessageRepository) MarkAsRead(ctx context.Context, msgID uuid.UUID) error {
+	return m.Called(ctx, msgID).Error(0)
+}
+
+func (m *MockMessageRepository) MarkConversationAsRead(ctx context.Context, convID, userID uuid.UUID) error {
+	return m.Called(ctx, convID, userID).Error(0)
+}
+
+func (m *MockMessageRepository) GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMessageRepository) DeleteMessage(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockMessageRepository) SearchMessages(ctx context.Context, userID uuid.UUID, query string, page, limit int) ([]domain.MessageSearchResult, int64, error) {
+	args := m.Called(ctx, userID, query, page, limit)
+	results, _ := args.Get(0).([]domain.MessageSearchResult)
+	return results, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockMessageRepository) ReserveBroadcast(ctx context.Context, broadcast *domain.MessageBroadcast, since time.Time, limit int64) error {
+	return m.Called(ctx, broadcast, since, limit).Error(0)
+}
+
+func (m *MockMessageRepository) UpdateBroadcastCounts(ctx context.Context, broadcast *domain.MessageBroadcast) error {
+	return m.Called(ctx, broadcast).Error(0)
+}
+
+// MockUserRepository is a mock implementation of UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	args := m.Called(ctx, usernames)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filters repository.UserFilters) ([]domain.User, int64, error) {
+	args := m.Called(ctx, filters)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return m.Called(ctx, id, passwordHash).Error(0)
+}
+
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdateLastActive(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+func (m *MockUserRepository) Anonymize(ctx context.Context, u *domain.User, entry *domain.AuditLog) error {
+	return m.Called(ctx, u, entry).Error(0)
+}
+
+// MockEnrollmentRepository is a mock implementation of EnrollmentRepository
+type MockEnrollmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockEnrollmentRepository) Create(ctx context.Context, e *domain.Enrollment) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockEnrollmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Enrollment, error) {
+	args := m.Called(ctx, id)
+	e, _ := args.Get(0).(*domain.Enrollment)
+	return e, args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetByUserAndCourse(ctx context.Context, userID, courseID uuid.UUID) (*domain.Enrollment, error) {
+	args := m.Called(ctx, userID, courseID)
+	e, _ := args.Get(0).(*domain.Enrollment)
+	return e, args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) Update(ctx context.Context, e *domain.Enrollment) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockEnrollmentRepository) List(ctx context.Context, filters repository.EnrollmentFilters) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, courseID, page, limit)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) UpdateProgress(ctx context.Context, id uuid.UUID, progress float64) error {
+	return m.Called(ctx, id, progress).Error(0)
+}
+
+func (m *MockEnrollmentRepository) GetStats(ctx context.Context, userID uuid.UUID) (*domain.StudentDashboardStats, error) {
+	args := m.Called(ctx, userID)
+	s, _ := args.Get(0).(*domain.StudentDashboardStats)
+	return s, args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) HasInstructor(ctx context.Context, userID, instructorID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID, instructorID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetCourseFunnel(ctx context.Context, courseID uuid.UUID) (*domain.CourseFunnel, error) {
+	args := m.Called(ctx, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CourseFunnel), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetAtRisk(ctx context.Context, courseID uuid.UUID, inactiveSince time.Time, maxProgress float64) ([]domain.AtRiskStudent, error) {
+	args := m.Called(ctx, courseID, inactiveSince, maxProgress)
+	return args.Get(0).([]domain.AtRiskStudent), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) MarkNudged(ctx context.Context, ids []uuid.UUID, now, cooldownSince time.Time) ([]uuid.UUID, error) {
+	args := m.Called(ctx, ids, now, cooldownSince)
+	claimed, _ := args.Get(0).([]uuid.UUID)
+	return claimed, args.Error(1)
+}
+
+// connect opens a connection for the user without any presence side effects
+func connect(uc *message.UseCase, userRepo *MockUserRepository, userID uuid.UUID) (<-chan realtime.Event, func()) {
+	userRepo.On("UpdateLastActive", mock.Anything, userID, mock.Anything).Return(nil)
+	userRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID, HidePresence: true}, nil)
+	return uc.Connect(context.Background(), userID)
+}
+
+func TestMessageUseCase_SendMessage_PushesToParticipants(t *testing.T) {
+	ctx := context.Background()
+	sender, recipient, outsider := uuid.New(), uuid.New(), uuid.New()
+	conv := &domain.Conversation{ID: uuid.New(), Participant1: sender, Participant2: recipient}
+
+	messageRepo := new(MockMessageRepository)
+	userRepo := new(MockUserRepository)
+	messageRepo.On("GetConversationByID", ctx, conv.ID).Return(conv, nil)
+	messageRepo.On("CreateMessage", ctx, mock.Anything).Return(nil)
+	messageRepo.On("GetMessageByID", ctx, mock.Anything).Return(&domain.Message{ConversationID: conv.ID, SenderID: sender, Content: "hi"}, nil)
+	uc := message.NewUseCase(messageRepo, userRepo, nil, nil, nil, realtime.NewHub(), config.MessagingConfig{})
+
+	recipientEvents, stopRecipient := connect(uc, userRepo, recipient)
+	defer stopRecipient()
+	senderEvents, stopSender := connect(uc, userRepo, sender)
+	defer stopSender()
+	outsiderEvents, stopOutsider := connect(uc, userRepo, outsider)
+	defer stopOutsider()
+
+	_, err := uc.SendMessage(ctx, sender, message.SendMessageInput{ConversationID: &conv.ID, Content: "hi"})
+	assert.NoError(t, err)
+
+	event := <-recipientEvents
+	assert.Equal(t, message.EventMessage, event.Type)
+	assert.Equal(t, message.EventMessage, (<-senderEvents).Type, "the sender's other tabs stay in sync")
+	assert.Empty(t, outsiderEvents)
+}
+
+func TestMessageUseCase_MarkConversationAsRead_SendsReceipt(t *testing.T) {
+	ctx := context.Background()
+	sender, reader := uuid.New(), uuid.New()
+	conv := &domain.Conversation{ID: uuid.New(), Participant1: sender, Participant2: reader}
+
+	messageRepo := new(MockMessageRepository)
+	userRepo := new(MockUserRepository)
+	messageRepo.On("GetConversationByID", ctx, conv.ID).Return(conv, nil)
+	messageRepo.On("MarkConversationAsRead", ctx, conv.ID, reader).Return(nil)
+	uc := message.NewUseCase(messageRepo, userRepo, nil, nil, nil, realtime.NewHub(), config.MessagingConfig{})
+
+	senderEvents, stopSender := connect(uc, userRepo, sender)
+	defer stopSender()
+	readerEvents, stopReader := connect(uc, userRepo, reader)
+	defer stopReader()
+
+	assert.NoError(t, uc.MarkConversationAsRead(ctx, reader, conv.ID))
+
+	event := <-senderEvents
+	assert.Equal(t, message.EventRead, event.Type)
+	if receipt, ok := event.Data.(message.ReadReceipt); assert.True(t, ok) {
+		assert.Equal(t, conv.ID, receipt.ConversationID)
+		assert.Equal(t, reader, receipt.ReaderID)
+		assert.Nil(t, receipt.MessageID)
+	}
+	assert.Empty(t, readerEvents)
+}
+
+func TestMessageUseCase_Typing(t *testing.T) {
+	ctx := context.Background()
+	typist, partner, outsider := uuid.New(), uuid.New(), uuid.New()
+	conv := &domain.Conversation{ID: uuid.New(), Participant1: typist, Participant2: partner}
+
+	messageRepo := new(MockMessageRepository)
+	userRepo := new(MockUserRepository)
+	messageRepo.On("GetConversationByID", ctx, conv.ID).Return(conv, nil)
+	uc := message.NewUseCase(messageRepo, userRepo, nil, nil, nil, realtime.NewHub(), config.MessagingConfig{})
+
+	partnerEvents, stopPartner := connect(uc, userRepo, partner)
+	defer stopPartner()
+
+	assert.NoError(t, uc.Typing(ctx, typist, conv.ID))
+	event := <-partnerEvents
+	assert.Equal(t, message.EventTyping, event.Type)
+	assert.Equal(t, message.TypingIndicator{ConversationID: conv.ID, UserID: typist}, event.Data)
+
+	assert.Error(t, uc.Typing(ctx, outsider, conv.ID))
+	assert.Empty(t, partnerEvents)
+	messageRepo.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything)
+}
+
+func TestMessageUseCase_Presence(t *testing.T) {
+	ctx := context.Background()
+	alice, bob, viewer := uuid.New(), uuid.New(), uuid.New()
+	lastActive := time.Now().Add(-time.Hour)
+
+	messageRepo := new(MockMessageRepository)
+	userRepo := new(MockUserRepository)
+	uc := message.NewUseCase(messageRepo, userRepo, nil, nil, nil, realtime.NewHub(), config.MessagingConfig{})
+
+	t.Run("partners are told when a user comes online and goes offline", func(t *testing.T) {
+		bobEvents, stopBob := connect(uc, userRepo, bob)
+		defer stopBob()
+
+		userRepo.On("UpdateLastActive", ctx, alice, mock.Anything).Return(nil)
+		userRepo.On("GetByID", ctx, alice).Return(&domain.User{ID: alice, LastActiveAt: &lastActive}, nil)
+		messageRepo.On("GetConversationPartners", ctx, alice).Return([]uuid.UUID{bob}, nil)
+
+		_, disconnect := uc.Connect(ctx, alice)
+		event := <-bobEvents
+		assert.Equal(t, message.EventPresence, event.Type)
+		assert.True(t, event.Data.(domain.Presence).Online)
+
+		presence, err := uc.GetPresence(ctx, viewer, alice)
+		assert.NoError(t, err)
+		assert.True(t, presence.Online)
+		assert.Equal(t, &lastActive, presence.LastActiveAt)
+
+		disconnect()
+		event = <-bobEvents
+		assert.False(t, event.Data.(domain.Presence).Online)
+		userRepo.AssertNumberOfCalls(t, "UpdateLastActive", 3)
+	})
+
+	t.Run("hidden users appear offline to others", func(t *testing.T) {
+		hidden := uuid.New()
+		_, disconnect := connect(uc, userRepo, hidden)
+		defer disconnect()
+
+		presence, err := uc.GetPresence(ctx, viewer, hidden)
+		assert.NoError(t, err)
+		assert.False(t, presence.Online)
+		assert.Nil(t, presence.LastActiveAt)
+
+		own, err := uc.GetPresence(ctx, hidden, hidden)
+		assert.NoError(t, err)
+		assert.True(t, own.Online)
+		messageRepo.AssertNotCalled(t, "GetConversationPartners", mock.Anything, hidden)
+	})
+}
+
+func TestMessageUseCase_GetOrCreateConversation_RequiresRelationship(t *testing.T) {
+	ctx := context.Background()
+	student := &domain.User{ID: uuid.New(), Role: domain.RoleStudent}
+	instructor := &domain.User{ID: uuid.New(), Role: domain.RoleTutor}
+	stranger := &domain.User{ID: uuid.New(), Role: domain.RoleStudent}
+	manager := &domain.User{ID: uuid.New(), Role: domain.RoleManager}
+
+	setup := func(cfg config.MessagingConfig) (*message.UseCase, *MockMessageRepository) {
+		messageRepo := new(MockMessageRepository)
+		userRepo := new(MockUserRepository)
+		enrollRepo := new(MockEnrollmentRepository)
+		for _, u := range []*domain.User{student, instructor, stranger, manager} {
+			userRepo.On("GetByID", ctx, u.ID).Return(u, nil)
+		}
+		messageRepo.On("GetConversationBetween", ctx, mock.Anything, mock.Anything).Return(nil, nil)
+		messageRepo.On("CreateConversation", ctx, mock.Anything).Return(nil)
+		messageRepo.On("GetConversationByID", ctx, mock.Anything).Return(&domain.Conversation{}, nil)
+		enrollRepo.On("HasInstructor", ctx, student.ID, instructor.ID).Return(true, nil)
+		enrollRepo.On("HasInstructor", ctx, mock.Anything, mock.Anything).Return(false, nil)
+		return message.NewUseCase(messageRepo, userRepo, nil, enrollRepo, nil, nil, cfg), messageRepo
+	}
+
+	t.Run("learners and their instructors can message each other", func(t *testing.T) {
+		uc, _ := setup(config.MessagingConfig{})
+		_, err := uc.GetOrCreateConversation(ctx, student.ID, instructor.ID)
+		assert.NoError(t, err)
+		_, err = uc.GetOrCreateConversation(ctx, instructor.ID, student.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unrelated users cannot", func(t *testing.T) {
+		uc, messageRepo := setup(config.MessagingConfig{})
+		_, err := uc.GetOrCreateConversation(ctx, stranger.ID, instructor.ID)
+		assert.ErrorIs(t, err, domain.ErrMessagingNotAllowed)
+		messageRepo.AssertNotCalled(t, "CreateConversation", mock.Anything, mock.Anything)
+	})
+
+	t.Run("staff and open deployments bypass the rule", func(t *testing.T) {
+		uc, _ := setup(config.MessagingConfig{})
+		_, err := uc.GetOrCreateConversation(ctx, manager.ID, stranger.ID)
+		assert.NoError(t, err)
+
+		uc, _ = setup(config.MessagingConfig{OpenMessaging: true})
+		_, err = uc.GetOrCreateConversation(ctx, stranger.ID, instructor.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("existing conversations carry on", func(t *testing.T) {
+		uc, messageRepo := setup(config.MessagingConfig{})
+		conv := &domain.Conversation{ID: uuid.New(), Participant1: stranger.ID, Participant2: instructor.ID}
+		messageRepo.ExpectedCalls = nil
+		messageRepo.On("GetConversationBetween", ctx, stranger.ID, instructor.ID).Return(conv, nil)
+		got, err := uc.GetOrCreateConversation(ctx, stranger.ID, instructor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, conv, got)
+	})
+}
+
+// formFiles builds multipart file headers for the given names and contents
+func formFiles(t *testing.T, files map[string]string) []*multipart.FileHeader {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, content := range files {
+		part, err := w.CreateFormFile("files", name)
+		assert.NoError(t, err)
+		_, _ = part.Write([]byte(content))
+	}
+	assert.NoError(t, w.Close())
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	assert.NoError(t, err)
+	return form.File["files"]
+}
+
+func TestMessageUseCase_SendWithAttachments(t *testing.T) {
+	ctx := context.Background()
+	sender, recipient := uuid.New(), uuid.New()
+	conv := &domain.Conversation{ID: uuid.New(), Participant1: sender, Participant2: recipient}
+	dir := t.TempDir()
+	storageSvc := storage.NewService(config.StorageConfig{
+		Driver:          "local",
+		LocalPath:       dir,
+		CDNBaseURL:      "/uploads",
+		MaxImageSize:    1 << 20,
+		MaxDocumentSize: 1 << 20,
+	})
+
+	t.Run("rejects disallowed types before storing anything", func(t *testing.T) {
+		messageRepo := new(MockMessageRepository)
+		uc := message.NewUseCase(messageRepo, nil, nil, nil, storageSvc, nil, config.MessagingConfig{})
+
+		_, err := uc.SendWithAttachments(ctx, sender, message.SendMessageInput{ConversationID: &conv.ID}, formFiles(t, map[string]string{
+			"notes.pdf": "%PDF",
+			"setup.exe": "MZ",
+		}))
+		var verrs domain.ValidationErrors
+		if assert.ErrorAs(t, err, &verrs) {
+			assert.Len(t, verrs, 1)
+			assert.Equal(t, "setup.exe", verrs[0].Field)
+		}
+		messageRepo.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything)
+		_, statErr := os.Stat(filepath.Join(dir, "messages"))
+		assert.True(t, os.IsNotExist(statErr))
+	})
+
+	t.Run("stores files with the message and removes them when it is deleted", func(t *testing.T) {
+		messageRepo := new(MockMessageRepository)
+		saved := &domain.Message{}
+		messageRepo.On("GetConversationByID", ctx, conv.ID).Return(conv, nil)
+		messageRepo.On("CreateMessage", ctx, mock.Anything).
+			Run(func(args mock.Arguments) {
+				*saved = *args.Get(1).(*domain.Message)
+				saved.ID = uuid.New()
+			}).
+			Return(nil)
+		messageRepo.On("GetMessageByID", ctx, mock.Anything).Return(saved, nil)
+		messageRepo.On("DeleteMessage", ctx, mock.Anything).Return(nil)
+		uc := message.NewUseCase(messageRepo, nil, nil, nil, storageSvc, nil, config.MessagingConfig{})
+
+		msg, err := uc.SendWithAttachments(ctx, sender, message.SendMessageInput{ConversationID: &conv.ID}, formFiles(t, map[string]string{
+			"Slides.PDF": "%PDF-1.7",
+		}))
+		assert.NoError(t, err)
+		if !assert.Len(t, msg.Attachments, 1) {
+			return
+		}
+		a := msg.Attachments[0]
+		assert.Equal(t, "Slides.PDF", a.FileName)
+		assert.Equal(t, "application/pdf", a.ContentType)
+		assert.Equal(t, int64(8), a.FileSize)
+		assert.True(t, strings.HasPrefix(a.FileURL, "/uploads/messages/attachments/"))
+
+		stored := filepath.Join(dir, strings.TrimPrefix(a.FileURL, "/uploads/"))
+		assert.FileExists(t, stored)
+
+		assert.NoError(t, uc.DeleteMessage(ctx, sender, msg.ID))
+		assert.NoFileExists(t, stored)
+	})
+
+	t.Run("checks content like other uploads", func(t *testing.T) {
+		messageRepo := new(MockMessageRepository)
+		messageRepo.On("GetConversationByID", ctx, conv.ID).Return(conv, nil)
+		uc := message.NewUseCase(messageRepo, nil, nil, nil, storageSvc, nil, config.MessagingConfig{})
+
+		_, err := uc.SendWithAttachments(ctx, sender, message.SendMessageInput{ConversationID: &conv.ID}, formFiles(t, map[string]string{
+			"notes.pdf": "%PDF-1.7",
+			"photo.png": "MZ not really a photo",
+		}))
+		assert.ErrorIs(t, err, domain.ErrFileContentMismatch)
+		messageRepo.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything)
+		files, _ := filepath.Glob(filepath.Join(dir, "messages", "attachments", "*"))
+		assert.Empty(t, files, "files stored before the bad one are removed")
+	})
+
+	t.Run("stores nothing for someone outside the conversation", func(t *testing.T) {
+		messageRepo := new(MockMessageRepository)
+		messageRepo.On("GetConversationByID", ctx, conv.ID).Return(conv, nil)
+		outsiderDir := t.TempDir()
+		outsiderStorage := storage.NewService(config.StorageConfig{Driver: "local", LocalPath: outsiderDir, MaxDocumentSize: 1 << 20})
+		uc := message.NewUseCase(messageRepo, nil, nil, nil, outsiderStorage, nil, config.MessagingConfig{})
+
+		_, err := uc.SendWithAttachments(ctx, uuid.New(), message.SendMessageInput{ConversationID: &conv.ID}, formFiles(t, map[string]string{
+			"notes.pdf": "%PDF-1.7",
+		}))
+		assert.EqualError(t, err, "access denied")
+		_, statErr := os.Stat(filepath.Join(outsiderDir, "messages"))
+		assert.True(t, os.IsNotExist(statErr), "nothing was stored, even briefly")
+	})
+}
+
+func TestMessageUseCase_SearchMessages(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("rejects an empty query", func(t *testing.T) {
+		messageRepo := new(MockMessageRepository)
+		uc := message.NewUseCase(messageRepo, nil, nil, nil, nil, nil, config.MessagingConfig{})
+
+		_, _, err := uc.SearchMessages(ctx, userID, "   ", 1, 20)
+		var verrs domain.ValidationErrors
+		assert.ErrorAs(t, err, &verrs)
+		messageRepo.AssertNotCalled(t, "SearchMessages", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("searches the user's conversations", func(t *testing.T) {
+		messageRepo := new(MockMessageRepository)
+		results := []domain.MessageSearchResult{{Snippet: "see the <mark>syllabus</mark>"}}
+		messageRepo.On("SearchMessages", ctx, userID, "syllabus", 1, 20).Return(results, int64(1), nil)
+		uc := message.NewUseCase(messageRepo, nil, nil, nil, nil, nil, config.MessagingConfig{})
+
+		got, total, err := uc.SearchMessages(ctx, userID, " syllabus ", 0, 500)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, results, got)
+	})
+}
+
+// courseRepo returns a fixed course
+type courseRepo struct {
+	repository.CourseRepository
+	course *domain.Course
+}
+
+func (r *courseRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Course, error) {
+	return r.course, nil
+}
+
+func TestMessageUseCase_BroadcastToCourse(t *testing.T) {
+	instructor, learner := uuid.New(), uuid.New()
+	course := &domain.Course{ID: uuid.New(), InstructorID: instructor}
+	enrollments := []domain.Enrollment{{UserID: instructor}, {UserID: learner}}
+
+	t.Run("records the broadcast before sending, and sends even if the caller goes away", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		messageRepo := new(MockMessageRepository)
+		enrollmentRepo := new(MockEnrollmentRepository)
+		reserved := false
+		messageRepo.On("ReserveBroadcast", ctx, mock.Anything, mock.Anything, int64(5)).
+			Run(func(mock.Arguments) {
+				reserved = true
+				cancel()
+			}).
+			Return(nil)
+		enrollmentRepo.On("List", mock.Anything, mock.Anything).Return(enrollments, int64(2), nil)
+		messageRepo.On("GetConversationBetween", mock.Anything, instructor, learner).Return(nil, nil)
+		messageRepo.On("CreateConversation", mock.Anything, mock.Anything).Return(nil)
+		messageRepo.On("CreateMessage", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				assert.True(t, reserved, "the broadcast is recorded first")
+				assert.NoError(t, args.Get(0).(context.Context).Err())
+			}).
+			Return(nil)
+		messageRepo.On("UpdateBroadcastCounts", mock.Anything, mock.Anything).Return(nil)
+		uc := message.NewUseCase(messageRepo, nil, &courseRepo{course: course}, enrollmentRepo, nil, nil, config.MessagingConfig{})
+
+		broadcast, err := uc.BroadcastToCourse(ctx, instructor, course.ID, "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, broadcast.RecipientCount)
+		messageRepo.AssertCalled(t, "UpdateBroadcastCounts", mock.Anything, broadcast)
+	})
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		ctx := context.Background()
+		messageRepo := new(MockMessageRepository)
+		enrollmentRepo := new(MockEnrollmentRepository)
+		messageRepo.On("ReserveBroadcast", ctx, mock.Anything, mock.Anything, int64(5)).Return(domain.ErrBroadcastLimitReached)
+		uc := message.NewUseCase(messageRepo, nil, &courseRepo{course: course}, enrollmentRepo, nil, nil, config.MessagingConfig{})
+
+		_, err := uc.BroadcastToCourse(ctx, instructor, course.ID, "hello")
+		assert.ErrorIs(t, err, domain.ErrBroadcastLimitReached)
+		enrollmentRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+
+	t.Run("records the counts when listing the course fails", func(t *testing.T) {
+		ctx := context.Background()
+		messageRepo := new(MockMessageRepository)
+		enrollmentRepo := new(MockEnrollmentRepository)
+		messageRepo.On("ReserveBroadcast", ctx, mock.Anything, mock.Anything, int64(5)).Return(nil)
+		enrollmentRepo.On("List", mock.Anything, mock.Anything).Return([]domain.Enrollment(nil), int64(0), assert.AnError)
+		messageRepo.On("UpdateBroadcastCounts", mock.Anything, mock.Anything).Return(nil)
+		uc := message.NewUseCase(messageRepo, nil, &courseRepo{course: course}, enrollmentRepo, nil, nil, config.MessagingConfig{})
+
+		_, err := uc.BroadcastToCourse(ctx, instructor, course.ID, "hello")
+		assert.ErrorIs(t, err, assert.AnError)
+		messageRepo.AssertCalled(t, "UpdateBroadcastCounts", mock.Anything, mock.Anything)
+	})
+}
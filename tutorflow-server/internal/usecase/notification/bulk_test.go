@@ -0,0 +1,69 @@
+package notification_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
+)
+
+func TestNotificationUseCase_MarkManyAsRead(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	notificationRepo := new(MockNotificationRepository)
+	notificationRepo.On("MarkAsRead", ctx, userID, ids).Return(int64(2), nil)
+	notificationRepo.On("GetUnreadCount", ctx, userID).Return(int64(3), nil)
+	uc := notification.NewUseCase(notificationRepo, nil, nil, nil, nil)
+
+	result, err := uc.MarkManyAsRead(ctx, userID, notification.BulkInput{IDs: ids})
+	require.NoError(t, err)
+	assert.Equal(t, &notification.BulkOutput{Count: 2, UnreadCount: 3}, result)
+	notificationRepo.AssertExpectations(t)
+}
+
+func TestNotificationUseCase_DeleteMany(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	before := time.Now().Add(-30 * 24 * time.Hour)
+
+	t.Run("by age", func(t *testing.T) {
+		notificationRepo := new(MockNotificationRepository)
+		notificationRepo.On("Delete", ctx, userID, []uuid.UUID(nil), &before).Return(int64(12), nil)
+		notificationRepo.On("GetUnreadCount", ctx, userID).Return(int64(0), nil)
+		uc := notification.NewUseCase(notificationRepo, nil, nil, nil, nil)
+
+		result, err := uc.DeleteMany(ctx, userID, notification.DeleteInput{IDs: []uuid.UUID{}, Before: &before})
+		require.NoError(t, err)
+		assert.Equal(t, &notification.BulkOutput{Count: 12, UnreadCount: 0}, result)
+		notificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("by ID", func(t *testing.T) {
+		ids := []uuid.UUID{uuid.New()}
+		notificationRepo := new(MockNotificationRepository)
+		notificationRepo.On("Delete", ctx, userID, ids, (*time.Time)(nil)).Return(int64(1), nil)
+		notificationRepo.On("GetUnreadCount", ctx, userID).Return(int64(4), nil)
+		uc := notification.NewUseCase(notificationRepo, nil, nil, nil, nil)
+
+		result, err := uc.DeleteMany(ctx, userID, notification.DeleteInput{IDs: ids})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), result.UnreadCount)
+	})
+
+	t.Run("needs a filter", func(t *testing.T) {
+		notificationRepo := new(MockNotificationRepository)
+		uc := notification.NewUseCase(notificationRepo, nil, nil, nil, nil)
+
+		_, err := uc.DeleteMany(ctx, userID, notification.DeleteInput{})
+		assert.ErrorIs(t, err, domain.ErrNotificationFilterRequired)
+		notificationRepo.AssertNotCalled(t, "Delete")
+	})
+}
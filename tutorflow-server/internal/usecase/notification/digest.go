@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
+)
+
+// maxDigestItems caps how many notifications are listed in one digest email;
+// the rest are counted
+const maxDigestItems = 50
+
+// GetDigest returns how often the user's notification emails are sent
+func (uc *UseCase) GetDigest(ctx context.Context, userID uuid.UUID) (*domain.NotificationDigest, error) {
+	return uc.prefRepo.GetDigest(ctx, userID)
+}
+
+// SetDigest changes how often the user's notification emails are sent
+func (uc *UseCase) SetDigest(ctx context.Context, userID uuid.UUID, frequency domain.DigestFrequency) (*domain.NotificationDigest, error) {
+	if !domain.ValidDigestFrequency(frequency) {
+		return nil, domain.ValidationErrors{{Field: "frequency", Message: "must be immediate, daily or weekly"}}
+	}
+	if err := uc.prefRepo.SetDigest(ctx, &domain.NotificationDigest{
+		UserID:    userID,
+		Frequency: frequency,
+		UpdatedAt: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+	return uc.prefRepo.GetDigest(ctx, userID)
+}
+
+// SendDigests emails every user whose daily or weekly digest is due a summary
+// of their unread notifications from the period, and marks those
+// notifications digested. Users with nothing new get no email. It returns how
+// many digests were sent.
+func (uc *UseCase) SendDigests(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for _, frequency := range []domain.DigestFrequency{domain.DigestDaily, domain.DigestWeekly} {
+		since := now.Add(-frequency.Period())
+		due, err := uc.prefRepo.GetDueDigests(ctx, frequency, since)
+		if err != nil {
+			return sent, err
+		}
+		for _, digest := range due {
+			ok, err := uc.sendDigest(ctx, digest, since, now)
+			if err != nil {
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+// sendDigest sends one user's digest covering notifications created after
+// since, reporting whether there was anything to send
+func (uc *UseCase) sendDigest(ctx context.Context, digest domain.NotificationDigest, since, now time.Time) (bool, error) {
+	if digest.LastSentAt != nil && digest.LastSentAt.After(since) {
+		since = *digest.LastSentAt
+	}
+	notifications, err := uc.notificationRepo.GetForDigest(ctx, digest.UserID, since)
+	if err != nil {
+		return false, err
+	}
+
+	prefs, _ := uc.prefRepo.GetByUser(ctx, digest.UserID)
+	var included []domain.Notification
+	for _, n := range notifications {
+		if prefs.Allows(n.Type.Category(), domain.NotificationChannelEmail) {
+			included = append(included, n)
+		}
+	}
+
+	send := len(included) > 0 && digest.User != nil && uc.emailSvc != nil
+	if send {
+		items := make([]email.DigestItem, 0, min(len(included), maxDigestItems))
+		for _, n := range included[:min(len(included), maxDigestItems)] {
+			items = append(items, uc.digestItem(n))
+		}
+		if err := uc.emailSvc.SendDigest(ctx, digest.User.Email, digest.User.FirstName, string(digest.Frequency), items, len(included)-len(items)); err != nil {
+			return false, err
+		}
+
+		ids := make([]uuid.UUID, len(included))
+		for i, n := range included {
+			ids[i] = n.ID
+		}
+		if err := uc.notificationRepo.MarkDigested(ctx, ids, now); err != nil {
+			return false, err
+		}
+	}
+
+	if err := uc.prefRepo.MarkDigestSent(ctx, digest.UserID, now); err != nil {
+		return false, err
+	}
+	return send, nil
+}
+
+// digestItem renders a notification for the digest, linking to the page its
+// data points at if there is one
+func (uc *UseCase) digestItem(n domain.Notification) email.DigestItem {
+	item := email.DigestItem{
+		Title: n.Title,
+		Time:  n.CreatedAt.Format("Jan 2, 15:04"),
+	}
+	if n.Message != nil {
+		item.Message = *n.Message
+	}
+	if n.Data != nil {
+		var data struct {
+			Link string `json:"link"`
+		}
+		if json.Unmarshal([]byte(*n.Data), &data) == nil && data.Link != "" {
+			item.Link = uc.emailSvc.AppLink(data.Link)
+		}
+	}
+	return item
+}
@@ -0,0 +1,76 @@
+package notification_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
+)
+
+func TestNotificationUseCase_SetDigest_RejectsUnknownFrequency(t *testing.T) {
+	prefRepo := new(MockNotificationPreferenceRepository)
+	uc := notification.NewUseCase(nil, prefRepo, nil, nil, nil)
+
+	_, err := uc.SetDigest(context.Background(), uuid.New(), "hourly")
+	var verrs domain.ValidationErrors
+	assert.ErrorAs(t, err, &verrs)
+	prefRepo.AssertNotCalled(t, "SetDigest", mock.Anything, mock.Anything)
+}
+
+func TestNotificationUseCase_SendDigests(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	busy := domain.NotificationDigest{UserID: uuid.New(), Frequency: domain.DigestDaily, User: &domain.User{Email: "a@example.com", FirstName: "Ana"}}
+	quiet := domain.NotificationDigest{UserID: uuid.New(), Frequency: domain.DigestDaily, User: &domain.User{Email: "b@example.com", FirstName: "Ben"}}
+	since := now.Add(-24 * time.Hour)
+
+	notificationRepo := new(MockNotificationRepository)
+	prefRepo := new(MockNotificationPreferenceRepository)
+	prefRepo.On("GetDueDigests", ctx, domain.DigestDaily, since).Return([]domain.NotificationDigest{busy, quiet}, nil)
+	prefRepo.On("GetDueDigests", ctx, domain.DigestWeekly, mock.Anything).Return(nil, nil)
+	prefRepo.On("GetByUser", ctx, mock.Anything).Return(domain.NotificationPreferences{
+		{Category: domain.NotificationCategoryPayment, Channel: domain.NotificationChannelEmail, Enabled: false},
+	}, nil)
+	prefRepo.On("MarkDigestSent", ctx, mock.Anything, now).Return(nil)
+
+	graded := domain.Notification{ID: uuid.New(), Type: domain.NotificationGradePosted, Title: "Grade posted"}
+	receipt := domain.Notification{ID: uuid.New(), Type: domain.NotificationPaymentReceived, Title: "Payment received"}
+	notificationRepo.On("GetForDigest", ctx, busy.UserID, since).Return([]domain.Notification{graded, receipt}, nil)
+	notificationRepo.On("GetForDigest", ctx, quiet.UserID, since).Return(nil, nil)
+	notificationRepo.On("MarkDigested", ctx, mock.Anything, now).Return(nil)
+
+	uc := notification.NewUseCase(notificationRepo, prefRepo, nil, email.NewService(config.EmailConfig{}), nil)
+	sent, err := uc.SendDigests(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent, "users with nothing new get no digest")
+
+	notificationRepo.AssertCalled(t, "MarkDigested", ctx, []uuid.UUID{graded.ID}, now)
+	notificationRepo.AssertNumberOfCalls(t, "MarkDigested", 1)
+	prefRepo.AssertCalled(t, "MarkDigestSent", ctx, busy.UserID, now)
+	prefRepo.AssertCalled(t, "MarkDigestSent", ctx, quiet.UserID, now)
+}
+
+func TestNotifier_EmailsNow(t *testing.T) {
+	ctx := context.Background()
+	immediate, daily, optedOut := uuid.New(), uuid.New(), uuid.New()
+
+	prefRepo := new(MockNotificationPreferenceRepository)
+	prefRepo.On("GetByUser", ctx, optedOut).Return(domain.NotificationPreferences{
+		{Category: domain.NotificationCategoryEnrollment, Channel: domain.NotificationChannelEmail, Enabled: false},
+	}, nil)
+	prefRepo.On("GetByUser", ctx, mock.Anything).Return(nil, nil)
+	prefRepo.On("GetDigest", ctx, daily).Return(&domain.NotificationDigest{UserID: daily, Frequency: domain.DigestDaily}, nil)
+	prefRepo.On("GetDigest", ctx, mock.Anything).Return(&domain.NotificationDigest{Frequency: domain.DigestImmediate}, nil)
+	notifier := notification.NewNotifier(nil, prefRepo, nil)
+
+	assert.True(t, notifier.EmailsNow(ctx, immediate, domain.NotificationCategoryEnrollment))
+	assert.False(t, notifier.EmailsNow(ctx, daily, domain.NotificationCategoryEnrollment), "the digest sends it instead")
+	assert.False(t, notifier.EmailsNow(ctx, optedOut, domain.NotificationCategoryEnrollment))
+}
@@ -4,27 +4,42 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
+	"github.com/tutorflow/tutorflow-server/internal/service/realtime"
 )
 
 // UseCase defines notification business logic
 type UseCase struct {
 	notificationRepo repository.NotificationRepository
+	prefRepo         repository.NotificationPreferenceRepository
 	enrollmentRepo   repository.EnrollmentRepository
+	emailSvc         *email.Service
+	hub              *realtime.Hub
+	notifier         *Notifier
 }
 
 // NewUseCase creates a new notification use case
 func NewUseCase(
 	notificationRepo repository.NotificationRepository,
+	prefRepo repository.NotificationPreferenceRepository,
 	enrollmentRepo repository.EnrollmentRepository,
+	emailSvc *email.Service,
+	hub *realtime.Hub,
 ) *UseCase {
 	return &UseCase{
 		notificationRepo: notificationRepo,
+		prefRepo:         prefRepo,
 		enrollmentRepo:   enrollmentRepo,
+		emailSvc:         emailSvc,
+		hub:              hub,
+		notifier:         NewNotifier(notificationRepo, prefRepo, hub),
 	}
 }
 
@@ -39,6 +54,23 @@ func (uc *UseCase) GetNotifications(ctx context.Context, userID uuid.UUID, page,
 	return uc.notificationRepo.GetByUser(ctx, userID, page, limit)
 }
 
+// GetNotificationsAfter returns a user's notifications after a cursor,
+// newest first, and the cursor for the page after them
+func (uc *UseCase) GetNotificationsAfter(ctx context.Context, userID uuid.UUID, cursor pagination.Cursor, limit int) ([]domain.Notification, string, error) {
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+	notifications, err := uc.notificationRepo.GetByUserAfter(ctx, userID, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	notifications, next := pagination.Next(notifications, limit, func(n domain.Notification) pagination.Cursor {
+		return pagination.Cursor{Time: n.CreatedAt, ID: n.ID}
+	})
+	return notifications, next, nil
+}
+
 // GetUnreadCount returns count of unread notifications
 func (uc *UseCase) GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64, error) {
 	return uc.notificationRepo.GetUnreadCount(ctx, userID)
@@ -46,8 +78,36 @@ func (uc *UseCase) GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64,
 
 // MarkAsRead marks a notification as read
 func (uc *UseCase) MarkAsRead(ctx context.Context, notificationID, userID uuid.UUID) error {
-	// TODO: Verify notification belongs to user
-	return uc.notificationRepo.MarkAsRead(ctx, notificationID)
+	_, err := uc.notificationRepo.MarkAsRead(ctx, userID, []uuid.UUID{notificationID})
+	return err
+}
+
+// BulkInput selects notifications by ID
+type BulkInput struct {
+	IDs []uuid.UUID `json:"ids" validate:"required,min=1,max=100"`
+}
+
+// DeleteInput selects notifications to delete by ID, by age or both
+type DeleteInput struct {
+	IDs    []uuid.UUID `json:"ids" validate:"omitempty,max=100"`
+	Before *time.Time  `json:"before"` // only those created before this time
+}
+
+// BulkOutput reports how many notifications changed and the user's unread
+// count afterwards, for the client's badge
+type BulkOutput struct {
+	Count       int64 `json:"count"`
+	UnreadCount int64 `json:"unread_count"`
+}
+
+// MarkManyAsRead marks the user's notifications among the IDs as read.
+// IDs of other users' notifications are ignored.
+func (uc *UseCase) MarkManyAsRead(ctx context.Context, userID uuid.UUID, input BulkInput) (*BulkOutput, error) {
+	count, err := uc.notificationRepo.MarkAsRead(ctx, userID, input.IDs)
+	if err != nil {
+		return nil, err
+	}
+	return uc.bulkOutput(ctx, userID, count)
 }
 
 // MarkAllAsRead marks all notifications as read
@@ -57,8 +117,76 @@ func (uc *UseCase) MarkAllAsRead(ctx context.Context, userID uuid.UUID) error {
 
 // DeleteNotification deletes a notification
 func (uc *UseCase) DeleteNotification(ctx context.Context, notificationID, userID uuid.UUID) error {
-	// TODO: Verify notification belongs to user
-	return uc.notificationRepo.Delete(ctx, notificationID)
+	_, err := uc.notificationRepo.Delete(ctx, userID, []uuid.UUID{notificationID}, nil)
+	return err
+}
+
+// DeleteMany deletes the user's notifications among the IDs, created before
+// the given time, or both. One of them is required so a bare request can't
+// clear everything.
+func (uc *UseCase) DeleteMany(ctx context.Context, userID uuid.UUID, input DeleteInput) (*BulkOutput, error) {
+	if len(input.IDs) == 0 && input.Before == nil {
+		return nil, domain.ErrNotificationFilterRequired
+	}
+	var ids []uuid.UUID
+	if len(input.IDs) > 0 {
+		ids = input.IDs
+	}
+	count, err := uc.notificationRepo.Delete(ctx, userID, ids, input.Before)
+	if err != nil {
+		return nil, err
+	}
+	return uc.bulkOutput(ctx, userID, count)
+}
+
+func (uc *UseCase) bulkOutput(ctx context.Context, userID uuid.UUID, count int64) (*BulkOutput, error) {
+	unread, err := uc.notificationRepo.GetUnreadCount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &BulkOutput{Count: count, UnreadCount: unread}, nil
+}
+
+// GetPreferences returns the user's setting for every category and channel
+func (uc *UseCase) GetPreferences(ctx context.Context, userID uuid.UUID) (domain.NotificationPreferenceMatrix, error) {
+	prefs, err := uc.prefRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return prefs.Matrix(), nil
+}
+
+// UpdatePreferences stores the settings given, leaving the rest unchanged,
+// and returns the full set
+func (uc *UseCase) UpdatePreferences(ctx context.Context, userID uuid.UUID, input domain.NotificationPreferenceMatrix) (domain.NotificationPreferenceMatrix, error) {
+	var errs domain.ValidationErrors
+	var prefs []domain.NotificationPreference
+	for category, channels := range input {
+		if !domain.ValidNotificationCategory(category) {
+			errs = append(errs, domain.ValidationError{Field: string(category), Message: "unknown notification category"})
+			continue
+		}
+		for channel, enabled := range channels {
+			if !domain.ValidNotificationChannel(channel) {
+				errs = append(errs, domain.ValidationError{Field: string(category) + "." + string(channel), Message: "unknown notification channel"})
+				continue
+			}
+			prefs = append(prefs, domain.NotificationPreference{
+				UserID:   userID,
+				Category: category,
+				Channel:  channel,
+				Enabled:  enabled,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := uc.prefRepo.Upsert(ctx, prefs); err != nil {
+		return nil, err
+	}
+	return uc.GetPreferences(ctx, userID)
 }
 
 // --- Notification Sending Helpers ---
@@ -72,7 +200,8 @@ type SendNotificationInput struct {
 	Data    map[string]interface{}  `json:"data,omitempty"`
 }
 
-// Send creates and sends a notification
+// Send creates a notification unless the user has turned off in-app
+// notifications for its category
 func (uc *UseCase) Send(ctx context.Context, input SendNotificationInput) error {
 	var dataStr *string
 	if input.Data != nil {
@@ -86,15 +215,19 @@ func (uc *UseCase) Send(ctx context.Context, input SendNotificationInput) error
 		messagePtr = &input.Message
 	}
 
-	notification := &domain.Notification{
+	return uc.notifier.Notify(ctx, &domain.Notification{
 		UserID:  input.UserID,
 		Type:    input.Type,
 		Title:   input.Title,
 		Message: messagePtr,
 		Data:    dataStr,
-	}
+	})
+}
 
-	return uc.notificationRepo.Create(ctx, notification)
+// Subscribe streams the user's new notifications until the returned
+// function is called
+func (uc *UseCase) Subscribe(userID uuid.UUID) (<-chan realtime.Event, func()) {
+	return uc.hub.Subscribe(userID)
 }
 
 // SendToMany sends notification to multiple users
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/realtime"
+)
+
+// Notifier stores in-app notifications and pushes them to the recipients'
+// open streams. Every use case that notifies users goes through it, so no
+// notification is saved without being published.
+type Notifier struct {
+	notificationRepo repository.NotificationRepository
+	prefRepo         repository.NotificationPreferenceRepository
+	hub              *realtime.Hub
+}
+
+// NewNotifier creates a notifier; a nil hub only stores notifications
+func NewNotifier(
+	notificationRepo repository.NotificationRepository,
+	prefRepo repository.NotificationPreferenceRepository,
+	hub *realtime.Hub,
+) *Notifier {
+	return &Notifier{notificationRepo: notificationRepo, prefRepo: prefRepo, hub: hub}
+}
+
+// Notify stores the notification unless the user has turned its category
+// off in-app, then publishes it
+func (n *Notifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	prefs, _ := n.prefRepo.GetByUser(ctx, notification.UserID)
+	if !prefs.Allows(notification.Type.Category(), domain.NotificationChannelInApp) {
+		return nil
+	}
+
+	if err := n.notificationRepo.Create(ctx, notification); err != nil {
+		return err
+	}
+	n.publish(notification)
+	return nil
+}
+
+// EmailsNow reports whether the user takes emails in category as they
+// happen: their preferences allow the email and they aren't on a digest,
+// which sends the matching notification instead. Every per-event email
+// checks it; account emails such as password resets don't.
+func (n *Notifier) EmailsNow(ctx context.Context, userID uuid.UUID, category domain.NotificationCategory) bool {
+	prefs, _ := n.prefRepo.GetByUser(ctx, userID)
+	if !prefs.Allows(category, domain.NotificationChannelEmail) {
+		return false
+	}
+	digest, err := n.prefRepo.GetDigest(ctx, userID)
+	return err == nil && digest.Frequency == domain.DigestImmediate
+}
+
+// NotifyBatch stores notifications in one insert and publishes each. The
+// caller has already applied the recipients' preferences.
+func (n *Notifier) NotifyBatch(ctx context.Context, notifications []domain.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := n.notificationRepo.CreateBatch(ctx, notifications); err != nil {
+		return err
+	}
+	for i := range notifications {
+		n.publish(&notifications[i])
+	}
+	return nil
+}
+
+func (n *Notifier) publish(notification *domain.Notification) {
+	if n.hub != nil {
+		n.hub.Publish(notification.UserID, realtime.Event{Type: "notification", Data: notification})
+	}
+}
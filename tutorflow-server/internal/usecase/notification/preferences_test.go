@@ -0,0 +1,190 @@
+package notification_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/pagination"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
+)
+
+// MockNotificationRepository is a mock implementation of NotificationRepository
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(ctx context.Context, n *domain.Notification) error {
+	return m.Called(ctx, n).Error(0)
+}
+
+func (m *MockNotificationRepository) GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Notification, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	return args.Get(0).([]domain.Notification), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockNotificationRepository) GetByUserAfter(ctx context.Context, userID uuid.UUID, cursor pagination.Cursor, limit int) ([]domain.Notification, error) {
+	args := m.Called(ctx, userID, cursor, limit)
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkAsRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID, ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkAllAsRead(ctx context.Context, userID uuid.UUID) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *MockNotificationRepository) Delete(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, before *time.Time) (int64, error) {
+	args := m.Called(ctx, userID, ids, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepository) GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepository) GetForDigest(ctx context.Context, userID uuid.UUID, since time.Time) ([]domain.Notification, error) {
+	args := m.Called(ctx, userID, since)
+	notifications, _ := args.Get(0).([]domain.Notification)
+	return notifications, args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkDigested(ctx context.Context, ids []uuid.UUID, at time.Time) error {
+	return m.Called(ctx, ids, at).Error(0)
+}
+
+func (m *MockNotificationRepository) CreateBatch(ctx context.Context, notifications []domain.Notification) error {
+	return m.Called(ctx, notifications).Error(0)
+}
+
+// MockNotificationPreferenceRepository is a mock implementation of NotificationPreferenceRepository
+type MockNotificationPreferenceRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationPreferenceRepository) GetByUser(ctx context.Context, userID uuid.UUID) (domain.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	prefs, _ := args.Get(0).(domain.NotificationPreferences)
+	return prefs, args.Error(1)
+}
+
+func (m *MockNotificationPreferenceRepository) Upsert(ctx context.Context, prefs []domain.NotificationPreference) error {
+	return m.Called(ctx, prefs).Error(0)
+}
+
+func (m *MockNotificationPreferenceRepository) GetDigest(ctx context.Context, userID uuid.UUID) (*domain.NotificationDigest, error) {
+	args := m.Called(ctx, userID)
+	digest, _ := args.Get(0).(*domain.NotificationDigest)
+	return digest, args.Error(1)
+}
+
+func (m *MockNotificationPreferenceRepository) SetDigest(ctx context.Context, digest *domain.NotificationDigest) error {
+	return m.Called(ctx, digest).Error(0)
+}
+
+func (m *MockNotificationPreferenceRepository) GetDueDigests(ctx context.Context, frequency domain.DigestFrequency, sentBefore time.Time) ([]domain.NotificationDigest, error) {
+	args := m.Called(ctx, frequency, sentBefore)
+	digests, _ := args.Get(0).([]domain.NotificationDigest)
+	return digests, args.Error(1)
+}
+
+func (m *MockNotificationPreferenceRepository) MarkDigestSent(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return m.Called(ctx, userID, at).Error(0)
+}
+
+func (m *MockNotificationPreferenceRepository) GetByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.NotificationPreferences, error) {
+	args := m.Called(ctx, userIDs)
+	prefs, _ := args.Get(0).(map[uuid.UUID]domain.NotificationPreferences)
+	return prefs, args.Error(1)
+}
+
+func (m *MockNotificationPreferenceRepository) GetDigestFrequencies(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]domain.DigestFrequency, error) {
+	args := m.Called(ctx, userIDs)
+	frequencies, _ := args.Get(0).(map[uuid.UUID]domain.DigestFrequency)
+	return frequencies, args.Error(1)
+}
+
+func TestNotificationPreferences_Defaults(t *testing.T) {
+	var prefs domain.NotificationPreferences
+	assert.True(t, prefs.Allows(domain.NotificationCategoryGrade, domain.NotificationChannelEmail))
+	assert.False(t, prefs.Allows(domain.NotificationCategoryMarketing, domain.NotificationChannelEmail), "marketing is opt-in")
+
+	prefs = domain.NotificationPreferences{
+		{Category: domain.NotificationCategoryGrade, Channel: domain.NotificationChannelEmail, Enabled: false},
+		{Category: domain.NotificationCategoryMarketing, Channel: domain.NotificationChannelPush, Enabled: true},
+	}
+	matrix := prefs.Matrix()
+	assert.Len(t, matrix, len(domain.NotificationCategories))
+	assert.False(t, matrix[domain.NotificationCategoryGrade][domain.NotificationChannelEmail])
+	assert.True(t, matrix[domain.NotificationCategoryGrade][domain.NotificationChannelPush])
+	assert.True(t, matrix[domain.NotificationCategoryMarketing][domain.NotificationChannelPush])
+	assert.False(t, matrix[domain.NotificationCategoryMarketing][domain.NotificationChannelInApp])
+}
+
+func TestNotificationUseCase_UpdatePreferences(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("stores only the settings given", func(t *testing.T) {
+		prefRepo := new(MockNotificationPreferenceRepository)
+		var stored []domain.NotificationPreference
+		prefRepo.On("Upsert", ctx, mock.Anything).
+			Run(func(args mock.Arguments) { stored = args.Get(1).([]domain.NotificationPreference) }).
+			Return(nil)
+		prefRepo.On("GetByUser", ctx, userID).Return(nil, nil)
+		uc := notification.NewUseCase(nil, prefRepo, nil, nil, nil)
+
+		_, err := uc.UpdatePreferences(ctx, userID, domain.NotificationPreferenceMatrix{
+			domain.NotificationCategoryMarketing: {domain.NotificationChannelEmail: true},
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, stored, 1) {
+			assert.Equal(t, userID, stored[0].UserID)
+			assert.Equal(t, domain.NotificationCategoryMarketing, stored[0].Category)
+			assert.True(t, stored[0].Enabled)
+		}
+	})
+
+	t.Run("rejects unknown categories and channels", func(t *testing.T) {
+		prefRepo := new(MockNotificationPreferenceRepository)
+		uc := notification.NewUseCase(nil, prefRepo, nil, nil, nil)
+
+		_, err := uc.UpdatePreferences(ctx, userID, domain.NotificationPreferenceMatrix{
+			"newsletter":                       {domain.NotificationChannelEmail: false},
+			domain.NotificationCategoryGrade:   {"sms": false},
+			domain.NotificationCategoryMessage: {domain.NotificationChannelPush: false},
+		})
+		var verrs domain.ValidationErrors
+		if assert.ErrorAs(t, err, &verrs) {
+			assert.Len(t, verrs, 2)
+		}
+		prefRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+	})
+}
+
+func TestNotificationUseCase_Send_RespectsPreferences(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	notificationRepo := new(MockNotificationRepository)
+	prefRepo := new(MockNotificationPreferenceRepository)
+	prefRepo.On("GetByUser", ctx, userID).Return(domain.NotificationPreferences{
+		{Category: domain.NotificationCategoryGrade, Channel: domain.NotificationChannelInApp, Enabled: false},
+	}, nil)
+	notificationRepo.On("Create", ctx, mock.Anything).Return(nil)
+	uc := notification.NewUseCase(notificationRepo, prefRepo, nil, nil, nil)
+
+	assert.NoError(t, uc.NotifyGradePosted(ctx, userID, "Quiz 1", "Go Basics", 9))
+	notificationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	assert.NoError(t, uc.NotifyPaymentReceived(ctx, userID, 49, "ORD-1"))
+	notificationRepo.AssertNumberOfCalls(t, "Create", 1)
+}
@@ -0,0 +1,70 @@
+package notification_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/service/realtime"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
+)
+
+func TestNotificationUseCase_Send_PublishesToSubscribers(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	notificationRepo := new(MockNotificationRepository)
+	prefRepo := new(MockNotificationPreferenceRepository)
+	prefRepo.On("GetByUser", ctx, mock.Anything).Return(nil, nil)
+	notificationRepo.On("Create", ctx, mock.Anything).Return(nil)
+	hub := realtime.NewHub()
+	uc := notification.NewUseCase(notificationRepo, prefRepo, nil, nil, hub)
+
+	events, unsubscribe := uc.Subscribe(userID)
+	assert.True(t, hub.Connected(userID))
+
+	assert.NoError(t, uc.NotifyPaymentReceived(ctx, userID, 49, "ORD-1"))
+	assert.NoError(t, uc.NotifyPaymentReceived(ctx, uuid.New(), 49, "ORD-2"), "users with no connection are skipped")
+
+	event := <-events
+	assert.Equal(t, "notification", event.Type)
+	if n, ok := event.Data.(*domain.Notification); assert.True(t, ok) {
+		assert.Equal(t, userID, n.UserID)
+	}
+	assert.Empty(t, events)
+
+	unsubscribe()
+	assert.False(t, hub.Connected(userID))
+	_, open := <-events
+	assert.False(t, open)
+}
+
+func TestNotifier_NotifyBatch_PublishesEach(t *testing.T) {
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+	notifications := []domain.Notification{
+		{UserID: first, Type: domain.NotificationAnnouncement, Title: "New Announcement"},
+		{UserID: second, Type: domain.NotificationAnnouncement, Title: "New Announcement"},
+	}
+
+	notificationRepo := new(MockNotificationRepository)
+	notificationRepo.On("CreateBatch", ctx, notifications).Return(nil)
+	hub := realtime.NewHub()
+	notifier := notification.NewNotifier(notificationRepo, nil, hub)
+
+	events, unsubscribe := hub.Subscribe(second)
+	defer unsubscribe()
+
+	assert.NoError(t, notifier.NotifyBatch(ctx, notifications))
+	event := <-events
+	if n, ok := event.Data.(*domain.Notification); assert.True(t, ok) {
+		assert.Equal(t, second, n.UserID)
+	}
+	assert.Empty(t, events, "only the subscriber's own notification is pushed")
+
+	assert.NoError(t, notifier.NotifyBatch(ctx, nil))
+	notificationRepo.AssertNumberOfCalls(t, "CreateBatch", 1)
+}
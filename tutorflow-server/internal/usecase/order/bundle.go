@@ -0,0 +1,138 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/service/payment"
+)
+
+// CreateBundleCheckout creates a Stripe Checkout session for a bundle. The
+// order holds one item per course so enrollment and instructor earnings follow
+// the same path as a cart purchase, with the bundle price spread across the
+// courses in proportion to their own prices. Courses the user already owns
+// are left out.
+func (uc *UseCase) CreateBundleCheckout(ctx context.Context, userID uuid.UUID, email string, bundleID uuid.UUID) (*domain.CreateOrderOutput, error) {
+	if err := uc.requireVerifiedEmail(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	bundle, err := uc.bundleRepo.GetByID(ctx, bundleID)
+	if err != nil {
+		return nil, domain.ErrBundleNotAvailable
+	}
+	if !bundle.IsAvailable() {
+		return nil, domain.ErrBundleNotAvailable
+	}
+
+	courses, err := uc.bundleRepo.GetCourses(ctx, bundleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var listTotal, subtotal float64
+	var toBuy []domain.Course
+	var weights []float64
+	for _, course := range courses {
+		if course.ID == uuid.Nil {
+			continue
+		}
+		price := course.GetEffectivePrice()
+		listTotal += price
+
+		existing, _ := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, course.ID)
+		if existing != nil {
+			continue
+		}
+		toBuy = append(toBuy, course)
+		weights = append(weights, price)
+		subtotal += price
+	}
+	if len(toBuy) == 0 {
+		return nil, domain.ErrBundleAlreadyOwned
+	}
+
+	total := bundle.BundlePrice
+	if bundle.DeductOwnedCourses && listTotal > 0 && subtotal < listTotal {
+		total = math.Round(total*subtotal/listTotal*100) / 100
+	}
+	discount := subtotal - total
+	if discount < 0 {
+		discount = 0
+	}
+
+	prices := domain.ProrateAmount(total, weights)
+	orderItems := make([]domain.OrderItem, len(toBuy))
+	for i, course := range toBuy {
+		orderItems[i] = domain.OrderItem{
+			CourseID:        course.ID,
+			Price:           prices[i],
+			InstructorShare: prices[i] * (1 - domain.PlatformFeePercent),
+		}
+	}
+
+	order := &domain.Order{
+		OrderNumber: domain.GenerateOrderNumber(),
+		UserID:      userID,
+		Status:      domain.OrderStatusPending,
+		Subtotal:    subtotal,
+		Discount:    discount,
+		Total:       total,
+		BundleID:    &bundle.ID,
+		Items:       orderItems,
+	}
+
+	if err := uc.orderRepo.Create(ctx, order); err != nil {
+		return nil, err
+	}
+
+	// Handle free orders
+	if total == 0 {
+		return uc.completeOrder(ctx, order)
+	}
+
+	session, err := uc.paymentSvc.CreateCheckoutSession(ctx, payment.CreateCheckoutSessionInput{
+		CustomerEmail: email,
+		OrderID:       order.ID.String(),
+		Items: []payment.LineItem{{
+			Name:        bundle.Title,
+			Description: fmt.Sprintf("Bundle of %d courses", len(toBuy)),
+			Amount:      int64(math.Round(total * 100)),
+			Quantity:    1,
+			ImageURL:    bundle.ThumbnailURL,
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+
+	paymentMethod := domain.PaymentMethodStripe
+	order.PaymentMethod = &paymentMethod
+	if err := uc.orderRepo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return &domain.CreateOrderOutput{
+		Order:       order,
+		CheckoutURL: session.URL,
+	}, nil
+}
+
+// recordBundlePurchase records a completed bundle order against the bundle
+func (uc *UseCase) recordBundlePurchase(ctx context.Context, order *domain.Order) {
+	purchase := &domain.BundlePurchase{
+		BundleID: *order.BundleID,
+		UserID:   order.UserID,
+		OrderID:  order.ID,
+		Price:    order.Total,
+	}
+	if err := uc.bundleRepo.RecordPurchase(ctx, purchase); err != nil {
+		uc.logger.Errorw("Failed to record bundle purchase", "order_id", order.ID, "bundle_id", *order.BundleID, "error", err)
+		return
+	}
+	_ = uc.bundleRepo.IncrementPurchaseCount(ctx, *order.BundleID)
+}
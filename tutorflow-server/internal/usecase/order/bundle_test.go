@@ -0,0 +1,209 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/order"
+)
+
+// The fakes embed the repository interfaces so only the methods a bundle
+// checkout touches need implementing; anything else panics.
+
+type fakeUserRepository struct {
+	repository.UserRepository
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	verified := time.Now()
+	return &domain.User{ID: id, EmailVerifiedAt: &verified}, nil
+}
+
+type fakeBundleRepository struct {
+	repository.BundleRepository
+	bundle    *domain.Bundle
+	courses   []domain.Course
+	purchases []domain.BundlePurchase
+}
+
+func (r *fakeBundleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Bundle, error) {
+	return r.bundle, nil
+}
+
+func (r *fakeBundleRepository) GetCourses(ctx context.Context, bundleID uuid.UUID) ([]domain.Course, error) {
+	return r.courses, nil
+}
+
+func (r *fakeBundleRepository) RecordPurchase(ctx context.Context, purchase *domain.BundlePurchase) error {
+	r.purchases = append(r.purchases, *purchase)
+	return nil
+}
+
+func (r *fakeBundleRepository) IncrementPurchaseCount(ctx context.Context, bundleID uuid.UUID) error {
+	r.bundle.PurchaseCount++
+	return nil
+}
+
+type fakeEnrollmentRepository struct {
+	repository.EnrollmentRepository
+	owned   map[uuid.UUID]bool
+	created []domain.Enrollment
+}
+
+func (r *fakeEnrollmentRepository) GetByUserAndCourse(ctx context.Context, userID, courseID uuid.UUID) (*domain.Enrollment, error) {
+	if r.owned[courseID] {
+		return &domain.Enrollment{UserID: userID, CourseID: courseID, Status: domain.EnrollmentStatusActive}, nil
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeEnrollmentRepository) Create(ctx context.Context, enrollment *domain.Enrollment) error {
+	r.created = append(r.created, *enrollment)
+	return nil
+}
+
+type fakeOrderRepository struct {
+	repository.OrderRepository
+	orders []*domain.Order
+	// completedElsewhere makes Complete report that another confirmation
+	// already completed the order
+	completedElsewhere bool
+}
+
+func (r *fakeOrderRepository) Create(ctx context.Context, o *domain.Order) error {
+	o.ID = uuid.New()
+	r.orders = append(r.orders, o)
+	return nil
+}
+
+func (r *fakeOrderRepository) Update(ctx context.Context, o *domain.Order) error {
+	return nil
+}
+
+func (r *fakeOrderRepository) Complete(ctx context.Context, o *domain.Order) (bool, error) {
+	if r.completedElsewhere {
+		return false, nil
+	}
+	now := time.Now()
+	o.Status = domain.OrderStatusCompleted
+	o.PaidAt = &now
+	return true, nil
+}
+
+func (r *fakeOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Order, error) {
+	for _, o := range r.orders {
+		if o.ID == id {
+			completed := *o
+			completed.Status = domain.OrderStatusCompleted
+			return &completed, nil
+		}
+	}
+	return nil, domain.ErrOrderNotFound
+}
+
+type fakeCourseRepository struct {
+	repository.CourseRepository
+}
+
+func (r *fakeCourseRepository) IncrementStudentCount(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func newBundleCheckout(bundles *fakeBundleRepository, enrollments *fakeEnrollmentRepository, orders *fakeOrderRepository) *order.UseCase {
+	// The cart and coupon repositories are nil: a bundle order must not
+	// touch the buyer's cart
+	return order.NewUseCase(orders, nil, nil, enrollments, &fakeCourseRepository{}, bundles, nil, &fakeUserRepository{}, nil, nil, zap.NewNop().Sugar())
+}
+
+func TestCreateBundleCheckout_SkipsOwnedCourses(t *testing.T) {
+	owned := domain.Course{ID: uuid.New(), Price: 40}
+	other := domain.Course{ID: uuid.New(), Price: 60}
+	bundles := &fakeBundleRepository{
+		bundle:  &domain.Bundle{ID: uuid.New(), Title: "Launch promo", IsActive: true},
+		courses: []domain.Course{owned, other},
+	}
+	enrollments := &fakeEnrollmentRepository{owned: map[uuid.UUID]bool{owned.ID: true}}
+	orders := &fakeOrderRepository{}
+	userID := uuid.New()
+
+	output, err := newBundleCheckout(bundles, enrollments, orders).
+		CreateBundleCheckout(context.Background(), userID, "learner@example.com", bundles.bundle.ID)
+	require.NoError(t, err)
+
+	// A free bundle completes straight away
+	assert.Equal(t, domain.OrderStatusCompleted, output.Order.Status)
+	assert.Equal(t, bundles.bundle.ID, *output.Order.BundleID)
+	require.Len(t, output.Order.Items, 1)
+	assert.Equal(t, other.ID, output.Order.Items[0].CourseID)
+
+	require.Len(t, enrollments.created, 1)
+	assert.Equal(t, other.ID, enrollments.created[0].CourseID)
+	assert.Equal(t, output.Order.ID, *enrollments.created[0].OrderID)
+
+	require.Len(t, bundles.purchases, 1)
+	assert.Equal(t, userID, bundles.purchases[0].UserID)
+	assert.Equal(t, 1, bundles.bundle.PurchaseCount)
+}
+
+func TestCreateBundleCheckout_CompletedElsewhereIsNotFulfilledAgain(t *testing.T) {
+	course := domain.Course{ID: uuid.New(), Price: 40}
+	bundles := &fakeBundleRepository{
+		bundle:  &domain.Bundle{ID: uuid.New(), Title: "Launch promo", IsActive: true},
+		courses: []domain.Course{course},
+	}
+	enrollments := &fakeEnrollmentRepository{}
+	orders := &fakeOrderRepository{completedElsewhere: true}
+
+	output, err := newBundleCheckout(bundles, enrollments, orders).
+		CreateBundleCheckout(context.Background(), uuid.New(), "learner@example.com", bundles.bundle.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.OrderStatusCompleted, output.Order.Status)
+	assert.Empty(t, enrollments.created, "the confirmation that completed the order already enrolled the buyer")
+	assert.Empty(t, bundles.purchases)
+	assert.Zero(t, bundles.bundle.PurchaseCount)
+}
+
+func TestCreateBundleCheckout_AllCoursesOwned(t *testing.T) {
+	course := domain.Course{ID: uuid.New(), Price: 40}
+	bundles := &fakeBundleRepository{
+		bundle:  &domain.Bundle{ID: uuid.New(), BundlePrice: 30, IsActive: true},
+		courses: []domain.Course{course},
+	}
+	enrollments := &fakeEnrollmentRepository{owned: map[uuid.UUID]bool{course.ID: true}}
+	orders := &fakeOrderRepository{}
+
+	_, err := newBundleCheckout(bundles, enrollments, orders).
+		CreateBundleCheckout(context.Background(), uuid.New(), "learner@example.com", bundles.bundle.ID)
+	assert.ErrorIs(t, err, domain.ErrBundleAlreadyOwned)
+	assert.Empty(t, orders.orders)
+}
+
+func TestCreateBundleCheckout_Unavailable(t *testing.T) {
+	bundles := &fakeBundleRepository{
+		bundle:  &domain.Bundle{ID: uuid.New(), BundlePrice: 30, IsActive: false},
+		courses: []domain.Course{{ID: uuid.New(), Price: 40}},
+	}
+	orders := &fakeOrderRepository{}
+
+	_, err := newBundleCheckout(bundles, &fakeEnrollmentRepository{}, orders).
+		CreateBundleCheckout(context.Background(), uuid.New(), "learner@example.com", bundles.bundle.ID)
+	assert.ErrorIs(t, err, domain.ErrBundleNotAvailable)
+	assert.Empty(t, orders.orders)
+}
+
+func TestProrateAmount(t *testing.T) {
+	assert.Equal(t, []float64{50, 30, 20}, domain.ProrateAmount(100, []float64{50, 30, 20}))
+	assert.Equal(t, []float64{3.34, 3.33, 3.33}, domain.ProrateAmount(10, []float64{1, 1, 1}))
+	assert.Equal(t, []float64{5, 5}, domain.ProrateAmount(10, []float64{0, 0}))
+	assert.Empty(t, domain.ProrateAmount(10, nil))
+}
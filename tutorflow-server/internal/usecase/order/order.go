@@ -6,8 +6,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/metrics"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
 	"github.com/tutorflow/tutorflow-server/internal/service/payment"
 )
@@ -19,7 +21,12 @@ type UseCase struct {
 	couponRepo     repository.CouponRepository
 	enrollmentRepo repository.EnrollmentRepository
 	courseRepo     repository.CourseRepository
+	bundleRepo     repository.BundleRepository
+	earningRepo    repository.EarningRepository
+	userRepo       repository.UserRepository
+	auditRepo      repository.AuditLogRepository
 	paymentSvc     *payment.Service
+	logger         *zap.SugaredLogger
 }
 
 // NewUseCase creates a new order use case
@@ -29,7 +36,12 @@ func NewUseCase(
 	couponRepo repository.CouponRepository,
 	enrollmentRepo repository.EnrollmentRepository,
 	courseRepo repository.CourseRepository,
+	bundleRepo repository.BundleRepository,
+	earningRepo repository.EarningRepository,
+	userRepo repository.UserRepository,
+	auditRepo repository.AuditLogRepository,
 	paymentSvc *payment.Service,
+	logger *zap.SugaredLogger,
 ) *UseCase {
 	return &UseCase{
 		orderRepo:      orderRepo,
@@ -37,7 +49,12 @@ func NewUseCase(
 		couponRepo:     couponRepo,
 		enrollmentRepo: enrollmentRepo,
 		courseRepo:     courseRepo,
+		bundleRepo:     bundleRepo,
+		earningRepo:    earningRepo,
+		userRepo:       userRepo,
+		auditRepo:      auditRepo,
 		paymentSvc:     paymentSvc,
+		logger:         logger,
 	}
 }
 
@@ -67,8 +84,24 @@ func (uc *UseCase) GetByCheckoutSession(ctx context.Context, sessionID string) (
 	return uc.ConfirmCheckout(ctx, sessionID)
 }
 
+// requireVerifiedEmail stops users who haven't confirmed their email from buying
+func (uc *UseCase) requireVerifiedEmail(ctx context.Context, userID uuid.UUID) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsEmailVerified() {
+		return domain.ErrEmailNotVerified
+	}
+	return nil
+}
+
 // CreateOrder creates an order from cart
 func (uc *UseCase) CreateOrder(ctx context.Context, userID uuid.UUID, email string, input domain.CreateOrderInput) (*domain.CreateOrderOutput, error) {
+	if err := uc.requireVerifiedEmail(ctx, userID); err != nil {
+		return nil, err
+	}
+
 	// Get user's cart
 	cart, err := uc.cartRepo.GetOrCreate(ctx, &userID, nil)
 	if err != nil {
@@ -84,8 +117,6 @@ func (uc *UseCase) CreateOrder(ctx context.Context, userID uuid.UUID, email stri
 	var orderItems []domain.OrderItem
 	var lineItems []payment.LineItem
 
-	platformFeePercent := 0.30 // 30% platform fee
-
 	for _, item := range cart.Items {
 		course, err := uc.courseRepo.GetByID(ctx, item.CourseID)
 		if err != nil {
@@ -93,7 +124,7 @@ func (uc *UseCase) CreateOrder(ctx context.Context, userID uuid.UUID, email stri
 		}
 
 		price := course.GetEffectivePrice()
-		instructorShare := price * (1 - platformFeePercent)
+		instructorShare := price * (1 - domain.PlatformFeePercent)
 
 		orderItems = append(orderItems, domain.OrderItem{
 			CourseID:        course.ID,
@@ -176,6 +207,10 @@ func (uc *UseCase) CreateOrder(ctx context.Context, userID uuid.UUID, email stri
 
 // CreateCheckout creates a Stripe Checkout session from cart
 func (uc *UseCase) CreateCheckout(ctx context.Context, userID uuid.UUID, email string, input domain.CreateOrderInput) (*domain.CreateOrderOutput, error) {
+	if err := uc.requireVerifiedEmail(ctx, userID); err != nil {
+		return nil, err
+	}
+
 	// Get user's cart
 	cart, err := uc.cartRepo.GetOrCreate(ctx, &userID, nil)
 	if err != nil {
@@ -191,8 +226,6 @@ func (uc *UseCase) CreateCheckout(ctx context.Context, userID uuid.UUID, email s
 	var orderItems []domain.OrderItem
 	var lineItems []payment.LineItem
 
-	platformFeePercent := 0.30
-
 	for _, item := range cart.Items {
 		course, err := uc.courseRepo.GetByID(ctx, item.CourseID)
 		if err != nil {
@@ -200,7 +233,7 @@ func (uc *UseCase) CreateCheckout(ctx context.Context, userID uuid.UUID, email s
 		}
 
 		price := course.GetEffectivePrice()
-		instructorShare := price * (1 - platformFeePercent)
+		instructorShare := price * (1 - domain.PlatformFeePercent)
 
 		orderItems = append(orderItems, domain.OrderItem{
 			CourseID:        course.ID,
@@ -297,12 +330,10 @@ func (uc *UseCase) ConfirmPayment(ctx context.Context, paymentIntentID string) (
 	}
 
 	if pi.Status != "succeeded" {
-		fmt.Printf("[ORDER DEBUG] Payment intent %s status: %s\n", paymentIntentID, pi.Status)
+		uc.logger.Debugw("Payment intent not succeeded", "payment_intent_id", paymentIntentID, "status", pi.Status)
 		return nil, fmt.Errorf("payment not completed: %s", pi.Status)
 	}
 
-	fmt.Printf("[ORDER DEBUG] Payment intent %s SUCCEEDED. Fulfilling order %s...\n", paymentIntentID, order.ID)
-
 	// Complete the order
 	output, err := uc.completeOrder(ctx, order)
 	if err != nil {
@@ -321,12 +352,10 @@ func (uc *UseCase) ConfirmCheckout(ctx context.Context, sessionID string) (*doma
 	}
 
 	if session.PaymentStatus != "paid" {
-		fmt.Printf("[ORDER DEBUG] Session %s not paid yet: %s\n", sessionID, session.PaymentStatus)
+		uc.logger.Debugw("Checkout session not paid", "session_id", sessionID, "payment_status", session.PaymentStatus)
 		return nil, fmt.Errorf("session not paid: %s", session.PaymentStatus)
 	}
 
-	fmt.Printf("[ORDER DEBUG] Session %s PAID. Fulfilling...\n", sessionID)
-
 	// Get order ID from metadata
 	orderIDStr, ok := session.Metadata["order_id"]
 	if !ok {
@@ -358,25 +387,34 @@ func (uc *UseCase) ConfirmCheckout(ctx context.Context, sessionID string) (*doma
 	return output.Order, nil
 }
 
-// completeOrder marks order as complete and creates enrollments
+// completeOrder marks order as complete and creates enrollments. Only the
+// call that moves the order to completed fulfils it; a repeated webhook or
+// confirmation gets the completed order back without paying out again.
 func (uc *UseCase) completeOrder(ctx context.Context, order *domain.Order) (*domain.CreateOrderOutput, error) {
-	now := time.Now()
-	order.Status = domain.OrderStatusCompleted
-	order.PaidAt = &now
-
-	if err := uc.orderRepo.Update(ctx, order); err != nil {
-		fmt.Printf("[ORDER DEBUG] Failed to update order %s to completed: %v\n", order.ID, err)
+	completed, err := uc.orderRepo.Complete(ctx, order)
+	if err != nil {
+		uc.logger.Errorw("Failed to complete order", "order_id", order.ID, "error", err)
 		return nil, err
 	}
+	if !completed {
+		uc.logger.Debugw("Order already completed, skipping fulfilment", "order_id", order.ID)
+		current, err := uc.orderRepo.GetByID(ctx, order.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.CreateOrderOutput{Order: current}, nil
+	}
 
-	fmt.Printf("[ORDER DEBUG] Order %s marked COMPLETED\n", order.ID)
+	uc.logger.Infow("Order completed", "order_id", order.ID)
+	metrics.OrdersCompleted.Inc()
+	now := *order.PaidAt
 
 	// Create enrollments for each course
 	for _, item := range order.Items {
 		// Check if student is already enrolled in this course (idempotency)
 		existing, _ := uc.enrollmentRepo.GetByUserAndCourse(ctx, order.UserID, item.CourseID)
 		if existing != nil {
-			fmt.Printf("[ORDER DEBUG] User %s already enrolled in course %s, skipping\n", order.UserID, item.CourseID)
+			uc.logger.Debugw("Already enrolled, skipping", "user_id", order.UserID, "course_id", item.CourseID)
 			continue
 		}
 
@@ -388,19 +426,26 @@ func (uc *UseCase) completeOrder(ctx context.Context, order *domain.Order) (*dom
 			OrderID:   &order.ID,
 		}
 		if err := uc.enrollmentRepo.Create(ctx, enrollment); err != nil {
-			fmt.Printf("[ORDER DEBUG] Failed to create enrollment for course %s: %v\n", item.CourseID, err)
-		} else {
-			fmt.Printf("[ORDER DEBUG] Created enrollment for course %s\n", item.CourseID)
+			uc.logger.Errorw("Failed to create enrollment", "order_id", order.ID, "course_id", item.CourseID, "error", err)
 		}
 
 		// Update course student count
 		_ = uc.courseRepo.IncrementStudentCount(ctx, item.CourseID)
 	}
 
-	// Clear cart
-	cart, _ := uc.cartRepo.GetOrCreate(ctx, &order.UserID, nil)
-	if cart != nil {
-		_ = uc.cartRepo.Clear(ctx, cart.ID)
+	// Record instructor earnings, split across co-instructors
+	for _, item := range order.Items {
+		uc.createEarnings(ctx, item)
+	}
+
+	if order.BundleID != nil {
+		uc.recordBundlePurchase(ctx, order)
+	} else {
+		// Clear cart
+		cart, _ := uc.cartRepo.GetOrCreate(ctx, &order.UserID, nil)
+		if cart != nil {
+			_ = uc.cartRepo.Clear(ctx, cart.ID)
+		}
 	}
 
 	// Increment coupon usage
@@ -411,6 +456,50 @@ func (uc *UseCase) completeOrder(ctx context.Context, order *domain.Order) (*dom
 	return &domain.CreateOrderOutput{Order: order}, nil
 }
 
+// createEarnings distributes an order item's instructor share across the
+// course's co-instructors, or to the owner when there are none
+func (uc *UseCase) createEarnings(ctx context.Context, item domain.OrderItem) {
+	if item.InstructorShare <= 0 {
+		return
+	}
+
+	splits, err := uc.courseRepo.GetInstructorSplits(ctx, item.CourseID)
+	if err != nil {
+		uc.logger.Errorw("Failed to load revenue splits", "course_id", item.CourseID, "error", err)
+		return
+	}
+	if len(splits) == 0 {
+		course := item.Course
+		if course == nil {
+			if course, err = uc.courseRepo.GetByID(ctx, item.CourseID); err != nil {
+				uc.logger.Errorw("Failed to load course for earnings", "course_id", item.CourseID, "error", err)
+				return
+			}
+		}
+		splits = []domain.CourseInstructor{{CourseID: item.CourseID, InstructorID: course.InstructorID, SharePercent: 100}}
+	}
+
+	platformFee := item.Price - item.InstructorShare
+	if platformFee < 0 {
+		platformFee = 0
+	}
+
+	amounts := domain.SplitAmount(item.InstructorShare, splits)
+	fees := domain.SplitAmount(platformFee, splits)
+	for i, split := range splits {
+		earning := &domain.InstructorEarning{
+			InstructorID: split.InstructorID,
+			OrderItemID:  item.ID,
+			Amount:       amounts[i],
+			PlatformFee:  fees[i],
+			Status:       "pending",
+		}
+		if err := uc.earningRepo.Create(ctx, earning); err != nil {
+			uc.logger.Errorw("Failed to create earning", "order_item_id", item.ID, "instructor_id", split.InstructorID, "error", err)
+		}
+	}
+}
+
 // HandleWebhook handles Stripe webhook events
 func (uc *UseCase) HandleWebhook(ctx context.Context, eventType string, payloadID string) error {
 	switch eventType {
@@ -462,6 +551,7 @@ type CreateCouponInput struct {
 // CreateCoupon creates a new coupon (admin)
 func (uc *UseCase) CreateCoupon(ctx context.Context, input domain.CreateCouponInput, createdBy uuid.UUID) (*domain.Coupon, error) {
 	coupon := &domain.Coupon{
+		ID:          uuid.New(), // known up front so the audit entry can refer to it
 		Code:        input.Code,
 		CouponType:  input.CouponType,
 		Value:       input.Value,
@@ -473,7 +563,8 @@ func (uc *UseCase) CreateCoupon(ctx context.Context, input domain.CreateCouponIn
 		IsActive:    true,
 	}
 
-	if err := uc.couponRepo.Create(ctx, coupon); err != nil {
+	entry := domain.NewAuditLog(createdBy, domain.AuditCouponCreate, "coupon", coupon.ID, nil, coupon)
+	if err := uc.auditRepo.Record(ctx, entry, coupon); err != nil {
 		return nil, err
 	}
 
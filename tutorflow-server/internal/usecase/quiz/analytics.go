@@ -0,0 +1,134 @@
+package quiz
+
+import (
+	"context"
+	"math"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+)
+
+// QuizAnalytics summarizes how students performed on a quiz
+type QuizAnalytics struct {
+	QuizID            uuid.UUID           `json:"quiz_id"`
+	Attempts          int64               `json:"attempts"`
+	PassRate          float64             `json:"pass_rate"`
+	AveragePercentage float64             `json:"average_percentage"`
+	Questions         []QuestionAnalytics `json:"questions"`
+}
+
+// QuestionAnalytics shows how a single question was answered
+type QuestionAnalytics struct {
+	QuestionID   uuid.UUID           `json:"question_id"`
+	QuestionText string              `json:"question_text"`
+	QuestionType domain.QuestionType `json:"question_type"`
+	Answered     int64               `json:"answered"`
+	Correct      int64               `json:"correct"`
+	CorrectRate  float64             `json:"correct_rate"`
+	Options      []OptionAnalytics   `json:"options,omitempty"`
+}
+
+// OptionAnalytics shows how often an option was chosen. WrongAnswerShare is
+// the percentage of wrong answers to the question that picked this option,
+// which flags distractors that mislead students.
+type OptionAnalytics struct {
+	OptionID         uuid.UUID `json:"option_id"`
+	OptionText       string    `json:"option_text"`
+	IsCorrect        bool      `json:"is_correct"`
+	Selected         int64     `json:"selected"`
+	SelectionRate    float64   `json:"selection_rate"`
+	WrongAnswerShare float64   `json:"wrong_answer_share"`
+}
+
+// GetQuizAnalytics returns attempt, question and option level statistics for a quiz
+func (uc *UseCase) GetQuizAnalytics(ctx context.Context, quizID uuid.UUID) (*QuizAnalytics, error) {
+	quiz, err := uc.quizRepo.GetByID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := uc.attemptRepo.GetAttemptSummary(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	questionCounts, err := uc.attemptRepo.GetQuestionAnswerCounts(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	optionCounts, err := uc.attemptRepo.GetOptionSelectionCounts(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildQuizAnalytics(quiz, summary, questionCounts, optionCounts), nil
+}
+
+// BuildQuizAnalytics combines the stored answer counts with the quiz structure.
+// Questions and options with no answers yet are still listed with zero counts.
+func BuildQuizAnalytics(
+	quiz *domain.Quiz,
+	summary *repository.QuizAttemptSummary,
+	questionCounts []repository.QuestionAnswerCount,
+	optionCounts []repository.OptionSelectionCount,
+) *QuizAnalytics {
+	analytics := &QuizAnalytics{
+		QuizID:    quiz.ID,
+		Questions: make([]QuestionAnalytics, 0, len(quiz.Questions)),
+	}
+	if summary != nil {
+		analytics.Attempts = summary.Attempts
+		analytics.PassRate = percent(summary.Passed, summary.Attempts)
+		analytics.AveragePercentage = math.Round(summary.AveragePercentage*100) / 100
+	}
+
+	byQuestion := make(map[uuid.UUID]repository.QuestionAnswerCount, len(questionCounts))
+	for _, qc := range questionCounts {
+		byQuestion[qc.QuestionID] = qc
+	}
+	byOption := make(map[uuid.UUID]repository.OptionSelectionCount, len(optionCounts))
+	for _, oc := range optionCounts {
+		byOption[oc.OptionID] = oc
+	}
+
+	for _, question := range quiz.Questions {
+		counts := byQuestion[question.ID]
+		qa := QuestionAnalytics{
+			QuestionID:   question.ID,
+			QuestionText: question.QuestionText,
+			QuestionType: question.QuestionType,
+			Answered:     counts.Answered,
+			Correct:      counts.Correct,
+			CorrectRate:  percent(counts.Correct, counts.Answered),
+		}
+
+		// Short answers and essays have no options to pick between
+		if question.QuestionType != domain.QuestionTypeShortAnswer && question.QuestionType != domain.QuestionTypeEssay {
+			wrongAnswers := counts.Answered - counts.Correct
+			for _, opt := range question.Options {
+				oc := byOption[opt.ID]
+				qa.Options = append(qa.Options, OptionAnalytics{
+					OptionID:         opt.ID,
+					OptionText:       opt.OptionText,
+					IsCorrect:        opt.IsCorrect,
+					Selected:         oc.Selected,
+					SelectionRate:    percent(oc.Selected, counts.Answered),
+					WrongAnswerShare: percent(oc.Wrong, wrongAnswers),
+				})
+			}
+		}
+
+		analytics.Questions = append(analytics.Questions, qa)
+	}
+
+	return analytics
+}
+
+// percent returns part/total as a percentage rounded to two decimals
+func percent(part, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
@@ -0,0 +1,64 @@
+package quiz_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/quiz"
+)
+
+func TestBuildQuizAnalytics_DistractorShare(t *testing.T) {
+	questionID := uuid.New()
+	optA, optB, optC := uuid.New(), uuid.New(), uuid.New()
+
+	q := &domain.Quiz{
+		ID: uuid.New(),
+		Questions: []domain.QuizQuestion{{
+			ID:           questionID,
+			QuestionType: domain.QuestionTypeSingleChoice,
+			Options: []domain.QuizOption{
+				{ID: optA, OptionText: "A", IsCorrect: true},
+				{ID: optB, OptionText: "B"},
+				{ID: optC, OptionText: "C"},
+			},
+		}},
+	}
+
+	analytics := quiz.BuildQuizAnalytics(q,
+		&repository.QuizAttemptSummary{Attempts: 20, Passed: 10, AveragePercentage: 50},
+		[]repository.QuestionAnswerCount{{QuestionID: questionID, Answered: 20, Correct: 10}},
+		[]repository.OptionSelectionCount{
+			{QuestionID: questionID, OptionID: optA, Selected: 10},
+			{QuestionID: questionID, OptionID: optB, Selected: 6, Wrong: 6},
+			{QuestionID: questionID, OptionID: optC, Selected: 4, Wrong: 4},
+		},
+	)
+
+	assert.Equal(t, 50.0, analytics.PassRate)
+	assert.Len(t, analytics.Questions, 1)
+
+	question := analytics.Questions[0]
+	assert.Equal(t, 50.0, question.CorrectRate)
+	assert.Equal(t, 60.0, question.Options[1].WrongAnswerShare, "60% of wrong answers chose B")
+	assert.Equal(t, 30.0, question.Options[1].SelectionRate)
+	assert.Equal(t, 0.0, question.Options[0].WrongAnswerShare)
+}
+
+func TestBuildQuizAnalytics_NoAttempts(t *testing.T) {
+	q := &domain.Quiz{
+		ID: uuid.New(),
+		Questions: []domain.QuizQuestion{{
+			ID:           uuid.New(),
+			QuestionType: domain.QuestionTypeTrueFalse,
+			Options:      []domain.QuizOption{{ID: uuid.New(), IsCorrect: true}, {ID: uuid.New()}},
+		}},
+	}
+
+	analytics := quiz.BuildQuizAnalytics(q, &repository.QuizAttemptSummary{}, nil, nil)
+	assert.Equal(t, int64(0), analytics.Attempts)
+	assert.Len(t, analytics.Questions[0].Options, 2)
+	assert.Equal(t, 0.0, analytics.Questions[0].Options[1].WrongAnswerShare)
+}
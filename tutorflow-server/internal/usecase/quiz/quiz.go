@@ -3,7 +3,6 @@ package quiz
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -276,7 +275,7 @@ func (uc *UseCase) StartAttempt(ctx context.Context, userID uuid.UUID, quizID uu
 	}
 
 	if !quiz.IsPublished {
-		return nil, fmt.Errorf("quiz is not published")
+		return nil, domain.ErrQuizNotPublished
 	}
 
 	// Check max attempts
@@ -286,7 +285,7 @@ func (uc *UseCase) StartAttempt(ctx context.Context, userID uuid.UUID, quizID uu
 	}
 
 	if attemptCount >= quiz.MaxAttempts {
-		return nil, fmt.Errorf("maximum attempts reached")
+		return nil, domain.ErrMaxAttemptsReached
 	}
 
 	attempt := &domain.QuizAttempt{
@@ -317,7 +316,7 @@ func (uc *UseCase) SubmitAttempt(ctx context.Context, attemptID uuid.UUID, answe
 	}
 
 	if attempt.CompletedAt != nil {
-		return nil, fmt.Errorf("attempt already completed")
+		return nil, domain.ErrAttemptCompleted
 	}
 
 	quiz, err := uc.quizRepo.GetByID(ctx, attempt.QuizID)
@@ -329,12 +328,12 @@ func (uc *UseCase) SubmitAttempt(ctx context.Context, attemptID uuid.UUID, answe
 	if quiz.TimeLimit != nil {
 		deadline := attempt.StartedAt.Add(time.Duration(*quiz.TimeLimit) * time.Minute)
 		if time.Now().After(deadline.Add(1 * time.Minute)) { // 1 minute grace period
-			return nil, fmt.Errorf("time limit exceeded")
+			return nil, domain.ErrTimeLimitExceeded
 		}
 	}
 
 	// Grade the quiz
-	score, maxScore := uc.gradeQuiz(quiz, answers)
+	score, maxScore, graded := uc.gradeQuiz(quiz, answers)
 	percentage := (score / maxScore) * 100
 	passed := percentage >= quiz.PassingScore
 
@@ -354,12 +353,20 @@ func (uc *UseCase) SubmitAttempt(ctx context.Context, attemptID uuid.UUID, answe
 		return nil, err
 	}
 
+	// Per-question answers only feed analytics, so a failure here keeps the grade
+	for i := range graded {
+		graded[i].AttemptID = attempt.ID
+	}
+	_ = uc.attemptRepo.CreateAnswers(ctx, graded)
+
 	return attempt, nil
 }
 
-// gradeQuiz calculates score based on answers
-func (uc *UseCase) gradeQuiz(quiz *domain.Quiz, answers map[string]interface{}) (float64, float64) {
+// gradeQuiz calculates score based on answers and returns each graded answer
+// for analytics. Essays are left for manual grading and not recorded.
+func (uc *UseCase) gradeQuiz(quiz *domain.Quiz, answers map[string]interface{}) (float64, float64, []domain.QuizAnswer) {
 	var score, maxScore float64
+	var graded []domain.QuizAnswer
 
 	for _, question := range quiz.Questions {
 		maxScore += question.Points
@@ -369,6 +376,19 @@ func (uc *UseCase) gradeQuiz(quiz *domain.Quiz, answers map[string]interface{})
 			continue
 		}
 
+		record := func(correct bool, optionIDs ...uuid.UUID) {
+			if correct {
+				score += question.Points
+			}
+			if len(optionIDs) == 0 {
+				graded = append(graded, domain.QuizAnswer{QuizID: quiz.ID, QuestionID: question.ID, IsCorrect: correct})
+			}
+			for _, id := range optionIDs {
+				optionID := id
+				graded = append(graded, domain.QuizAnswer{QuizID: quiz.ID, QuestionID: question.ID, OptionID: &optionID, IsCorrect: correct})
+			}
+		}
+
 		switch question.QuestionType {
 		case domain.QuestionTypeSingleChoice, domain.QuestionTypeTrueFalse:
 			answerStr, ok := answer.(string)
@@ -376,8 +396,8 @@ func (uc *UseCase) gradeQuiz(quiz *domain.Quiz, answers map[string]interface{})
 				continue
 			}
 			for _, opt := range question.Options {
-				if opt.ID.String() == answerStr && opt.IsCorrect {
-					score += question.Points
+				if opt.ID.String() == answerStr {
+					record(opt.IsCorrect, opt.ID)
 					break
 				}
 			}
@@ -398,8 +418,12 @@ func (uc *UseCase) gradeQuiz(quiz *domain.Quiz, answers map[string]interface{})
 			correctCount := 0
 			selectedCorrect := 0
 			incorrectSelected := false
+			var selected []uuid.UUID
 
 			for _, opt := range question.Options {
+				if answerIDs[opt.ID.String()] {
+					selected = append(selected, opt.ID)
+				}
 				if opt.IsCorrect {
 					correctCount++
 					if answerIDs[opt.ID.String()] {
@@ -410,9 +434,7 @@ func (uc *UseCase) gradeQuiz(quiz *domain.Quiz, answers map[string]interface{})
 				}
 			}
 
-			if !incorrectSelected && selectedCorrect == correctCount && len(answerIDs) == correctCount {
-				score += question.Points
-			}
+			record(!incorrectSelected && selectedCorrect == correctCount && len(answerIDs) == correctCount, selected...)
 
 		case domain.QuestionTypeShortAnswer:
 			// Short answers require manual grading, but we can do exact match
@@ -420,12 +442,14 @@ func (uc *UseCase) gradeQuiz(quiz *domain.Quiz, answers map[string]interface{})
 			if !ok {
 				continue
 			}
+			correct := false
 			for _, opt := range question.Options {
 				if opt.IsCorrect && opt.OptionText == answerStr {
-					score += question.Points
+					correct = true
 					break
 				}
 			}
+			record(correct)
 
 		case domain.QuestionTypeEssay:
 			// Essays require manual grading
@@ -433,7 +457,7 @@ func (uc *UseCase) gradeQuiz(quiz *domain.Quiz, answers map[string]interface{})
 		}
 	}
 
-	return score, maxScore
+	return score, maxScore, graded
 }
 
 // GetAttempt returns attempt by ID
@@ -543,7 +567,7 @@ func (uc *UseCase) SubmitAssignment(ctx context.Context, userID uuid.UUID, input
 
 	// Check if overdue
 	if assignment.IsOverdue() && !assignment.AllowLateSubmission {
-		return nil, fmt.Errorf("assignment is past due date")
+		return nil, domain.ErrAssignmentPastDue
 	}
 
 	// Check existing submission
@@ -0,0 +1,214 @@
+package recommendation
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+)
+
+const (
+	// interestHalfLife is the enrollment age at which its influence drops by half
+	interestHalfLife = 90 * 24 * time.Hour
+	// maxInterestCategories bounds how many categories feed the candidate query
+	maxInterestCategories = 5
+)
+
+// UseCase defines course recommendation business logic
+type UseCase struct {
+	recommendationRepo repository.RecommendationRepository
+	courseRepo         repository.CourseRepository
+}
+
+// NewUseCase creates a new recommendation use case
+func NewUseCase(
+	recommendationRepo repository.RecommendationRepository,
+	courseRepo repository.CourseRepository,
+) *UseCase {
+	return &UseCase{
+		recommendationRepo: recommendationRepo,
+		courseRepo:         courseRepo,
+	}
+}
+
+// GetAlsoBought returns courses frequently bought together with the given course.
+// When userID is set, courses the user already owns are excluded. Courses with
+// little purchase history are topped up with popular courses from the same categories.
+func (uc *UseCase) GetAlsoBought(ctx context.Context, courseID uuid.UUID, userID *uuid.UUID, limit int) ([]domain.Course, error) {
+	if limit < 1 || limit > 20 {
+		limit = 6
+	}
+
+	if _, err := uc.courseRepo.GetByID(ctx, courseID); err != nil {
+		return nil, err
+	}
+
+	courses, err := uc.recommendationRepo.GetAlsoBought(ctx, courseID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(courses) < limit {
+		exclude := make([]uuid.UUID, len(courses))
+		for i, c := range courses {
+			exclude[i] = c.ID
+		}
+
+		popular, err := uc.recommendationRepo.GetPopularInSameCategories(ctx, courseID, userID, exclude, limit-len(courses))
+		if err != nil {
+			return nil, err
+		}
+		courses = append(courses, popular...)
+	}
+
+	if courses == nil {
+		courses = []domain.Course{}
+	}
+	return courses, nil
+}
+
+// GetRecommendationsForUser returns top-rated published courses in the categories
+// the user has enrolled in, favouring recent enrollments and matching levels.
+// Users without enrollments get featured and trending courses instead.
+func (uc *UseCase) GetRecommendationsForUser(ctx context.Context, userID uuid.UUID, limit int) ([]domain.Course, error) {
+	if limit < 1 || limit > 20 {
+		limit = 10
+	}
+
+	interests, err := uc.recommendationRepo.GetEnrollmentInterests(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var courses []domain.Course
+	if len(interests) > 0 {
+		profile := BuildInterestProfile(interests, time.Now())
+
+		candidates, err := uc.recommendationRepo.GetTopRatedInCategories(ctx, profile.TopCategories(maxInterestCategories), userID, limit*3)
+		if err != nil {
+			return nil, err
+		}
+
+		courses = profile.Rank(candidates)
+		if len(courses) > limit {
+			courses = courses[:limit]
+		}
+	}
+
+	if len(courses) < limit {
+		exclude := make([]uuid.UUID, len(courses))
+		for i, c := range courses {
+			exclude[i] = c.ID
+		}
+
+		trending, err := uc.recommendationRepo.GetTrending(ctx, &userID, exclude, limit-len(courses))
+		if err != nil {
+			return nil, err
+		}
+		courses = append(courses, trending...)
+	}
+
+	if courses == nil {
+		courses = []domain.Course{}
+	}
+	return courses, nil
+}
+
+// InterestProfile holds recency-weighted category and level preferences of a user
+type InterestProfile struct {
+	Categories map[uuid.UUID]float64
+	Levels     map[domain.CourseLevel]float64
+}
+
+// BuildInterestProfile weights every enrollment by 0.5^(age/half-life), so a
+// course enrolled in today counts twice as much as one from three months ago.
+func BuildInterestProfile(interests []repository.EnrollmentInterest, now time.Time) InterestProfile {
+	profile := InterestProfile{
+		Categories: make(map[uuid.UUID]float64),
+		Levels:     make(map[domain.CourseLevel]float64),
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, in := range interests {
+		age := now.Sub(in.EnrolledAt)
+		if age < 0 {
+			age = 0
+		}
+		weight := math.Pow(0.5, float64(age)/float64(interestHalfLife))
+
+		if in.CategoryID != nil {
+			profile.Categories[*in.CategoryID] += weight
+		}
+		// Interests come one row per category, so count each course's level once
+		if !seen[in.CourseID] {
+			seen[in.CourseID] = true
+			profile.Levels[in.Level] += weight
+		}
+	}
+
+	return profile
+}
+
+// TopCategories returns up to n category IDs ordered by weight
+func (p InterestProfile) TopCategories(n int) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(p.Categories))
+	for id := range p.Categories {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if p.Categories[ids[i]] != p.Categories[ids[j]] {
+			return p.Categories[ids[i]] > p.Categories[ids[j]]
+		}
+		return ids[i].String() < ids[j].String()
+	})
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
+
+// Score rates how well a course matches the profile. Category affinity dominates,
+// level match and rating break ties between similarly relevant courses.
+func (p InterestProfile) Score(course domain.Course) float64 {
+	var categoryTotal, levelTotal float64
+	for _, w := range p.Categories {
+		categoryTotal += w
+	}
+	for _, w := range p.Levels {
+		levelTotal += w
+	}
+
+	var score float64
+	if categoryTotal > 0 {
+		for _, cat := range course.Categories {
+			score += p.Categories[cat.ID] / categoryTotal
+		}
+	}
+	if levelTotal > 0 {
+		score += 0.5 * p.Levels[course.Level] / levelTotal
+	}
+	score += 0.25 * course.Rating / 5
+
+	return score
+}
+
+// Rank orders candidates by profile score, keeping the incoming order for ties
+func (p InterestProfile) Rank(candidates []domain.Course) []domain.Course {
+	ranked := make([]domain.Course, len(candidates))
+	copy(ranked, candidates)
+
+	scores := make(map[uuid.UUID]float64, len(ranked))
+	for _, c := range ranked {
+		scores[c.ID] = p.Score(c)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i].ID] > scores[ranked[j].ID]
+	})
+
+	return ranked
+}
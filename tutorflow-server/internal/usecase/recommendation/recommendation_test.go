@@ -0,0 +1,94 @@
+package recommendation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/recommendation"
+)
+
+// MockRecommendationRepository is a mock implementation of RecommendationRepository
+type MockRecommendationRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecommendationRepository) GetAlsoBought(ctx context.Context, courseID uuid.UUID, excludeUserID *uuid.UUID, limit int) ([]domain.Course, error) {
+	args := m.Called(ctx, courseID, excludeUserID, limit)
+	return args.Get(0).([]domain.Course), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) GetPopularInSameCategories(ctx context.Context, courseID uuid.UUID, excludeUserID *uuid.UUID, excludeIDs []uuid.UUID, limit int) ([]domain.Course, error) {
+	args := m.Called(ctx, courseID, excludeUserID, excludeIDs, limit)
+	return args.Get(0).([]domain.Course), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) GetEnrollmentInterests(ctx context.Context, userID uuid.UUID) ([]repository.EnrollmentInterest, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]repository.EnrollmentInterest), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) GetTopRatedInCategories(ctx context.Context, categoryIDs []uuid.UUID, excludeUserID uuid.UUID, limit int) ([]domain.Course, error) {
+	args := m.Called(ctx, categoryIDs, excludeUserID, limit)
+	return args.Get(0).([]domain.Course), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) GetTrending(ctx context.Context, excludeUserID *uuid.UUID, excludeIDs []uuid.UUID, limit int) ([]domain.Course, error) {
+	args := m.Called(ctx, excludeUserID, excludeIDs, limit)
+	return args.Get(0).([]domain.Course), args.Error(1)
+}
+
+func TestBuildInterestProfile_RecencyWeighting(t *testing.T) {
+	now := time.Now()
+	recent := uuid.New()
+	old := uuid.New()
+
+	profile := recommendation.BuildInterestProfile([]repository.EnrollmentInterest{
+		{CourseID: uuid.New(), CategoryID: &recent, Level: domain.CourseLevelAdvanced, EnrolledAt: now},
+		{CourseID: uuid.New(), CategoryID: &old, Level: domain.CourseLevelBeginner, EnrolledAt: now.Add(-90 * 24 * time.Hour)},
+	}, now)
+
+	assert.InDelta(t, 1.0, profile.Categories[recent], 0.001)
+	assert.InDelta(t, 0.5, profile.Categories[old], 0.001)
+	assert.Equal(t, []uuid.UUID{recent, old}, profile.TopCategories(5))
+	assert.Greater(t, profile.Levels[domain.CourseLevelAdvanced], profile.Levels[domain.CourseLevelBeginner])
+}
+
+func TestInterestProfile_Rank(t *testing.T) {
+	now := time.Now()
+	recent := uuid.New()
+	old := uuid.New()
+
+	profile := recommendation.BuildInterestProfile([]repository.EnrollmentInterest{
+		{CourseID: uuid.New(), CategoryID: &recent, Level: domain.CourseLevelIntermediate, EnrolledAt: now.Add(-24 * time.Hour)},
+		{CourseID: uuid.New(), CategoryID: &old, Level: domain.CourseLevelIntermediate, EnrolledAt: now.Add(-365 * 24 * time.Hour)},
+	}, now)
+
+	oldTopRated := domain.Course{ID: uuid.New(), Rating: 5, Level: domain.CourseLevelIntermediate, Categories: []domain.Category{{ID: old}}}
+	recentMatch := domain.Course{ID: uuid.New(), Rating: 4, Level: domain.CourseLevelIntermediate, Categories: []domain.Category{{ID: recent}}}
+
+	ranked := profile.Rank([]domain.Course{oldTopRated, recentMatch})
+	assert.Equal(t, recentMatch.ID, ranked[0].ID, "recent interests should outrank a slightly better rating")
+}
+
+func TestRecommendationUseCase_GetRecommendationsForUser_NewUser(t *testing.T) {
+	mockRepo := new(MockRecommendationRepository)
+	uc := recommendation.NewUseCase(mockRepo, nil)
+
+	userID := uuid.New()
+	trending := []domain.Course{{ID: uuid.New(), IsFeatured: true}, {ID: uuid.New()}}
+
+	mockRepo.On("GetEnrollmentInterests", mock.Anything, userID).Return([]repository.EnrollmentInterest{}, nil)
+	mockRepo.On("GetTrending", mock.Anything, &userID, []uuid.UUID{}, 10).Return(trending, nil)
+
+	courses, err := uc.GetRecommendationsForUser(context.Background(), userID, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, trending, courses)
+	mockRepo.AssertNotCalled(t, "GetTopRatedInCategories", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
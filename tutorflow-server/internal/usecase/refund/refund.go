@@ -14,6 +14,7 @@ type refundUseCase struct {
 	refundRepo     repository.RefundRepository
 	orderRepo      repository.OrderRepository
 	enrollmentRepo repository.EnrollmentRepository
+	auditRepo      repository.AuditLogRepository
 }
 
 // NewRefundUseCase creates a new refund use case
@@ -21,11 +22,13 @@ func NewUseCase(
 	refundRepo repository.RefundRepository,
 	orderRepo repository.OrderRepository,
 	enrollmentRepo repository.EnrollmentRepository,
+	auditRepo repository.AuditLogRepository,
 ) domain.RefundUseCase {
 	return &refundUseCase{
 		refundRepo:     refundRepo,
 		orderRepo:      orderRepo,
 		enrollmentRepo: enrollmentRepo,
+		auditRepo:      auditRepo,
 	}
 }
 
@@ -106,6 +109,7 @@ func (uc *refundUseCase) ApproveRefund(
 		return nil, errors.New("refund cannot be processed")
 	}
 
+	before := refundAuditState(refund)
 	now := time.Now()
 	refund.Status = domain.RefundStatusApproved
 	refund.ProcessedBy = &adminID
@@ -113,14 +117,16 @@ func (uc *refundUseCase) ApproveRefund(
 	refund.AdminNotes = notes
 	refund.UpdatedAt = now
 
-	if err := uc.refundRepo.Update(ctx, refund); err != nil {
-		return nil, err
-	}
-
-	// Update order status
+	// The refund, the order status and the audit entry are saved together
+	changes := []interface{}{refund}
 	if refund.Order != nil {
 		refund.Order.Status = domain.OrderStatusRefunded
-		uc.orderRepo.Update(ctx, refund.Order)
+		changes = append(changes, refund.Order)
+	}
+
+	entry := domain.NewAuditLog(adminID, domain.AuditRefundApprove, "refund", refund.ID, before, refundAuditState(refund))
+	if err := uc.auditRepo.Record(ctx, entry, changes...); err != nil {
+		return nil, err
 	}
 
 	return refund, nil
@@ -141,6 +147,7 @@ func (uc *refundUseCase) RejectRefund(
 		return nil, errors.New("refund cannot be processed")
 	}
 
+	before := refundAuditState(refund)
 	now := time.Now()
 	refund.Status = domain.RefundStatusRejected
 	refund.ProcessedBy = &adminID
@@ -148,13 +155,23 @@ func (uc *refundUseCase) RejectRefund(
 	refund.AdminNotes = notes
 	refund.UpdatedAt = now
 
-	if err := uc.refundRepo.Update(ctx, refund); err != nil {
+	entry := domain.NewAuditLog(adminID, domain.AuditRefundReject, "refund", refund.ID, before, refundAuditState(refund))
+	if err := uc.auditRepo.Record(ctx, entry, refund); err != nil {
 		return nil, err
 	}
 
 	return refund, nil
 }
 
+// refundAuditState is what the audit log keeps of a refund
+func refundAuditState(refund *domain.Refund) map[string]interface{} {
+	return map[string]interface{}{
+		"status":      refund.Status,
+		"amount":      refund.Amount,
+		"admin_notes": refund.AdminNotes,
+	}
+}
+
 // GetUserRefunds returns user's refund requests
 func (uc *refundUseCase) GetUserRefunds(
 	ctx context.Context,
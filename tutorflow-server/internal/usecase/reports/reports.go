@@ -37,12 +37,21 @@ func NewUseCase(
 
 // Recently Viewed
 
-func (uc *UseCase) TrackView(ctx context.Context, userID, courseID uuid.UUID) error {
-	return uc.rvRepo.Track(ctx, userID, courseID)
+// maxRecentlyViewed caps how many viewed courses are kept per user
+const maxRecentlyViewed = 50
+
+// RecordView moves a course to the top of the user's history and drops the oldest
+// entries beyond the cap
+func (uc *UseCase) RecordView(ctx context.Context, userID, courseID uuid.UUID) error {
+	if err := uc.rvRepo.Track(ctx, userID, courseID); err != nil {
+		return err
+	}
+	return uc.rvRepo.Prune(ctx, userID, maxRecentlyViewed)
 }
 
+// GetRecentlyViewed returns the user's distinct recently viewed courses, newest first
 func (uc *UseCase) GetRecentlyViewed(ctx context.Context, userID uuid.UUID, limit int) ([]domain.RecentlyViewed, error) {
-	if limit <= 0 || limit > 50 {
+	if limit <= 0 || limit > maxRecentlyViewed {
 		limit = 10
 	}
 	return uc.rvRepo.GetByUser(ctx, userID, limit)
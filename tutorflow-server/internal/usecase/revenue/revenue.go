@@ -74,14 +74,12 @@ func (uc *revenueUseCase) RequestPayout(ctx context.Context, instructorID uuid.U
 
 func (uc *revenueUseCase) HandlePaymentSuccess(ctx context.Context, order *domain.Order) error {
 	// Create earnings for each instructor in the order
-	platformFeePercent := 0.30
-
 	for _, item := range order.Items {
 		earning := &domain.InstructorEarning{
 			InstructorID: item.Course.InstructorID,
 			OrderItemID:  item.ID,
 			Amount:       item.InstructorShare,
-			PlatformFee:  item.Price * platformFeePercent,
+			PlatformFee:  item.Price * domain.PlatformFeePercent,
 			Status:       "pending", // Initially pending
 		}
 
@@ -3,20 +3,24 @@ package review
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
 )
 
 // UseCase defines review business logic
 type UseCase struct {
-	reviewRepo       repository.ReviewRepository
-	enrollmentRepo   repository.EnrollmentRepository
-	courseRepo       repository.CourseRepository
-	notificationRepo repository.NotificationRepository
+	reviewRepo     repository.ReviewRepository
+	enrollmentRepo repository.EnrollmentRepository
+	courseRepo     repository.CourseRepository
+	notifier       *notification.Notifier
+	cfg            config.ReviewConfig
 }
 
 // NewUseCase creates a new review use case
@@ -24,13 +28,15 @@ func NewUseCase(
 	reviewRepo repository.ReviewRepository,
 	enrollmentRepo repository.EnrollmentRepository,
 	courseRepo repository.CourseRepository,
-	notificationRepo repository.NotificationRepository,
+	notifier *notification.Notifier,
+	cfg config.ReviewConfig,
 ) *UseCase {
 	return &UseCase{
-		reviewRepo:       reviewRepo,
-		enrollmentRepo:   enrollmentRepo,
-		courseRepo:       courseRepo,
-		notificationRepo: notificationRepo,
+		reviewRepo:     reviewRepo,
+		enrollmentRepo: enrollmentRepo,
+		courseRepo:     courseRepo,
+		notifier:       notifier,
+		cfg:            cfg,
 	}
 }
 
@@ -63,19 +69,21 @@ type CreateReviewInput struct {
 	Content  *string   `json:"content" validate:"omitempty,max=5000"`
 }
 
-// CreateReview creates a new course review
+// CreateReview creates a new course review. Only learners enrolled in the
+// course can review it, once each; an existing review is changed with
+// UpdateReview instead.
 func (uc *UseCase) CreateReview(ctx context.Context, userID uuid.UUID, input CreateReviewInput) (*domain.CourseReview, error) {
-	// Check if user already reviewed this course
-	existing, _ := uc.reviewRepo.GetByUserAndCourse(ctx, userID, input.CourseID)
-	if existing != nil {
-		return nil, fmt.Errorf("you have already reviewed this course")
+	if existing, _ := uc.reviewRepo.GetByUserAndCourse(ctx, userID, input.CourseID); existing != nil {
+		return nil, domain.ErrAlreadyReviewed
 	}
 
-	// Check if user is enrolled
+	// Learners who finished the course may review it after their access ends
 	enrollment, err := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, input.CourseID)
-	isVerified := false
-	if err == nil && enrollment != nil && enrollment.CanAccess() {
-		isVerified = true
+	if err != nil || enrollment == nil || !(enrollment.IsActive() || enrollment.IsCompleted()) {
+		return nil, domain.ErrNotEnrolled
+	}
+	if enrollment.Progress < uc.cfg.MinProgressPercent {
+		return nil, domain.ErrReviewProgressRequired
 	}
 
 	review := &domain.CourseReview{
@@ -84,8 +92,8 @@ func (uc *UseCase) CreateReview(ctx context.Context, userID uuid.UUID, input Cre
 		Rating:             input.Rating,
 		Title:              input.Title,
 		Content:            input.Content,
-		IsVerifiedPurchase: isVerified,
-		Status:             "published",
+		IsVerifiedPurchase: true, // only enrolled learners get this far
+		Status:             uc.initialStatus(),
 	}
 
 	if err := uc.reviewRepo.Create(ctx, review); err != nil {
@@ -104,7 +112,7 @@ func (uc *UseCase) CreateReview(ctx context.Context, userID uuid.UUID, input Cre
 			Title:   "New Course Review",
 			Message: stringPtr(fmt.Sprintf("Your course \"%s\" received a %.1f star review", course.Title, input.Rating)),
 		}
-		_ = uc.notificationRepo.Create(ctx, notification)
+		_ = uc.notifier.Notify(ctx, notification)
 	}
 
 	return review, nil
@@ -138,6 +146,10 @@ func (uc *UseCase) UpdateReview(ctx context.Context, reviewID, userID uuid.UUID,
 	if input.Content != nil {
 		review.Content = input.Content
 	}
+	// Edits go back through moderation so approval can't be used to slip in new text
+	if review.Status == domain.ReviewStatusApproved {
+		review.Status = uc.initialStatus()
+	}
 
 	if err := uc.reviewRepo.Update(ctx, review); err != nil {
 		return nil, err
@@ -161,29 +173,71 @@ func (uc *UseCase) DeleteReview(ctx context.Context, reviewID, userID uuid.UUID,
 	return uc.reviewRepo.Delete(ctx, reviewID)
 }
 
-// VoteReview votes on a review
-func (uc *UseCase) VoteReview(ctx context.Context, reviewID, userID uuid.UUID, isHelpful bool) error {
-	// Verify review exists
+// VoteCounts is a review's tally after a vote changes. UserVote is the
+// caller's current vote, or nil once it is removed.
+type VoteCounts struct {
+	ReviewID       uuid.UUID `json:"review_id"`
+	HelpfulCount   int       `json:"helpful_count"`
+	UnhelpfulCount int       `json:"unhelpful_count"`
+	UserVote       *bool     `json:"user_vote"`
+}
+
+// VoteReview records whether a user found a review helpful. Each user has one
+// vote per review, so voting again changes it rather than adding another.
+func (uc *UseCase) VoteReview(ctx context.Context, reviewID, userID uuid.UUID, isHelpful bool) (*VoteCounts, error) {
+	if err := uc.checkVotable(ctx, reviewID, userID); err != nil {
+		return nil, err
+	}
+
+	helpful, unhelpful, err := uc.reviewRepo.Vote(ctx, reviewID, userID, isHelpful)
+	if err != nil {
+		return nil, err
+	}
+	return &VoteCounts{ReviewID: reviewID, HelpfulCount: helpful, UnhelpfulCount: unhelpful, UserVote: &isHelpful}, nil
+}
+
+// RemoveVote withdraws a user's vote on a review
+func (uc *UseCase) RemoveVote(ctx context.Context, reviewID, userID uuid.UUID) (*VoteCounts, error) {
+	if _, err := uc.reviewRepo.GetByID(ctx, reviewID); err != nil {
+		return nil, err
+	}
+
+	helpful, unhelpful, err := uc.reviewRepo.RemoveVote(ctx, reviewID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &VoteCounts{ReviewID: reviewID, HelpfulCount: helpful, UnhelpfulCount: unhelpful}, nil
+}
+
+func (uc *UseCase) checkVotable(ctx context.Context, reviewID, userID uuid.UUID) error {
 	review, err := uc.reviewRepo.GetByID(ctx, reviewID)
 	if err != nil {
 		return err
 	}
-
-	// Can't vote on your own review
+	// Reviews awaiting moderation aren't public yet
+	if review.Status != domain.ReviewStatusApproved {
+		return domain.ErrReviewNotFound
+	}
 	if review.UserID == userID {
-		return fmt.Errorf("you cannot vote on your own review")
+		return domain.ErrCannotVoteOwnReview
 	}
-
-	return uc.reviewRepo.Vote(ctx, reviewID, userID, isHelpful)
+	return nil
 }
 
-// ReplyToReviewInput for instructor reply
-type ReplyToReviewInput struct {
-	Reply string `json:"reply" validate:"required,max=2000"`
+// RespondToReviewInput for an instructor's public response
+type RespondToReviewInput struct {
+	Response string `json:"response" validate:"required,max=2000"`
 }
 
-// ReplyToReview allows instructor to reply to a review
-func (uc *UseCase) ReplyToReview(ctx context.Context, reviewID, instructorID uuid.UUID, reply string) (*domain.CourseReview, error) {
+// RespondToReview stores the course instructor's public response to a
+// review. A review has at most one response, so responding again replaces
+// it. The reviewer is notified either way.
+func (uc *UseCase) RespondToReview(ctx context.Context, reviewID, instructorID uuid.UUID, text string) (*domain.CourseReview, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, domain.ValidationErrors{{Field: "response", Message: "response cannot be empty"}}
+	}
+
 	review, err := uc.reviewRepo.GetByID(ctx, reviewID)
 	if err != nil {
 		return nil, err
@@ -194,29 +248,92 @@ func (uc *UseCase) ReplyToReview(ctx context.Context, reviewID, instructorID uui
 	if err != nil {
 		return nil, err
 	}
-
 	if course.InstructorID != instructorID {
-		return nil, fmt.Errorf("only the course instructor can reply to reviews")
+		return nil, domain.ErrNotCourseOwner
 	}
 
+	updating := review.InstructorReply != nil
 	now := time.Now()
-	review.InstructorReply = &reply
+	review.InstructorReply = &text
 	review.InstructorReplyAt = &now
+	review.User = nil
 
 	if err := uc.reviewRepo.Update(ctx, review); err != nil {
 		return nil, err
 	}
 
 	// Notify reviewer
+	message := fmt.Sprintf("The instructor responded to your review on \"%s\"", course.Title)
+	if updating {
+		message = fmt.Sprintf("The instructor updated their response to your review on \"%s\"", course.Title)
+	}
 	notification := &domain.Notification{
 		UserID:  review.UserID,
-		Type:    domain.NotificationMessage,
-		Title:   "Instructor Replied to Your Review",
-		Message: stringPtr(fmt.Sprintf("The instructor replied to your review on \"%s\"", course.Title)),
+		Type:    domain.NotificationReviewResponse,
+		Title:   "Instructor Responded to Your Review",
+		Message: &message,
 	}
-	_ = uc.notificationRepo.Create(ctx, notification)
+	_ = uc.notifier.Notify(ctx, notification)
 
-	return review, nil
+	return uc.reviewRepo.GetByID(ctx, reviewID)
+}
+
+// initialStatus is the status of a newly written or edited review
+func (uc *UseCase) initialStatus() domain.ReviewStatus {
+	if uc.cfg.AutoApprove {
+		return domain.ReviewStatusApproved
+	}
+	return domain.ReviewStatusPending
+}
+
+// ListPendingReviews returns reviews waiting for moderation. instructorID
+// limits them to one instructor's courses; admins pass nil to see all.
+func (uc *UseCase) ListPendingReviews(ctx context.Context, instructorID *uuid.UUID, page, limit int) ([]domain.CourseReview, int64, error) {
+	return uc.reviewRepo.ListPending(ctx, instructorID, page, limit)
+}
+
+// ModerateReviewInput for approving or rejecting a review
+type ModerateReviewInput struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+}
+
+// ModerateReview approves or rejects a review. Admins can moderate any
+// review; instructors only those on their own courses.
+func (uc *UseCase) ModerateReview(ctx context.Context, reviewID, moderatorID uuid.UUID, isAdmin bool, status domain.ReviewStatus) (*domain.CourseReview, error) {
+	review, err := uc.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin {
+		course, err := uc.courseRepo.GetByID(ctx, review.CourseID)
+		if err != nil {
+			return nil, err
+		}
+		if course.InstructorID != moderatorID {
+			return nil, domain.ErrNotCourseOwner
+		}
+	}
+
+	now := time.Now()
+	review.Status = status
+	review.ModeratedBy = &moderatorID
+	review.ModeratedAt = &now
+	review.User = nil
+
+	// Update recomputes the course rating from approved reviews
+	if err := uc.reviewRepo.Update(ctx, review); err != nil {
+		return nil, err
+	}
+
+	return uc.reviewRepo.GetByID(ctx, reviewID)
+}
+
+// RecomputeRatings rebuilds course ratings from approved reviews. Reviews
+// adjust ratings incrementally, so this only has to correct drift and can
+// run in the background.
+func (uc *UseCase) RecomputeRatings(ctx context.Context) (int64, error) {
+	return uc.reviewRepo.RecomputeRatings(ctx)
 }
 
 // GetCourseRatingSummary returns rating breakdown for a course
@@ -232,18 +349,14 @@ func (uc *UseCase) GetCourseRatingSummary(ctx context.Context, courseID uuid.UUI
 		return nil, err
 	}
 
-	// Get all reviews to calculate distribution
-	reviews, total, err := uc.reviewRepo.GetByCourse(ctx, courseID, 1, 1000)
+	distribution, err := uc.reviewRepo.GetRatingDistribution(ctx, courseID)
 	if err != nil {
 		return nil, err
 	}
 
-	distribution := map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
-	for _, r := range reviews {
-		star := int(r.Rating)
-		if star >= 1 && star <= 5 {
-			distribution[star]++
-		}
+	var total int64
+	for _, count := range distribution {
+		total += count
 	}
 
 	return &RatingSummary{
@@ -253,6 +366,46 @@ func (uc *UseCase) GetCourseRatingSummary(ctx context.Context, courseID uuid.UUI
 	}, nil
 }
 
+// StarCount is the number of reviews at a single star level
+type StarCount struct {
+	Stars   int     `json:"stars"`
+	Count   int64   `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// RatingDistribution is a star histogram for a course, ordered 5 to 1
+type RatingDistribution struct {
+	CourseID uuid.UUID   `json:"course_id"`
+	Total    int64       `json:"total"`
+	Stars    []StarCount `json:"stars"`
+}
+
+// GetRatingDistribution returns review counts per star level for a course
+func (uc *UseCase) GetRatingDistribution(ctx context.Context, courseID uuid.UUID) (*RatingDistribution, error) {
+	if _, err := uc.courseRepo.GetByID(ctx, courseID); err != nil {
+		return nil, err
+	}
+
+	counts, err := uc.reviewRepo.GetRatingDistribution(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RatingDistribution{CourseID: courseID, Stars: make([]StarCount, 0, 5)}
+	for star := 1; star <= 5; star++ {
+		result.Total += counts[star]
+	}
+	for star := 5; star >= 1; star-- {
+		sc := StarCount{Stars: star, Count: counts[star]}
+		if result.Total > 0 {
+			sc.Percent = float64(sc.Count) / float64(result.Total) * 100
+		}
+		result.Stars = append(result.Stars, sc)
+	}
+
+	return result, nil
+}
+
 // FeatureReview marks a review as featured (admin/instructor)
 func (uc *UseCase) FeatureReview(ctx context.Context, reviewID uuid.UUID, featured bool) error {
 	review, err := uc.reviewRepo.GetByID(ctx, reviewID)
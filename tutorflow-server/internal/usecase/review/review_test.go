@@ -0,0 +1,315 @@
+package review_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/realtime"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/notification"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/review"
+	"gorm.io/gorm"
+)
+
+// MockReviewRepository is a mock implementation of ReviewRepository
+type MockReviewRepository struct {
+	mock.Mock
+}
+
+func (m *MockReviewRepository) Create(ctx context.Context, r *domain.CourseReview) error {
+	return m.Called(ctx, r).Error(0)
+}
+
+func (m *MockReviewRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.CourseReview, error) {
+	args := m.Called(ctx, id)
+	r, _ := args.Get(0).(*domain.CourseReview)
+	return r, args.Error(1)
+}
+
+func (m *MockReviewRepository) Update(ctx context.Context, r *domain.CourseReview) error {
+	return m.Called(ctx, r).Error(0)
+}
+
+func (m *MockReviewRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockReviewRepository) GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.CourseReview, int64, error) {
+	args := m.Called(ctx, courseID, page, limit)
+	return args.Get(0).([]domain.CourseReview), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockReviewRepository) GetByUserAndCourse(ctx context.Context, userID, courseID uuid.UUID) (*domain.CourseReview, error) {
+	args := m.Called(ctx, userID, courseID)
+	r, _ := args.Get(0).(*domain.CourseReview)
+	return r, args.Error(1)
+}
+
+func (m *MockReviewRepository) RecomputeRatings(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockReviewRepository) Vote(ctx context.Context, reviewID, userID uuid.UUID, isHelpful bool) (int, int, error) {
+	args := m.Called(ctx, reviewID, userID, isHelpful)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockReviewRepository) RemoveVote(ctx context.Context, reviewID, userID uuid.UUID) (int, int, error) {
+	args := m.Called(ctx, reviewID, userID)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockReviewRepository) GetRatingDistribution(ctx context.Context, courseID uuid.UUID) (map[int]int64, error) {
+	args := m.Called(ctx, courseID)
+	return args.Get(0).(map[int]int64), args.Error(1)
+}
+
+func (m *MockReviewRepository) ListPending(ctx context.Context, instructorID *uuid.UUID, page, limit int) ([]domain.CourseReview, int64, error) {
+	args := m.Called(ctx, instructorID, page, limit)
+	return args.Get(0).([]domain.CourseReview), args.Get(1).(int64), args.Error(2)
+}
+
+// MockEnrollmentRepository is a mock implementation of EnrollmentRepository
+type MockEnrollmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockEnrollmentRepository) Create(ctx context.Context, e *domain.Enrollment) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockEnrollmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Enrollment, error) {
+	args := m.Called(ctx, id)
+	e, _ := args.Get(0).(*domain.Enrollment)
+	return e, args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetByUserAndCourse(ctx context.Context, userID, courseID uuid.UUID) (*domain.Enrollment, error) {
+	args := m.Called(ctx, userID, courseID)
+	e, _ := args.Get(0).(*domain.Enrollment)
+	return e, args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) Update(ctx context.Context, e *domain.Enrollment) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockEnrollmentRepository) List(ctx context.Context, filters repository.EnrollmentFilters) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) GetByUser(ctx context.Context, userID uuid.UUID, page, limit int) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) GetByCourse(ctx context.Context, courseID uuid.UUID, page, limit int) ([]domain.Enrollment, int64, error) {
+	args := m.Called(ctx, courseID, page, limit)
+	return args.Get(0).([]domain.Enrollment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEnrollmentRepository) UpdateProgress(ctx context.Context, id uuid.UUID, progress float64) error {
+	return m.Called(ctx, id, progress).Error(0)
+}
+
+func (m *MockEnrollmentRepository) GetStats(ctx context.Context, userID uuid.UUID) (*domain.StudentDashboardStats, error) {
+	args := m.Called(ctx, userID)
+	s, _ := args.Get(0).(*domain.StudentDashboardStats)
+	return s, args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) HasInstructor(ctx context.Context, userID, instructorID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID, instructorID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetCourseFunnel(ctx context.Context, courseID uuid.UUID) (*domain.CourseFunnel, error) {
+	args := m.Called(ctx, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CourseFunnel), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) GetAtRisk(ctx context.Context, courseID uuid.UUID, inactiveSince time.Time, maxProgress float64) ([]domain.AtRiskStudent, error) {
+	args := m.Called(ctx, courseID, inactiveSince, maxProgress)
+	return args.Get(0).([]domain.AtRiskStudent), args.Error(1)
+}
+
+func (m *MockEnrollmentRepository) MarkNudged(ctx context.Context, ids []uuid.UUID, now, cooldownSince time.Time) ([]uuid.UUID, error) {
+	args := m.Called(ctx, ids, now, cooldownSince)
+	claimed, _ := args.Get(0).([]uuid.UUID)
+	return claimed, args.Error(1)
+}
+
+func TestReviewUseCase_CreateReview_Eligibility(t *testing.T) {
+	ctx := context.Background()
+	userID, courseID := uuid.New(), uuid.New()
+	input := review.CreateReviewInput{CourseID: courseID, Rating: 4}
+
+	t.Run("not enrolled", func(t *testing.T) {
+		reviewRepo := new(MockReviewRepository)
+		enrollRepo := new(MockEnrollmentRepository)
+		reviewRepo.On("GetByUserAndCourse", ctx, userID, courseID).Return(nil, gorm.ErrRecordNotFound)
+		enrollRepo.On("GetByUserAndCourse", ctx, userID, courseID).Return(nil, gorm.ErrRecordNotFound)
+		uc := review.NewUseCase(reviewRepo, enrollRepo, nil, nil, config.ReviewConfig{AutoApprove: true})
+
+		_, err := uc.CreateReview(ctx, userID, input)
+		assert.ErrorIs(t, err, domain.ErrNotEnrolled)
+		reviewRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("cancelled enrollments can't review", func(t *testing.T) {
+		reviewRepo := new(MockReviewRepository)
+		enrollRepo := new(MockEnrollmentRepository)
+		reviewRepo.On("GetByUserAndCourse", ctx, userID, courseID).Return(nil, gorm.ErrRecordNotFound)
+		enrollRepo.On("GetByUserAndCourse", ctx, userID, courseID).
+			Return(&domain.Enrollment{Status: domain.EnrollmentStatusCancelled}, nil)
+		uc := review.NewUseCase(reviewRepo, enrollRepo, nil, nil, config.ReviewConfig{AutoApprove: true})
+
+		_, err := uc.CreateReview(ctx, userID, input)
+		assert.ErrorIs(t, err, domain.ErrNotEnrolled)
+	})
+
+	t.Run("already reviewed", func(t *testing.T) {
+		reviewRepo := new(MockReviewRepository)
+		reviewRepo.On("GetByUserAndCourse", ctx, userID, courseID).
+			Return(&domain.CourseReview{ID: uuid.New(), UserID: userID, CourseID: courseID}, nil)
+		uc := review.NewUseCase(reviewRepo, new(MockEnrollmentRepository), nil, nil, config.ReviewConfig{AutoApprove: true})
+
+		_, err := uc.CreateReview(ctx, userID, input)
+		assert.ErrorIs(t, err, domain.ErrAlreadyReviewed)
+		reviewRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("minimum progress", func(t *testing.T) {
+		reviewRepo := new(MockReviewRepository)
+		enrollRepo := new(MockEnrollmentRepository)
+		reviewRepo.On("GetByUserAndCourse", ctx, userID, courseID).Return(nil, gorm.ErrRecordNotFound)
+		enrollRepo.On("GetByUserAndCourse", ctx, userID, courseID).
+			Return(&domain.Enrollment{Status: domain.EnrollmentStatusActive, Progress: 10}, nil)
+		uc := review.NewUseCase(reviewRepo, enrollRepo, nil, nil, config.ReviewConfig{AutoApprove: true, MinProgressPercent: 25})
+
+		_, err := uc.CreateReview(ctx, userID, input)
+		assert.ErrorIs(t, err, domain.ErrReviewProgressRequired)
+	})
+}
+
+func TestReviewUseCase_ModerateReview(t *testing.T) {
+	ctx := context.Background()
+	adminID := uuid.New()
+	pending := &domain.CourseReview{ID: uuid.New(), CourseID: uuid.New(), Status: domain.ReviewStatusPending}
+
+	reviewRepo := new(MockReviewRepository)
+	reviewRepo.On("GetByID", ctx, pending.ID).Return(pending, nil)
+	reviewRepo.On("Update", ctx, mock.Anything).Return(nil)
+	uc := review.NewUseCase(reviewRepo, nil, nil, nil, config.ReviewConfig{})
+
+	moderated, err := uc.ModerateReview(ctx, pending.ID, adminID, true, domain.ReviewStatusApproved)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReviewStatusApproved, moderated.Status)
+	assert.Equal(t, &adminID, moderated.ModeratedBy)
+	assert.NotNil(t, moderated.ModeratedAt)
+}
+
+func TestReviewUseCase_UpdateReview_RequeuesForModeration(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	approved := &domain.CourseReview{ID: uuid.New(), UserID: userID, Rating: 5, Status: domain.ReviewStatusApproved}
+
+	reviewRepo := new(MockReviewRepository)
+	reviewRepo.On("GetByID", ctx, approved.ID).Return(approved, nil)
+	reviewRepo.On("Update", ctx, mock.Anything).Return(nil)
+	uc := review.NewUseCase(reviewRepo, nil, nil, nil, config.ReviewConfig{AutoApprove: false})
+
+	rating := 1.0
+	updated, err := uc.UpdateReview(ctx, approved.ID, userID, review.UpdateReviewInput{Rating: &rating})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReviewStatusPending, updated.Status, "edited reviews wait for approval again")
+}
+
+func TestReviewUseCase_VoteReview(t *testing.T) {
+	ctx := context.Background()
+	authorID, voterID := uuid.New(), uuid.New()
+	approved := &domain.CourseReview{ID: uuid.New(), UserID: authorID, Status: domain.ReviewStatusApproved}
+
+	reviewRepo := new(MockReviewRepository)
+	reviewRepo.On("GetByID", ctx, approved.ID).Return(approved, nil)
+	reviewRepo.On("Vote", ctx, approved.ID, voterID, false).Return(3, 1, nil)
+	uc := review.NewUseCase(reviewRepo, nil, nil, nil, config.ReviewConfig{})
+
+	counts, err := uc.VoteReview(ctx, approved.ID, voterID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, counts.HelpfulCount)
+	assert.Equal(t, 1, counts.UnhelpfulCount)
+	assert.False(t, *counts.UserVote)
+
+	_, err = uc.VoteReview(ctx, approved.ID, authorID, true)
+	assert.ErrorIs(t, err, domain.ErrCannotVoteOwnReview)
+}
+
+type respondCourses struct {
+	repository.CourseRepository
+	course *domain.Course
+}
+
+func (r *respondCourses) GetByID(ctx context.Context, id uuid.UUID) (*domain.Course, error) {
+	return r.course, nil
+}
+
+type respondNotifications struct {
+	repository.NotificationRepository
+}
+
+func (r *respondNotifications) Create(ctx context.Context, n *domain.Notification) error {
+	n.ID = uuid.New()
+	return nil
+}
+
+type respondPrefs struct {
+	repository.NotificationPreferenceRepository
+}
+
+func (r *respondPrefs) GetByUser(ctx context.Context, userID uuid.UUID) (domain.NotificationPreferences, error) {
+	return nil, nil
+}
+
+func TestReviewUseCase_RespondToReview_PublishesNotification(t *testing.T) {
+	ctx := context.Background()
+	instructorID, reviewerID := uuid.New(), uuid.New()
+	course := &domain.Course{ID: uuid.New(), Title: "Go Basics", InstructorID: instructorID}
+	existing := &domain.CourseReview{ID: uuid.New(), CourseID: course.ID, UserID: reviewerID}
+
+	reviewRepo := new(MockReviewRepository)
+	reviewRepo.On("GetByID", ctx, existing.ID).Return(existing, nil)
+	reviewRepo.On("Update", ctx, mock.Anything).Return(nil)
+	hub := realtime.NewHub()
+	notifier := notification.NewNotifier(&respondNotifications{}, &respondPrefs{}, hub)
+	uc := review.NewUseCase(reviewRepo, nil, &respondCourses{course: course}, notifier, config.ReviewConfig{})
+
+	events, unsubscribe := hub.Subscribe(reviewerID)
+	defer unsubscribe()
+
+	_, err := uc.RespondToReview(ctx, existing.ID, instructorID, "Thanks for the feedback")
+	require.NoError(t, err)
+
+	select {
+	case event := <-events:
+		n, ok := event.Data.(*domain.Notification)
+		require.True(t, ok)
+		assert.Equal(t, reviewerID, n.UserID)
+		assert.Equal(t, domain.NotificationReviewResponse, n.Type)
+		assert.Equal(t, domain.NotificationCategoryReview, n.Type.Category())
+	default:
+		t.Fatal("the reviewer's stream got no notification")
+	}
+}
@@ -3,19 +3,35 @@ package search
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
 )
 
+const (
+	// maxLoggedQueryLength matches the search_logs.query column size
+	maxLoggedQueryLength = 255
+	// suggestionThreshold is the minimum similarity for a suggestion to be shown
+	suggestionThreshold = 0.4
+	// maxSuggestionLength caps suggestion text so long titles don't break the dropdown
+	maxSuggestionLength = 80
+)
+
 // UseCase defines search business logic
 type UseCase struct {
 	searchRepo   repository.SearchRepository
 	courseRepo   repository.CourseRepository
 	categoryRepo repository.CategoryRepository
+	cfg          config.SearchConfig
 }
 
 // NewUseCase creates a new search use case
@@ -23,11 +39,13 @@ func NewUseCase(
 	searchRepo repository.SearchRepository,
 	courseRepo repository.CourseRepository,
 	categoryRepo repository.CategoryRepository,
+	cfg config.SearchConfig,
 ) *UseCase {
 	return &UseCase{
 		searchRepo:   searchRepo,
 		courseRepo:   courseRepo,
 		categoryRepo: categoryRepo,
+		cfg:          cfg,
 	}
 }
 
@@ -44,6 +62,24 @@ type SearchInput struct {
 	SortOrder  string     `json:"sort_order,omitempty"` // asc, desc
 	Page       int        `json:"page,omitempty"`
 	Limit      int        `json:"limit,omitempty"`
+	UserID     *uuid.UUID `json:"-"` // searching user, if authenticated
+}
+
+// toFilters converts search input into repository filters
+func (input SearchInput) toFilters() repository.SearchFilters {
+	return repository.SearchFilters{
+		Query:      input.Query,
+		CategoryID: input.CategoryID,
+		Level:      input.Level,
+		MinPrice:   input.MinPrice,
+		MaxPrice:   input.MaxPrice,
+		MinRating:  input.MinRating,
+		IsFree:     input.IsFree,
+		SortBy:     input.SortBy,
+		SortOrder:  input.SortOrder,
+		Page:       input.Page,
+		Limit:      input.Limit,
+	}
 }
 
 // SearchResult contains search results with metadata
@@ -91,26 +127,21 @@ func (uc *UseCase) Search(ctx context.Context, input SearchInput) (*SearchResult
 		}
 	}
 
-	// Build search filters
-	filters := repository.SearchFilters{
-		Query:      input.Query,
-		CategoryID: input.CategoryID,
-		Level:      input.Level,
-		MinPrice:   input.MinPrice,
-		MaxPrice:   input.MaxPrice,
-		MinRating:  input.MinRating,
-		IsFree:     input.IsFree,
-		SortBy:     input.SortBy,
-		SortOrder:  input.SortOrder,
-		Page:       input.Page,
-		Limit:      input.Limit,
-	}
+	filters := input.toFilters()
 
 	courses, total, err := uc.searchRepo.SearchCourses(ctx, filters)
 	if err != nil {
 		return nil, err
 	}
 
+	// Record the query off the request path; the request context is cancelled
+	// as soon as the response is written.
+	if uc.cfg.RecordQueries && input.Query != "" {
+		go func() {
+			_ = uc.RecordSearch(context.WithoutCancel(ctx), input.Query, input.UserID, total)
+		}()
+	}
+
 	// Calculate pages
 	totalPages := int(total) / input.Limit
 	if int(total)%input.Limit > 0 {
@@ -131,9 +162,10 @@ func (uc *UseCase) Search(ctx context.Context, input SearchInput) (*SearchResult
 	}, nil
 }
 
-// GetSearchFacets returns facets for filter UI
-func (uc *UseCase) GetSearchFacets(ctx context.Context, query string) (*SearchFacets, error) {
-	facets, err := uc.searchRepo.GetFacets(ctx, query)
+// GetSearchFacets returns facets for filter UI. Each facet is counted against
+// the other active filters, so the counts narrow as the user refines a search.
+func (uc *UseCase) GetSearchFacets(ctx context.Context, input SearchInput) (*SearchFacets, error) {
+	facets, err := uc.searchRepo.GetFacets(ctx, input.toFilters(), BuildPriceRanges(uc.cfg.PriceBuckets))
 	if err != nil {
 		return nil, err
 	}
@@ -163,23 +195,108 @@ func (uc *UseCase) GetSearchFacets(ctx context.Context, query string) (*SearchFa
 	}, nil
 }
 
-// GetSuggestions returns autocomplete suggestions
+// BuildPriceRanges turns ascending bucket bounds into price facets: a "Free"
+// bucket, one bucket between each pair of bounds and an open-ended top bucket.
+// Non-positive and duplicate bounds are ignored.
+func BuildPriceRanges(bounds []float64) []repository.PriceRange {
+	sorted := make([]float64, 0, len(bounds))
+	for _, b := range bounds {
+		if b > 0 {
+			sorted = append(sorted, b)
+		}
+	}
+	sort.Float64s(sorted)
+
+	zero := 0.0
+	ranges := []repository.PriceRange{{Label: "Free", Value: "free", Min: 0, Max: &zero}}
+
+	lower := 0.0
+	for _, b := range sorted {
+		if b == lower {
+			continue
+		}
+		upper := b
+		ranges = append(ranges, repository.PriceRange{
+			Label: fmt.Sprintf("$%s - $%s", formatPrice(lower), formatPrice(upper)),
+			Value: formatPrice(lower) + "-" + formatPrice(upper),
+			Min:   lower,
+			Max:   &upper,
+		})
+		lower = b
+	}
+	ranges = append(ranges, repository.PriceRange{
+		Label: fmt.Sprintf("$%s+", formatPrice(lower)),
+		Value: formatPrice(lower) + "+",
+		Min:   lower,
+	})
+
+	return ranges
+}
+
+func formatPrice(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// GetSuggestions returns autocomplete suggestions. Candidates from course
+// titles and category names are ranked by trigram similarity so that minor
+// typos ("javscript") still find the intended courses.
 func (uc *UseCase) GetSuggestions(ctx context.Context, query string, limit int) ([]string, error) {
-	if len(query) < 2 {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) < 2 {
 		return []string{}, nil
 	}
 	if limit < 1 || limit > 10 {
 		limit = 5
 	}
-	return uc.searchRepo.GetSuggestions(ctx, query, limit)
+
+	candidates, err := uc.searchRepo.GetSuggestions(ctx, query, limit*3)
+	if err != nil {
+		return nil, err
+	}
+
+	return RankSuggestions(query, candidates, limit), nil
 }
 
-// GetTrendingSearches returns popular search terms
+// GetTrendingSearches returns popular search terms. Only queries seen at least
+// TrendingMinCount times in the window are returned, so rare or personal
+// queries never surface.
 func (uc *UseCase) GetTrendingSearches(ctx context.Context, limit int) ([]string, error) {
 	if limit < 1 || limit > 20 {
 		limit = 10
 	}
-	return uc.searchRepo.GetTrendingSearches(ctx, limit)
+
+	window := uc.cfg.TrendingWindow
+	if window <= 0 {
+		window = 7 * 24 * time.Hour
+	}
+	minCount := uc.cfg.TrendingMinCount
+	if minCount < 1 {
+		minCount = 1
+	}
+
+	return uc.searchRepo.GetTrendingSearches(ctx, time.Now().Add(-window), minCount, limit)
+}
+
+// GetMyRecentSearches returns the user's distinct recent queries, newest first
+func (uc *UseCase) GetMyRecentSearches(ctx context.Context, userID uuid.UUID, limit int) ([]string, error) {
+	if limit < 1 || limit > 20 {
+		limit = 10
+	}
+
+	searches, err := uc.searchRepo.GetRecentSearches(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if searches == nil {
+		searches = []string{}
+	}
+	return searches, nil
+}
+
+// ClearMySearchHistory removes the user's searches from their personal history.
+// The queries remain anonymously counted towards trending searches.
+func (uc *UseCase) ClearMySearchHistory(ctx context.Context, userID uuid.UUID) error {
+	return uc.searchRepo.ClearUserSearches(ctx, userID)
 }
 
 // GetRelatedCourses returns courses similar to a given course
@@ -214,12 +331,26 @@ func (uc *UseCase) GetRelatedCourses(ctx context.Context, courseID uuid.UUID, li
 
 // RecordSearch logs a search for analytics
 func (uc *UseCase) RecordSearch(ctx context.Context, query string, userID *uuid.UUID, resultCount int64) error {
+	if !uc.cfg.RecordQueries {
+		return nil
+	}
+	query = NormalizeQuery(query)
 	if query == "" {
 		return nil
 	}
 	return uc.searchRepo.RecordSearch(ctx, query, userID, resultCount)
 }
 
+// NormalizeQuery lowercases and collapses whitespace so equivalent searches
+// aggregate together
+func NormalizeQuery(query string) string {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if utf8.RuneCountInString(query) > maxLoggedQueryLength {
+		query = string([]rune(query)[:maxLoggedQueryLength])
+	}
+	return query
+}
+
 // FormatSearchQuery normalizes query for FTS
 func FormatSearchQuery(query string) string {
 	// Clean and format for PostgreSQL tsquery
@@ -246,3 +377,124 @@ func FormatWebSearchQuery(query string) string {
 	}
 	return strings.Join(formatted, " & ")
 }
+
+// RankSuggestions scores candidates against the query, drops those below
+// suggestionThreshold, dedupes case-insensitively and returns the top limit.
+func RankSuggestions(query string, candidates []string, limit int) []string {
+	type scored struct {
+		text  string
+		score float64
+	}
+
+	seen := make(map[string]bool, len(candidates))
+	ranked := make([]scored, 0, len(candidates))
+	for _, c := range candidates {
+		text := truncateSuggestion(strings.TrimSpace(c))
+		key := strings.ToLower(text)
+		if text == "" || seen[key] {
+			continue
+		}
+
+		score := SuggestionScore(query, text)
+		if score < suggestionThreshold {
+			continue
+		}
+
+		seen[key] = true
+		ranked = append(ranked, scored{text: text, score: score})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	results := make([]string, len(ranked))
+	for i, r := range ranked {
+		results[i] = r.text
+	}
+	return results
+}
+
+// SuggestionScore returns how well text matches a (possibly misspelled) query,
+// from 0 to 1. Prefix matches score highest; otherwise the query is compared
+// with every run of the same number of words in text using trigram similarity,
+// which mirrors pg_trgm's word_similarity.
+func SuggestionScore(query, text string) float64 {
+	queryWords := tokenize(query)
+	textWords := tokenize(text)
+	if len(queryWords) == 0 || len(textWords) == 0 {
+		return 0
+	}
+
+	q := strings.Join(queryWords, " ")
+	if strings.HasPrefix(strings.Join(textWords, " "), q) {
+		return 1
+	}
+
+	n := len(queryWords)
+	if n > len(textWords) {
+		n = len(textWords)
+	}
+
+	best := 0.0
+	for i := 0; i+n <= len(textWords); i++ {
+		window := strings.Join(textWords[i:i+n], " ")
+		if strings.HasPrefix(window, q) {
+			return 0.9
+		}
+		if sim := trigramSimilarity(q, window); sim > best {
+			best = sim
+		}
+	}
+	return best
+}
+
+// trigramSimilarity is the Jaccard similarity of the trigram sets of a and b,
+// computed the same way as pg_trgm's similarity().
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range tokenize(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// tokenize lowercases s and splits it on anything that isn't a letter or digit
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func truncateSuggestion(text string) string {
+	if utf8.RuneCountInString(text) <= maxSuggestionLength {
+		return text
+	}
+	runes := []rune(text)[:maxSuggestionLength]
+	if i := strings.LastIndex(string(runes), " "); i > 0 {
+		return strings.TrimSpace(string(runes)[:i])
+	}
+	return string(runes)
+}
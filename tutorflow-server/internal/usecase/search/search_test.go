@@ -0,0 +1,121 @@
+package search_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/search"
+)
+
+// MockSearchRepository is a mock implementation of SearchRepository
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockSearchRepository) SearchCourses(ctx context.Context, filters repository.SearchFilters) ([]domain.Course, int64, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]domain.Course), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockSearchRepository) GetFacets(ctx context.Context, filters repository.SearchFilters, priceRanges []repository.PriceRange) (*repository.SearchFacets, error) {
+	args := m.Called(ctx, filters, priceRanges)
+	return args.Get(0).(*repository.SearchFacets), args.Error(1)
+}
+
+func (m *MockSearchRepository) GetSuggestions(ctx context.Context, query string, limit int) ([]string, error) {
+	args := m.Called(ctx, query, limit)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSearchRepository) GetTrendingSearches(ctx context.Context, since time.Time, minCount, limit int) ([]string, error) {
+	args := m.Called(ctx, since, minCount, limit)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSearchRepository) RecordSearch(ctx context.Context, query string, userID *uuid.UUID, resultCount int64) error {
+	args := m.Called(ctx, query, userID, resultCount)
+	return args.Error(0)
+}
+
+func (m *MockSearchRepository) GetRecentSearches(ctx context.Context, userID uuid.UUID, limit int) ([]string, error) {
+	args := m.Called(ctx, userID, limit)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSearchRepository) ClearUserSearches(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestSearchUseCase_GetSuggestions_Typo(t *testing.T) {
+	mockRepo := new(MockSearchRepository)
+	uc := search.NewUseCase(mockRepo, nil, nil, config.SearchConfig{})
+
+	candidates := []string{
+		"Java Fundamentals",
+		"JavaScript for Beginners",
+		"javascript for beginners",
+		"Python Basics",
+		"Advanced JavaScript Patterns",
+	}
+	mockRepo.On("GetSuggestions", mock.Anything, "javscript", 15).Return(candidates, nil)
+
+	suggestions, err := uc.GetSuggestions(context.Background(), "javscript", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"JavaScript for Beginners", "Advanced JavaScript Patterns"}, suggestions)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchUseCase_GetSuggestions_ShortQuery(t *testing.T) {
+	mockRepo := new(MockSearchRepository)
+	uc := search.NewUseCase(mockRepo, nil, nil, config.SearchConfig{})
+
+	suggestions, err := uc.GetSuggestions(context.Background(), "j", 5)
+	assert.NoError(t, err)
+	assert.Empty(t, suggestions)
+	mockRepo.AssertNotCalled(t, "GetSuggestions", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRankSuggestions(t *testing.T) {
+	longTitle := "Web Development " + strings.Repeat("Masterclass ", 10)
+
+	suggestions := search.RankSuggestions("web dev", []string{
+		"Web Development",
+		"WEB DEVELOPMENT",
+		longTitle,
+		"Data Science",
+	}, 5)
+
+	assert.Len(t, suggestions, 2, "duplicates and unrelated candidates should be dropped")
+	assert.Equal(t, "Web Development", suggestions[0])
+	assert.LessOrEqual(t, len([]rune(suggestions[1])), 80, "suggestions should be capped in length")
+}
+
+func TestSuggestionScore(t *testing.T) {
+	assert.Equal(t, 1.0, search.SuggestionScore("java", "JavaScript for Beginners"))
+	assert.Greater(t, search.SuggestionScore("javscript", "JavaScript for Beginners"), 0.5)
+	assert.Less(t, search.SuggestionScore("javscript", "Java Fundamentals"), 0.4)
+	assert.Equal(t, 0.0, search.SuggestionScore("", "Anything"))
+}
+
+func TestBuildPriceRanges(t *testing.T) {
+	ranges := search.BuildPriceRanges([]float64{50, 20, 0, 50})
+
+	values := make([]string, len(ranges))
+	for i, r := range ranges {
+		values[i] = r.Value
+	}
+	assert.Equal(t, []string{"free", "0-20", "20-50", "50+"}, values)
+	assert.Equal(t, "$20 - $50", ranges[2].Label)
+	assert.Nil(t, ranges[3].Max)
+
+	assert.Len(t, search.BuildPriceRanges(nil), 2, "no bounds still yields free and paid buckets")
+}
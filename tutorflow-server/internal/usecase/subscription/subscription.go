@@ -8,21 +8,34 @@ import (
 	"github.com/google/uuid"
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/payment"
 )
 
+// Billing takes payment for subscriptions; payment.Service implements it
+// with Stripe
+type Billing interface {
+	CreateSubscriptionCheckout(ctx context.Context, input payment.SubscriptionCheckoutInput) (string, error)
+	SetCancelAtPeriodEnd(ctx context.Context, stripeSubscriptionID string, cancel bool) error
+	ChangeSubscriptionPrice(ctx context.Context, stripeSubscriptionID, priceID string) error
+	CancelSubscription(ctx context.Context, stripeSubscriptionID string) error
+}
+
 type subscriptionUseCase struct {
 	subscriptionRepo repository.SubscriptionRepository
 	userRepo         repository.UserRepository
+	billing          Billing
 }
 
 // NewSubscriptionUseCase creates a new subscription use case
 func NewUseCase(
 	subscriptionRepo repository.SubscriptionRepository,
 	userRepo repository.UserRepository,
+	billing Billing,
 ) domain.SubscriptionUseCase {
 	return &subscriptionUseCase{
 		subscriptionRepo: subscriptionRepo,
 		userRepo:         userRepo,
+		billing:          billing,
 	}
 }
 
@@ -66,23 +79,28 @@ func (uc *subscriptionUseCase) UpdatePlan(ctx context.Context, plan *domain.Subs
 	existing.MaxDownloads = plan.MaxDownloads
 	existing.OfflineAccess = plan.OfflineAccess
 	existing.CertificateAccess = plan.CertificateAccess
+	existing.Tier = plan.Tier
+	existing.AllCourses = plan.AllCourses
+	existing.StripeProductID = plan.StripeProductID
+	existing.StripePriceMonthlyID = plan.StripePriceMonthlyID
+	existing.StripePriceYearlyID = plan.StripePriceYearlyID
 	existing.IsActive = plan.IsActive
 	existing.UpdatedAt = time.Now()
 
 	return uc.subscriptionRepo.UpdatePlan(ctx, existing)
 }
 
-// Subscribe creates a new subscription for a user
+// Subscribe starts a subscription for a user. Free plans start straight
+// away; paid plans return a Stripe Checkout URL and start when Stripe reports
+// the first payment.
 func (uc *subscriptionUseCase) Subscribe(
 	ctx context.Context,
 	userID uuid.UUID,
 	planSlug string,
 	interval domain.SubscriptionInterval,
-) (*domain.Subscription, error) {
-	// Check if user already has an active subscription
-	existing, _ := uc.subscriptionRepo.GetActiveByUserID(ctx, userID)
-	if existing != nil && existing.IsActive() {
-		return nil, errors.New("user already has an active subscription")
+) (*domain.SubscriptionCheckout, error) {
+	if interval != domain.SubscriptionIntervalMonthly && interval != domain.SubscriptionIntervalYearly {
+		return nil, errors.New("interval must be monthly or yearly")
 	}
 
 	// Get the plan
@@ -95,8 +113,33 @@ func (uc *subscriptionUseCase) Subscribe(
 		return nil, errors.New("plan is not available")
 	}
 
-	// Calculate period dates
+	price, priceID := plan.PriceFor(interval)
+	if price > 0 && (priceID == "" || uc.billing == nil) {
+		return nil, errors.New("plan is not available for purchase")
+	}
+
+	// Check if user already has an active subscription. Free subscribers
+	// move to a paid plan through checkout; the paid subscription is newer,
+	// so it becomes the active one once paid.
+	existing, _ := uc.subscriptionRepo.GetActiveByUserID(ctx, userID)
+	if existing != nil && existing.IsActive() && (price <= 0 || existing.StripeSubscriptionID != "") {
+		return nil, errors.New("user already has an active subscription")
+	}
+
+	// An abandoned checkout leaves a pending subscription behind. Retrying
+	// the same plan picks it up again; any other choice expires it.
 	now := time.Now()
+	pending, _ := uc.subscriptionRepo.GetPendingByUserID(ctx, userID)
+	if pending != nil && (price <= 0 || pending.PlanID != plan.ID || pending.Interval != interval) {
+		pending.Status = domain.SubscriptionStatusExpired
+		pending.UpdatedAt = now
+		if err := uc.subscriptionRepo.Update(ctx, pending); err != nil {
+			return nil, err
+		}
+		pending = nil
+	}
+
+	// Calculate period dates
 	var periodEnd time.Time
 	if interval == domain.SubscriptionIntervalMonthly {
 		periodEnd = now.AddDate(0, 1, 0)
@@ -114,15 +157,43 @@ func (uc *subscriptionUseCase) Subscribe(
 		CurrentPeriodEnd:   periodEnd,
 		CancelAtPeriodEnd:  false,
 	}
+	if price > 0 {
+		// Stripe sets the real period once paid
+		subscription.Status = domain.SubscriptionStatusIncomplete
+		subscription.CurrentPeriodEnd = now
+	}
 
-	if err := uc.subscriptionRepo.Create(ctx, subscription); err != nil {
+	if pending != nil {
+		subscription.ID = pending.ID
+		subscription.CreatedAt = pending.CreatedAt
+		err = uc.subscriptionRepo.Update(ctx, subscription)
+	} else {
+		err = uc.subscriptionRepo.Create(ctx, subscription)
+	}
+	if err != nil {
 		return nil, err
 	}
 
 	// Load the plan for response
 	subscription.Plan = plan
+	result := &domain.SubscriptionCheckout{Subscription: subscription}
+
+	if price > 0 {
+		user, err := uc.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		result.CheckoutURL, err = uc.billing.CreateSubscriptionCheckout(ctx, payment.SubscriptionCheckoutInput{
+			CustomerEmail:  user.Email,
+			PriceID:        priceID,
+			SubscriptionID: subscription.ID.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return subscription, nil
+	return result, nil
 }
 
 // GetUserSubscription returns the user's current subscription
@@ -137,6 +208,14 @@ func (uc *subscriptionUseCase) CancelSubscription(ctx context.Context, userID uu
 		return errors.New("no active subscription found")
 	}
 
+	// Record the change once Stripe has it, even if the caller goes away
+	ctx = context.WithoutCancel(ctx)
+	if sub.StripeSubscriptionID != "" && uc.billing != nil {
+		if err := uc.billing.SetCancelAtPeriodEnd(ctx, sub.StripeSubscriptionID, true); err != nil {
+			return err
+		}
+	}
+
 	now := time.Now()
 	sub.CancelAtPeriodEnd = true
 	sub.CanceledAt = &now
@@ -156,6 +235,13 @@ func (uc *subscriptionUseCase) ResumeSubscription(ctx context.Context, userID uu
 		return errors.New("subscription is not scheduled for cancellation")
 	}
 
+	ctx = context.WithoutCancel(ctx)
+	if sub.StripeSubscriptionID != "" && uc.billing != nil {
+		if err := uc.billing.SetCancelAtPeriodEnd(ctx, sub.StripeSubscriptionID, false); err != nil {
+			return err
+		}
+	}
+
 	sub.CancelAtPeriodEnd = false
 	sub.CanceledAt = nil
 	sub.UpdatedAt = time.Now()
@@ -163,7 +249,8 @@ func (uc *subscriptionUseCase) ResumeSubscription(ctx context.Context, userID uu
 	return uc.subscriptionRepo.Update(ctx, sub)
 }
 
-// ChangeSubscription changes the user's subscription to a new plan
+// ChangeSubscription changes the user's subscription to a new plan. Free
+// subscribers move to a paid plan by subscribing to it, which checks out.
 func (uc *subscriptionUseCase) ChangeSubscription(
 	ctx context.Context,
 	userID uuid.UUID,
@@ -183,6 +270,22 @@ func (uc *subscriptionUseCase) ChangeSubscription(
 		return nil, errors.New("plan is not available")
 	}
 
+	// Nothing bills a free subscription, so paying starts with checkout
+	price, priceID := newPlan.PriceFor(sub.Interval)
+	if sub.StripeSubscriptionID == "" && price > 0 {
+		return nil, errors.New("subscribe to a paid plan to start paying for it")
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if sub.StripeSubscriptionID != "" {
+		if priceID == "" || uc.billing == nil {
+			return nil, errors.New("plan is not available for purchase")
+		}
+		if err := uc.billing.ChangeSubscriptionPrice(ctx, sub.StripeSubscriptionID, priceID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update to new plan (takes effect immediately)
 	sub.PlanID = newPlan.ID
 	sub.UpdatedAt = time.Now()
@@ -195,53 +298,222 @@ func (uc *subscriptionUseCase) ChangeSubscription(
 	return sub, nil
 }
 
-// HandleWebhook handles Stripe webhook events
+// EndSubscriptions ends every subscription the user has straight away,
+// canceling them with Stripe so billing stops. Deleting an account does this
+// first.
+func (uc *subscriptionUseCase) EndSubscriptions(ctx context.Context, userID uuid.UUID) error {
+	subs, err := uc.subscriptionRepo.GetOpenByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+	for i := range subs {
+		sub := &subs[i]
+		if sub.StripeSubscriptionID != "" {
+			if uc.billing == nil {
+				return errors.New("subscription billing is not configured")
+			}
+			if err := uc.billing.CancelSubscription(ctx, sub.StripeSubscriptionID); err != nil {
+				return err
+			}
+		}
+
+		sub.Status = domain.SubscriptionStatusCanceled
+		sub.CancelAtPeriodEnd = false
+		if sub.CanceledAt == nil {
+			sub.CanceledAt = &now
+		}
+		sub.UpdatedAt = now
+		if err := uc.subscriptionRepo.Update(ctx, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleWebhook applies Stripe subscription events. Updating a subscription
+// also grants or revokes the enrollments it gave access to.
 func (uc *subscriptionUseCase) HandleWebhook(ctx context.Context, event string, data map[string]interface{}) error {
+	object, ok := data["object"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
 	switch event {
-	case "customer.subscription.updated":
-		// Handle subscription updates from Stripe
-		return uc.handleSubscriptionUpdated(ctx, data)
+	case "customer.subscription.created", "customer.subscription.updated":
+		return uc.handleSubscriptionUpdated(ctx, object)
 	case "customer.subscription.deleted":
-		// Handle subscription deletion
-		return uc.handleSubscriptionDeleted(ctx, data)
+		return uc.handleSubscriptionDeleted(ctx, object)
 	case "invoice.payment_failed":
-		// Handle failed payment
-		return uc.handlePaymentFailed(ctx, data)
+		return uc.handlePaymentFailed(ctx, object)
 	case "invoice.paid":
-		// Handle successful payment
-		return uc.handlePaymentSucceeded(ctx, data)
+		return uc.handlePaymentSucceeded(ctx, object)
 	default:
 		return nil
 	}
 }
 
-func (uc *subscriptionUseCase) handleSubscriptionUpdated(ctx context.Context, data map[string]interface{}) error {
-	object, ok := data["object"].(map[string]interface{})
-	if !ok {
+// stripeStatuses maps Stripe subscription statuses onto ours
+var stripeStatuses = map[string]domain.SubscriptionStatus{
+	"active":             domain.SubscriptionStatusActive,
+	"trialing":           domain.SubscriptionStatusTrialing,
+	"past_due":           domain.SubscriptionStatusPastDue,
+	"unpaid":             domain.SubscriptionStatusPastDue,
+	"canceled":           domain.SubscriptionStatusCanceled,
+	"incomplete":         domain.SubscriptionStatusIncomplete,
+	"incomplete_expired": domain.SubscriptionStatusExpired,
+}
+
+// findByStripe finds our subscription for a Stripe one, by its ID or, before
+// we have seen it, the subscription_id we put in its metadata. Events for
+// subscriptions that aren't ours return nil.
+func (uc *subscriptionUseCase) findByStripe(ctx context.Context, stripeSubID string, metadata interface{}) *domain.Subscription {
+	if stripeSubID == "" {
+		return nil
+	}
+	if sub, err := uc.subscriptionRepo.GetByStripeID(ctx, stripeSubID); err == nil {
+		return sub
+	}
+
+	meta, _ := metadata.(map[string]interface{})
+	idStr, _ := meta["subscription_id"].(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil
+	}
+	sub, err := uc.subscriptionRepo.GetByID(ctx, id)
+	if err != nil {
 		return nil
 	}
+	sub.StripeSubscriptionID = stripeSubID
+	return sub
+}
 
+func (uc *subscriptionUseCase) handleSubscriptionUpdated(ctx context.Context, object map[string]interface{}) error {
 	stripeSubID, _ := object["id"].(string)
-	if stripeSubID == "" {
+	sub := uc.findByStripe(ctx, stripeSubID, object["metadata"])
+	if sub == nil {
 		return nil
 	}
 
-	// Find subscription by Stripe ID and update
-	// In production, you'd look up by StripeSubscriptionID
-	return nil
+	if status, ok := stripeStatuses[stringField(object, "status")]; ok {
+		sub.Status = status
+	}
+	if customer := stringField(object, "customer"); customer != "" {
+		sub.StripeCustomerID = customer
+	}
+	if start, ok := timeField(object, "current_period_start"); ok {
+		sub.CurrentPeriodStart = start
+	}
+	if end, ok := timeField(object, "current_period_end"); ok {
+		sub.CurrentPeriodEnd = end
+	}
+	if cancel, ok := object["cancel_at_period_end"].(bool); ok {
+		sub.CancelAtPeriodEnd = cancel
+	}
+	sub.UpdatedAt = time.Now()
+
+	return uc.subscriptionRepo.Update(ctx, sub)
 }
 
-func (uc *subscriptionUseCase) handleSubscriptionDeleted(ctx context.Context, data map[string]interface{}) error {
-	// Mark subscription as expired
-	return nil
+func (uc *subscriptionUseCase) handleSubscriptionDeleted(ctx context.Context, object map[string]interface{}) error {
+	stripeSubID, _ := object["id"].(string)
+	sub := uc.findByStripe(ctx, stripeSubID, object["metadata"])
+	if sub == nil {
+		return nil
+	}
+
+	now := time.Now()
+	sub.Status = domain.SubscriptionStatusCanceled
+	if sub.CanceledAt == nil {
+		sub.CanceledAt = &now
+	}
+	sub.UpdatedAt = now
+
+	return uc.subscriptionRepo.Update(ctx, sub)
 }
 
-func (uc *subscriptionUseCase) handlePaymentFailed(ctx context.Context, data map[string]interface{}) error {
-	// Mark subscription as past_due
-	return nil
+func (uc *subscriptionUseCase) handlePaymentFailed(ctx context.Context, invoice map[string]interface{}) error {
+	sub := uc.findByStripe(ctx, stringField(invoice, "subscription"), invoiceMetadata(invoice))
+	if sub == nil {
+		return nil
+	}
+
+	sub.Status = domain.SubscriptionStatusPastDue
+	sub.UpdatedAt = time.Now()
+
+	return uc.subscriptionRepo.Update(ctx, sub)
 }
 
-func (uc *subscriptionUseCase) handlePaymentSucceeded(ctx context.Context, data map[string]interface{}) error {
-	// Extend subscription period
-	return nil
+func (uc *subscriptionUseCase) handlePaymentSucceeded(ctx context.Context, invoice map[string]interface{}) error {
+	sub := uc.findByStripe(ctx, stringField(invoice, "subscription"), invoiceMetadata(invoice))
+	if sub == nil {
+		return nil
+	}
+
+	sub.Status = domain.SubscriptionStatusActive
+	// The invoice's line covers the period just paid for
+	if lines, ok := invoice["lines"].(map[string]interface{}); ok {
+		if items, ok := lines["data"].([]interface{}); ok && len(items) > 0 {
+			line, _ := items[0].(map[string]interface{})
+			period, _ := line["period"].(map[string]interface{})
+			if start, ok := timeField(period, "start"); ok {
+				sub.CurrentPeriodStart = start
+			}
+			if end, ok := timeField(period, "end"); ok {
+				sub.CurrentPeriodEnd = end
+			}
+		}
+	}
+	sub.UpdatedAt = time.Now()
+
+	return uc.subscriptionRepo.Update(ctx, sub)
+}
+
+// ExpireLapsed ends subscriptions whose period is over and that Stripe won't
+// renew, i.e. free plans. It returns how many were expired.
+func (uc *subscriptionUseCase) ExpireLapsed(ctx context.Context) (int, error) {
+	subs, err := uc.subscriptionRepo.GetExpiringSubscriptions(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	now := time.Now()
+	for i := range subs {
+		sub := &subs[i]
+		if sub.StripeSubscriptionID != "" || sub.CurrentPeriodEnd.After(now) {
+			continue
+		}
+		sub.Status = domain.SubscriptionStatusExpired
+		sub.UpdatedAt = now
+		if err := uc.subscriptionRepo.Update(ctx, sub); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// invoiceMetadata returns the subscription metadata Stripe copies onto an
+// invoice
+func invoiceMetadata(invoice map[string]interface{}) interface{} {
+	details, _ := invoice["subscription_details"].(map[string]interface{})
+	return details["metadata"]
+}
+
+func stringField(object map[string]interface{}, key string) string {
+	v, _ := object[key].(string)
+	return v
+}
+
+// timeField reads a Unix timestamp, which JSON decodes as a float64
+func timeField(object map[string]interface{}, key string) (time.Time, bool) {
+	v, ok := object[key].(float64)
+	if !ok || v == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(v), 0), true
 }
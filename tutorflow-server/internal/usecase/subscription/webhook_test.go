@@ -0,0 +1,322 @@
+package subscription_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/payment"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/subscription"
+)
+
+// MockSubscriptionStore implements repository.SubscriptionRepository
+type MockSubscriptionStore struct {
+	mock.Mock
+}
+
+var _ repository.SubscriptionRepository = (*MockSubscriptionStore)(nil)
+
+func (m *MockSubscriptionStore) CreatePlan(ctx context.Context, plan *domain.SubscriptionPlan) error {
+	return m.Called(ctx, plan).Error(0)
+}
+
+func (m *MockSubscriptionStore) GetPlanByID(ctx context.Context, id uuid.UUID) (*domain.SubscriptionPlan, error) {
+	args := m.Called(ctx, id)
+	plan, _ := args.Get(0).(*domain.SubscriptionPlan)
+	return plan, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) GetPlanBySlug(ctx context.Context, slug string) (*domain.SubscriptionPlan, error) {
+	args := m.Called(ctx, slug)
+	plan, _ := args.Get(0).(*domain.SubscriptionPlan)
+	return plan, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) GetActivePlans(ctx context.Context) ([]domain.SubscriptionPlan, error) {
+	args := m.Called(ctx)
+	plans, _ := args.Get(0).([]domain.SubscriptionPlan)
+	return plans, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) UpdatePlan(ctx context.Context, plan *domain.SubscriptionPlan) error {
+	return m.Called(ctx, plan).Error(0)
+}
+
+func (m *MockSubscriptionStore) Create(ctx context.Context, sub *domain.Subscription) error {
+	args := m.Called(ctx, sub)
+	sub.ID = uuid.New()
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionStore) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	args := m.Called(ctx, id)
+	sub, _ := args.Get(0).(*domain.Subscription)
+	return sub, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error) {
+	args := m.Called(ctx, userID)
+	sub, _ := args.Get(0).(*domain.Subscription)
+	return sub, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error) {
+	args := m.Called(ctx, userID)
+	sub, _ := args.Get(0).(*domain.Subscription)
+	return sub, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) GetByStripeID(ctx context.Context, stripeSubscriptionID string) (*domain.Subscription, error) {
+	args := m.Called(ctx, stripeSubscriptionID)
+	sub, _ := args.Get(0).(*domain.Subscription)
+	return sub, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) GetPendingByUserID(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error) {
+	args := m.Called(ctx, userID)
+	sub, _ := args.Get(0).(*domain.Subscription)
+	return sub, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) GetOpenByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Subscription, error) {
+	args := m.Called(ctx, userID)
+	subs, _ := args.Get(0).([]domain.Subscription)
+	return subs, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) Update(ctx context.Context, sub *domain.Subscription) error {
+	return m.Called(ctx, sub).Error(0)
+}
+
+func (m *MockSubscriptionStore) Cancel(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockSubscriptionStore) GetExpiringSubscriptions(ctx context.Context, days int) ([]domain.Subscription, error) {
+	args := m.Called(ctx, days)
+	subs, _ := args.Get(0).([]domain.Subscription)
+	return subs, args.Error(1)
+}
+
+// fakeBilling records checkouts and cancellations instead of calling Stripe
+type fakeBilling struct {
+	checkout payment.SubscriptionCheckoutInput
+	canceled []string
+}
+
+func (f *fakeBilling) CreateSubscriptionCheckout(ctx context.Context, input payment.SubscriptionCheckoutInput) (string, error) {
+	f.checkout = input
+	return "https://checkout.stripe.test/session", nil
+}
+
+func (f *fakeBilling) SetCancelAtPeriodEnd(ctx context.Context, stripeSubscriptionID string, cancel bool) error {
+	return nil
+}
+
+func (f *fakeBilling) ChangeSubscriptionPrice(ctx context.Context, stripeSubscriptionID, priceID string) error {
+	return nil
+}
+
+func (f *fakeBilling) CancelSubscription(ctx context.Context, stripeSubscriptionID string) error {
+	f.canceled = append(f.canceled, stripeSubscriptionID)
+	return nil
+}
+
+// ctxUserRepository returns a fixed user
+type ctxUserRepository struct {
+	repository.UserRepository
+	user *domain.User
+}
+
+func (r *ctxUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return r.user, nil
+}
+
+func TestSubscribe_PaidPlanStartsCheckout(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := new(MockSubscriptionStore)
+	billing := &fakeBilling{}
+	uc := subscription.NewUseCase(repo, &ctxUserRepository{user: &domain.User{ID: userID, Email: "sam@example.com"}}, billing)
+
+	plan := &domain.SubscriptionPlan{ID: uuid.New(), Slug: "pro", PriceMonthly: 9.99, StripePriceMonthlyID: "price_pro", IsActive: true}
+	repo.On("GetActiveByUserID", ctx, userID).Return(nil, errors.New("not found"))
+	repo.On("GetPlanBySlug", ctx, "pro").Return(plan, nil)
+	repo.On("GetPendingByUserID", ctx, userID).Return(nil, errors.New("not found"))
+	repo.On("Create", ctx, mock.AnythingOfType("*domain.Subscription")).Return(nil)
+
+	result, err := uc.Subscribe(ctx, userID, "pro", domain.SubscriptionIntervalMonthly)
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://checkout.stripe.test/session", result.CheckoutURL)
+	assert.Equal(t, domain.SubscriptionStatusIncomplete, result.Subscription.Status)
+	assert.False(t, result.Subscription.GrantsAccess())
+	assert.Equal(t, "price_pro", billing.checkout.PriceID)
+	assert.Equal(t, result.Subscription.ID.String(), billing.checkout.SubscriptionID)
+}
+
+func TestSubscribe_AbandonedCheckout(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	pro := &domain.SubscriptionPlan{ID: uuid.New(), Slug: "pro", PriceMonthly: 9.99, PriceYearly: 99, StripePriceMonthlyID: "price_pro", StripePriceYearlyID: "price_pro_yearly", IsActive: true}
+	pending := func() *domain.Subscription {
+		return &domain.Subscription{ID: uuid.New(), UserID: userID, PlanID: pro.ID, Status: domain.SubscriptionStatusIncomplete, Interval: domain.SubscriptionIntervalMonthly}
+	}
+	setup := func(left *domain.Subscription) (*MockSubscriptionStore, domain.SubscriptionUseCase) {
+		repo := new(MockSubscriptionStore)
+		repo.On("GetActiveByUserID", ctx, userID).Return(nil, errors.New("not found"))
+		repo.On("GetPlanBySlug", ctx, "pro").Return(pro, nil)
+		repo.On("GetPendingByUserID", ctx, userID).Return(left, nil)
+		user := &ctxUserRepository{user: &domain.User{ID: userID, Email: "sam@example.com"}}
+		return repo, subscription.NewUseCase(repo, user, &fakeBilling{})
+	}
+
+	t.Run("retrying the same plan reuses the pending subscription", func(t *testing.T) {
+		left := pending()
+		repo, uc := setup(left)
+		repo.On("Update", ctx, mock.AnythingOfType("*domain.Subscription")).Return(nil)
+
+		result, err := uc.Subscribe(ctx, userID, "pro", domain.SubscriptionIntervalMonthly)
+		require.NoError(t, err)
+		assert.Equal(t, left.ID, result.Subscription.ID)
+		assert.Equal(t, domain.SubscriptionStatusIncomplete, result.Subscription.Status)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("choosing differently expires it", func(t *testing.T) {
+		left := pending()
+		repo, uc := setup(left)
+		repo.On("Update", ctx, left).Return(nil)
+		repo.On("Create", ctx, mock.AnythingOfType("*domain.Subscription")).Return(nil)
+
+		result, err := uc.Subscribe(ctx, userID, "pro", domain.SubscriptionIntervalYearly)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SubscriptionStatusExpired, left.Status)
+		assert.NotEqual(t, left.ID, result.Subscription.ID)
+		repo.AssertCalled(t, "Create", ctx, result.Subscription)
+	})
+}
+
+func TestChangeSubscription_FreeToPaid(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	free := &domain.SubscriptionPlan{ID: uuid.New(), Slug: "free", IsActive: true}
+	pro := &domain.SubscriptionPlan{ID: uuid.New(), Slug: "pro", PriceMonthly: 9.99, StripePriceMonthlyID: "price_pro", IsActive: true}
+	current := &domain.Subscription{
+		ID: uuid.New(), UserID: userID, PlanID: free.ID, Status: domain.SubscriptionStatusActive,
+		Interval: domain.SubscriptionIntervalMonthly, CurrentPeriodEnd: time.Now().AddDate(0, 1, 0),
+	}
+	repo := new(MockSubscriptionStore)
+	repo.On("GetActiveByUserID", ctx, userID).Return(current, nil)
+	repo.On("GetPlanBySlug", ctx, "pro").Return(pro, nil)
+	repo.On("GetPendingByUserID", ctx, userID).Return(nil, errors.New("not found"))
+	repo.On("Create", ctx, mock.AnythingOfType("*domain.Subscription")).Return(nil)
+	billing := &fakeBilling{}
+	uc := subscription.NewUseCase(repo, &ctxUserRepository{user: &domain.User{ID: userID, Email: "sam@example.com"}}, billing)
+
+	_, err := uc.ChangeSubscription(ctx, userID, "pro")
+	require.Error(t, err, "a free subscription can't switch to a paid plan without paying")
+	assert.Equal(t, free.ID, current.PlanID)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	result, err := uc.Subscribe(ctx, userID, "pro", domain.SubscriptionIntervalMonthly)
+	require.NoError(t, err, "it goes through checkout instead")
+	assert.Equal(t, "https://checkout.stripe.test/session", result.CheckoutURL)
+	assert.Equal(t, domain.SubscriptionStatusIncomplete, result.Subscription.Status)
+	assert.Equal(t, "price_pro", billing.checkout.PriceID)
+}
+
+func TestEndSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	paid := domain.Subscription{ID: uuid.New(), UserID: userID, Status: domain.SubscriptionStatusActive, StripeSubscriptionID: "sub_123", CancelAtPeriodEnd: true}
+	free := domain.Subscription{ID: uuid.New(), UserID: userID, Status: domain.SubscriptionStatusActive}
+	repo := new(MockSubscriptionStore)
+	repo.On("GetOpenByUserID", ctx, userID).Return([]domain.Subscription{paid, free}, nil)
+	var saved []*domain.Subscription
+	repo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Subscription")).
+		Run(func(args mock.Arguments) { saved = append(saved, args.Get(1).(*domain.Subscription)) }).
+		Return(nil)
+	billing := &fakeBilling{}
+	uc := subscription.NewUseCase(repo, nil, billing)
+
+	require.NoError(t, uc.EndSubscriptions(ctx, userID))
+
+	assert.Equal(t, []string{"sub_123"}, billing.canceled, "Stripe stops billing straight away")
+	require.Len(t, saved, 2)
+	for _, sub := range saved {
+		assert.Equal(t, domain.SubscriptionStatusCanceled, sub.Status)
+		assert.False(t, sub.CancelAtPeriodEnd)
+		assert.NotNil(t, sub.CanceledAt)
+	}
+}
+
+func TestHandleWebhook(t *testing.T) {
+	ctx := context.Background()
+	periodEnd := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+
+	t.Run("first update links the Stripe subscription and grants access", func(t *testing.T) {
+		repo := new(MockSubscriptionStore)
+		uc := subscription.NewUseCase(repo, nil, &fakeBilling{})
+		sub := &domain.Subscription{ID: uuid.New(), Status: domain.SubscriptionStatusIncomplete}
+
+		repo.On("GetByStripeID", ctx, "sub_123").Return(nil, errors.New("not found"))
+		repo.On("GetByID", ctx, sub.ID).Return(sub, nil)
+		repo.On("Update", ctx, sub).Return(nil)
+
+		err := uc.HandleWebhook(ctx, "customer.subscription.updated", map[string]interface{}{
+			"object": map[string]interface{}{
+				"id":                 "sub_123",
+				"status":             "active",
+				"customer":           "cus_1",
+				"current_period_end": float64(periodEnd.Unix()),
+				"metadata":           map[string]interface{}{"subscription_id": sub.ID.String()},
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "sub_123", sub.StripeSubscriptionID)
+		assert.Equal(t, "cus_1", sub.StripeCustomerID)
+		assert.Equal(t, periodEnd, sub.CurrentPeriodEnd)
+		assert.True(t, sub.GrantsAccess())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("failed payment revokes access", func(t *testing.T) {
+		repo := new(MockSubscriptionStore)
+		uc := subscription.NewUseCase(repo, nil, &fakeBilling{})
+		sub := &domain.Subscription{ID: uuid.New(), Status: domain.SubscriptionStatusActive, CurrentPeriodEnd: periodEnd, StripeSubscriptionID: "sub_123"}
+
+		repo.On("GetByStripeID", ctx, "sub_123").Return(sub, nil)
+		repo.On("Update", ctx, sub).Return(nil)
+
+		err := uc.HandleWebhook(ctx, "invoice.payment_failed", map[string]interface{}{
+			"object": map[string]interface{}{"subscription": "sub_123"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.SubscriptionStatusPastDue, sub.Status)
+		assert.False(t, sub.GrantsAccess())
+	})
+
+	t.Run("events for unknown subscriptions are ignored", func(t *testing.T) {
+		repo := new(MockSubscriptionStore)
+		uc := subscription.NewUseCase(repo, nil, &fakeBilling{})
+		repo.On("GetByStripeID", ctx, "sub_other").Return(nil, errors.New("not found"))
+
+		err := uc.HandleWebhook(ctx, "customer.subscription.deleted", map[string]interface{}{
+			"object": map[string]interface{}{"id": "sub_other"},
+		})
+
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,247 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/user"
+)
+
+// MockUserRepository is a mock implementation of UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	u, _ := args.Get(0).(*domain.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	args := m.Called(ctx, usernames)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, u *domain.User) error {
+	return m.Called(ctx, u).Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filters repository.UserFilters) ([]domain.User, int64, error) {
+	args := m.Called(ctx, filters)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return m.Called(ctx, id, passwordHash).Error(0)
+}
+
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) UpdateLastActive(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+func (m *MockUserRepository) Anonymize(ctx context.Context, u *domain.User, entry *domain.AuditLog) error {
+	return m.Called(ctx, u, entry).Error(0)
+}
+
+// fakeDeletionRepo keeps deletion tokens in memory
+type fakeDeletionRepo struct {
+	repository.AccountDeletionRepository
+	tokens map[string]domain.AccountDeletionToken
+}
+
+func (r *fakeDeletionRepo) Create(ctx context.Context, token *domain.AccountDeletionToken) error {
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *fakeDeletionRepo) GetByHash(ctx context.Context, tokenHash string) (*domain.AccountDeletionToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, domain.ErrDeletionTokenInvalid
+	}
+	return &token, nil
+}
+
+func (r *fakeDeletionRepo) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	for tokenHash, token := range r.tokens {
+		if token.UserID == userID {
+			delete(r.tokens, tokenHash)
+		}
+	}
+	return nil
+}
+
+// fakeSubscriptions records whose subscriptions were ended
+type fakeSubscriptions struct {
+	ended []uuid.UUID
+	err   error
+}
+
+func (f *fakeSubscriptions) EndSubscriptions(ctx context.Context, userID uuid.UUID) error {
+	f.ended = append(f.ended, userID)
+	return f.err
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+
+	newUser := func(t *testing.T) *domain.User {
+		passwordHash, err := hash.HashPassword("correct-horse")
+		require.NoError(t, err)
+		username := "sam"
+		phone := "+15550100"
+		return &domain.User{
+			ID:           uuid.New(),
+			Email:        "sam@example.com",
+			Username:     &username,
+			PasswordHash: passwordHash,
+			FirstName:    "Sam",
+			LastName:     "Lee",
+			Phone:        &phone,
+			Status:       domain.StatusActive,
+		}
+	}
+
+	t.Run("wrong password", func(t *testing.T) {
+		u := newUser(t)
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, u.ID).Return(u, nil)
+		uc := user.NewUseCase(userRepo, nil, nil, nil, nil, nil)
+
+		err := uc.DeleteAccount(ctx, u.ID, user.DeleteAccountInput{Password: "wrong"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		userRepo.AssertNotCalled(t, "Anonymize", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("anonymizes and audits", func(t *testing.T) {
+		u := newUser(t)
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, u.ID).Return(u, nil)
+		userRepo.On("Anonymize", ctx, mock.Anything, mock.Anything).Return(nil)
+		uc := user.NewUseCase(userRepo, nil, nil, nil, nil, nil)
+
+		err := uc.DeleteAccount(ctx, u.ID, user.DeleteAccountInput{Password: "correct-horse"})
+		require.NoError(t, err)
+
+		saved := userRepo.Calls[1].Arguments.Get(1).(*domain.User)
+		entry := userRepo.Calls[1].Arguments.Get(2).(*domain.AuditLog)
+
+		assert.Equal(t, u.ID, saved.ID)
+		assert.Equal(t, domain.StatusDeleted, saved.Status)
+		assert.NotContains(t, saved.Email, "sam@example.com")
+		assert.Equal(t, "Deleted", saved.FirstName)
+		assert.Nil(t, saved.Username)
+		assert.Nil(t, saved.Phone)
+		assert.False(t, hash.CheckPassword("correct-horse", saved.PasswordHash))
+
+		assert.Equal(t, domain.AuditUserDelete, entry.Action)
+		assert.Equal(t, u.ID, entry.ActorID)
+		assert.NotContains(t, *entry.Before, "sam@example.com")
+	})
+
+	t.Run("with a deletion link", func(t *testing.T) {
+		u := newUser(t)
+		deletions := &fakeDeletionRepo{tokens: map[string]domain.AccountDeletionToken{}}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, u.ID).Return(u, nil)
+		userRepo.On("Anonymize", ctx, mock.Anything, mock.Anything).Return(nil)
+		uc := user.NewUseCase(userRepo, nil, nil, deletions, nil, nil)
+
+		require.NoError(t, uc.RequestAccountDeletion(ctx, u.ID))
+		require.Len(t, deletions.tokens, 1)
+
+		// The email isn't sent without a mail service, so plant a token we know
+		token, tokenHash, err := hash.GenerateSecureToken(32)
+		require.NoError(t, err)
+		require.NoError(t, deletions.Create(ctx, &domain.AccountDeletionToken{
+			UserID: u.ID, TokenHash: tokenHash, ExpiresAt: time.Now().Add(time.Hour),
+		}))
+
+		err = uc.DeleteAccount(ctx, u.ID, user.DeleteAccountInput{Token: "not-the-token"})
+		assert.ErrorIs(t, err, domain.ErrDeletionTokenInvalid)
+
+		other := newUser(t)
+		userRepo.On("GetByID", ctx, other.ID).Return(other, nil)
+		err = uc.DeleteAccount(ctx, other.ID, user.DeleteAccountInput{Token: token})
+		assert.ErrorIs(t, err, domain.ErrDeletionTokenInvalid, "a link only deletes the account it was sent to")
+
+		require.NoError(t, uc.DeleteAccount(ctx, u.ID, user.DeleteAccountInput{Token: token}))
+		userRepo.AssertNumberOfCalls(t, "Anonymize", 1)
+	})
+
+	t.Run("expired deletion link", func(t *testing.T) {
+		u := newUser(t)
+		token, tokenHash, err := hash.GenerateSecureToken(32)
+		require.NoError(t, err)
+		deletions := &fakeDeletionRepo{tokens: map[string]domain.AccountDeletionToken{
+			tokenHash: {UserID: u.ID, TokenHash: tokenHash, ExpiresAt: time.Now().Add(-time.Minute)},
+		}}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, u.ID).Return(u, nil)
+		uc := user.NewUseCase(userRepo, nil, nil, deletions, nil, nil)
+
+		err = uc.DeleteAccount(ctx, u.ID, user.DeleteAccountInput{Token: token})
+
+		assert.ErrorIs(t, err, domain.ErrDeletionTokenInvalid)
+		userRepo.AssertNotCalled(t, "Anonymize", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ends subscriptions before anonymizing", func(t *testing.T) {
+		u := newUser(t)
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, u.ID).Return(u, nil)
+		userRepo.On("Anonymize", ctx, mock.Anything, mock.Anything).Return(nil)
+		subs := &fakeSubscriptions{}
+		uc := user.NewUseCase(userRepo, nil, nil, nil, subs, nil)
+
+		require.NoError(t, uc.DeleteAccount(ctx, u.ID, user.DeleteAccountInput{Password: "correct-horse"}))
+		assert.Equal(t, []uuid.UUID{u.ID}, subs.ended)
+	})
+
+	t.Run("keeps the account if billing can't be stopped", func(t *testing.T) {
+		u := newUser(t)
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", ctx, u.ID).Return(u, nil)
+		uc := user.NewUseCase(userRepo, nil, nil, nil, &fakeSubscriptions{err: assert.AnError}, nil)
+
+		err := uc.DeleteAccount(ctx, u.ID, user.DeleteAccountInput{Password: "correct-horse"})
+
+		assert.ErrorIs(t, err, assert.AnError)
+		userRepo.AssertNotCalled(t, "Anonymize", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
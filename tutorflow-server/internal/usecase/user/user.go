@@ -2,28 +2,52 @@ package user
 
 import (
 	"context"
+	"net/url"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/tutorflow/tutorflow-server/internal/domain"
 	"github.com/tutorflow/tutorflow-server/internal/pkg/hash"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
+	"github.com/tutorflow/tutorflow-server/internal/service/email"
 )
 
+// accountDeletionTTL is how long a deletion link works, as the email promises
+const accountDeletionTTL = time.Hour
+
+// Subscriptions ends a user's subscriptions; the subscription use case
+// implements it
+type Subscriptions interface {
+	EndSubscriptions(ctx context.Context, userID uuid.UUID) error
+}
+
 // UseCase defines user management business logic
 type UseCase struct {
-	userRepo  repository.UserRepository
-	tutorRepo repository.TutorProfileRepository
+	userRepo      repository.UserRepository
+	tutorRepo     repository.TutorProfileRepository
+	auditRepo     repository.AuditLogRepository
+	deletionRepo  repository.AccountDeletionRepository
+	subscriptions Subscriptions
+	emailSvc      *email.Service
 }
 
 // NewUseCase creates a new user use case
 func NewUseCase(
 	userRepo repository.UserRepository,
 	tutorRepo repository.TutorProfileRepository,
+	auditRepo repository.AuditLogRepository,
+	deletionRepo repository.AccountDeletionRepository,
+	subscriptions Subscriptions,
+	emailSvc *email.Service,
 ) *UseCase {
 	return &UseCase{
-		userRepo:  userRepo,
-		tutorRepo: tutorRepo,
+		userRepo:      userRepo,
+		tutorRepo:     tutorRepo,
+		auditRepo:     auditRepo,
+		deletionRepo:  deletionRepo,
+		subscriptions: subscriptions,
+		emailSvc:      emailSvc,
 	}
 }
 
@@ -61,11 +85,13 @@ func (uc *UseCase) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, err
 
 // UpdateInput for updating a user
 type UpdateInput struct {
-	FirstName *string `json:"first_name" validate:"omitempty,min=2,max=100"`
-	LastName  *string `json:"last_name" validate:"omitempty,min=2,max=100"`
-	Phone     *string `json:"phone" validate:"omitempty,max=20"`
-	Bio       *string `json:"bio" validate:"omitempty,max=1000"`
-	AvatarURL *string `json:"avatar_url" validate:"omitempty,url"`
+	Username     *string `json:"username"` // 3-30 letters, digits or underscores; stored lowercase
+	FirstName    *string `json:"first_name" validate:"omitempty,min=2,max=100"`
+	LastName     *string `json:"last_name" validate:"omitempty,min=2,max=100"`
+	Phone        *string `json:"phone" validate:"omitempty,max=20"`
+	Bio          *string `json:"bio" validate:"omitempty,max=1000"`
+	AvatarURL    *string `json:"avatar_url" validate:"omitempty,url"`
+	HidePresence *bool   `json:"hide_presence"` // appear offline to other users
 }
 
 // Update updates a user
@@ -75,6 +101,20 @@ func (uc *UseCase) Update(ctx context.Context, id uuid.UUID, input UpdateInput)
 		return nil, err
 	}
 
+	if input.Username != nil {
+		username, ok := domain.NormalizeUsername(*input.Username)
+		if !ok {
+			return nil, domain.ValidationErrors{{Field: "username", Message: "must be 3-30 letters, digits or underscores"}}
+		}
+		taken, err := uc.userRepo.GetByUsernames(ctx, []string{username})
+		if err != nil {
+			return nil, err
+		}
+		if len(taken) > 0 && taken[0].ID != user.ID {
+			return nil, domain.ErrUsernameTaken
+		}
+		user.Username = &username
+	}
 	if input.FirstName != nil {
 		user.FirstName = *input.FirstName
 	}
@@ -90,6 +130,9 @@ func (uc *UseCase) Update(ctx context.Context, id uuid.UUID, input UpdateInput)
 	if input.AvatarURL != nil {
 		user.AvatarURL = input.AvatarURL
 	}
+	if input.HidePresence != nil {
+		user.HidePresence = *input.HidePresence
+	}
 
 	if err := uc.userRepo.Update(ctx, user); err != nil {
 		return nil, err
@@ -103,15 +146,36 @@ type UpdateStatusInput struct {
 	Status domain.UserStatus `json:"status" validate:"required,oneof=active inactive suspended pending"`
 }
 
-// UpdateStatus updates a user's status (admin only)
-func (uc *UseCase) UpdateStatus(ctx context.Context, id uuid.UUID, input UpdateStatusInput) error {
+// UpdateStatus updates a user's status (admin only), recording actorID in
+// the audit log
+func (uc *UseCase) UpdateStatus(ctx context.Context, actorID, id uuid.UUID, input UpdateStatusInput) error {
 	user, err := uc.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if user.IsDeleted() {
+		return domain.ValidationErrors{{Field: "id", Message: "the account has been deleted"}}
+	}
 
+	entry := domain.NewAuditLog(actorID, domain.AuditUserStatusChange, "user", id,
+		map[string]domain.UserStatus{"status": user.Status},
+		map[string]domain.UserStatus{"status": input.Status})
 	user.Status = input.Status
-	return uc.userRepo.Update(ctx, user)
+	return uc.auditRepo.Record(ctx, entry, user)
+}
+
+// Suspend blocks a user from signing in and from the API until reactivated.
+// A suspended instructor's courses are hidden from listings meanwhile.
+func (uc *UseCase) Suspend(ctx context.Context, actorID, id uuid.UUID) error {
+	if actorID == id {
+		return domain.ValidationErrors{{Field: "id", Message: "you cannot suspend your own account"}}
+	}
+	return uc.UpdateStatus(ctx, actorID, id, UpdateStatusInput{Status: domain.StatusSuspended})
+}
+
+// Reactivate lifts a suspension
+func (uc *UseCase) Reactivate(ctx context.Context, actorID, id uuid.UUID) error {
+	return uc.UpdateStatus(ctx, actorID, id, UpdateStatusInput{Status: domain.StatusActive})
 }
 
 // UpdateRoleInput for updating user role
@@ -119,8 +183,9 @@ type UpdateRoleInput struct {
 	Role domain.UserRole `json:"role" validate:"required,oneof=admin manager tutor student"`
 }
 
-// UpdateRole updates a user's role (admin only)
-func (uc *UseCase) UpdateRole(ctx context.Context, id uuid.UUID, input UpdateRoleInput) error {
+// UpdateRole updates a user's role (admin only), recording actorID in the
+// audit log
+func (uc *UseCase) UpdateRole(ctx context.Context, actorID, id uuid.UUID, input UpdateRoleInput) error {
 	user, err := uc.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -129,7 +194,10 @@ func (uc *UseCase) UpdateRole(ctx context.Context, id uuid.UUID, input UpdateRol
 	oldRole := user.Role
 	user.Role = input.Role
 
-	if err := uc.userRepo.Update(ctx, user); err != nil {
+	entry := domain.NewAuditLog(actorID, domain.AuditUserRoleChange, "user", id,
+		map[string]domain.UserRole{"role": oldRole},
+		map[string]domain.UserRole{"role": input.Role})
+	if err := uc.auditRepo.Record(ctx, entry, user); err != nil {
 		return err
 	}
 
@@ -149,6 +217,107 @@ func (uc *UseCase) Delete(ctx context.Context, id uuid.UUID) error {
 	return uc.userRepo.Delete(ctx, id)
 }
 
+// RequestAccountDeletion emails the user a link that confirms deleting their
+// account. Users who sign in through a provider have no password to confirm
+// with, so this is how they prove it's them.
+func (uc *UseCase) RequestAccountDeletion(ctx context.Context, id uuid.UUID) error {
+	user, err := uc.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	token, tokenHash, err := hash.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	// Only the newest link works
+	if err := uc.deletionRepo.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.deletionRepo.Create(ctx, &domain.AccountDeletionToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(accountDeletionTTL),
+	}); err != nil {
+		return err
+	}
+
+	if uc.emailSvc == nil {
+		return nil
+	}
+	confirmURL := uc.emailSvc.AppLink("/delete-account?token=" + url.QueryEscape(token))
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		_ = uc.emailSvc.SendAccountDeletion(ctx, user.Email, user.FirstName, confirmURL)
+	}()
+	return nil
+}
+
+// DeleteAccountInput confirms closing the caller's own account, with either
+// their password or the token from a deletion link
+type DeleteAccountInput struct {
+	Password string `json:"password" validate:"required_without=Token"`
+	Token    string `json:"token"`
+}
+
+// DeleteAccount closes the user's account at their request. Personal data is
+// scrubbed rather than the row removed, so orders stay for accounting and
+// reviews and discussions show as from a deleted user. Every refresh token is
+// revoked, access tokens stop working once the account shows as deleted, and
+// active enrollments are cancelled. Subscriptions end first, so Stripe stops
+// billing.
+func (uc *UseCase) DeleteAccount(ctx context.Context, id uuid.UUID, input DeleteAccountInput) error {
+	user, err := uc.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if input.Token != "" {
+		if err := uc.checkDeletionToken(ctx, id, input.Token); err != nil {
+			return err
+		}
+	} else if !hash.CheckPassword(input.Password, user.PasswordHash) {
+		return domain.ErrInvalidCredentials
+	}
+
+	// Stop billing before the account is scrubbed; if Stripe can't cancel,
+	// the account is kept
+	if uc.subscriptions != nil {
+		if err := uc.subscriptions.EndSubscriptions(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	// Nobody can sign in with a password nobody knows
+	unusable, err := hash.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	passwordHash, err := hash.HashPassword(unusable)
+	if err != nil {
+		return err
+	}
+
+	entry := domain.NewAuditLog(id, domain.AuditUserDelete, "user", id,
+		map[string]domain.UserStatus{"status": user.Status},
+		map[string]domain.UserStatus{"status": domain.StatusDeleted})
+	user.Anonymize(passwordHash)
+	return uc.userRepo.Anonymize(ctx, user, entry)
+}
+
+// checkDeletionToken makes sure token is an unexpired deletion link sent to
+// the user. Anonymizing the account uses the link up.
+func (uc *UseCase) checkDeletionToken(ctx context.Context, userID uuid.UUID, token string) error {
+	deletion, err := uc.deletionRepo.GetByHash(ctx, hash.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if deletion.UserID != userID || time.Now().After(deletion.ExpiresAt) {
+		return domain.ErrDeletionTokenInvalid
+	}
+	return nil
+}
+
 // CreateUserInput for admin user creation
 type CreateUserInput struct {
 	Email     string          `json:"email" validate:"required,email"`
@@ -0,0 +1,111 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/useragent"
+)
+
+// MaxDeviceNameLength caps user-chosen device names
+const MaxDeviceNameLength = 100
+
+// RegisterDevice records a playback device, or refreshes its last-seen time.
+// Names are only derived for new devices so a rename sticks.
+func (uc *videoUseCase) RegisterDevice(ctx context.Context, userID uuid.UUID, device domain.DeviceInfo) error {
+	info := useragent.Parse(device.UserAgent)
+
+	existing, _ := uc.videoRepo.GetDeviceSession(ctx, userID, device.DeviceID)
+	if existing != nil {
+		existing.LastSeenAt = time.Now()
+		existing.IsActive = true
+		existing.Browser = info.Browser
+		existing.OS = info.OS
+		existing.IP = device.IP
+		return uc.videoRepo.UpdateDeviceSession(ctx, existing)
+	}
+
+	session := &domain.DeviceSession{
+		UserID:     userID,
+		DeviceID:   device.DeviceID,
+		DeviceName: info.Name,
+		DeviceType: info.Type,
+		Browser:    info.Browser,
+		OS:         info.OS,
+		IP:         device.IP,
+		IsActive:   true,
+		LastSeenAt: time.Now(),
+	}
+
+	return uc.videoRepo.CreateDeviceSession(ctx, session)
+}
+
+// GetUserDevices returns user's registered devices, flagging the one with
+// currentDeviceID
+func (uc *videoUseCase) GetUserDevices(ctx context.Context, userID uuid.UUID, currentDeviceID string) ([]domain.DeviceSession, error) {
+	devices, err := uc.videoRepo.GetUserDeviceSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range devices {
+		devices[i].IsCurrent = currentDeviceID != "" && devices[i].DeviceID == currentDeviceID
+	}
+	return devices, nil
+}
+
+// RenameDevice sets a user-chosen name on one of the user's devices
+func (uc *videoUseCase) RenameDevice(ctx context.Context, userID uuid.UUID, deviceID, name string) (*domain.DeviceSession, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > MaxDeviceNameLength {
+		return nil, errors.New("device name must be between 1 and 100 characters")
+	}
+
+	session, err := uc.videoRepo.GetDeviceSession(ctx, userID, deviceID)
+	if err != nil || !session.IsActive {
+		return nil, errors.New("device not found")
+	}
+
+	session.DeviceName = name
+	if err := uc.videoRepo.UpdateDeviceSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// RemoveDevice removes a device from user's account and expires its signed
+// URLs so playback on it stops straight away
+func (uc *videoUseCase) RemoveDevice(ctx context.Context, userID uuid.UUID, deviceID string) error {
+	session, err := uc.videoRepo.GetDeviceSession(ctx, userID, deviceID)
+	if err != nil {
+		return errors.New("device not found")
+	}
+
+	if err := uc.videoRepo.DeactivateDeviceSession(ctx, session.ID); err != nil {
+		return err
+	}
+	return uc.videoRepo.ExpireDeviceSignedURLs(ctx, userID, deviceID)
+}
+
+// ValidateDeviceLimit checks if user has reached device limit. Devices that
+// are already registered and active don't need a free slot.
+func (uc *videoUseCase) ValidateDeviceLimit(ctx context.Context, userID uuid.UUID, deviceID string) error {
+	if existing, _ := uc.videoRepo.GetDeviceSession(ctx, userID, deviceID); existing != nil && existing.IsActive {
+		return nil
+	}
+
+	count, err := uc.videoRepo.CountActiveDevices(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if int(count) >= uc.config.MaxDevices {
+		return errors.New("device limit reached")
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package video_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/video"
+)
+
+func TestVideoUseCase_RemoveDevice_ExpiresSessions(t *testing.T) {
+	mockRepo := new(MockVideoRepository)
+	uc := video.NewUseCase(mockRepo, nil, nil, nil, nil, "secret", config.VideoConfig{})
+
+	userID := uuid.New()
+	device := &domain.DeviceSession{ID: uuid.New(), UserID: userID, DeviceID: "laptop", IsActive: true}
+	mockRepo.On("GetDeviceSession", mock.Anything, userID, "laptop").Return(device, nil)
+	mockRepo.On("DeactivateDeviceSession", mock.Anything, device.ID).Return(nil)
+	mockRepo.On("ExpireDeviceSignedURLs", mock.Anything, userID, "laptop").Return(nil)
+
+	assert.NoError(t, uc.RemoveDevice(context.Background(), userID, "laptop"))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVideoUseCase_ValidateDeviceLimit_KnownDevice(t *testing.T) {
+	mockRepo := new(MockVideoRepository)
+	uc := video.NewUseCase(mockRepo, nil, nil, nil, nil, "secret", config.VideoConfig{})
+	userID := uuid.New()
+
+	mockRepo.On("GetDeviceSession", mock.Anything, userID, "phone").Return(&domain.DeviceSession{IsActive: true}, nil)
+	mockRepo.On("GetDeviceSession", mock.Anything, userID, "new-tablet").Return(nil, assert.AnError)
+	mockRepo.On("CountActiveDevices", mock.Anything, userID).Return(int64(3), nil)
+
+	assert.NoError(t, uc.ValidateDeviceLimit(context.Background(), userID, "phone"), "registered devices don't need a free slot")
+	assert.Error(t, uc.ValidateDeviceLimit(context.Background(), userID, "new-tablet"))
+}
@@ -0,0 +1,106 @@
+package video
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// keyPathPrefix is the API route that serves HLS decryption keys
+const keyPathPrefix = "/api/v1/drm/key/"
+
+// segmentNamePattern matches one element of a playlist or segment path
+var segmentNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]*$`)
+
+// hlsPrefix is the storage folder holding a video's playlist and segments
+func hlsPrefix(videoID uuid.UUID) string {
+	return "videos/hls/" + videoID.String()
+}
+
+// ValidSegmentPath reports whether p names a playlist or segment inside a
+// video's HLS folder. Only .m3u8 and .ts files are served, and path elements
+// are restricted to plain names so a request can't climb out of the folder.
+func ValidSegmentPath(p string) bool {
+	if p == "" || len(p) > 200 || path.Clean(p) != p {
+		return false
+	}
+	if ext := path.Ext(p); ext != ".m3u8" && ext != ".ts" {
+		return false
+	}
+	for _, elem := range strings.Split(p, "/") {
+		if !segmentNamePattern.MatchString(elem) || strings.Contains(elem, "..") {
+			return false
+		}
+	}
+	return true
+}
+
+// KeyPath returns the relative key URI baked into a video's playlist.
+// Playlists never store an origin; ResolveKeyURIs adds the configured one
+// when the playlist is served, so moving the API doesn't need a re-encode.
+func KeyPath(videoID uuid.UUID) string {
+	return keyPathPrefix + videoID.String()
+}
+
+// ResolveKeyURIs rewrites the URI of every #EXT-X-KEY tag that points at the
+// key endpoint so it uses baseURL. Playlists encoded before key URIs were
+// relative carry an absolute origin (often localhost), which is replaced too.
+// An empty baseURL leaves the key URIs relative to the playlist's host.
+func ResolveKeyURIs(playlist, baseURL string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	lines := strings.Split(playlist, "\n")
+	for i, line := range lines {
+		start, end, ok := keyURIBounds(line)
+		if !ok {
+			continue
+		}
+
+		uri := line[start:end]
+		idx := strings.Index(uri, keyPathPrefix)
+		if idx == -1 {
+			// Not our key endpoint; leave third-party key servers alone
+			continue
+		}
+		lines[i] = fmt.Sprintf("%s%s%s%s", line[:start], baseURL, uri[idx:], line[end:])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AddKeyToken appends the playback token to the URI of an #EXT-X-KEY line
+// so the player's key request is tied to its session. Other lines are
+// returned unchanged.
+func AddKeyToken(line, token string) string {
+	start, end, ok := keyURIBounds(line)
+	if !ok {
+		return line
+	}
+
+	sep := "?"
+	if strings.Contains(line[start:end], "?") {
+		sep = "&"
+	}
+	return line[:end] + sep + "token=" + url.QueryEscape(token) + line[end:]
+}
+
+// keyURIBounds locates the quoted URI attribute of an #EXT-X-KEY line
+func keyURIBounds(line string) (start, end int, ok bool) {
+	if !strings.HasPrefix(line, "#EXT-X-KEY:") {
+		return 0, 0, false
+	}
+
+	start = strings.Index(line, `URI="`)
+	if start == -1 {
+		return 0, 0, false
+	}
+	start += len(`URI="`)
+	end = strings.IndexByte(line[start:], '"')
+	if end == -1 {
+		return 0, 0, false
+	}
+	return start, start + end, true
+}
@@ -0,0 +1,52 @@
+package video_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/video"
+)
+
+func TestResolveKeyURIs(t *testing.T) {
+	videoID := uuid.New()
+	playlist := strings.Join([]string{
+		"#EXTM3U",
+		`#EXT-X-KEY:METHOD=AES-128,URI="` + video.KeyPath(videoID) + `",IV=0x00112233`,
+		"segment_000.ts",
+		`#EXT-X-KEY:METHOD=AES-128,URI="http://localhost:8080` + video.KeyPath(videoID) + `"`,
+		"segment_001.ts",
+		`#EXT-X-KEY:METHOD=AES-128,URI="https://keys.example.com/k/1"`,
+	}, "\n")
+
+	resolved := video.ResolveKeyURIs(playlist, "https://api.tutorflow.com/")
+	lines := strings.Split(resolved, "\n")
+
+	want := `URI="https://api.tutorflow.com` + video.KeyPath(videoID) + `"`
+	assert.Contains(t, lines[1], want+",IV=0x00112233")
+	assert.Contains(t, lines[3], want, "legacy localhost URIs are rewritten")
+	assert.Equal(t, "segment_000.ts", lines[2])
+	assert.Contains(t, lines[5], "https://keys.example.com/k/1", "other key servers are untouched")
+
+	relative := video.ResolveKeyURIs(playlist, "")
+	assert.Contains(t, strings.Split(relative, "\n")[3], `URI="`+video.KeyPath(videoID)+`"`)
+}
+
+func TestValidSegmentPath(t *testing.T) {
+	for _, p := range []string{"index.m3u8", "segment_000.ts", "720p/index.m3u8", "720p/segment_001.ts"} {
+		assert.True(t, video.ValidSegmentPath(p), p)
+	}
+	for _, p := range []string{
+		"", "../index.m3u8", "../../etc/passwd", "720p/../../x.ts", "/index.m3u8",
+		"./index.m3u8", "a//b.ts", "..%2findex.m3u8", `..\index.m3u8`, "video.key", "input.mp4", "..ts",
+	} {
+		assert.False(t, video.ValidSegmentPath(p), p)
+	}
+}
+
+func TestAddKeyToken(t *testing.T) {
+	line := `#EXT-X-KEY:METHOD=AES-128,URI="/api/v1/drm/key/abc",IV=0x01`
+	assert.Equal(t, `#EXT-X-KEY:METHOD=AES-128,URI="/api/v1/drm/key/abc?token=t1",IV=0x01`, video.AddKeyToken(line, "t1"))
+	assert.Equal(t, "segment_000.ts", video.AddKeyToken("segment_000.ts", "t1"))
+}
@@ -0,0 +1,152 @@
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ProbeResult holds the media details read from an input file
+type ProbeResult struct {
+	Duration int // seconds, rounded up
+	Width    int
+	Height   int
+	Size     int64
+}
+
+// Resolution formats the frame size as WIDTHxHEIGHT
+func (p *ProbeResult) Resolution() string {
+	return fmt.Sprintf("%dx%d", p.Width, p.Height)
+}
+
+// ffprobe -print_format json output. Numbers such as durations are emitted as
+// strings, and tags differ between containers, so everything optional is loose.
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType    string            `json:"codec_type"`
+		Width        int               `json:"width"`
+		Height       int               `json:"height"`
+		Duration     string            `json:"duration"`
+		Tags         map[string]string `json:"tags"`
+		SideDataList []struct {
+			Rotation json.Number `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+		Size     string `json:"size"`
+	} `json:"format"`
+}
+
+// probeVideo runs ffprobe against the input file
+func probeVideo(ctx context.Context, inputPath string) (*ProbeResult, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		inputPath,
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("ffprobe could not read video: %s", msg)
+		}
+		return nil, fmt.Errorf("ffprobe could not read video: %w", err)
+	}
+
+	return ParseProbeOutput(stdout.Bytes())
+}
+
+// ParseProbeOutput extracts duration, frame size and file size from ffprobe
+// JSON output. The first video stream is used; its width and height are
+// swapped when the stream is rotated a quarter turn so portrait phone
+// recordings report a portrait resolution.
+func ParseProbeOutput(data []byte) (*ProbeResult, error) {
+	var out ffprobeOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+
+	for _, stream := range out.Streams {
+		if stream.CodecType != "video" {
+			continue
+		}
+		if stream.Width <= 0 || stream.Height <= 0 {
+			return nil, errors.New("video stream has no frame size")
+		}
+
+		// Containers don't always carry a duration; fall back to the stream's
+		seconds := parseSeconds(out.Format.Duration)
+		if seconds <= 0 {
+			seconds = parseSeconds(stream.Duration)
+		}
+		if seconds <= 0 {
+			return nil, errors.New("could not determine video duration")
+		}
+
+		result := &ProbeResult{
+			Duration: int(math.Ceil(seconds)),
+			Width:    stream.Width,
+			Height:   stream.Height,
+		}
+		result.Size, _ = strconv.ParseInt(out.Format.Size, 10, 64)
+
+		rotation := stream.Tags["rotate"]
+		for _, sd := range stream.SideDataList {
+			if sd.Rotation != "" {
+				rotation = sd.Rotation.String()
+			}
+		}
+		if deg, err := strconv.Atoi(rotation); err == nil && (deg%180+180)%180 == 90 {
+			result.Width, result.Height = result.Height, result.Width
+		}
+
+		return result, nil
+	}
+
+	return nil, errors.New("file contains no video stream")
+}
+
+func parseSeconds(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+// trackProgress reads ffmpeg -progress output and reports the share of the
+// video transcoded so far, as a percentage, each time it moves by step
+func trackProgress(r io.Reader, duration int, step int, report func(percent int)) {
+	scanner := bufio.NewScanner(r)
+	last := 0
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		// out_time_ms is in microseconds despite its name
+		if !ok || (key != "out_time_us" && key != "out_time_ms") || duration <= 0 {
+			continue
+		}
+		us, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || us < 0 {
+			continue
+		}
+		// Finishing is reported once the files are uploaded, not here
+		percent := min(int(us/1e4)/duration, 99)
+		if percent >= last+step {
+			last = percent
+			report(percent)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package video_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/video"
+)
+
+func TestParseProbeOutput(t *testing.T) {
+	output := `{
+		"streams": [
+			{"codec_type": "audio", "duration": "125.100000"},
+			{"codec_type": "video", "width": 1280, "height": 720, "duration": "125.041667"}
+		],
+		"format": {"duration": "125.103000", "size": "10485760"}
+	}`
+
+	probe, err := video.ParseProbeOutput([]byte(output))
+	assert.NoError(t, err)
+	assert.Equal(t, 126, probe.Duration)
+	assert.Equal(t, "1280x720", probe.Resolution())
+	assert.Equal(t, int64(10485760), probe.Size)
+}
+
+func TestParseProbeOutput_Rotated(t *testing.T) {
+	output := `{
+		"streams": [
+			{"codec_type": "video", "width": 1920, "height": 1080, "duration": "30.0",
+			 "side_data_list": [{"side_data_type": "Display Matrix", "rotation": -90}]}
+		],
+		"format": {}
+	}`
+
+	probe, err := video.ParseProbeOutput([]byte(output))
+	assert.NoError(t, err)
+	assert.Equal(t, 30, probe.Duration, "stream duration is used when the container has none")
+	assert.Equal(t, "1080x1920", probe.Resolution())
+}
+
+func TestParseProbeOutput_Unusable(t *testing.T) {
+	cases := map[string]string{
+		"not json":    `Invalid data found when processing input`,
+		"audio only":  `{"streams": [{"codec_type": "audio"}], "format": {"duration": "60.0"}}`,
+		"no duration": `{"streams": [{"codec_type": "video", "width": 640, "height": 360}], "format": {"duration": "N/A"}}`,
+		"no size":     `{"streams": [{"codec_type": "video"}], "format": {"duration": "60.0"}}`,
+	}
+
+	for name, output := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := video.ParseProbeOutput([]byte(output))
+			assert.Error(t, err)
+		})
+	}
+}
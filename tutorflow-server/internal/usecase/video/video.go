@@ -3,7 +3,9 @@ package video
 import (
 	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base32"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,12 +13,14 @@ import (
 	"mime/multipart"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
 	"github.com/tutorflow/tutorflow-server/internal/repository"
 )
 
@@ -24,6 +28,7 @@ type videoUseCase struct {
 	videoRepo      repository.VideoRepository
 	lessonRepo     repository.LessonRepository
 	enrollmentRepo repository.EnrollmentRepository
+	progressRepo   repository.LessonProgressRepository
 	storageService domain.StorageService
 	config         domain.HLSConfig
 	signingSecret  string
@@ -34,24 +39,50 @@ func NewUseCase(
 	videoRepo repository.VideoRepository,
 	lessonRepo repository.LessonRepository,
 	enrollmentRepo repository.EnrollmentRepository,
+	progressRepo repository.LessonProgressRepository,
 	storageService domain.StorageService,
 	signingSecret string,
+	cfg config.VideoConfig,
 ) domain.VideoUseCase {
+	hlsConfig := domain.DefaultHLSConfig()
+	hlsConfig.KeyBaseURL = cfg.KeyBaseURL
+	hlsConfig.Watermark = cfg.Watermark
+	if cfg.WatermarkOpacity > 0 {
+		hlsConfig.WatermarkOpacity = cfg.WatermarkOpacity
+	}
+
 	return &videoUseCase{
 		videoRepo:      videoRepo,
 		lessonRepo:     lessonRepo,
 		enrollmentRepo: enrollmentRepo,
+		progressRepo:   progressRepo,
 		storageService: storageService,
-		config:         domain.DefaultHLSConfig(),
+		config:         hlsConfig,
 		signingSecret:  signingSecret,
 	}
 }
 
 // UploadVideo uploads a video for a lesson
-func (uc *videoUseCase) UploadVideo(ctx context.Context, lessonID uuid.UUID, fileURL string) (*domain.HLSVideoAsset, error) {
+func (uc *videoUseCase) UploadVideo(ctx context.Context, lessonID, userID uuid.UUID, fileURL string) (*domain.HLSVideoAsset, error) {
 	if fileURL == "" {
 		return nil, errors.New("file URL is empty")
 	}
+	// Only the user's own uploads can be processed, including ones they sent
+	// straight to storage with a presigned URL. Anything else is reported as
+	// missing rather than confirming someone else's file exists.
+	stored, ok := uc.storageService.PathFromURL(fileURL)
+	if !ok || !strings.HasPrefix(path.Clean(stored), domain.VideoUploadFolder(userID)+"/") {
+		return nil, fmt.Errorf("%w: %s", domain.ErrFileNotFound, fileURL)
+	}
+	return uc.registerVideo(ctx, lessonID, fileURL)
+}
+
+// registerVideo creates the asset for a video in storage and queues it for
+// transcoding
+func (uc *videoUseCase) registerVideo(ctx context.Context, lessonID uuid.UUID, fileURL string) (*domain.HLSVideoAsset, error) {
+	if !uc.storageService.FileExists(fileURL) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrFileNotFound, fileURL)
+	}
 	// Check if video already exists for lesson
 	existing, _ := uc.videoRepo.GetAssetByLessonID(ctx, lessonID)
 	if existing != nil {
@@ -73,18 +104,10 @@ func (uc *videoUseCase) UploadVideo(ctx context.Context, lessonID uuid.UUID, fil
 	}
 	fmt.Printf("Created video asset in DB with ID: %s, OriginalURL: %s\n", asset.ID, asset.OriginalURL)
 
-	// In production, trigger async video processing job here
-	// For now, we'll mark strictly as pending and expect ProcessVideo to be called.
-	// But to match previous behavior for non-async parts, we can leave it or trigger it.
-	// The user asked to implement "real video processing", so usually this would be triggered via a queue.
-	// We will manually trigger ProcessVideo for testing purposes if needed, or assume the caller does it.
-
-	// Start processing in a goroutine to simulate async job
-	go func() {
-		// Create a new context for the background job
-		bgCtx := context.Background()
-		_ = uc.ProcessVideo(bgCtx, asset.ID)
-	}()
+	// Queue transcoding; the worker picks it up and retries on failure
+	if err := uc.videoRepo.EnqueueJob(ctx, asset.ID); err != nil {
+		return nil, err
+	}
 
 	return asset, nil
 }
@@ -101,8 +124,7 @@ func (uc *videoUseCase) UploadVideoFile(ctx context.Context, lessonID uuid.UUID,
 
 	fmt.Printf("UploadVideoFile: storageService.UploadVideo returned URL: '%s'\n", url)
 
-	// Delegate to standard UploadVideo logic
-	return uc.UploadVideo(ctx, lessonID, url)
+	return uc.registerVideo(ctx, lessonID, url)
 }
 
 // ProcessVideo processes a video into HLS format
@@ -156,8 +178,8 @@ func (uc *videoUseCase) ProcessVideo(ctx context.Context, videoID uuid.UUID) err
 	// Create key info file
 	keyInfoFile := filepath.Join(tempDir, "video.keyinfo")
 	// The first line is the URI that will be written to the playlist.
-	// We want this to be our API endpoint.
-	keyURI := fmt.Sprintf("/api/v1/drm/key/%s", videoID) // Relative to domain
+	// It stays relative; the public origin is added when the playlist is served.
+	keyURI := KeyPath(videoID)
 
 	keyInfoContent := fmt.Sprintf("%s\n%s\n%s", keyURI, keyFile, iv)
 	if err := os.WriteFile(keyInfoFile, []byte(keyInfoContent), 0600); err != nil {
@@ -173,57 +195,24 @@ func (uc *videoUseCase) ProcessVideo(ctx context.Context, videoID uuid.UUID) err
 		return uc.handleProcessingError(ctx, asset, fmt.Errorf("OriginalURL is empty"))
 	}
 
-	isRemote := strings.HasPrefix(asset.OriginalURL, "http://") || strings.HasPrefix(asset.OriginalURL, "https://")
-
-	// Better isS3 detection: if it's remote and we are using S3 driver, or if it contains bucket name
-	// Also check for localhost:9000 which is common for local MinIO
-	isS3 := isRemote && (strings.Contains(asset.OriginalURL, "minio") ||
-		strings.Contains(asset.OriginalURL, "localhost:9000") ||
-		strings.Contains(asset.OriginalURL, uc.storageService.GetBucket()))
-
-	if isRemote {
-		fmt.Printf("Video is remote (isS3: %v), downloading...\n", isS3)
-		var stream io.ReadCloser
-		var err error
-
-		if isS3 {
-			// Extract S3 path from URL
-			// URL format: http://minio:9000/bucket/videos/originals/filename.mp4
-			// We need to get everything after the bucket name
-			bucketName := uc.storageService.GetBucket()
-			searchStr := "/" + bucketName + "/"
-			idx := strings.Index(asset.OriginalURL, searchStr)
-			if idx == -1 {
-				// Fallback to previous logic if bucket name not found in URL
-				parts := strings.SplitN(asset.OriginalURL, "://", 2)
-				if len(parts) == 2 {
-					hostAndPath := parts[1]
-					pathParts := strings.SplitN(hostAndPath, "/", 2)
-					if len(pathParts) == 2 {
-						s3Path := pathParts[1]
-						if strings.HasPrefix(s3Path, bucketName+"/") {
-							s3Path = strings.TrimPrefix(s3Path, bucketName+"/")
-						}
-						fmt.Printf("Derived S3 path (fallback): %s\n", s3Path)
-						stream, _, err = uc.storageService.GetFileStream(ctx, s3Path)
-					}
-				}
-			} else {
-				s3Path := asset.OriginalURL[idx+len(searchStr):]
-				fmt.Printf("Derived S3 path: %s\n", s3Path)
-				stream, _, err = uc.storageService.GetFileStream(ctx, s3Path)
-			}
-		} else {
-			// Generic HTTP download (TODO: use http.Get if needed, but for now assuming it's mostly S3 in this app)
-			return uc.handleProcessingError(ctx, asset, fmt.Errorf("generic HTTP download not implemented yet for URL: %s", asset.OriginalURL))
-		}
+	storagePath, ok := uc.storageService.PathFromURL(asset.OriginalURL)
+	if !ok {
+		return uc.handleProcessingError(ctx, asset, fmt.Errorf("video is not in storage: %s", asset.OriginalURL))
+	}
 
+	if localPath := uc.storageService.LocalPath(storagePath); localPath != "" {
+		// Local storage - ffmpeg can read the file where it is
+		if _, err := os.Stat(localPath); os.IsNotExist(err) {
+			return uc.handleProcessingError(ctx, asset, fmt.Errorf("video file not found at: %s", localPath))
+		}
+		inputPath = localPath
+		fmt.Printf("Using local video path: %s\n", inputPath)
+	} else {
+		fmt.Printf("Video is remote, downloading %s...\n", storagePath)
+		stream, _, err := uc.storageService.GetFileStream(ctx, storagePath)
 		if err != nil {
 			return uc.handleProcessingError(ctx, asset, fmt.Errorf("failed to get file stream: %w", err))
 		}
-		if stream == nil {
-			return uc.handleProcessingError(ctx, asset, errors.New("file stream is nil"))
-		}
 		defer stream.Close()
 
 		// Save to temp file
@@ -241,26 +230,18 @@ func (uc *videoUseCase) ProcessVideo(ctx context.Context, videoID uuid.UUID) err
 
 		inputPath = tempInputFile
 		fmt.Printf("Downloaded video to: %s (size: %d bytes)\n", inputPath, size)
-	} else {
-		// Local file - need to resolve the actual file path
-		// The URL returned by storage service is relative (e.g., /videos/originals/filename.mp4)
-		// But the actual file is stored at basePath + relative path
-		localPath := asset.OriginalURL
-
-		// Check if it's a relative path and resolve to actual file location
-		if !filepath.IsAbs(localPath) {
-			// Strip leading slash if present
-			localPath = strings.TrimPrefix(localPath, "/")
-			// Prepend the storage base path
-			localPath = filepath.Join(uc.storageService.GetBasePath(), localPath)
-		}
+	}
 
-		// Verify file exists
-		if _, err := os.Stat(localPath); os.IsNotExist(err) {
-			return uc.handleProcessingError(ctx, asset, fmt.Errorf("video file not found at: %s", localPath))
-		}
-		inputPath = localPath
-		fmt.Printf("Using local video path: %s\n", inputPath)
+	// Probe before transcoding so unreadable uploads fail fast with ffprobe's
+	// reason, and so the duration is known for progress reporting
+	probe, err := probeVideo(ctx, inputPath)
+	if err != nil {
+		return uc.handleProcessingError(ctx, asset, err)
+	}
+	asset.Duration = probe.Duration
+	asset.Resolution = probe.Resolution()
+	if probe.Size > 0 {
+		asset.FileSize = probe.Size
 	}
 
 	// 5. Run FFmpeg
@@ -281,16 +262,27 @@ func (uc *videoUseCase) ProcessVideo(ctx context.Context, videoID uuid.UUID) err
 		"-hls_playlist_type", "vod",
 		"-hls_key_info_file", keyInfoFile,
 		"-hls_segment_filename", segmentPath,
+		"-progress", "pipe:1", "-nostats",
 		playlistPath,
 	)
 
-	// Capture output for debugging
-	cmd.Stdout = os.Stdout
+	// Stdout carries the progress feed; keep stderr for debugging
 	cmd.Stderr = os.Stderr
+	progress, err := cmd.StdoutPipe()
+	if err != nil {
+		return uc.handleProcessingError(ctx, asset, err)
+	}
 
 	fmt.Printf("Running FFmpeg command: %v\n", cmd.Args)
 
-	if err := cmd.Run(); err != nil {
+	if err := cmd.Start(); err != nil {
+		return uc.handleProcessingError(ctx, asset, fmt.Errorf("ffmpeg failed: %w", err))
+	}
+	trackProgress(progress, asset.Duration, 5, func(percent int) {
+		asset.Progress = percent
+		_ = uc.videoRepo.UpdateAsset(ctx, asset)
+	})
+	if err := cmd.Wait(); err != nil {
 		return uc.handleProcessingError(ctx, asset, fmt.Errorf("ffmpeg failed: %w", err))
 	}
 
@@ -304,82 +296,37 @@ func (uc *videoUseCase) ProcessVideo(ctx context.Context, videoID uuid.UUID) err
 		IV:             iv,
 		KeyURL:         keyURI,
 	}
-	if err := uc.videoRepo.CreateEncryption(ctx, encRecord); err != nil {
-		return uc.handleProcessingError(ctx, asset, err)
+	// A retried job already stored a key; replace it with the one just used
+	if existing, _ := uc.videoRepo.GetEncryptionByVideoID(ctx, videoID); existing != nil {
+		encRecord.ID = existing.ID
+		encRecord.CreatedAt = existing.CreatedAt
+		err = uc.videoRepo.UpdateEncryption(ctx, encRecord)
+	} else {
+		err = uc.videoRepo.CreateEncryption(ctx, encRecord)
 	}
-
-	// 6. Move files to permanent storage
-	// For this PoC, we'll just keep them in a specific 'processed' folder
-	finalDir := filepath.Join("uploads", "hls", videoID.String())
-	if err := os.MkdirAll(finalDir, 0755); err != nil {
+	if err != nil {
 		return uc.handleProcessingError(ctx, asset, err)
 	}
 
-	// Copy/Move files
-	files, _ := os.ReadDir(outputDir)
-	for _, f := range files {
-		src := filepath.Join(outputDir, f.Name())
-		dst := filepath.Join(finalDir, f.Name())
-		// Move
-		if err := os.Rename(src, dst); err != nil {
-			// Fallback to copy if rename fails (cross-device)
-			if err := copyFile(src, dst); err != nil {
-				return uc.handleProcessingError(ctx, asset, err)
-			}
-		}
-	}
-
-	// 7. Upload to S3 if configured
-	// We check if driver is s3 using config (or just call UploadHLSFiles which handles it)
-	// But we need a prefix for S3. Let's use "hls/{videoID}"
-	s3Prefix := fmt.Sprintf("videos/hls/%s", videoID)
-	if err := uc.storageService.UploadHLSFiles(ctx, outputDir, s3Prefix); err != nil {
+	// 6. Store the playlist and segments where GetVideoSegment reads them
+	if err := uc.storageService.UploadHLSFiles(ctx, outputDir, hlsPrefix(videoID)); err != nil {
 		return uc.handleProcessingError(ctx, asset, fmt.Errorf("failed to upload HLS files: %w", err))
 	}
 
-	// 8. Update Asset
+	// 7. Update Asset
 	asset.Status = domain.VideoStatusCompleted
-	asset.Duration = 600           // TODO: Parse from ffmpeg output
-	asset.Resolution = "1920x1080" // TODO: Parse
+	asset.Progress = 100
 	asset.UpdatedAt = time.Now()
 
-	// Update playlist URL to point to S3/CDN
-	// If S3, the URL is s3Endpoint/bucket/hls/{videoID}/index.m3u8 or cdnBase/hls/{videoID}/index.m3u8
-	// We can trust the storage service/config to know the base.
-	// But `UploadHLSFiles` doesn't return the URL.
-	// We can construct it.
-	// However, the `VideoQuality` struct has `PlaylistURL`. We should populate that?
-	// The current code doesn't create `VideoQuality` records yet.
-	// Let's at least update the asset to be ready.
-	// The `GetPlaybackURL` function generates a URL pointing to `/api/v1/videos/stream/...`
-	// which presumably proxies or redirects?
-	// Actually `GetPlaybackURL` returns `/api/v1/videos/stream/%s/index.m3u8?token=%s`
-	// And the handler needs to serve the content.
-	// If we use S3, we might want `GetPlaybackURL` to return a signed S3 URL or
-	// we keep using the proxy to enforce our token auth?
-	//
-	// Strategy:
-	// The frontend requests /api/v1/videos/stream/:id/index.m3u8?token=...
-	// The BACKEND handler receives this.
-	// It validates the token.
-	// Then it needs to serve the m3u8.
-	// If stored on S3, the backend can:
-	// A) Proxy the content (read from S3, write to response) - simpler, uses server BW.
-	// B) Redirect to a presigned S3 URL - better for performance, but need to handle encryption keys carefully.
-	//
-	// Given we have AES encryption, the m3u8 itself isn't sensitive, but the KEY is.
-	// The KEY inside m3u8 points to /api/v1/drm/key/...
-	// So we can redirect to S3 for segments and m3u8.
-	//
-	// For now, let's assume the existing `GetPlaybackURL` flow works for the player to START.
-	// But `GetPlaybackURL` returns a URL that points to `playbackURL`.
-	// We need to implement the Handler for `/api/v1/videos/stream/...` to serve the files.
-	// The current code lacks that handler! Run `grep` showed "videos.GET("/lessons/:lessonId/playback", h.GetPlaybackURL)"
-	// But where is the route for `fmt.Sprintf("/api/v1/videos/stream/%s/index.m3u8?token=%s", asset.ID, token)`?
-	// It seems missing!
-	//
-	// We need to ADD a handler to serve the HLS content (proxy from S3).
-	// But first, let's finish the upload part.
+	// Keep the lesson's displayed length in line with the actual video
+	if lesson, err := uc.lessonRepo.GetByID(ctx, asset.LessonID); err == nil {
+		duration := asset.Duration
+		lesson.VideoDuration = &duration
+		lesson.Module = nil
+		if err := uc.lessonRepo.Update(ctx, lesson); err != nil {
+			fmt.Printf("ProcessVideo: failed to update lesson duration: %v\n", err)
+		}
+	}
 
 	return uc.videoRepo.UpdateAsset(ctx, asset)
 }
@@ -391,91 +338,113 @@ func (uc *videoUseCase) handleProcessingError(ctx context.Context, asset *domain
 	return err
 }
 
-func copyFile(src, dst string) error {
-	in, err := os.Open(src)
+// GetProcessingStatus returns video processing status
+func (uc *videoUseCase) GetProcessingStatus(ctx context.Context, lessonID uuid.UUID) (*domain.HLSVideoAsset, error) {
+	asset, err := uc.videoRepo.GetAssetByLessonID(ctx, lessonID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer in.Close()
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
+	if job, err := uc.videoRepo.GetJobByVideoID(ctx, asset.ID); err == nil {
+		asset.Job = job
 	}
-	defer out.Close()
-	_, err = io.Copy(out, in)
-	return err
-}
-
-// GetProcessingStatus returns video processing status
-func (uc *videoUseCase) GetProcessingStatus(ctx context.Context, lessonID uuid.UUID) (*domain.HLSVideoAsset, error) {
-	return uc.videoRepo.GetAssetByLessonID(ctx, lessonID)
+	return asset, nil
 }
 
-// GetPlaybackURL returns a signed playback URL for a video
-func (uc *videoUseCase) GetPlaybackURL(ctx context.Context, lessonID, userID uuid.UUID, deviceID string) (string, error) {
+// GetPlaybackURL returns a signed playback grant for a video. Every session
+// gets a watermark code stored with its signed URL so a leaked recording can
+// be traced back to the viewer.
+func (uc *videoUseCase) GetPlaybackURL(ctx context.Context, lessonID, userID uuid.UUID, device domain.DeviceInfo) (*domain.PlaybackGrant, error) {
 	// Verify user has access to the lesson
 	lesson, err := uc.lessonRepo.GetByID(ctx, lessonID)
 	if err != nil {
-		return "", errors.New("lesson not found")
+		return nil, errors.New("lesson not found")
 	}
 
 	// Check enrollment (unless it's a preview)
 	if !lesson.IsPreview {
 		if lesson.Module == nil {
 			fmt.Printf("[VIDEO DEBUG] Lesson %s has no Module loaded\n", lessonID)
-			return "", errors.New("lesson module information missing")
+			return nil, errors.New("lesson module information missing")
 		}
 
 		enrollment, err := uc.enrollmentRepo.GetByUserAndCourse(ctx, userID, lesson.Module.CourseID)
 		if err != nil {
-			return "", errors.New("user is not enrolled in this course")
+			return nil, errors.New("user is not enrolled in this course")
 		}
 
 		if !enrollment.IsActive() && !enrollment.IsCompleted() {
-			return "", errors.New("user is not enrolled or active in this course")
+			return nil, errors.New("user is not enrolled or active in this course")
+		}
+
+		prerequisiteDone := true
+		if lesson.PrerequisiteLessonID != nil {
+			progress, err := uc.progressRepo.GetByEnrollmentAndLesson(ctx, enrollment.ID, *lesson.PrerequisiteLessonID)
+			prerequisiteDone = err == nil && progress != nil && progress.IsCompleted
+		}
+		if lock := lesson.LockFor(enrollment.EnrolledAt, prerequisiteDone, time.Now()); lock != nil {
+			return nil, lock
 		}
 	}
 
 	// Get video asset
 	asset, err := uc.videoRepo.GetAssetByLessonID(ctx, lessonID)
 	if err != nil {
-		return "", errors.New("video not found")
+		return nil, errors.New("video not found")
 	}
 
 	if asset.Status != domain.VideoStatusCompleted {
-		return "", errors.New("video is not ready for playback")
+		return nil, errors.New("video is not ready for playback")
 	}
 
 	// Validate device limit
-	if err := uc.ValidateDeviceLimit(ctx, userID); err != nil {
-		return "", err
+	if err := uc.ValidateDeviceLimit(ctx, userID, device.DeviceID); err != nil {
+		return nil, err
 	}
 
 	// Register device session
-	uc.RegisterDevice(ctx, userID, deviceID, "Unknown", "unknown")
+	_ = uc.RegisterDevice(ctx, userID, device)
 
 	// Generate signed URL
-	token := uc.generateToken(asset.ID, userID, deviceID)
+	token := uc.generateToken(asset.ID, userID, device.DeviceID)
 	expiresAt := time.Now().Add(time.Duration(uc.config.SignedURLExpiry) * time.Second)
 
+	watermarkCode, err := generateWatermarkCode()
+	if err != nil {
+		return nil, err
+	}
+
+	// This URL points to our HLS playlist serve endpoint
+	playbackURL := fmt.Sprintf("/api/v1/videos/stream/%s/index.m3u8?token=%s", asset.ID, token)
+
 	signedURL := &domain.SignedURL{
-		VideoID:   asset.ID,
-		UserID:    userID,
-		SessionID: uuid.New().String(),
-		DeviceID:  deviceID,
-		Token:     token,
-		ExpiresAt: expiresAt,
+		VideoID:       asset.ID,
+		UserID:        userID,
+		SessionID:     uuid.New().String(),
+		DeviceID:      device.DeviceID,
+		URL:           playbackURL,
+		Token:         token,
+		WatermarkCode: watermarkCode,
+		ExpiresAt:     expiresAt,
 	}
 
 	if err := uc.videoRepo.CreateSignedURL(ctx, signedURL); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Return the playback URL with token
-	// This URL should point to our HLS playlist serve endpoint
-	// e.g. /api/v1/videos/stream/:id/index.m3u8?token=...
-	playbackURL := fmt.Sprintf("/api/v1/videos/stream/%s/index.m3u8?token=%s", asset.ID, token)
-	return playbackURL, nil
+	grant := &domain.PlaybackGrant{URL: playbackURL, ExpiresAt: expiresAt}
+	if uc.config.Watermark {
+		grant.Watermark = &domain.Watermark{Code: watermarkCode, Opacity: uc.config.WatermarkOpacity}
+	}
+	return grant, nil
+}
+
+// TraceWatermark returns the playback session a watermark code was issued to
+func (uc *videoUseCase) TraceWatermark(ctx context.Context, code string) (*domain.SignedURL, error) {
+	signedURL, err := uc.videoRepo.GetSignedURLByWatermark(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, errors.New("watermark not found")
+	}
+	return signedURL, nil
 }
 
 // GetEncryptionKeyByVideoID returns the encryption key for a video by video ID
@@ -493,10 +462,12 @@ func (uc *videoUseCase) GetEncryptionKeyByVideoID(ctx context.Context, videoID u
 	return key, nil
 }
 
-// GetEncryptionKey returns the encryption key for a video
-func (uc *videoUseCase) GetEncryptionKey(ctx context.Context, token string) ([]byte, error) {
+// GetEncryptionKey returns the encryption key for a video. The token is
+// scoped to the playback session and may fetch the key any number of times
+// until it expires; each fetch is recorded for auditing.
+func (uc *videoUseCase) GetEncryptionKey(ctx context.Context, videoID uuid.UUID, token string) ([]byte, error) {
 	signedURL, err := uc.videoRepo.GetSignedURLByToken(ctx, token)
-	if err != nil {
+	if err != nil || signedURL.VideoID != videoID {
 		return nil, errors.New("invalid token")
 	}
 
@@ -516,18 +487,16 @@ func (uc *videoUseCase) GetEncryptionKey(ctx context.Context, token string) ([]b
 		return nil, errors.New("invalid encryption key")
 	}
 
-	// Mark URL as used (for single-use tokens, but for HLS key we might need to allow multiple fetches?)
-	// Usually key is fetched once per session or periodically.
-	// Let's keep it for now.
-	uc.videoRepo.MarkSignedURLUsed(ctx, token)
+	// Auditing must not interrupt playback
+	_ = uc.videoRepo.RecordKeyFetch(ctx, signedURL.ID)
 
 	return key, nil
 }
 
-// ValidatePlayback validates if a playback session is valid
-func (uc *videoUseCase) ValidatePlayback(ctx context.Context, token string) error {
+// ValidatePlayback validates that a playback token is live and was issued for the video
+func (uc *videoUseCase) ValidatePlayback(ctx context.Context, videoID uuid.UUID, token string) error {
 	signedURL, err := uc.videoRepo.GetSignedURLByToken(ctx, token)
-	if err != nil {
+	if err != nil || signedURL.VideoID != videoID {
 		return errors.New("invalid token")
 	}
 
@@ -562,7 +531,7 @@ func (uc *videoUseCase) EnableEncryption(ctx context.Context, videoID uuid.UUID,
 		KeyID:          uuid.New().String(),
 		EncryptionKey:  key,
 		IV:             iv,
-		KeyURL:         fmt.Sprintf("/api/v1/drm/key/%s", videoID),
+		KeyURL:         KeyPath(videoID),
 	}
 
 	// Check if encryption already exists
@@ -597,63 +566,45 @@ func (uc *videoUseCase) RotateEncryptionKey(ctx context.Context, videoID uuid.UU
 	return uc.videoRepo.UpdateEncryption(ctx, encryption)
 }
 
-// RegisterDevice registers a device for a user
-func (uc *videoUseCase) RegisterDevice(ctx context.Context, userID uuid.UUID, deviceID, deviceName, deviceType string) error {
-	// Check if device already exists
-	existing, _ := uc.videoRepo.GetDeviceSession(ctx, userID, deviceID)
-	if existing != nil {
-		// Update last seen
-		existing.LastSeenAt = time.Now()
-		existing.IsActive = true
-		return uc.videoRepo.UpdateDeviceSession(ctx, existing)
-	}
-
-	session := &domain.DeviceSession{
-		UserID:     userID,
-		DeviceID:   deviceID,
-		DeviceName: deviceName,
-		DeviceType: deviceType,
-		IsActive:   true,
-		LastSeenAt: time.Now(),
+// GetVideoSegment returns a stream for a video segment or playlist
+func (uc *videoUseCase) GetVideoSegment(ctx context.Context, videoID uuid.UUID, segment string) (io.ReadCloser, string, error) {
+	if !ValidSegmentPath(segment) {
+		return nil, "", errors.New("invalid segment path")
 	}
 
-	return uc.videoRepo.CreateDeviceSession(ctx, session)
-}
-
-// GetUserDevices returns user's registered devices
-func (uc *videoUseCase) GetUserDevices(ctx context.Context, userID uuid.UUID) ([]domain.DeviceSession, error) {
-	return uc.videoRepo.GetUserDeviceSessions(ctx, userID)
-}
-
-// RemoveDevice removes a device from user's account
-func (uc *videoUseCase) RemoveDevice(ctx context.Context, userID uuid.UUID, deviceID string) error {
-	session, err := uc.videoRepo.GetDeviceSession(ctx, userID, deviceID)
+	stream, contentType, err := uc.storageService.GetFileStream(ctx, hlsPrefix(videoID)+"/"+segment)
 	if err != nil {
-		return errors.New("device not found")
+		// Videos processed before files were kept under videos/hls
+		stream, contentType, err = uc.storageService.GetFileStream(ctx, fmt.Sprintf("hls/%s/%s", videoID, segment))
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
-	return uc.videoRepo.DeactivateDeviceSession(ctx, session.ID)
-}
-
-// ValidateDeviceLimit checks if user has reached device limit
-func (uc *videoUseCase) ValidateDeviceLimit(ctx context.Context, userID uuid.UUID) error {
-	count, err := uc.videoRepo.CountActiveDevices(ctx, userID)
-	if err != nil {
-		return err
+	// Object stores don't always keep the content type players need
+	if strings.HasSuffix(segment, ".ts") {
+		return stream, "video/MP2T", nil
 	}
+	defer stream.Close()
+	contentType = "application/vnd.apple.mpegurl"
 
-	if int(count) >= uc.config.MaxDevices {
-		return errors.New("device limit reached")
+	// Point key URIs at the configured API origin
+	content, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, "", err
 	}
-
-	return nil
+	playlist := ResolveKeyURIs(string(content), uc.config.KeyBaseURL)
+	return io.NopCloser(strings.NewReader(playlist)), contentType, nil
 }
 
-// GetVideoSegment returns a stream for a video segment or playlist
-func (uc *videoUseCase) GetVideoSegment(ctx context.Context, videoID uuid.UUID, segment string) (io.ReadCloser, string, error) {
-	// Construct path: "hls/<videoID>/<segment>"
-	path := fmt.Sprintf("hls/%s/%s", videoID.String(), segment)
-	return uc.storageService.GetFileStream(ctx, path)
+// generateWatermarkCode returns a short code that is easy to read off a
+// screen recording
+func generateWatermarkCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
 }
 
 // Helper to generate signed token
@@ -677,8 +628,9 @@ func (uc *videoUseCase) DeleteVideo(ctx context.Context, lessonID uuid.UUID) err
 	}
 
 	// Delete HLS folder
-	hlsPrefix := fmt.Sprintf("videos/hls/%s", asset.ID.String())
-	_ = uc.storageService.DeleteFolder(ctx, hlsPrefix)
+	_ = uc.storageService.DeleteFolder(ctx, hlsPrefix(asset.ID))
+
+	_ = uc.videoRepo.DeleteJob(ctx, asset.ID)
 
 	// Delete from DB (repository handles cascading if configured, but let's be explicit if needed)
 	// Actually GORM Delete handles the record.
@@ -0,0 +1,295 @@
+package video_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/video"
+)
+
+// MockVideoRepository is a mock implementation of VideoRepository
+type MockVideoRepository struct {
+	mock.Mock
+}
+
+func (m *MockVideoRepository) CreateAsset(ctx context.Context, asset *domain.HLSVideoAsset) error {
+	return m.Called(ctx, asset).Error(0)
+}
+
+func (m *MockVideoRepository) GetAssetByID(ctx context.Context, id uuid.UUID) (*domain.HLSVideoAsset, error) {
+	args := m.Called(ctx, id)
+	asset, _ := args.Get(0).(*domain.HLSVideoAsset)
+	return asset, args.Error(1)
+}
+
+func (m *MockVideoRepository) GetAssetByLessonID(ctx context.Context, lessonID uuid.UUID) (*domain.HLSVideoAsset, error) {
+	args := m.Called(ctx, lessonID)
+	asset, _ := args.Get(0).(*domain.HLSVideoAsset)
+	return asset, args.Error(1)
+}
+
+func (m *MockVideoRepository) UpdateAsset(ctx context.Context, asset *domain.HLSVideoAsset) error {
+	return m.Called(ctx, asset).Error(0)
+}
+
+func (m *MockVideoRepository) DeleteAsset(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockVideoRepository) EnqueueJob(ctx context.Context, videoID uuid.UUID) error {
+	return m.Called(ctx, videoID).Error(0)
+}
+
+func (m *MockVideoRepository) ClaimJob(ctx context.Context, staleBefore time.Time) (*domain.VideoJob, error) {
+	args := m.Called(ctx, staleBefore)
+	job, _ := args.Get(0).(*domain.VideoJob)
+	return job, args.Error(1)
+}
+
+func (m *MockVideoRepository) GetJobByVideoID(ctx context.Context, videoID uuid.UUID) (*domain.VideoJob, error) {
+	args := m.Called(ctx, videoID)
+	job, _ := args.Get(0).(*domain.VideoJob)
+	return job, args.Error(1)
+}
+
+func (m *MockVideoRepository) UpdateJob(ctx context.Context, job *domain.VideoJob) error {
+	return m.Called(ctx, job).Error(0)
+}
+
+func (m *MockVideoRepository) TouchJob(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockVideoRepository) DeleteJob(ctx context.Context, videoID uuid.UUID) error {
+	return m.Called(ctx, videoID).Error(0)
+}
+
+func (m *MockVideoRepository) EnqueueOrphanedAssets(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockVideoRepository) CreateQuality(ctx context.Context, quality *domain.VideoQuality) error {
+	return m.Called(ctx, quality).Error(0)
+}
+
+func (m *MockVideoRepository) GetQualitiesByVideoID(ctx context.Context, videoID uuid.UUID) ([]domain.VideoQuality, error) {
+	args := m.Called(ctx, videoID)
+	return args.Get(0).([]domain.VideoQuality), args.Error(1)
+}
+
+func (m *MockVideoRepository) CreateEncryption(ctx context.Context, encryption *domain.VideoEncryption) error {
+	return m.Called(ctx, encryption).Error(0)
+}
+
+func (m *MockVideoRepository) GetEncryptionByVideoID(ctx context.Context, videoID uuid.UUID) (*domain.VideoEncryption, error) {
+	args := m.Called(ctx, videoID)
+	encryption, _ := args.Get(0).(*domain.VideoEncryption)
+	return encryption, args.Error(1)
+}
+
+func (m *MockVideoRepository) UpdateEncryption(ctx context.Context, encryption *domain.VideoEncryption) error {
+	return m.Called(ctx, encryption).Error(0)
+}
+
+func (m *MockVideoRepository) CreateSignedURL(ctx context.Context, signedURL *domain.SignedURL) error {
+	return m.Called(ctx, signedURL).Error(0)
+}
+
+func (m *MockVideoRepository) GetSignedURLByToken(ctx context.Context, token string) (*domain.SignedURL, error) {
+	args := m.Called(ctx, token)
+	signedURL, _ := args.Get(0).(*domain.SignedURL)
+	return signedURL, args.Error(1)
+}
+
+func (m *MockVideoRepository) GetSignedURLByWatermark(ctx context.Context, code string) (*domain.SignedURL, error) {
+	args := m.Called(ctx, code)
+	signedURL, _ := args.Get(0).(*domain.SignedURL)
+	return signedURL, args.Error(1)
+}
+
+func (m *MockVideoRepository) RecordKeyFetch(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockVideoRepository) ExpireDeviceSignedURLs(ctx context.Context, userID uuid.UUID, deviceID string) error {
+	return m.Called(ctx, userID, deviceID).Error(0)
+}
+
+func (m *MockVideoRepository) DeleteExpiredSignedURLs(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	args := m.Called(ctx, expiredBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockVideoRepository) CreateDeviceSession(ctx context.Context, session *domain.DeviceSession) error {
+	return m.Called(ctx, session).Error(0)
+}
+
+func (m *MockVideoRepository) GetDeviceSession(ctx context.Context, userID uuid.UUID, deviceID string) (*domain.DeviceSession, error) {
+	args := m.Called(ctx, userID, deviceID)
+	session, _ := args.Get(0).(*domain.DeviceSession)
+	return session, args.Error(1)
+}
+
+func (m *MockVideoRepository) GetUserDeviceSessions(ctx context.Context, userID uuid.UUID) ([]domain.DeviceSession, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.DeviceSession), args.Error(1)
+}
+
+func (m *MockVideoRepository) CountActiveDevices(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockVideoRepository) UpdateDeviceSession(ctx context.Context, session *domain.DeviceSession) error {
+	return m.Called(ctx, session).Error(0)
+}
+
+func (m *MockVideoRepository) DeactivateDeviceSession(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockVideoRepository) DeactivateStaleDeviceSessions(ctx context.Context, olderThan time.Time) (int64, error) {
+	args := m.Called(ctx, olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func newKeySession(t *testing.T, expiresAt time.Time) (*MockVideoRepository, *domain.SignedURL, []byte) {
+	t.Helper()
+
+	key, iv, err := domain.GenerateEncryptionKey()
+	assert.NoError(t, err)
+	keyBytes, _ := hex.DecodeString(key)
+
+	signedURL := &domain.SignedURL{
+		ID:        uuid.New(),
+		VideoID:   uuid.New(),
+		UserID:    uuid.New(),
+		Token:     "session-token",
+		ExpiresAt: expiresAt,
+	}
+
+	mockRepo := new(MockVideoRepository)
+	mockRepo.On("GetSignedURLByToken", mock.Anything, signedURL.Token).Return(signedURL, nil)
+	mockRepo.On("GetEncryptionByVideoID", mock.Anything, signedURL.VideoID).Return(&domain.VideoEncryption{
+		VideoID:       signedURL.VideoID,
+		EncryptionKey: key,
+		IV:            iv,
+	}, nil)
+	return mockRepo, signedURL, keyBytes
+}
+
+func TestVideoUseCase_GetEncryptionKey_RepeatedFetches(t *testing.T) {
+	mockRepo, signedURL, want := newKeySession(t, time.Now().Add(time.Hour))
+	mockRepo.On("RecordKeyFetch", mock.Anything, signedURL.ID).Return(nil)
+	uc := video.NewUseCase(mockRepo, nil, nil, nil, nil, "secret", config.VideoConfig{})
+
+	// A player fetches the key on start, on seek and after a reload
+	for i := 0; i < 5; i++ {
+		key, err := uc.GetEncryptionKey(context.Background(), signedURL.VideoID, signedURL.Token)
+		assert.NoError(t, err, "fetch %d", i+1)
+		assert.Equal(t, want, key)
+	}
+
+	mockRepo.AssertNumberOfCalls(t, "RecordKeyFetch", 5)
+}
+
+func TestVideoUseCase_GetEncryptionKey_ExpiredSession(t *testing.T) {
+	mockRepo, signedURL, _ := newKeySession(t, time.Now().Add(-time.Minute))
+	uc := video.NewUseCase(mockRepo, nil, nil, nil, nil, "secret", config.VideoConfig{})
+
+	_, err := uc.GetEncryptionKey(context.Background(), signedURL.VideoID, signedURL.Token)
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "RecordKeyFetch", mock.Anything, mock.Anything)
+}
+
+func TestVideoUseCase_GetEncryptionKey_OtherVideo(t *testing.T) {
+	mockRepo, signedURL, _ := newKeySession(t, time.Now().Add(time.Hour))
+	uc := video.NewUseCase(mockRepo, nil, nil, nil, nil, "secret", config.VideoConfig{})
+
+	_, err := uc.GetEncryptionKey(context.Background(), uuid.New(), signedURL.Token)
+	assert.Error(t, err, "a session token only unlocks its own video")
+	mockRepo.AssertNotCalled(t, "GetEncryptionByVideoID", mock.Anything, mock.Anything)
+}
+
+func TestVideoUseCase_TraceWatermark(t *testing.T) {
+	mockRepo := new(MockVideoRepository)
+	uc := video.NewUseCase(mockRepo, nil, nil, nil, nil, "secret", config.VideoConfig{})
+
+	viewer := &domain.SignedURL{UserID: uuid.New(), WatermarkCode: "MFRGGZDF"}
+	mockRepo.On("GetSignedURLByWatermark", mock.Anything, "MFRGGZDF").Return(viewer, nil)
+
+	traced, err := uc.TraceWatermark(context.Background(), " mfrggzdf ")
+	assert.NoError(t, err, "codes read off a recording are normalized")
+	assert.Equal(t, viewer.UserID, traced.UserID)
+}
+
+// fakeStorage holds the files at the URLs in files
+type fakeStorage struct {
+	domain.StorageService
+	files map[string]bool
+}
+
+func (f *fakeStorage) FileExists(url string) bool {
+	return f.files[url]
+}
+
+func (f *fakeStorage) PathFromURL(url string) (string, bool) {
+	path, ok := strings.CutPrefix(url, "/uploads/")
+	return path, ok && path != ""
+}
+
+func TestVideoUseCase_UploadVideo(t *testing.T) {
+	lessonID := uuid.New()
+	userID := uuid.New()
+	own := "/uploads/" + domain.VideoUploadFolder(userID) + "/a.mp4"
+	others := "/uploads/" + domain.VideoUploadFolder(uuid.New()) + "/b.mp4"
+	// Names the other user's file from inside this user's folder
+	escape := "/uploads/" + domain.VideoUploadFolder(userID) + "/../" + path.Base(path.Dir(others)) + "/b.mp4"
+	files := &fakeStorage{files: map[string]bool{own: true, others: true, escape: true, "/uploads/videos/originals/c.mp4": true}}
+
+	t.Run("queues a file in storage", func(t *testing.T) {
+		mockRepo := new(MockVideoRepository)
+		mockRepo.On("GetAssetByLessonID", mock.Anything, lessonID).Return(nil, errors.New("record not found"))
+		mockRepo.On("CreateAsset", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("EnqueueJob", mock.Anything, mock.Anything).Return(nil)
+		uc := video.NewUseCase(mockRepo, nil, nil, nil, files, "secret", config.VideoConfig{})
+
+		asset, err := uc.UploadVideo(context.Background(), lessonID, userID, own)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.VideoStatusPending, asset.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("refuses files that aren't in storage", func(t *testing.T) {
+		mockRepo := new(MockVideoRepository)
+		uc := video.NewUseCase(mockRepo, nil, nil, nil, files, "secret", config.VideoConfig{})
+
+		for _, url := range []string{"/uploads/" + domain.VideoUploadFolder(userID) + "/missing.mp4", "https://example.com/a.mp4"} {
+			_, err := uc.UploadVideo(context.Background(), lessonID, userID, url)
+			assert.ErrorIs(t, err, domain.ErrFileNotFound, url)
+		}
+		mockRepo.AssertNotCalled(t, "CreateAsset", mock.Anything, mock.Anything)
+	})
+
+	t.Run("refuses files the user didn't upload", func(t *testing.T) {
+		mockRepo := new(MockVideoRepository)
+		uc := video.NewUseCase(mockRepo, nil, nil, nil, files, "secret", config.VideoConfig{})
+
+		for _, url := range []string{others, "/uploads/videos/originals/c.mp4", escape} {
+			_, err := uc.UploadVideo(context.Background(), lessonID, userID, url)
+			assert.ErrorIs(t, err, domain.ErrFileNotFound, url)
+		}
+		mockRepo.AssertNotCalled(t, "CreateAsset", mock.Anything, mock.Anything)
+	})
+}
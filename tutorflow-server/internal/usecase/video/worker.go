@@ -0,0 +1,191 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/metrics"
+	"github.com/tutorflow/tutorflow-server/internal/repository"
+)
+
+// maxRetryDelay caps the exponential backoff between attempts
+const maxRetryDelay = time.Hour
+
+// Worker runs persisted transcoding jobs. A semaphore caps how many ffmpeg
+// processes run at once; jobs beyond that wait in the table.
+type Worker struct {
+	videoRepo repository.VideoRepository
+	videoUC   domain.VideoUseCase
+	cfg       config.VideoConfig
+	slots     chan struct{}
+	running   sync.WaitGroup
+}
+
+// NewWorker creates a transcoding worker
+func NewWorker(videoRepo repository.VideoRepository, videoUC domain.VideoUseCase, cfg config.VideoConfig) *Worker {
+	if cfg.MaxConcurrentJobs <= 0 {
+		cfg.MaxConcurrentJobs = 1
+	}
+	if cfg.JobMaxAttempts <= 0 {
+		cfg.JobMaxAttempts = 1
+	}
+	if cfg.JobPollInterval <= 0 {
+		cfg.JobPollInterval = 5 * time.Second
+	}
+	if cfg.JobStaleAfter <= 0 {
+		cfg.JobStaleAfter = 5 * time.Minute
+	}
+
+	return &Worker{
+		videoRepo: videoRepo,
+		videoUC:   videoUC,
+		cfg:       cfg,
+		slots:     make(chan struct{}, cfg.MaxConcurrentJobs),
+	}
+}
+
+// Start polls for due jobs until ctx is cancelled. Cancelling ctx also stops
+// running ffmpeg processes; their jobs are requeued without using an attempt,
+// and Start returns once every one of them has been.
+func (w *Worker) Start(ctx context.Context) {
+	if n, err := w.videoRepo.EnqueueOrphanedAssets(ctx); err != nil {
+		fmt.Printf("VideoWorker: failed to enqueue orphaned assets: %v\n", err)
+	} else if n > 0 {
+		fmt.Printf("VideoWorker: queued %d unfinished videos\n", n)
+	}
+
+	ticker := time.NewTicker(w.cfg.JobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.running.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch claims due jobs while there are free slots
+func (w *Worker) dispatch(ctx context.Context) {
+	for {
+		select {
+		case w.slots <- struct{}{}:
+		default:
+			return
+		}
+
+		job, err := w.videoRepo.ClaimJob(ctx, time.Now().Add(-w.cfg.JobStaleAfter))
+		if err != nil || job == nil {
+			<-w.slots
+			if err != nil && ctx.Err() == nil {
+				fmt.Printf("VideoWorker: failed to claim job: %v\n", err)
+			}
+			return
+		}
+
+		w.running.Go(func() {
+			defer func() { <-w.slots }()
+			w.run(ctx, job)
+		})
+	}
+}
+
+func (w *Worker) run(ctx context.Context, job *domain.VideoJob) {
+	done := make(chan struct{})
+	go w.heartbeat(ctx, job, done)
+
+	metrics.VideosProcessing.Inc()
+	err := w.videoUC.ProcessVideo(ctx, job.VideoID)
+	metrics.VideosProcessing.Dec()
+	close(done)
+
+	// ctx is gone on shutdown; record the outcome regardless
+	saveCtx := context.Background()
+	now := time.Now()
+
+	var outcome string
+	switch {
+	case err == nil:
+		outcome = "completed"
+		job.Status = domain.VideoJobCompleted
+		job.CompletedAt = &now
+		job.LastError = ""
+	case ctx.Err() != nil:
+		// Interrupted by shutdown rather than a bad video
+		outcome = "interrupted"
+		job.Status = domain.VideoJobPending
+		job.Attempts--
+		job.RunAfter = now
+		w.resetAsset(saveCtx, job)
+		fmt.Printf("VideoWorker: requeued job %s interrupted by shutdown\n", job.ID)
+	case job.Attempts >= w.cfg.JobMaxAttempts:
+		outcome = "failed"
+		job.Status = domain.VideoJobFailed
+		job.LastError = err.Error()
+	default:
+		outcome = "retrying"
+		job.Status = domain.VideoJobPending
+		job.LastError = err.Error()
+		job.RunAfter = now.Add(RetryDelay(w.cfg.JobRetryBackoff, job.Attempts))
+		w.resetAsset(saveCtx, job)
+	}
+	job.HeartbeatAt = nil
+	metrics.VideoJobsFinished.WithLabelValues(outcome).Inc()
+
+	if err := w.videoRepo.UpdateJob(saveCtx, job); err != nil {
+		fmt.Printf("VideoWorker: failed to update job %s: %v\n", job.ID, err)
+	}
+}
+
+// heartbeat keeps a running job from being treated as stale until done closes
+func (w *Worker) heartbeat(ctx context.Context, job *domain.VideoJob, done <-chan struct{}) {
+	ticker := time.NewTicker(w.cfg.JobStaleAfter / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = w.videoRepo.TouchJob(ctx, job.ID)
+		}
+	}
+}
+
+// resetAsset shows a video awaiting retry as pending instead of failed,
+// keeping the last error for the instructor to see
+func (w *Worker) resetAsset(ctx context.Context, job *domain.VideoJob) {
+	asset, err := w.videoRepo.GetAssetByID(ctx, job.VideoID)
+	if err != nil {
+		return
+	}
+	asset.Status = domain.VideoStatusPending
+	asset.Progress = 0
+	_ = w.videoRepo.UpdateAsset(ctx, asset)
+}
+
+// RetryDelay returns how long to wait before retrying after the given attempt.
+// The delay starts at base and doubles each attempt, up to an hour.
+func RetryDelay(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return min(delay, maxRetryDelay)
+}
@@ -0,0 +1,74 @@
+package video_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tutorflow/tutorflow-server/internal/domain"
+	"github.com/tutorflow/tutorflow-server/internal/pkg/config"
+	"github.com/tutorflow/tutorflow-server/internal/usecase/video"
+)
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, video.RetryDelay(time.Minute, 1))
+	assert.Equal(t, 2*time.Minute, video.RetryDelay(time.Minute, 2))
+	assert.Equal(t, 4*time.Minute, video.RetryDelay(time.Minute, 3))
+	assert.Equal(t, time.Hour, video.RetryDelay(time.Minute, 20), "backoff is capped")
+	assert.Equal(t, time.Duration(0), video.RetryDelay(0, 3))
+}
+
+// blockingVideoUC transcodes until its context is cancelled
+type blockingVideoUC struct {
+	domain.VideoUseCase
+	started chan struct{}
+}
+
+func (uc *blockingVideoUC) ProcessVideo(ctx context.Context, videoID uuid.UUID) error {
+	close(uc.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestWorker_Shutdown(t *testing.T) {
+	job := &domain.VideoJob{ID: uuid.New(), VideoID: uuid.New(), Status: domain.VideoJobRunning, Attempts: 2}
+	asset := &domain.HLSVideoAsset{ID: job.VideoID, Status: domain.VideoStatusProcessing, Progress: 40}
+
+	repo := new(MockVideoRepository)
+	repo.On("EnqueueOrphanedAssets", mock.Anything).Return(int64(0), nil)
+	repo.On("ClaimJob", mock.Anything, mock.Anything).Return(job, nil).Once()
+	repo.On("ClaimJob", mock.Anything, mock.Anything).Return(nil, nil)
+	repo.On("GetAssetByID", mock.Anything, job.VideoID).Return(asset, nil)
+	repo.On("UpdateAsset", mock.Anything, asset).Return(nil)
+	repo.On("UpdateJob", mock.Anything, job).Return(nil)
+
+	uc := &blockingVideoUC{started: make(chan struct{})}
+	worker := video.NewWorker(repo, uc, config.VideoConfig{JobPollInterval: time.Hour, JobStaleAfter: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		worker.Start(ctx)
+		close(stopped)
+	}()
+
+	<-uc.started
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not stop")
+	}
+
+	// By the time Start returns the job is queued to run again, as if it
+	// never started
+	repo.AssertCalled(t, "UpdateJob", mock.Anything, job)
+	assert.Equal(t, domain.VideoJobPending, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Nil(t, job.HeartbeatAt)
+	assert.Equal(t, domain.VideoStatusPending, asset.Status)
+	assert.Zero(t, asset.Progress)
+}